@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// activityMaxWeeks is the longest period whose activity is shown per week; longer ones are
+// shown per month.
+const activityMaxWeeks = 27
+
+// heatCells are the cells of the activity heatmap, from no commits to the most commits.
+var heatCells = []rune("·░▒▓█")
+
+var (
+	activitySince  string
+	activityAuthor string
+	activityFilter filter.Filter
+)
+
+// repoActivity is what the activity command found in a repository.
+type repoActivity struct {
+	entry   state.RepositoryEntry
+	commits []time.Time // Newest first
+	err     error
+	noEmail bool // No --author and no user.email to recognize the user's commits by
+}
+
+// activityCmd represents the activity command
+var activityCmd = &cobra.Command{
+	Use:   "activity",
+	Short: "Shows which repositories you commit to, as a heatmap.",
+	Long: `Counts your commits in every tracked repository since --since (6 months by default) and
+shows them as a heatmap, one row per repository and one column per week (or per month for
+periods longer than 6 months), the most active repositories first:
+
+  NAME   COMMITS  LAST COMMIT  ACTIVITY                    PATH
+  api    42       2026-10-14   ···░░▒░▒▓█▓▒░·░▒▒▓▓█▓▒▒░░▒░  /home/me/git/github.com/acme/api
+  cobra  3        2026-06-02   ·········░·░░··············  /home/me/git/github.com/spf13/cobra
+
+Darker cells mean more commits, relative to the busiest week (or month) of all repositories. The
+repositories you haven't committed to in the period are listed below the heatmap; they are
+candidates for archiving (see 'fussy-git cleanup-advisor').
+
+Your commits are the ones whose author matches the user.email git uses in each repository,
+so identities configured per directory (includeIf) are recognized. --author overrides it with
+a pattern matched against the author's name and email, like 'git log --author'. Commits on
+all branches, remote-tracking branches and tags are counted, as of the last fetch; nothing is
+fetched.
+
+--since takes a number of days, weeks, months or years (e.g. 30d, 2w, 6m, 1y) or a date
+(e.g. 2026-01-01). Use --domain, --owner, --tag and --path-prefix to only include a subset
+of repositories.
+
+Examples:
+  fussy-git activity
+  fussy-git activity --since 1y --owner acme
+  fussy-git activity --author 'jane@' --since 30d`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		now := time.Now()
+		since, err := parseSince(activitySince, now)
+		if err != nil {
+			return usageError("invalid --since '%s': %v", activitySince, err)
+		}
+		if activityAuthor != "" {
+			if _, err := regexp.Compile(activityAuthor); err != nil {
+				return usageError("invalid --author pattern: %v", err)
+			}
+		}
+
+		repos := activityFilter.Apply(repoState.Repositories)
+		if len(repos) == 0 {
+			fmt.Println("No repositories to inspect.")
+			return nil
+		}
+		results := collectActivity(repos, since)
+
+		monthly := now.Sub(since) > activityMaxWeeks*7*24*time.Hour
+		var active, idle []repoActivity
+		var failures []string
+		noEmail, total, busiest := 0, 0, 0
+		for _, r := range results {
+			switch {
+			case r.err != nil:
+				failures = append(failures, fmt.Sprintf("%s: %v", r.entry.Path, r.err))
+			case r.noEmail:
+				noEmail++
+			case len(r.commits) == 0:
+				idle = append(idle, r)
+			default:
+				active = append(active, r)
+				total += len(r.commits)
+				for _, n := range activityBuckets(r.commits, since, now, monthly) {
+					busiest = max(busiest, n)
+				}
+			}
+		}
+		sort.SliceStable(active, func(i, j int) bool {
+			if len(active[i].commits) != len(active[j].commits) {
+				return len(active[i].commits) > len(active[j].commits)
+			}
+			return active[i].commits[0].After(active[j].commits[0])
+		})
+
+		period := "week"
+		if monthly {
+			period = "month"
+		}
+		if len(active) == 0 {
+			fmt.Printf("No commits by you since %s.\n", since.Format(time.DateOnly))
+		} else {
+			fmt.Printf("Your commits since %s, one column per %s (oldest first):\n\n", since.Format(time.DateOnly), period)
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tCOMMITS\tLAST COMMIT\tACTIVITY\tPATH")
+			fmt.Fprintln(w, "----\t-------\t-----------\t--------\t----")
+			for _, r := range active {
+				fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", r.entry.Name, len(r.commits), r.commits[0].Format(time.DateOnly),
+					heatmapRow(activityBuckets(r.commits, since, now, monthly), busiest), r.entry.Path)
+			}
+			w.Flush()
+		}
+
+		if len(idle) > 0 {
+			fmt.Printf("\nNo commits by you since %s (candidates for archiving):\n", since.Format(time.DateOnly))
+			for _, r := range idle {
+				fmt.Printf("  %s\n", r.entry.Path)
+			}
+		}
+
+		fmt.Printf("\nActivity summary:\n")
+		fmt.Printf("  Repositories:    %d\n", len(repos))
+		fmt.Printf("  Committed to:    %d\n", len(active))
+		fmt.Printf("  Without commits: %d\n", len(idle))
+		fmt.Printf("  Commits:         %d\n", total)
+		if noEmail > 0 {
+			fmt.Printf("[WARN] %d repositories have no user.email configured to recognize your commits by; use --author.\n", noEmail)
+		}
+		for _, failure := range failures {
+			fmt.Printf("[FAIL] %s\n", failure)
+		}
+		return nil
+	},
+}
+
+// collectActivity reads the user's commits since since in the repositories, several at a
+// time, and returns them in the order of repos.
+func collectActivity(repos []state.RepositoryEntry, since time.Time) []repoActivity {
+	results := make([]repoActivity, len(repos))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < inspectWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = repositoryActivity(repos[idx], since)
+			}
+		}()
+	}
+	for i := range repos {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// repositoryActivity reads the user's commits since since in a repository.
+func repositoryActivity(repo state.RepositoryEntry, since time.Time) repoActivity {
+	r := repoActivity{entry: repo}
+	if !gitutil.IsGitRepository(repo.Path) {
+		r.err = fmt.Errorf("not a Git repository")
+		return r
+	}
+	author := activityAuthor
+	if author == "" {
+		email := gitutil.ConfigValue(repo.Path, "user.email")
+		if email == "" {
+			r.noEmail = true
+			return r
+		}
+		author = regexp.QuoteMeta(email)
+	}
+	r.commits, r.err = gitutil.AuthorCommitTimes(repo.Path, author, since)
+	return r
+}
+
+// activityBuckets counts commits per week (or per month if monthly) from since to now.
+func activityBuckets(commits []time.Time, since, now time.Time, monthly bool) []int {
+	index := func(t time.Time) int {
+		if monthly {
+			return (t.Year()-since.Year())*12 + int(t.Month()) - int(since.Month())
+		}
+		return int(t.Sub(since) / (7 * 24 * time.Hour))
+	}
+	buckets := make([]int, index(now)+1)
+	for _, t := range commits {
+		if i := index(t); i >= 0 && i < len(buckets) {
+			buckets[i]++
+		}
+	}
+	return buckets
+}
+
+// heatmapRow renders commit counts as heatmap cells, scaled to busiest, the highest count of
+// all rows, so rows can be compared. Cells without commits are always the lightest.
+func heatmapRow(buckets []int, busiest int) string {
+	var b strings.Builder
+	for _, n := range buckets {
+		level := 0
+		if n > 0 {
+			level = 1 + (n*(len(heatCells)-1)-1)/busiest
+		}
+		b.WriteRune(heatCells[level])
+	}
+	return b.String()
+}
+
+// parseSince parses a point in time given as a date (2006-01-02) or as a period before now:
+// a number of days, weeks, months or years, e.g. "30d", "2w", "6m" or "1y".
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return date, nil
+	}
+	if len(value) < 2 {
+		return time.Time{}, fmt.Errorf("must be a date or a period like 30d, 2w, 6m or 1y")
+	}
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n <= 0 {
+		return time.Time{}, fmt.Errorf("must be a date or a period like 30d, 2w, 6m or 1y")
+	}
+	switch value[len(value)-1] {
+	case 'd':
+		return now.AddDate(0, 0, -n), nil
+	case 'w':
+		return now.AddDate(0, 0, -7*n), nil
+	case 'm':
+		return now.AddDate(0, -n, 0), nil
+	case 'y':
+		return now.AddDate(-n, 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("must be a date or a period like 30d, 2w, 6m or 1y")
+}
+
+func init() {
+	activityCmd.Flags().StringVar(&activitySince, "since", "6m", "Period to show: a number of days, weeks, months or years (e.g. 30d, 2w, 6m, 1y) before now, or a date")
+	activityCmd.Flags().StringVar(&activityAuthor, "author", "", "Count the commits whose author matches this pattern instead of each repository's user.email")
+	addFilterFlags(activityCmd, &activityFilter)
+}
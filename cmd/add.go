@@ -2,8 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"github.com/jmsnll/fussy-git/internal/gitutil"
-	"github.com/jmsnll/fussy-git/internal/state"
 	"path/filepath"
 	"strings"
 
@@ -22,11 +20,18 @@ The command will:
 2. Fetch its remote 'origin' URL.
 3. Parse the URL to determine its components.
 4. Add the repository information to fussy-git's state file.
+5. Install the configured git hooks, if any (see 'fussy-git help install-hooks').
+6. Register it for git's background maintenance, if 'git_maintenance' is enabled.
+
+If a tracked repository with the same 'origin' URL is missing from its recorded path, the
+repository is taken to have been moved by hand and that entry is updated to the new path
+instead of tracking the repository twice.
 
 If the repository is not located in the path fussy-git would conventionally use
 (i.e., $FUSSY_GIT_HOME/<domain>/<user_or_org>/<project_name>), a warning will be displayed.
 The 'reorganize' command (not yet implemented) could later move such repositories.`,
-	Args: cobra.ExactArgs(1), // Requires exactly one argument: the path to the repository
+	Annotations: mutates,
+	Args:        cobra.ExactArgs(1), // Requires exactly one argument: the path to the repository
 	RunE: func(cmd *cobra.Command, args []string) error {
 		repoPathArg := args[0]
 
@@ -43,44 +48,32 @@ The 'reorganize' command (not yet implemented) could later move such repositorie
 			fmt.Printf("Absolute path to repository: %s\n", absRepoPath)
 		}
 
-		// 2. Verify it's a Git repository
-		if !gitutil.IsGitRepository(absRepoPath) {
-			return fmt.Errorf("path '%s' is not a valid Git repository", absRepoPath)
-		}
-		if verbose {
-			fmt.Printf("Path '%s' confirmed as a Git repository.\n", absRepoPath)
-		}
-
 		// Check if already tracked
 		if existingEntry, found := repoState.FindRepositoryByPath(absRepoPath); found {
 			fmt.Printf("Repository at '%s' is already managed by fussy-git (Name: %s, URL: %s).\n", absRepoPath, existingEntry.Name, existingEntry.CurrentURL)
 			return nil // Already tracked, nothing to do.
 		}
 
-		// 3. Fetch its remote origin URL
-		originURL, err := gitutil.GetRemoteOriginURL(absRepoPath, verbose)
+		// 2-4. Verify it's a Git repository, fetch its remote origin URL and parse it
+		newEntry, parsedURL, err := inspectLocalRepository(absRepoPath)
 		if err != nil {
-			return fmt.Errorf("failed to get remote origin URL for repository at '%s': %w. Ensure 'origin' remote is set", absRepoPath, err)
-		}
-		if originURL == "" {
-			return fmt.Errorf("remote 'origin' URL is empty for repository at '%s'", absRepoPath)
-		}
-		if verbose {
-			fmt.Printf("Found remote origin URL: %s\n", originURL)
+			return err
 		}
+		originURL := newEntry.CurrentURL
 
-		// 4. Parse this URL
-		parsedURL, err := gitutil.ParseGitURL(originURL)
-		if err != nil {
-			return fmt.Errorf("failed to parse remote origin URL '%s': %w", originURL, err)
-		}
-		if verbose {
-			fmt.Printf("Parsed URL -> Domain: %s, Path: %s, User: %s, RepoName: %s\n",
-				parsedURL.Domain, parsedURL.Path, parsedURL.User, parsedURL.RepoName)
+		// A repository moved by hand is the tracked one with its URL, not a new one.
+		if idx := findMovedRepository(newEntry); idx != -1 {
+			oldPath := repoState.Repositories[idx].Path
+			recordDetectedMove(idx, absRepoPath)
+			if err := repoState.Save(appConfig.StateFilePath); err != nil {
+				return fmt.Errorf("failed to save state: %w", err)
+			}
+			fmt.Printf("Detected move of '%s' from '%s'; updated the tracked entry to '%s'.\n", newEntry.Name, oldPath, absRepoPath)
+			return nil
 		}
 
 		// 5. Determine the conventional path fussy-git would use
-		conventionalPath := parsedURL.GetLocalPath(appConfig.FussyGitHome)
+		conventionalPath := conventionalRepoPath(parsedURL)
 		if verbose {
 			fmt.Printf("Conventional fussy-git path for this repo: %s\n", conventionalPath)
 		}
@@ -97,20 +90,18 @@ The 'reorganize' command (not yet implemented) could later move such repositorie
 		}
 
 		// 6. Add the repository information to the state file
-		newEntry := state.RepositoryEntry{
-			Name:          parsedURL.RepoName,
-			Path:          absRepoPath, // Use the actual current path
-			OriginalURL:   originURL,   // The fetched origin URL is the "original" in this context
-			CurrentURL:    originURL,   // Assume current is same as origin for a newly added repo
-			Domain:        parsedURL.Domain,
-			NormalizedFS:  parsedURL.GetNormalizedFSPath(),
-			ManuallyAdded: true, // Mark as manually added
-		}
-
+		newEntry.Maintenance = startGitMaintenance(absRepoPath)
 		if err := repoState.AddRepository(newEntry); err != nil {
 			return fmt.Errorf("failed to add repository to state: %w", err)
 		}
 
+		if err := installHooks(absRepoPath); err != nil {
+			fmt.Printf("Warning: Failed to install git hooks: %v\n", err)
+		}
+		if err := applyIdentity(absRepoPath, parsedURL); err != nil {
+			fmt.Printf("Warning: Failed to apply the configured git identity: %v\n", err)
+		}
+
 		if err := repoState.Save(appConfig.StateFilePath); err != nil {
 			return fmt.Errorf("repository information for '%s' processed, but failed to save state: %w", absRepoPath, err)
 		}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/adopt"
+	"github.com/jmsnll/fussy-git/internal/config"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var dryRunAdoptConfig bool
+
+// adoptConfigCmd represents the adopt-config command
+var adoptConfigCmd = &cobra.Command{
+	Use:   "adopt-config <ghq|projectile|git-workspace> [<source>]",
+	Short: "Takes over the settings and repositories of ghq, projectile or git-workspace.",
+	Long: `Reads the configuration of another tool that manages Git repositories and translates it into
+fussy-git's config file and state, to make switching tools painless:
+
+  ghq            The first ghq root ($GHQ_ROOT, or 'ghq.root' in the git config, or ~/ghq)
+                 becomes FUSSY_GIT_HOME with the domain layout, which matches ghq's
+                 <root>/<host>/<owner>/<repo> structure, so its repositories are already in
+                 place. Every repository below the ghq roots is tracked.
+  projectile     The known projects of projectile (an Emacs package) in its bookmarks file
+                 (~/.emacs.d/projectile-bookmarks.eld) are tracked, if they are Git repositories.
+  git-workspace  The repositories of the workspace ($GIT_WORKSPACE) listed in its
+                 workspace-lock.toml are tracked. Those that aren't cloned yet are listed with
+                 the command to clone them.
+
+<source> replaces where the tool's settings are read from: a ghq root, the projectile
+bookmarks file, or the git-workspace directory.
+
+Repositories are tracked where they are, like 'fussy-git add' does; run 'fussy-git reorganize'
+afterwards to move them to their conventional locations. Repositories that are already tracked
+are skipped, and one that was moved by hand is recognized by its 'origin' URL as with
+'fussy-git import-dir'.
+
+If there is no config file yet, it is created with the translated settings. An existing config
+file is never rewritten: the settings to add to it are printed instead. Settings of the tool
+without a fussy-git equivalent (e.g. the providers of git-workspace) are listed at the end.
+
+Examples:
+  fussy-git adopt-config ghq --dry-run
+  fussy-git adopt-config projectile ~/.config/emacs/projectile-bookmarks.eld
+  fussy-git adopt-config git-workspace ~/workspace`,
+	Annotations: mutates,
+	Args:        cobra.RangeArgs(1, 2),
+	ValidArgs:   adopt.Tools,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tool := args[0]
+		if !slices.Contains(adopt.Tools, tool) {
+			return usageError("unknown tool '%s': must be one of %s", tool, strings.Join(adopt.Tools, ", "))
+		}
+		source := ""
+		if len(args) == 2 {
+			source = args[1]
+		}
+		result, err := adopt.Read(tool, source)
+		if err != nil {
+			return err
+		}
+
+		if err := adoptSettings(tool, result.Settings); err != nil {
+			return err
+		}
+
+		fmt.Printf("Repositories known to %s: %d\n", tool, len(result.Repositories))
+		registered, skipped, notCloned, movesDetected := 0, 0, 0, 0
+		for _, repo := range result.Repositories {
+			fmt.Printf("%s\n", repo.Path)
+			if !gitutil.IsGitRepository(repo.Path) {
+				if repo.URL != "" {
+					fmt.Printf("  [SKIP] Not cloned. Clone it with 'fussy-git clone %s'.\n", repo.URL)
+					notCloned++
+				} else {
+					fmt.Println("  [SKIP] Not a Git repository (or missing).")
+				}
+				skipped++
+				continue
+			}
+			if _, tracked := repoState.FindRepositoryByPath(repo.Path); tracked {
+				fmt.Println("  [SKIP] Already tracked by fussy-git.")
+				skipped++
+				continue
+			}
+			entry, _, err := inspectLocalRepository(repo.Path)
+			if err != nil {
+				fmt.Printf("  [SKIP] %v\n", err)
+				skipped++
+				continue
+			}
+
+			if idx := findMovedRepository(entry); idx != -1 {
+				oldPath := repoState.Repositories[idx].Path
+				if dryRunAdoptConfig {
+					fmt.Printf("  [MOVED] Detected move from %s; would update the tracked entry.\n", oldPath)
+				} else {
+					recordDetectedMove(idx, repo.Path)
+					fmt.Printf("  [MOVED] Detected move from %s; updated the tracked entry.\n", oldPath)
+				}
+				movesDetected++
+				continue
+			}
+
+			if dryRunAdoptConfig {
+				fmt.Printf("  Would register '%s' (%s)\n", entry.Name, entry.CurrentURL)
+			} else {
+				if err := repoState.AddRepository(entry); err != nil {
+					fmt.Printf("  [SKIP] Failed to add repository to state: %v\n", err)
+					skipped++
+					continue
+				}
+				fmt.Printf("  Registered '%s' (%s)\n", entry.Name, entry.CurrentURL)
+			}
+			registered++
+		}
+
+		if (registered > 0 || movesDetected > 0) && !dryRunAdoptConfig {
+			if err := repoState.Save(appConfig.StateFilePath); err != nil {
+				return fmt.Errorf("failed to save state after adopting %s: %w", tool, err)
+			}
+		}
+
+		if len(result.Notes) > 0 {
+			fmt.Printf("\nNot adopted:\n")
+			for _, note := range result.Notes {
+				fmt.Printf("  %s\n", note)
+			}
+		}
+
+		fmt.Printf("\nAdoption summary:\n")
+		fmt.Printf("  Repositories found:      %d\n", len(result.Repositories))
+		if dryRunAdoptConfig {
+			fmt.Printf("  Would register:          %d\n", registered)
+		} else {
+			fmt.Printf("  Repositories registered: %d\n", registered)
+		}
+		fmt.Printf("  Moves detected:          %d\n", movesDetected)
+		if notCloned > 0 {
+			fmt.Printf("  Not cloned:              %d\n", notCloned)
+		}
+		fmt.Printf("  Repositories skipped:    %d\n", skipped)
+		if registered > 0 && !dryRunAdoptConfig {
+			fmt.Println("\nRun 'fussy-git reorganize --dry-run' to see which repositories aren't in their conventional locations.")
+		}
+		return nil
+	},
+}
+
+// adoptSettings writes the settings translated from tool to a new config file, or prints them
+// for an existing one. Settings that are already in effect are left out.
+func adoptSettings(tool string, settings []adopt.Setting) error {
+	var pending []adopt.Setting
+	for _, s := range settings {
+		switch s.Key {
+		case "fussy_git_home":
+			if home, err := config.ExpandPath(s.Value); err == nil && samePath(home, appConfig.FussyGitHome) {
+				continue
+			}
+		case "layout":
+			if s.Value == appConfig.Layout {
+				continue
+			}
+		}
+		pending = append(pending, s)
+	}
+	if len(pending) == 0 {
+		if len(settings) > 0 {
+			fmt.Printf("The settings of %s are already in effect.\n\n", tool)
+		}
+		return nil
+	}
+
+	var lines strings.Builder
+	for _, s := range pending {
+		fmt.Fprintf(&lines, "%s: %s  # from %s\n", s.Key, strconv.Quote(s.Value), s.From)
+	}
+
+	switch {
+	case appConfig.ConfigFileFound:
+		fmt.Printf("%s already exists and is left as it is. Add these settings to it:\n\n%s\n", appConfig.ConfigFile, lines.String())
+	case dryRunAdoptConfig:
+		fmt.Printf("Would create %s with:\n\n%s\n", appConfig.ConfigFile, lines.String())
+	default:
+		content := fmt.Sprintf("# Adopted from %s by 'fussy-git adopt-config' on %s.\n%s", tool, time.Now().Format("2006-01-02"), lines.String())
+		if err := os.MkdirAll(filepath.Dir(appConfig.ConfigFile), 0700); err != nil {
+			return fmt.Errorf("failed to create directory for config file %s: %w", appConfig.ConfigFile, err)
+		}
+		if err := os.WriteFile(appConfig.ConfigFile, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write config file %s: %w", appConfig.ConfigFile, err)
+		}
+		fmt.Printf("Created %s with:\n\n%s\n", appConfig.ConfigFile, lines.String())
+	}
+	return nil
+}
+
+func init() {
+	adoptConfigCmd.Flags().BoolVar(&dryRunAdoptConfig, "dry-run", false, "Show the settings and repositories that would be adopted without changing anything")
+}
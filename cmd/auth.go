@@ -0,0 +1,338 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/auth"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/keyring"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// envKeyringPassphrase holds the passphrase of the encrypted keyring file on machines
+// where it can't be typed in.
+const envKeyringPassphrase = "FUSSY_GIT_KEYRING_PASSPHRASE"
+
+var (
+	providerAuth  string
+	withTokenAuth bool
+)
+
+// authCmd represents the auth command
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manages and tests API tokens and SSH access per host.",
+	Long: `Stores API tokens for git hosts and checks that tokens and SSH keys work before running bulk
+operations. Tokens are never written to config.yaml; they are stored in the OS keyring (the
+macOS Keychain, the Secret Service via libsecret's 'secret-tool' on Linux, or the Windows
+Credential Manager) when one is available, and otherwise in ~/.fussy-git/keyring.enc,
+encrypted with a passphrase. The passphrase is asked for when needed, or read from
+FUSSY_GIT_KEYRING_PASSPHRASE on headless machines. The 'keyring' setting selects the
+storage: auto (the default), system or file. ~/.fussy-git/credentials.json records which
+hosts have a token.
+
+Supported providers are github (including GitHub Enterprise Server), gitlab, gitea (also
+Forgejo and Codeberg) and bitbucket.`,
+}
+
+// authLoginCmd represents the auth login command
+var authLoginCmd = &cobra.Command{
+	Use:   "login <domain>",
+	Short: "Stores an API token for a host.",
+	Long: `Stores an API token for the host <domain>, replacing any token stored before. The token is
+read from a prompt, or from stdin with --with-token. The provider is guessed from the domain
+(github.com, gitlab.*, ...); pass --provider for hosts whose name doesn't give it away.
+
+Run 'fussy-git auth test <domain>' afterwards to check the token.
+
+Examples:
+  fussy-git auth login github.com
+  fussy-git auth login git.example.com --provider gitlab
+  echo "$GITLAB_TOKEN" | fussy-git auth login gitlab.com --with-token`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domain := strings.ToLower(args[0])
+		provider := providerAuth
+		if provider == "" {
+			if provider = auth.DetectProvider(domain); provider == "" {
+				return fmt.Errorf("can't tell which provider %s is; pass --provider (%s)", domain, strings.Join(auth.Providers, ", "))
+			}
+		} else if !slices.Contains(auth.Providers, provider) {
+			return fmt.Errorf("unknown provider '%s' (supported: %s)", provider, strings.Join(auth.Providers, ", "))
+		}
+
+		token, err := readToken(fmt.Sprintf("Token for %s (%s): ", domain, provider))
+		if err != nil {
+			return err
+		}
+		if token == "" {
+			return fmt.Errorf("no token given")
+		}
+
+		store, err := openCredentials()
+		if err != nil {
+			return err
+		}
+		storage, err := store.Set(domain, provider, token)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Token for %s stored in the %s.\n", domain, storage)
+		return nil
+	},
+}
+
+// authLogoutCmd represents the auth logout command
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout <domain>",
+	Short: "Removes the API token stored for a host.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openCredentials()
+		if err != nil {
+			return err
+		}
+		if err := store.Delete(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Token for %s removed.\n", args[0])
+		return nil
+	},
+}
+
+// authStatusCmd represents the auth status command
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Lists the hosts that have an API token.",
+	Long: `Lists the hosts that have an API token, their provider, where the token is stored and a
+redacted form of it. Tokens aren't checked; use 'fussy-git auth test' for that.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openCredentials()
+		if err != nil {
+			return err
+		}
+		domains := store.Domains()
+		if len(domains) == 0 {
+			fmt.Println("No tokens stored. Add one with 'fussy-git auth login <domain>'.")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DOMAIN\tPROVIDER\tSTORAGE\tTOKEN\tUPDATED")
+		fmt.Fprintln(w, "------\t--------\t-------\t-----\t-------")
+		for _, domain := range domains {
+			c, _ := store.Lookup(domain)
+			token, err := store.Token(domain)
+			display := auth.Redact(token)
+			if err != nil {
+				display = "(unreadable)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", domain, c.Provider, c.Storage, display, c.UpdatedAt.Format("2006-01-02"))
+		}
+		return w.Flush()
+	},
+}
+
+// authTestCmd represents the auth test command
+var authTestCmd = &cobra.Command{
+	Use:   "test [domain...]",
+	Short: "Checks API tokens and SSH access per host.",
+	Long: `Checks, per host, that the stored API token is accepted and has the scopes fussy-git needs
+(repo on GitHub, api or read_api on GitLab, repository on Bitbucket), and that your SSH key is
+accepted, the way 'ssh -T git@<domain>' does. Run it before bulk operations such as
+'fussy-git clone --file' or 'fussy-git outdated' to catch expired tokens and missing keys early.
+
+Without arguments, every host with a stored token and every host of a tracked repository is
+checked. SSH is checked for hosts given as arguments, private hosts, and hosts with
+repositories cloned over SSH; the host's ssh_command setting is used if configured.
+Tokens of hosts listed in 'private_hosts' are not sent to their API.
+
+The command exits with status 2 if any check fails.
+
+Examples:
+  fussy-git auth test
+  fussy-git auth test github.com gitlab.example.com`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openCredentials()
+		if err != nil {
+			return err
+		}
+
+		sshDomains := map[string]bool{}
+		for _, repo := range repoState.Repositories {
+			if parsed, err := parseRepoURL(repo.CurrentURL); err == nil && parsed.IsSSH {
+				sshDomains[strings.ToLower(parsed.Domain)] = true
+			}
+		}
+		domains := args
+		if len(domains) == 0 {
+			seen := map[string]bool{}
+			for _, domain := range store.Domains() {
+				seen[domain] = true
+			}
+			for _, repo := range repoState.Repositories {
+				if repo.Domain != "" {
+					seen[strings.ToLower(repo.Domain)] = true
+				}
+			}
+			for domain := range seen {
+				domains = append(domains, domain)
+			}
+			sort.Strings(domains)
+		}
+		if len(domains) == 0 {
+			fmt.Println("Nothing to test: no tokens stored and no repositories tracked.")
+			return nil
+		}
+
+		passed, failed, skipped := 0, 0, 0
+		result := func(ok bool, format string, a ...any) {
+			if ok {
+				passed++
+				fmt.Printf("  [OK] "+format+"\n", a...)
+			} else {
+				failed++
+				fmt.Printf("  [FAIL] "+format+"\n", a...)
+			}
+		}
+		skip := func(format string, a ...any) {
+			skipped++
+			fmt.Printf("  [SKIP] "+format+"\n", a...)
+		}
+		for _, domain := range domains {
+			domain = strings.ToLower(domain)
+			fmt.Printf("%s:\n", domain)
+			private := isPrivateHost(domain)
+
+			if c, ok := store.Lookup(domain); !ok {
+				skip("Token: none stored")
+			} else if private {
+				skip("Token: not checked, %s is a private host", domain)
+			} else if token, err := store.Token(domain); err != nil {
+				result(false, "Token: %v", err)
+			} else if info, err := auth.CheckToken(domain, c.Provider, token); err != nil {
+				result(false, "Token: %v", err)
+			} else {
+				scopes := "not reported"
+				if info.Scopes != nil {
+					scopes = strings.Join(info.Scopes, ", ")
+				}
+				if info.Sufficient() {
+					result(true, "Token: authenticated as %s (%s), scopes: %s", info.User, c.Provider, scopes)
+				} else {
+					result(false, "Token: authenticated as %s (%s), but it has none of the scopes %s (has: %s)", info.User, c.Provider, strings.Join(info.Required, ", "), scopes)
+				}
+			}
+
+			if len(args) == 0 && !private && !sshDomains[domain] {
+				skip("SSH: no repositories cloned over SSH")
+				continue
+			}
+			greeting, err := gitutil.CheckSSHAuth(domain, appConfig.Layers.For(domain).SSHCommand)
+			if err != nil {
+				result(false, "SSH: %v", hostKeyHint(err, domain))
+				continue
+			}
+			if line, _, _ := strings.Cut(greeting, "\n"); line != "" {
+				greeting = ": " + line
+			}
+			result(true, "SSH: key accepted%s", greeting)
+		}
+
+		fmt.Printf("\nAuth test summary:\n  Passed: %d\n  Failed: %d\n  Skipped: %d\n", passed, failed, skipped)
+		if failed > 0 {
+			return &exitError{code: exitIssues, err: fmt.Errorf("%d auth checks failed", failed)}
+		}
+		return nil
+	},
+}
+
+// openCredentials opens the credentials store with the configured keyring.
+func openCredentials() (*auth.Store, error) {
+	ring, err := keyring.Open(appConfig.Keyring, appConfig.KeyringFile, keyringPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	return auth.Open(appConfig.CredentialsFile, ring)
+}
+
+// keyringPassphrase returns the passphrase of the encrypted keyring file, from
+// FUSSY_GIT_KEYRING_PASSPHRASE or a prompt. A new file's passphrase is asked for twice.
+func keyringPassphrase() (string, error) {
+	if passphrase := os.Getenv(envKeyringPassphrase); passphrase != "" {
+		return passphrase, nil
+	}
+	if !isTerminal(os.Stdin) {
+		return "", fmt.Errorf("no OS keyring available and the keyring file %s needs a passphrase; set %s", appConfig.KeyringFile, envKeyringPassphrase)
+	}
+	if _, err := os.Stat(appConfig.KeyringFile); err == nil {
+		return readSecret(fmt.Sprintf("Passphrase for %s: ", appConfig.KeyringFile))
+	}
+	fmt.Printf("No OS keyring available; secrets are stored in %s, encrypted with a passphrase.\n", appConfig.KeyringFile)
+	passphrase, err := readSecret("New passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	confirm, err := readSecret("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirm {
+		return "", fmt.Errorf("the passphrases don't match")
+	}
+	return passphrase, nil
+}
+
+// readToken reads a token from stdin: the whole input with --with-token, otherwise from a prompt.
+func readToken(prompt string) (string, error) {
+	if withTokenAuth {
+		var sb strings.Builder
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			sb.WriteString(scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return "", fmt.Errorf("failed to read token from stdin: %w", err)
+		}
+		return strings.TrimSpace(sb.String()), nil
+	}
+	return readSecret(prompt)
+}
+
+// readSecret reads a line typed at a prompt, with echo turned off if stdin is a terminal.
+func readSecret(prompt string) (string, error) {
+	fmt.Print(prompt)
+	if isTerminal(os.Stdin) {
+		if err := stty("-echo"); err == nil {
+			defer func() {
+				stty("echo")
+				fmt.Println()
+			}()
+		}
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// stty changes a setting of the terminal connected to stdin.
+func stty(settings ...string) error {
+	cmd := exec.Command("stty", settings...)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
+
+func init() {
+	authLoginCmd.Flags().StringVar(&providerAuth, "provider", "", "API flavour of the host: github, gitlab, gitea or bitbucket (guessed from the domain if omitted)")
+	authLoginCmd.Flags().BoolVar(&withTokenAuth, "with-token", false, "Read the token from stdin instead of prompting")
+	authCmd.AddCommand(authLoginCmd, authLogoutCmd, authStatusCmd, authTestCmd, authTrustHostCmd)
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	branchAll      bool
+	branchFilter   filter.Filter
+	branchBulk     bulkOptions
+	branchFrom     string
+	branchNoSwitch bool
+	branchForce    bool
+)
+
+// branchCmd represents the branch command
+var branchCmd = &cobra.Command{
+	Use:   "branch",
+	Short: "Creates, switches or deletes a branch across several repositories.",
+	Long: `Performs the same branch operation in every repository selected by --all or the filter
+flags (--domain, --owner, --tag, --path-prefix, --meta, --group), e.g. when a feature spans
+several services:
+  fussy-git branch create feature/login --group auth-services
+  fussy-git branch switch main --group auth-services
+  fussy-git branch delete feature/login --group auth-services
+
+Repositories where the operation fails (e.g. the branch already exists, or switching would
+overwrite uncommitted changes) are reported and left as they are; the others go ahead. A
+report with every repository's result follows. The --jobs, --output, --fail-fast and
+--continue-on-error flags work as for 'fussy-git exec'.`,
+}
+
+// branchCreateCmd represents the branch create command
+var branchCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Creates a branch in the selected repositories and switches to it.",
+	Long: `Creates the branch <name> in every selected repository, starting at the commit checked out
+(or at --from, e.g. origin/main), and switches to it unless --no-switch is given.`,
+	Annotations: writesTree,
+	Args:        cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command := []string{"git", "switch", "--create", args[0]}
+		if branchNoSwitch {
+			command = []string{"git", "branch", args[0]}
+		}
+		if branchFrom != "" {
+			command = append(command, branchFrom)
+		}
+		return runBranchBulk("branch create", args[0], command)
+	},
+}
+
+// branchSwitchCmd represents the branch switch command
+var branchSwitchCmd = &cobra.Command{
+	Use:   "switch <name>",
+	Short: "Switches the selected repositories to a branch.",
+	Long: `Switches every selected repository to the branch <name>. If a repository has no local
+branch of that name but origin has one, a local branch tracking it is created.`,
+	Annotations: writesTree,
+	Args:        cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBranchBulk("branch switch", args[0], []string{"git", "switch", args[0]})
+	},
+}
+
+// branchDeleteCmd represents the branch delete command
+var branchDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Deletes a branch in the selected repositories.",
+	Long: `Deletes the local branch <name> in every selected repository. Branches that aren't merged
+into their upstream (or HEAD) are kept unless --force is given, and the branch that is checked
+out is never deleted. Repositories without the branch are skipped.`,
+	Annotations: writesTree,
+	Args:        cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flag := "--delete"
+		if branchForce {
+			flag = "-D"
+		}
+		return runBranchBulk("branch delete", args[0], []string{"git", "branch", flag, args[0]})
+	},
+}
+
+// runBranchBulk runs the git command of a branch operation in the selected repositories and
+// prints the report. Repositories without the branch are skipped when deleting it.
+func runBranchBulk(operation, name string, command []string) error {
+	if !branchAll && branchFilter.IsEmpty() {
+		return usageError("select the repositories with --all or the filter flags (--domain, --owner, --tag, --path-prefix, --meta, --group)")
+	}
+	if err := branchBulk.validate(); err != nil {
+		return err
+	}
+	if !gitutil.ValidBranchName(name) {
+		return usageError("'%s' is not a valid branch name", name)
+	}
+	repos := branchFilter.Apply(repoState.Repositories)
+	if operation == "branch delete" {
+		var withBranch []state.RepositoryEntry
+		for _, repo := range repos {
+			if gitutil.BranchExists(repo.Path, name) {
+				withBranch = append(withBranch, repo)
+			} else if verbose {
+				fmt.Printf("[SKIP] %s: has no branch '%s'\n", repo.Name, name)
+			}
+		}
+		if skipped := len(repos) - len(withBranch); skipped > 0 && branchBulk.output == "text" {
+			fmt.Printf("%d repositories have no branch '%s' and are skipped.\n", skipped, name)
+		}
+		repos = withBranch
+	}
+	if len(repos) == 0 {
+		fmt.Fprintln(os.Stderr, "No repositories match.")
+		return nil
+	}
+
+	report := runBulk(operation, repos, &branchBulk, func(repo state.RepositoryEntry, stdout, stderr io.Writer) (int, error) {
+		return runInRepository(repo, command, nil, stdout, stderr)
+	})
+	return report.print(&branchBulk)
+}
+
+func init() {
+	for _, c := range []*cobra.Command{branchCreateCmd, branchSwitchCmd, branchDeleteCmd} {
+		c.Flags().BoolVar(&branchAll, "all", false, "Operate on every tracked repository")
+		addFilterFlags(c, &branchFilter)
+		addBulkFlags(c, &branchBulk, 4)
+		c.ValidArgsFunction = cobra.NoFileCompletions
+	}
+	branchCreateCmd.Flags().StringVar(&branchFrom, "from", "", "Start the branch at this commit, branch or tag instead of the checked out commit")
+	branchCreateCmd.Flags().BoolVar(&branchNoSwitch, "no-switch", false, "Only create the branch, don't switch to it")
+	branchDeleteCmd.Flags().BoolVar(&branchForce, "force", false, "Also delete branches that aren't merged")
+	branchCmd.AddCommand(branchCreateCmd, branchSwitchCmd, branchDeleteCmd)
+}
@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Statuses of a repository in the report of a bulk operation.
+const (
+	bulkStatusOK      = "ok"
+	bulkStatusFailed  = "failed"
+	bulkStatusSkipped = "skipped"
+)
+
+// bulkStderrLines is how many lines of a failure's error output the text report shows.
+const bulkStderrLines = 10
+
+// bulkOptions are the flags shared by operations run on many repositories.
+type bulkOptions struct {
+	jobs            int
+	output          string // "text" or "json"
+	failFast        bool
+	continueOnError bool
+	silent          bool // Print nothing while running; the caller shows progress itself
+}
+
+// addBulkFlags registers the flags of bulk operations on a command. defaultJobs is the
+// number of repositories processed at the same time unless --jobs is given.
+func addBulkFlags(c *cobra.Command, o *bulkOptions, defaultJobs int) {
+	c.Flags().IntVarP(&o.jobs, "jobs", "j", defaultJobs, "Number of repositories to process in parallel")
+	c.Flags().StringVarP(&o.output, "output", "o", "text", "Format of the report: 'text' or 'json'")
+	c.Flags().BoolVar(&o.failFast, "fail-fast", false, "Stop after the first repository that fails; the remaining ones are skipped")
+	c.Flags().BoolVar(&o.continueOnError, "continue-on-error", false, "Exit with status 0 even if some repositories failed (they are still reported)")
+}
+
+func (o *bulkOptions) validate() error {
+	if o.output != "text" && o.output != "json" {
+		return usageError("invalid --output value '%s': must be 'text' or 'json'", o.output)
+	}
+	if o.jobs < 1 {
+		return usageError("--jobs must be at least 1")
+	}
+	if o.failFast && o.continueOnError {
+		return usageError("--fail-fast and --continue-on-error can't be used together")
+	}
+	return nil
+}
+
+// bulkResult is the outcome of one repository of a bulk operation.
+type bulkResult struct {
+	Name     string  `json:"name"`
+	Path     string  `json:"path"`
+	Status   string  `json:"status"`
+	ExitCode int     `json:"exit_code"`
+	Duration float64 `json:"duration_seconds"`
+	Error    string  `json:"error,omitempty"`
+	Stdout   string  `json:"stdout,omitempty"`
+	Stderr   string  `json:"stderr,omitempty"` // Error output, kept for failures only
+}
+
+// bulkReport is the report of a bulk operation, printed as a table or as JSON.
+type bulkReport struct {
+	Operation    string       `json:"operation"`
+	Total        int          `json:"total"`
+	Succeeded    int          `json:"succeeded"`
+	Failed       int          `json:"failed"`
+	Skipped      int          `json:"skipped"`
+	Duration     float64      `json:"duration_seconds"`
+	Repositories []bulkResult `json:"repositories"`
+}
+
+// bulkOperation runs on one repository, writing its output to stdout and stderr. It returns
+// the exit code of what it ran (0 on success) and an error if it failed.
+type bulkOperation func(repo state.RepositoryEntry, stdout, stderr io.Writer) (exitCode int, err error)
+
+// bulkSkip is returned by a bulkOperation to report the repository as skipped, e.g. because
+// its directory is missing, rather than as failed.
+type bulkSkip struct {
+	reason string
+}
+
+func (s *bulkSkip) Error() string { return s.reason }
+
+// bulkExitCode returns the exit code of the git command or program that err reports the
+// failure of, exitFailure if it didn't exit with one, or 0 if err is nil.
+func bulkExitCode(err error) int {
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.ExitCode()
+	default:
+		return exitFailure
+	}
+}
+
+// runBulk runs op on every repository, o.jobs at a time, and returns the report. In text
+// mode, each repository's output is printed as a block once it is done; with a single job it
+// is streamed as it is produced instead. In JSON mode, output is only part of the report, and
+// with o.silent nothing is printed at all.
+func runBulk(operation string, repos []state.RepositoryEntry, o *bulkOptions, op bulkOperation) *bulkReport {
+	report := &bulkReport{Operation: operation, Total: len(repos), Repositories: make([]bulkResult, len(repos))}
+	stream := o.jobs == 1 && o.output == "text" && !o.silent
+	start := time.Now()
+
+	var mu sync.Mutex // Serializes printing and the fail-fast flag
+	stop := false
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < o.jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				repo := repos[i]
+				result := &report.Repositories[i]
+				*result = bulkResult{Name: repo.Name, Path: repo.Path}
+
+				mu.Lock()
+				skip := stop
+				mu.Unlock()
+				if skip {
+					result.Status, result.Error = bulkStatusSkipped, "not run: an earlier repository failed"
+					continue
+				}
+
+				var stdout, stderr bytes.Buffer
+				outW, errW := io.Writer(&stdout), io.Writer(&stderr)
+				if stream {
+					fmt.Printf("==> %s (%s)\n", repo.Name, repo.Path)
+					outW, errW = os.Stdout, io.MultiWriter(os.Stderr, &stderr)
+				}
+				began := time.Now()
+				code, err := op(repo, outW, errW)
+				result.Duration = time.Since(began).Seconds()
+				result.ExitCode = code
+				var skipped *bulkSkip
+				switch {
+				case errors.As(err, &skipped):
+					result.Status, result.Error = bulkStatusSkipped, skipped.reason
+				case err != nil:
+					result.Status, result.Error = bulkStatusFailed, err.Error()
+					result.Stderr = stderr.String()
+				default:
+					result.Status = bulkStatusOK
+				}
+				if o.output == "json" {
+					result.Stdout = stdout.String()
+				}
+
+				mu.Lock()
+				if result.Status == bulkStatusFailed && o.failFast {
+					stop = true
+				}
+				if o.output == "text" && !o.silent {
+					if !stream {
+						fmt.Printf("==> %s (%s)\n", repo.Name, repo.Path)
+						os.Stdout.Write(stdout.Bytes())
+						os.Stdout.Write(stderr.Bytes())
+					}
+					switch result.Status {
+					case bulkStatusSkipped:
+						fmt.Printf("[SKIP] %s: %s\n", repo.Name, result.Error)
+					case bulkStatusFailed:
+						fmt.Printf("[FAIL] %s: %v\n", repo.Name, err)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range repos {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	report.Duration = time.Since(start).Seconds()
+	report.count()
+	return report
+}
+
+// addFailure adds a repository that failed before the operation could run on it to the
+// report, e.g. one that isn't tracked.
+func (r *bulkReport) addFailure(name, path string, err error) {
+	r.Repositories = append(r.Repositories, bulkResult{Name: name, Path: path, Status: bulkStatusFailed, ExitCode: exitFailure, Error: err.Error()})
+	r.Total++
+	r.count()
+}
+
+// count updates the totals of the report from the results of its repositories.
+func (r *bulkReport) count() {
+	r.Succeeded, r.Failed, r.Skipped = 0, 0, 0
+	for _, result := range r.Repositories {
+		switch result.Status {
+		case bulkStatusOK:
+			r.Succeeded++
+		case bulkStatusFailed:
+			r.Failed++
+		case bulkStatusSkipped:
+			r.Skipped++
+		}
+	}
+}
+
+// print writes the report to stdout in the format selected by o, and returns an error if any
+// repository failed, unless --continue-on-error was given. Skipped repositories count as
+// failed for the exit code, so --fail-fast stopping after the first one exits with
+// exitPartial only if an earlier repository succeeded.
+func (r *bulkReport) print(o *bulkOptions) error {
+	if o.output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	} else {
+		title := strings.ToUpper(r.Operation[:1]) + r.Operation[1:]
+		fmt.Printf("\n%s summary:\n", title)
+		fmt.Printf("  Repositories: %d\n", r.Total)
+		fmt.Printf("  Succeeded:    %d\n", r.Succeeded)
+		fmt.Printf("  Failed:       %d\n", r.Failed)
+		fmt.Printf("  Skipped:      %d\n", r.Skipped)
+		fmt.Printf("  Duration:     %s\n\n", formatSeconds(r.Duration))
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STATUS\tEXIT\tDURATION\tNAME\tPATH")
+		fmt.Fprintln(w, "------\t----\t--------\t----\t----")
+		for _, result := range r.Repositories {
+			exit, duration, path := "-", "-", result.Path
+			if result.Status != bulkStatusSkipped {
+				exit, duration = fmt.Sprint(result.ExitCode), formatSeconds(result.Duration)
+			}
+			if path == "" {
+				path = "-" // Not tracked
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", result.Status, exit, duration, result.Name, path)
+		}
+		w.Flush()
+
+		for _, result := range r.Repositories {
+			if result.Status != bulkStatusFailed {
+				continue
+			}
+			if result.Path == "" {
+				fmt.Printf("\n[FAIL] %s: %s\n", result.Name, result.Error)
+			} else {
+				fmt.Printf("\n[FAIL] %s (%s): %s\n", result.Name, result.Path, result.Error)
+			}
+			lines := strings.Split(strings.TrimRight(result.Stderr, "\n"), "\n")
+			if len(lines) > bulkStderrLines {
+				fmt.Printf("    ... %d more lines\n", len(lines)-bulkStderrLines)
+				lines = lines[len(lines)-bulkStderrLines:]
+			}
+			for _, line := range lines {
+				if line != "" {
+					fmt.Printf("    %s\n", line)
+				}
+			}
+		}
+	}
+
+	var failed []string
+	for _, result := range r.Repositories {
+		if result.Status == bulkStatusFailed {
+			failed = append(failed, result.Path)
+		}
+	}
+	notifyFinished(r.Operation, time.Duration(r.Duration*float64(time.Second)),
+		fmt.Sprintf("%d of %d repositories succeeded, %d failed", r.Succeeded, r.Total, r.Failed), failed)
+
+	if r.Failed > 0 && !o.continueOnError {
+		return bulkFailure(r.Failed+r.Skipped, r.Total, fmt.Errorf("%d of %d repositories failed", r.Failed, r.Total))
+	}
+	return nil
+}
+
+// formatSeconds formats a duration in seconds for reports, e.g. "1.2s" or "35ms".
+func formatSeconds(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second))
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
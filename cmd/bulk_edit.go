@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// bulkSelection selects the repositories an edit of the state applies to: the repositories
+// named as arguments, or every repository matching the filter flags (or --all).
+type bulkSelection struct {
+	filter filter.Filter
+	all    bool
+	dryRun bool
+}
+
+// addBulkSelectionFlags registers the flags of bulkSelection on a command that changes the
+// state of the repositories it is given.
+func addBulkSelectionFlags(c *cobra.Command, s *bulkSelection) {
+	addFilterFlags(c, &s.filter)
+	c.Flags().BoolVar(&s.all, "all", false, "Change every tracked repository")
+	c.Flags().BoolVar(&s.dryRun, "dry-run", false, "Show what would change without saving anything")
+}
+
+// bulkSelectionHelp describes how bulkSelection selects repositories, for the help of the
+// commands using it.
+const bulkSelectionHelp = `Instead of naming repositories, select them with the filter flags (--domain, --owner, --tag,
+--path-prefix, --meta, --group) or --all, to change hundreds of repositories at once. Use
+--dry-run to see what would change first.`
+
+// selected reports whether repositories are selected with flags rather than named.
+func (s *bulkSelection) selected() bool {
+	return s.all || !s.filter.IsEmpty()
+}
+
+// indices returns the indices of the repositories to change: those refs refers to, or those
+// the flags select. Either must be given, but not both.
+func (s *bulkSelection) indices(cmd *cobra.Command, refs []string) ([]int, error) {
+	switch {
+	case s.selected() && len(refs) > 0:
+		return nil, usageError("name repositories or select them with --all or filter flags, not both")
+	case !s.selected() && len(refs) == 0:
+		return nil, usageError("name the repositories to change, or select them with filter flags or --all")
+	case s.all && !s.filter.IsEmpty():
+		return nil, usageError("--all can't be combined with filter flags")
+	}
+	var indices []int
+	if !s.selected() {
+		for _, ref := range refs {
+			idx, err := lookupRepository(ref)
+			if err != nil {
+				return nil, err
+			}
+			indices = append(indices, idx)
+		}
+		return indices, nil
+	}
+	if err := checkGroupFilter(cmd); err != nil {
+		return nil, err
+	}
+	for i, repo := range repoState.Repositories {
+		if s.filter.Match(repo) {
+			indices = append(indices, i)
+		}
+	}
+	return indices, nil
+}
+
+// apply calls edit for each of the repositories at indices. edit changes the entry and
+// describes the change tersely (e.g. "tag +work"), or returns "" if the repository is left as
+// it is. The changes are listed, followed by a summary when several repositories were
+// selected, and saved unless --dry-run is given (then the state isn't changed at all).
+// operation names the edit in the summary, e.g. "Tag".
+func (s *bulkSelection) apply(operation string, indices []int, edit func(entry *state.RepositoryEntry) string) error {
+	if len(indices) == 0 {
+		fmt.Println("No repositories match the given filters.")
+		return nil
+	}
+	changed := 0
+	for _, idx := range indices {
+		entry := &repoState.Repositories[idx]
+		if s.dryRun {
+			copied := *entry
+			copied.Tags = append([]string(nil), entry.Tags...)
+			copied.Metadata = copyMetadata(entry.Metadata)
+			copied.Groups = append([]string(nil), entry.Groups...)
+			entry = &copied
+		}
+		change := edit(entry)
+		if change == "" {
+			continue
+		}
+		entry.LastModified = time.Now()
+		changed++
+		if s.dryRun {
+			fmt.Printf("  Would change %s: %s\n", entry.NormalizedFS, change)
+		} else {
+			fmt.Printf("  %s: %s\n", entry.NormalizedFS, change)
+		}
+	}
+
+	if s.selected() || len(indices) > 1 {
+		fmt.Printf("\n%s summary:\n", operation)
+		fmt.Printf("  Selected:  %d\n", len(indices))
+		fmt.Printf("  Changed:   %d\n", changed)
+		fmt.Printf("  Unchanged: %d\n", len(indices)-changed)
+	} else if changed == 0 {
+		fmt.Printf("Nothing to change for %s.\n", repoState.Repositories[indices[0]].NormalizedFS)
+	}
+	if s.dryRun {
+		fmt.Println("\nDry run: nothing was saved.")
+		return nil
+	}
+	if changed == 0 {
+		return nil
+	}
+	if err := repoState.Save(appConfig.StateFilePath); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// copyMetadata returns a copy of the metadata of a repository.
+func copyMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/fsutil"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var clearCache bool
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Lists or clears the local mirror cache used to speed up clones.",
+	Long: `With 'clone_cache: true' in the config file, fussy-git keeps a bare mirror of every repository
+it clones in the 'clone_cache_dir' directory (default ~/.fussy-git/cache). The first clone of a
+repository creates its mirror; later clones of the same repository, e.g. after deleting a
+checkout or for scratch clones at another --path, first update the mirror and then copy the
+objects from it ('git clone --reference-if-able <mirror> --dissociate'). Only new objects are
+downloaded, and the clone doesn't depend on the mirror afterwards.
+
+Clones sharing objects with a fork (--reference, --auto-reference) don't use the cache.
+Use 'clone --no-cache' to bypass it for a single clone.
+
+Without flags, the command lists the mirrors in the cache with their size and when they were
+last updated. --clear deletes all of them; checkouts are not affected.
+
+Examples:
+  fussy-git cache
+  fussy-git cache --clear`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if clearCache {
+			if appConfig.ReadOnly {
+				return readOnlyError("'cache --clear'")
+			}
+			if err := os.RemoveAll(appConfig.CloneCacheDir); err != nil {
+				return fmt.Errorf("failed to clear the clone cache at %s: %w", appConfig.CloneCacheDir, err)
+			}
+			fmt.Printf("Clone cache at %s cleared.\n", appConfig.CloneCacheDir)
+			return nil
+		}
+
+		mirrors, err := listCacheMirrors()
+		if err != nil {
+			return err
+		}
+		status := "enabled"
+		if !appConfig.CloneCache {
+			status = "disabled, set 'clone_cache: true' to enable it"
+		}
+		fmt.Printf("Clone cache: %s (%s)\n", appConfig.CloneCacheDir, status)
+		if len(mirrors) == 0 {
+			fmt.Println("The cache is empty.")
+			return nil
+		}
+
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "MIRROR\tSIZE\tUPDATED")
+		fmt.Fprintln(w, "------\t----\t-------")
+		var total int64
+		for _, mirror := range mirrors {
+			size, _ := fsutil.DirSize(mirror)
+			total += size
+			rel, _ := filepath.Rel(appConfig.CloneCacheDir, mirror)
+			fmt.Fprintf(w, "%s\t%s\t%s\n", strings.TrimSuffix(rel, ".git"), formatSize(size), mirrorUpdated(mirror).Format("2006-01-02 15:04"))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Printf("\n%d mirrors, %s in total\n", len(mirrors), formatSize(total))
+		return nil
+	},
+}
+
+// cacheMirrorPath returns where the cache keeps the mirror of a repository. URLs differing
+// only in protocol share a mirror.
+func cacheMirrorPath(parsed *gitutil.ParsedGitURL) string {
+	return filepath.Join(appConfig.CloneCacheDir, parsed.GetNormalizedFSPath()+".git")
+}
+
+// cacheCloneArgs creates or updates the cache mirror of job's repository and returns the
+// 'git clone' options to copy objects from it. It returns no options if the cache is
+// disabled or not used for job. status is called with progress messages. An error means
+// the mirror can't be used; the clone then works without the cache.
+func cacheCloneArgs(job *cloneJob, status func(msg string)) ([]string, error) {
+	if !appConfig.CloneCache || cloneNoCache || job.reference != "" {
+		return nil, nil
+	}
+	mirror := cacheMirrorPath(job.parsed)
+	if gitutil.IsGitRepository(mirror) {
+		status("Updating cached mirror " + mirror)
+		if err := gitutil.UpdateMirror(mirror, verbose); err != nil {
+			// A stale mirror still saves downloading most objects; git fetches the rest.
+			status(fmt.Sprintf("Failed to update cached mirror, using it as is: %v", err))
+		}
+	} else {
+		status("Creating cached mirror " + mirror)
+		if err := os.MkdirAll(filepath.Dir(mirror), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create clone cache directory: %w", err)
+		}
+		if err := gitutil.CloneMirror(job.url, mirror, verbose); err != nil {
+			os.RemoveAll(mirror)
+			removeEmptyParents(filepath.Dir(mirror), appConfig.CloneCacheDir)
+			return nil, err
+		}
+	}
+	return []string{"--reference-if-able", mirror, "--dissociate"}, nil
+}
+
+// listCacheMirrors returns the paths of all mirrors in the clone cache.
+func listCacheMirrors() ([]string, error) {
+	var mirrors []string
+	err := filepath.WalkDir(appConfig.CloneCacheDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == appConfig.CloneCacheDir {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() && strings.HasSuffix(path, ".git") {
+			mirrors = append(mirrors, path)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the clone cache at %s: %w", appConfig.CloneCacheDir, err)
+	}
+	return mirrors, nil
+}
+
+// mirrorUpdated returns when a mirror was last created or updated.
+func mirrorUpdated(mirror string) time.Time {
+	for _, name := range []string{"FETCH_HEAD", "packed-refs", "HEAD"} {
+		if info, err := os.Stat(filepath.Join(mirror, name)); err == nil {
+			return info.ModTime()
+		}
+	}
+	return time.Time{}
+}
+
+// formatSize formats a number of bytes with a binary unit, e.g. "1.5 MiB".
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	cacheCmd.Flags().BoolVar(&clearCache, "clear", false, "Delete all mirrors in the clone cache")
+}
@@ -0,0 +1,345 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/audit"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/fsutil"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Actions suggested by cleanup-advisor.
+const (
+	cleanupGC      = "gc"                // Pack loose objects and prune garbage
+	cleanupRemove  = "remove"            // Delete the clone; everything is on the reachable origin
+	cleanupBundle  = "bundle-and-delete" // Keep all refs in a git bundle, then delete the clone
+	cleanupArchive = "archive"           // Keep the whole directory in a .tar.gz, then delete the clone
+)
+
+// cleanupGCThreshold is how many bytes 'git gc' must be able to reclaim in a repository for
+// cleanup-advisor to suggest it.
+const cleanupGCThreshold = 64 << 20
+
+var (
+	cleanupStaleAfter time.Duration
+	cleanupOffline    bool
+	cleanupArchiveDir string
+	cleanupApply      bool
+	cleanupYes        bool
+	cleanupFilter     filter.Filter
+)
+
+// cleanupAdvice is what cleanup-advisor suggests doing with a repository.
+type cleanupAdvice struct {
+	entry   state.RepositoryEntry
+	action  string // One of the cleanup* constants
+	savings int64  // Estimated bytes freed
+	reasons []string
+}
+
+// cleanupAdvisorCmd represents the cleanup-advisor command
+var cleanupAdvisorCmd = &cobra.Command{
+	Use:   "cleanup-advisor",
+	Short: "Suggests how to free disk space, per repository.",
+	Long: `Combines disk usage, staleness, the availability of origin and uncommitted work to suggest
+concrete actions that free disk space, with an estimate of how much each one saves:
+
+  gc                 The repository is in use, but 'git gc' can reclaim at least 64 MiB of
+                     loose objects and garbage.
+  remove             No activity (commits or fetches) for longer than --stale-after, nothing
+                     uncommitted or stashed, every commit is on a remote-tracking branch and
+                     origin is reachable: the clone can simply be deleted and cloned again.
+  bundle-and-delete  Stale and clean, but some commits exist only in this clone, or origin is
+                     gone or unreachable: every branch and tag is saved to a git bundle in
+                     the archive directory first (clone it again with 'git clone <bundle>').
+  archive            Stale, with uncommitted changes or stashes a bundle can't keep: the whole
+                     directory is saved to a .tar.gz in the archive directory first.
+
+Bundles and archives are named after the location of the clone below FUSSY_GIT_HOME, e.g.
+~/.fussy-git/archive/github.com/spf13/cobra.bundle.
+
+Repositories that are locked, come from the shared state, have a separate git directory or
+share their objects with other tracked clones (see 'clone --reference') are only ever
+suggested gc. Reachability of origin is checked with 'git ls-remote'; with --offline
+it isn't, and stale repositories are bundled rather than removed. The estimates are rough:
+bundles are assumed to be as large as the packed objects, and archives as large as the git
+directory.
+
+Nothing is changed unless --apply is given, which asks before applying each suggestion
+(y/n/a/q); --yes applies all of them without asking. Repositories that are deleted are no
+longer tracked, and every deletion is recorded in the audit log next to the state file.
+
+Examples:
+  fussy-git cleanup-advisor
+  fussy-git cleanup-advisor --stale-after 4380h --offline
+  fussy-git cleanup-advisor --owner old-team --apply`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cleanupYes && !cleanupApply {
+			return usageError("--yes can only be used with --apply")
+		}
+		if cleanupStaleAfter <= 0 {
+			return usageError("--stale-after must be positive")
+		}
+		if cleanupApply && appConfig.ReadOnly {
+			return readOnlyError("'cleanup-advisor --apply'")
+		}
+		if cleanupArchiveDir == "" {
+			cleanupArchiveDir = filepath.Join(filepath.Dir(appConfig.StateFilePath), "archive")
+		}
+
+		repos := cleanupFilter.Apply(repoState.Repositories)
+		if len(repos) == 0 {
+			fmt.Println("No repositories to inspect.")
+			return nil
+		}
+		advice := adviseCleanups(repos)
+		if len(advice) == 0 {
+			fmt.Printf("No cleanup suggestions for %d repositories.\n", len(repos))
+			return nil
+		}
+
+		var total int64
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ACTION\tSAVES\tPATH\tWHY")
+		fmt.Fprintln(w, "------\t-----\t----\t---")
+		for _, a := range advice {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", a.action, formatSize(a.savings), a.entry.Path, strings.Join(a.reasons, ", "))
+			total += a.savings
+		}
+		w.Flush()
+
+		fmt.Printf("\nCleanup advisor summary:\n")
+		fmt.Printf("  Repositories:      %d\n", len(repos))
+		fmt.Printf("  Suggestions:       %d\n", len(advice))
+		fmt.Printf("  Estimated savings: %s\n", formatSize(total))
+		if !cleanupApply {
+			fmt.Println("\nRun with --apply to apply the suggestions.")
+			return nil
+		}
+
+		fmt.Println()
+		prompter := newActionPrompter()
+		if cleanupYes {
+			prompter.applyAll = true
+		}
+		var applied, removed int
+		var saved int64
+		var failed []string
+		for _, a := range advice {
+			if !prompter.Confirm(fmt.Sprintf("%s %s (saves about %s)?", a.action, a.entry.Path, formatSize(a.savings))) {
+				continue
+			}
+			kept, err := applyCleanup(a)
+			if err != nil {
+				fmt.Printf("[FAIL] %s: %v\n", a.entry.Path, err)
+				failed = append(failed, a.entry.Path)
+				continue
+			}
+			if kept != "" {
+				fmt.Printf("[OK] %s: %s, kept in %s\n", a.entry.Path, a.action, kept)
+			} else {
+				fmt.Printf("[OK] %s: %s\n", a.entry.Path, a.action)
+			}
+			applied++
+			saved += a.savings
+			if a.action != cleanupGC {
+				removed++
+			}
+		}
+		if removed > 0 {
+			if err := repoState.Save(appConfig.StateFilePath); err != nil {
+				return fmt.Errorf("repositories were deleted, but failed to save state: %w", err)
+			}
+		}
+
+		fmt.Printf("\nCleanup summary:\n")
+		fmt.Printf("  Applied:         %d\n", applied)
+		fmt.Printf("  Deleted:         %d\n", removed)
+		fmt.Printf("  Failed:          %d\n", len(failed))
+		fmt.Printf("  Estimated freed: %s\n", formatSize(saved))
+		if len(failed) > 0 {
+			return bulkFailure(len(failed), applied+len(failed), fmt.Errorf("%d cleanups failed", len(failed)))
+		}
+		return nil
+	},
+}
+
+// adviseCleanups inspects repositories, several at a time, and returns the suggestions for
+// them, the largest savings first.
+func adviseCleanups(repos []state.RepositoryEntry) []cleanupAdvice {
+	results := make([]*cleanupAdvice, len(repos))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < inspectWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = adviseCleanup(repos[idx])
+			}
+		}()
+	}
+	for i := range repos {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var advice []cleanupAdvice
+	for _, a := range results {
+		if a != nil {
+			advice = append(advice, *a)
+		}
+	}
+	sort.SliceStable(advice, func(i, j int) bool { return advice[i].savings > advice[j].savings })
+	return advice
+}
+
+// adviseCleanup returns what to do with a repository to free disk space, or nil if nothing.
+func adviseCleanup(repo state.RepositoryEntry) *cleanupAdvice {
+	if _, err := os.Stat(repo.Path); err != nil || !gitutil.IsGitRepository(repo.Path) {
+		return nil // Reported by doctor.
+	}
+	a := &cleanupAdvice{entry: repo}
+	last := lastActivity(repo.Path)
+	stale := !last.IsZero() && time.Since(last) > cleanupStaleAfter
+	deletable := !repo.Locked && !repoState.IsShared(repo.Path) && repo.GitDir == ""
+	// Deleting a repository whose objects other clones borrow would corrupt them.
+	if stale && deletable && len(borrowersOf(repo.Path)) > 0 {
+		deletable = false
+	}
+	if !stale || !deletable {
+		reclaimable, err := gitutil.ReclaimableSize(repo.Path)
+		if err != nil || reclaimable < cleanupGCThreshold {
+			return nil
+		}
+		a.action, a.savings = cleanupGC, reclaimable
+		a.reasons = append(a.reasons, fmt.Sprintf("%s of loose objects and garbage", formatSize(reclaimable)))
+		return a
+	}
+
+	size, _ := fsutil.DirSize(repo.Path)
+	a.reasons = append(a.reasons, "no activity for "+formatAge(time.Since(last)))
+
+	dirty, err := gitutil.IsDirty(repo.Path)
+	if err != nil {
+		return nil
+	}
+	stashes, _ := gitutil.StashCount(repo.Path)
+	if dirty || stashes > 0 {
+		if dirty {
+			a.reasons = append(a.reasons, "uncommitted changes")
+		}
+		if stashes > 0 {
+			a.reasons = append(a.reasons, fmt.Sprintf("%d stashes", stashes))
+		}
+		a.action, a.savings = cleanupArchive, max(0, size-gitDirSize(repo.Path))
+		return a
+	}
+
+	localOnly, err := gitutil.LocalOnlyCommits(repo.Path)
+	switch {
+	case err != nil:
+		a.reasons = append(a.reasons, "unpushed commits unknown")
+	case localOnly > 0:
+		a.reasons = append(a.reasons, fmt.Sprintf("%d commits only in this clone", localOnly))
+	}
+	reachable := false
+	switch {
+	case cleanupOffline:
+		a.reasons = append(a.reasons, "origin not checked")
+	default:
+		if problem := gitutil.CheckRemote(repo.Path, remoteCheckTimeout); problem != nil {
+			a.reasons = append(a.reasons, "origin "+problem.Kind)
+		} else {
+			reachable = true
+		}
+	}
+	if err == nil && localOnly == 0 && reachable {
+		a.reasons = append(a.reasons, "everything pushed to reachable origin")
+		a.action, a.savings = cleanupRemove, size
+		return a
+	}
+	_, packed, _ := gitutil.CountObjects(repo.Path)
+	a.action, a.savings = cleanupBundle, max(0, size-packed)
+	return a
+}
+
+// applyCleanup carries out a suggestion. For deletions, the repository is removed from the
+// in-memory state, which the caller saves, and the location of the bundle or archive that
+// keeps it is returned.
+func applyCleanup(a cleanupAdvice) (string, error) {
+	repo := a.entry
+	if a.action == cleanupGC {
+		return "", gitutil.GarbageCollectNow(repo.Path)
+	}
+	if borrowers := borrowersOf(repo.Path); len(borrowers) > 0 {
+		return "", fmt.Errorf("%s shares its objects with %s, which would be corrupted; not deleted", repo.Path, strings.Join(borrowers, ", "))
+	}
+
+	kept := ""
+	switch a.action {
+	case cleanupBundle, cleanupArchive:
+		// Named after the location of the clone, which is unique, unlike its normalized path.
+		name := filepath.FromSlash(repo.NormalizedFS)
+		if isWithin(repo.Path, appConfig.FussyGitHome) {
+			name, _ = filepath.Rel(appConfig.FussyGitHome, repo.Path)
+		}
+		kept = filepath.Join(cleanupArchiveDir, name)
+		if a.action == cleanupBundle {
+			kept += ".bundle"
+		} else {
+			kept += ".tar.gz"
+		}
+		if _, err := os.Lstat(kept); err == nil {
+			return "", fmt.Errorf("%s already exists; move it away first", kept)
+		}
+		if err := os.MkdirAll(filepath.Dir(kept), 0700); err != nil {
+			return "", fmt.Errorf("failed to create the archive directory: %w", err)
+		}
+		var err error
+		if a.action == cleanupBundle {
+			err = gitutil.CreateBundle(repo.Path, kept)
+		} else {
+			err = fsutil.ArchiveDir(repo.Path, kept)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.RemoveAll(repo.Path); err != nil {
+		return kept, fmt.Errorf("failed to delete %s: %w", repo.Path, err)
+	}
+	repoState.RemoveRepositoryByPath(repo.Path)
+	removeEmptyParents(filepath.Dir(repo.Path), appConfig.FussyGitHome)
+
+	details := map[string]any{"url": repo.CurrentURL, "saved_bytes": a.savings}
+	if kept != "" {
+		details["kept_in"] = kept
+	}
+	if err := audit.Append(auditLogPath(), audit.Event{Action: "cleanup." + a.action, Repo: repo.Path, Details: details}); err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] %v\n", err)
+	}
+	return kept, nil
+}
+
+func init() {
+	cleanupAdvisorCmd.Flags().DurationVar(&cleanupStaleAfter, "stale-after", 365*24*time.Hour, "How long a repository must have had no commits or fetches to be suggested for deletion")
+	cleanupAdvisorCmd.Flags().BoolVar(&cleanupOffline, "offline", false, "Don't check whether origin is reachable; stale repositories are bundled instead of removed")
+	cleanupAdvisorCmd.Flags().StringVar(&cleanupArchiveDir, "archive-dir", "", "Directory bundles and archives are kept in (default: 'archive' next to the state file)")
+	cleanupAdvisorCmd.Flags().BoolVar(&cleanupApply, "apply", false, "Apply the suggestions, asking before each one")
+	cleanupAdvisorCmd.Flags().BoolVarP(&cleanupYes, "yes", "y", false, "With --apply, apply every suggestion without asking")
+	addFilterFlags(cleanupAdvisorCmd, &cleanupFilter)
+}
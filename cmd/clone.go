@@ -2,137 +2,574 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/jmsnll/fussy-git/internal/config"
 	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/layout"
 	"github.com/jmsnll/fussy-git/internal/state"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	cloneTargetPath    string
+	cloneBatchFile     string
+	cloneJobs          int
+	cloneOutput        string
+	cloneReference     string
+	cloneAutoReference bool
+	cloneNoCache       bool
+	cloneDepth         int
+	cloneShallowSince  string
+	cloneFilter        string
+	cloneSparse        []string
+	cloneFull          bool
+	cloneLocalName     string
+	clonePrintPath     bool
+)
+
 // cloneCmd represents the clone command
 var cloneCmd = &cobra.Command{
-	Use:   "clone <repo_url>",
+	Use:   "clone <repo_url>...",
 	Short: "Clones a repository into the fussy-git directory structure.",
 	Long: `Clones a Git repository from the given URL.
 The repository will be placed in a structured directory:
 $FUSSY_GIT_HOME/<domain>/<user_or_org>/<project_name>.
+With the 'owner' or 'flat' layout configured, the domain (and owner) are left out.
+
+SSH URLs using a Host alias from ~/.ssh/config (e.g. git@work-gh:org/repo.git) are placed
+under the real hostname if the alias is listed in the 'ssh_host_aliases' config mapping,
+or if 'resolve_ssh_aliases: true' is set (the alias is then looked up with 'ssh -G'):
+  ssh_host_aliases:
+    work-gh: github.com
 
 Examples:
   fussy-git clone https://github.com/spf13/cobra.git
   fussy-git clone git@github.com:spf13/cobra.git
+  fussy-git clone https://github.com/spf13/cobra/tree/main/doc   # browser URLs are cleaned up
+  fussy-git clone gh:spf13/cobra                                 # host shortcut
+
+Host shortcuts save typing full URLs: 'gh:', 'gl:' and 'bb:' expand to https://github.com/,
+https://gitlab.com/ and https://bitbucket.org/. More can be added, or the defaults replaced,
+with the 'host_shortcuts' mapping in the config file (map a prefix to "" to remove it):
+  host_shortcuts:
+    work: git.example.com
+If you prefer SSH, combine them with a git rule such as
+'git config --global url.git@github.com:.insteadOf https://github.com/'. A git
+url.<base>.insteadOf rule for the shortcut prefix itself takes precedence over host_shortcuts.
+
+Internal hosts can be listed in 'private_hosts' (patterns like GOPRIVATE, e.g.
+"*.corp.example.com"; the FUSSY_GIT_PRIVATE_HOSTS variable takes a comma-separated list).
+HTTPS URLs for them are cloned over SSH instead, and fussy-git never queries them for
+metadata (e.g. 'fussy-git get' doesn't fetch go-import tags from them):
+  private_hosts:
+    - git.corp.example.com
+
+If a clone fails because the host can't be reached or rejects your credentials, it can be
+retried with the other protocol: set 'protocol_fallback' to ssh-to-https, https-to-ssh or
+both (the default is off). Private hosts are never retried over HTTPS. The protocol that
+worked is remembered per domain and tried first for later clones from that domain, and
+the repository's origin uses the URL that worked.
 
 This command will:
 1. Parse the repository URL.
 2. Determine the target directory based on FUSSY_GIT_HOME.
 3. Clone the repository into the target directory.
-4. Update the local state file (e.g., repos.json) with the repository's information.`,
-	Args: cobra.ExactArgs(1), // Requires exactly one argument: the repository URL
-	RunE: func(cmd *cobra.Command, args []string) error {
-		repoURL := args[0]
+4. Update the local state file (e.g., repos.json) with the repository's information.
 
-		if verbose {
-			fmt.Printf("Attempting to clone: %s\n", repoURL)
-			fmt.Printf("Using FUSSY_GIT_HOME: %s\n", appConfig.FussyGitHome)
-		}
+Use --path to clone into a specific directory instead, e.g. when tooling requires a
+project to live at a fixed location. The repository is still tracked, and it is pinned
+so that 'reorganize' leaves it where it is:
+  fussy-git clone --path ~/tools/cobra https://github.com/spf13/cobra.git
+
+A repository that is already tracked isn't cloned again, even if it was cloned with another
+URL for it (e.g. its SSH URL when the HTTPS one is given) or lives at another location: its
+directory is reported instead. With --print-path, only the directory of the repository is
+printed on stdout, whether it was cloned now or already tracked:
+  cd "$(fussy-git clone --print-path git@github.com:spf13/cobra.git)"
 
-		// 1. Parse the repository URL
-		parsedURL, err := gitutil.ParseGitURL(repoURL)
+Repositories can also be cloned from a local path or file:// URL, e.g. a bare repository
+on a mounted drive. They go into the 'local_dir' directory below FUSSY_GIT_HOME ('local' by
+default), whatever the layout, as <name>-<hash>: the hash of the source path keeps
+repositories with the same name from different places apart. Use --name to choose the
+directory name instead. Relative paths are tracked as absolute paths:
+  fussy-git clone /srv/git/tool.git             # $FUSSY_GIT_HOME/local/tool-<hash>
+  fussy-git clone --name tool file:///srv/git/tool.git
+
+Several repositories can be cloned at once by passing several URLs, or with --batch and a
+manifest file listing one URL per line (blank lines and lines starting with '#' are ignored;
+use '-' to read from stdin). Branch and commit pins in the manifest are ignored here; see
+'fussy-git sync'. Up to --jobs clones run in parallel. On a terminal, a live display
+shows one line per active clone with git's progress and the completed/failed counters.
+The 'max_network_jobs' and 'bandwidth_limit' settings keep large batches from saturating
+the network connection (see 'fussy-git help maintenance' for how the cap works).
+Repositories that are already cloned and tracked are skipped. At the end a summary is
+printed; with --output json it is a JSON document on stdout, and progress goes to stderr:
+  fussy-git clone --batch repos.txt --jobs 8
+  fussy-git clone --batch - --output json < repos.txt > clone-summary.json
+
+For scripts, --quiet prints nothing but one tab-separated line per repository cloned,
+'cloned <path> <url>', and per repository of several that couldn't be cloned,
+'failed <url> <error>'. Repositories that are already tracked print nothing. Errors are still
+reported on stderr, and the exit status is the same as without --quiet:
+  fussy-git clone --quiet --batch repos.txt > cloned.tsv
+
+Forks of large projects can share git's object storage with a local clone of the upstream
+(or of another fork) instead of downloading and storing all objects again. --reference takes
+a tracked repository or the path of a local repository; --auto-reference looks for a tracked
+repository with the same name on the same host under another owner. Objects are then borrowed
+through git alternates (see 'git clone --reference'): the referenced repository must not be
+deleted, and 'git gc --prune' shouldn't be run in it while the clone depends on it. 'reorganize'
+keeps the references intact when it moves a referenced repository, and 'doctor' reports
+clones whose referenced repository is gone. To make a clone independent again, run
+'git repack -a -d' in it and delete .git/objects/info/alternates:
+  fussy-git clone --reference github.com/golang/go https://github.com/me/go.git
+  fussy-git clone --auto-reference https://github.com/me/go.git
+
+With 'clone_cache: true' in the config file, clones go through a local mirror cache that
+makes repeated clones of the same repository near-instant (see 'fussy-git help cache').
+
+If 'hooks_template_dir' or 'hooks_path' is configured, the team's git hooks are installed
+into every clone (see 'fussy-git help install-hooks'). With 'git_maintenance: true', every clone
+is registered for git's background maintenance ('git maintenance start'), which prefetches and
+repacks it on a schedule.
+
+Giant repositories can be cloned partially: --depth or --shallow-since truncate the history,
+--filter makes a partial clone that fetches objects on demand (e.g. blob:none or tree:0), and
+--sparse checks out only the given directories. The same options can be configured as
+defaults per domain, owner or repository with clone_depth, clone_shallow_since, clone_filter
+and clone_sparse (see 'fussy-git help config'); flags take precedence, and --full ignores the
+defaults. The options are recorded in the state, so that 'fussy-git unshallow' and
+'fussy-git densify' can reverse them later:
+  fussy-git clone --filter blob:none --sparse services/api,libs https://git.corp.example.com/mono.git`,
+	Annotations: writesTree,
+	Args:        cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateCloneFlags(); err != nil {
+			return err
+		}
+		if quietOutput && (clonePrintPath || cloneOutput == "json") {
+			return usageError("--quiet can't be used with --print-path or --output json")
+		}
+		endQuiet, err := beginQuiet()
 		if err != nil {
-			return fmt.Errorf("invalid repository URL '%s': %w", repoURL, err)
+			return err
 		}
-		if verbose {
-			fmt.Printf("Parsed URL -> Domain: %s, Path: %s, User: %s, RepoName: %s\n",
-				parsedURL.Domain, parsedURL.Path, parsedURL.User, parsedURL.RepoName)
+		defer endQuiet()
+		if cloneBatchFile != "" || len(args) > 1 {
+			if clonePrintPath {
+				return usageError("--print-path can't be used when cloning several repositories")
+			}
+			return runBatchClone(args)
+		}
+		if len(args) != 1 {
+			return usageError("accepts 1 repository URL, or several together with --batch; received %d", len(args))
+		}
+		if cloneOutput != "text" {
+			return usageError("--output is only supported when cloning several repositories")
+		}
+		var pathOut io.Writer = io.Discard
+		if clonePrintPath {
+			// Only the path goes to stdout, so that it can be captured; the rest is progress.
+			stdout := os.Stdout
+			pathOut, os.Stdout = stdout, os.Stderr
+			defer func() { os.Stdout = stdout }()
 		}
 
-		// 2. Determine the target directory
-		targetPath := parsedURL.GetLocalPath(appConfig.FussyGitHome)
-
-		if verbose {
-			fmt.Printf("Target clone directory: %s\n", targetPath)
+		explicitPath := cloneTargetPath
+		if cloneLocalName != "" {
+			if cloneTargetPath != "" {
+				return usageError("--name and --path can't be used together")
+			}
+			if parsed, err := parseRepoURL(gitutil.SanitizeURL(expandShortcut(args[0]))); err != nil || !parsed.IsLocal() {
+				return usageError("--name is only supported for repositories cloned from a local path or file:// URL")
+			}
+			if cloneLocalName == "." || cloneLocalName == ".." || strings.ContainsRune(cloneLocalName, filepath.Separator) {
+				return usageError("invalid --name '%s': must be a single directory name", cloneLocalName)
+			}
+			explicitPath = filepath.Join(localReposDir(), cloneLocalName)
 		}
 
-		// Check if the repository already exists at the target path or is already tracked
-		if existingEntry, found := repoState.FindRepositoryByPath(targetPath); found {
-			// Path exists and is tracked. Check if URL matches.
-			if existingEntry.OriginalURL == repoURL || existingEntry.CurrentURL == repoURL {
-				fmt.Printf("Repository %s already cloned at %s and tracked with a matching URL.\n", parsedURL.RepoName, targetPath)
-				return nil // Already exists and matches, do nothing
+		job, err := prepareClone(args[0], explicitPath, nil)
+		if err != nil {
+			return err
+		}
+		if cloneLocalName != "" {
+			// Recorded as the repository's location instead of its LocalKey; it isn't pinned.
+			job.pathOverride = job.target
+		}
+		if job.url != job.rawURL {
+			fmt.Printf("Using repository URL %s for %s\n", job.url, job.rawURL)
+		}
+		if job.alreadyTracked {
+			if job.trackedURL != job.url {
+				fmt.Printf("Repository %s is already tracked at %s (as %s).\n", job.parsed.RepoName, job.target, job.trackedURL)
+			} else {
+				fmt.Printf("Repository %s already cloned at %s and tracked with a matching URL.\n", job.parsed.RepoName, job.target)
 			}
-			// Path exists and is tracked, but with a different URL. This is a conflict.
-			return fmt.Errorf("directory %s is already tracked by fussy-git with a different URL (%s). Please remove or reorganize.", targetPath, existingEntry.CurrentURL)
+			fmt.Fprintln(pathOut, job.target)
+			return nil // Already exists and matches, do nothing
 		}
 
-		// Path is not tracked by fussy-git. Check if it exists on disk.
-		if _, statErr := os.Stat(targetPath); !os.IsNotExist(statErr) {
-			// Directory exists but is not in our state file.
-			// It could be an untracked git repo or a non-git directory.
-			// For now, we'll error out to prevent accidental overwrites or confusion.
-			// A more advanced version could offer to adopt/overwrite if it's a git repo.
-			return fmt.Errorf("directory %s already exists on disk but is not tracked by fussy-git. Please remove it or use 'fussy-git add %s' if it's a valid git repository you wish to track from its current location", targetPath, targetPath)
+		if err := applyCloneOptions(job); err != nil {
+			return err
 		}
 
-		// 3. Create the parent directory if it doesn't exist
-		parentDir := filepath.Dir(targetPath)
-		if err := os.MkdirAll(parentDir, 0755); err != nil {
-			return fmt.Errorf("failed to create parent directory %s: %w", parentDir, err)
+		// A repository cloned to an explicit location is pinned there so reorganize won't move it.
+		if err := cloneAndRegister(job, cloneTargetPath != ""); err != nil {
+			return err
 		}
-		if verbose {
-			fmt.Printf("Ensured parent directory exists: %s\n", parentDir)
+		fmt.Fprintln(pathOut, job.target)
+		return nil
+	},
+}
+
+// cloneAndRegister clones a prepared job, adds it to the state and saves the state.
+func cloneAndRegister(job *cloneJob, pinned bool) error {
+	// 4. Clone the repository
+	fmt.Printf("Cloning %s into %s...\n", job.url, job.target)
+	if job.reference != "" {
+		fmt.Printf("Sharing objects with %s\n", job.reference)
+	}
+	cacheArgs, err := cacheCloneArgs(job, func(msg string) { fmt.Println(msg) })
+	if err != nil {
+		fmt.Printf("[WARN] Cloning without the clone cache: %v\n", err)
+	}
+	var output string
+	start := time.Now()
+	err = cloneWithFallback(job, func(repoURL string) error {
+		var cloneErr error
+		output, cloneErr = gitutil.CloneRepository(repoURL, job.target, verbose, append(job.cloneArgs, cacheArgs...)...)
+		return cloneErr
+	}, func(msg string) { fmt.Println(msg) })
+	if err != nil {
+		// CloneRepository already formats the error well, including output.
+		return hostKeyHint(err, job.parsed.Host)
+	}
+	job.elapsed = time.Since(start)
+	fmt.Printf("Successfully cloned %s\n", job.parsed.RepoName)
+	if verbose && len(output) > 0 && !strings.Contains(output, "Cloning into") { // Avoid redundant "Cloning into..."
+		fmt.Printf("Git clone output:\n%s\n", output)
+	}
+
+	// 5. Update the local state file
+	if err := registerClone(job, pinned); err != nil {
+		return err
+	}
+	if err := installHooks(job.target); err != nil {
+		fmt.Printf("[WARN] Failed to install git hooks: %v\n", err)
+	}
+	if err := setUpClone(job.target, job.parsed, os.Stdout, !job.noBootstrap); err != nil {
+		fmt.Printf("[WARN] Failed to set up the clone: %v\n", err)
+	}
+
+	err = repoState.Save(appConfig.StateFilePath)
+	if err != nil {
+		// At this point, the repo is cloned and state in memory is updated, but saving failed.
+		// This is not ideal. The user might need to manually check the state file.
+		return fmt.Errorf("repository cloned to %s and state updated in memory, but failed to save state to disk: %w. Please check %s", job.target, err, appConfig.StateFilePath)
+	}
+
+	if verbose {
+		fmt.Printf("Repository state updated and saved to %s\n", appConfig.StateFilePath)
+	}
+
+	fmt.Printf("Repository %s successfully cloned and tracked by fussy-git.\n", job.parsed.RepoName)
+	reportAction(cloneStatusCloned, job.target, job.rawURL)
+	return nil
+}
+
+// cloneJob describes a repository to be cloned and where it goes.
+type cloneJob struct {
+	rawURL         string // The URL as given by the user
+	url            string // The cleaned up URL that is cloned
+	parsed         *gitutil.ParsedGitURL
+	target         string
+	alreadyTracked bool               // The repository is already cloned at target and tracked; nothing to do
+	trackedURL     string             // The URL the repository is tracked with, if alreadyTracked
+	modulePath     string             // Go module path the repository was requested by, if any (see 'get')
+	pathOverride   string             // Recorded as the repository's PathOverride, if set
+	cloneArgs      []string           // Extra 'git clone' options, e.g. --reference <path>
+	reference      string             // Local repository objects are borrowed from, if any
+	options        state.CloneOptions // Options limiting the history, objects and checkout of the clone
+	elapsed        time.Duration      // How long 'git clone' took, recorded in the clone statistics
+	noBootstrap    bool               // Don't run the bootstrap commands on the clone, e.g. one a web page asked for
+}
+
+// prepareClone parses rawURL and determines where it will be cloned: explicitPath if set,
+// otherwise its conventional location. It fails if the target is taken by something else.
+// Targets in reserved (may be nil) are treated as taken; it is used to detect several
+// repositories of a batch mapping to the same directory.
+func prepareClone(rawURL, explicitPath string, reserved map[string]string) (*cloneJob, error) {
+	// Tolerate shortcuts like gh:owner/repo and URLs pasted from a browser, e.g. .../owner/repo/tree/main/pkg
+	repoURL := gitutil.SanitizeURL(expandShortcut(rawURL))
+
+	if verbose {
+		fmt.Printf("Attempting to clone: %s\n", repoURL)
+		fmt.Printf("Using FUSSY_GIT_HOME: %s\n", appConfig.FussyGitHome)
+	}
+
+	// 1. Parse the repository URL
+	parsedURL, err := parseRepoURL(repoURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository URL '%s': %w", repoURL, err)
+	}
+	if parsedURL.IsLocal() {
+		// git resolves relative paths from the working directory; the state needs the absolute path.
+		if !strings.HasPrefix(repoURL, "file://") {
+			repoURL = parsedURL.SourcePath
+		}
+		if _, err := os.Stat(parsedURL.SourcePath); err != nil {
+			return nil, fmt.Errorf("local repository %s can't be cloned: %w", parsedURL.SourcePath, err)
 		}
+	} else if isPrivateHost(parsedURL.Domain) {
+		// Private hosts are only accessed over SSH, whatever protocol is configured.
+		if !parsedURL.IsSSH {
+			sshURL, err := parsedURL.ToSSH()
+			if err != nil {
+				return nil, fmt.Errorf("'%s' is a private host and only SSH URLs can be used: %w", parsedURL.Domain, err)
+			}
+			if parsedURL, err = parseRepoURL(sshURL); err != nil {
+				return nil, fmt.Errorf("invalid repository URL '%s': %w", sshURL, err)
+			}
+			repoURL = sshURL
+		}
+	} else if protocolURL, err := applyProtocol(repoURL, parsedURL); err != nil {
+		return nil, fmt.Errorf("failed to convert '%s' to the configured protocol: %w", repoURL, err)
+	} else if protocolURL != repoURL {
+		if parsedURL, err = parseRepoURL(protocolURL); err != nil {
+			return nil, fmt.Errorf("invalid repository URL '%s': %w", protocolURL, err)
+		}
+		repoURL = protocolURL
+	}
+	if preferredURL := preferWorkingProtocol(repoURL, parsedURL); preferredURL != repoURL {
+		if parsedURL, err = parseRepoURL(preferredURL); err != nil {
+			return nil, fmt.Errorf("invalid repository URL '%s': %w", preferredURL, err)
+		}
+		repoURL = preferredURL
+	}
+	if verbose {
+		fmt.Printf("Parsed URL -> Domain: %s, Path: %s, User: %s, RepoName: %s\n",
+			parsedURL.Domain, parsedURL.Path, parsedURL.User, parsedURL.RepoName)
+	}
 
-		// 4. Clone the repository
-		fmt.Printf("Cloning %s into %s...\n", repoURL, targetPath)
-		output, err := gitutil.CloneRepository(repoURL, targetPath, verbose)
+	// 2. Determine the target directory
+	targetPath := conventionalRepoPath(parsedURL)
+	if explicitPath != "" {
+		targetPath, err = config.ExpandPath(explicitPath)
 		if err != nil {
-			// CloneRepository already formats the error well, including output.
-			return err // No need to wrap further, CloneRepository provides good context.
+			return nil, err
 		}
-		fmt.Printf("Successfully cloned %s\n", parsedURL.RepoName)
-		if verbose && len(output) > 0 && !strings.Contains(output, "Cloning into") { // Avoid redundant "Cloning into..."
-			fmt.Printf("Git clone output:\n%s\n", output)
+	}
+
+	if verbose {
+		fmt.Printf("Target clone directory: %s\n", targetPath)
+	}
+	job := &cloneJob{rawURL: rawURL, url: repoURL, parsed: parsedURL, target: targetPath}
+	if sshCommand := repoSettings(parsedURL).SSHCommand; sshCommand != "" {
+		// Used for the clone itself, and kept in the clone's config.
+		job.cloneArgs = append(job.cloneArgs, "--config", "core.sshCommand="+sshCommand)
+	}
+	job.cloneArgs = append(job.cloneArgs, sharedCloneArgs()...)
+
+	// The repository may already be tracked under another URL for it, e.g. its SSH URL when
+	// the HTTPS one is cloned, and at another location, e.g. pinned elsewhere.
+	if explicitPath == "" && !parsedURL.IsLocal() {
+		if idx := repoState.IndexOfNormalizedPath(filepath.ToSlash(parsedURL.GetNormalizedFSPath())); idx >= 0 {
+			job.target = repoState.Repositories[idx].Path
+			job.trackedURL = repoState.Repositories[idx].CurrentURL
+			job.alreadyTracked = true
+			return job, nil
 		}
+	}
 
-		// 5. Update the local state file
-		newRepoEntry := state.RepositoryEntry{
-			Name:         parsedURL.RepoName,
-			Path:         targetPath,
-			OriginalURL:  repoURL,
-			CurrentURL:   repoURL, // Initially, original and current are the same
-			Domain:       parsedURL.Domain,
-			NormalizedFS: parsedURL.GetNormalizedFSPath(),
-			// Timestamps (ClonedAt, LastChecked, LastModified) are set by AddRepository
+	// Check if the repository already exists at the target path or is already tracked
+	if existingEntry, found := repoState.FindRepositoryByPath(targetPath); found {
+		// Path exists and is tracked. Check if URL matches.
+		if existingEntry.OriginalURL == repoURL || existingEntry.CurrentURL == repoURL {
+			job.trackedURL = existingEntry.CurrentURL
+			job.alreadyTracked = true
+			return job, nil
 		}
-		err = repoState.AddRepository(newRepoEntry)
-		if err != nil {
-			// Attempt to clean up the cloned directory if adding to state fails.
-			// This is a best-effort cleanup.
-			fmt.Fprintf(os.Stderr, "Error: Failed to add repository to state: %v\n", err)
-			fmt.Fprintf(os.Stderr, "Attempting to clean up cloned directory: %s\n", targetPath)
-			if removeErr := os.RemoveAll(targetPath); removeErr != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to clean up directory %s: %v\n", targetPath, removeErr)
-			}
-			return fmt.Errorf("failed to add repository to state after cloning: %w", err)
+		// Path exists and is tracked, but with a different URL. This is a conflict.
+		if repoLayout := repoLayout(parsedURL); repoLayout != layout.Domain {
+			return nil, fmt.Errorf("directory %s is already tracked by fussy-git with a different URL (%s): the '%s' layout maps both repositories to the same directory. Use 'clone --path' to choose another location", targetPath, existingEntry.CurrentURL, repoLayout)
 		}
+		return nil, fmt.Errorf("directory %s is already tracked by fussy-git with a different URL (%s). Please remove or reorganize.", targetPath, existingEntry.CurrentURL)
+	}
 
-		err = repoState.Save(appConfig.StateFilePath)
-		if err != nil {
-			// At this point, the repo is cloned and state in memory is updated, but saving failed.
-			// This is not ideal. The user might need to manually check the state file.
-			return fmt.Errorf("repository cloned to %s and state updated in memory, but failed to save state to disk: %w. Please check %s", targetPath, err, appConfig.StateFilePath)
+	if otherURL, taken := reserved[targetPath]; taken {
+		return nil, fmt.Errorf("directory %s is also the target of %s in this batch", targetPath, otherURL)
+	}
+
+	// Path is not tracked by fussy-git. Check if it exists on disk.
+	if _, statErr := os.Stat(targetPath); !os.IsNotExist(statErr) {
+		// Directory exists but is not in our state file.
+		// It could be an untracked git repo or a non-git directory.
+		// For now, we'll error out to prevent accidental overwrites or confusion.
+		// A more advanced version could offer to adopt/overwrite if it's a git repo.
+		return nil, fmt.Errorf("directory %s already exists on disk but is not tracked by fussy-git. Please remove it or use 'fussy-git add %s' if it's a valid git repository you wish to track from its current location", targetPath, targetPath)
+	}
+
+	// 3. Create the parent directory if it doesn't exist
+	parentDir := filepath.Dir(targetPath)
+	if err := makeRepoDirs(parentDir); err != nil {
+		return nil, fmt.Errorf("failed to create parent directory %s: %w", parentDir, err)
+	}
+	if verbose {
+		fmt.Printf("Ensured parent directory exists: %s\n", parentDir)
+	}
+	return job, nil
+}
+
+// validateCloneFlags checks the flags that limit what clones fetch and check out.
+func validateCloneFlags() error {
+	if cloneDepth < 0 {
+		return usageError("invalid --depth %d: must be positive", cloneDepth)
+	}
+	if cloneDepth > 0 && cloneShallowSince != "" {
+		return usageError("--depth and --shallow-since can't be used together")
+	}
+	if err := config.ValidateCloneFilter(cloneFilter); err != nil {
+		return usageError("invalid --filter: %v", err)
+	}
+	if cloneFull && (cloneDepth > 0 || cloneShallowSince != "" || cloneFilter != "" || len(cloneSparse) > 0) {
+		return usageError("--full can't be combined with --depth, --shallow-since, --filter or --sparse")
+	}
+	return nil
+}
+
+// applyCloneOptions adds the 'git clone' options requested with flags or configured for the
+// repository to job.
+func applyCloneOptions(job *cloneJob) error {
+	if err := setCloneReference(job); err != nil {
+		return err
+	}
+	job.options = cloneOptions(job.parsed)
+	if job.options.Depth > 0 {
+		job.cloneArgs = append(job.cloneArgs, "--depth", strconv.Itoa(job.options.Depth))
+	}
+	if job.options.ShallowSince != "" {
+		job.cloneArgs = append(job.cloneArgs, "--shallow-since", job.options.ShallowSince)
+	}
+	if job.options.Filter != "" {
+		job.cloneArgs = append(job.cloneArgs, "--filter", job.options.Filter)
+	}
+	if len(job.options.Sparse) > 0 {
+		job.cloneArgs = append(job.cloneArgs, "--sparse")
+	}
+	return nil
+}
+
+// cloneOptions returns the options limiting the clone of a repository: those given with flags,
+// otherwise the clone_* settings configured for it. With --full, there are none.
+func cloneOptions(parsedURL *gitutil.ParsedGitURL) state.CloneOptions {
+	if cloneFull {
+		return state.CloneOptions{}
+	}
+	settings := repoSettings(parsedURL)
+	options := state.CloneOptions{
+		Depth:        settings.CloneDepth,
+		ShallowSince: settings.CloneShallowSince,
+		Filter:       settings.CloneFilter,
+		Sparse:       settings.CloneSparse,
+	}
+	if cloneDepth > 0 || cloneShallowSince != "" {
+		options.Depth, options.ShallowSince = cloneDepth, cloneShallowSince
+	}
+	if cloneFilter != "" {
+		options.Filter = cloneFilter
+	}
+	if len(cloneSparse) > 0 {
+		options.Sparse = cloneSparse
+	}
+	return options
+}
+
+// registerClone adds a freshly cloned repository to the in-memory state. If that fails,
+// the clone is removed again. The state is not saved.
+func registerClone(job *cloneJob, pinned bool) error {
+	if err := shareWorkTree(job.target); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to make %s group-writable: %v\n", job.target, err)
+	}
+	maintenance := startGitMaintenance(job.target)
+	var options *state.CloneOptions
+	if !job.options.IsZero() {
+		options = &job.options
+	}
+	if len(job.options.Sparse) > 0 {
+		// 'git clone --sparse' only checks out the top-level files.
+		if err := gitutil.SetSparseCheckout(job.target, job.options.Sparse, verbose); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to check out %s in %s: %v\n", strings.Join(job.options.Sparse, ", "), job.target, err)
 		}
+	}
+	newRepoEntry := state.RepositoryEntry{
+		Name:         job.parsed.RepoName,
+		Path:         job.target,
+		ResolvedPath: resolvedPath(job.target),
+		OriginalURL:  job.url,
+		CurrentURL:   job.url, // Initially, original and current are the same
+		Domain:       job.parsed.Domain,
+		NormalizedFS: job.parsed.GetNormalizedFSPath(),
+		PathOverride: job.pathOverride,
+		Pinned:       pinned,
+		ModulePath:   job.modulePath,
+		Shallow:      gitutil.IsShallow(job.target),
+		LFS:          gitutil.UsesLFS(job.target),
+		License:      detectLicense(job.target),
+		Description:  readDescription(job.target),
+		Maintenance:  maintenance,
+		CloneStats:   measureClone(job.target, job.elapsed),
+		CloneOptions: options,
+		// Timestamps (ClonedAt, LastChecked, LastModified) are set by AddRepository
+	}
+	if err := repoState.AddRepository(newRepoEntry); err != nil {
+		// Attempt to clean up the cloned directory if adding to state fails.
+		// This is a best-effort cleanup.
+		fmt.Fprintf(os.Stderr, "Error: Failed to add repository to state: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Attempting to clean up cloned directory: %s\n", job.target)
+		if removeErr := os.RemoveAll(job.target); removeErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to clean up directory %s: %v\n", job.target, removeErr)
+		}
+		return fmt.Errorf("failed to add repository to state after cloning: %w", err)
+	}
+	return nil
+}
 
+// measureClone returns the statistics of a fresh clone at path that took elapsed, or nil if
+// its objects can't be counted.
+func measureClone(path string, elapsed time.Duration) *state.CloneStats {
+	objects, size, err := gitutil.CountObjects(path)
+	if err != nil {
 		if verbose {
-			fmt.Printf("Repository state updated and saved to %s\n", appConfig.StateFilePath)
+			fmt.Printf("Could not count the objects of %s: %v\n", path, err)
 		}
-
-		fmt.Printf("Repository %s successfully cloned and tracked by fussy-git.\n", parsedURL.RepoName)
 		return nil
-	},
+	}
+	lfsSize, _, _ := gitutil.LFSObjectsSize(path)
+	return &state.CloneStats{Duration: elapsed.Seconds(), Objects: objects, Size: size, LFSSize: lfsSize}
 }
 
 func init() {
 	// rootCmd.AddCommand(cloneCmd) // This is done in cmd/root.go's init()
+	cloneCmd.Flags().StringVar(&cloneTargetPath, "path", "", "Clone into this directory instead of the conventional location (the repository is pinned there)")
+	cloneCmd.Flags().StringVar(&cloneBatchFile, "batch", "", "Clone all repository URLs listed in this file, one per line ('-' reads from stdin)")
+	cloneCmd.Flags().IntVarP(&cloneJobs, "jobs", "j", 0, "Number of repositories to clone in parallel when cloning several (default: the max_network_jobs setting, 4)")
+	cloneCmd.Flags().StringVarP(&cloneOutput, "output", "o", "text", "Format of the summary when cloning several repositories: 'text' or 'json'")
+	cloneCmd.Flags().StringVar(&cloneReference, "reference", "", "Share objects with this local repository (tracked repository or path) via git alternates")
+	cloneCmd.Flags().IntVar(&cloneDepth, "depth", 0, "Create a shallow clone with only the last N commits of the default branch (see 'fussy-git unshallow')")
+	cloneCmd.Flags().StringVar(&cloneShallowSince, "shallow-since", "", "Create a shallow clone with the history of the default branch since this date, e.g. 2024-01-01 (see 'fussy-git unshallow')")
+	cloneCmd.Flags().StringVar(&cloneFilter, "filter", "", "Create a partial clone that fetches the filtered objects on demand, e.g. blob:none or tree:0 (see 'fussy-git densify')")
+	cloneCmd.Flags().StringSliceVar(&cloneSparse, "sparse", nil, "Check out only these directories, comma-separated (see 'fussy-git densify')")
+	cloneCmd.Flags().BoolVar(&cloneFull, "full", false, "Make a complete clone, ignoring the clone_depth, clone_shallow_since, clone_filter and clone_sparse settings")
+	cloneCmd.Flags().BoolVar(&cloneNoCache, "no-cache", false, "Don't use the clone cache, even if 'clone_cache' is enabled")
+	cloneCmd.Flags().StringVar(&cloneLocalName, "name", "", "For a repository cloned from a local path or file:// URL, the directory name below local_dir (default: <name>-<hash of the path>)")
+	cloneCmd.Flags().BoolVar(&clonePrintPath, "print-path", false, "Print only the repository's directory on stdout, whether it was cloned or already tracked (messages go to stderr)")
+	addQuietFlag(cloneCmd)
+	cloneCmd.Flags().BoolVar(&cloneAutoReference, "auto-reference", false, "Share objects with a tracked fork or upstream of the repository (same host and name), if there is one")
 }
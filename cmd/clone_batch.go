@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/manifest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Statuses of a repository in the batch clone summary.
+const (
+	cloneStatusCloned  = "cloned"
+	cloneStatusSkipped = "skipped"
+	cloneStatusFailed  = "failed"
+)
+
+// batchCloneResult is the outcome of cloning one repository of a batch.
+type batchCloneResult struct {
+	URL      string  `json:"url"`
+	Path     string  `json:"path,omitempty"`
+	Status   string  `json:"status"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration_seconds,omitempty"`
+}
+
+// batchCloneSummary is the machine-readable summary printed by 'clone --output json'.
+type batchCloneSummary struct {
+	Total        int                `json:"total"`
+	Cloned       int                `json:"cloned"`
+	Skipped      int                `json:"skipped"`
+	Failed       int                `json:"failed"`
+	Repositories []batchCloneResult `json:"repositories"`
+}
+
+// runBatchClone clones all URLs given as arguments or listed in the --batch file in parallel.
+func runBatchClone(args []string) error {
+	if cloneTargetPath != "" {
+		return usageError("--path can't be used when cloning several repositories")
+	}
+	if cloneOutput != "text" && cloneOutput != "json" {
+		return usageError("invalid --output value '%s': must be 'text' or 'json'", cloneOutput)
+	}
+	if cloneJobs < 0 {
+		return usageError("--jobs must not be negative")
+	}
+
+	urls := append([]string{}, args...)
+	if cloneBatchFile != "" {
+		fromFile, err := readBatchFile(cloneBatchFile)
+		if err != nil {
+			return err
+		}
+		urls = append(urls, fromFile...)
+	}
+	if len(urls) == 0 {
+		return usageError("no repository URLs to clone")
+	}
+
+	// With JSON output, stdout is reserved for the summary.
+	progressOut := os.Stdout
+	if cloneOutput == "json" {
+		progressOut = os.Stderr
+	}
+	board := newProgressBoard(progressOut, "Cloning", len(urls))
+	started := time.Now()
+
+	// Validate all URLs and reserve their targets up front, so that two URLs mapping
+	// to the same directory are caught before anything is cloned.
+	results := make([]batchCloneResult, len(urls))
+	jobs := make([]*cloneJob, len(urls))
+	reserved := map[string]string{}
+	for i, rawURL := range urls {
+		results[i] = batchCloneResult{URL: rawURL}
+		job, err := prepareClone(rawURL, "", reserved)
+		if err == nil && !job.alreadyTracked {
+			err = applyCloneOptions(job)
+		}
+		switch {
+		case err != nil:
+			results[i].Status, results[i].Error = cloneStatusFailed, err.Error()
+			board.Done(rawURL, err)
+		case job.alreadyTracked:
+			results[i].Status, results[i].Path = cloneStatusSkipped, job.target
+			board.Skip(rawURL, fmt.Sprintf("already cloned at %s", job.target))
+		default:
+			results[i].Path = job.target
+			reserved[job.target] = rawURL
+			jobs[i] = job
+		}
+	}
+
+	// Without a terminal, ssh can't ask to accept a new host key, so clones from hosts whose
+	// key isn't known would all fail; report them up front instead.
+	unknownHosts := checkHostKeys(jobs)
+	for i, job := range jobs {
+		if job == nil || !unknownHosts[job.parsed.Host] || fallbackAllowed(job.parsed) {
+			continue
+		}
+		err := fmt.Errorf("the SSH host key of %s is not trusted yet; add it with 'fussy-git auth trust-host %s'", job.parsed.Host, job.parsed.Host)
+		results[i].Status, results[i].Error = cloneStatusFailed, err.Error()
+		board.Done(job.rawURL, err)
+		jobs[i] = nil
+	}
+
+	workers := cloneJobs
+	if workers == 0 {
+		workers = appConfig.MaxNetworkJobs
+	}
+	limiter := newNetworkLimiter(workers)
+
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				job := jobs[i]
+				label := job.rawURL
+				limiter.Acquire()
+				board.Start(label)
+				start := time.Now()
+				cloneArgs := job.cloneArgs
+				cacheArgs, err := cacheCloneArgs(job, func(msg string) { board.Update(label, msg) })
+				if err == nil {
+					cloneArgs = append(cloneArgs, cacheArgs...)
+				}
+				err = cloneWithFallback(job, func(repoURL string) error {
+					return gitutil.CloneRepositoryWithProgress(repoURL, job.target, func(line string) {
+						board.Update(label, line)
+					}, cloneArgs...)
+				}, func(msg string) { board.Update(label, msg) })
+				err = hostKeyHint(err, job.parsed.Host)
+				var transferred int64
+				if err == nil && limiter.Limited() {
+					transferred = gitDirSize(job.target)
+				}
+				limiter.Release(transferred)
+				if err == nil {
+					job.elapsed = time.Since(start)
+					err = registerClone(job, false)
+				} else {
+					removeEmptyParents(filepath.Dir(job.target), appConfig.FussyGitHome)
+				}
+				// The repository is cloned and tracked even if setting it up fails; that's only reported.
+				var setupErr error
+				if err == nil {
+					board.Update(label, "setting up")
+					if setupErr = installHooks(job.target); setupErr == nil {
+						setupErr = setUpClone(job.target, job.parsed, nil, true)
+					}
+				}
+				results[i].Duration = time.Since(start).Seconds()
+				switch {
+				case err != nil:
+					results[i].Status, results[i].Error = cloneStatusFailed, err.Error()
+				case setupErr != nil:
+					results[i].Status, results[i].Error = cloneStatusCloned, "set up failed: "+setupErr.Error()
+				default:
+					results[i].Status = cloneStatusCloned
+				}
+				board.Done(label, err)
+			}
+		}()
+	}
+	for i, job := range jobs {
+		if job != nil {
+			queue <- i
+		}
+	}
+	close(queue)
+	wg.Wait()
+	board.Close()
+
+	summary := batchCloneSummary{Total: len(results), Repositories: results}
+	for _, r := range results {
+		switch r.Status {
+		case cloneStatusCloned:
+			summary.Cloned++
+		case cloneStatusSkipped:
+			summary.Skipped++
+		case cloneStatusFailed:
+			summary.Failed++
+		}
+	}
+
+	if summary.Cloned > 0 {
+		if err := repoState.Save(appConfig.StateFilePath); err != nil {
+			return fmt.Errorf("repositories were cloned, but failed to save state to disk: %w. Please check %s", err, appConfig.StateFilePath)
+		}
+	}
+
+	if cloneOutput == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summary); err != nil {
+			return fmt.Errorf("failed to write clone summary: %w", err)
+		}
+	} else {
+		fmt.Printf("\nClone summary:\n")
+		fmt.Printf("  Repositories: %d\n", summary.Total)
+		fmt.Printf("  Cloned:       %d\n", summary.Cloned)
+		fmt.Printf("  Skipped:      %d\n", summary.Skipped)
+		fmt.Printf("  Failed:       %d\n", summary.Failed)
+		for _, r := range results {
+			if r.Status == cloneStatusCloned && r.Error != "" {
+				fmt.Printf("[WARN] %s: %s\n", r.URL, r.Error)
+			}
+		}
+	}
+
+	var failed []string
+	for _, r := range results {
+		switch r.Status {
+		case cloneStatusCloned:
+			reportAction(cloneStatusCloned, r.Path, r.URL)
+		case cloneStatusFailed:
+			failed = append(failed, r.URL)
+			reportAction(cloneStatusFailed, r.URL, r.Error)
+		}
+	}
+	notifyFinished("clone", time.Since(started),
+		fmt.Sprintf("%d of %d repositories cloned, %d skipped, %d failed", summary.Cloned, summary.Total, summary.Skipped, summary.Failed), failed)
+
+	if summary.Failed > 0 {
+		return bulkFailure(summary.Failed, summary.Total, fmt.Errorf("%d of %d repositories could not be cloned", summary.Failed, summary.Total))
+	}
+	return nil
+}
+
+// readBatchFile reads the repository URLs listed in the manifest at path, or on stdin if path
+// is "-". Branch and commit pins are ignored; 'fussy-git sync --checkout' applies them.
+func readBatchFile(path string) ([]string, error) {
+	entries, err := manifest.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, 0, len(entries))
+	for _, e := range entries {
+		urls = append(urls, e.URL)
+	}
+	return urls, nil
+}
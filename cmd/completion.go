@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/config"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	completionShell string
+	completionNoFcd bool
+)
+
+// Lines delimiting the block 'completion install' adds to shell startup files, so it can be
+// replaced when the command runs again.
+const (
+	rcBlockStart = "# >>> fussy-git >>>"
+	rcBlockEnd   = "# <<< fussy-git <<<"
+)
+
+// completionInstallCmd represents the completion install command. It is added to cobra's
+// default completion command in addCompletionInstall.
+var completionInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Installs shell completion and the fcd function for your shell.",
+	Long: `Detects the shell you are using (bash, zsh or fish) and installs the completion script for
+fussy-git where that shell picks it up. Completion includes the names of tracked repositories
+for commands that take one (e.g. 'fussy-git info <TAB>'); they are looked up when you press
+TAB, so new clones complete without installing again.
+
+The 'fcd' function is installed as well: 'fcd <repo>' changes to the directory of a tracked
+repository and completes repository names. Use --no-fcd to skip it.
+
+Where things go:
+  bash  ~/.local/share/bash-completion/completions/fussy-git (loaded by bash-completion),
+        fcd in ~/.fussy-git/shell/fcd.bash, sourced from ~/.bashrc
+  zsh   ~/.fussy-git/shell/fussy-git.zsh and fcd.zsh, sourced from ~/.zshrc
+  fish  ~/.config/fish/completions/ and ~/.config/fish/functions/
+Lines added to a startup file are marked with '# >>> fussy-git >>>' and replaced, not
+duplicated, when the command runs again. Open a new shell afterwards.
+
+The shell is detected from the process that started fussy-git and falls back to $SHELL.
+Use --shell to choose it explicitly.
+
+Examples:
+  fussy-git completion install
+  fussy-git completion install --shell zsh --no-fcd`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		shell := completionShell
+		if shell == "" {
+			if shell = detectShell(); shell == "" {
+				return usageError("could not detect your shell; use --shell bash, zsh or fish")
+			}
+			fmt.Printf("Detected shell: %s\n", shell)
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to determine the home directory: %w", err)
+		}
+		shellDir := filepath.Join(home, config.ConfigDirNameForHelp, "shell")
+
+		var script bytes.Buffer
+		switch shell {
+		case "bash":
+			if err := rootCmd.GenBashCompletionV2(&script, true); err != nil {
+				return err
+			}
+			dataDir := os.Getenv("XDG_DATA_HOME")
+			if dataDir == "" {
+				dataDir = filepath.Join(home, ".local", "share")
+			}
+			completionDir := filepath.Join(dataDir, "bash-completion", "completions")
+			if dir := os.Getenv("BASH_COMPLETION_USER_DIR"); dir != "" {
+				completionDir = filepath.Join(dir, "completions")
+			}
+			if err := writeShellFile(filepath.Join(completionDir, "fussy-git"), script.String()); err != nil {
+				return err
+			}
+			if !completionNoFcd {
+				fcdPath := filepath.Join(shellDir, "fcd.bash")
+				if err := writeShellFile(fcdPath, fcdBash); err != nil {
+					return err
+				}
+				if err := updateRCFile(filepath.Join(home, ".bashrc"), fmt.Sprintf("[ -f %q ] && . %q", fcdPath, fcdPath)); err != nil {
+					return err
+				}
+			}
+		case "zsh":
+			if err := rootCmd.GenZshCompletion(&script); err != nil {
+				return err
+			}
+			scriptPath := filepath.Join(shellDir, "fussy-git.zsh")
+			if err := writeShellFile(scriptPath, script.String()); err != nil {
+				return err
+			}
+			lines := []string{
+				"(( $+functions[compdef] )) || { autoload -Uz compinit && compinit }",
+				fmt.Sprintf("source %q", scriptPath),
+			}
+			if !completionNoFcd {
+				fcdPath := filepath.Join(shellDir, "fcd.zsh")
+				if err := writeShellFile(fcdPath, fcdZsh); err != nil {
+					return err
+				}
+				lines = append(lines, fmt.Sprintf("source %q", fcdPath))
+			}
+			zdotdir := os.Getenv("ZDOTDIR")
+			if zdotdir == "" {
+				zdotdir = home
+			}
+			if err := updateRCFile(filepath.Join(zdotdir, ".zshrc"), lines...); err != nil {
+				return err
+			}
+		case "fish":
+			if err := rootCmd.GenFishCompletion(&script, true); err != nil {
+				return err
+			}
+			configDir := os.Getenv("XDG_CONFIG_HOME")
+			if configDir == "" {
+				configDir = filepath.Join(home, ".config")
+			}
+			fishDir := filepath.Join(configDir, "fish")
+			if err := writeShellFile(filepath.Join(fishDir, "completions", "fussy-git.fish"), script.String()); err != nil {
+				return err
+			}
+			if !completionNoFcd {
+				if err := writeShellFile(filepath.Join(fishDir, "functions", "fcd.fish"), fcdFishFunction); err != nil {
+					return err
+				}
+				if err := writeShellFile(filepath.Join(fishDir, "completions", "fcd.fish"), fcdFishCompletion); err != nil {
+					return err
+				}
+			}
+		default:
+			return usageError("shell '%s' is not supported by 'completion install'; supported are bash, zsh and fish (see 'fussy-git completion --help' for others)", shell)
+		}
+
+		fmt.Println("\nOpen a new shell (or restart your current one) to use the completion.")
+		if shell == "bash" {
+			fmt.Println("Completion for bash requires the bash-completion package.")
+		}
+		return nil
+	},
+}
+
+// addCompletionInstall adds 'install' to the completion command cobra generates.
+func addCompletionInstall() {
+	rootCmd.InitDefaultCompletionCmd()
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == "completion" {
+			c.AddCommand(completionInstallCmd)
+		}
+	}
+}
+
+// detectShell returns the name of the shell fussy-git was started from: the parent process if
+// it is a known shell (only determined on Linux), otherwise the basename of $SHELL.
+func detectShell() string {
+	if runtime.GOOS == "linux" {
+		if comm, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", os.Getppid())); err == nil {
+			switch name := strings.TrimPrefix(strings.TrimSpace(string(comm)), "-"); name {
+			case "bash", "zsh", "fish":
+				return name
+			}
+		}
+	}
+	return filepath.Base(os.Getenv("SHELL"))
+}
+
+// writeShellFile writes a generated shell script, creating its directory as needed.
+func writeShellFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Printf("  Wrote %s\n", path)
+	return nil
+}
+
+// updateRCFile makes the shell startup file at path contain lines in a block marked with
+// rcBlockStart and rcBlockEnd. An existing block is replaced; otherwise the block is appended.
+func updateRCFile(path string, lines ...string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	block := rcBlockStart + "\n" + strings.Join(lines, "\n") + "\n" + rcBlockEnd + "\n"
+
+	content := string(data)
+	start := strings.Index(content, rcBlockStart)
+	end := strings.Index(content, rcBlockEnd)
+	if start >= 0 && end > start {
+		rest := strings.TrimPrefix(content[end+len(rcBlockEnd):], "\n")
+		content = content[:start] + block + rest
+	} else {
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		if content != "" {
+			content += "\n"
+		}
+		content += block
+	}
+	if content == string(data) {
+		fmt.Printf("  %s is up to date\n", path)
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to update %s: %w", path, err)
+	}
+	fmt.Printf("  Updated %s\n", path)
+	return nil
+}
+
+// completeRepository completes the first argument of commands taking a repository reference:
+// the names of tracked repositories (if unambiguous) and their normalized paths, with the
+// repository's directory as description. Later arguments complete files.
+func completeRepository(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || repoState == nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	names := make(map[string]int)
+	for _, repo := range repoState.Repositories {
+		names[repo.Name]++
+	}
+	var completions []string
+	for _, repo := range repoState.Repositories {
+		if names[repo.Name] == 1 && strings.HasPrefix(repo.Name, toComplete) {
+			completions = append(completions, repo.Name+"\t"+repo.Path)
+		}
+		// Normalized paths are only offered once typing one has started, so a bare TAB
+		// lists every repository once.
+		if toComplete != "" && repo.NormalizedFS != "" && strings.HasPrefix(repo.NormalizedFS, toComplete) {
+			completions = append(completions, repo.NormalizedFS+"\t"+repo.Path)
+		}
+	}
+	sort.Strings(completions)
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// The fcd function for each shell. Repository names are completed through fussy-git's own
+// completion of 'which'.
+const (
+	fcdBash = `# fcd <repo>: change to the directory of a repository tracked by fussy-git.
+fcd() {
+  local dir
+  dir=$(command fussy-git which "$@") && cd "$dir"
+}
+
+_fcd() {
+  local cur=${COMP_WORDS[COMP_CWORD]}
+  local IFS=$'\n'
+  COMPREPLY=($(command fussy-git __complete which "$cur" 2>/dev/null | grep -v '^:' | cut -f1))
+}
+complete -F _fcd fcd
+`
+	fcdZsh = `# fcd <repo>: change to the directory of a repository tracked by fussy-git.
+fcd() {
+  local dir
+  dir=$(command fussy-git which "$@") && cd "$dir"
+}
+
+_fcd() {
+  local -a repos
+  repos=(${(f)"$(command fussy-git __complete which "${words[CURRENT]}" 2>/dev/null | grep -v '^:' | sed 's/\t/:/')"})
+  _describe 'repository' repos
+}
+compdef _fcd fcd
+`
+	fcdFishFunction = `# fcd <repo>: change to the directory of a repository tracked by fussy-git.
+function fcd --description 'Change to the directory of a repository tracked by fussy-git'
+    set -l dir (command fussy-git which $argv); and cd $dir
+end
+`
+	fcdFishCompletion = `complete -c fcd -f -a '(command fussy-git __complete which (commandline -ct) 2>/dev/null | string match -v ":*")'
+`
+)
+
+func init() {
+	completionInstallCmd.Flags().StringVar(&completionShell, "shell", "", "Shell to install completion for: bash, zsh or fish (default: detected)")
+	completionInstallCmd.Flags().BoolVar(&completionNoFcd, "no-fcd", false, "Don't install the fcd function")
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Shows configuration that applies to individual repositories.",
+	Long: `Commands for the layered configuration. Some settings can be set at the top level of the
+config file and overridden per domain, per owner and per repository, from least to most specific:
+
+  protocol: ssh                 # clone with 'ssh' or 'https' URLs (private_hosts always use SSH)
+  layout: domain                # directory layout: domain, owner or flat
+  bootstrap:                    # shell commands run in a repository after it is cloned
+    - make setup
+  user_name: Jane Doe           # git user.name set in cloned and added repositories
+  user_email: jane@example.com  # git user.email set in cloned and added repositories
+  ssh_command: ssh -i ~/.ssh/id_work  # git core.sshCommand, used for cloning and set in the repository
+  signing_key: ~/.ssh/id_work.pub     # git user.signingKey; commits must be signed where it is set
+  signing_format: ssh           # git gpg.format of signing_key: openpgp (default), ssh or x509
+  env:                          # environment of bootstrap commands and 'fussy-git exec'
+    - GOFLAGS=-mod=mod
+  envrc_template: ~/.config/fussy-git/envrc  # .envrc template for new clones (with direnv: true)
+  clone_depth: 1                # new clones fetch only the last N commits (or clone_shallow_since: 2024-01-01)
+  clone_filter: blob:none       # new clones are partial clones fetching objects on demand
+  clone_sparse: [services/api]  # new clones check out only these directories
+
+  domains:
+    gitlab.com:
+      protocol: https
+    git.corp.example.com:       # giant monorepos
+      clone_filter: blob:none
+      clone_shallow_since: 2024-01-01
+  owners:
+    github.com/work-org:
+      user_email: jane@work.example.com
+      ssh_command: ssh -i ~/.ssh/id_work
+  repos:
+    github.com/work-org/api:
+      bootstrap: [npm ci]
+      env: [NODE_OPTIONS=--max-old-space-size=4096]
+
+A value set at a more specific level replaces the less specific one (bootstrap lists are replaced,
+not merged, and clone_depth and clone_shallow_since replace each other). Environment variables
+are the exception: the variables of all levels are combined, and a variable set at several
+levels takes the most specific value. Repository metadata named
+env.<NAME> (see 'fussy-git meta') sets a variable for that repository only, overriding the config
+file. Variables whose name looks like a secret (token, password, secret) are refused.
+
+With 'direnv: true', every new clone gets a .envrc rendered from its envrc_template, or else from
+the template configured for the repository's language in 'envrc_templates' (go, node, python,
+rust, ruby or java), and 'direnv allow' is run on it. A .envrc that is part of the repository is
+never replaced. Templates use Go's text/template syntax with the fields .Name, .Path, .URL,
+.Domain, .Owner, .NormalizedPath, .Language and .Env, and a quote function for shell quoting:
+
+  direnv: true
+  envrc_templates:
+    go: ~/.config/fussy-git/envrc-go   # e.g. "{{range .Env}}export {{quote .}}\n{{end}}layout go"
+
+Use 'fussy-git config show <repo>' to see the effective values and where they come from.`,
+}
+
+// configShowCmd represents the config show command
+var configShowCmd = &cobra.Command{
+	Use:   "show <repo|url>",
+	Short: "Shows the effective per-repository settings and where each comes from.",
+	Long: `Shows the effective value of every setting that can be overridden per domain, owner or
+repository (see 'fussy-git help config'), and the level it comes from: global, domain, owner,
+repo, or default if it isn't set anywhere.
+
+<repo> can be a tracked repository (its path, normalized path, URL, or unambiguous name), a
+repository URL that isn't cloned yet, or a normalized path such as github.com/spf13/cobra.
+
+Examples:
+  fussy-git config show github.com/spf13/cobra
+  fussy-git config show git@github.com:work-org/api.git`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRepository,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		normalizedPath, err := settingsTarget(args[0])
+		if err != nil {
+			return err
+		}
+		resolved := appConfig.Layers.For(normalizedPath)
+		layoutName := resolved.Layout
+		if layoutName == "" {
+			layoutName = appConfig.Layout
+		}
+
+		fmt.Printf("Effective settings for %s:\n\n", normalizedPath)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+		fmt.Fprintln(w, "-------\t-----\t------")
+		for _, s := range []struct{ key, value string }{
+			{"protocol", resolved.Protocol},
+			{"layout", layoutName},
+			{"bootstrap", strings.Join(resolved.Bootstrap, "; ")},
+			{"user_name", resolved.UserName},
+			{"user_email", resolved.UserEmail},
+			{"ssh_command", resolved.SSHCommand},
+			{"signing_key", resolved.SigningKey},
+			{"signing_format", resolved.SigningFormat},
+			{"env", strings.Join(resolved.Env, " ")},
+			{"envrc_template", resolved.EnvrcTemplate},
+			{"clone_depth", cloneDepthSetting(resolved.CloneDepth)},
+			{"clone_shallow_since", resolved.CloneShallowSince},
+			{"clone_filter", resolved.CloneFilter},
+			{"clone_sparse", strings.Join(resolved.CloneSparse, ", ")},
+		} {
+			value := s.value
+			if value == "" {
+				value = "(not set)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", s.key, value, resolved.Sources[s.key])
+		}
+		return w.Flush()
+	},
+}
+
+// cloneDepthSetting formats the clone_depth setting for 'config show', "" if it isn't set.
+func cloneDepthSetting(depth int) string {
+	if depth == 0 {
+		return ""
+	}
+	return strconv.Itoa(depth)
+}
+
+// settingsTarget returns the normalized path (e.g. github.com/spf13/cobra) whose settings
+// 'config show' displays for ref.
+func settingsTarget(ref string) (string, error) {
+	idx, err := lookupRepository(ref)
+	if err == nil {
+		return filepath.ToSlash(repoState.Repositories[idx].NormalizedFS), nil
+	}
+	if !errors.Is(err, errNotTracked) {
+		return "", err
+	}
+	if looksLikeURL(ref) {
+		parsedURL, err := parseRepoURL(gitutil.SanitizeURL(expandShortcut(ref)))
+		if err != nil {
+			return "", fmt.Errorf("invalid repository URL '%s': %w", ref, err)
+		}
+		return filepath.ToSlash(parsedURL.GetNormalizedFSPath()), nil
+	}
+	if !strings.Contains(strings.Trim(ref, "/"), "/") {
+		return "", fmt.Errorf("'%s' is not tracked; pass a URL or a path like <domain>/<owner>/<repo>", ref)
+	}
+	return strings.Trim(ref, "/"), nil
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+}
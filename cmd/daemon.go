@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/audit"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	intervalDaemon time.Duration
+	jitterDaemon   time.Duration
+	notifyDaemon   bool
+	socketDaemon   string
+	noSocketDaemon bool
+)
+
+// daemonSnapshot records what a daemon cycle observed, so the next cycle can report what changed.
+type daemonSnapshot struct {
+	behind     map[string]int  // Repository path -> commits behind upstream
+	issueRepos map[string]bool // Paths of the repositories doctor reported issues for
+}
+
+// daemonCmd represents the daemon command
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Runs maintenance and metadata sync periodically in the foreground.",
+	Long: `Runs in the foreground and periodically keeps all tracked repositories in shape.
+Every cycle it:
+
+1. Syncs metadata: reads each repository's live 'origin' URL, updates the stored URL
+   if it changed, and records when it was last checked.
+2. Runs the same steps as 'fussy-git maintenance' (state backup, fetch, gc, pruning,
+   stats history, doctor).
+3. Appends a summary of the cycle to the audit log next to the state file.
+4. Sends a notification when repositories developed issues since the previous cycle, or
+   fell 'notify_behind_threshold' (default 50) or more commits behind their upstream.
+   Notifications go to the desktop with --notify or 'notify_desktop: true', and to
+   'notify_webhook' if configured (see 'fussy-git maintenance --help').
+
+Cycles run every --interval, delayed by a random amount up to --jitter so that several
+machines sharing a remote don't all fetch at the same moment. The first cycle runs
+immediately and establishes the baseline for notifications.
+
+While it runs, the daemon serves a gRPC interface on a unix socket (daemon.sock next to the
+state file, or --socket), so integrations such as status bar widgets and editor plugins can
+query the tracked repositories without running fussy-git for each query. Its methods are List
+and Search, which answer like 'fussy-git list' and 'fussy-git search', Status, which reports the
+branch, HEAD and working tree of a repository, and Clone, which runs 'fussy-git clone'. The
+service is defined in pkg/daemonpb/daemon.proto. Only the user running the daemon can connect
+to the socket; --no-socket disables it.
+
+Stop the daemon with Ctrl+C or SIGTERM; a running cycle is finished first.
+To run it in the background, use your service manager, e.g. a systemd user unit.
+
+Examples:
+  fussy-git daemon
+  fussy-git daemon --interval 1h --jitter 5m --notify
+  fussy-git daemon --socket /run/user/1000/fussy-git.sock`,
+	Annotations: mutates,
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if intervalDaemon < time.Minute {
+			return fmt.Errorf("--interval must be at least 1m, got %s", intervalDaemon)
+		}
+		if jitterDaemon < 0 {
+			return fmt.Errorf("--jitter must not be negative, got %s", jitterDaemon)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			// Restore the default behavior so a second Ctrl+C terminates immediately.
+			stop()
+		}()
+
+		fmt.Printf("fussy-git daemon started (interval %s, jitter up to %s)\n", intervalDaemon, jitterDaemon)
+		if !noSocketDaemon {
+			socket := socketDaemon
+			if socket == "" {
+				socket = daemonSocketPath()
+			}
+			stopServer, err := serveDaemonSocket(socket)
+			if err != nil {
+				return err
+			}
+			defer stopServer()
+			fmt.Printf("Serving gRPC on unix://%s\n", socket)
+		}
+		var prev *daemonSnapshot
+		for {
+			snapshot, err := runDaemonCycle(prev)
+			if err != nil {
+				daemonLogf("[FAIL] %v", err)
+			} else {
+				prev = snapshot
+			}
+
+			delay := intervalDaemon
+			if jitterDaemon > 0 {
+				delay += rand.N(jitterDaemon)
+			}
+			daemonLogf("Next cycle at %s", time.Now().Add(delay).Format(time.DateTime))
+			select {
+			case <-ctx.Done():
+				daemonLogf("Stopping fussy-git daemon.")
+				return nil
+			case <-time.After(delay):
+			}
+		}
+	},
+}
+
+// runDaemonCycle runs one metadata sync and maintenance pass and reports changes compared to prev.
+func runDaemonCycle(prev *daemonSnapshot) (*daemonSnapshot, error) {
+	// Other fussy-git commands may have changed the state since the last cycle.
+	loaded, err := loadRepoState()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load repository state: %w", err)
+	}
+	repoState = loaded
+
+	daemonLogf("Starting cycle for %d repositories", len(repoState.Repositories))
+	urlsUpdated, syncFailures := syncRepositoryMetadata()
+	result := runMaintenance()
+	failures := append(syncFailures, result.failures...)
+
+	snapshot := &daemonSnapshot{behind: result.behind, issueRepos: result.issueRepos}
+
+	// The first cycle only establishes a baseline.
+	var gainedUpstream, newIssues []string
+	if prev != nil {
+		for path, behind := range snapshot.behind {
+			if behind > prev.behind[path] {
+				gainedUpstream = append(gainedUpstream, path)
+			}
+		}
+		for path := range snapshot.issueRepos {
+			if !prev.issueRepos[path] {
+				newIssues = append(newIssues, path)
+			}
+		}
+		sort.Strings(newIssues)
+	}
+
+	daemonLogf("Cycle finished: %d URLs updated, %d fetched, %d pruned, %d with issues, %d gained upstream changes, %d failures",
+		urlsUpdated, result.fetched, len(result.pruned), result.withIssues, len(gainedUpstream), len(failures))
+	for _, failure := range failures {
+		daemonLogf("[FAIL] %s", failure)
+	}
+	for _, path := range newIssues {
+		daemonLogf("[ISSUE] New issues in %s", path)
+	}
+
+	if err := audit.Append(auditLogPath(), audit.Event{
+		Action: "daemon.cycle",
+		Details: map[string]any{
+			"repositories":      len(repoState.Repositories),
+			"urls_updated":      urlsUpdated,
+			"fetched":           result.fetched,
+			"pruned":            result.pruned,
+			"repos_with_issues": result.withIssues,
+			"new_issues":        newIssues,
+			"gained_upstream":   gainedUpstream,
+			"failures":          failures,
+		},
+	}); err != nil {
+		daemonLogf("[WARN] %v", err)
+	}
+
+	if prev != nil {
+		notifier := newNotifier(notifyDaemon)
+		notifyIssues(notifier, newIssues, daemonLogf)
+		notifyBehind(notifier, farBehind(snapshot.behind, prev.behind), daemonLogf)
+	}
+	return snapshot, nil
+}
+
+// syncRepositoryMetadata refreshes the stored origin URL and LastChecked timestamp of every
+// repository from its live 'origin' remote, its GitDir from its .git file and whether it uses
+// Git LFS, and saves the state. It returns the number of
+// URLs that changed and any failures.
+func syncRepositoryMetadata() (int, []string) {
+	updated := 0
+	var failures []string
+	now := time.Now()
+	for i := range repoState.Repositories {
+		entry := &repoState.Repositories[i]
+		if _, err := os.Stat(entry.Path); err != nil {
+			continue // Reported by doctor and pruned by maintenance.
+		}
+		live, err := readLiveMetadata(entry.Path)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("sync %s: %v", entry.Path, err))
+			continue
+		}
+		if applyLiveMetadata(entry, live, now, os.Stdout) {
+			updated++
+		}
+	}
+	repoState.Reindex() // URLs were changed in place.
+	if err := repoState.Save(appConfig.StateFilePath); err != nil {
+		failures = append(failures, fmt.Sprintf("sync: failed to save state: %v", err))
+	}
+	return updated, failures
+}
+
+// daemonLogf prints a timestamped log line.
+func daemonLogf(format string, args ...any) {
+	fmt.Printf("[%s] %s\n", time.Now().Format(time.DateTime), fmt.Sprintf(format, args...))
+}
+
+func init() {
+	daemonCmd.Flags().DurationVar(&intervalDaemon, "interval", 6*time.Hour, "Time between cycles")
+	daemonCmd.Flags().DurationVar(&jitterDaemon, "jitter", 10*time.Minute, "Maximum random delay added to each interval")
+	daemonCmd.Flags().BoolVar(&notifyDaemon, "notify", false, "Send desktop notifications when repositories develop issues or fall far behind upstream, even if notify_desktop isn't set")
+	daemonCmd.Flags().StringVar(&socketDaemon, "socket", "", "Unix socket to serve the gRPC interface on (default daemon.sock next to the state file)")
+	daemonCmd.Flags().BoolVar(&noSocketDaemon, "no-socket", false, "Don't serve the gRPC interface")
+}
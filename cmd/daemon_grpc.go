@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jmsnll/fussy-git/pkg/daemonpb"
+	"github.com/jmsnll/fussy-git/pkg/fussy"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// daemonServer answers the gRPC requests of integrations from the inventory, which it reads
+// again for every request so that changes made by the daemon's cycles and by other fussy-git
+// commands are seen. It doesn't touch the global repoState, which the cycles use.
+type daemonServer struct {
+	daemonpb.UnimplementedDaemonServer
+	mu  sync.Mutex
+	inv *fussy.Inventory
+}
+
+// serveDaemonSocket starts serving the gRPC interface on a unix socket at path. A socket left
+// behind by a daemon that didn't stop cleanly is replaced; one a running daemon listens on
+// isn't. The returned function stops the server and removes the socket.
+func serveDaemonSocket(path string) (func(), error) {
+	inv, err := fussy.Open(fussy.Options{ConfigFile: cfgFile, Home: homeFlag, StateFile: stateFlag})
+	if err != nil {
+		return nil, err
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("another daemon is already listening on %s", path)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	// Clone runs with the user's credentials, so only the user may connect.
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict access to %s: %w", path, err)
+	}
+
+	server := grpc.NewServer()
+	daemonpb.RegisterDaemonServer(server, &daemonServer{inv: inv})
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			daemonLogf("[FAIL] gRPC server: %v", err)
+		}
+	}()
+	return func() {
+		server.GracefulStop()
+		os.Remove(path)
+	}, nil
+}
+
+// reload reads the inventory again. Callers must hold s.mu.
+func (s *daemonServer) reload() error {
+	if err := s.inv.Reload(); err != nil {
+		return status.Errorf(codes.Unavailable, "failed to load repository state: %v", err)
+	}
+	return nil
+}
+
+// find returns the repository ref refers to, with a status error if there is none.
+func (s *daemonServer) find(ref string) (fussy.Repository, error) {
+	if ref == "" {
+		return fussy.Repository{}, status.Error(codes.InvalidArgument, "repository reference is empty")
+	}
+	repo, err := s.inv.Find(ref)
+	switch {
+	case errors.Is(err, fussy.ErrNotFound):
+		return fussy.Repository{}, status.Error(codes.NotFound, err.Error())
+	case err != nil:
+		return fussy.Repository{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return repo, nil
+}
+
+func (s *daemonServer) List(ctx context.Context, req *daemonpb.ListRequest) (*daemonpb.ListResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	q := fussy.Query{Domains: req.GetDomains(), Owners: req.GetOwners(), Tags: req.GetTags(), Groups: req.GetGroups()}
+	resp := &daemonpb.ListResponse{}
+	for _, repo := range s.inv.Select(q) {
+		resp.Repositories = append(resp.Repositories, repositoryMessage(repo))
+	}
+	return resp, nil
+}
+
+func (s *daemonServer) Search(ctx context.Context, req *daemonpb.SearchRequest) (*daemonpb.ListResponse, error) {
+	if len(req.GetTerms()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no search terms")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	resp := &daemonpb.ListResponse{}
+	for _, repo := range s.inv.Select(fussy.Query{Terms: req.GetTerms()}) {
+		if req.GetLimit() > 0 && len(resp.Repositories) == int(req.GetLimit()) {
+			break
+		}
+		resp.Repositories = append(resp.Repositories, repositoryMessage(repo))
+	}
+	return resp, nil
+}
+
+func (s *daemonServer) Status(ctx context.Context, req *daemonpb.StatusRequest) (*daemonpb.StatusResponse, error) {
+	s.mu.Lock()
+	if err := s.reload(); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	repo, err := s.find(req.GetRepository())
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	repoStatus, err := fussy.RepositoryStatus(repo.Path)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &daemonpb.StatusResponse{
+		Repository:  repositoryMessage(repo),
+		Branch:      repoStatus.Branch,
+		Head:        repoStatus.Head,
+		Dirty:       repoStatus.Dirty,
+		HasUpstream: repoStatus.Upstream,
+		Ahead:       int32(repoStatus.Ahead),
+		Behind:      int32(repoStatus.Behind),
+	}, nil
+}
+
+// Clone runs 'fussy-git clone' rather than cloning in the daemon's process, so the clone
+// follows exactly the same rules (hooks, templates, layout) and locks the state like any
+// other command.
+func (s *daemonServer) Clone(ctx context.Context, req *daemonpb.CloneRequest) (*daemonpb.CloneResponse, error) {
+	if req.GetUrl() == "" {
+		return nil, status.Error(codes.InvalidArgument, "URL is empty")
+	}
+	if strings.HasPrefix(req.GetUrl(), "-") {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid URL '%s'", req.GetUrl())
+	}
+	s.mu.Lock()
+	if err := s.reload(); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	repo, err := s.inv.Find(req.GetUrl())
+	s.mu.Unlock()
+	if err == nil {
+		return &daemonpb.CloneResponse{Repository: repositoryMessage(repo), AlreadyTracked: true}, nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to determine the fussy-git executable: %v", err)
+	}
+	var output bytes.Buffer
+	clone := exec.CommandContext(ctx, exe, append(globalFlagArgs(), "clone", "--", req.GetUrl())...)
+	clone.Stdout = &output
+	clone.Stderr = &output
+	if err := clone.Run(); err != nil {
+		return nil, status.Errorf(codes.Aborted, "fussy-git clone failed: %v\n%s", err, strings.TrimSpace(output.String()))
+	}
+	daemonLogf("Cloned %s for a gRPC client", req.GetUrl())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	if repo, err = s.find(req.GetUrl()); err != nil {
+		return nil, err
+	}
+	return &daemonpb.CloneResponse{Repository: repositoryMessage(repo)}, nil
+}
+
+// globalFlagArgs returns the flags selecting the configuration the daemon was started with,
+// for the fussy-git commands it runs.
+func globalFlagArgs() []string {
+	var args []string
+	if cfgFile != "" {
+		args = append(args, "--config", cfgFile)
+	}
+	if homeFlag != "" {
+		args = append(args, "--home", homeFlag)
+	}
+	if stateFlag != "" {
+		args = append(args, "--state-file", stateFlag)
+	}
+	return args
+}
+
+// repositoryMessage converts a tracked repository to its gRPC message.
+func repositoryMessage(repo fussy.Repository) *daemonpb.Repository {
+	return &daemonpb.Repository{
+		Name:           repo.Name,
+		Path:           repo.Path,
+		Url:            repo.CurrentURL,
+		NormalizedPath: repo.NormalizedFS,
+		Domain:         repo.Domain,
+		Tags:           repo.Tags,
+		Groups:         repo.Groups,
+		Description:    repo.Description,
+		Pinned:         repo.Pinned,
+		Locked:         repo.Locked,
+	}
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	densifyAll    bool
+	densifyFilter filter.Filter
+)
+
+// densifyCmd represents the densify command
+var densifyCmd = &cobra.Command{
+	Use:   "densify [--all|<repo>]",
+	Short: "Converts partial clones and sparse checkouts into complete clones.",
+	Long: `Reverses the options that made a clone partial (e.g. 'fussy-git clone --filter blob:none',
+or a clone_filter configured for its domain) and sparse (--sparse or clone_sparse): all
+objects are fetched from origin, so the repository works offline and doesn't depend on
+origin anymore, and the whole tree is checked out. Fetching all objects needs git 2.36 or
+newer. Use 'fussy-git unshallow' to fetch the history a shallow clone left out.
+
+The options a repository was cloned with are recorded in the state and shown by
+'fussy-git info'. The command checks the repository itself, so the record is corrected if
+it was densified by other means.
+
+Pass a repository, or --all for every partial or sparse repository (optionally narrowed with
+--domain, --owner, --tag and --path-prefix). <repo> can be the repository's path, its
+normalized path (e.g. github.com/spf13/cobra), its URL, or its name if that is unambiguous.
+
+Examples:
+  fussy-git densify git.corp.example.com/platform/monorepo
+  fussy-git densify --all --domain git.corp.example.com`,
+	Annotations: writesTree,
+	Args:        cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if densifyAll == (len(args) == 1) {
+			return fmt.Errorf("specify either a repository or --all")
+		}
+
+		var indices []int
+		if densifyAll {
+			for _, repo := range densifyFilter.Apply(repoState.Repositories) {
+				if idx, err := lookupRepository(repo.Path); err == nil {
+					indices = append(indices, idx)
+				}
+			}
+		} else {
+			idx, err := lookupRepository(args[0])
+			if err != nil {
+				return err
+			}
+			indices = append(indices, idx)
+		}
+
+		densified, failed, changed := 0, 0, false
+		for _, idx := range indices {
+			entry := &repoState.Repositories[idx]
+			if !gitutil.IsGitRepository(entry.Path) {
+				if !densifyAll {
+					return fmt.Errorf("%s is not a git repository", entry.Path)
+				}
+				continue
+			}
+			partial, sparse := gitutil.IsPartialClone(entry.Path), gitutil.IsSparse(entry.Path)
+			if !partial && !sparse {
+				if forgetPartialClone(entry, true, true) {
+					changed = true
+				}
+				if !densifyAll {
+					fmt.Printf("%s is already a complete clone.\n", entry.Path)
+				}
+				continue
+			}
+			if partial {
+				fmt.Printf("Fetching all objects of %s...\n", entry.Path)
+				if err := gitutil.FetchMissingObjects(entry.Path, verbose); err != nil {
+					fmt.Printf("[FAIL] %s: %v\n", entry.Path, err)
+					failed++
+					continue
+				}
+				forgetPartialClone(entry, true, false)
+				changed = true
+			}
+			if sparse {
+				fmt.Printf("Checking out the whole tree of %s...\n", entry.Path)
+				if err := gitutil.DisableSparseCheckout(entry.Path, verbose); err != nil {
+					fmt.Printf("[FAIL] %s: %v\n", entry.Path, err)
+					failed++
+					continue
+				}
+				forgetPartialClone(entry, false, true)
+				changed = true
+			}
+			fmt.Printf("[OK] %s\n", entry.Path)
+			densified++
+		}
+
+		if changed {
+			if err := repoState.Save(appConfig.StateFilePath); err != nil {
+				return fmt.Errorf("failed to save state: %w", err)
+			}
+		}
+		if densifyAll {
+			fmt.Printf("\nDensify summary:\n")
+			fmt.Printf("  Densified: %d\n", densified)
+			fmt.Printf("  Failed:    %d\n", failed)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d repositories could not be densified", failed)
+		}
+		return nil
+	},
+}
+
+// forgetPartialClone removes the object filter (if filter is set) and the sparse checkout (if
+// sparse is set) from the options recorded for entry, and reports whether the entry changed.
+func forgetPartialClone(entry *state.RepositoryEntry, filter, sparse bool) bool {
+	o := entry.CloneOptions
+	if o == nil {
+		return false
+	}
+	changed := false
+	if filter && o.Filter != "" {
+		o.Filter, changed = "", true
+	}
+	if sparse && len(o.Sparse) > 0 {
+		o.Sparse, changed = nil, true
+	}
+	if o.IsZero() {
+		entry.CloneOptions = nil
+	}
+	return changed
+}
+
+func init() {
+	densifyCmd.Flags().BoolVar(&densifyAll, "all", false, "Densify every partial or sparse repository")
+	addFilterFlags(densifyCmd, &densifyFilter)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"slices"
+)
+
+// derivedFieldChanges returns the fields of a repository that are derived from its CurrentURL
+// (Name, Domain, NormalizedFS) and whose stored value differs from the one the URL gives now.
+// Old is the stored value, New the derived one.
+func derivedFieldChanges(repo state.RepositoryEntry) ([]state.FieldChange, error) {
+	parsed, err := parseRepoURL(repo.CurrentURL)
+	if err != nil {
+		return nil, err
+	}
+	var changes []state.FieldChange
+	field := func(name, stored, derived string) {
+		if stored != derived {
+			changes = append(changes, state.FieldChange{Field: name, Old: stored, New: derived})
+		}
+	}
+	field("name", repo.Name, parsed.RepoName)
+	field("domain", repo.Domain, parsed.Domain)
+	field("normalized_fs", repo.NormalizedFS, parsed.GetNormalizedFSPath())
+	return changes, nil
+}
+
+// applyDerivedFields sets the fields of entry that are derived from its URL to the values parsed
+// gives. The caller must call Reindex, since NormalizedFS is indexed.
+func applyDerivedFields(entry *state.RepositoryEntry, parsed *gitutil.ParsedGitURL) {
+	entry.Name = parsed.RepoName
+	entry.Domain = parsed.Domain
+	entry.NormalizedFS = parsed.GetNormalizedFSPath()
+}
+
+// fixDerivedFields recomputes the fields derived from the URL of the repositories in repos,
+// printing what changed, and returns how many repositories were fixed. Repositories that ignore
+// the derived-fields check are left alone. The state is not saved.
+func fixDerivedFields(repos []state.RepositoryEntry) int {
+	fixed := 0
+	for _, repo := range repos {
+		if slices.Contains(repo.IgnoredChecks, checkDerivedFields) {
+			continue // Deliberately different, see 'doctor ignore'.
+		}
+		changes, err := derivedFieldChanges(repo)
+		if err != nil || len(changes) == 0 {
+			continue // An unparseable URL is reported by the invalid-url check.
+		}
+		idx := repoState.IndexOfPath(repo.Path)
+		if idx < 0 {
+			continue
+		}
+		parsed, _ := parseRepoURL(repo.CurrentURL)
+		applyDerivedFields(&repoState.Repositories[idx], parsed)
+		for _, c := range changes {
+			fmt.Printf("[FIXED] %s: %s '%s' -> '%s'\n", repo.Path, c.Field, c.Old, c.New)
+			reportAction("fixed", repo.Path, c.Field, c.New)
+		}
+		fixed++
+	}
+	if fixed > 0 {
+		repoState.Reindex() // NormalizedFS was changed in place.
+	}
+	return fixed
+}
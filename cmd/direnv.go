@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/config"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// envrcLanguages maps files that mark a project's language to the keys of 'envrc_templates',
+// checked in this order.
+var envrcLanguages = []struct{ marker, language string }{
+	{"go.mod", "go"},
+	{"package.json", "node"},
+	{"Cargo.toml", "rust"},
+	{"pyproject.toml", "python"},
+	{"requirements.txt", "python"},
+	{"Gemfile", "ruby"},
+	{"pom.xml", "java"},
+	{"build.gradle", "java"},
+}
+
+// envrcData is what .envrc templates are rendered with.
+type envrcData struct {
+	Name           string   // Repository name, e.g. "cobra"
+	Path           string   // Local path of the repository
+	URL            string   // Clone URL
+	Domain         string   // e.g. "github.com"
+	Owner          string   // e.g. "spf13"; empty for repositories without an owner
+	NormalizedPath string   // e.g. "github.com/spf13/cobra"
+	Language       string   // Detected language, e.g. "go"; empty if unknown
+	Env            []string // Environment variables configured for the repository, as NAME=value
+}
+
+// detectLanguage returns the language of the project at repoPath, or "" if it isn't recognized.
+func detectLanguage(repoPath string) string {
+	for _, l := range envrcLanguages {
+		if _, err := os.Stat(filepath.Join(repoPath, l.marker)); err == nil {
+			return l.language
+		}
+	}
+	return ""
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// setUpDirenv writes a .envrc rendered from the repository's template into a fresh clone and
+// allows it with direnv, if the direnv integration is enabled. An existing .envrc (e.g. one
+// that is part of the repository) is left alone. Progress is written to out.
+func setUpDirenv(repoPath string, parsedURL *gitutil.ParsedGitURL, out io.Writer) error {
+	if !appConfig.Direnv {
+		return nil
+	}
+	envrc := filepath.Join(repoPath, ".envrc")
+	if _, err := os.Stat(envrc); err == nil {
+		fmt.Fprintf(out, "Keeping the repository's own .envrc; review it and run 'direnv allow' yourself.\n")
+		return nil
+	}
+
+	settings := repoSettings(parsedURL)
+	language := detectLanguage(repoPath)
+	templateFile := settings.EnvrcTemplate
+	if templateFile == "" {
+		templateFile = appConfig.EnvrcTemplates[language]
+	}
+	if templateFile == "" {
+		return nil
+	}
+	templateFile, err := config.ExpandPath(templateFile)
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New(filepath.Base(templateFile)).
+		Funcs(template.FuncMap{"quote": shellQuote}).
+		Option("missingkey=error").
+		ParseFiles(templateFile)
+	if err != nil {
+		return fmt.Errorf("failed to read .envrc template: %w", err)
+	}
+
+	normalized := filepath.ToSlash(parsedURL.GetNormalizedFSPath())
+	parts := strings.Split(normalized, "/")
+	owner := ""
+	if len(parts) > 2 {
+		owner = strings.Join(parts[1:len(parts)-1], "/")
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, envrcData{
+		Name:           parsedURL.RepoName,
+		Path:           repoPath,
+		URL:            parsedURL.OriginalURL,
+		Domain:         parsedURL.Domain,
+		Owner:          owner,
+		NormalizedPath: normalized,
+		Language:       language,
+		Env:            settings.Env,
+	}); err != nil {
+		return fmt.Errorf("failed to render .envrc template %s: %w", templateFile, err)
+	}
+	if err := os.WriteFile(envrc, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", envrc, err)
+	}
+	// The file is local to this checkout; keep it from showing up as an untracked change.
+	if err := gitutil.AddExclude(repoPath, "/.envrc"); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Wrote .envrc from %s\n", templateFile)
+
+	if _, err := exec.LookPath("direnv"); err != nil {
+		fmt.Fprintf(out, "direnv is not installed; run 'direnv allow %s' once it is.\n", repoPath)
+		return nil
+	}
+	allow := exec.Command("direnv", "allow", repoPath)
+	if output, err := allow.CombinedOutput(); err != nil {
+		return fmt.Errorf("'direnv allow' failed: %w. Output:\n%s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -2,14 +2,27 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
 	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
 	"os"
-	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	doctorFilter   filter.Filter
+	doctorFailOn   string
+	doctorWatch    bool
+	doctorInterval time.Duration
+	// doctorCheckRemotes enables the 'remote' check, which needs the network.
+	doctorCheckRemotes bool
+	doctorFix          bool
+)
+
 // doctorCmd represents the doctor command
 var doctorCmd = &cobra.Command{
 	Use:   "doctor",
@@ -19,10 +32,106 @@ Checks performed include:
 - Existence of the repository path on the filesystem.
 - Whether the path is a valid Git repository.
 - Consistency of the current remote 'origin' URL with the stored state.
-- Whether the repository is in its conventional fussy-git location.
+- Whether the repository is in its conventional fussy-git location
+  (informational only for pinned repositories). If a path override is set for
+  the repository, it is used instead of the computed conventional location.
+- Whether the last 'fussy-git verify' found corrupt or missing objects.
+- Whether the repository is owned by another user without being listed in git's
+  safe.directory, and whether you can write to it. If 'shared_group' is set for a
+  FUSSY_GIT_HOME shared by several users, also whether the repository belongs to that
+  group, is group-writable, and has core.sharedRepository set.
+
+Every finding has a severity: error, warning or info. Each check has an ID, shown in brackets
+after the finding, and its severity can be changed with 'doctor_severities' in the config file,
+e.g. to treat manually added repositories outside the conventional layout as informational:
+  doctor_severities:
+    unconventional-path-manual: info
+
+To silence a check for a single repository whose deviation is intentional, while it keeps
+running for all others, use 'fussy-git doctor ignore <repo> <check>...'.
+
+Checks and their default severities:
+  missing-path, inaccessible-path, not-a-repository   error
+  origin-unreadable, invalid-url, url-mismatch         error
+  corrupt (found by 'fussy-git verify')                error
+  broken-alternates (referenced repository is gone)    error
+  unconventional-path                                  warning
+  hooks (configured git hooks missing or outdated)     warning
+  lfs-content (Git LFS files checked out as pointers)  warning
+  maintenance (not registered, with git_maintenance)   warning
+  signing (unsigned commits where signing_key is set)  warning
+  unconventional-path-manual (manually added repos)    warning
+  derived-fields (name or normalized path is stale)    warning
+  remote (only with --check-remotes)                   warning
+  remote-moved, default-branch (from webhooks)         warning
+  remote-archived (from webhooks)                      info
+  unconventional-path-pinned, shallow, alternates      info
+  symlinked-path (path is or is under a symlink)       info
+
+Exit codes:
+  0  no findings at or above the --fail-on severity (default: error)
+  1  doctor itself failed
+  2  some repositories have findings at or above the --fail-on severity
+
+With --check-remotes, doctor also runs 'git ls-remote --exit-code origin' in every repository
+(in parallel, at most 'max_network_jobs' at a time) to find remotes that were deleted, made
+private or moved. The summary counts the failing remotes by kind: not found (deleted, renamed
+or private), moved (the server redirects; the new URL is shown), access denied, unreachable,
+and failed for anything else. 'fussy-git serve --webhooks' records renames, transfers, archiving
+and default branch changes as the provider reports them, and doctor reports them right away.
 
-This command is read-only and does not make any changes.`,
+The name, domain and normalized path (e.g. github.com/spf13/cobra) of a repository are derived
+from its URL, and can drift from it, e.g. after its remote was changed by hand; the
+derived-fields check reports that. With --fix, they are recomputed from the stored URL of
+every checked repository and the state is saved before the checks run. Other findings are
+never fixed automatically.
+
+Without --fix, this command is read-only and does not make any changes.
+Use --domain, --owner, --tag and --path-prefix to check only a subset of repositories.
+
+With --watch, doctor keeps running until Ctrl-C and checks again every --interval (30s by
+default), and a second after the state file or a directory containing repositories changes,
+e.g. while 'fussy-git reorganize' runs in another terminal. The first check lists every
+repository with warnings or errors; after that only changes are printed: repositories that
+broke, were fixed, moved or whose findings changed. Informational findings are left out.
+In this mode doctor always exits with status 0.
+
+For scripts, --quiet prints only one tab-separated line per warning or error,
+'<severity> <path> <check> <message>', and with --fix one per field it fixed,
+'fixed <path> <field> <new value>'. Nothing is printed if all repositories are healthy; the
+exit status is the same as without --quiet. --quiet can't be used with --watch.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		failOn, err := parseFailOn(doctorFailOn)
+		if err != nil {
+			return err
+		}
+		if err := validateDoctorSeverities(); err != nil {
+			return err
+		}
+		if doctorWatch {
+			if quietOutput {
+				return usageError("--quiet can't be used with --watch")
+			}
+			if doctorCheckRemotes {
+				return usageError("--check-remotes can't be used with --watch")
+			}
+			if doctorInterval <= 0 {
+				return usageError("--interval must be positive")
+			}
+			if doctorFix {
+				return usageError("--fix can't be used with --watch")
+			}
+			return runDoctorWatch(doctorInterval)
+		}
+		if doctorFix && appConfig.ReadOnly {
+			return readOnlyError("'doctor --fix'")
+		}
+		endQuiet, err := beginQuiet()
+		if err != nil {
+			return err
+		}
+		defer endQuiet()
+
 		if verbose {
 			fmt.Printf("Running fussy-git doctor...\n")
 			fmt.Printf("State file: %s\n", appConfig.StateFilePath)
@@ -34,104 +143,81 @@ This command is read-only and does not make any changes.`,
 			return nil
 		}
 
-		fmt.Printf("Found %d repositories to check.\n\n", len(repoState.Repositories))
-
-		issuesFound := 0
-		reposOk := 0
-
-		for i, repo := range repoState.Repositories {
-			fmt.Printf("Checking repository #%d: %s (Path: %s)\n", i+1, repo.Name, repo.Path)
-			var repoIssues []string
-
-			// 1. Check if path exists
-			if _, err := os.Stat(repo.Path); os.IsNotExist(err) {
-				repoIssues = append(repoIssues, fmt.Sprintf("Path does not exist: %s", repo.Path))
-			} else if err != nil {
-				repoIssues = append(repoIssues, fmt.Sprintf("Error accessing path %s: %v", repo.Path, err))
-			} else {
-				// Path exists, proceed with more checks
+		repos := doctorFilter.Apply(repoState.Repositories)
+		if len(repos) == 0 {
+			fmt.Println("No repositories match the given filters. Nothing to check.")
+			return nil
+		}
 
-				// 2. Check if it's a Git repository
-				if !gitutil.IsGitRepository(repo.Path) {
-					repoIssues = append(repoIssues, fmt.Sprintf("Path is not a Git repository: %s", repo.Path))
-				} else {
-					// It's a Git repository
-
-					// 3. Check remote origin URL consistency
-					currentLiveOriginURL, err := gitutil.GetRemoteOriginURL(repo.Path, verbose)
-					if err != nil {
-						repoIssues = append(repoIssues, fmt.Sprintf("Failed to get live origin URL: %v", err))
-					} else {
-						// Normalize both URLs for comparison (e.g. SSH vs HTTPS)
-						parsedStoredURL, errStored := gitutil.ParseGitURL(repo.CurrentURL)
-						parsedLiveURL, errLive := gitutil.ParseGitURL(currentLiveOriginURL)
-
-						if errStored != nil {
-							repoIssues = append(repoIssues, fmt.Sprintf("Could not parse stored CurrentURL '%s': %v", repo.CurrentURL, errStored))
-						}
-						if errLive != nil {
-							repoIssues = append(repoIssues, fmt.Sprintf("Could not parse live origin URL '%s': %v", currentLiveOriginURL, errLive))
-						}
+		if doctorFix {
+			if fixed := fixDerivedFields(repos); fixed > 0 {
+				if err := repoState.Save(appConfig.StateFilePath); err != nil {
+					return fmt.Errorf("failed to save state after fixing %d repositories: %w", fixed, err)
+				}
+				fmt.Printf("Fixed the derived fields of %d repositories.\n\n", fixed)
+				repos = doctorFilter.Apply(repoState.Repositories)
+			}
+		}
 
-						if errStored == nil && errLive == nil {
-							// Compare based on normalized HTTPS versions for robustness
-							storedHTTPS, _ := parsedStoredURL.ToHTTPS()
-							liveHTTPS, _ := parsedLiveURL.ToHTTPS()
-
-							if storedHTTPS != liveHTTPS {
-								repoIssues = append(repoIssues,
-									fmt.Sprintf("Remote URL mismatch: Stored: '%s', Live: '%s'", repo.CurrentURL, currentLiveOriginURL))
-							}
-						} else if repo.CurrentURL != currentLiveOriginURL { // Fallback to direct string comparison if parsing failed for one
-							repoIssues = append(repoIssues,
-								fmt.Sprintf("Remote URL mismatch (direct string): Stored: '%s', Live: '%s'", repo.CurrentURL, currentLiveOriginURL))
-						}
+		fmt.Printf("Found %d repositories to check.\n", len(repos))
+		var remoteProblems map[string]*gitutil.RemoteProblem
+		if doctorCheckRemotes {
+			remoteProblems = checkRemotes(repos)
+		}
+		fmt.Println()
 
-						// 4. Check conventional path
-						// Use the live URL for determining conventional path, as it's the most current.
-						// If live URL parsing failed, this check might be less reliable or skipped.
-						if parsedLiveURL != nil {
-							conventionalPath := parsedLiveURL.GetLocalPath(appConfig.FussyGitHome)
-							normalizedActualPath := strings.TrimRight(filepath.Clean(repo.Path), string(filepath.Separator))
-							normalizedConventionalPath := strings.TrimRight(filepath.Clean(conventionalPath), string(filepath.Separator))
-
-							if normalizedActualPath != normalizedConventionalPath {
-								// Only flag as a major issue if not manually added to a custom path,
-								// or if it's a significant deviation.
-								// For now, just note it.
-								msg := fmt.Sprintf("Not in conventional location. Actual: '%s', Expected: '%s'", repo.Path, conventionalPath)
-								if repo.ManuallyAdded && verbose { // Less critical if manually added, more of an FYI
-									msg += " (Note: Repository was manually added)"
-								}
-								repoIssues = append(repoIssues, msg)
-							}
-						}
-					}
-				}
+		reposOk, withWarnings, withErrors, failing := 0, 0, 0, 0
+		for i, repo := range repos {
+			fmt.Printf("Checking repository #%d: %s (Path: %s)\n", i+1, repo.Name, repo.Path)
+			findings := checkRepository(repo)
+			if problem := remoteProblems[repo.Path]; problem != nil && !slices.Contains(repo.IgnoredChecks, checkRemote) {
+				findings = append(findings, remoteFinding(problem))
 			}
 
-			if len(repoIssues) > 0 {
-				issuesFound++
-				fmt.Println("  Status: ISSUES FOUND")
-				for _, issue := range repoIssues {
-					fmt.Printf("    - %s\n", issue)
-				}
-			} else {
+			worst, found := worstSeverity(findings)
+			switch {
+			case found && worst == severityError:
+				withErrors++
+				fmt.Println("  Status: ERRORS FOUND")
+			case found && worst == severityWarning:
+				withWarnings++
+				fmt.Println("  Status: WARNINGS")
+			default:
 				reposOk++
 				fmt.Println("  Status: OK")
 			}
+			if found && worst >= failOn {
+				failing++
+			}
+			for _, f := range findings {
+				if f.severity == severityInfo {
+					fmt.Printf("    (info) %s [%s]\n", f.message, f.check)
+				} else {
+					fmt.Printf("    - %s: %s [%s]\n", f.severity, f.message, f.check)
+					reportAction(f.severity.String(), repo.Path, f.check, f.message)
+				}
+			}
 			fmt.Println("---") // Separator for readability
 		}
 
 		fmt.Printf("\nDoctor summary:\n")
-		fmt.Printf("  Repositories checked: %d\n", len(repoState.Repositories))
-		fmt.Printf("  Repositories OK:      %d\n", reposOk)
-		fmt.Printf("  Repositories with issues: %d\n", issuesFound)
+		fmt.Printf("  Repositories checked:       %d\n", len(repos))
+		fmt.Printf("  Repositories OK:            %d\n", reposOk)
+		fmt.Printf("  Repositories with warnings: %d\n", withWarnings)
+		fmt.Printf("  Repositories with errors:   %d\n", withErrors)
+		if doctorCheckRemotes {
+			printRemoteSummary(remoteProblems)
+		}
 
-		if issuesFound > 0 {
-			fmt.Println("\nPlease review the issues listed above.")
+		if withErrors+withWarnings > 0 {
+			fmt.Println("\nPlease review the findings listed above.")
+		}
+		if failing > 0 {
 			// Suggest commands to fix, e.g., 'fussy-git reorganize' or manual intervention.
-			return fmt.Errorf("%d repositories reported issues", issuesFound) // Return an error to indicate non-zero exit status
+			return &exitError{code: exitIssues, err: fmt.Errorf("%d repositories reported problems", failing)}
+		}
+		if withErrors+withWarnings > 0 {
+			return nil
 		}
 
 		fmt.Println("All checks passed. Your fussy-git setup looks healthy!")
@@ -139,8 +225,209 @@ This command is read-only and does not make any changes.`,
 	},
 }
 
+// parseFailOn parses the --fail-on flag. "never" never fails.
+func parseFailOn(value string) (severity, error) {
+	if value == "never" {
+		return severityError + 1, nil
+	}
+	sev, err := parseSeverity(value)
+	if err != nil || sev == severityInfo {
+		return 0, usageError("invalid --fail-on '%s': must be one of error, warning, never", value)
+	}
+	return sev, nil
+}
+
+// checkRepository runs the doctor checks against a single tracked repository and returns its
+// findings, with the severities configured in 'doctor_severities'. Checks the repository
+// ignores are left out.
+func checkRepository(repo state.RepositoryEntry) (findings []doctorFinding) {
+	report := func(check, message string) {
+		if slices.Contains(repo.IgnoredChecks, check) {
+			return
+		}
+		findings = append(findings, doctorFinding{check: check, severity: checkSeverity(check), message: message})
+	}
+
+	// Fields derived from the URL must match it.
+	if changes, err := derivedFieldChanges(repo); err == nil {
+		for _, c := range changes {
+			report(checkDerivedFields, fmt.Sprintf("Stored %s '%s' doesn't match '%s' derived from its URL '%s'; 'doctor --fix' updates it",
+				c.Field, c.Old, c.New, repo.CurrentURL))
+		}
+	}
+
+	// Changes on the provider reported by webhooks ('serve --webhooks').
+	if rc := repo.RemoteChanges; rc != nil {
+		if rc.MovedTo != "" && rc.MovedTo != repo.CurrentURL {
+			report(checkRemoteMoved, fmt.Sprintf("Renamed or transferred to %s on the provider (webhook of %s); update it with 'git remote set-url origin %s' and run 'fussy-git reorganize'",
+				rc.MovedTo, rc.ReceivedAt.Format("2006-01-02"), rc.MovedTo))
+		}
+		if rc.Archived {
+			report(checkRemoteArchived, "Archived on the provider; it no longer receives changes")
+		}
+	}
+
+	// 1. Check if path exists
+	if _, err := os.Stat(repo.Path); os.IsNotExist(err) {
+		report(checkMissingPath, fmt.Sprintf("Path does not exist: %s (if it was moved, 'fussy-git import-dir' or 'fussy-git add' on its new location records the move)", repo.Path))
+	} else if err != nil {
+		report(checkInaccessiblePath, fmt.Sprintf("Error accessing path %s: %v", repo.Path, err))
+	} else {
+		// Path exists, proceed with more checks
+		// Paths are compared with symlinks resolved, so a symlinked path is only noted.
+		if resolved := resolvedPath(repo.Path); resolved != "" {
+			report(checkSymlinkedPath, fmt.Sprintf("Path is reached through a symlink and resolves to '%s'", resolved))
+		}
+
+		// 2. Check if it's a Git repository. git refuses to look at repositories of other users.
+		if problem := ownershipProblem(repo.Path); problem != "" {
+			report(checkOwnership, problem)
+		} else if !gitutil.IsGitRepository(repo.Path) {
+			report(checkNotARepository, fmt.Sprintf("Path is not a Git repository: %s", repo.Path))
+		} else {
+			// It's a Git repository
+
+			// Corruption found by the last 'fussy-git verify'.
+			if v := repo.Verification; v != nil && v.Problem != "" {
+				// git's last line is usually the most specific, e.g. "missing blob <id>".
+				lines := strings.Split(v.Problem, "\n")
+				report(checkCorrupt, fmt.Sprintf("'fussy-git verify' found corrupt or missing objects on %s: %s",
+					v.CheckedAt.Format("2006-01-02"), lines[len(lines)-1]))
+			}
+
+			if gitutil.IsShallow(repo.Path) {
+				report(checkShallow, "Shallow clone with truncated history; 'fussy-git unshallow' fetches the rest")
+			}
+
+			if gitutil.UsesLFS(repo.Path) {
+				for _, problem := range lfsProblems(repo.Path) {
+					report(checkLFSContent, problem)
+				}
+			}
+
+			for _, problem := range hookProblems(repo.Path) {
+				report(checkHooks, problem)
+			}
+
+			for _, problem := range permissionProblems(repo.Path) {
+				report(checkPermissions, problem)
+			}
+
+			if problem := maintenanceProblem(repo); problem != "" {
+				report(checkMaintenance, problem)
+			}
+
+			for _, problem := range signingProblems(repo) {
+				report(checkSigning, problem)
+			}
+
+			if rc := repo.RemoteChanges; rc != nil && rc.DefaultBranch != "" {
+				if branch, err := gitutil.DefaultBranch(repo.Path); err == nil && branch != rc.DefaultBranch {
+					report(checkDefaultBranch, fmt.Sprintf("The default branch on the provider is now '%s', but origin/HEAD still points at '%s'; update it with 'git remote set-head origin --auto'",
+						rc.DefaultBranch, branch))
+				}
+			}
+
+			// Objects borrowed from another repository (clone --reference) must still be there.
+			if alternates, err := gitutil.Alternates(repo.Path); err != nil {
+				report(checkBrokenAlternates, err.Error())
+			} else {
+				for _, dir := range alternates {
+					if _, err := os.Stat(dir); err != nil {
+						report(checkBrokenAlternates, fmt.Sprintf("Shares objects with '%s', which no longer exists; restore it, or reclone the repository", dir))
+					} else if verbose {
+						report(checkAlternates, fmt.Sprintf("Shares objects with '%s'", dir))
+					}
+				}
+			}
+
+			// 3. Check remote origin URL consistency
+			currentLiveOriginURL, err := gitutil.GetRemoteOriginURL(repo.Path, verbose)
+			if err != nil {
+				report(checkOriginUnreadable, fmt.Sprintf("Failed to get live origin URL: %v", err))
+			} else {
+				// Normalize both URLs for comparison (e.g. SSH vs HTTPS)
+				parsedStoredURL, errStored := parseRepoURL(repo.CurrentURL)
+				parsedLiveURL, errLive := parseRepoURL(currentLiveOriginURL)
+
+				if errStored != nil {
+					report(checkInvalidURL, fmt.Sprintf("Could not parse stored CurrentURL '%s': %v", repo.CurrentURL, errStored))
+				}
+				if errLive != nil {
+					report(checkInvalidURL, fmt.Sprintf("Could not parse live origin URL '%s': %v", currentLiveOriginURL, errLive))
+				}
+
+				if errStored == nil && errLive == nil {
+					// Compare based on normalized HTTPS versions for robustness
+					storedHTTPS, _ := parsedStoredURL.ToHTTPS()
+					liveHTTPS, _ := parsedLiveURL.ToHTTPS()
+
+					if storedHTTPS != liveHTTPS {
+						report(checkURLMismatch,
+							fmt.Sprintf("Remote URL mismatch: Stored: '%s', Live: '%s'", repo.CurrentURL, currentLiveOriginURL))
+					}
+				} else if repo.CurrentURL != currentLiveOriginURL { // Fallback to direct string comparison if parsing failed for one
+					report(checkURLMismatch,
+						fmt.Sprintf("Remote URL mismatch (direct string): Stored: '%s', Live: '%s'", repo.CurrentURL, currentLiveOriginURL))
+				}
+
+				// 4. Check conventional path
+				// Use the live URL for determining conventional path, as it's the most current.
+				// If live URL parsing failed, this check might be less reliable or skipped.
+				if parsedLiveURL != nil {
+					// A per-repository path override replaces the computed conventional path.
+					conventionalPath := expectedRepoPath(repo, parsedLiveURL)
+
+					if !samePath(repo.Path, conventionalPath) {
+						// Only flag as a major issue if not manually added to a custom path,
+						// or if it's a significant deviation.
+						// For now, just note it.
+						msg := fmt.Sprintf("Not in conventional location. Actual: '%s', Expected: '%s'", repo.Path, conventionalPath)
+						if repo.PathOverride != "" {
+							msg = fmt.Sprintf("Not at its path override. Actual: '%s', Expected: '%s'", repo.Path, conventionalPath)
+						}
+						switch {
+						case repo.Pinned:
+							// Pinned repositories live at their custom path on purpose.
+							report(checkPinnedPath, msg+" (pinned)")
+						case repo.ManuallyAdded:
+							// Repositories added from where they were are less likely to be misplaced by accident.
+							report(checkManualPath, msg+" (manually added)")
+						default:
+							report(checkUnconventionalPath, msg)
+						}
+					}
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// lfsProblems returns why files of a repository using Git LFS lack their content: git-lfs
+// isn't installed, so they are checked out as pointers, or their content was never downloaded.
+func lfsProblems(repoPath string) []string {
+	if !gitutil.LFSInstalled() {
+		return []string{"Uses Git LFS, but git-lfs isn't installed; LFS files are checked out as pointers without their content"}
+	}
+	missing, err := gitutil.LFSMissingContent(repoPath)
+	if err != nil {
+		return []string{fmt.Sprintf("Failed to list the LFS files: %v", err)}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("%d LFS files are pointers without their content (e.g. '%s'); run 'git lfs pull' to download it",
+		len(missing), missing[0])}
+}
+
 func init() {
 	rootCmd.AddCommand(doctorCmd)
-	// Potential flags for doctorCmd:
-	// doctorCmd.Flags().BoolP("fix", "f", false, "Attempt to automatically fix some common issues (use with caution)")
+	addFilterFlags(doctorCmd, &doctorFilter)
+	doctorCmd.Flags().StringVar(&doctorFailOn, "fail-on", "error", "Lowest severity that makes doctor exit with status 2: 'error', 'warning' or 'never'")
+	doctorCmd.Flags().BoolVar(&doctorCheckRemotes, "check-remotes", false, "Also check that the origin of every repository can be reached (needs the network)")
+	doctorCmd.Flags().BoolVar(&doctorWatch, "watch", false, "Keep checking and print only what changed, until interrupted")
+	doctorCmd.Flags().DurationVar(&doctorInterval, "interval", 30*time.Second, "How often --watch checks, in addition to checking on changes")
+	addQuietFlag(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Recompute the name, domain and normalized path of repositories from their URL before checking")
 }
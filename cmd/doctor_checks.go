@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+)
+
+// severity classifies a doctor finding. Only errors make doctor fail by default.
+type severity int
+
+const (
+	severityInfo severity = iota
+	severityWarning
+	severityError
+)
+
+func (s severity) String() string {
+	switch s {
+	case severityError:
+		return "error"
+	case severityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// parseSeverity parses "error", "warning" or "info".
+func parseSeverity(s string) (severity, error) {
+	switch s {
+	case "error":
+		return severityError, nil
+	case "warning":
+		return severityWarning, nil
+	case "info":
+		return severityInfo, nil
+	}
+	return severityInfo, fmt.Errorf("invalid severity '%s': must be one of error, warning, info", s)
+}
+
+// IDs of the doctor checks, used in the output and as keys of the 'doctor_severities' setting.
+const (
+	checkMissingPath        = "missing-path"
+	checkInaccessiblePath   = "inaccessible-path"
+	checkNotARepository     = "not-a-repository"
+	checkCorrupt            = "corrupt"
+	checkShallow            = "shallow"
+	checkBrokenAlternates   = "broken-alternates"
+	checkAlternates         = "alternates"
+	checkOriginUnreadable   = "origin-unreadable"
+	checkInvalidURL         = "invalid-url"
+	checkURLMismatch        = "url-mismatch"
+	checkUnconventionalPath = "unconventional-path"
+	checkManualPath         = "unconventional-path-manual"
+	checkPinnedPath         = "unconventional-path-pinned"
+	checkHooks              = "hooks"
+	checkOwnership          = "ownership"
+	checkPermissions        = "permissions"
+	checkRemote             = "remote"
+	checkSymlinkedPath      = "symlinked-path"
+	checkLFSContent         = "lfs-content"
+	checkMaintenance        = "maintenance"
+	checkDerivedFields      = "derived-fields"
+	checkRemoteMoved        = "remote-moved"
+	checkRemoteArchived     = "remote-archived"
+	checkDefaultBranch      = "default-branch"
+	checkSigning            = "signing"
+)
+
+// defaultSeverities are the severities of the doctor checks unless configured otherwise.
+var defaultSeverities = map[string]severity{
+	checkMissingPath:        severityError,
+	checkInaccessiblePath:   severityError,
+	checkNotARepository:     severityError,
+	checkCorrupt:            severityError,
+	checkShallow:            severityInfo,
+	checkBrokenAlternates:   severityError,
+	checkAlternates:         severityInfo,
+	checkOriginUnreadable:   severityError,
+	checkInvalidURL:         severityError,
+	checkURLMismatch:        severityError,
+	checkUnconventionalPath: severityWarning,
+	checkManualPath:         severityWarning,
+	checkPinnedPath:         severityInfo,
+	checkHooks:              severityWarning,
+	checkOwnership:          severityError,
+	checkPermissions:        severityWarning,
+	checkRemote:             severityWarning,
+	checkSymlinkedPath:      severityInfo,
+	checkLFSContent:         severityWarning,
+	checkMaintenance:        severityWarning,
+	checkDerivedFields:      severityWarning,
+	checkRemoteMoved:        severityWarning,
+	checkRemoteArchived:     severityInfo,
+	checkDefaultBranch:      severityWarning,
+	checkSigning:            severityWarning,
+}
+
+// doctorFinding is one result of a doctor check.
+type doctorFinding struct {
+	check    string
+	severity severity
+	message  string
+}
+
+// checkSeverity returns the severity of a check, as configured in 'doctor_severities' or by default.
+// The configuration is validated by validateDoctorSeverities when doctor starts.
+func checkSeverity(check string) severity {
+	if configured, ok := appConfig.DoctorSeverities[check]; ok {
+		if sev, err := parseSeverity(configured); err == nil {
+			return sev
+		}
+	}
+	return defaultSeverities[check]
+}
+
+// validateDoctorSeverities checks that 'doctor_severities' only names known checks.
+// Its values are validated when the configuration is loaded.
+func validateDoctorSeverities() error {
+	for check := range appConfig.DoctorSeverities {
+		if _, known := defaultSeverities[check]; !known {
+			return fmt.Errorf("invalid configuration: doctor_severities: unknown check '%s' (known checks: %v)", check, knownChecks())
+		}
+	}
+	return nil
+}
+
+// worstSeverity returns the highest severity among findings, and false if there are none.
+func worstSeverity(findings []doctorFinding) (severity, bool) {
+	worst, found := severityInfo, false
+	for _, f := range findings {
+		if !found || f.severity > worst {
+			worst, found = f.severity, true
+		}
+	}
+	return worst, found
+}
+
+// countSeverity returns how many findings have the given severity.
+func countSeverity(findings []doctorFinding, sev severity) int {
+	n := 0
+	for _, f := range findings {
+		if f.severity == sev {
+			n++
+		}
+	}
+	return n
+}
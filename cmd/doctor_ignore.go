@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// doctorIgnoreCmd represents the doctor ignore command
+var doctorIgnoreCmd = &cobra.Command{
+	Use:   "ignore <repo> <check>...",
+	Short: "Stops doctor from reporting the given checks for a repository.",
+	Long: `Suppresses doctor checks for a single repository, for deviations that are intentional, e.g. a
+repository kept outside the conventional layout on purpose. The ignored checks are recorded in
+the state and apply wherever doctor's checks are used: doctor (including --watch), the health
+score of 'list --health', maintenance and the web dashboard. All other checks stay active, and
+the check stays active for every other repository; to change a check for all repositories, set
+its severity with 'doctor_severities' in the config file.
+
+<check> is the ID doctor shows in brackets after a finding, e.g. unconventional-path (see
+'fussy-git doctor --help' for the list). 'fussy-git info' shows the ignored checks of a
+repository, and 'fussy-git doctor unignore' reports them again.
+
+<repo> can be the repository's path, its normalized path (e.g. github.com/spf13/cobra),
+its URL, or its name if that is unambiguous.
+
+Examples:
+  fussy-git doctor ignore ~/src/legacy-app unconventional-path-manual
+  fussy-git doctor ignore github.com/me/big-assets lfs-content shallow`,
+	Annotations:       mutates,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeIgnoreArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setIgnoredChecks(args[0], args[1:], true)
+	},
+}
+
+// doctorUnignoreCmd represents the doctor unignore command
+var doctorUnignoreCmd = &cobra.Command{
+	Use:               "unignore <repo> <check>...",
+	Short:             "Makes doctor report the given checks for a repository again.",
+	Annotations:       mutates,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeIgnoreArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setIgnoredChecks(args[0], args[1:], false)
+	},
+}
+
+// setIgnoredChecks adds checks to or removes them from the ignored checks of the referenced
+// repository and saves the state.
+func setIgnoredChecks(ref string, checks []string, ignore bool) error {
+	for _, check := range checks {
+		if _, known := defaultSeverities[check]; !known {
+			return usageError("unknown check '%s' (known checks: %s)", check, strings.Join(knownChecks(), ", "))
+		}
+	}
+	idx, err := lookupRepository(ref)
+	if err != nil {
+		return err
+	}
+	entry := &repoState.Repositories[idx]
+
+	var changed []string
+	for _, check := range checks {
+		if slices.Contains(entry.IgnoredChecks, check) == ignore || slices.Contains(changed, check) {
+			continue
+		}
+		if ignore {
+			entry.IgnoredChecks = append(entry.IgnoredChecks, check)
+		} else {
+			entry.IgnoredChecks = slices.DeleteFunc(entry.IgnoredChecks, func(c string) bool { return c == check })
+		}
+		changed = append(changed, check)
+	}
+	if len(changed) == 0 {
+		if ignore {
+			fmt.Printf("Repository '%s' (%s) already ignores these checks.\n", entry.Name, entry.Path)
+		} else {
+			fmt.Printf("Repository '%s' (%s) doesn't ignore these checks.\n", entry.Name, entry.Path)
+		}
+		return nil
+	}
+	sort.Strings(entry.IgnoredChecks)
+	if len(entry.IgnoredChecks) == 0 {
+		entry.IgnoredChecks = nil
+	}
+	entry.LastModified = time.Now()
+	if err := repoState.Save(appConfig.StateFilePath); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	if ignore {
+		fmt.Printf("Doctor now ignores %s for '%s' at %s.\n", strings.Join(changed, ", "), entry.Name, entry.Path)
+	} else {
+		fmt.Printf("Doctor reports %s for '%s' at %s again.\n", strings.Join(changed, ", "), entry.Name, entry.Path)
+	}
+	return nil
+}
+
+// knownChecks returns the IDs of all doctor checks, sorted.
+func knownChecks() []string {
+	checks := make([]string, 0, len(defaultSeverities))
+	for check := range defaultSeverities {
+		checks = append(checks, check)
+	}
+	sort.Strings(checks)
+	return checks
+}
+
+// completeIgnoreArgs completes the repository, then the check IDs.
+func completeIgnoreArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completeRepository(cmd, args, toComplete)
+	}
+	return knownChecks(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	doctorCmd.AddCommand(doctorIgnoreCmd)
+	doctorCmd.AddCommand(doctorUnignoreCmd)
+}
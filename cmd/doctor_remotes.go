@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// remoteCheckTimeout is how long doctor --check-remotes waits for a remote to answer.
+const remoteCheckTimeout = 30 * time.Second
+
+// checkRemotes runs 'git ls-remote' against the origin of every repository that exists on
+// disk, in parallel within the network limits, and returns the problems by repository path.
+func checkRemotes(repos []state.RepositoryEntry) map[string]*gitutil.RemoteProblem {
+	var toCheck []state.RepositoryEntry
+	for _, repo := range repos {
+		if _, err := os.Stat(repo.Path); err == nil && gitutil.IsGitRepository(repo.Path) {
+			toCheck = append(toCheck, repo)
+		}
+	}
+	fmt.Printf("Checking the remotes of %d repositories...\n", len(toCheck))
+
+	limiter := newNetworkLimiter(0)
+	problems := make(map[string]*gitutil.RemoteProblem)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, repo := range toCheck {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limiter.Acquire()
+			problem := gitutil.CheckRemote(repo.Path, remoteCheckTimeout)
+			limiter.Release(0)
+			if problem != nil {
+				mu.Lock()
+				problems[repo.Path] = problem
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return problems
+}
+
+// remoteFinding turns a problem found by --check-remotes into a doctor finding.
+func remoteFinding(p *gitutil.RemoteProblem) doctorFinding {
+	var message string
+	switch p.Kind {
+	case gitutil.RemoteNotFound:
+		message = fmt.Sprintf("Origin not found; the repository was deleted, renamed or made private: %s", p.Detail)
+	case gitutil.RemoteMoved:
+		message = fmt.Sprintf("Origin moved to %s; update it with 'git remote set-url origin %s' and run 'fussy-git reorganize'", p.NewURL, p.NewURL)
+	default:
+		message = fmt.Sprintf("Origin %s: %s", p.Kind, p.Detail)
+	}
+	return doctorFinding{check: checkRemote, severity: checkSeverity(checkRemote), message: message}
+}
+
+// printRemoteSummary prints how many remotes failed, by kind of failure.
+func printRemoteSummary(problems map[string]*gitutil.RemoteProblem) {
+	byKind := make(map[string]int)
+	for _, p := range problems {
+		byKind[p.Kind]++
+	}
+	kinds := make([]string, 0, len(byKind))
+	for kind := range byKind {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		return byKind[kinds[i]] > byKind[kinds[j]] || byKind[kinds[i]] == byKind[kinds[j]] && kinds[i] < kinds[j]
+	})
+
+	fmt.Printf("  Remotes with problems:      %d\n", len(problems))
+	for _, kind := range kinds {
+		fmt.Printf("    %-25s %d\n", kind+":", byKind[kind])
+	}
+}
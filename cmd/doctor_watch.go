@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// doctorWatchDebounce is how long doctor --watch waits after a filesystem event before it
+// checks again, so a burst of events (e.g. a reorganize run) leads to a single pass.
+const doctorWatchDebounce = time.Second
+
+// watchedRepo is what doctor --watch remembers about a repository between passes.
+type watchedRepo struct {
+	entry    state.RepositoryEntry
+	findings map[string]doctorFinding // Findings of warning or error severity, by check and message
+}
+
+// runDoctorWatch checks the selected repositories every interval, and shortly after the
+// state file or the directories containing repositories change, until interrupted. After
+// the first pass, which prints every problem, only changes are printed: repositories that
+// broke, that were fixed, or whose problems changed.
+func runDoctorWatch(interval time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		fmt.Printf("[WARN] Can't watch the filesystem for changes, checking every %s only: %v\n", interval, err)
+	} else {
+		defer watcher.Close()
+	}
+	watched := make(map[string]bool)
+	watch := func(dir string) {
+		if watcher == nil || watched[dir] {
+			return
+		}
+		if err := watcher.Add(dir); err == nil {
+			watched[dir] = true
+		} else if verbose {
+			fmt.Printf("Not watching %s: %v\n", dir, err)
+		}
+	}
+
+	var previous map[string]watchedRepo
+	pass := func() {
+		// Other commands (e.g. reorganize) change the state while doctor watches.
+		rs, err := loadRepoState()
+		if err != nil {
+			fmt.Printf("%s [WARN] Failed to load repository state: %v\n", time.Now().Format("15:04:05"), err)
+			return
+		}
+		repoState = rs
+		current := make(map[string]watchedRepo)
+		for _, repo := range doctorFilter.Apply(repoState.Repositories) {
+			findings := make(map[string]doctorFinding)
+			for _, f := range checkRepository(repo) {
+				if f.severity > severityInfo {
+					findings[f.check+"\x00"+f.message] = f
+				}
+			}
+			current[repo.OriginalURL] = watchedRepo{entry: repo, findings: findings}
+			watch(filepath.Dir(repo.Path))
+		}
+		watch(filepath.Dir(appConfig.StateFilePath))
+
+		if previous == nil {
+			printDoctorBaseline(current)
+		} else {
+			printDoctorChanges(previous, current)
+		}
+		previous = current
+	}
+
+	fmt.Printf("Watching repositories every %s and on changes; press Ctrl-C to stop.\n", interval)
+	pass()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var events <-chan fsnotify.Event
+	var watchErrors <-chan error
+	if watcher != nil {
+		events, watchErrors = watcher.Events, watcher.Errors
+	}
+	debounce := time.NewTimer(doctorWatchDebounce)
+	debounce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("\nStopped watching.")
+			return nil
+		case <-ticker.C:
+			pass()
+		case event := <-events:
+			if verbose {
+				fmt.Printf("Change: %s\n", event)
+			}
+			debounce.Reset(doctorWatchDebounce)
+		case err := <-watchErrors:
+			fmt.Printf("[WARN] Watching the filesystem: %v\n", err)
+		case <-debounce.C:
+			pass()
+			ticker.Reset(interval)
+		}
+	}
+}
+
+// printDoctorBaseline prints the result of the first pass of doctor --watch.
+func printDoctorBaseline(current map[string]watchedRepo) {
+	failing := 0
+	for _, repo := range current {
+		if len(repo.findings) > 0 {
+			failing++
+		}
+	}
+	fmt.Printf("%s Checked %d repositories: %d OK, %d with problems.\n", time.Now().Format("15:04:05"), len(current), len(current)-failing, failing)
+	for _, repo := range sortedWatchedRepos(current) {
+		if len(repo.findings) > 0 {
+			fmt.Printf("  [FAIL] %s (%s)\n", repo.entry.Name, repo.entry.Path)
+			for _, line := range watchedFindingLines("      - ", repo.findings, nil) {
+				fmt.Println(line)
+			}
+		}
+	}
+}
+
+// printDoctorChanges prints how the findings of doctor --watch changed since the previous pass.
+// Nothing is printed if nothing changed.
+func printDoctorChanges(previous, current map[string]watchedRepo) {
+	var lines []string
+	for _, repo := range sortedWatchedRepos(current) {
+		before, known := previous[repo.entry.OriginalURL]
+		label := fmt.Sprintf("%s (%s)", repo.entry.Name, repo.entry.Path)
+		switch {
+		case len(repo.findings) > 0 && len(before.findings) == 0:
+			lines = append(lines, "  [BROKEN] "+label)
+			lines = append(lines, watchedFindingLines("      - ", repo.findings, nil)...)
+		case len(repo.findings) == 0 && len(before.findings) > 0:
+			lines = append(lines, "  [FIXED] "+label)
+		case len(repo.findings) > 0:
+			added := watchedFindingLines("      + ", repo.findings, before.findings)
+			resolved := watchedFindingLines("      - fixed: ", before.findings, repo.findings)
+			if len(added)+len(resolved) > 0 {
+				lines = append(lines, "  [CHANGED] "+label)
+				lines = append(append(lines, added...), resolved...)
+			}
+		}
+		if known && before.entry.Path != repo.entry.Path && len(repo.findings) == 0 {
+			lines = append(lines, fmt.Sprintf("  [MOVED] %s: %s -> %s", repo.entry.Name, before.entry.Path, repo.entry.Path))
+		}
+	}
+	for _, repo := range sortedWatchedRepos(previous) {
+		if _, ok := current[repo.entry.OriginalURL]; !ok {
+			lines = append(lines, fmt.Sprintf("  [UNTRACKED] %s (%s) is no longer checked", repo.entry.Name, repo.entry.Path))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Printf("%s Changes:\n", time.Now().Format("15:04:05"))
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
+
+// watchedFindingLines formats the findings that aren't in except, in a stable order.
+func watchedFindingLines(prefix string, findings, except map[string]doctorFinding) []string {
+	var lines []string
+	for key, f := range findings {
+		if _, ok := except[key]; !ok {
+			lines = append(lines, fmt.Sprintf("%s%s: %s [%s]", prefix, f.severity, f.message, f.check))
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// sortedWatchedRepos returns the repositories of a pass ordered by name and path.
+func sortedWatchedRepos(repos map[string]watchedRepo) []watchedRepo {
+	sorted := make([]watchedRepo, 0, len(repos))
+	for _, repo := range repos {
+		sorted = append(sorted, repo)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].entry.Name != sorted[j].entry.Name {
+			return sorted[i].entry.Name < sorted[j].entry.Name
+		}
+		return sorted[i].entry.Path < sorted[j].entry.Path
+	})
+	return sorted
+}
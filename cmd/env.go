@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// envCmd represents the env command
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Prints the effective configuration and where each setting comes from.",
+	Long: `Prints every setting fussy-git uses, its effective value, and its source, to debug why
+a setting doesn't have the value you expect. Settings are resolved in this order, the first
+one that is set wins:
+
+  1. Flags: --home and --state-file
+  2. Environment variables: FUSSY_GIT_<SETTING> (e.g. FUSSY_GIT_LAYOUT), and FUSSY_GIT_HOME
+  3. The config file: ~/.fussy-git/config.yaml, or the file given with --config
+  4. Built-in defaults
+
+Path settings (fussy_git_home, state_file_path, clone_cache_dir, hooks_template_dir,
+shared_state_file, envrc_templates and local_dir) may refer to environment variables as
+$VAR or ${VAR}, and start with ~; referring to a variable that isn't set is an error.
+
+Values of credentials (settings whose name contains token, password or secret) are redacted.
+Only the configuration is loaded, so the command also works when the state file is broken.`,
+	Args: cobra.NoArgs,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		if appConfig, err = loadConfig(); err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		origin := "default location"
+		if cfgFile != "" {
+			origin = "from --config"
+		}
+		status := "found"
+		if !appConfig.ConfigFileFound {
+			status = "not found, using defaults and environment"
+		}
+		fmt.Printf("Config file: %s (%s, %s)\n\n", appConfig.ConfigFile, origin, status)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+		fmt.Fprintln(w, "-------\t-----\t------")
+		for _, s := range appConfig.Settings {
+			value := s.Value
+			if value == "" {
+				value = "(not set)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", s.Key, value, s.Source)
+		}
+		return w.Flush()
+	},
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	execAll    bool
+	execFilter filter.Filter
+	execBulk   bulkOptions
+)
+
+// execCmd represents the exec command
+var execCmd = &cobra.Command{
+	Use:   "exec <repo> -- <command> [args...] | exec --all|<filters> -- <command> [args...]",
+	Short: "Runs a command in a repository's directory with its configured environment.",
+	Long: `Runs a command in the directory of a tracked repository, with the environment variables
+configured for it added to the current environment, so project-specific tooling works without
+direnv. Variables come from the 'env' setting of the config file, which can be set globally and
+per domain, owner and repository (see 'fussy-git help config'), and from repository metadata
+named env.<NAME> (see 'fussy-git meta'), which takes precedence. The same variables are set for
+the repository's bootstrap commands.
+
+The command's exit code is passed on. Everything after <repo> is the command; use -- before it
+if it starts with a flag.
+
+With --all or any of the filter flags (--domain, --owner, --tag, --path-prefix, --meta,
+--group), no <repo> is given and the command runs in every matching repository, --jobs at a
+time (one by default). Each repository's output is printed under a "==> <name> (<path>)" header, and a
+report follows: a table with every repository's status, exit code and duration, and the last
+lines of the error output of each one that failed. With --output json, the report is printed
+as JSON instead and includes each repository's output. A repository fails when the command
+exits with a non-zero code or can't be started.
+  default              Run the command everywhere; exit with status 1 if it failed anywhere.
+  --fail-fast          Start no more repositories after the first failure; the remaining ones
+                       are reported as skipped.
+  --continue-on-error  Run the command everywhere and exit with status 0 even if it failed
+                       somewhere; failures are still reported.
+If the command failed in some repositories but not all, exec exits with status 3.
+
+<repo> can be the repository's path, its normalized path (e.g. github.com/spf13/cobra),
+its URL, or its name if that is unambiguous.
+
+Examples:
+  fussy-git meta set github.com/work-org/api env.NODE_OPTIONS --max-old-space-size=4096
+  fussy-git exec github.com/work-org/api -- npm test
+  fussy-git exec cobra go test ./...
+  fussy-git exec --owner work-org --jobs 4 -- make lint
+  fussy-git exec --all --fail-fast --output json -- git status --short`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeRepository,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if execAll || !execFilter.IsEmpty() {
+			return runExecBulk(cmd, args)
+		}
+		if len(args) < 2 {
+			return usageError("no command given; usage: %s", cmd.UseLine())
+		}
+		idx, err := lookupRepository(args[0])
+		if err != nil {
+			return err
+		}
+		entry := repoState.Repositories[idx]
+		command := execCommand(args[1:])
+		if len(command) == 0 {
+			return usageError("no command given; usage: %s", cmd.UseLine())
+		}
+
+		code, err := runInRepository(entry, command, os.Stdin, os.Stdout, os.Stderr)
+		if code > 0 {
+			return &exitError{code: code, err: err}
+		}
+		return err
+	},
+}
+
+// runExecBulk runs the command in args in every repository selected by --all or the filter
+// flags and prints the report.
+func runExecBulk(cmd *cobra.Command, args []string) error {
+	if err := execBulk.validate(); err != nil {
+		return err
+	}
+	command := execCommand(args)
+	if len(command) == 0 {
+		return usageError("no command given; usage: %s", cmd.UseLine())
+	}
+	repos := execFilter.Apply(repoState.Repositories)
+	if len(repos) == 0 {
+		fmt.Fprintln(os.Stderr, "No repositories match.")
+		return nil
+	}
+
+	report := runBulk("exec", repos, &execBulk, func(repo state.RepositoryEntry, stdout, stderr io.Writer) (int, error) {
+		return runInRepository(repo, command, nil, stdout, stderr)
+	})
+	return report.print(&execBulk)
+}
+
+// execCommand returns the command in args, without the -- separating it from the arguments
+// of exec.
+func execCommand(args []string) []string {
+	if len(args) > 0 && args[0] == "--" {
+		return args[1:]
+	}
+	return args
+}
+
+// runInRepository runs command in the repository's directory with its environment. It returns
+// the command's exit code, and an error if it exited with a non-zero code or couldn't be run.
+func runInRepository(entry state.RepositoryEntry, command []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	c := exec.Command(command[0], command[1:]...)
+	c.Dir = entry.Path
+	c.Env = append(os.Environ(), repoEnv(entry)...)
+	c.Stdin, c.Stdout, c.Stderr = stdin, stdout, stderr
+	if verbose {
+		fmt.Fprintf(stderr, "Running %v in %s with %v\n", command, entry.Path, repoEnv(entry))
+	}
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), fmt.Errorf("'%s' exited with code %d", command[0], exitErr.ExitCode())
+		}
+		return exitFailure, fmt.Errorf("failed to run '%s' in %s: %w", command[0], entry.Path, err)
+	}
+	return 0, nil
+}
+
+func init() {
+	// Flags after <repo> belong to the command being run.
+	execCmd.Flags().SetInterspersed(false)
+	execCmd.Flags().BoolVar(&execAll, "all", false, "Run the command in every tracked repository")
+	addFilterFlags(execCmd, &execFilter)
+	addBulkFlags(execCmd, &execBulk, 1)
+}
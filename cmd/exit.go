@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes with a specific meaning, documented in the help of the root command. Any other
+// error exits with exitFailure.
+const (
+	exitFailure = 1 // The command failed
+	exitIssues  = 2 // The command ran, but found problems that need attention (e.g. doctor issues)
+	exitPartial = 3 // An operation on several repositories failed for some of them, but not all
+	exitState   = 4 // The state file can't be read or is corrupt, or reorganize couldn't save it
+	exitUsage   = 5 // Invalid arguments or flags
+
+	exitInterrupted = 130 // Stopped by SIGINT or SIGTERM, like shells report a process killed by SIGINT
+)
+
+// exitCodesHelp documents the exit codes in the help of the root command.
+const exitCodesHelp = `Exit codes:
+  0    success
+  1    the command failed
+  2    the command ran, but found problems that need attention (doctor, verify, auth check)
+  3    an operation on several repositories (clone, exec) failed for some of them, but not all
+  4    the state file can't be read or is corrupt, or reorganize couldn't save it
+  5    invalid arguments or flags
+  130  interrupted by Ctrl-C or SIGTERM (reorganize stops cleanly after the operation in progress)
+'exec' with a single repository passes on the exit code of the command it ran.`
+
+// exitError is an error that requests a specific process exit code.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// usageError returns an error for invalid arguments or flags, which exits with exitUsage.
+func usageError(format string, args ...any) error {
+	return &exitError{code: exitUsage, err: fmt.Errorf(format, args...)}
+}
+
+// bulkFailure returns the error for an operation on total repositories of which failed
+// failed: exitFailure if all of them failed, exitPartial otherwise.
+func bulkFailure(failed, total int, err error) error {
+	if failed == total {
+		return &exitError{code: exitFailure, err: err}
+	}
+	return &exitError{code: exitPartial, err: err}
+}
+
+// markUsageErrors makes errors of the argument validation of c and its subcommands exit with
+// exitUsage, like errors parsing their flags.
+func markUsageErrors(c *cobra.Command) {
+	c.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return &exitError{code: exitUsage, err: err}
+	})
+	if validate := c.Args; validate != nil {
+		c.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				return &exitError{code: exitUsage, err: err}
+			}
+			return nil
+		}
+	}
+	for _, sub := range c.Commands() {
+		markUsageErrors(sub)
+	}
+}
+
+// ExitCode returns the process exit code main should use for an error returned by Execute.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	return exitFailure
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	fetchAllRemotes bool
+	fetchPrune      bool
+	fetchFilter     filter.Filter
+	fetchBulk       bulkOptions
+)
+
+// fetchCmd represents the fetch command
+var fetchCmd = &cobra.Command{
+	Use:   "fetch",
+	Short: "Fetches every tracked repository, with progress.",
+	Long: `Fetches origin of every tracked repository, several at a time (at most --jobs, by default
+the max_network_jobs setting), and records when each was fetched ('fussy-git info' shows it).
+Working trees and local branches are never changed.
+
+On a terminal, a live display shows the progress of the running fetches and the overall
+counters; every finished repository gets an [OK] or [FAIL] line. Repositories whose directory
+is missing are skipped. At the end, a report lists the status, git's exit code and the duration
+of every repository, followed by git's output for the ones that failed; with --output json,
+the report is printed as JSON and the progress goes to stderr. With --fail-fast, the fetches
+not started yet are skipped after the first failure.
+
+With --all-remotes, every remote of a repository is fetched, not only origin. With --prune,
+remote-tracking branches whose branch was deleted on the remote are removed.
+
+Use --domain, --owner, --tag and --path-prefix to fetch only a subset of repositories.
+
+Exit codes: 0 if every fetch succeeded (or with --continue-on-error), 1 if all failed, 3 if
+some failed.
+
+Examples:
+  fussy-git fetch
+  fussy-git fetch --all-remotes --prune
+  fussy-git fetch --owner work-org --jobs 8`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("jobs") {
+			fetchBulk.jobs = appConfig.MaxNetworkJobs
+		}
+		if err := fetchBulk.validate(); err != nil {
+			return err
+		}
+		repos := fetchFilter.Apply(repoState.Repositories)
+		if len(repos) == 0 {
+			fmt.Fprintln(os.Stderr, "No repositories to fetch.")
+			return nil
+		}
+
+		// With JSON output, stdout is reserved for the report.
+		progressOut := os.Stdout
+		if fetchBulk.output == "json" {
+			progressOut = os.Stderr
+		}
+		board := newProgressBoard(progressOut, "Fetching", len(repos))
+		fetchBulk.silent = true
+		fetchedAt := map[string]time.Time{}
+		var mu sync.Mutex
+
+		limiter := newNetworkLimiter(fetchBulk.jobs)
+		report := runBulk("fetch", repos, &fetchBulk, func(repo state.RepositoryEntry, stdout, stderr io.Writer) (int, error) {
+			label := repo.Path
+			if _, err := os.Stat(repo.Path); err != nil {
+				board.Skip(label, "directory is missing")
+				return 0, &bulkSkip{reason: "directory is missing"}
+			}
+			limiter.Acquire()
+			board.Start(label)
+			var before int64
+			if limiter.Limited() {
+				before = gitDirSize(repo.Path)
+			}
+			err := gitutil.FetchWithProgress(repo.Path, fetchAllRemotes, fetchPrune, func(line string) {
+				board.Update(label, line)
+				fmt.Fprintln(stderr, line)
+			})
+			var transferred int64
+			if limiter.Limited() {
+				transferred = max(0, gitDirSize(repo.Path)-before)
+			}
+			limiter.Release(transferred)
+			board.Done(label, err)
+			if code := bulkExitCode(err); code != 0 {
+				// git's output is in the report already.
+				return code, fmt.Errorf("git fetch exited with code %d", code)
+			}
+			mu.Lock()
+			fetchedAt[repo.Path] = time.Now()
+			mu.Unlock()
+			return 0, nil
+		})
+		for _, result := range report.Repositories {
+			if result.Status == bulkStatusSkipped && result.Error != "directory is missing" {
+				board.Skip(result.Path, result.Error)
+			}
+		}
+		board.Close()
+
+		for path, at := range fetchedAt {
+			if idx := repoState.IndexOfPath(path); idx >= 0 {
+				repoState.Repositories[idx].LastFetched = at
+				repoState.Repositories[idx].LastChecked = at
+			}
+		}
+		if len(fetchedAt) > 0 {
+			if err := repoState.Save(appConfig.StateFilePath); err != nil {
+				return fmt.Errorf("repositories were fetched, but failed to save state: %w", err)
+			}
+		}
+		return report.print(&fetchBulk)
+	},
+}
+
+func init() {
+	fetchCmd.Flags().BoolVar(&fetchAllRemotes, "all-remotes", false, "Fetch every remote of each repository, not only origin")
+	fetchCmd.Flags().BoolVar(&fetchPrune, "prune", false, "Remove remote-tracking branches whose branch was deleted on the remote")
+	addFilterFlags(fetchCmd, &fetchFilter)
+	addBulkFlags(fetchCmd, &fetchBulk, 0)
+	fetchCmd.Flags().Lookup("jobs").Usage = "Number of repositories to fetch in parallel (default: the max_network_jobs setting, 4)"
+}
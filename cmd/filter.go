@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"github.com/jmsnll/fussy-git/internal/filter"
+
+	"github.com/spf13/cobra"
+)
+
+// addFilterFlags registers the common repository selection flags on a command.
+// Each flag can be repeated or given a comma-separated list of values.
+func addFilterFlags(c *cobra.Command, f *filter.Filter) {
+	c.Flags().StringSliceVar(&f.Domains, "domain", nil, "Only include repositories hosted on this domain (repeatable)")
+	c.Flags().StringSliceVar(&f.Owners, "owner", nil, "Only include repositories owned by this user or organization (repeatable)")
+	c.Flags().StringSliceVar(&f.Tags, "tag", nil, "Only include repositories with this tag (repeatable)")
+	c.Flags().StringSliceVar(&f.PathPrefixes, "path-prefix", nil, "Only include repositories located under this directory (repeatable)")
+	c.Flags().StringSliceVar(&f.Metadata, "meta", nil, "Only include repositories with this metadata, as key=value or just key (repeatable)")
+	c.Flags().StringSliceVar(&f.Groups, "group", nil, "Only include repositories in this group, see 'fussy-git group' (repeatable)")
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/goimport"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// getCmd represents the get command
+var getCmd = &cobra.Command{
+	Use:   "get <import_path>",
+	Short: "Clones the repository behind a Go module or import path.",
+	Long: `Resolves a Go module or import path to its repository the way 'go get' does and clones it
+into the fussy-git directory structure. Import paths on GitHub and Bitbucket map directly to
+their repositories; vanity import paths (e.g. golang.org/x/tools or gopkg.in/yaml.v3) are
+resolved through the <meta name="go-import"> tag served at https://<import_path>?go-get=1.
+
+The repository is placed at the conventional location of its real URL (golang.org/x/tools ends
+up under go.googlesource.com/tools) and the module path is recorded in the state, so the
+repository can later be referred to by it, e.g. 'fussy-git pin golang.org/x/tools'.
+Package paths inside a module resolve to the module's repository. A version suffix
+(@v1.2.3, @latest) is ignored.
+
+Hosts listed in 'private_hosts' are not queried: their import paths are assumed to have the
+form <host>/<owner>/<repo> and are cloned over SSH.
+
+Examples:
+  fussy-git get golang.org/x/tools
+  fussy-git get github.com/spf13/cobra/doc
+  fussy-git get gopkg.in/yaml.v3@latest`,
+	Annotations: writesTree,
+	Args:        cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		importPath := normalizeImportPath(args[0])
+		if importPath == "" {
+			return fmt.Errorf("invalid import path '%s'", args[0])
+		}
+
+		if idx := lookupByModulePath(importPath); idx >= 0 {
+			entry := repoState.Repositories[idx]
+			fmt.Printf("%s is already tracked as %s at %s\n", importPath, entry.ModulePath, entry.Path)
+			return nil
+		}
+
+		var root *goimport.RepoRoot
+		host, _, _ := strings.Cut(importPath, "/")
+		if isPrivateHost(host) {
+			// Private hosts are never queried; assume the usual <host>/<owner>/<repo> layout.
+			parts := strings.Split(importPath, "/")
+			if len(parts) < 3 {
+				return fmt.Errorf("'%s' is on a private host; expected an import path of the form %s/<owner>/<repo>", importPath, host)
+			}
+			prefix := strings.Join(parts[:3], "/")
+			root = &goimport.RepoRoot{Prefix: prefix, VCS: "git", RepoURL: fmt.Sprintf("git@%s:%s.git", host, strings.Join(parts[1:3], "/"))}
+		} else {
+			var err error
+			if root, err = goimport.Resolve(importPath); err != nil {
+				return err
+			}
+		}
+		if root.VCS != "git" {
+			return fmt.Errorf("%s is hosted in a %s repository (%s); only git is supported", root.Prefix, root.VCS, root.RepoURL)
+		}
+		fmt.Printf("Resolved %s to %s (module root %s)\n", importPath, root.RepoURL, root.Prefix)
+
+		job, err := prepareClone(root.RepoURL, "", nil)
+		if err != nil {
+			return err
+		}
+		job.modulePath = root.Prefix
+		if job.alreadyTracked {
+			entry, _ := repoState.FindRepositoryByPath(job.target)
+			if entry.ModulePath != root.Prefix {
+				entry.ModulePath = root.Prefix
+				if err := repoState.UpdateRepository(*entry); err != nil {
+					return err
+				}
+				if err := repoState.Save(appConfig.StateFilePath); err != nil {
+					return fmt.Errorf("failed to save state: %w", err)
+				}
+			}
+			fmt.Printf("Repository %s already cloned at %s; recorded module path %s.\n", entry.Name, entry.Path, root.Prefix)
+			return nil
+		}
+		return cloneAndRegister(job, false)
+	},
+}
+
+// normalizeImportPath turns what users paste as a Go import path into the bare path:
+// a scheme, a version suffix and a trailing /... pattern are removed.
+// e.g. "https://golang.org/x/tools/...@latest" -> "golang.org/x/tools"
+func normalizeImportPath(arg string) string {
+	path := strings.TrimSpace(arg)
+	path, _, _ = strings.Cut(path, "@")
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "https://"), "http://")
+	path = strings.TrimSuffix(path, "/...")
+	return strings.Trim(path, "/")
+}
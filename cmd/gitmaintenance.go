@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+)
+
+// startGitMaintenance registers a new clone or added repository for git's background maintenance
+// if 'git_maintenance' is enabled. It reports whether the repository is registered, which is
+// recorded in its Maintenance field; failures are only warned about.
+func startGitMaintenance(repoPath string) bool {
+	if !appConfig.GitMaintenance {
+		return false
+	}
+	if err := gitutil.StartMaintenance(repoPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	// 'git maintenance start' registers the repository before it sets up the scheduler, which
+	// may be what failed.
+	return gitutil.MaintenanceRegistered(repoPath)
+}
+
+// relocateGitMaintenance moves the background maintenance registration of a repository that was
+// moved from oldPaths (its path and resolved path before the move) to its new path.
+func relocateGitMaintenance(entry *state.RepositoryEntry, oldPaths ...string) {
+	if !entry.Maintenance {
+		return
+	}
+	for _, old := range oldPaths {
+		if old == "" {
+			continue
+		}
+		if err := gitutil.UnregisterMaintenance(old); err != nil {
+			fmt.Printf("    [WARN] %v\n", err)
+		}
+	}
+	if err := gitutil.RegisterMaintenance(entry.Path); err != nil {
+		fmt.Printf("    [WARN] %v\n", err)
+		entry.Maintenance = false
+	}
+}
+
+// maintenanceProblem returns why a repository isn't maintained in the background although
+// 'git_maintenance' is enabled, or "" if it is.
+func maintenanceProblem(repo state.RepositoryEntry) string {
+	if !appConfig.GitMaintenance || gitutil.MaintenanceRegistered(repo.Path) {
+		return ""
+	}
+	if repo.Maintenance {
+		return fmt.Sprintf("No longer registered for git maintenance; run 'git -C %s maintenance start'", repo.Path)
+	}
+	return fmt.Sprintf("Not registered for git maintenance although 'git_maintenance' is enabled; run 'git -C %s maintenance start'", repo.Path)
+}
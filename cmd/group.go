@@ -0,0 +1,318 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"slices"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	groupDescription string
+	groupSelection   bulkSelection
+)
+
+// groupCmd represents the group command
+var groupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "Manages named groups of repositories that are operated on as a unit.",
+	Long: `Groups collect the repositories of a multi-repo project under one name, so they can be
+operated on together: every command that accepts filters takes --group <name>, e.g.
+  fussy-git exec --group platform -- git pull
+  fussy-git list --group platform
+  fussy-git top --group platform
+
+Groups are stored in the state file. A repository can be in any number of groups, and stays
+in them when it is moved or its URL changes. Group names must not contain ',' or whitespace.
+Repositories can be added to or removed from a group by name, or selected with the filter
+flags or --all.
+
+<repo> can be the repository's path, its normalized path (e.g. github.com/spf13/cobra),
+its URL, or its name if that is unambiguous.
+
+Examples:
+  fussy-git group create platform --description "Services of the platform team"
+  fussy-git group add platform api-gateway github.com/work-org/auth
+  fussy-git group add platform --owner platform-org --dry-run
+  fussy-git group show platform
+  fussy-git group remove platform api-gateway
+  fussy-git group delete platform`,
+}
+
+// groupCreateCmd represents the group create command
+var groupCreateCmd = &cobra.Command{
+	Use:         "create <name>",
+	Short:       "Creates an empty group.",
+	Annotations: mutates,
+	Args:        cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := state.ValidateGroupName(name); err != nil {
+			return usageError("invalid group name: %v", err)
+		}
+		if _, ok := repoState.Groups[name]; ok {
+			return fmt.Errorf("group '%s' already exists", name)
+		}
+		if repoState.Groups == nil {
+			repoState.Groups = make(map[string]state.Group)
+		}
+		repoState.Groups[name] = state.Group{Description: groupDescription, CreatedAt: time.Now()}
+		if err := repoState.Save(appConfig.StateFilePath); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		fmt.Printf("Created group '%s'. Add repositories with 'fussy-git group add %s <repo>...'.\n", name, name)
+		return nil
+	},
+}
+
+// groupDeleteCmd represents the group delete command
+var groupDeleteCmd = &cobra.Command{
+	Use:               "delete <name>",
+	Short:             "Deletes a group. Its repositories are not touched.",
+	Annotations:       mutates,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeGroup,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := requireGroup(name); err != nil {
+			return err
+		}
+		members := repoState.DeleteGroup(name)
+		if err := repoState.Save(appConfig.StateFilePath); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		fmt.Printf("Deleted group '%s' (%d repositories were members).\n", name, members)
+		return nil
+	},
+}
+
+// groupAddCmd represents the group add command
+var groupAddCmd = &cobra.Command{
+	Use:               "add <name> [<repo>...]",
+	Short:             "Adds repositories to a group.",
+	Annotations:       mutates,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeGroupMembers,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changeGroupMembers(cmd, args[0], args[1:], true)
+	},
+}
+
+// groupRemoveCmd represents the group remove command
+var groupRemoveCmd = &cobra.Command{
+	Use:               "remove <name> [<repo>...]",
+	Short:             "Removes repositories from a group.",
+	Annotations:       mutates,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeGroupMembers,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changeGroupMembers(cmd, args[0], args[1:], false)
+	},
+}
+
+// groupListCmd represents the group list command
+var groupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists all groups with their number of repositories.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(repoState.Groups) == 0 {
+			fmt.Println("No groups defined. Create one with 'fussy-git group create <name>'.")
+			return nil
+		}
+		members := make(map[string]int)
+		for _, repo := range repoState.Repositories {
+			for _, group := range repo.Groups {
+				members[group]++
+			}
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "GROUP\tREPOSITORIES\tDESCRIPTION")
+		fmt.Fprintln(w, "-----\t------------\t-----------")
+		for _, name := range sortedGroupNames() {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", name, members[name], repoState.Groups[name].Description)
+		}
+		return w.Flush()
+	},
+}
+
+// groupShowCmd represents the group show command
+var groupShowCmd = &cobra.Command{
+	Use:               "show <name>",
+	Short:             "Lists the repositories of a group.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeGroup,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := requireGroup(name); err != nil {
+			return err
+		}
+		if description := repoState.Groups[name].Description; description != "" {
+			fmt.Printf("%s: %s\n\n", name, description)
+		}
+		var members []state.RepositoryEntry
+		for _, repo := range repoState.Repositories {
+			if repo.InGroup(name) {
+				members = append(members, repo)
+			}
+		}
+		if len(members) == 0 {
+			fmt.Printf("Group '%s' has no repositories. Add some with 'fussy-git group add %s <repo>...'.\n", name, name)
+			return nil
+		}
+		sort.Slice(members, func(i, j int) bool { return members[i].NormalizedFS < members[j].NormalizedFS })
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tPATH\tURL")
+		fmt.Fprintln(w, "----\t----\t---")
+		for _, repo := range members {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", repo.Name, repo.Path, repo.CurrentURL)
+		}
+		return w.Flush()
+	},
+}
+
+// changeGroupMembers adds the referenced repositories, or those the selection flags select,
+// to the group, or removes them from it. All references are resolved before anything is
+// changed.
+func changeGroupMembers(cmd *cobra.Command, name string, refs []string, add bool) error {
+	if err := requireGroup(name); err != nil {
+		return err
+	}
+	if groupSelection.selected() || groupSelection.dryRun || len(refs) == 0 {
+		indices, err := groupSelection.indices(cmd, refs)
+		if err != nil {
+			return err
+		}
+		return groupSelection.apply("Group", indices, func(entry *state.RepositoryEntry) string {
+			switch {
+			case add && !entry.InGroup(name):
+				entry.Groups = append(entry.Groups, name)
+				sort.Strings(entry.Groups)
+				return "group +" + name
+			case !add && entry.InGroup(name):
+				entry.Groups = slices.DeleteFunc(entry.Groups, func(group string) bool { return group == name })
+				if len(entry.Groups) == 0 {
+					entry.Groups = nil
+				}
+				return "group -" + name
+			}
+			return ""
+		})
+	}
+	indexes := make([]int, 0, len(refs))
+	for _, ref := range refs {
+		idx, err := lookupRepository(ref)
+		if err != nil {
+			return err
+		}
+		indexes = append(indexes, idx)
+	}
+
+	changed := 0
+	for _, idx := range indexes {
+		entry := &repoState.Repositories[idx]
+		switch {
+		case add && entry.InGroup(name):
+			fmt.Printf("  %s is already in group '%s'.\n", entry.Name, name)
+		case add:
+			entry.Groups = append(entry.Groups, name)
+			sort.Strings(entry.Groups)
+			entry.LastModified = time.Now()
+			changed++
+			fmt.Printf("  Added %s to group '%s'.\n", entry.Name, name)
+		case !entry.InGroup(name):
+			fmt.Printf("  %s is not in group '%s'.\n", entry.Name, name)
+		default:
+			kept := entry.Groups[:0]
+			for _, group := range entry.Groups {
+				if group != name {
+					kept = append(kept, group)
+				}
+			}
+			entry.Groups = kept
+			if len(entry.Groups) == 0 {
+				entry.Groups = nil
+			}
+			entry.LastModified = time.Now()
+			changed++
+			fmt.Printf("  Removed %s from group '%s'.\n", entry.Name, name)
+		}
+	}
+	if changed == 0 {
+		return nil
+	}
+	if err := repoState.Save(appConfig.StateFilePath); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// requireGroup returns an error if no group of that name exists.
+func requireGroup(name string) error {
+	if _, ok := repoState.Groups[name]; !ok {
+		return fmt.Errorf("group '%s' does not exist; create it with 'fussy-git group create %s'", name, name)
+	}
+	return nil
+}
+
+// checkGroupFilter returns a usage error if the --group flag of cmd, if it has one, names a
+// group that doesn't exist, rather than letting the command silently select nothing.
+func checkGroupFilter(cmd *cobra.Command) error {
+	flag := cmd.Flags().Lookup("group")
+	if flag == nil || !flag.Changed {
+		return nil
+	}
+	groups, err := cmd.Flags().GetStringSlice("group")
+	if err != nil {
+		return nil
+	}
+	for _, name := range groups {
+		if _, ok := repoState.Groups[name]; !ok {
+			return usageError("group '%s' does not exist (see 'fussy-git group list')", name)
+		}
+	}
+	return nil
+}
+
+// sortedGroupNames returns the names of all groups in alphabetical order.
+func sortedGroupNames() []string {
+	names := make([]string, 0, len(repoState.Groups))
+	for name := range repoState.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// completeGroup completes the name of a group as the first argument.
+func completeGroup(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || repoState == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, name := range sortedGroupNames() {
+		names = append(names, name+"\t"+repoState.Groups[name].Description)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeGroupMembers completes a group name, followed by repositories.
+func completeGroupMembers(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completeGroup(cmd, args, toComplete)
+	}
+	return completeRepository(cmd, nil, toComplete)
+}
+
+func init() {
+	groupCreateCmd.Flags().StringVar(&groupDescription, "description", "", "Description of the group")
+	for _, c := range []*cobra.Command{groupAddCmd, groupRemoveCmd} {
+		addBulkSelectionFlags(c, &groupSelection)
+	}
+	groupCmd.AddCommand(groupCreateCmd, groupDeleteCmd, groupAddCmd, groupRemoveCmd, groupListCmd, groupShowCmd)
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Deductions from the health score of 100 a repository starts with. The score never drops
+// below 0.
+const (
+	healthPerError      = 30 // Per doctor finding of severity error
+	healthPerWarning    = 10 // Per doctor finding of severity warning
+	healthDirty         = 10 // Uncommitted changes
+	healthUnpushed      = 15 // Commits on the current branch that aren't on its upstream
+	healthBehind        = 5  // Commits on the upstream that aren't on the current branch
+	healthDiverged      = 10 // Both of the above, which needs a merge or rebase
+	healthStaleQuarter  = 5  // No commit or fetch for 3 months
+	healthStaleHalfYear = 10 // ... for 6 months
+	healthStaleYear     = 20 // ... for a year
+)
+
+// healthScoreHelp describes the health score in the help of the commands that show it.
+const healthScoreHelp = `The health score (0-100) of a repository starts at 100 and drops for doctor findings
+(30 per error, 10 per warning), uncommitted changes (10), commits that aren't pushed to the
+upstream branch (15), commits on the upstream that aren't pulled (5), both at once (another
+10), and staleness: no commit or fetch for 3 months (5), 6 months (10) or a year (20). It is
+computed from the working tree and the last fetch; nothing is fetched.`
+
+// repositoryHealth is the health score of a repository and why it isn't 100.
+type repositoryHealth struct {
+	entry   state.RepositoryEntry
+	score   int
+	reasons []string
+}
+
+// assessHealth computes the health score of a repository.
+func assessHealth(repo state.RepositoryEntry) repositoryHealth {
+	h := repositoryHealth{entry: repo, score: 100}
+	deduct := func(points int, reason string, args ...any) {
+		h.score -= points
+		h.reasons = append(h.reasons, fmt.Sprintf(reason, args...))
+	}
+
+	findings := checkRepository(repo)
+	if n := countSeverity(findings, severityError); n > 0 {
+		deduct(n*healthPerError, "%d doctor errors", n)
+	}
+	if n := countSeverity(findings, severityWarning); n > 0 {
+		deduct(n*healthPerWarning, "%d doctor warnings", n)
+	}
+
+	if gitutil.IsGitRepository(repo.Path) {
+		if dirty, err := gitutil.IsDirty(repo.Path); err == nil && dirty {
+			deduct(healthDirty, "uncommitted changes")
+		}
+		// Without an upstream there is nothing to compare with.
+		if ahead, behind, err := gitutil.AheadBehind(repo.Path); err == nil {
+			if ahead > 0 {
+				deduct(healthUnpushed, "%d unpushed commits", ahead)
+			}
+			if behind > 0 {
+				deduct(healthBehind, "%d commits behind upstream", behind)
+			}
+			if ahead > 0 && behind > 0 {
+				deduct(healthDiverged, "diverged from upstream")
+			}
+		}
+		if last := lastActivity(repo.Path); !last.IsZero() {
+			switch idle := time.Since(last); {
+			case idle > 365*24*time.Hour:
+				deduct(healthStaleYear, "no activity for %s", formatAge(idle))
+			case idle > 182*24*time.Hour:
+				deduct(healthStaleHalfYear, "no activity for %s", formatAge(idle))
+			case idle > 91*24*time.Hour:
+				deduct(healthStaleQuarter, "no activity for %s", formatAge(idle))
+			}
+		}
+	}
+	h.score = max(h.score, 0)
+	return h
+}
+
+// lastActivity returns when a repository last got a commit or was fetched, whichever is
+// later, or the zero time if neither is known.
+func lastActivity(repoPath string) time.Time {
+	last, _ := gitutil.LastCommitTime(repoPath)
+	if fetchHead, err := gitutil.GitPath(repoPath, "FETCH_HEAD"); err == nil {
+		if info, err := os.Stat(fetchHead); err == nil && info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+	return last
+}
+
+// assessRepositories computes the health of repositories, several at a time, and returns it
+// in the order of repos.
+func assessRepositories(repos []state.RepositoryEntry) []repositoryHealth {
+	results := make([]repositoryHealth, len(repos))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < inspectWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = assessHealth(repos[idx])
+			}
+		}()
+	}
+	for i := range repos {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// sortByHealth orders repositories from the least to the most healthy, by name within a score.
+func sortByHealth(health []repositoryHealth) {
+	sort.SliceStable(health, func(i, j int) bool {
+		if health[i].score != health[j].score {
+			return health[i].score < health[j].score
+		}
+		return health[i].entry.Name < health[j].entry.Name
+	})
+}
+
+// summary describes why a score isn't 100, or "-" if it is.
+func (h repositoryHealth) summary() string {
+	if len(h.reasons) == 0 {
+		return "-"
+	}
+	return strings.Join(h.reasons, ", ")
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	installHooksAll    bool
+	installHooksFilter filter.Filter
+)
+
+// installHooksCmd represents the install-hooks command
+var installHooksCmd = &cobra.Command{
+	Use:   "install-hooks [--all|<repo>...]",
+	Short: "Installs the team's standard git hooks into tracked repositories.",
+	Long: `Sets up the git hooks configured in the config file in tracked repositories. Two setups
+are supported:
+
+  hooks_template_dir: ~/team/hooks   # copy these hooks into .git/hooks of every repository
+  hooks_path: ~/team/hooks           # point core.hooksPath of every repository at this directory
+
+With hooks_template_dir, every file in the directory (except *.sample) is copied into the
+repository's hooks directory and made executable. An existing hook with different content is
+kept as <hook>.orig. With hooks_path, nothing is copied, so updates to the shared directory take
+effect immediately, but the repository's own hooks are no longer run.
+
+Hooks are installed automatically when a repository is cloned or added. Use this command for
+repositories tracked before the setting was configured, or after the template changed;
+'doctor' reports repositories whose hooks are missing or outdated.
+
+Examples:
+  fussy-git install-hooks github.com/spf13/cobra
+  fussy-git install-hooks --all --domain git.example.com`,
+	Annotations: mutates,
+	Args:        cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !hooksConfigured() {
+			return fmt.Errorf("no hooks are configured; set 'hooks_template_dir' or 'hooks_path' in %s", appConfig.ConfigFile)
+		}
+		if installHooksAll == (len(args) > 0) {
+			return fmt.Errorf("specify either repositories or --all")
+		}
+
+		var paths []string
+		if installHooksAll {
+			for _, repo := range installHooksFilter.Apply(repoState.Repositories) {
+				paths = append(paths, repo.Path)
+			}
+		} else {
+			for _, ref := range args {
+				idx, err := lookupRepository(ref)
+				if err != nil {
+					return err
+				}
+				paths = append(paths, repoState.Repositories[idx].Path)
+			}
+		}
+
+		installed, skipped, failed := 0, 0, 0
+		for _, path := range paths {
+			if !gitutil.IsGitRepository(path) {
+				fmt.Printf("[SKIP] %s: not a git repository.\n", path)
+				skipped++
+				continue
+			}
+			if err := installHooks(path); err != nil {
+				fmt.Printf("[FAIL] %s: %v\n", path, err)
+				failed++
+				continue
+			}
+			fmt.Printf("[OK] %s\n", path)
+			installed++
+		}
+
+		fmt.Printf("\nInstall hooks summary:\n")
+		fmt.Printf("  Installed: %d\n", installed)
+		fmt.Printf("  Skipped:   %d\n", skipped)
+		fmt.Printf("  Failed:    %d\n", failed)
+		if failed > 0 {
+			return fmt.Errorf("hooks could not be installed in %d repositories", failed)
+		}
+		return nil
+	},
+}
+
+// hooksConfigured reports whether a hooks template or a shared hooks path is configured.
+func hooksConfigured() bool {
+	return appConfig.HooksTemplateDir != "" || appConfig.HooksPath != ""
+}
+
+// installHooks sets up the configured hooks in a repository: it either sets core.hooksPath or
+// copies the hooks of the template directory. It does nothing if no hooks are configured.
+func installHooks(repoPath string) error {
+	if appConfig.HooksPath != "" {
+		return gitutil.SetConfigValue(repoPath, "core.hooksPath", appConfig.HooksPath)
+	}
+	if appConfig.HooksTemplateDir == "" {
+		return nil
+	}
+	hooksDir, err := repositoryHooksDir(repoPath)
+	if err != nil {
+		return err
+	}
+	names, err := templateHooks()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return fmt.Errorf("failed to create hooks directory %s: %w", hooksDir, err)
+	}
+	for _, name := range names {
+		content, err := os.ReadFile(filepath.Join(appConfig.HooksTemplateDir, name))
+		if err != nil {
+			return fmt.Errorf("failed to read hook template: %w", err)
+		}
+		target := filepath.Join(hooksDir, name)
+		if existing, err := os.ReadFile(target); err == nil {
+			if bytes.Equal(existing, content) {
+				continue
+			}
+			if _, err := os.Stat(target + ".orig"); os.IsNotExist(err) {
+				if err := os.Rename(target, target+".orig"); err != nil {
+					return fmt.Errorf("failed to keep existing hook %s: %w", target, err)
+				}
+			}
+		}
+		if err := os.WriteFile(target, content, 0755); err != nil {
+			return fmt.Errorf("failed to install hook %s: %w", target, err)
+		}
+		// WriteFile doesn't change the mode of an existing file.
+		if err := os.Chmod(target, 0755); err != nil {
+			return fmt.Errorf("failed to make hook %s executable: %w", target, err)
+		}
+	}
+	return nil
+}
+
+// hookProblems returns what differs between the repository's hooks and the configured ones.
+func hookProblems(repoPath string) []string {
+	if appConfig.HooksPath != "" {
+		current := gitutil.ConfigValue(repoPath, "core.hooksPath")
+		if current == "" {
+			return []string{fmt.Sprintf("core.hooksPath is not set, expected '%s'; run 'fussy-git install-hooks'", appConfig.HooksPath)}
+		}
+		if current != appConfig.HooksPath {
+			return []string{fmt.Sprintf("core.hooksPath is '%s', expected '%s'; run 'fussy-git install-hooks'", current, appConfig.HooksPath)}
+		}
+		return nil
+	}
+	if appConfig.HooksTemplateDir == "" {
+		return nil
+	}
+	hooksDir, err := repositoryHooksDir(repoPath)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	names, err := templateHooks()
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var missing, outdated []string
+	for _, name := range names {
+		want, err := os.ReadFile(filepath.Join(appConfig.HooksTemplateDir, name))
+		if err != nil {
+			continue
+		}
+		have, err := os.ReadFile(filepath.Join(hooksDir, name))
+		switch {
+		case err != nil:
+			missing = append(missing, name)
+		case !bytes.Equal(have, want):
+			outdated = append(outdated, name)
+		}
+	}
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("Hooks missing: %s; run 'fussy-git install-hooks'", strings.Join(missing, ", ")))
+	}
+	if len(outdated) > 0 {
+		problems = append(problems, fmt.Sprintf("Hooks differ from the template: %s; run 'fussy-git install-hooks'", strings.Join(outdated, ", ")))
+	}
+	return problems
+}
+
+// repositoryHooksDir returns the directory git runs the repository's hooks from. It fails if
+// core.hooksPath points elsewhere, since hooks copied into the repository would then be ignored.
+func repositoryHooksDir(repoPath string) (string, error) {
+	if hooksPath := gitutil.ConfigValue(repoPath, "core.hooksPath"); hooksPath != "" {
+		return "", fmt.Errorf("core.hooksPath is set to '%s', so hooks in the repository would not be run", hooksPath)
+	}
+	return gitutil.GitPath(repoPath, "hooks")
+}
+
+// templateHooks returns the names of the hooks in the template directory.
+func templateHooks() ([]string, error) {
+	entries, err := os.ReadDir(appConfig.HooksTemplateDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hooks template directory: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && !strings.HasSuffix(e.Name(), ".sample") {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+func init() {
+	installHooksCmd.Flags().BoolVar(&installHooksAll, "all", false, "Install hooks into every tracked repository")
+	addFilterFlags(installHooksCmd, &installHooksFilter)
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	identityFix    bool
+	identityFilter filter.Filter
+)
+
+// identityMismatch is a git identity setting whose effective value in a repository isn't the
+// one the layered config expects.
+type identityMismatch struct {
+	entry     state.RepositoryEntry
+	key       string // git config key, e.g. "user.email"
+	effective string
+	origin    string // Where the effective value is set, e.g. "file:/home/me/.gitconfig"
+	expected  string
+	source    string // Config level the expected value comes from, e.g. "owner github.com/work-org"
+}
+
+// identityCmd represents the identity command
+var identityCmd = &cobra.Command{
+	Use:   "identity",
+	Short: "Checks the git identity used in tracked repositories.",
+	Long: `Commands for the git identity (user.name and user.email) you commit with in tracked
+repositories. The expected identity is configured with the user_name and user_email settings,
+globally or per domain, owner or repository (see 'fussy-git help config'):
+
+  user_email: jane@example.com
+  owners:
+    github.com/work-org:
+      user_email: jane@work.example.com`,
+}
+
+// identityAuditCmd represents the identity audit command
+var identityAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Lists repositories where you would commit with the wrong identity.",
+	Long: `Compares the user.name and user.email git uses in every tracked repository with the ones
+the config expects for it (the user_name and user_email settings of its domain, owner or
+repository, see 'fussy-git help config'), and lists the repositories where they differ, with
+where git takes the wrong value from:
+
+  NAME  SETTING     EFFECTIVE         SET IN                    EXPECTED               EXPECTED BY
+  api   user.email  jane@example.com  file:/home/me/.gitconfig  jane@work.example.com  owner github.com/work-org
+
+The effective values are the ones 'git commit' would use: the repository's own config, or else
+the global one, including identities set per directory with includeIf. Emails are compared
+ignoring case. Repositories for which no identity is configured aren't checked.
+
+--fix sets the expected values in the local config of each listed repository, like clone does
+for new repositories. Use --domain, --owner, --tag and --path-prefix to only audit a subset of
+repositories.
+
+Exits with status 2 if wrong identities remain, so it can run from scripts or a login hook.
+
+Examples:
+  fussy-git identity audit
+  fussy-git identity audit --owner work-org --fix`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if identityFix && appConfig.ReadOnly {
+			return readOnlyError("'identity audit --fix'")
+		}
+
+		repos := identityFilter.Apply(repoState.Repositories)
+		var mismatches []identityMismatch
+		var failures []string
+		checked, unconfigured, wrong := 0, 0, 0
+		for _, repo := range repos {
+			expected := appConfig.Layers.For(filepath.ToSlash(repo.NormalizedFS))
+			if expected.UserName == "" && expected.UserEmail == "" {
+				unconfigured++
+				continue
+			}
+			if !gitutil.IsGitRepository(repo.Path) {
+				failures = append(failures, fmt.Sprintf("%s: not a Git repository", repo.Path))
+				continue
+			}
+			checked++
+			found := identityMismatches(repo, expected.UserName, expected.UserEmail, expected.Sources)
+			if len(found) > 0 {
+				wrong++
+				mismatches = append(mismatches, found...)
+			}
+		}
+
+		if checked == 0 {
+			fmt.Println("No repositories with a configured identity to audit. Set user_name or user_email (see 'fussy-git help config').")
+		} else if len(mismatches) == 0 {
+			fmt.Printf("All %d audited repositories use the expected identity.\n", checked)
+		} else {
+			fmt.Printf("Repositories that would commit with the wrong identity:\n\n")
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tSETTING\tEFFECTIVE\tSET IN\tEXPECTED\tEXPECTED BY\tPATH")
+			fmt.Fprintln(w, "----\t-------\t---------\t------\t--------\t-----------\t----")
+			for _, m := range mismatches {
+				effective, origin := m.effective, m.origin
+				if effective == "" {
+					effective, origin = "(not set)", "-"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", m.entry.Name, m.key, effective, origin, m.expected, m.source, m.entry.Path)
+			}
+			w.Flush()
+		}
+
+		fixed := 0
+		if identityFix && len(mismatches) > 0 {
+			fmt.Println()
+			failed := map[string]bool{}
+			for _, m := range mismatches {
+				if err := gitutil.SetConfigValue(m.entry.Path, m.key, m.expected); err != nil {
+					fmt.Printf("[FAIL] Could not set %s in %s: %v\n", m.key, m.entry.Path, err)
+					failed[m.entry.Path] = true
+					continue
+				}
+				fmt.Printf("[OK] Set %s to '%s' in %s\n", m.key, m.expected, m.entry.Path)
+			}
+			fixed = wrong - len(failed)
+		}
+
+		fmt.Printf("\nIdentity audit summary:\n")
+		fmt.Printf("  Repositories audited:        %d\n", checked)
+		fmt.Printf("  Without configured identity: %d\n", unconfigured)
+		fmt.Printf("  Wrong identity:              %d\n", wrong)
+		if identityFix {
+			fmt.Printf("  Fixed:                       %d\n", fixed)
+		}
+		for _, failure := range failures {
+			fmt.Printf("[FAIL] %s\n", failure)
+		}
+
+		if remaining := wrong - fixed; remaining > 0 {
+			if !identityFix {
+				fmt.Println("\nRun 'fussy-git identity audit --fix' to set the expected identity in these repositories.")
+			}
+			return &exitError{code: exitIssues, err: fmt.Errorf("%d repositories use the wrong identity", remaining)}
+		}
+		return nil
+	},
+}
+
+// identityMismatches compares the effective user.name and user.email of a repository with the
+// expected ones; an empty expected value isn't checked.
+func identityMismatches(repo state.RepositoryEntry, userName, userEmail string, sources map[string]string) []identityMismatch {
+	var found []identityMismatch
+	for _, c := range []struct {
+		key, setting, expected string
+		equal                  func(a, b string) bool
+	}{
+		{"user.name", "user_name", userName, func(a, b string) bool { return a == b }},
+		{"user.email", "user_email", userEmail, strings.EqualFold},
+	} {
+		if c.expected == "" {
+			continue
+		}
+		effective := gitutil.ConfigValue(repo.Path, c.key)
+		if c.equal(effective, c.expected) {
+			continue
+		}
+		found = append(found, identityMismatch{
+			entry:     repo,
+			key:       c.key,
+			effective: effective,
+			origin:    gitutil.ConfigOrigin(repo.Path, c.key),
+			expected:  c.expected,
+			source:    sources[c.setting],
+		})
+	}
+	return found
+}
+
+func init() {
+	identityAuditCmd.Flags().BoolVar(&identityFix, "fix", false, "Set the expected identity in the local config of the repositories that use the wrong one")
+	addFilterFlags(identityAuditCmd, &identityFilter)
+	identityCmd.AddCommand(identityAuditCmd)
+}
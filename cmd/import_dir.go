@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/config"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	dryRunImportDir bool
+	yesImportDir    bool
+	noMoveImportDir bool
+)
+
+// importDirCmd represents the import-dir command
+var importDirCmd = &cobra.Command{
+	Use:   "import-dir <dir>",
+	Short: "Finds Git repositories in a directory, moves them to their conventional locations and tracks them.",
+	Long: `Recursively searches <dir> for Git repositories and brings them under fussy-git management
+in one go. For every repository found, its 'origin' URL determines the conventional location
+below FUSSY_GIT_HOME; the repository is moved there and registered in the state file.
+
+Each move is confirmed individually. Answer 'y' to move and register the repository,
+'n' to leave it alone, 'a' to accept this and all remaining repositories, 'q' to stop,
+or 'p' to register it at its current location and pin it there.
+
+Repositories that are already tracked or have no usable 'origin' remote are skipped.
+A repository that was moved by hand (e.g. within FUSSY_GIT_HOME) is recognized by its
+'origin' URL: if a tracked repository with that URL is missing from its recorded path, that
+entry is updated to the new location ("detected move") instead of tracking it twice.
+If a repository's conventional location is already taken, it is registered where it is.
+
+Examples:
+  fussy-git import-dir ~/projects --dry-run
+  fussy-git import-dir ~/projects --yes
+  fussy-git import-dir ~/projects --no-move     # only register, don't move anything`,
+	Annotations: mutates,
+	Args:        cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := config.ExpandPath(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Scanning %s for Git repositories...\n", root)
+		repoPaths, err := gitutil.FindRepositories(root)
+		if err != nil {
+			return fmt.Errorf("failed to scan %s: %w", root, err)
+		}
+		fmt.Printf("Found %d Git repositories.\n\n", len(repoPaths))
+
+		var prompter *actionPrompter
+		if !yesImportDir && !dryRunImportDir {
+			prompter = newActionPrompter()
+		}
+
+		registered, moved, skipped, movesDetected := 0, 0, 0, 0
+		for _, repoPath := range repoPaths {
+			if prompter != nil && prompter.Quit() {
+				skipped++
+				continue
+			}
+			fmt.Printf("%s\n", repoPath)
+
+			if _, tracked := repoState.FindRepositoryByPath(repoPath); tracked {
+				fmt.Println("  [SKIP] Already tracked by fussy-git.")
+				skipped++
+				continue
+			}
+
+			entry, parsedURL, err := inspectLocalRepository(repoPath)
+			if err != nil {
+				fmt.Printf("  [SKIP] %v\n", err)
+				skipped++
+				continue
+			}
+
+			if idx := findMovedRepository(entry); idx != -1 {
+				oldPath := repoState.Repositories[idx].Path
+				if dryRunImportDir {
+					fmt.Printf("  [MOVED] Detected move from %s; would update the tracked entry.\n", oldPath)
+				} else {
+					recordDetectedMove(idx, repoPath)
+					fmt.Printf("  [MOVED] Detected move from %s; updated the tracked entry.\n", oldPath)
+				}
+				movesDetected++
+				continue
+			}
+
+			target := conventionalRepoPath(parsedURL)
+			needsMove := !noMoveImportDir && !samePath(repoPath, target)
+			if needsMove {
+				if _, statErr := os.Stat(target); statErr == nil {
+					fmt.Printf("  [WARN] Conventional location '%s' is already taken; the repository stays where it is.\n", target)
+					needsMove = false
+				}
+			}
+
+			if dryRunImportDir {
+				if needsMove {
+					fmt.Printf("  Would move to %s\n", target)
+					moved++
+				}
+				fmt.Printf("  Would register '%s' (%s)\n", entry.Name, entry.CurrentURL)
+				registered++
+				continue
+			}
+
+			if prompter != nil {
+				question := fmt.Sprintf("Register '%s' (%s) at its current location?", entry.Name, entry.CurrentURL)
+				if needsMove {
+					question = fmt.Sprintf("Move '%s' to '%s' and register it?", entry.Name, target)
+				}
+				switch prompter.ConfirmOrPin(question) {
+				case choiceSkip:
+					fmt.Println("  [SKIP] Declined.")
+					skipped++
+					continue
+				case choicePin:
+					entry.Pinned = true
+					needsMove = false
+				}
+			}
+
+			if needsMove {
+				if err := moveRepository(&entry, target); err != nil {
+					fmt.Printf("  [WARN] %v. Registering it at its current location instead.\n", err)
+				} else {
+					moved++
+				}
+			}
+
+			if err := repoState.AddRepository(entry); err != nil {
+				fmt.Printf("  [SKIP] Failed to add repository to state: %v\n", err)
+				skipped++
+				continue
+			}
+			if entry.Pinned {
+				fmt.Printf("  Registered '%s' at %s (pinned)\n", entry.Name, entry.Path)
+			} else {
+				fmt.Printf("  Registered '%s' at %s\n", entry.Name, entry.Path)
+			}
+			registered++
+		}
+
+		if (registered > 0 || movesDetected > 0) && !dryRunImportDir {
+			if err := repoState.Save(appConfig.StateFilePath); err != nil {
+				return fmt.Errorf("failed to save state after import: %w", err)
+			}
+		}
+
+		fmt.Printf("\nImport summary:\n")
+		fmt.Printf("  Repositories found:      %d\n", len(repoPaths))
+		if dryRunImportDir {
+			fmt.Printf("  Would register:          %d\n", registered)
+			fmt.Printf("  Would move:              %d\n", moved)
+		} else {
+			fmt.Printf("  Repositories registered: %d\n", registered)
+			fmt.Printf("  Repositories moved:      %d\n", moved)
+		}
+		fmt.Printf("  Moves detected:          %d\n", movesDetected)
+		fmt.Printf("  Repositories skipped:    %d\n", skipped)
+		return nil
+	},
+}
+
+func init() {
+	importDirCmd.Flags().BoolVar(&dryRunImportDir, "dry-run", false, "Show what would be moved and registered without changing anything")
+	importDirCmd.Flags().BoolVarP(&yesImportDir, "yes", "y", false, "Don't ask for confirmation")
+	importDirCmd.Flags().BoolVar(&noMoveImportDir, "no-move", false, "Register repositories at their current locations without moving them")
+}
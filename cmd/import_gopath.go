@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/config"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	moveImportGopath   bool
+	dryRunImportGopath bool
+)
+
+// importGopathCmd represents the import-gopath command
+var importGopathCmd = &cobra.Command{
+	Use:   "import-gopath [<gopath_src>]",
+	Short: "Registers every Git repository found in an old GOPATH src tree.",
+	Long: `Walks a GOPATH-style src tree (default: $GOPATH/src, or ~/go/src if GOPATH is unset)
+and registers every Git repository found there with fussy-git.
+
+By default repositories stay where they are. With --move they are moved into FUSSY_GIT_HOME,
+preserving their import-path structure: $GOPATH/src/github.com/spf13/cobra ends up at
+$FUSSY_GIT_HOME/github.com/spf13/cobra. If the import path differs from the location
+computed from the origin URL (e.g. vanity import paths like golang.org/x/tools), the
+import-path location is recorded as the repository's path override so doctor and
+reorganize leave it there.
+
+Repositories that are already tracked, have no usable 'origin' remote, or whose target
+directory already exists are skipped and reported.`,
+	Annotations: mutates,
+	Args:        cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var srcRoots []string
+		if len(args) == 1 {
+			srcRoots = []string{args[0]}
+		} else {
+			gopath := os.Getenv("GOPATH")
+			if gopath == "" {
+				gopath = "~/go"
+			}
+			for _, p := range filepath.SplitList(gopath) {
+				srcRoots = append(srcRoots, filepath.Join(p, "src"))
+			}
+		}
+
+		found, registered, moved, skipped := 0, 0, 0, 0
+		for _, srcRoot := range srcRoots {
+			absRoot, err := config.ExpandPath(srcRoot)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Scanning %s for Git repositories...\n", absRoot)
+			repoPaths, err := gitutil.FindRepositories(absRoot)
+			if err != nil {
+				return fmt.Errorf("failed to scan %s: %w", absRoot, err)
+			}
+			found += len(repoPaths)
+
+			for _, repoPath := range repoPaths {
+				importPath, err := filepath.Rel(absRoot, repoPath)
+				if err != nil {
+					return fmt.Errorf("failed to determine import path of %s: %w", repoPath, err)
+				}
+				fmt.Printf("%s\n", importPath)
+
+				if _, tracked := repoState.FindRepositoryByPath(repoPath); tracked {
+					fmt.Println("  [SKIP] Already tracked by fussy-git.")
+					skipped++
+					continue
+				}
+
+				entry, parsedURL, err := inspectLocalRepository(repoPath)
+				if err != nil {
+					fmt.Printf("  [SKIP] %v\n", err)
+					skipped++
+					continue
+				}
+
+				if moveImportGopath {
+					target := filepath.Join(appConfig.FussyGitHome, importPath)
+					if _, tracked := repoState.FindRepositoryByPath(target); tracked {
+						fmt.Printf("  [SKIP] %s is already tracked by fussy-git.\n", target)
+						skipped++
+						continue
+					}
+					if _, statErr := os.Stat(target); statErr == nil && dryRunImportGopath {
+						fmt.Printf("  [SKIP] Target path '%s' already exists.\n", target)
+						skipped++
+						continue
+					} else if dryRunImportGopath {
+						fmt.Printf("  Would move to %s\n", target)
+					} else if err := moveRepository(&entry, target); err != nil {
+						fmt.Printf("  [SKIP] %v\n", err)
+						skipped++
+						continue
+					}
+					moved++
+
+					// Keep vanity import paths where Go tooling expects them.
+					if !samePath(target, conventionalRepoPath(parsedURL)) {
+						entry.PathOverride = target
+						fmt.Printf("  Import path differs from the URL-based location %s; recorded a path override.\n", conventionalRepoPath(parsedURL))
+					}
+				}
+
+				if dryRunImportGopath {
+					fmt.Printf("  Would register '%s' (%s)\n", entry.Name, entry.CurrentURL)
+				} else {
+					if err := repoState.AddRepository(entry); err != nil {
+						fmt.Printf("  [SKIP] Failed to add repository to state: %v\n", err)
+						skipped++
+						continue
+					}
+					fmt.Printf("  Registered '%s' (%s)\n", entry.Name, entry.CurrentURL)
+				}
+				registered++
+			}
+		}
+
+		if registered > 0 && !dryRunImportGopath {
+			if err := repoState.Save(appConfig.StateFilePath); err != nil {
+				return fmt.Errorf("failed to save state after import: %w", err)
+			}
+		}
+
+		fmt.Printf("\nImport summary:\n")
+		fmt.Printf("  Repositories found:      %d\n", found)
+		if dryRunImportGopath {
+			fmt.Printf("  Would register:          %d\n", registered)
+			fmt.Printf("  Would move:              %d\n", moved)
+		} else {
+			fmt.Printf("  Repositories registered: %d\n", registered)
+			fmt.Printf("  Repositories moved:      %d\n", moved)
+		}
+		fmt.Printf("  Repositories skipped:    %d\n", skipped)
+		return nil
+	},
+}
+
+func init() {
+	importGopathCmd.Flags().BoolVar(&moveImportGopath, "move", false, "Move repositories into FUSSY_GIT_HOME, preserving their import paths")
+	importGopathCmd.Flags().BoolVar(&dryRunImportGopath, "dry-run", false, "Show what would be registered and moved without changing anything")
+}
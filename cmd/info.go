@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// infoCmd represents the info command
+var infoCmd = &cobra.Command{
+	Use:   "info <repo>",
+	Short: "Shows everything fussy-git knows about a repository.",
+	Long: `Prints the state recorded for a repository: its location, URLs, timestamps, tags, notes,
+flags such as pinned or shallow, how long its clone took and how much it fetched, the result
+of the last verification, and its metadata (see 'fussy-git meta').
+
+<repo> can be the repository's path, its normalized path (e.g. github.com/spf13/cobra),
+its URL, or its name if that is unambiguous.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRepository,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		refreshStaleRepositories()
+		idx, err := lookupRepository(args[0])
+		if err != nil {
+			return err
+		}
+		entry := repoState.Repositories[idx]
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		field := func(label, value string) {
+			if value != "" {
+				fmt.Fprintf(w, "%s:\t%s\n", label, value)
+			}
+		}
+		field("Name", entry.Name)
+		field("Path", entry.Path)
+		field("Resolved path", entry.ResolvedPath)
+		field("Git directory", entry.GitDir)
+		field("Normalized path", entry.NormalizedFS)
+		field("Domain", entry.Domain)
+		field("Original URL", entry.OriginalURL)
+		if entry.CurrentURL != entry.OriginalURL {
+			field("Current URL", entry.CurrentURL)
+		}
+		field("Module path", entry.ModulePath)
+		field("Description", entry.Description)
+		field("License", entry.License)
+		field("Path override", entry.PathOverride)
+		field("Tags", strings.Join(entry.Tags, ", "))
+		field("Groups", strings.Join(entry.Groups, ", "))
+		field("Notes", entry.Notes)
+		field("Ignored checks", strings.Join(entry.IgnoredChecks, ", "))
+
+		var flags []string
+		if entry.Pinned {
+			flags = append(flags, "pinned")
+		}
+		if entry.Locked {
+			flags = append(flags, "locked")
+		}
+		if repoState.IsShared(entry.Path) {
+			flags = append(flags, "from the shared state")
+		}
+		if entry.Shallow {
+			flags = append(flags, "shallow")
+		}
+		if entry.LFS {
+			flags = append(flags, "uses Git LFS")
+		}
+		if entry.ManuallyAdded {
+			flags = append(flags, "manually added")
+		}
+		field("Flags", strings.Join(flags, ", "))
+
+		field("Cloned", formatInfoTime(entry.ClonedAt))
+		if s := entry.CloneStats; s != nil {
+			clone := fmt.Sprintf("took %s, %s in %d objects", formatSeconds(s.Duration), formatSize(s.Size), s.Objects)
+			if s.LFSSize > 0 {
+				clone += fmt.Sprintf(" and %s of LFS content", formatSize(s.LFSSize))
+			}
+			field("Clone", clone)
+		}
+		field("Clone options", formatCloneOptions(entry.CloneOptions))
+		field("Last checked", formatInfoTime(entry.LastChecked))
+		field("Last fetched", formatInfoTime(entry.LastFetched))
+		field("Last mirrored", formatInfoTime(entry.LastMirrored))
+		field("Last modified", formatInfoTime(entry.LastModified))
+		if v := entry.Verification; v != nil {
+			result := "intact"
+			if v.Problem != "" {
+				result = v.Problem
+			}
+			field("Verified", fmt.Sprintf("%s (%s)", formatInfoTime(v.CheckedAt), result))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if len(entry.Metadata) > 0 {
+			fmt.Println("\nMetadata:")
+			w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			for _, key := range sortedMetadataKeys(entry.Metadata) {
+				fmt.Fprintf(w, "  %s\t%s\n", key, entry.Metadata[key])
+			}
+			return w.Flush()
+		}
+		return nil
+	},
+}
+
+// formatInfoTime formats t for 'fussy-git info', or returns "" for the zero time.
+func formatInfoTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// formatCloneOptions describes the options a repository was cloned with, e.g.
+// "--depth 1, --filter blob:none", or "" for a complete clone.
+func formatCloneOptions(o *state.CloneOptions) string {
+	if o.IsZero() {
+		return ""
+	}
+	var options []string
+	if o.Depth > 0 {
+		options = append(options, fmt.Sprintf("--depth %d", o.Depth))
+	}
+	if o.ShallowSince != "" {
+		options = append(options, "--shallow-since "+o.ShallowSince)
+	}
+	if o.Filter != "" {
+		options = append(options, "--filter "+o.Filter)
+	}
+	if len(o.Sparse) > 0 {
+		options = append(options, "--sparse "+strings.Join(o.Sparse, ","))
+	}
+	return strings.Join(options, ", ")
+}
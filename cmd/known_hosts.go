@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	yesTrustHost         bool
+	fingerprintTrustHost []string
+)
+
+// publishedHostKeys are the SHA256 host key fingerprints code hosts publish, so their keys
+// can be trusted without asking. See
+// https://docs.github.com/en/authentication/keeping-your-account-and-data-secure/githubs-ssh-key-fingerprints
+var publishedHostKeys = map[string][]string{
+	"github.com": {
+		"SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s", // RSA
+		"SHA256:p2QAMXNIC1TJYWeIOttrVc98/R1BUFWu3/LiyKgUfQM", // ECDSA
+		"SHA256:+DiY3wvvV6TuJJhbpZisF/zLDA0zPMSvHdkr4UvCOqU", // Ed25519
+	},
+}
+
+// authTrustHostCmd represents the auth trust-host command
+var authTrustHostCmd = &cobra.Command{
+	Use:   "trust-host <domain>...",
+	Short: "Adds the SSH host keys of hosts to known_hosts.",
+	Long: `Fetches the SSH host keys of each host and adds them to ~/.ssh/known_hosts, so that clones
+over SSH don't stop at ssh's "authenticity of host can't be established" prompt. git runs
+without a terminal, so that prompt makes clones from a new host fail, which is what happens
+to unattended bulk clones ('fussy-git clone --file') of a host you haven't connected to yet.
+
+Keys are only added once they are verified:
+- For hosts that publish their fingerprints (github.com), the keys are checked against them.
+- Otherwise, with --fingerprint, against the fingerprints you pass (from the host's docs or admin).
+- Otherwise the fingerprints are shown and you are asked to confirm them.
+--yes skips the confirmation for hosts that can't be verified otherwise; only use it on
+networks you trust. Hosts that are already known are left alone, and a host whose keys
+don't match the expected fingerprints is never added.
+
+Host aliases and ports from ~/.ssh/config are taken into account.
+
+Examples:
+  fussy-git auth trust-host github.com
+  fussy-git auth trust-host git.example.com --fingerprint SHA256:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prompter := newActionPrompter()
+		failed := 0
+		for _, domain := range args {
+			if err := trustHost(domain, prompter); err != nil {
+				fmt.Printf("[FAIL] %s: %v\n", domain, err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("the host keys of %d of %d hosts were not added", failed, len(args))
+		}
+		return nil
+	},
+}
+
+// trustHost verifies the host keys of domain and adds them to known_hosts.
+func trustHost(domain string, prompter *actionPrompter) error {
+	needed, err := gitutil.NeedsHostKey(domain)
+	if err != nil {
+		return err
+	}
+	if !needed {
+		fmt.Printf("[SKIP] %s is already known (or your ssh configuration accepts new host keys).\n", domain)
+		return nil
+	}
+	keys, err := gitutil.ScanHostKeys(domain)
+	if err != nil {
+		return err
+	}
+
+	expected, source := publishedHostKeys[strings.ToLower(domain)], "the fingerprints published by "+domain
+	if len(fingerprintTrustHost) > 0 {
+		expected, source = fingerprintTrustHost, "the fingerprints given with --fingerprint"
+	}
+	if expected != nil {
+		var verified []gitutil.HostKey
+		for _, key := range keys {
+			if slices.Contains(expected, key.Fingerprint) {
+				verified = append(verified, key)
+			} else if len(fingerprintTrustHost) == 0 {
+				// A host that publishes all its keys must not offer any other.
+				return fmt.Errorf("the %s key %s doesn't match %s; someone may be intercepting the connection", key.Type, key.Fingerprint, source)
+			}
+		}
+		if len(verified) == 0 {
+			return fmt.Errorf("none of the keys offered by %s match %s; someone may be intercepting the connection", domain, source)
+		}
+		if err := gitutil.AddKnownHosts(verified); err != nil {
+			return err
+		}
+		fmt.Printf("[OK] Added %d host keys of %s to %s, verified against %s.\n", len(verified), domain, gitutil.KnownHostsFile(), source)
+		return nil
+	}
+
+	fmt.Printf("%s offers these host keys:\n", domain)
+	for _, key := range keys {
+		fmt.Printf("  %-20s %s\n", key.Type, key.Fingerprint)
+	}
+	if yesTrustHost {
+		fmt.Printf("[WARN] Trusting the keys of %s without verifying them (--yes).\n", domain)
+	} else if !prompter.Confirm(fmt.Sprintf("Do these fingerprints match the ones published for %s?", domain)) {
+		return fmt.Errorf("not confirmed")
+	}
+	if err := gitutil.AddKnownHosts(keys); err != nil {
+		return err
+	}
+	fmt.Printf("[OK] Added %d host keys of %s to %s.\n", len(keys), domain, gitutil.KnownHostsFile())
+	return nil
+}
+
+// hostKeyHint adds advice on how to trust a host to errors caused by an unknown SSH host key.
+func hostKeyHint(err error, domain string) error {
+	if err != nil && strings.Contains(err.Error(), "Host key verification failed") {
+		return fmt.Errorf("%w\nThe SSH host key of %s is not trusted yet; add it with 'fussy-git auth trust-host %s'", err, domain, domain)
+	}
+	return err
+}
+
+// checkHostKeys returns, for bulk clones, the SSH hosts among jobs whose host keys are
+// unknown, so they can be reported before starting clones that would all fail.
+func checkHostKeys(jobs []*cloneJob) map[string]bool {
+	checked := map[string]bool{}
+	unknown := map[string]bool{}
+	for _, job := range jobs {
+		if job == nil || !job.parsed.IsSSH || checked[job.parsed.Host] {
+			continue
+		}
+		checked[job.parsed.Host] = true
+		if needed, err := gitutil.NeedsHostKey(job.parsed.Host); err == nil && needed {
+			unknown[job.parsed.Host] = true
+		}
+	}
+	return unknown
+}
+
+func init() {
+	authTrustHostCmd.Flags().BoolVarP(&yesTrustHost, "yes", "y", false, "Trust host keys that can't be verified without asking")
+	authTrustHostCmd.Flags().StringSliceVar(&fingerprintTrustHost, "fingerprint", nil, "Expected SHA256 host key fingerprint (repeatable); only matching keys are added")
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/license"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	licensesFilter filter.Filter
+	licensesOutput string
+	licensesCached bool
+)
+
+// licenseGroup lists the repositories under one license, for the JSON output of 'licenses'.
+type licenseGroup struct {
+	License      string               `json:"license"`
+	Repositories []licensedRepository `json:"repositories"`
+}
+
+// licensedRepository is a repository in the JSON output of 'licenses'.
+type licensedRepository struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	URL  string `json:"url"`
+}
+
+// licensesCmd represents the licenses command
+var licensesCmd = &cobra.Command{
+	Use:   "licenses",
+	Short: "Lists repositories grouped by license.",
+	Long: `Scans every tracked repository for license files (LICENSE, LICENCE, COPYING, UNLICENSE and
+variants such as LICENSE.md or LICENSE-MIT) in its top-level directory, identifies the license
+from their text, records it in the state and lists the repositories grouped by license, e.g.
+for a compliance review of what is checked out.
+
+Licenses are shown as SPDX identifiers (MIT, Apache-2.0, GPL-3.0, BSD-3-Clause, ...), and
+repositories with several recognized license files as "MIT OR Apache-2.0". The detection is
+heuristic: 'unknown' means a license file was found but not recognized, 'none' that there is
+no license file. 'fussy-git info' shows the recorded license, which is also detected when a
+repository is cloned or added.
+
+With --cached, the licenses recorded by the last scan are listed without scanning; repositories
+that were never scanned are listed as 'not scanned'. In read-only mode, the scan results are
+not recorded. Use the filter flags to list only some repositories, and --output json for
+a machine-readable report.
+
+Examples:
+  fussy-git licenses
+  fussy-git licenses --owner work-org --output json > licenses.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if licensesOutput != "text" && licensesOutput != "json" {
+			return usageError("invalid --output value '%s': must be 'text' or 'json'", licensesOutput)
+		}
+		repos := licensesFilter.Apply(repoState.Repositories)
+		if len(repos) == 0 {
+			fmt.Println("No repositories to scan.")
+			return nil
+		}
+
+		if !licensesCached {
+			if err := scanLicenses(repos); err != nil {
+				return err
+			}
+		}
+
+		byLicense := map[string][]state.RepositoryEntry{}
+		for _, repo := range repos {
+			id := repo.License
+			if id == "" {
+				id = "not scanned"
+			}
+			byLicense[id] = append(byLicense[id], repo)
+		}
+		groups := make([]licenseGroup, 0, len(byLicense))
+		for id, members := range byLicense {
+			sort.SliceStable(members, func(i, j int) bool { return members[i].Path < members[j].Path })
+			group := licenseGroup{License: id}
+			for _, repo := range members {
+				group.Repositories = append(group.Repositories, licensedRepository{Name: repo.Name, Path: repo.Path, URL: repo.CurrentURL})
+			}
+			groups = append(groups, group)
+		}
+		// The most common licenses first; unknown and missing licenses last, where they stand out.
+		last := func(id string) bool { return id == license.Unknown || id == license.None || id == "not scanned" }
+		sort.Slice(groups, func(i, j int) bool {
+			if last(groups[i].License) != last(groups[j].License) {
+				return !last(groups[i].License)
+			}
+			if len(groups[i].Repositories) != len(groups[j].Repositories) {
+				return len(groups[i].Repositories) > len(groups[j].Repositories)
+			}
+			return groups[i].License < groups[j].License
+		})
+
+		if licensesOutput == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(groups)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for i, group := range groups {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "%s (%d)\n", group.License, len(group.Repositories))
+			for _, repo := range group.Repositories {
+				fmt.Fprintf(w, "  %s\t%s\n", repo.Name, repo.Path)
+			}
+		}
+		w.Flush()
+
+		fmt.Printf("\nLicenses summary:\n")
+		fmt.Printf("  Repositories:    %d\n", len(repos))
+		fmt.Printf("  Licenses:        %d\n", countLicenses(groups, last))
+		fmt.Printf("  Unknown license: %d\n", len(byLicense[license.Unknown]))
+		fmt.Printf("  No license file: %d\n", len(byLicense[license.None]))
+		return nil
+	},
+}
+
+// scanLicenses detects the license of the given repositories, several at a time, updates them
+// and the state, and saves the state unless in read-only mode. Repositories whose directory
+// can't be read keep their recorded license.
+func scanLicenses(repos []state.RepositoryEntry) error {
+	ids := make([]string, len(repos)) // Empty for repositories that couldn't be scanned
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < inspectWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				id, err := license.Detect(repos[i].Path)
+				if err != nil {
+					if verbose {
+						fmt.Fprintf(os.Stderr, "[WARN] Could not scan %s for license files: %v\n", repos[i].Path, err)
+					}
+					continue
+				}
+				ids[i] = id
+			}
+		}()
+	}
+	for i := range repos {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	changed := false
+	for i, id := range ids {
+		if id == "" || repos[i].License == id {
+			continue
+		}
+		repos[i].License = id
+		if idx := repoState.IndexOfPath(repos[i].Path); idx >= 0 {
+			repoState.Repositories[idx].License = id
+			changed = true
+		}
+	}
+	if !changed || appConfig.ReadOnly {
+		return nil
+	}
+	if err := repoState.Save(appConfig.StateFilePath); err != nil {
+		return fmt.Errorf("failed to save the detected licenses: %w", err)
+	}
+	return nil
+}
+
+// countLicenses returns how many distinct licenses the groups are for, counting each license
+// of dual-licensed repositories once and leaving out the groups last reports.
+func countLicenses(groups []licenseGroup, last func(id string) bool) int {
+	seen := map[string]bool{}
+	for _, group := range groups {
+		if last(group.License) {
+			continue
+		}
+		for _, id := range strings.Split(group.License, " OR ") {
+			seen[id] = true
+		}
+	}
+	return len(seen)
+}
+
+// detectLicense returns the license of the repository at repoPath, or "" if it can't be read.
+func detectLicense(repoPath string) string {
+	id, err := license.Detect(repoPath)
+	if err != nil {
+		return ""
+	}
+	return id
+}
+
+func init() {
+	licensesCmd.Flags().StringVarP(&licensesOutput, "output", "o", "text", "Output format: 'text' or 'json'")
+	licensesCmd.Flags().BoolVar(&licensesCached, "cached", false, "List the licenses recorded by the last scan instead of scanning")
+	addFilterFlags(licensesCmd, &licensesFilter)
+}
@@ -2,12 +2,24 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
 	"os"
+	"sort"
 	"text/tabwriter" // For aligned output
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	listFilter filter.Filter
+	listHealth bool
+	listSort   string
+	listWide   bool
+)
+
+// descriptionWidth is how many characters of the README description of repositories tables show.
+const descriptionWidth = 60
+
 // listCmd represents the list command
 var listCmd = &cobra.Command{
 	Use:   "list",
@@ -15,11 +27,40 @@ var listCmd = &cobra.Command{
 	Long: `Lists all repositories that have been cloned or added to fussy-git's tracking.
 The information is read from the state file (e.g., ~/.fussy-git/repos.json).
 
-Output includes the repository name, its local path, and the current remote URL.`,
+Output includes the repository name, its local path, and the current remote URL.
+The common filter flags (--domain, --owner, --tag, --path-prefix, --meta, --group) narrow the list.
+
+Before listing, the origin URLs of repositories that weren't checked for longer than the
+url_refresh_after setting (24h by default, 0 disables it) are refreshed from their clones,
+at most 64 per run, oldest first, so the state stays fresh without running doctor. Changed
+URLs are reported on stderr. 'fussy-git info' does the same.
+
+With --wide, a DESCRIPTION column shows the beginning of each repository's README description
+(its first paragraph, or its title), recorded when it is cloned or added and refreshed with its
+URL, to help remember what half-forgotten repositories are. 'fussy-git search' matches it too.
+
+With --health, a HEALTH column shows the health score of every repository, and --sort health
+lists the least healthy repositories first, to see where cleanup is needed most.
+
+` + healthScoreHelp + `
+
+Examples:
+  fussy-git list --owner spf13
+  fussy-git list --wide --tag tools
+  fussy-git list --sort health`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		switch listSort {
+		case "", "name", "path":
+		case "health":
+			listHealth = true
+		default:
+			return usageError("invalid --sort '%s': must be one of name, path, health", listSort)
+		}
+
 		if verbose {
 			fmt.Printf("Listing repositories from state file: %s\n", appConfig.StateFilePath)
 		}
+		refreshStaleRepositories()
 
 		if len(repoState.Repositories) == 0 {
 			fmt.Println("No repositories are currently managed by fussy-git.")
@@ -32,11 +73,40 @@ Output includes the repository name, its local path, and the current remote URL.
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 		defer w.Flush()
 
+		repos := listFilter.Apply(repoState.Repositories)
+		switch listSort {
+		case "name":
+			sort.SliceStable(repos, func(i, j int) bool { return repos[i].Name < repos[j].Name })
+		case "path":
+			sort.SliceStable(repos, func(i, j int) bool { return repos[i].Path < repos[j].Path })
+		}
+		if listHealth {
+			health := assessRepositories(repos)
+			if listSort == "health" {
+				sortByHealth(health)
+			}
+			fmt.Fprintln(w, "NAME\tHEALTH\tPATH\tCURRENT URL\tDOMAIN\tISSUES")
+			fmt.Fprintln(w, "----\t------\t----\t-----------\t------\t------")
+			for _, h := range health {
+				fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", h.entry.Name, h.score, h.entry.Path, h.entry.CurrentURL, h.entry.Domain, h.summary())
+			}
+			return nil
+		}
+
+		if listWide {
+			fmt.Fprintln(w, "NAME\tPATH\tCURRENT URL\tDOMAIN\tDESCRIPTION")
+			fmt.Fprintln(w, "----\t----\t-----------\t------\t-----------")
+			for _, repo := range repos {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", repo.Name, repo.Path, repo.CurrentURL, repo.Domain, truncateDescription(repo.Description, descriptionWidth))
+			}
+			return nil
+		}
+
 		// Print header
 		fmt.Fprintln(w, "NAME\tPATH\tCURRENT URL\tORIGINAL URL\tDOMAIN")
 		fmt.Fprintln(w, "----\t----\t-----------\t------------\t------")
 
-		for _, repo := range repoState.Repositories {
+		for _, repo := range repos {
 			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
 				repo.Name,
 				repo.Path,
@@ -52,7 +122,10 @@ Output includes the repository name, its local path, and the current remote URL.
 
 func init() {
 	rootCmd.AddCommand(listCmd)
+	addFilterFlags(listCmd, &listFilter)
+	listCmd.Flags().BoolVar(&listHealth, "health", false, "Show the health score of every repository")
+	listCmd.Flags().BoolVar(&listWide, "wide", false, "Show the README description of every repository")
+	listCmd.Flags().StringVar(&listSort, "sort", "", "Order of the list: 'name', 'path' or 'health' (least healthy first; implies --health). Default: order of the state file")
 	// Potentially add flags to listCmd in the future, e.g.:
 	// listCmd.Flags().BoolP("full-path", "f", false, "Display full paths instead of truncated")
-	// listCmd.Flags().StringP("sort-by", "s", "name", "Sort repositories by (name, path, url, domain)")
 }
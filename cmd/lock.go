@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// lockCmd represents the lock command
+var lockCmd = &cobra.Command{
+	Use:   "lock <repo>",
+	Short: "Protects a repository from operations that move, replace or forget it.",
+	Long: `Marks a repository as locked, to guard checkouts that can't be recreated from their remote,
+e.g. ones with local-only history:
+- 'reorganize' and 'rewrite' don't move it unless --force is given.
+- 'reclone' doesn't replace it unless --force is given.
+- 'maintenance' never prunes its entry from the state when its directory is missing.
+
+Unlike a pin, a lock doesn't change where the repository is expected to be: 'doctor' still
+reports it if it isn't at its conventional location.
+
+<repo> can be the repository's path, its normalized path (e.g. github.com/spf13/cobra),
+its URL, or its name if that is unambiguous.`,
+	Annotations:       mutates,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRepository,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setLocked(args[0], true)
+	},
+}
+
+// unlockCmd represents the unlock command
+var unlockCmd = &cobra.Command{
+	Use:               "unlock <repo>",
+	Short:             "Removes the lock from a repository.",
+	Annotations:       mutates,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRepository,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setLocked(args[0], false)
+	},
+}
+
+// setLocked updates the Locked flag of the referenced repository and saves the state.
+func setLocked(ref string, locked bool) error {
+	idx, err := lookupRepository(ref)
+	if err != nil {
+		return err
+	}
+	entry := &repoState.Repositories[idx]
+
+	if entry.Locked == locked {
+		if locked {
+			fmt.Printf("Repository '%s' (%s) is already locked.\n", entry.Name, entry.Path)
+		} else {
+			fmt.Printf("Repository '%s' (%s) is not locked.\n", entry.Name, entry.Path)
+		}
+		return nil
+	}
+
+	entry.Locked = locked
+	entry.LastModified = time.Now()
+	if err := repoState.Save(appConfig.StateFilePath); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	if locked {
+		fmt.Printf("Locked repository '%s' at %s.\n", entry.Name, entry.Path)
+	} else {
+		fmt.Printf("Unlocked repository '%s' at %s.\n", entry.Name, entry.Path)
+	}
+	return nil
+}
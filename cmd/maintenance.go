@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	quietMaintenance       bool
+	skipFetchMaintenance   bool
+	skipGCMaintenance      bool
+	noPruneMaintenance     bool
+	keepBackupsMaintenance int
+	notifyMaintenance      bool
+)
+
+// maintenanceResult summarizes one maintenance run.
+type maintenanceResult struct {
+	backupPath string
+	fetched    int
+	collected  int
+	pruned     []string
+	withIssues int
+	issueRepos map[string]bool // Paths of the repositories with issues
+	behind     map[string]int  // Repository path -> commits behind upstream, after fetching
+	failures   []string        // Steps that failed, e.g. a fetch that couldn't reach the remote
+	problems   []string        // Doctor issues and other findings that need attention
+}
+
+// maintenanceCmd represents the maintenance command
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Runs routine housekeeping on all tracked repositories, suitable for cron.",
+	Long: `Runs all routine housekeeping in one go, designed to be run unattended from cron or a
+systemd timer. In order, it:
+
+1. Backs up the state file to a 'backups' directory next to it, keeping the newest --keep-backups copies.
+2. Fetches all remotes of every repository ('git fetch --all --prune').
+3. Runs 'git gc --auto' in every repository.
+4. Prunes state entries whose repository path no longer exists. As a safeguard against unmounted
+   drives, nothing is pruned if more than half of the tracked repositories are missing. Locked
+   repositories (see 'fussy-git lock') are never pruned; they are reported instead.
+5. Records the number of repositories, their size on disk, and how many are dirty or behind
+   their upstream, for 'fussy-git stats --history'.
+6. Runs the doctor checks and reports repositories with errors (warnings and informational
+   findings are left to 'fussy-git doctor').
+
+Fetches run in parallel, at most 'max_network_jobs' (default 4) at a time. Setting
+'bandwidth_limit' (e.g. "2MB/s") in the config file caps the average transfer rate: git can't
+throttle a single transfer, so fetches are spaced out instead, keeping a batch under the cap on
+average. The same settings apply to 'clone' with several repositories and 'outdated'.
+
+Only a concise summary is printed, followed by any failures and issues. With --quiet nothing is
+printed unless something needs attention, so cron only sends mail when there is a problem.
+
+If notifications are configured (see below) or --notify is given, a notification is sent when
+repositories have issues, and when repositories are 'notify_behind_threshold' (default 50) or
+more commits behind their upstream. The settings in the config file:
+  notify_desktop: true              # desktop notifications (notify-send or macOS)
+  notify_webhook: https://hooks.slack.com/services/...
+  notify_webhook_format: slack      # or json; detected from the URL by default
+  notify_behind_threshold: 50
+  notify_finished_after: 1m         # notify when clone, exec, branch or fetch ran this long
+A JSON webhook receives {"event", "title", "message", "repositories", "host", "time"}, where
+event is "issues", "behind" or "finished".
+
+Exit codes:
+  0  everything succeeded and doctor found no issues
+  1  a maintenance step failed (e.g. a fetch or the state backup)
+  2  all steps succeeded, but doctor reported issues
+
+Examples:
+  fussy-git maintenance
+  fussy-git maintenance --skip-fetch --no-prune
+
+  # crontab entry running it every night at 03:00
+  0 3 * * * fussy-git maintenance --quiet`,
+	Annotations: writesTree,
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		result := runMaintenance()
+		printMaintenanceResult(result)
+
+		notifier := newNotifier(notifyMaintenance)
+		warn := func(format string, args ...any) { fmt.Printf(format+"\n", args...) }
+		notifyIssues(notifier, sortedKeys(result.issueRepos), warn)
+		notifyBehind(notifier, farBehind(result.behind, nil), warn)
+
+		if len(result.failures) > 0 {
+			return &exitError{code: exitFailure, err: fmt.Errorf("maintenance finished with %d failures", len(result.failures))}
+		}
+		if result.withIssues > 0 {
+			return &exitError{code: exitIssues, err: fmt.Errorf("%d repositories reported issues", result.withIssues)}
+		}
+		return nil
+	},
+}
+
+func runMaintenance() *maintenanceResult {
+	result := &maintenanceResult{issueRepos: map[string]bool{}, behind: map[string]int{}}
+
+	// 1. Back up the state before anything below modifies it.
+	backupDir := filepath.Join(filepath.Dir(appConfig.StateFilePath), "backups")
+	backupPath, err := state.Backup(appConfig.StateFilePath, backupDir, keepBackupsMaintenance)
+	if err != nil {
+		result.failures = append(result.failures, fmt.Sprintf("state backup: %v", err))
+	}
+	result.backupPath = backupPath
+
+	// 2 + 3. Fetch and gc the repositories that exist, several at a time.
+	var existing, missing []state.RepositoryEntry
+	for _, repo := range repoState.Repositories {
+		if _, err := os.Stat(repo.Path); os.IsNotExist(err) {
+			missing = append(missing, repo)
+		} else {
+			existing = append(existing, repo)
+		}
+	}
+
+	if !skipFetchMaintenance {
+		limiter := newNetworkLimiter(0)
+		report := runBulk("fetch", existing, &bulkOptions{jobs: inspectWorkers, output: "text", silent: true},
+			func(repo state.RepositoryEntry, stdout, stderr io.Writer) (int, error) {
+				err := throttledFetch(limiter, repo.Path)
+				return bulkExitCode(err), err
+			})
+		result.fetched = report.Succeeded
+		for _, r := range report.Repositories {
+			if r.Status == bulkStatusFailed {
+				result.failures = append(result.failures, fmt.Sprintf("fetch %s: %s", r.Path, r.Error))
+			}
+		}
+	}
+
+	if !skipGCMaintenance {
+		var mu sync.Mutex
+		jobs := make(chan state.RepositoryEntry)
+		var wg sync.WaitGroup
+		for i := 0; i < inspectWorkers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for repo := range jobs {
+					err := gitutil.GarbageCollect(repo.Path, verbose)
+					mu.Lock()
+					if err != nil {
+						result.failures = append(result.failures, fmt.Sprintf("gc %s: %v", repo.Path, err))
+					} else {
+						result.collected++
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+		for _, repo := range existing {
+			jobs <- repo
+		}
+		close(jobs)
+		wg.Wait()
+	}
+	for _, repo := range existing {
+		if _, behind, err := gitutil.AheadBehind(repo.Path); err == nil {
+			result.behind[repo.Path] = behind
+		}
+	}
+
+	// 4. Prune entries whose repositories are gone.
+	if !noPruneMaintenance && len(missing) > 0 {
+		if len(missing) > 1 && len(missing)*2 > len(repoState.Repositories) {
+			result.failures = append(result.failures, fmt.Sprintf(
+				"prune: %d of %d repositories are missing, which looks like an unmounted drive; nothing was pruned",
+				len(missing), len(repoState.Repositories)))
+		} else {
+			for _, repo := range missing {
+				if repo.Locked {
+					result.failures = append(result.failures, fmt.Sprintf("prune: %s is missing but locked; unlock it to prune it", repo.Path))
+					continue
+				}
+				if repoState.RemoveRepositoryByPath(repo.Path) {
+					result.pruned = append(result.pruned, repo.Path)
+				}
+			}
+			if err := repoState.Save(appConfig.StateFilePath); err != nil {
+				result.failures = append(result.failures, fmt.Sprintf("prune: failed to save state: %v", err))
+			}
+		}
+	}
+
+	// 5. Record the summary metrics for 'stats --history'.
+	if err := recordStatsSnapshot(existing, result.behind); err != nil {
+		result.failures = append(result.failures, fmt.Sprintf("stats history: %v", err))
+	}
+
+	// 6. Doctor, reporting only what needs attention.
+	for _, repo := range repoState.Repositories {
+		findings := checkRepository(repo)
+		if countSeverity(findings, severityError) == 0 {
+			continue
+		}
+		result.withIssues++
+		result.issueRepos[repo.Path] = true
+		for _, f := range findings {
+			if f.severity == severityError {
+				result.problems = append(result.problems, fmt.Sprintf("%s: %s [%s]", repo.Path, f.message, f.check))
+			}
+		}
+	}
+	return result
+}
+
+// farBehind returns the paths of the repositories that are at least notify_behind_threshold
+// commits behind, leaving out those that already were in previous, in alphabetical order.
+func farBehind(behind, previous map[string]int) []string {
+	threshold := appConfig.NotifyBehindThreshold
+	var paths []string
+	for path, n := range behind {
+		if n >= threshold && previous[path] < threshold {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// sortedKeys returns the keys of a set in alphabetical order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func printMaintenanceResult(result *maintenanceResult) {
+	if quietMaintenance && len(result.failures) == 0 && len(result.problems) == 0 {
+		return
+	}
+
+	fmt.Printf("Maintenance summary:\n")
+	fmt.Printf("  Repositories:         %d\n", len(repoState.Repositories))
+	if result.backupPath != "" {
+		fmt.Printf("  State backup:         %s\n", result.backupPath)
+	}
+	if !skipFetchMaintenance {
+		fmt.Printf("  Fetched:              %d\n", result.fetched)
+	}
+	if !skipGCMaintenance {
+		fmt.Printf("  Garbage collected:    %d\n", result.collected)
+	}
+	fmt.Printf("  Stale entries pruned: %d\n", len(result.pruned))
+	fmt.Printf("  Repos with issues:    %d\n", result.withIssues)
+	fmt.Printf("  Failures:             %d\n", len(result.failures))
+
+	for _, path := range result.pruned {
+		fmt.Printf("[PRUNE] %s\n", path)
+	}
+	for _, failure := range result.failures {
+		fmt.Printf("[FAIL] %s\n", failure)
+	}
+	for _, problem := range result.problems {
+		fmt.Printf("[ISSUE] %s\n", problem)
+	}
+}
+
+func init() {
+	maintenanceCmd.Flags().BoolVarP(&quietMaintenance, "quiet", "q", false, "Print nothing unless a step failed or doctor found issues")
+	maintenanceCmd.Flags().BoolVar(&skipFetchMaintenance, "skip-fetch", false, "Don't fetch remotes")
+	maintenanceCmd.Flags().BoolVar(&skipGCMaintenance, "skip-gc", false, "Don't run 'git gc --auto'")
+	maintenanceCmd.Flags().BoolVar(&noPruneMaintenance, "no-prune", false, "Don't remove state entries whose repository path no longer exists")
+	maintenanceCmd.Flags().IntVar(&keepBackupsMaintenance, "keep-backups", 10, "Number of state backups to keep (0 keeps all)")
+	maintenanceCmd.Flags().BoolVar(&notifyMaintenance, "notify", false, "Send desktop notifications about issues and repositories far behind upstream, even if notify_desktop isn't set")
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/manifest"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	manifestFilter filter.Filter
+	manifestPin    bool
+)
+
+// manifestCmd represents the manifest command
+var manifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Prints a manifest of the tracked repositories, optionally pinned to their checkouts.",
+	Long: `Prints a manifest listing the URLs of the tracked repositories, one per line, sorted by
+normalized path. A manifest recreates the same set of repositories on another machine with
+'fussy-git sync <manifest>' (or 'fussy-git clone --batch <manifest>').
+
+With --pin, every line also records the branch that is checked out and the commit HEAD points
+to, so 'fussy-git sync --checkout' can restore exactly these checkouts later, like a snapshot
+of a multi-repo project:
+  https://github.com/spf13/cobra branch=main commit=40b5bc1437a564fc795d388b23835e84f54cd1d1
+Pins can also be written by hand: 'branch=' alone follows the branch, 'commit=' alone (or
+together with a branch that has moved on) detaches HEAD at the commit.
+
+Use the filter flags (--domain, --owner, --tag, --path-prefix, --meta, --group) to include
+only some repositories, e.g. the repositories of a group.
+
+Examples:
+  fussy-git manifest > repos.txt
+  fussy-git manifest --group platform --pin > platform-release-1.4.txt`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repos := manifestFilter.Apply(repoState.Repositories)
+		sort.Slice(repos, func(i, j int) bool { return repos[i].NormalizedFS < repos[j].NormalizedFS })
+
+		entries := make([]manifest.Entry, 0, len(repos))
+		for _, repo := range repos {
+			entry := manifest.Entry{URL: repo.CurrentURL}
+			if manifestPin {
+				head, err := gitutil.HeadCommit(repo.Path)
+				if err != nil || head == "" {
+					fmt.Fprintf(os.Stderr, "[WARN] %s: can't pin, HEAD can't be resolved; listed without pins.\n", repo.Name)
+				} else {
+					entry.Commit = head
+					entry.Branch, _ = gitutil.CurrentBranch(repo.Path)
+				}
+				if dirty, err := gitutil.IsDirty(repo.Path); err == nil && dirty {
+					fmt.Fprintf(os.Stderr, "[WARN] %s has uncommitted changes; they are not part of the pin.\n", repo.Name)
+				}
+			}
+			entries = append(entries, entry)
+		}
+		return manifest.Write(os.Stdout, entries)
+	},
+}
+
+func init() {
+	addFilterFlags(manifestCmd, &manifestFilter)
+	manifestCmd.Flags().BoolVar(&manifestPin, "pin", false, "Record the checked out branch and commit of every repository")
+}
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var metaSelection bulkSelection
+
+// metaCmd represents the meta command
+var metaCmd = &cobra.Command{
+	Use:   "meta",
+	Short: "Manages key/value metadata attached to repositories.",
+	Long: `Attaches arbitrary key/value metadata to tracked repositories, e.g. the team owning a
+repository or a ticket it is being worked on for. Metadata is stored in the state file, shown
+by 'fussy-git info', and can be used to select repositories in commands that accept filters:
+  --meta team=platform   repositories whose 'team' is 'platform' (ignoring case)
+  --meta ticket          repositories that have a 'ticket' at all
+
+Keys must not be empty or contain '=' or whitespace.
+
+To set or remove metadata on many repositories at once, give key=value pairs (for set) or keys
+(for unset) and select the repositories with the filter flags (--domain, --owner, --tag,
+--path-prefix, --meta, --group) or --all. Use --dry-run to see what would change first.
+
+<repo> can be the repository's path, its normalized path (e.g. github.com/spf13/cobra),
+its URL, or its name if that is unambiguous.
+
+Examples:
+  fussy-git meta set github.com/spf13/cobra team platform
+  fussy-git meta get github.com/spf13/cobra
+  fussy-git list --meta team=platform
+  fussy-git meta unset github.com/spf13/cobra team
+  fussy-git meta set team=platform --owner myorg
+  fussy-git meta unset ticket --tag done --dry-run`,
+}
+
+// metaSetCmd represents the meta set command
+var metaSetCmd = &cobra.Command{
+	Use:               "set <repo> <key> <value> | set <key>=<value>... (--all | <filters>)",
+	Short:             "Sets a metadata value on a repository, or on all selected repositories.",
+	Annotations:       mutates,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeRepository,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if metaSelection.selected() {
+			return setMetadataBulk(cmd, args)
+		}
+		if len(args) != 3 {
+			return usageError("accepts <repo> <key> <value>, or key=value pairs with --all or filter flags; received %d arguments", len(args))
+		}
+		key, value := args[1], args[2]
+		if err := state.ValidateMetadataKey(key); err != nil {
+			return fmt.Errorf("invalid metadata key: %w", err)
+		}
+		idx, err := lookupRepository(args[0])
+		if err != nil {
+			return err
+		}
+		entry := &repoState.Repositories[idx]
+		if current, ok := entry.Metadata[key]; ok && current == value {
+			fmt.Printf("'%s' of repository '%s' is already '%s'.\n", key, entry.Name, value)
+			return nil
+		}
+
+		if metaSelection.dryRun {
+			fmt.Printf("Would set '%s' of repository '%s' to '%s'.\n", key, entry.Name, value)
+			return nil
+		}
+		if entry.Metadata == nil {
+			entry.Metadata = make(map[string]string)
+		}
+		entry.Metadata[key] = value
+		entry.LastModified = time.Now()
+		if err := repoState.Save(appConfig.StateFilePath); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		fmt.Printf("Set '%s' of repository '%s' to '%s'.\n", key, entry.Name, value)
+		return nil
+	},
+}
+
+// metaGetCmd represents the meta get command
+var metaGetCmd = &cobra.Command{
+	Use:   "get <repo> [key]",
+	Short: "Prints a metadata value of a repository, or all of its metadata.",
+	Long: `Prints the value of <key> on its own, so it can be used in scripts. The command exits with
+status 1 if the key isn't set. Without a key, all metadata of the repository is printed as
+key=value lines, sorted by key.`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeRepository,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		idx, err := lookupRepository(args[0])
+		if err != nil {
+			return err
+		}
+		entry := repoState.Repositories[idx]
+		if len(args) == 2 {
+			value, ok := entry.Metadata[args[1]]
+			if !ok {
+				return fmt.Errorf("repository '%s' has no metadata '%s'", entry.Name, args[1])
+			}
+			fmt.Println(value)
+			return nil
+		}
+		for _, key := range sortedMetadataKeys(entry.Metadata) {
+			fmt.Printf("%s=%s\n", key, entry.Metadata[key])
+		}
+		return nil
+	},
+}
+
+// metaUnsetCmd represents the meta unset command
+var metaUnsetCmd = &cobra.Command{
+	Use:               "unset <repo> <key> | unset <key>... (--all | <filters>)",
+	Short:             "Removes a metadata value from a repository, or from all selected repositories.",
+	Annotations:       mutates,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeRepository,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if metaSelection.selected() {
+			return unsetMetadataBulk(cmd, args)
+		}
+		if len(args) != 2 {
+			return usageError("accepts <repo> <key>, or keys with --all or filter flags; received %d arguments", len(args))
+		}
+		idx, err := lookupRepository(args[0])
+		if err != nil {
+			return err
+		}
+		entry := &repoState.Repositories[idx]
+		key := args[1]
+		if _, ok := entry.Metadata[key]; !ok {
+			fmt.Printf("Repository '%s' has no metadata '%s'.\n", entry.Name, key)
+			return nil
+		}
+
+		if metaSelection.dryRun {
+			fmt.Printf("Would remove '%s' from repository '%s'.\n", key, entry.Name)
+			return nil
+		}
+		delete(entry.Metadata, key)
+		if len(entry.Metadata) == 0 {
+			entry.Metadata = nil
+		}
+		entry.LastModified = time.Now()
+		if err := repoState.Save(appConfig.StateFilePath); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		fmt.Printf("Removed '%s' from repository '%s'.\n", key, entry.Name)
+		return nil
+	},
+}
+
+// setMetadataBulk sets the key=value pairs in args on every repository the selection flags
+// select.
+func setMetadataBulk(cmd *cobra.Command, args []string) error {
+	keys := make([]string, 0, len(args))
+	values := map[string]string{}
+	for _, arg := range args {
+		key, value, found := strings.Cut(arg, "=")
+		if !found {
+			return usageError("'%s' is not a key=value pair; with --all or filter flags, set takes key=value pairs", arg)
+		}
+		if err := state.ValidateMetadataKey(key); err != nil {
+			return usageError("invalid metadata key: %v", err)
+		}
+		if _, dup := values[key]; !dup {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+	indices, err := metaSelection.indices(cmd, nil)
+	if err != nil {
+		return err
+	}
+	return metaSelection.apply("Metadata", indices, func(entry *state.RepositoryEntry) string {
+		var changes []string
+		for _, key := range keys {
+			if current, ok := entry.Metadata[key]; ok && current == values[key] {
+				continue
+			}
+			if entry.Metadata == nil {
+				entry.Metadata = make(map[string]string)
+			}
+			entry.Metadata[key] = values[key]
+			changes = append(changes, key+"="+values[key])
+		}
+		return strings.Join(changes, ", ")
+	})
+}
+
+// unsetMetadataBulk removes the keys in args from every repository the selection flags select.
+func unsetMetadataBulk(cmd *cobra.Command, keys []string) error {
+	indices, err := metaSelection.indices(cmd, nil)
+	if err != nil {
+		return err
+	}
+	return metaSelection.apply("Metadata", indices, func(entry *state.RepositoryEntry) string {
+		var changes []string
+		for _, key := range keys {
+			if _, ok := entry.Metadata[key]; !ok {
+				continue
+			}
+			delete(entry.Metadata, key)
+			changes = append(changes, "-"+key)
+		}
+		if len(entry.Metadata) == 0 {
+			entry.Metadata = nil
+		}
+		return strings.Join(changes, ", ")
+	})
+}
+
+// sortedMetadataKeys returns the keys of metadata in alphabetical order.
+func sortedMetadataKeys(metadata map[string]string) []string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	metaCmd.AddCommand(metaSetCmd, metaGetCmd, metaUnsetCmd)
+	for _, c := range []*cobra.Command{metaSetCmd, metaUnsetCmd} {
+		addBulkSelectionFlags(c, &metaSelection)
+	}
+}
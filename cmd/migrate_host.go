@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/plan"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	dryRunMigrateHost bool
+	yesMigrateHost    bool
+)
+
+// migrateHostCmd represents the migrate-host command
+var migrateHostCmd = &cobra.Command{
+	Use:   "migrate-host <old-host> <new-host>",
+	Short: "Moves all repositories of a Git server that changed its hostname to the new one.",
+	Long: `Brings every repository cloned from <old-host> over to <new-host> after a self-hosted Git
+server migrated domains: the 'origin' remote of each is set to the same URL on the new host
+('git remote set-url'), the URL is updated in the state, and the repository is moved to its
+location for the new URL, so the whole <old-host> directory below FUSSY_GIT_HOME moves to
+<new-host>. The user, port and path of the URLs are kept, whether they are SSH or HTTPS URLs.
+
+The changes are shown and, once confirmed, applied as one operation like 'fussy-git reorganize'
+applies its plan: progress is recorded in a journal next to the state file, Ctrl-C finishes
+the operation in progress and saves the state, and if the run is killed, the next
+'fussy-git reorganize' or 'fussy-git migrate-host' records what was done. Each move is
+verified before it is committed.
+
+Repositories whose live 'origin' differs from the stored URL are skipped; run
+'fussy-git reorganize' first to record it. Pinned repositories and those with a path
+override get the new URL but stay where they are. Moves of locked repositories and of
+repositories with uncommitted changes are skipped; 'fussy-git reorganize' (with --force or
+--force-dirty) moves them later. Settings for the old host in the config file (e.g. its
+entry under 'domains') aren't changed.
+
+Examples:
+  fussy-git migrate-host gitlab.old.corp gitlab.new.corp --dry-run
+  fussy-git migrate-host gitlab.old.corp gitlab.new.corp --yes`,
+	Annotations: writesTree,
+	Args:        cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		oldHost, newHost := strings.ToLower(args[0]), strings.ToLower(args[1])
+		for _, host := range []string{oldHost, newHost} {
+			if host == "" || strings.ContainsAny(host, "/@: ") {
+				return usageError("invalid host '%s': expected a hostname such as gitlab.example.com", host)
+			}
+		}
+		if oldHost == newHost {
+			return usageError("the old and new host are the same")
+		}
+
+		if !dryRunMigrateHost {
+			if err := recoverReorgJournal(); err != nil {
+				return err
+			}
+		}
+
+		migration := plan.New(appConfig.FussyGitHome, appConfig.StateFilePath)
+		matched, skipped := 0, 0
+		for _, repo := range repoState.Repositories {
+			newURL, ok := replaceURLHost(repo.CurrentURL, oldHost, newHost)
+			if !ok {
+				continue
+			}
+			matched++
+			fmt.Printf("%s (%s)\n", repo.Name, repo.Path)
+			if !gitutil.IsGitRepository(repo.Path) {
+				fmt.Println("  [SKIP] Not a Git repository (or missing).")
+				skipped++
+				continue
+			}
+			if liveURL, err := gitutil.GetRemoteOriginURL(repo.Path, verbose); err != nil || liveURL != repo.CurrentURL {
+				fmt.Printf("  [SKIP] origin is '%s', not the stored '%s'. Run 'fussy-git reorganize' first.\n", liveURL, repo.CurrentURL)
+				skipped++
+				continue
+			}
+			parsed, err := parseRepoURL(newURL)
+			if err != nil {
+				fmt.Printf("  [SKIP] New URL '%s' is invalid: %v\n", newURL, err)
+				skipped++
+				continue
+			}
+			fmt.Printf("  - %s\n", repo.CurrentURL)
+			fmt.Printf("  + %s\n", newURL)
+			migration.Add(plan.Operation{Type: plan.OpSetURL, Repo: repo.Name, Path: repo.Path, Source: repo.CurrentURL, Target: newURL})
+
+			target := expectedRepoPath(repo, parsed)
+			switch {
+			case samePath(repo.Path, target):
+			case repo.Pinned:
+				fmt.Printf("  Pinned: stays at '%s'\n", repo.Path)
+			default:
+				fmt.Printf("  Moves to %s\n", target)
+				if _, err := os.Stat(target); err == nil {
+					fmt.Println("  [WARN] The target already exists; the move will fail.")
+				}
+				migration.Add(plan.Operation{Type: plan.OpMove, Repo: repo.Name, Path: repo.Path, Source: repo.Path, Target: target})
+			}
+		}
+
+		if matched == 0 {
+			fmt.Printf("No tracked repository has a URL on %s.\n", oldHost)
+			return nil
+		}
+		fmt.Println()
+		if len(migration.Operations) == 0 {
+			fmt.Printf("None of the %d repositories on %s can be migrated.\n", matched, oldHost)
+			return nil
+		}
+		if dryRunMigrateHost {
+			fmt.Printf("DRY RUN: %d of %d repositories on %s would be migrated to %s (%d operations).\n",
+				matched-skipped, matched, oldHost, newHost, len(migration.Operations))
+			return nil
+		}
+		if !yesMigrateHost && !newActionPrompter().Confirm(fmt.Sprintf("Migrate %d repositories from %s to %s?", matched-skipped, oldHost, newHost)) {
+			fmt.Println("Aborted; nothing was changed.")
+			return nil
+		}
+
+		// Left over from an interrupted reorganize; this run supersedes it.
+		os.Remove(reorgRemainingPath())
+		return applyReorgPlan(migration)
+	},
+}
+
+// replaceURLHost returns rawURL with the host oldHost replaced by newHost, keeping its user,
+// port and path, and whether rawURL is on oldHost. Both URLs (ssh://, https://) and the
+// scp-like SSH syntax (git@host:path) are supported.
+func replaceURLHost(rawURL, oldHost, newHost string) (string, bool) {
+	if strings.Contains(rawURL, "://") {
+		u, err := url.Parse(rawURL)
+		if err != nil || !strings.EqualFold(u.Hostname(), oldHost) {
+			return rawURL, false
+		}
+		if port := u.Port(); port != "" {
+			u.Host = net.JoinHostPort(newHost, port)
+		} else {
+			u.Host = newHost
+		}
+		return u.String(), true
+	}
+	userHost, path, found := strings.Cut(rawURL, ":")
+	if !found {
+		return rawURL, false
+	}
+	user, host := "", userHost
+	if i := strings.LastIndex(userHost, "@"); i >= 0 {
+		user, host = userHost[:i+1], userHost[i+1:]
+	}
+	if !strings.EqualFold(host, oldHost) {
+		return rawURL, false
+	}
+	return user + newHost + ":" + path, true
+}
+
+func init() {
+	migrateHostCmd.Flags().BoolVar(&dryRunMigrateHost, "dry-run", false, "Show the changes without applying them")
+	migrateHostCmd.Flags().BoolVarP(&yesMigrateHost, "yes", "y", false, "Apply the changes without asking for confirmation")
+}
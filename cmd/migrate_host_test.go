@@ -0,0 +1,32 @@
+package cmd
+
+import "testing"
+
+func TestReplaceURLHost(t *testing.T) {
+	tests := []struct {
+		name, in    string
+		want        string
+		wantMatched bool
+	}{
+		{"https", "https://git.old.example/team/repo.git", "https://git.new.example/team/repo.git", true},
+		{"https with user and port", "https://me@git.old.example:8443/team/repo", "https://me@git.new.example:8443/team/repo", true},
+		{"ssh URL", "ssh://git@git.old.example/team/repo.git", "ssh://git@git.new.example/team/repo.git", true},
+		{"ssh URL with port", "ssh://git@git.old.example:2222/team/repo.git", "ssh://git@git.new.example:2222/team/repo.git", true},
+		{"scp-like", "git@git.old.example:team/repo.git", "git@git.new.example:team/repo.git", true},
+		{"scp-like without user", "git.old.example:team/repo.git", "git.new.example:team/repo.git", true},
+		{"host case", "https://GIT.OLD.EXAMPLE/team/repo", "https://git.new.example/team/repo", true},
+		{"other host", "https://github.com/team/repo", "https://github.com/team/repo", false},
+		{"host suffix", "https://git.old.example.org/team/repo", "https://git.old.example.org/team/repo", false},
+		{"host in path", "https://github.com/git.old.example/repo", "https://github.com/git.old.example/repo", false},
+		{"scp-like other host", "git@github.com:team/repo.git", "git@github.com:team/repo.git", false},
+		{"local path", "/src/team/repo", "/src/team/repo", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, matched := replaceURLHost(tt.in, "git.old.example", "git.new.example")
+			if got != tt.want || matched != tt.wantMatched {
+				t.Errorf("replaceURLHost(%q) = %q, %v, want %q, %v", tt.in, got, matched, tt.want, tt.wantMatched)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	mirrorRemote      string
+	mirrorForce       bool
+	mirrorDryRun      bool
+	mirrorJobs        int
+	mirrorSetupFilter filter.Filter
+	mirrorRunFilter   filter.Filter
+)
+
+// mirrorData is what mirror remote URL templates are rendered with.
+type mirrorData struct {
+	Name           string // Repository name, e.g. "cobra"
+	Domain         string // e.g. "github.com"
+	Owner          string // e.g. "spf13"; empty for repositories without an owner
+	NormalizedPath string // e.g. "github.com/spf13/cobra"
+	Path           string // Local path of the repository
+}
+
+// mirrorPushCmd represents the mirror-push command
+var mirrorPushCmd = &cobra.Command{
+	Use:   "mirror-push",
+	Short: "Backs up repositories by pushing them to a second remote.",
+	Long: `Keeps a backup of every repository on a second remote, e.g. a private backup server or an
+S3-backed git server, as insurance for work that was never pushed anywhere else.
+
+'mirror-push setup <url-template>' adds the remote (named 'backup' unless --remote is given)
+to every repository. The URL is rendered from a Go template with the fields .Name, .Domain,
+.Owner, .NormalizedPath (e.g. github.com/spf13/cobra) and .Path. 'mirror-push run' then
+force-pushes every local branch and tag of every repository that has the remote. Branches and
+tags deleted locally are kept on the remote, so the backup never loses anything.
+
+Examples:
+  fussy-git mirror-push setup 'git@backup.lan:mirrors/{{.NormalizedPath}}.git'
+  fussy-git mirror-push setup --owner work-org 'ssh://nas/volume1/git/{{.Owner}}/{{.Name}}.git'
+  fussy-git mirror-push run
+  fussy-git mirror-push run --remote nas --jobs 8`,
+}
+
+// mirrorPushSetupCmd represents the mirror-push setup command
+var mirrorPushSetupCmd = &cobra.Command{
+	Use:   "setup <url-template>",
+	Short: "Adds the mirror remote to every repository.",
+	Long: `Adds the mirror remote to every tracked repository (or the ones matching the filters), with
+its URL rendered from <url-template>. See 'fussy-git help mirror-push' for the template fields.
+
+Repositories that already have the remote with the same URL are skipped; if its URL differs,
+the repository fails unless --force is given, which points the remote at the new URL. Use
+--dry-run to see the URLs first.
+
+Exit codes: 0 if every repository was set up, 1 if all failed, 3 if some failed.`,
+	Annotations: mutates,
+	Args:        cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tmpl, err := template.New("url").Option("missingkey=error").Parse(args[0])
+		if err != nil {
+			return usageError("invalid URL template: %v", err)
+		}
+		repos := mirrorSetupFilter.Apply(repoState.Repositories)
+		if len(repos) == 0 {
+			fmt.Println("No repositories to set up.")
+			return nil
+		}
+
+		var configured, unchanged, skipped int
+		var failed []string
+		for _, repo := range repos {
+			if _, err := os.Stat(repo.Path); err != nil {
+				fmt.Printf("[SKIP] %s: directory is missing\n", repo.Path)
+				skipped++
+				continue
+			}
+			url, err := mirrorURL(tmpl, repo)
+			if err != nil {
+				fmt.Printf("[FAIL] %s: %v\n", repo.Path, err)
+				failed = append(failed, repo.Path)
+				continue
+			}
+			existing := gitutil.RemoteURL(repo.Path, mirrorRemote)
+			switch {
+			case existing == url:
+				if verbose {
+					fmt.Printf("[SKIP] %s: '%s' already points at %s\n", repo.Path, mirrorRemote, url)
+				}
+				unchanged++
+				continue
+			case existing != "" && !mirrorForce:
+				fmt.Printf("[FAIL] %s: '%s' already points at %s; use --force to replace it with %s\n", repo.Path, mirrorRemote, existing, url)
+				failed = append(failed, repo.Path)
+				continue
+			}
+			if mirrorDryRun {
+				fmt.Printf("%s: would set '%s' to %s\n", repo.Path, mirrorRemote, url)
+				configured++
+				continue
+			}
+			if err := gitutil.SetMirrorRemote(repo.Path, mirrorRemote, url); err != nil {
+				fmt.Printf("[FAIL] %s: %v\n", repo.Path, err)
+				failed = append(failed, repo.Path)
+				continue
+			}
+			fmt.Printf("[OK] %s: '%s' -> %s\n", repo.Path, mirrorRemote, url)
+			configured++
+		}
+
+		if mirrorDryRun {
+			fmt.Printf("\nDRY RUN: %d repositories would be set up, %d failed.\n", configured, len(failed))
+			return nil
+		}
+		fmt.Printf("\nMirror setup summary:\n")
+		fmt.Printf("  Repositories:       %d\n", len(repos))
+		fmt.Printf("  Configured:         %d\n", configured)
+		fmt.Printf("  Already configured: %d\n", unchanged)
+		fmt.Printf("  Skipped:            %d\n", skipped)
+		fmt.Printf("  Failed:             %d\n", len(failed))
+		if len(failed) > 0 {
+			return bulkFailure(len(failed), len(repos)-skipped, fmt.Errorf("%d of %d repositories could not be set up", len(failed), len(repos)))
+		}
+		return nil
+	},
+}
+
+// mirrorPushRunCmd represents the mirror-push run command
+var mirrorPushRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Pushes every branch and tag to the mirror remote, with progress.",
+	Long: `Force-pushes every local branch and tag of every repository that has the mirror remote,
+several at a time (at most --jobs, by default the max_network_jobs setting), and records when
+each was mirrored ('fussy-git info' shows it). Repositories without the remote, or whose
+directory is missing, are skipped.
+
+On a terminal, a live display shows the progress of the running pushes and the overall
+counters; every finished repository gets an [OK] or [FAIL] line.
+
+Exit codes: 0 if every push succeeded, 1 if all failed, 3 if some failed.`,
+	Args:        cobra.NoArgs,
+	Annotations: mutates,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if mirrorJobs < 0 {
+			return usageError("--jobs must not be negative")
+		}
+		repos := mirrorRunFilter.Apply(repoState.Repositories)
+		if len(repos) == 0 {
+			fmt.Println("No repositories to mirror.")
+			return nil
+		}
+
+		board := newProgressBoard(os.Stdout, "Mirroring", len(repos))
+		started := time.Now()
+		mirroredAt := make([]time.Time, len(repos)) // Zero for repositories that weren't pushed
+		var failed, skipped []string
+		var mu sync.Mutex
+
+		workers := mirrorJobs
+		if workers == 0 {
+			workers = appConfig.MaxNetworkJobs
+		}
+		limiter := newNetworkLimiter(workers)
+		queue := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range queue {
+					repo := repos[i]
+					label := repo.Path
+					reason := ""
+					if _, err := os.Stat(repo.Path); err != nil {
+						reason = "directory is missing"
+					} else if gitutil.RemoteURL(repo.Path, mirrorRemote) == "" {
+						reason = fmt.Sprintf("no '%s' remote; see 'fussy-git mirror-push setup'", mirrorRemote)
+					}
+					if reason != "" {
+						board.Skip(label, reason)
+						mu.Lock()
+						skipped = append(skipped, repo.Path)
+						mu.Unlock()
+						continue
+					}
+					limiter.Acquire()
+					board.Start(label)
+					err := gitutil.PushMirrorWithProgress(repo.Path, mirrorRemote, func(line string) {
+						board.Update(label, line)
+					})
+					// Pushes upload; the bandwidth limit only measures downloads.
+					limiter.Release(0)
+					board.Done(label, err)
+					mu.Lock()
+					if err != nil {
+						failed = append(failed, repo.Path)
+					} else {
+						mirroredAt[i] = time.Now()
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+		for i := range repos {
+			queue <- i
+		}
+		close(queue)
+		wg.Wait()
+		board.Close()
+
+		mirrored := 0
+		for i, at := range mirroredAt {
+			if at.IsZero() {
+				continue
+			}
+			mirrored++
+			if idx := repoState.IndexOfPath(repos[i].Path); idx >= 0 {
+				repoState.Repositories[idx].LastMirrored = at
+			}
+		}
+		if mirrored > 0 {
+			if err := repoState.Save(appConfig.StateFilePath); err != nil {
+				return fmt.Errorf("repositories were mirrored, but failed to save state: %w", err)
+			}
+		}
+
+		fmt.Printf("\nMirror push summary:\n")
+		fmt.Printf("  Repositories: %d\n", len(repos))
+		fmt.Printf("  Mirrored:     %d\n", mirrored)
+		fmt.Printf("  Skipped:      %d\n", len(skipped))
+		fmt.Printf("  Failed:       %d\n", len(failed))
+
+		notifyFinished("mirror-push", time.Since(started),
+			fmt.Sprintf("%d of %d repositories mirrored, %d skipped, %d failed", mirrored, len(repos), len(skipped), len(failed)), failed)
+
+		if len(failed) > 0 {
+			return bulkFailure(len(failed), len(repos)-len(skipped), fmt.Errorf("%d of %d repositories could not be mirrored", len(failed), len(repos)))
+		}
+		return nil
+	},
+}
+
+// mirrorURL renders the mirror remote URL of a repository from tmpl.
+func mirrorURL(tmpl *template.Template, repo state.RepositoryEntry) (string, error) {
+	data := mirrorData{Name: repo.Name, Domain: repo.Domain, NormalizedPath: repo.NormalizedFS, Path: repo.Path}
+	if parsed, err := parseRepoURL(repo.CurrentURL); err == nil {
+		data.Owner = parsed.Owner()
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render the URL template: %w", err)
+	}
+	url := strings.TrimSpace(buf.String())
+	if url == "" {
+		return "", fmt.Errorf("the URL template rendered to an empty URL")
+	}
+	return url, nil
+}
+
+func init() {
+	mirrorPushCmd.PersistentFlags().StringVar(&mirrorRemote, "remote", "backup", "Name of the mirror remote")
+
+	mirrorPushSetupCmd.Flags().BoolVar(&mirrorForce, "force", false, "Point an existing remote with a different URL at the new URL")
+	mirrorPushSetupCmd.Flags().BoolVar(&mirrorDryRun, "dry-run", false, "Show the URLs without changing any repository")
+	addFilterFlags(mirrorPushSetupCmd, &mirrorSetupFilter)
+
+	mirrorPushRunCmd.Flags().IntVarP(&mirrorJobs, "jobs", "j", 0, "Number of repositories to push in parallel (default: the max_network_jobs setting, 4)")
+	addFilterFlags(mirrorPushRunCmd, &mirrorRunFilter)
+
+	mirrorPushCmd.AddCommand(mirrorPushSetupCmd)
+	mirrorPushCmd.AddCommand(mirrorPushRunCmd)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/jmsnll/fussy-git/internal/fsutil"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/throttle"
+)
+
+// newNetworkLimiter returns a limiter for bulk network operations according to the
+// max_network_jobs and bandwidth_limit settings. Pass jobs > 0 to override max_network_jobs.
+func newNetworkLimiter(jobs int) *throttle.Limiter {
+	if jobs <= 0 {
+		jobs = appConfig.MaxNetworkJobs
+	}
+	return throttle.New(jobs, appConfig.BandwidthLimit)
+}
+
+// gitDirSize returns the size of a repository's git directory. With a bandwidth limit,
+// its growth is used to estimate how much a clone or fetch transferred.
+func gitDirSize(repoPath string) int64 {
+	size, _ := fsutil.DirSize(gitutil.CommonGitDir(repoPath))
+	return size
+}
+
+// throttledFetch fetches all remotes of a repository once the limiter allows it.
+func throttledFetch(limiter *throttle.Limiter, repoPath string) error {
+	limiter.Acquire()
+	var before int64
+	if limiter.Limited() {
+		before = gitDirSize(repoPath)
+	}
+	err := gitutil.Fetch(repoPath, verbose)
+	var transferred int64
+	if limiter.Limited() {
+		transferred = max(0, gitDirSize(repoPath)-before)
+	}
+	limiter.Release(transferred)
+	return err
+}
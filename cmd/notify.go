@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/notify"
+	"os"
+	"sort"
+	"time"
+)
+
+// newNotifier returns the notifier configured by the notify_* settings. desktop enables
+// desktop notifications even if notify_desktop isn't set, e.g. for 'daemon --notify'.
+func newNotifier(desktop bool) *notify.Notifier {
+	return &notify.Notifier{
+		Desktop:       desktop || appConfig.NotifyDesktop,
+		WebhookURL:    appConfig.NotifyWebhook,
+		WebhookFormat: appConfig.NotifyWebhookFormat,
+	}
+}
+
+// sendNotification sends msg if notifications are enabled, reporting failures through warn.
+func sendNotification(notifier *notify.Notifier, msg notify.Notification, warn func(format string, args ...any)) {
+	if !notifier.Enabled() {
+		return
+	}
+	sort.Strings(msg.Repositories)
+	if err := notifier.Send(msg); err != nil {
+		warn("[WARN] %v", err)
+	}
+}
+
+// notifyIssues notifies that the repositories at paths have issues.
+func notifyIssues(notifier *notify.Notifier, paths []string, warn func(format string, args ...any)) {
+	if len(paths) == 0 {
+		return
+	}
+	sendNotification(notifier, notify.Notification{
+		Event:        notify.EventIssues,
+		Title:        "fussy-git: repositories need attention",
+		Message:      fmt.Sprintf("%d repositories have issues; run 'fussy-git doctor' for details.", len(paths)),
+		Repositories: paths,
+	}, warn)
+}
+
+// notifyBehind notifies that the repositories at paths are at least notify_behind_threshold
+// commits behind their upstream.
+func notifyBehind(notifier *notify.Notifier, paths []string, warn func(format string, args ...any)) {
+	if len(paths) == 0 {
+		return
+	}
+	sendNotification(notifier, notify.Notification{
+		Event:        notify.EventBehind,
+		Title:        "fussy-git: repositories fell behind",
+		Message:      fmt.Sprintf("%d repositories are %d or more commits behind upstream; see 'fussy-git outdated'.", len(paths), appConfig.NotifyBehindThreshold),
+		Repositories: paths,
+	}, warn)
+}
+
+// notifyFinished notifies that a bulk operation finished, if it ran for at least
+// notify_finished_after. failed lists the paths of the repositories it failed for.
+func notifyFinished(operation string, elapsed time.Duration, summary string, failed []string) {
+	if appConfig.NotifyFinishedAfter <= 0 || elapsed < appConfig.NotifyFinishedAfter {
+		return
+	}
+	title := fmt.Sprintf("fussy-git %s finished", operation)
+	if len(failed) > 0 {
+		title = fmt.Sprintf("fussy-git %s finished with failures", operation)
+	}
+	sendNotification(newNotifier(false), notify.Notification{
+		Event:        notify.EventFinished,
+		Title:        title,
+		Message:      fmt.Sprintf("%s in %s.", summary, elapsed.Round(time.Second)),
+		Repositories: failed,
+	}, func(format string, args ...any) { fmt.Fprintf(os.Stderr, format+"\n", args...) })
+}
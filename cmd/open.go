@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// openInBrowser opens url with the platform's default handler. It does not wait for the browser to exit.
+func openInBrowser(url string) error {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", url)
+	case "windows":
+		c = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		c = exec.Command("xdg-open", url)
+	}
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("failed to open %s in a browser: %w", url, err)
+	}
+	go c.Wait()
+	return nil
+}
+
+// openInEditor opens dir with the configured editor, falling back to $VISUAL and $EDITOR.
+// The editor setting may contain arguments, e.g. "code --new-window". It does not wait for the editor to exit.
+func openInEditor(dir string) error {
+	editor := appConfig.Editor
+	if editor == "" {
+		editor = os.Getenv("VISUAL")
+	}
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		return fmt.Errorf("no editor configured: set 'editor' in the config file, or $VISUAL or $EDITOR")
+	}
+
+	c := exec.Command(fields[0], append(fields[1:], dir)...)
+	c.Dir = dir
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("failed to start editor '%s': %w", editor, err)
+	}
+	go c.Wait()
+	return nil
+}
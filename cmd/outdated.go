@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	noFetchOutdated bool
+	sortOutdated    string
+	pullOutdated    bool
+	outdatedFilter  filter.Filter
+)
+
+// outdatedRepository describes a repository whose local default branch is behind origin.
+type outdatedRepository struct {
+	entry  state.RepositoryEntry
+	branch string
+	ahead  int
+	behind int
+	dirty  bool
+}
+
+// outdatedCmd represents the outdated command
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "Lists repositories whose default branch is behind origin.",
+	Long: `Fetches all tracked repositories and lists those whose local default branch
+(the branch origin/HEAD points to, usually main or master) is behind its counterpart
+on origin, together with how many commits it is behind and ahead.
+
+With --pull, the default branch of every listed repository is fast-forwarded to origin.
+Repositories with uncommitted changes, or whose branch has local commits that aren't on
+origin, are left alone. If the default branch isn't checked out, only the branch is moved
+and the working tree is untouched.
+
+Use --domain, --owner, --tag and --path-prefix to check only a subset of repositories.
+
+Examples:
+  fussy-git outdated
+  fussy-git outdated --no-fetch --sort name
+  fussy-git outdated --owner spf13 --pull`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch sortOutdated {
+		case "behind", "name", "path":
+		default:
+			return fmt.Errorf("invalid --sort '%s': must be one of behind, name, path", sortOutdated)
+		}
+		if pullOutdated && appConfig.ReadOnly {
+			return readOnlyError("'outdated --pull'")
+		}
+
+		repos := outdatedFilter.Apply(repoState.Repositories)
+		if len(repos) == 0 {
+			fmt.Println("No repositories to check.")
+			return nil
+		}
+
+		if !noFetchOutdated {
+			fmt.Printf("Fetching %d repositories...\n", len(repos))
+			for _, failure := range fetchRepositories(repos) {
+				fmt.Printf("[WARN] %s\n", failure)
+			}
+		}
+
+		outdated := findOutdatedRepositories(repos)
+		if len(outdated) == 0 {
+			fmt.Println("All repositories are up to date with origin.")
+			return nil
+		}
+
+		sort.SliceStable(outdated, func(i, j int) bool {
+			switch sortOutdated {
+			case "name":
+				return strings.ToLower(outdated[i].entry.Name) < strings.ToLower(outdated[j].entry.Name)
+			case "path":
+				return outdated[i].entry.Path < outdated[j].entry.Path
+			default:
+				return outdated[i].behind > outdated[j].behind
+			}
+		})
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "BEHIND\tAHEAD\tBRANCH\tNAME\tPATH\tSTATUS")
+		fmt.Fprintln(w, "------\t-----\t------\t----\t----\t------")
+		for _, o := range outdated {
+			status := "clean"
+			if o.dirty {
+				status = "dirty"
+			}
+			fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\n", o.behind, o.ahead, o.branch, o.entry.Name, o.entry.Path, status)
+		}
+		w.Flush()
+
+		if !pullOutdated {
+			fmt.Printf("\n%d repositories are behind origin. Use --pull to fast-forward the clean ones.\n", len(outdated))
+			return nil
+		}
+
+		fmt.Println()
+		pulled, skipped, failed := 0, 0, 0
+		for _, o := range outdated {
+			switch {
+			case o.dirty:
+				fmt.Printf("[SKIP] %s: has uncommitted changes.\n", o.entry.Path)
+				skipped++
+			case o.ahead > 0:
+				fmt.Printf("[SKIP] %s: '%s' has %d local commits not on origin; can't fast-forward.\n", o.entry.Path, o.branch, o.ahead)
+				skipped++
+			default:
+				if err := gitutil.FastForwardBranch(o.entry.Path, o.branch, "origin/"+o.branch, verbose); err != nil {
+					fmt.Printf("[FAIL] %s: %v\n", o.entry.Path, err)
+					failed++
+					continue
+				}
+				fmt.Printf("[PULL] %s: fast-forwarded '%s' by %d commits.\n", o.entry.Path, o.branch, o.behind)
+				pulled++
+			}
+		}
+
+		fmt.Printf("\nPull summary:\n")
+		fmt.Printf("  Fast-forwarded: %d\n", pulled)
+		fmt.Printf("  Skipped:        %d\n", skipped)
+		fmt.Printf("  Failed:         %d\n", failed)
+		if failed > 0 {
+			return fmt.Errorf("%d repositories could not be fast-forwarded", failed)
+		}
+		return nil
+	},
+}
+
+// fetchRepositories fetches all remotes of the given repositories, several at a time within the
+// configured network limits, and returns a description of each failure.
+func fetchRepositories(repos []state.RepositoryEntry) []string {
+	var failures []string
+	var mu sync.Mutex
+	limiter := newNetworkLimiter(0)
+	jobs := make(chan state.RepositoryEntry)
+	var wg sync.WaitGroup
+	for i := 0; i < inspectWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for repo := range jobs {
+				if err := throttledFetch(limiter, repo.Path); err != nil {
+					mu.Lock()
+					failures = append(failures, fmt.Sprintf("fetch %s: %v", repo.Path, err))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, repo := range repos {
+		if _, err := os.Stat(repo.Path); err == nil {
+			jobs <- repo
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return failures
+}
+
+// findOutdatedRepositories returns the repositories whose local default branch is behind origin.
+// Repositories that don't exist or whose default branch can't be determined are skipped.
+func findOutdatedRepositories(repos []state.RepositoryEntry) []outdatedRepository {
+	var outdated []outdatedRepository
+	for _, repo := range repos {
+		if !gitutil.IsGitRepository(repo.Path) {
+			continue
+		}
+		branch, err := gitutil.DefaultBranch(repo.Path)
+		if err != nil || !gitutil.BranchExists(repo.Path, branch) {
+			if verbose {
+				fmt.Printf("Skipping %s: no local default branch found.\n", repo.Path)
+			}
+			continue
+		}
+		ahead, behind, err := gitutil.AheadBehindRefs(repo.Path, branch, "origin/"+branch)
+		if err != nil {
+			fmt.Printf("[WARN] %s: %v\n", repo.Path, err)
+			continue
+		}
+		if behind == 0 {
+			continue
+		}
+		dirty, err := gitutil.IsDirty(repo.Path)
+		if err != nil {
+			dirty = true // Err on the side of not touching it.
+		}
+		outdated = append(outdated, outdatedRepository{entry: repo, branch: branch, ahead: ahead, behind: behind, dirty: dirty})
+	}
+	return outdated
+}
+
+func init() {
+	outdatedCmd.Flags().BoolVar(&noFetchOutdated, "no-fetch", false, "Don't fetch first; compare against the remote-tracking branches as of the last fetch")
+	outdatedCmd.Flags().StringVar(&sortOutdated, "sort", "behind", "Sort by 'behind' (most behind first), 'name' or 'path'")
+	outdatedCmd.Flags().BoolVar(&pullOutdated, "pull", false, "Fast-forward the default branch of clean repositories")
+	addFilterFlags(outdatedCmd, &outdatedFilter)
+}
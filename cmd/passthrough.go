@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// passthroughHelp describes the passthrough restrictions in the help of the root command.
+const passthroughHelp = `In repositories tracked by fussy-git, passthrough can be restricted in the config file:
+
+  passthrough_allow: [status, log, diff, fetch, pull]  # only these git subcommands pass through
+  passthrough_deny: [filter-branch]                     # these never pass through
+  passthrough_confirm: ["reset --hard", "clean -f"]     # these ask for confirmation first
+
+A passthrough_confirm entry matches when all of its options are given ('clean -f' matches
+'clean -fdx'), in any of their spellings: 'push --force' also matches 'push -f',
+'push --force-with-lease' and force-pushed refspecs like 'push origin +main', and 'branch -D'
+matches 'branch --delete --force'. By default, clean -f, reset --hard, checkout -f, push --force, branch -D and
+stash clear ask for confirmation; set passthrough_confirm: [] to never ask. Without a terminal
+to ask on, they are refused. Running git directly is never restricted.`
+
+// checkPassthrough checks the git command command with args against the passthrough
+// restrictions before it is run in the managed repository repoDir, asking for confirmation
+// if it is a destructive one. It returns an error if the command must not be run.
+func checkPassthrough(repoDir, command string, args []string) error {
+	restricted := len(appConfig.PassthroughAllow) > 0 || len(appConfig.PassthroughDeny) > 0 || len(appConfig.PassthroughConfirm) > 0
+	if !restricted {
+		return nil
+	}
+	// Without parsing git's own options, the subcommand isn't known.
+	if strings.HasPrefix(command, "-") {
+		return fmt.Errorf("git options before the subcommand ('%s') can't be passed through in repositories tracked by fussy-git while passthrough is restricted; run git directly", command)
+	}
+	if slices.Contains(appConfig.PassthroughDeny, command) {
+		return fmt.Errorf("'git %s' is not passed through in repositories tracked by fussy-git (passthrough_deny); run git directly", command)
+	}
+	if len(appConfig.PassthroughAllow) > 0 && !slices.Contains(appConfig.PassthroughAllow, command) {
+		return fmt.Errorf("'git %s' is not passed through in repositories tracked by fussy-git (not in passthrough_allow); run git directly", command)
+	}
+	for _, rule := range appConfig.PassthroughConfirm {
+		if !passthroughMatches(rule, command, args) {
+			continue
+		}
+		gitCommand := strings.Join(append([]string{"git", command}, args...), " ")
+		if !isTerminal(os.Stdin) {
+			return fmt.Errorf("'%s' needs confirmation (passthrough_confirm: '%s'), but there is no terminal to ask on; run git directly", gitCommand, rule)
+		}
+		if !newActionPrompter().Confirm(fmt.Sprintf("Run '%s' in %s?", gitCommand, repoDir)) {
+			return fmt.Errorf("'%s' was not confirmed", gitCommand)
+		}
+		return nil
+	}
+	return nil
+}
+
+// optionSpellings lists, per git subcommand, options that are spelled in several ways, each
+// spelling being one or more options that must all be given. "+<refspec>" stands for a refspec
+// with a leading '+', which forces its update like --force.
+var optionSpellings = map[string][][]string{
+	"clean":    {{"-f", "--force"}},
+	"checkout": {{"-f", "--force"}},
+	"switch":   {{"-f", "--force", "--discard-changes"}},
+	"push":     {{"-f", "--force", "--force-with-lease", "+<refspec>"}},
+	"branch":   {{"-D", "-d -f", "-d --force", "--delete -f", "--delete --force"}},
+}
+
+// spellings returns the ways the option of a git subcommand can be given, each one or more
+// options that must all be given; just the option itself unless optionSpellings lists it.
+func spellings(command, option string) [][]string {
+	for _, group := range optionSpellings[command] {
+		if slices.Contains(group, option) {
+			alternatives := make([][]string, len(group))
+			for i, spelling := range group {
+				alternatives[i] = strings.Fields(spelling)
+			}
+			return alternatives
+		}
+	}
+	return [][]string{{option}}
+}
+
+// passthroughMatches reports whether the git command command with args matches rule, a
+// subcommand followed by the options (or words) that must all be given, e.g. "clean -f", in
+// any of their spellings (see optionSpellings).
+func passthroughMatches(rule, command string, args []string) bool {
+	fields := strings.Fields(rule)
+	if len(fields) == 0 || fields[0] != command {
+		return false
+	}
+	for _, option := range fields[1:] {
+		given := false
+		for _, spelling := range spellings(command, option) {
+			if !slices.ContainsFunc(spelling, func(o string) bool { return !hasOption(args, o) }) {
+				given = true
+				break
+			}
+		}
+		if !given {
+			return false
+		}
+	}
+	return true
+}
+
+// hasOption reports whether option is among args, before any "--". A long option may be given
+// a value (--force=...), and a single-letter option may be combined with others (-fdx).
+// "+<refspec>" matches any argument starting with '+'.
+func hasOption(args []string, option string) bool {
+	short := len(option) == 2 && option[0] == '-' && option[1] != '-'
+	for _, arg := range args {
+		switch {
+		case arg == "--":
+			return false
+		case option == "+<refspec>":
+			if len(arg) > 1 && arg[0] == '+' {
+				return true
+			}
+		case arg == option:
+			return true
+		case strings.HasPrefix(option, "--") && strings.HasPrefix(arg, option+"="):
+			return true
+		case short && len(arg) > 2 && arg[0] == '-' && arg[1] != '-' && strings.ContainsRune(arg[1:], rune(option[1])):
+			return true
+		}
+	}
+	return false
+}
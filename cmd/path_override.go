@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/config"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var clearPathOverride bool
+
+// pathOverrideCmd represents the path-override command
+var pathOverrideCmd = &cobra.Command{
+	Use:   "path-override <repo> [<dir>]",
+	Short: "Shows, sets or clears a repository's custom conventional location.",
+	Long: `Defines a custom location for a repository that replaces the conventional path
+computed from its URL ($FUSSY_GIT_HOME/<domain>/<user_or_org>/<project_name>).
+
+'doctor' validates the repository against the override, and 'reorganize' moves the
+repository to the override if it lives elsewhere.
+
+Examples:
+  fussy-git path-override cobra ~/work/cobra    # set the override
+  fussy-git path-override cobra                 # show the current override
+  fussy-git path-override --clear cobra         # go back to the computed path
+
+<repo> can be the repository's path, its normalized path (e.g. github.com/spf13/cobra),
+its URL, or its name if that is unambiguous.
+
+In read-only mode the command is refused; 'fussy-git info <repo>' shows the override.`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeRepository,
+	Annotations:       mutates,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		idx, err := lookupRepository(args[0])
+		if err != nil {
+			return err
+		}
+		entry := &repoState.Repositories[idx]
+
+		if len(args) == 1 && !clearPathOverride {
+			if entry.PathOverride == "" {
+				fmt.Printf("Repository '%s' has no path override.\n", entry.Name)
+			} else {
+				fmt.Printf("Repository '%s' path override: %s\n", entry.Name, entry.PathOverride)
+			}
+			return nil
+		}
+		if len(args) == 2 && clearPathOverride {
+			return fmt.Errorf("cannot set and --clear a path override at the same time")
+		}
+
+		newOverride := ""
+		if len(args) == 2 {
+			newOverride, err = config.ExpandPath(args[1])
+			if err != nil {
+				return err
+			}
+		}
+		if newOverride == entry.PathOverride {
+			fmt.Printf("Path override of '%s' is unchanged.\n", entry.Name)
+			return nil
+		}
+
+		entry.PathOverride = newOverride
+		entry.LastModified = time.Now()
+		if err := repoState.Save(appConfig.StateFilePath); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+
+		if newOverride == "" {
+			fmt.Printf("Cleared path override of '%s'.\n", entry.Name)
+			return nil
+		}
+		fmt.Printf("Path override of '%s' set to %s.\n", entry.Name, newOverride)
+		if !samePath(entry.Path, newOverride) {
+			fmt.Println("Run 'fussy-git reorganize' to move the repository there.")
+		}
+		return nil
+	},
+}
+
+func init() {
+	pathOverrideCmd.Flags().BoolVar(&clearPathOverride, "clear", false, "Remove the path override")
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"github.com/jmsnll/fussy-git/internal/audit"
+	"github.com/jmsnll/fussy-git/internal/fsutil"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"github.com/jmsnll/fussy-git/internal/trend"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// conventionalRepoPath returns the conventional location for a repository URL
+// according to the layout configured for it. Repositories cloned from local paths go into
+// the local_dir directory whatever the layout, named by their LocalKey.
+func conventionalRepoPath(parsedURL *gitutil.ParsedGitURL) string {
+	return urlResolver().ConventionalPath(parsedURL)
+}
+
+// localReposDir returns the directory repositories cloned from local paths go into.
+func localReposDir() string {
+	return urlResolver().LocalReposDir()
+}
+
+// expectedRepoPath returns the location a tracked repository is supposed to live at:
+// its PathOverride if one is set, otherwise the conventional path derived from parsedURL.
+func expectedRepoPath(entry state.RepositoryEntry, parsedURL *gitutil.ParsedGitURL) string {
+	if entry.PathOverride != "" {
+		return entry.PathOverride
+	}
+	return conventionalRepoPath(parsedURL)
+}
+
+// removeEmptyParents removes dir and its parents as long as they are empty,
+// stopping at (and never removing) stopAt. It's used to tidy up after a repository
+// has been moved out of a directory tree, e.g. when switching layouts.
+func removeEmptyParents(dir, stopAt string) {
+	stopAt = filepath.Clean(stopAt)
+	for dir = filepath.Clean(dir); dir != stopAt && strings.HasPrefix(dir, stopAt+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		entries, err := os.ReadDir(dir)
+		if err != nil || len(entries) > 0 {
+			return
+		}
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+	}
+}
+
+// samePath reports whether two paths refer to the same location after cleaning and resolving
+// symlinks, so e.g. a FUSSY_GIT_HOME that is a symlink doesn't make every repository look
+// misplaced. Paths that don't exist yet are resolved as far as they exist.
+func samePath(a, b string) bool {
+	normalizedA := strings.TrimRight(filepath.Clean(a), string(filepath.Separator))
+	normalizedB := strings.TrimRight(filepath.Clean(b), string(filepath.Separator))
+	return normalizedA == normalizedB || fsutil.ResolvePath(normalizedA) == fsutil.ResolvePath(normalizedB)
+}
+
+// resolvedPath returns path with symlinks resolved, or "" if that is path itself. It is
+// recorded as the ResolvedPath of repositories.
+func resolvedPath(path string) string {
+	if resolved := fsutil.ResolvePath(path); resolved != filepath.Clean(path) {
+		return resolved
+	}
+	return ""
+}
+
+// isWithin reports whether path is dir or located below it.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// separateGitDir returns the git directory the .git file of the work tree at path points to,
+// or "" if it has a .git directory. It is recorded as the GitDir of repositories.
+func separateGitDir(path string) string {
+	if layout, err := gitutil.DetectGitDir(path); err == nil && layout.Separate {
+		return layout.GitDir
+	}
+	return ""
+}
+
+// statsHistoryPath returns the location of the history of 'stats --history', which lives next
+// to the state file.
+func statsHistoryPath() string {
+	return filepath.Join(filepath.Dir(appConfig.StateFilePath), trend.FileName)
+}
+
+// auditLogPath returns the location of the audit log, which lives next to the state file.
+func auditLogPath() string {
+	return filepath.Join(filepath.Dir(appConfig.StateFilePath), audit.FileName)
+}
+
+// daemonSocketPath returns the default location of the unix socket 'fussy-git daemon' serves
+// its gRPC interface on, which lives next to the state file.
+func daemonSocketPath() string {
+	return filepath.Join(filepath.Dir(appConfig.StateFilePath), "daemon.sock")
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// pinCmd represents the pin command
+var pinCmd = &cobra.Command{
+	Use:   "pin <repo>",
+	Short: "Pins a repository to its current location so reorganize never moves it.",
+	Long: `Marks a repository as pinned. Pinned repositories keep their custom paths:
+- 'reorganize' never moves them (remote URL changes are still recorded).
+- 'doctor' reports a non-conventional location as informational instead of as an issue.
+
+<repo> can be the repository's path, its normalized path (e.g. github.com/spf13/cobra),
+its URL, or its name if that is unambiguous.`,
+	Annotations:       mutates,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRepository,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setPinned(args[0], true)
+	},
+}
+
+// unpinCmd represents the unpin command
+var unpinCmd = &cobra.Command{
+	Use:               "unpin <repo>",
+	Short:             "Removes the pin from a repository so reorganize may move it again.",
+	Annotations:       mutates,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRepository,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setPinned(args[0], false)
+	},
+}
+
+// setPinned updates the Pinned flag of the referenced repository and saves the state.
+func setPinned(ref string, pinned bool) error {
+	idx, err := lookupRepository(ref)
+	if err != nil {
+		return err
+	}
+	entry := &repoState.Repositories[idx]
+
+	if entry.Pinned == pinned {
+		if pinned {
+			fmt.Printf("Repository '%s' (%s) is already pinned.\n", entry.Name, entry.Path)
+		} else {
+			fmt.Printf("Repository '%s' (%s) is not pinned.\n", entry.Name, entry.Path)
+		}
+		return nil
+	}
+
+	entry.Pinned = pinned
+	entry.LastModified = time.Now()
+	if err := repoState.Save(appConfig.StateFilePath); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	if pinned {
+		fmt.Printf("Pinned repository '%s' at %s. Reorganize will not move it.\n", entry.Name, entry.Path)
+	} else {
+		fmt.Printf("Unpinned repository '%s' at %s.\n", entry.Name, entry.Path)
+	}
+	return nil
+}
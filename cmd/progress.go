@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// progressRedrawInterval limits how often the live display is redrawn for progress updates.
+const progressRedrawInterval = 100 * time.Millisecond
+
+// progressBoard renders the progress of parallel tasks. On a terminal it keeps a live block at
+// the bottom of the output with one line per active task and the overall counters; finished
+// tasks are printed as permanent lines above it. Elsewhere (pipes, log files) only the permanent
+// lines are printed, so the output stays readable.
+type progressBoard struct {
+	mu         sync.Mutex
+	out        io.Writer
+	live       bool
+	width      int
+	title      string
+	total      int
+	succeeded  int
+	failed     int
+	skipped    int
+	active     []string          // Labels of the running tasks, in start order
+	status     map[string]string // Latest progress line per running task
+	drawnLines int
+	lastDraw   time.Time
+}
+
+// newProgressBoard creates a board for total tasks writing to out, which is a *os.File
+// so it can be detected whether it is a terminal.
+func newProgressBoard(out *os.File, title string, total int) *progressBoard {
+	width := 100
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 20 {
+		width = columns
+	}
+	return &progressBoard{
+		out:    out,
+		live:   isTerminal(out),
+		width:  width,
+		title:  title,
+		total:  total,
+		status: map[string]string{},
+	}
+}
+
+// isTerminal reports whether f is an interactive terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Start marks a task as running.
+func (b *progressBoard) Start(label string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.active = append(b.active, label)
+	b.status[label] = "starting..."
+	b.redraw(true)
+}
+
+// Update records the latest progress line of a running task.
+func (b *progressBoard) Update(label, line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.status[label] = line
+	b.redraw(false)
+}
+
+// Done marks a task as finished and prints a permanent line for it:
+// "[OK]" if err is nil, "[FAIL]" with the error otherwise.
+func (b *progressBoard) Done(label string, err error) {
+	if err != nil {
+		b.finish(label, &b.failed, fmt.Sprintf("[FAIL] %s: %v", label, err))
+	} else {
+		b.finish(label, &b.succeeded, fmt.Sprintf("[OK]   %s", label))
+	}
+}
+
+// Skip counts a task that didn't need to run and prints a permanent line with the reason.
+func (b *progressBoard) Skip(label, reason string) {
+	b.finish(label, &b.skipped, fmt.Sprintf("[SKIP] %s: %s", label, reason))
+}
+
+func (b *progressBoard) finish(label string, counter *int, line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, l := range b.active {
+		if l == label {
+			b.active = append(b.active[:i], b.active[i+1:]...)
+			break
+		}
+	}
+	delete(b.status, label)
+	*counter++
+
+	b.clear()
+	fmt.Fprintln(b.out, line)
+	b.redraw(true)
+}
+
+// Close removes the live block. The board must not be used afterwards.
+func (b *progressBoard) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clear()
+}
+
+// clear erases the live block. The caller must hold b.mu.
+func (b *progressBoard) clear() {
+	if !b.live || b.drawnLines == 0 {
+		return
+	}
+	// Move the cursor up to the first line of the block and erase everything below it.
+	fmt.Fprintf(b.out, "\x1b[%dA\r\x1b[J", b.drawnLines)
+	b.drawnLines = 0
+}
+
+// redraw repaints the live block, at most every progressRedrawInterval unless force is set.
+// The caller must hold b.mu.
+func (b *progressBoard) redraw(force bool) {
+	if !b.live || (!force && time.Since(b.lastDraw) < progressRedrawInterval) {
+		return
+	}
+	b.clear()
+	lines := []string{fmt.Sprintf("%s: %d/%d done, %d failed, %d skipped, %d active",
+		b.title, b.succeeded+b.failed+b.skipped, b.total, b.failed, b.skipped, len(b.active))}
+	for _, label := range b.active {
+		lines = append(lines, fmt.Sprintf("  %s  %s", label, b.status[label]))
+	}
+	for _, line := range lines {
+		// Lines must not wrap, or the cursor movement in clear would be off.
+		if runes := []rune(line); len(runes) > b.width-1 {
+			line = string(runes[:b.width-2]) + "…"
+		}
+		fmt.Fprintln(b.out, line)
+	}
+	b.drawnLines = len(lines)
+	b.lastDraw = time.Now()
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// promptChoice is the outcome of asking the user about a single action.
+type promptChoice int
+
+const (
+	choiceApply promptChoice = iota // Apply the action
+	choiceSkip                      // Skip the action
+	choicePin                       // Skip the action and pin the repository so it isn't proposed again
+)
+
+// actionPrompter asks the user to confirm individual actions using y/n/a/q semantics:
+//
+//	y - apply this action
+//	n - skip this action
+//	a - apply this action and all remaining ones without asking again
+//	q - skip this action and all remaining ones
+//	p - pin the repository instead (only offered for moves)
+//
+// Once 'a' or 'q' has been answered, further calls return immediately.
+type actionPrompter struct {
+	in       *bufio.Reader
+	out      io.Writer
+	applyAll bool
+	quit     bool
+}
+
+// newActionPrompter creates a prompter reading answers from stdin.
+func newActionPrompter() *actionPrompter {
+	return &actionPrompter{
+		in:  bufio.NewReader(os.Stdin),
+		out: os.Stdout,
+	}
+}
+
+// Confirm prints the question and waits for an answer.
+// It returns true if the action should be applied.
+func (p *actionPrompter) Confirm(question string) bool {
+	return p.ask(question, false) == choiceApply
+}
+
+// ConfirmOrPin works like Confirm but additionally offers to pin the repository.
+func (p *actionPrompter) ConfirmOrPin(question string) promptChoice {
+	return p.ask(question, true)
+}
+
+// ask prompts until a valid answer is given.
+func (p *actionPrompter) ask(question string, offerPin bool) promptChoice {
+	if p.quit {
+		return choiceSkip
+	}
+	if p.applyAll {
+		return choiceApply
+	}
+
+	options := "y,n,a,q"
+	if offerPin {
+		options += ",p"
+	}
+
+	for {
+		fmt.Fprintf(p.out, "%s [%s,?] ", question, options)
+		line, err := p.in.ReadString('\n')
+		if err != nil && line == "" {
+			// EOF or unreadable stdin: treat it as a request to stop, never as consent.
+			fmt.Fprintln(p.out)
+			p.quit = true
+			return choiceSkip
+		}
+
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y", "yes":
+			return choiceApply
+		case "n", "no", "":
+			return choiceSkip
+		case "a", "all":
+			p.applyAll = true
+			return choiceApply
+		case "q", "quit":
+			p.quit = true
+			return choiceSkip
+		case "p", "pin":
+			if offerPin {
+				return choicePin
+			}
+			fallthrough
+		default:
+			fmt.Fprintln(p.out, "y - apply this action")
+			fmt.Fprintln(p.out, "n - skip this action")
+			fmt.Fprintln(p.out, "a - apply this and all remaining actions")
+			fmt.Fprintln(p.out, "q - skip this and all remaining actions")
+			if offerPin {
+				fmt.Fprintln(p.out, "p - pin this repository at its current location and skip the move")
+			}
+		}
+	}
+}
+
+// Quit reports whether the user asked to stop processing further actions.
+func (p *actionPrompter) Quit() bool {
+	return p.quit
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+)
+
+// urlProtocol returns "ssh" or "https" for a parsed repository URL.
+func urlProtocol(parsedURL *gitutil.ParsedGitURL) string {
+	if parsedURL.IsSSH {
+		return "ssh"
+	}
+	return "https"
+}
+
+// fallbackAllowed reports whether a failed clone of parsedURL may be retried over the other
+// protocol, according to the protocol_fallback setting. Private hosts are
+// never accessed over HTTPS.
+func fallbackAllowed(parsedURL *gitutil.ParsedGitURL) bool {
+	switch appConfig.ProtocolFallback {
+	case "both":
+		return !parsedURL.IsSSH || !isPrivateHost(parsedURL.Domain)
+	case "ssh-to-https":
+		return parsedURL.IsSSH && !isPrivateHost(parsedURL.Domain)
+	case "https-to-ssh":
+		return !parsedURL.IsSSH
+	}
+	return false
+}
+
+// otherProtocolURL converts a repository URL to the protocol it isn't using.
+func otherProtocolURL(parsedURL *gitutil.ParsedGitURL) (string, error) {
+	if parsedURL.IsSSH {
+		return parsedURL.ToHTTPS()
+	}
+	return parsedURL.ToSSH()
+}
+
+// preferWorkingProtocol converts repoURL to the protocol a previous clone from the same
+// domain fell back to, so the transport known to work is tried first. It only applies when
+// protocol_fallback allows switching from the URL's protocol.
+func preferWorkingProtocol(repoURL string, parsedURL *gitutil.ParsedGitURL) string {
+	preferred := repoState.PreferredProtocol(parsedURL.Domain)
+	if preferred == "" || preferred == urlProtocol(parsedURL) || !fallbackAllowed(parsedURL) {
+		return repoURL
+	}
+	if otherURL, err := otherProtocolURL(parsedURL); err == nil {
+		return otherURL
+	}
+	return repoURL
+}
+
+// cloneWithFallback runs clone with job.url. If it fails because the remote can't be reached
+// or rejects the credentials, and protocol_fallback allows it, clone is run again with the
+// URL converted to the other protocol; job.url and job.parsed are updated if that works.
+// When falling back is enabled, the protocol that worked is recorded for the domain.
+func cloneWithFallback(job *cloneJob, clone func(repoURL string) error, notify func(msg string)) error {
+	domain := job.parsed.Domain
+	err := clone(job.url)
+	if err != nil && fallbackAllowed(job.parsed) && gitutil.IsTransportError(err) {
+		otherURL, convErr := otherProtocolURL(job.parsed)
+		if convErr != nil {
+			return err
+		}
+		otherParsed, convErr := parseRepoURL(otherURL)
+		if convErr != nil {
+			return err
+		}
+		notify(fmt.Sprintf("Cloning over %s failed; retrying with %s", urlProtocol(job.parsed), otherURL))
+		if retryErr := clone(otherURL); retryErr != nil {
+			return fmt.Errorf("%w\nRetrying over %s failed as well: %v", err, urlProtocol(otherParsed), retryErr)
+		}
+		job.url, job.parsed, err = otherURL, otherParsed, nil
+	}
+	if err == nil && appConfig.ProtocolFallback != "off" {
+		repoState.SetPreferredProtocol(domain, urlProtocol(job.parsed))
+	}
+	return err
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/auth"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	prsOutput string
+	prsFilter filter.Filter
+)
+
+// trackedPullRequest is an open pull request of a tracked repository.
+type trackedPullRequest struct {
+	auth.PullRequest
+	Domain     string `json:"domain"`
+	Repository string `json:"repository"` // Name of the tracked repository
+	Path       string `json:"path"`
+}
+
+// prsCmd represents the prs command
+var prsCmd = &cobra.Command{
+	Use:   "prs",
+	Short: "Lists your open pull/merge requests across all tracked repositories.",
+	Long: `Asks the API of every host tracked repositories are on for the open pull requests (merge
+requests on GitLab) you authored or are assigned to, and lists those of tracked repositories
+with their CI status and age, oldest first.
+
+Hosts are queried with the tokens stored by 'fussy-git auth login'; hosts without a token are
+skipped. On Bitbucket, which has no assignees, only pull requests you authored are listed.
+The CI status summarizes all checks of the request's latest commit: failure if any check
+failed, pending if any is still running, success otherwise.
+
+Use --domain, --owner, --tag, --group and --path-prefix to only list the requests of some
+repositories, and --output json for a machine-readable list.
+
+Examples:
+  fussy-git prs
+  fussy-git prs --group platform
+  fussy-git prs --domain github.com --output json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if prsOutput != "text" && prsOutput != "json" {
+			return usageError("invalid --output '%s': must be 'text' or 'json'", prsOutput)
+		}
+		repos := prsFilter.Apply(repoState.Repositories)
+		if len(repos) == 0 {
+			fmt.Println("No repositories to check.")
+			return nil
+		}
+
+		// Repositories by domain and by their lowercased path on the host.
+		byDomain := make(map[string]map[string]state.RepositoryEntry)
+		for _, repo := range repos {
+			parsed, err := parseRepoURL(repo.CurrentURL)
+			if err != nil || parsed.Domain == "" {
+				continue
+			}
+			domain := strings.ToLower(parsed.Domain)
+			if byDomain[domain] == nil {
+				byDomain[domain] = make(map[string]state.RepositoryEntry)
+			}
+			byDomain[domain][strings.ToLower(strings.TrimSuffix(parsed.Path, ".git"))] = repo
+		}
+
+		store, err := openCredentials()
+		if err != nil {
+			return err
+		}
+		var domains, withoutToken []string
+		for domain := range byDomain {
+			if _, ok := store.Lookup(domain); ok {
+				domains = append(domains, domain)
+			} else {
+				withoutToken = append(withoutToken, domain)
+			}
+		}
+		sort.Strings(domains)
+		sort.Strings(withoutToken)
+		if len(withoutToken) > 0 && prsOutput == "text" {
+			fmt.Printf("[SKIP] No token stored for %s; add one with 'fussy-git auth login <domain>'.\n", strings.Join(withoutToken, ", "))
+		}
+		if len(domains) == 0 {
+			return fmt.Errorf("no token is stored for any host of the selected repositories; add one with 'fussy-git auth login <domain>'")
+		}
+
+		var (
+			mu       sync.Mutex
+			wg       sync.WaitGroup
+			prs      []trackedPullRequest
+			failures []string
+		)
+		for _, domain := range domains {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				found, err := domainPullRequests(store, domain, byDomain[domain])
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					failures = append(failures, fmt.Sprintf("%s: %v", domain, err))
+					return
+				}
+				prs = append(prs, found...)
+			}()
+		}
+		wg.Wait()
+		sort.Strings(failures)
+		sort.SliceStable(prs, func(i, j int) bool { return prs[i].CreatedAt.Before(prs[j].CreatedAt) })
+
+		if prsOutput == "json" {
+			if prs == nil {
+				prs = []trackedPullRequest{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(prs); err != nil {
+				return err
+			}
+		} else {
+			for _, failure := range failures {
+				fmt.Printf("[FAIL] %s\n", failure)
+			}
+			if len(prs) == 0 {
+				fmt.Println("No open pull requests authored by or assigned to you.")
+			} else {
+				printPullRequests(prs)
+			}
+		}
+		if len(failures) > 0 {
+			return bulkFailure(len(failures), len(domains), fmt.Errorf("pull requests could not be listed for %d of %d hosts", len(failures), len(domains)))
+		}
+		return nil
+	},
+}
+
+// domainPullRequests returns the open pull requests of the token's user on domain that belong
+// to one of repos, which are keyed by their lowercased path on the host.
+func domainPullRequests(store *auth.Store, domain string, repos map[string]state.RepositoryEntry) ([]trackedPullRequest, error) {
+	credential, _ := store.Lookup(domain)
+	token, err := store.Token(domain)
+	if err != nil {
+		return nil, err
+	}
+	info, err := auth.CheckToken(domain, credential.Provider, token)
+	if err != nil {
+		return nil, err
+	}
+	prs, err := auth.OpenPullRequests(domain, credential.Provider, token, info.User)
+	if err != nil {
+		return nil, err
+	}
+	var tracked []trackedPullRequest
+	for _, pr := range prs {
+		repo, ok := repos[strings.ToLower(pr.Repo)]
+		if !ok {
+			if verbose {
+				fmt.Printf("Ignoring %s#%d: %s/%s is not tracked or not selected.\n", pr.Repo, pr.Number, domain, pr.Repo)
+			}
+			continue
+		}
+		tracked = append(tracked, trackedPullRequest{PullRequest: pr, Domain: domain, Repository: repo.Name, Path: repo.Path})
+	}
+	return tracked, nil
+}
+
+// printPullRequests prints pull requests as a table.
+func printPullRequests(prs []trackedPullRequest) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\t#\tTITLE\tCI\tAGE")
+	fmt.Fprintln(w, "----------\t-\t-----\t--\t---")
+	for _, pr := range prs {
+		title := pr.Title
+		if pr.Draft {
+			title = "[draft] " + title
+		}
+		ci := pr.CI
+		if ci == "" {
+			ci = "-"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", pr.Repository, pr.Number, title, ci, formatAge(time.Since(pr.CreatedAt)))
+	}
+	w.Flush()
+}
+
+// formatAge formats a duration in its largest whole unit, e.g. "3d" or "5h".
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	}
+}
+
+func init() {
+	prsCmd.Flags().StringVarP(&prsOutput, "output", "o", "text", "Output format: 'text' or 'json'")
+	addFilterFlags(prsCmd, &prsFilter)
+}
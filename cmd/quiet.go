@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// quietOutput is set by the --quiet flag of the commands that support it (see addQuietFlag).
+var quietOutput bool
+
+// essentialOut receives the lines of the quiet output contract. Outside of quiet mode it
+// discards them, since the regular output reports the same.
+var essentialOut io.Writer = io.Discard
+
+// addQuietFlag registers --quiet on cmd. The command must call beginQuiet before printing
+// anything and report its actions with reportAction.
+func addQuietFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&quietOutput, "quiet", "q", false, "Print only one tab-separated line per action taken, and nothing else (errors still go to stderr)")
+}
+
+// beginQuiet starts quiet mode if --quiet was given: everything the command prints to stdout
+// is discarded, except the lines of reportAction. The returned function ends it.
+func beginQuiet() (func(), error) {
+	if !quietOutput {
+		return func() {}, nil
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", os.DevNull, err)
+	}
+	stdout := os.Stdout
+	os.Stdout, essentialOut = devNull, stdout
+	return func() {
+		os.Stdout, essentialOut = stdout, io.Discard
+		devNull.Close()
+	}, nil
+}
+
+// reportAction prints a line of the quiet output contract: the action, e.g. "cloned", followed
+// by its fields, separated by tabs. Tabs and newlines within fields are replaced by spaces so
+// every action stays on one line.
+func reportAction(action string, fields ...string) {
+	line := []string{action}
+	for _, field := range fields {
+		line = append(line, strings.NewReplacer("\t", " ", "\n", " ").Replace(field))
+	}
+	fmt.Fprintln(essentialOut, strings.Join(line, "\t"))
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// annotationMutates marks commands that change repositories, the state file or other data of
+// fussy-git, which are refused in read-only mode. Commands marked with annotationWritesTree
+// are refused too.
+const annotationMutates = "fussy-git/mutates"
+
+// mutates is the annotation set on commands that change repositories or the state.
+var mutates = map[string]string{annotationMutates: "true"}
+
+var readOnlyFlag bool
+
+// readOnlyHelp describes read-only mode in the help of the root command.
+const readOnlyHelp = `Read-only mode ('read_only: true' in the config file, or --read-only) is for auditing a tree
+that must not be changed: commands that move, clone, delete or rewrite repositories, change
+their remotes or change the state are refused unless run with --dry-run, and the state file
+is only written to update timestamps, e.g. by 'fussy-git fetch'. Commands run with 'exec' or
+passed through to git are not restricted.`
+
+// checkReadOnly enables read-only mode if --read-only is given, and refuses to run a command
+// that mutates anything in read-only mode, unless it only shows what it would do (--dry-run).
+func checkReadOnly(cmd *cobra.Command) error {
+	if readOnlyFlag {
+		appConfig.ReadOnly = true
+	}
+	if !appConfig.ReadOnly || (cmd.Annotations[annotationMutates] == "" && cmd.Annotations[annotationWritesTree] == "") {
+		return nil
+	}
+	if dryRun := cmd.Flags().Lookup("dry-run"); dryRun != nil && dryRun.Value.String() == "true" {
+		return nil
+	}
+	return readOnlyError(fmt.Sprintf("'%s'", cmd.CommandPath()))
+}
+
+// readOnlyError returns the error for an operation refused in read-only mode.
+func readOnlyError(operation string) error {
+	source := "'read_only' is set in " + appConfig.ConfigFile
+	if readOnlyFlag {
+		source = "--read-only is given"
+	}
+	return fmt.Errorf("%s changes repositories or the state, which isn't allowed in read-only mode (%s)", operation, source)
+}
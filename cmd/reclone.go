@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/fsutil"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var forceReclone bool
+
+// salvageRef is the ref uncommitted changes of a broken repository are recorded at, so they
+// can be fetched into the fresh clone.
+const salvageRef = "refs/fussy-git/salvage"
+
+// recloneCmd represents the reclone command
+var recloneCmd = &cobra.Command{
+	Use:   "reclone <repo>",
+	Short: "Replaces a corrupt repository with a fresh clone, keeping local work where possible.",
+	Long: `Repairs a corrupt or otherwise broken repository (see 'fussy-git verify') by cloning it again
+from its stored URL and swapping the fresh clone into place. Before that, as much local work as
+can still be read from the broken repository is carried over:
+- Local branches, including commits that were never pushed. Branches whose objects are
+  missing can't be recovered and are listed.
+- Uncommitted changes to tracked files, which are added to the stash of the fresh clone
+  ('git stash pop' applies them).
+- Untracked files that aren't ignored.
+The branch that was checked out is checked out again.
+
+The fresh clone is placed at the repository's conventional location (or its path override),
+or at its current path if the repository is pinned. The broken repository is not deleted: it
+is renamed to <path>.broken-<timestamp>, so anything else can still be copied from it. Delete
+it once you have checked nothing is missing.
+
+Locked repositories (see 'fussy-git lock') are only recloned with --force.
+
+<repo> can be the repository's path, its normalized path (e.g. github.com/spf13/cobra),
+its URL, or its name if that is unambiguous.
+
+Examples:
+  fussy-git reclone github.com/spf13/cobra
+  fussy-git reclone ~/git/github.com/spf13/cobra`,
+	Annotations:       writesTree,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRepository,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		idx, err := lookupRepository(args[0])
+		if err != nil {
+			return err
+		}
+		entry := repoState.Repositories[idx]
+		if entry.Locked && !forceReclone {
+			return fmt.Errorf("repository '%s' is locked; unlock it with 'fussy-git unlock' or use --force", entry.Name)
+		}
+		parsedURL, err := parseRepoURL(entry.CurrentURL)
+		if err != nil {
+			return fmt.Errorf("invalid stored URL '%s' for %s: %w", entry.CurrentURL, entry.Path, err)
+		}
+
+		finalPath := entry.Path
+		if !entry.Pinned {
+			finalPath = expectedRepoPath(entry, parsedURL)
+		}
+		if !samePath(finalPath, entry.Path) {
+			if _, err := os.Stat(finalPath); !os.IsNotExist(err) {
+				return fmt.Errorf("the repository's conventional location %s is taken; move it away or pin the repository to reclone it in place", finalPath)
+			}
+		}
+		freshPath := finalPath + ".fussy-git-reclone"
+		if _, err := os.Stat(freshPath); !os.IsNotExist(err) {
+			return fmt.Errorf("%s already exists, probably left over from an interrupted reclone; remove it first", freshPath)
+		}
+		if err := makeRepoDirs(filepath.Dir(finalPath)); err != nil {
+			return fmt.Errorf("failed to create parent directory of %s: %w", finalPath, err)
+		}
+
+		// 1. Clone into a fresh directory next to the final location, so it can be renamed into place.
+		fmt.Printf("Cloning %s into %s...\n", entry.CurrentURL, freshPath)
+		start := time.Now()
+		job := &cloneJob{url: entry.CurrentURL, parsed: parsedURL}
+		cacheArgs, err := cacheCloneArgs(job, func(msg string) { fmt.Println(msg) })
+		if err != nil {
+			fmt.Printf("[WARN] Cloning without the clone cache: %v\n", err)
+		}
+		if _, err := gitutil.CloneRepository(entry.CurrentURL, freshPath, verbose, append(sharedCloneArgs(), cacheArgs...)...); err != nil {
+			os.RemoveAll(freshPath)
+			return err
+		}
+		stats := measureClone(freshPath, time.Since(start))
+
+		// 2. Carry over what can be read from the broken repository.
+		_, statErr := os.Stat(entry.Path)
+		oldExists := statErr == nil
+		if gitutil.IsGitRepository(entry.Path) {
+			salvageLocalWork(entry.Path, freshPath)
+		} else if oldExists {
+			fmt.Printf("[WARN] %s is not a git repository anymore; no local work can be recovered from it.\n", entry.Path)
+		}
+
+		// 3. Swap the fresh clone into place, keeping the broken repository as a backup.
+		backupPath := ""
+		if oldExists {
+			backupPath = fmt.Sprintf("%s.broken-%s", entry.Path, time.Now().Format("20060102-150405"))
+			if err := os.Rename(entry.Path, backupPath); err != nil {
+				return fmt.Errorf("failed to move the broken repository aside: %w. The fresh clone is at %s", err, freshPath)
+			}
+		}
+		if err := os.Rename(freshPath, finalPath); err != nil {
+			if backupPath != "" {
+				if restoreErr := os.Rename(backupPath, entry.Path); restoreErr != nil {
+					return fmt.Errorf("failed to move the fresh clone into place (%v) and to restore the broken repository from %s: %w", err, backupPath, restoreErr)
+				}
+			}
+			return fmt.Errorf("failed to move the fresh clone into place: %w. It is at %s", err, freshPath)
+		}
+		if err := shareWorkTree(finalPath); err != nil {
+			fmt.Printf("[WARN] Failed to make %s group-writable: %v\n", finalPath, err)
+		}
+		if !samePath(finalPath, entry.Path) {
+			updateAlternatesAfterMove(entry.Path, finalPath)
+		}
+
+		// 4. Record the new location; the old verification result doesn't apply anymore.
+		repoState.Repositories[idx].Path = finalPath
+		repoState.Repositories[idx].ResolvedPath = resolvedPath(finalPath)
+		repoState.Repositories[idx].GitDir = ""
+		repoState.Repositories[idx].Verification = nil
+		repoState.Repositories[idx].Shallow = false
+		repoState.Repositories[idx].CloneOptions = nil
+		repoState.Repositories[idx].LFS = gitutil.UsesLFS(finalPath)
+		repoState.Repositories[idx].CloneStats = stats
+		repoState.Repositories[idx].LastModified = time.Now()
+		repoState.Reindex()
+		if err := repoState.Save(appConfig.StateFilePath); err != nil {
+			return fmt.Errorf("repository recloned to %s, but failed to save state: %w", finalPath, err)
+		}
+
+		fmt.Printf("\nRepository %s recloned at %s.\n", entry.Name, finalPath)
+		if backupPath != "" {
+			fmt.Printf("The broken repository was kept at %s; delete it once you've checked nothing is missing.\n", backupPath)
+		}
+		return nil
+	},
+}
+
+// salvageLocalWork copies local branches, uncommitted changes and untracked files from the
+// broken repository at oldPath into the fresh clone at freshPath, as far as they can be read,
+// and checks out the branch that was checked out before. Problems are reported as warnings.
+func salvageLocalWork(oldPath, freshPath string) {
+	fmt.Printf("Recovering local work from %s...\n", oldPath)
+	checkedOut, _ := gitutil.CurrentBranch(oldPath)
+
+	branches, err := gitutil.LocalBranches(oldPath)
+	if err != nil {
+		fmt.Printf("[WARN] Could not list local branches: %v\n", err)
+	}
+	var lost []string
+	for _, branch := range branches {
+		if err := gitutil.FetchBranch(freshPath, oldPath, branch); err != nil {
+			lost = append(lost, branch)
+			if verbose {
+				fmt.Printf("    %s: %v\n", branch, err)
+			}
+			continue
+		}
+		fmt.Printf("  Recovered branch '%s'.\n", branch)
+	}
+	for _, branch := range lost {
+		fmt.Printf("[WARN] Branch '%s' could not be recovered: objects it needs are missing or corrupt.\n", branch)
+	}
+
+	// Fetching may have moved the checked-out branch, so always refresh the working tree.
+	target := checkedOut
+	if target == "" || !gitutil.BranchExists(freshPath, target) {
+		target, _ = gitutil.CurrentBranch(freshPath)
+	}
+	if err := gitutil.CheckoutClean(freshPath, target); err != nil {
+		fmt.Printf("[WARN] Could not check out '%s' in the fresh clone: %v\n", target, err)
+	}
+
+	if stash, err := gitutil.StashCreate(oldPath); err != nil {
+		fmt.Printf("[WARN] Uncommitted changes could not be recovered: %v\n", err)
+	} else if stash != "" {
+		err := gitutil.UpdateRef(oldPath, salvageRef, stash)
+		if err == nil {
+			err = gitutil.FetchStash(freshPath, oldPath, salvageRef, "Uncommitted changes recovered by fussy-git reclone")
+		}
+		if err != nil {
+			fmt.Printf("[WARN] Uncommitted changes could not be recovered: %v\n", err)
+		} else {
+			fmt.Println("  Recovered uncommitted changes into the stash; apply them with 'git stash pop'.")
+		}
+	}
+
+	files, err := gitutil.UntrackedFiles(oldPath)
+	if err != nil {
+		fmt.Printf("[WARN] Untracked files could not be listed: %v\n", err)
+		return
+	}
+	copied := 0
+	for _, file := range files {
+		if _, err := os.Lstat(filepath.Join(freshPath, file)); err == nil {
+			continue // Now tracked upstream; keep origin's version.
+		}
+		if err := fsutil.CopyFile(filepath.Join(oldPath, file), filepath.Join(freshPath, file)); err != nil {
+			fmt.Printf("[WARN] Untracked file %s could not be copied: %v\n", file, err)
+			continue
+		}
+		copied++
+	}
+	if copied > 0 {
+		fmt.Printf("  Recovered %d untracked files.\n", copied)
+	}
+}
+
+func init() {
+	recloneCmd.Flags().BoolVar(&forceReclone, "force", false, "Reclone the repository even if it is locked")
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// setCloneReference makes job borrow objects from an existing local repository (see
+// 'git clone --reference'): the one given with --reference, or with --auto-reference a
+// tracked fork sibling of the repository being cloned. It does nothing otherwise.
+func setCloneReference(job *cloneJob) error {
+	if cloneReference != "" {
+		path, err := resolveReferenceRepository(cloneReference)
+		if err != nil {
+			return err
+		}
+		job.cloneArgs = append(job.cloneArgs, "--reference", path)
+		job.reference = path
+		return nil
+	}
+	if !cloneAutoReference {
+		return nil
+	}
+	if idx := findForkSibling(job.parsed, job.target); idx >= 0 {
+		// The sibling might be broken or gone; git then clones without sharing.
+		path := repoState.Repositories[idx].Path
+		job.cloneArgs = append(job.cloneArgs, "--reference-if-able", path)
+		job.reference = path
+	}
+	return nil
+}
+
+// resolveReferenceRepository returns the local repository path given with --reference,
+// either as a tracked repository reference or as the path of any local git repository.
+func resolveReferenceRepository(ref string) (string, error) {
+	if idx, err := lookupRepository(ref); err == nil {
+		return repoState.Repositories[idx].Path, nil
+	}
+	path, err := filepath.Abs(ref)
+	if err != nil || !gitutil.IsGitRepository(path) {
+		return "", fmt.Errorf("--reference '%s' is neither a tracked repository nor a local git repository", ref)
+	}
+	return path, nil
+}
+
+// findForkSibling returns the index of a tracked repository that is likely a fork or the
+// upstream of parsed: same domain and repository name, different owner. Repositories that
+// don't borrow objects themselves are preferred, to avoid chains of alternates.
+// It returns -1 if there is none.
+func findForkSibling(parsed *gitutil.ParsedGitURL, target string) int {
+	best := -1
+	for i, repo := range repoState.Repositories {
+		if !strings.EqualFold(repo.Domain, parsed.Domain) || !strings.EqualFold(repo.Name, parsed.RepoName) ||
+			samePath(repo.Path, target) || !gitutil.IsGitRepository(repo.Path) {
+			continue
+		}
+		if alternates, err := gitutil.Alternates(repo.Path); err == nil && len(alternates) == 0 {
+			return i
+		}
+		if best < 0 {
+			best = i
+		}
+	}
+	return best
+}
+
+// borrowersOf returns the names of the tracked repositories other than the one at path that
+// borrow objects from it through their alternates; removing or moving it breaks them.
+func borrowersOf(path string) []string {
+	objects := gitutil.ObjectsDir(path)
+	var borrowers []string
+	for _, repo := range repoState.Repositories {
+		if samePath(repo.Path, path) {
+			continue
+		}
+		alternates, err := gitutil.Alternates(repo.Path)
+		if err != nil {
+			continue
+		}
+		for _, dir := range alternates {
+			if samePath(dir, objects) {
+				borrowers = append(borrowers, repo.Name)
+				break
+			}
+		}
+	}
+	return borrowers
+}
+
+// updateAlternatesAfterMove points tracked repositories that borrow objects from the
+// repository moved from oldPath to newPath at its new location. Failures are reported
+// but don't fail the move; 'doctor' reports the affected repositories.
+func updateAlternatesAfterMove(oldPath, newPath string) {
+	oldObjects, newObjects := gitutil.ObjectsDir(oldPath), gitutil.ObjectsDir(newPath)
+	for _, repo := range repoState.Repositories {
+		if samePath(repo.Path, oldPath) || samePath(repo.Path, newPath) {
+			continue
+		}
+		alternates, err := gitutil.Alternates(repo.Path)
+		if err != nil || len(alternates) == 0 {
+			continue
+		}
+		changed := false
+		for i, dir := range alternates {
+			if samePath(dir, oldObjects) {
+				alternates[i], changed = newObjects, true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if err := gitutil.SetAlternates(repo.Path, alternates); err != nil {
+			fmt.Fprintf(os.Stderr, "    [WARN] %s shares objects with the moved repository and could not be updated: %v\n", repo.Name, err)
+			continue
+		}
+		fmt.Printf("    Updated %s, which shares objects with it.\n", repo.Name)
+	}
+}
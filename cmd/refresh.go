@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/readme"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// refreshBatchLimit is the most repositories refreshStaleRepositories refreshes in one run, the
+// least recently checked first, so a large tree is brought up to date over several runs
+// instead of slowing down a single one.
+const refreshBatchLimit = 64
+
+// liveMetadata is what is read from a clone to keep its entry up to date.
+type liveMetadata struct {
+	url    string
+	gitDir string
+	lfs    bool
+	// description is read from the README, "" if it has none or can't be read.
+	description string
+}
+
+// readLiveMetadata reads the origin URL, separate git directory, use of Git LFS and README
+// description of the clone at path.
+func readLiveMetadata(path string) (liveMetadata, error) {
+	url, err := gitutil.GetRemoteOriginURL(path, verbose)
+	if err != nil {
+		return liveMetadata{}, err
+	}
+	return liveMetadata{url: url, gitDir: separateGitDir(path), lfs: gitutil.UsesLFS(path), description: readDescription(path)}, nil
+}
+
+// applyLiveMetadata records live in entry as checked at now, describing a changed URL on out,
+// and reports whether the URL changed. The caller must call Reindex if it did.
+func applyLiveMetadata(entry *state.RepositoryEntry, live liveMetadata, now time.Time, out io.Writer) bool {
+	entry.LastChecked = now
+	if live.gitDir != entry.GitDir {
+		entry.GitDir = live.gitDir
+		entry.LastModified = now
+	}
+	if live.lfs != entry.LFS {
+		entry.LFS = live.lfs
+		entry.LastModified = now
+	}
+	if live.description != entry.Description {
+		entry.Description = live.description
+		entry.LastModified = now
+	}
+	if live.url == entry.CurrentURL {
+		return false
+	}
+	recordURLUpdate(entry, live.url, out)
+	entry.LastModified = now
+	return true
+}
+
+// refreshStaleRepositories refreshes the origin URL of the repositories that weren't checked
+// for longer than the url_refresh_after setting from their clones, reading several at a time,
+// and saves the state. Changed URLs are reported on stderr, to keep the output of the command
+// intact. Nothing is refreshed in read-only mode, and failures are only shown with --verbose:
+// the refresh is opportunistic, and doctor reports repositories that can't be read.
+func refreshStaleRepositories() {
+	if appConfig.URLRefreshAfter == 0 || appConfig.ReadOnly {
+		return
+	}
+	now := time.Now()
+	var stale []int
+	for i, repo := range repoState.Repositories {
+		if now.Sub(repo.LastChecked) < appConfig.URLRefreshAfter || repoState.IsShared(repo.Path) {
+			continue
+		}
+		if _, err := os.Stat(repo.Path); err != nil {
+			continue // Reported by doctor and pruned by maintenance.
+		}
+		stale = append(stale, i)
+	}
+	if len(stale) == 0 {
+		return
+	}
+	sort.SliceStable(stale, func(i, j int) bool {
+		return repoState.Repositories[stale[i]].LastChecked.Before(repoState.Repositories[stale[j]].LastChecked)
+	})
+	if len(stale) > refreshBatchLimit {
+		stale = stale[:refreshBatchLimit]
+	}
+
+	live := make([]liveMetadata, len(stale))
+	errs := make([]error, len(stale))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < inspectWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				live[j], errs[j] = readLiveMetadata(repoState.Repositories[stale[j]].Path)
+			}
+		}()
+	}
+	for j := range stale {
+		jobs <- j
+	}
+	close(jobs)
+	wg.Wait()
+
+	var changes strings.Builder // Printed once the state is saved
+	updated := 0
+	for j, idx := range stale {
+		entry := &repoState.Repositories[idx]
+		if errs[j] != nil {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "[WARN] Could not refresh the URL of %s: %v\n", entry.Path, errs[j])
+			}
+			entry.LastChecked = now // Retried after url_refresh_after, not on every run.
+			continue
+		}
+		if applyLiveMetadata(entry, live[j], now, &changes) {
+			updated++
+		}
+	}
+	if updated > 0 {
+		repoState.Reindex() // URLs were changed in place.
+	}
+	if err := repoState.Save(appConfig.StateFilePath); err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] Refreshed repository URLs, but failed to save state: %v\n", err)
+		return
+	}
+	if updated > 0 {
+		fmt.Fprintf(os.Stderr, "Refreshed the origin URL of %d repositories not checked for %s:\n", updated, appConfig.URLRefreshAfter)
+		fmt.Fprint(os.Stderr, changes.String())
+	}
+}
+
+// readDescription returns the description from the README of the repository at repoPath, or ""
+// if it has none or it can't be read.
+func readDescription(repoPath string) string {
+	description, err := readme.Description(repoPath)
+	if err != nil {
+		return ""
+	}
+	return description
+}
+
+// truncateDescription shortens a description to at most max characters for a table column.
+func truncateDescription(description string, max int) string {
+	runes := []rune(description)
+	if len(runes) <= max {
+		return description
+	}
+	return strings.TrimSpace(string(runes[:max-3])) + "..."
+}
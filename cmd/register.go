@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"time"
+)
+
+// inspectLocalRepository verifies that absRepoPath is a Git repository, reads and parses its
+// 'origin' URL, and builds the state entry used to track it at its current location.
+// The entry is not added to the state; that's left to the caller.
+func inspectLocalRepository(absRepoPath string) (state.RepositoryEntry, *gitutil.ParsedGitURL, error) {
+	// Verify it's a Git repository
+	if !gitutil.IsGitRepository(absRepoPath) {
+		return state.RepositoryEntry{}, nil, fmt.Errorf("path '%s' is not a valid Git repository", absRepoPath)
+	}
+	if verbose {
+		fmt.Printf("Path '%s' confirmed as a Git repository.\n", absRepoPath)
+	}
+
+	// Fetch its remote origin URL
+	originURL, err := gitutil.GetRemoteOriginURL(absRepoPath, verbose)
+	if err != nil {
+		return state.RepositoryEntry{}, nil, fmt.Errorf("failed to get remote origin URL for repository at '%s': %w. Ensure 'origin' remote is set", absRepoPath, err)
+	}
+	if originURL == "" {
+		return state.RepositoryEntry{}, nil, fmt.Errorf("remote 'origin' URL is empty for repository at '%s'", absRepoPath)
+	}
+	if verbose {
+		fmt.Printf("Found remote origin URL: %s\n", originURL)
+	}
+
+	// Parse this URL
+	parsedURL, err := parseRepoURL(originURL)
+	if err != nil {
+		return state.RepositoryEntry{}, nil, fmt.Errorf("failed to parse remote origin URL '%s': %w", originURL, err)
+	}
+	if verbose {
+		fmt.Printf("Parsed URL -> Domain: %s, Path: %s, User: %s, RepoName: %s\n",
+			parsedURL.Domain, parsedURL.Path, parsedURL.User, parsedURL.RepoName)
+	}
+
+	entry := state.RepositoryEntry{
+		Name:          parsedURL.RepoName,
+		Path:          absRepoPath, // Use the actual current path
+		ResolvedPath:  resolvedPath(absRepoPath),
+		GitDir:        separateGitDir(absRepoPath),
+		OriginalURL:   originURL, // The fetched origin URL is the "original" in this context
+		CurrentURL:    originURL, // Assume current is same as origin for a newly added repo
+		Domain:        parsedURL.Domain,
+		NormalizedFS:  parsedURL.GetNormalizedFSPath(),
+		ManuallyAdded: true, // Mark as manually added
+		Shallow:       gitutil.IsShallow(absRepoPath),
+		LFS:           gitutil.UsesLFS(absRepoPath),
+		License:       detectLicense(absRepoPath),
+		Description:   readDescription(absRepoPath),
+	}
+	return entry, parsedURL, nil
+}
+
+// findMovedRepository returns the index of the tracked repository that entry (found at a path
+// that isn't tracked) was moved from by hand: the one with the same origin URL whose recorded
+// path no longer holds a Git repository. It returns -1 if there is none, or if several
+// tracked repositories would match.
+func findMovedRepository(entry state.RepositoryEntry) int {
+	found := -1
+	for i, repo := range repoState.Repositories {
+		if repo.NormalizedFS != entry.NormalizedFS && repo.CurrentURL != entry.CurrentURL {
+			continue
+		}
+		if _, err := os.Stat(repo.Path); err == nil && gitutil.IsGitRepository(repo.Path) {
+			continue
+		}
+		if found != -1 {
+			return -1
+		}
+		found = i
+	}
+	return found
+}
+
+// recordDetectedMove updates the tracked repository at idx, which was moved by hand, to its
+// new location newPath.
+func recordDetectedMove(idx int, newPath string) {
+	entry := &repoState.Repositories[idx]
+	oldPath, oldResolvedPath := entry.Path, entry.ResolvedPath
+	entry.Path = newPath
+	entry.ResolvedPath = resolvedPath(newPath)
+	entry.GitDir = separateGitDir(newPath)
+	if entry.PathOverride != "" {
+		entry.PathOverride = newPath
+	}
+	entry.LastModified = time.Now()
+	relocateGitMaintenance(entry, oldPath, oldResolvedPath)
+	updateAlternatesAfterMove(oldPath, newPath)
+	repoState.Reindex()
+}
@@ -2,16 +2,38 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/fsutil"
 	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/plan"
 	"github.com/jmsnll/fussy-git/internal/state"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
-var dryRunReorg bool
+var (
+	dryRunReorg      bool
+	interactiveReorg bool
+	reorgFilter      filter.Filter
+	reorgOutput      string
+	reorgApplyPlan   string
+	forceDirtyReorg  bool
+	forceReorg       bool
+	reorgOnConflict  string
+	// noVerifyMoves disables the integrity check after moves (see moveRepository).
+	noVerifyMoves bool
+	// verifyCopyMoves checks copies made by moves against checksums of the original (see copyVerified).
+	verifyCopyMoves bool
+)
 
 // reorganizeCmd represents the reorganize command
 var reorganizeCmd = &cobra.Command{
@@ -23,203 +45,950 @@ var reorganizeCmd = &cobra.Command{
 3. If the live 'origin' URL differs from the 'CurrentURL' stored in fussy-git's state,
    the state will be updated (unless --dry-run is active).
 4. Calculates the conventional filesystem path for the repository based on its
-   (potentially updated) 'origin' URL and your FUSSY_GIT_HOME.
+   (potentially updated) 'origin' URL, your FUSSY_GIT_HOME and the configured layout.
 5. If the repository's actual local path differs from this conventional path,
    it will be moved to the conventional path, and fussy-git's state will be updated
    (unless --dry-run is active).
 
-Use --dry-run to see what changes would be made without applying them.`,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		if verbose {
-			fmt.Println("Starting repository reorganization process...")
-			if dryRunReorg {
-				fmt.Println("DRY RUN active: No changes will be made to the filesystem or state file.")
-			}
-			fmt.Printf("State file: %s\n", appConfig.StateFilePath)
-			fmt.Printf("FUSSY_GIT_HOME: %s\n", appConfig.FussyGitHome)
-		}
+Use --dry-run to see what changes would be made without applying them.
+Use --interactive to confirm each URL update and move individually. At every prompt,
+answer 'y' to apply, 'n' to skip, 'a' to apply this and all remaining actions,
+or 'q' to skip everything that remains. Moves can also be answered with 'p' to pin the
+repository at its current location instead.
 
-		if len(repoState.Repositories) == 0 {
-			fmt.Println("No repositories are currently managed by fussy-git. Nothing to reorganize.")
-			return nil
-		}
+Pinned repositories (see 'fussy-git pin') are never moved; their URL changes are still recorded.
+Locked repositories (see 'fussy-git lock') are only moved with --force.
+Repositories with a path override (see 'fussy-git path-override') are moved to that path
+instead of the computed conventional one.
+
+Paths are compared with symlinks resolved: a repository reached through a symlink (e.g. when
+FUSSY_GIT_HOME or one of its directories is a symlink) is in place if its resolved path is the
+resolved conventional path. If a repository's path is itself a symlink, moving it moves the link.
+
+Repositories with uncommitted changes or untracked files are not moved, so that a failed
+move can never take unsaved work with it. Commit or stash the changes first, or use
+--force-dirty to move them anyway.
+
+After every move, the repository's HEAD is compared with its value before the move and
+'git fsck --connectivity-only' checks that no objects went missing; if either fails, the move
+is rolled back. Moves between filesystems copy the repository and only remove the original
+once the copy has been verified; for repositories using Git LFS, whose content git doesn't
+check, the copied LFS objects must also match the original in number and size and pass
+'git lfs fsck' (if git-lfs is installed). Use --no-verify to skip the checks on very large
+repositories.
+
+For paranoid moves, --verify-copy checks every copy file by file: each file of the original is
+hashed (SHA-256) before copying and compared with its copy afterwards, and the original is only
+removed if all of them match, with the number of bytes verified reported. A copy that doesn't
+match is removed again, leaving the original in place. Moves that are plain renames don't copy
+anything and aren't affected.
 
-		fmt.Printf("Found %d repositories to check for reorganization.\n\n", len(repoState.Repositories))
+Moves from or to a network filesystem (NFS, SMB, ...) are also done by copying, with the
+progress shown, rather than renaming: a rename on a network filesystem may be a copy in
+disguise and can fail halfway, while a copy leaves the original in place until it has been
+verified. Planning warns about such moves of large repositories before they start.
 
-		var modifiedEntries []state.RepositoryEntry
-		stateModified := false
-		actionsTaken := 0
-		actionsProposed := 0
+Repositories whose .git is a file are moved as a whole: a git directory created with
+'git clone --separate-git-dir' next to the work tree (e.g. <name>.git beside <name>, inside
+FUSSY_GIT_HOME) is moved along and the .git file is updated; one elsewhere stays where it is.
+Linked worktrees ('git worktree add'), and repositories that have some, are repaired with
+'git worktree repair' so both sides find each other again.
 
-		originalRepositories := make([]state.RepositoryEntry, len(repoState.Repositories))
-		copy(originalRepositories, repoState.Repositories)
+Switching the 'layout' setting (domain, owner or flat) and running reorganize migrates
+all repositories to the new structure. Repositories that would collide in the new layout
+(e.g. two owners with a repository of the same name in the flat layout) are not moved.
 
-		// Create a new slice for updated repositories to avoid modifying while iterating
-		updatedRepositories := make([]state.RepositoryEntry, 0, len(repoState.Repositories))
+` + conflictHelp + `
+
+Use --domain, --owner, --tag and --path-prefix to limit the run to a subset of repositories,
+e.g. 'fussy-git reorganize --domain github.com --owner work-org'.
+
+Plans can be reviewed before they are executed:
+  fussy-git reorganize --dry-run --output json > plan.json
+  fussy-git reorganize --apply-plan plan.json
+When applying a plan, each operation is executed exactly as written. Operations whose
+source no longer matches the current state (e.g. the repository was moved in the meantime)
+are reported and skipped.
+
+For scripts, --quiet prints only one tab-separated line per operation carried out (or, with
+--dry-run, planned): its type as in plans, its source and its target, e.g.
+'move <old path> <new path>' or 'url-update <old URL> <new URL>', and 'failed <path> <reason>'
+for operations that failed. Nothing is printed if all repositories are organized.
+
+Ctrl-C (or SIGTERM) never leaves a move half done: the move in progress is completed, or
+rolled back if its checks were interrupted, the state is saved, and reorganize prints where
+it left off before exiting with status 130. The operations it didn't get to are written to
+<state file>.reorganize-remaining.json for --apply-plan. Progress is also recorded in a journal
+next to the state file; if reorganize is killed or the machine goes down, the next run records
+the moves that completed in the state before planning.`,
+	Annotations: writesTree,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if interactiveReorg && dryRunReorg {
+			return fmt.Errorf("--interactive and --dry-run cannot be used together")
+		}
+		if reorgOutput != "text" && reorgOutput != "json" {
+			return fmt.Errorf("invalid --output value '%s': must be 'text' or 'json'", reorgOutput)
+		}
+		if reorgOutput == "json" && !dryRunReorg {
+			return fmt.Errorf("--output json is only supported together with --dry-run")
+		}
+		if !validConflictStrategy(reorgOnConflict) {
+			return usageError("invalid --on-conflict value '%s': must be 'skip', 'swap', 'merge', 'suffix' or 'auto'", reorgOnConflict)
+		}
+		if reorgApplyPlan != "" && (dryRunReorg || !reorgFilter.IsEmpty()) {
+			return fmt.Errorf("--apply-plan cannot be combined with --dry-run or filters; the plan defines exactly what is applied")
+		}
+		if quietOutput && (interactiveReorg || reorgOutput == "json") {
+			return usageError("--quiet can't be used with --interactive or --output json")
+		}
+		endQuiet, err := beginQuiet()
+		if err != nil {
+			return err
+		}
+		defer endQuiet()
 
-		for _, repoEntry := range originalRepositories {
-			currentRepo := repoEntry // Make a mutable copy for this iteration
-			fmt.Printf("Processing: %s (Path: %s)\n", currentRepo.Name, currentRepo.Path)
-			actionLog := []string{} // Log actions for this specific repo
+		// In JSON mode stdout is reserved for the plan itself.
+		var out io.Writer = os.Stdout
+		if reorgOutput == "json" {
+			out = io.Discard
+		}
 
-			// --- Basic Health Checks ---
-			if _, err := os.Stat(currentRepo.Path); os.IsNotExist(err) {
-				actionLog = append(actionLog, fmt.Sprintf("  [SKIP] Path does not exist: %s. Consider removing from state.", currentRepo.Path))
-				fmt.Println(strings.Join(actionLog, "\n"))
-				updatedRepositories = append(updatedRepositories, currentRepo) // Keep original entry if skipped
-				continue
-			} else if err != nil {
-				actionLog = append(actionLog, fmt.Sprintf("  [SKIP] Error accessing path %s: %v. Manual check required.", currentRepo.Path, err))
-				fmt.Println(strings.Join(actionLog, "\n"))
-				updatedRepositories = append(updatedRepositories, currentRepo)
-				continue
+		if verbose {
+			fmt.Fprintln(out, "Starting repository reorganization process...")
+			if dryRunReorg {
+				fmt.Fprintln(out, "DRY RUN active: No changes will be made to the filesystem or state file.")
 			}
+			fmt.Fprintf(out, "State file: %s\n", appConfig.StateFilePath)
+			fmt.Fprintf(out, "FUSSY_GIT_HOME: %s\n", appConfig.FussyGitHome)
+		}
 
-			if !gitutil.IsGitRepository(currentRepo.Path) {
-				actionLog = append(actionLog, fmt.Sprintf("  [SKIP] Path is not a Git repository: %s. Manual check required.", currentRepo.Path))
-				fmt.Println(strings.Join(actionLog, "\n"))
-				updatedRepositories = append(updatedRepositories, currentRepo)
-				continue
+		if !dryRunReorg {
+			if err := recoverReorgJournal(); err != nil {
+				return err
 			}
+		}
 
-			// --- URL Check and Update ---
-			liveOriginURL, err := gitutil.GetRemoteOriginURL(currentRepo.Path, verbose)
+		var reorgPlan *plan.Plan
+		if reorgApplyPlan != "" {
+			loaded, err := plan.Load(reorgApplyPlan)
 			if err != nil {
-				actionLog = append(actionLog, fmt.Sprintf("  [WARN] Failed to get live origin URL: %v. Skipping URL and path checks for this repo.", err))
-				fmt.Println(strings.Join(actionLog, "\n"))
-				updatedRepositories = append(updatedRepositories, currentRepo)
-				continue
-			}
-
-			parsedLiveURL, errLiveParse := gitutil.ParseGitURL(liveOriginURL)
-			if errLiveParse != nil {
-				actionLog = append(actionLog, fmt.Sprintf("  [WARN] Failed to parse live origin URL '%s': %v. Skipping URL and path checks.", liveOriginURL, errLiveParse))
-				fmt.Println(strings.Join(actionLog, "\n"))
-				updatedRepositories = append(updatedRepositories, currentRepo)
-				continue
-			}
-
-			parsedStoredURL, _ := gitutil.ParseGitURL(currentRepo.CurrentURL) // Error handled by checking if nil later
-
-			// Compare normalized URLs (e.g. HTTPS vs SSH)
-			liveHTTPS, _ := parsedLiveURL.ToHTTPS()
-			storedHTTPS := ""
-			if parsedStoredURL != nil {
-				storedHTTPS, _ = parsedStoredURL.ToHTTPS()
-			}
-
-			if parsedStoredURL == nil || liveHTTPS != storedHTTPS {
-				oldURL := currentRepo.CurrentURL
-				actionLog = append(actionLog, fmt.Sprintf("  Remote URL changed: Was '%s', now '%s'", oldURL, liveOriginURL))
-				actionsProposed++
-				if !dryRunReorg {
-					currentRepo.CurrentURL = liveOriginURL
-					// If OriginalURL was the same as the old CurrentURL, update it too,
-					// assuming the "original" intent was to track this remote.
-					if currentRepo.OriginalURL == oldURL {
-						currentRepo.OriginalURL = liveOriginURL
-						actionLog = append(actionLog, fmt.Sprintf("    Also updated OriginalURL to '%s'", liveOriginURL))
-					}
-					stateModified = true
-					actionsTaken++
-				}
+				return err
 			}
-
-			// --- Path Reorganization Check ---
-			// Use the live (and potentially updated in `currentRepo.CurrentURL`) URL for conventional path
-			finalParsedURLForPath, _ := gitutil.ParseGitURL(currentRepo.CurrentURL)
-			if finalParsedURLForPath == nil {
-				actionLog = append(actionLog, fmt.Sprintf("  [WARN] Cannot determine conventional path due to unparsable CurrentURL '%s'.", currentRepo.CurrentURL))
-				fmt.Println(strings.Join(actionLog, "\n"))
-				updatedRepositories = append(updatedRepositories, currentRepo)
-				continue
-			}
-
-			conventionalPath := finalParsedURLForPath.GetLocalPath(appConfig.FussyGitHome)
-			normalizedActualPath := strings.TrimRight(filepath.Clean(currentRepo.Path), string(filepath.Separator))
-			normalizedConventionalPath := strings.TrimRight(filepath.Clean(conventionalPath), string(filepath.Separator))
-
-			if normalizedActualPath != normalizedConventionalPath {
-				actionLog = append(actionLog, fmt.Sprintf("  Path mismatch: Actual '%s', Conventional '%s'", currentRepo.Path, conventionalPath))
-				actionsProposed++
-
-				if !dryRunReorg {
-					// Pre-move safety checks
-					if _, err := os.Stat(conventionalPath); !os.IsNotExist(err) {
-						// Target path exists. This is a conflict.
-						actionLog = append(actionLog, fmt.Sprintf("  [FAIL] Conventional path '%s' already exists. Cannot move. Manual intervention required.", conventionalPath))
-						// Do not proceed with move for this repo
-					} else {
-						// Ensure parent directory of conventionalPath exists
-						parentConventionalDir := filepath.Dir(conventionalPath)
-						if err := os.MkdirAll(parentConventionalDir, 0755); err != nil {
-							actionLog = append(actionLog, fmt.Sprintf("  [FAIL] Failed to create parent directory '%s' for move: %v", parentConventionalDir, err))
-						} else {
-							actionLog = append(actionLog, fmt.Sprintf("  Moving repository from '%s' to '%s'...", currentRepo.Path, conventionalPath))
-							if err := os.Rename(currentRepo.Path, conventionalPath); err != nil {
-								actionLog = append(actionLog, fmt.Sprintf("  [FAIL] Failed to move repository: %v", err))
-							} else {
-								actionLog = append(actionLog, "    Move successful.")
-								currentRepo.Path = conventionalPath
-								stateModified = true
-								actionsTaken++
-							}
-						}
-					}
+			reorgPlan = loaded
+			fmt.Printf("Loaded plan with %d operations from %s (generated %s).\n\n",
+				len(reorgPlan.Operations), reorgApplyPlan, reorgPlan.GeneratedAt.Format(time.RFC3339))
+		} else {
+			if len(repoState.Repositories) == 0 {
+				fmt.Fprintln(out, "No repositories are currently managed by fussy-git. Nothing to reorganize.")
+				if reorgOutput == "json" {
+					return plan.New(appConfig.FussyGitHome, appConfig.StateFilePath).Write(os.Stdout)
 				}
+				return nil
 			}
 
-			// Update name if it was derived from the old path/URL and the URL changed significantly
-			if currentRepo.Name != finalParsedURLForPath.RepoName {
-				oldName := currentRepo.Name
-				currentRepo.Name = finalParsedURLForPath.RepoName
-				actionLog = append(actionLog, fmt.Sprintf("  Repository name updated from '%s' to '%s' based on new URL.", oldName, currentRepo.Name))
-				if !dryRunReorg {
-					stateModified = true
-					// This doesn't count as a separate "action taken" if URL/path already changed.
+			selected := reorgFilter.Apply(repoState.Repositories)
+			if len(selected) == 0 {
+				fmt.Fprintln(out, "No repositories match the given filters. Nothing to reorganize.")
+				if reorgOutput == "json" {
+					return plan.New(appConfig.FussyGitHome, appConfig.StateFilePath).Write(os.Stdout)
 				}
+				return nil
 			}
+			fmt.Fprintf(out, "Found %d repositories to check for reorganization.\n\n", len(selected))
+			reorgPlan = buildReorgPlan(selected, out)
+		}
 
-			if len(actionLog) > 0 {
-				fmt.Println(strings.Join(actionLog, "\n"))
+		if dryRunReorg {
+			if reorgOutput == "json" {
+				return reorgPlan.Write(os.Stdout)
+			}
+			for _, op := range reorgPlan.Operations {
+				reportAction(op.Type, op.Source, op.Target)
+			}
+			if len(reorgPlan.Operations) > 0 {
+				fmt.Println("\nDRY RUN summary: The above changes would be made.")
 			} else {
-				fmt.Println("  No issues or changes needed.")
+				fmt.Println("\nNo changes were necessary. All repositories are organized.")
 			}
-			fmt.Println("---")
-			updatedRepositories = append(updatedRepositories, currentRepo)
-			if stateModified && !dryRunReorg { // If any modification happened to this repo's entry
-				modifiedEntries = append(modifiedEntries, currentRepo)
+			fmt.Printf("\nReorganization summary:\n")
+			fmt.Printf("  Actions proposed: %d\n", len(reorgPlan.Operations))
+			return nil
+		}
+
+		// Left over from an interrupted run (and possibly just loaded); this run supersedes it.
+		os.Remove(reorgRemainingPath())
+
+		if len(reorgPlan.Operations) == 0 {
+			fmt.Println("\nNo changes were necessary. All repositories are organized.")
+			fmt.Printf("\nReorganization summary:\n")
+			fmt.Printf("  Actions taken:    0\n")
+			return nil
+		}
+
+		return applyReorgPlan(reorgPlan)
+	},
+}
+
+// buildReorgPlan inspects the given repositories and collects the URL updates and moves
+// needed to bring them in line with their conventional locations.
+// Progress is narrated to out as each repository is processed.
+func buildReorgPlan(repos []state.RepositoryEntry, out io.Writer) *plan.Plan {
+	reorgPlan := plan.New(appConfig.FussyGitHome, appConfig.StateFilePath)
+
+	for _, repo := range repos {
+		fmt.Fprintf(out, "Processing: %s (Path: %s)\n", repo.Name, repo.Path)
+		ops, actionLog, skipped := planRepository(repo)
+
+		if len(actionLog) > 0 {
+			fmt.Fprintln(out, strings.Join(actionLog, "\n"))
+		} else {
+			fmt.Fprintln(out, "  No issues or changes needed.")
+		}
+		fmt.Fprintln(out, "---")
+
+		if skipped {
+			reorgPlan.Warnings = append(reorgPlan.Warnings, fmt.Sprintf("%s: %s", repo.Path, strings.TrimSpace(actionLog[len(actionLog)-1])))
+		}
+		for _, op := range ops {
+			reorgPlan.Add(op)
+		}
+	}
+
+	dropLayoutCollisions(reorgPlan, repos, out)
+	resolveMoveConflicts(reorgPlan, reorgOnConflict, out)
+	return reorgPlan
+}
+
+// dropLayoutCollisions removes moves that would place several repositories at the same location.
+// This happens with the 'owner' and 'flat' layouts when two repositories share a name
+// (e.g. github.com/a/utils and github.com/b/utils in the flat layout).
+func dropLayoutCollisions(reorgPlan *plan.Plan, repos []state.RepositoryEntry, out io.Writer) {
+	// Where every repository ends up once the plan has been applied.
+	finalPath := make(map[string]string, len(repos))
+	for _, repo := range repos {
+		finalPath[repo.Path] = filepath.Clean(repo.Path)
+	}
+	for _, op := range reorgPlan.Operations {
+		if op.Type == plan.OpMove {
+			finalPath[op.Path] = filepath.Clean(op.Target)
+		}
+	}
+
+	occupants := make(map[string][]string)
+	for repoPath, target := range finalPath {
+		occupants[target] = append(occupants[target], repoPath)
+	}
+
+	colliding := make(map[string]bool)
+	for target, repoPaths := range occupants {
+		if len(repoPaths) < 2 {
+			continue
+		}
+		sort.Strings(repoPaths)
+		msg := fmt.Sprintf("[FAIL] Layout collision: %s all map to '%s' with the configured layout. Use a path override for one of them.",
+			strings.Join(repoPaths, ", "), target)
+		fmt.Fprintf(out, "  %s\n", msg)
+		reorgPlan.Warnings = append(reorgPlan.Warnings, msg)
+		for _, repoPath := range repoPaths {
+			colliding[repoPath] = true
+		}
+	}
+	if len(colliding) == 0 {
+		return
+	}
+
+	kept := reorgPlan.Operations[:0]
+	for _, op := range reorgPlan.Operations {
+		if op.Type == plan.OpMove && colliding[op.Path] {
+			continue
+		}
+		kept = append(kept, op)
+	}
+	reorgPlan.Operations = kept
+}
+
+// planRepository determines the operations needed for a single repository.
+// It returns the operations, a human readable log, and whether the repository had to be skipped.
+func planRepository(repo state.RepositoryEntry) ([]plan.Operation, []string, bool) {
+	var ops []plan.Operation
+	actionLog := []string{}
+
+	// --- Basic Health Checks ---
+	if _, err := os.Stat(repo.Path); os.IsNotExist(err) {
+		actionLog = append(actionLog, fmt.Sprintf("  [SKIP] Path does not exist: %s. Consider removing from state.", repo.Path))
+		return nil, actionLog, true
+	} else if err != nil {
+		actionLog = append(actionLog, fmt.Sprintf("  [SKIP] Error accessing path %s: %v. Manual check required.", repo.Path, err))
+		return nil, actionLog, true
+	}
+
+	if !gitutil.IsGitRepository(repo.Path) {
+		actionLog = append(actionLog, fmt.Sprintf("  [SKIP] Path is not a Git repository: %s. Manual check required.", repo.Path))
+		return nil, actionLog, true
+	}
+
+	// --- URL Check ---
+	liveOriginURL, err := gitutil.GetRemoteOriginURL(repo.Path, verbose)
+	if err != nil {
+		actionLog = append(actionLog, fmt.Sprintf("  [WARN] Failed to get live origin URL: %v. Skipping URL and path checks for this repo.", err))
+		return nil, actionLog, true
+	}
+
+	parsedLiveURL, errLiveParse := parseRepoURL(liveOriginURL)
+	if errLiveParse != nil {
+		actionLog = append(actionLog, fmt.Sprintf("  [WARN] Failed to parse live origin URL '%s': %v. Skipping URL and path checks.", liveOriginURL, errLiveParse))
+		return nil, actionLog, true
+	}
+
+	parsedStoredURL, _ := parseRepoURL(repo.CurrentURL) // Error handled by checking if nil later
+
+	// Compare normalized URLs (e.g. HTTPS vs SSH)
+	liveHTTPS, _ := parsedLiveURL.ToHTTPS()
+	storedHTTPS := ""
+	if parsedStoredURL != nil {
+		storedHTTPS, _ = parsedStoredURL.ToHTTPS()
+	}
+
+	// The URL the repository will have once the plan is applied; the conventional path is based on it.
+	effectiveURL := parsedStoredURL
+	if parsedStoredURL == nil || liveHTTPS != storedHTTPS {
+		actionLog = append(actionLog, fmt.Sprintf("  Remote URL changed: Was '%s', now '%s'", repo.CurrentURL, liveOriginURL))
+		ops = append(ops, plan.Operation{
+			Type:   plan.OpURLUpdate,
+			Repo:   repo.Name,
+			Path:   repo.Path,
+			Source: repo.CurrentURL,
+			Target: liveOriginURL,
+		})
+		effectiveURL = parsedLiveURL
+	}
+
+	// --- Path Reorganization Check ---
+	// A per-repository path override takes the place of the computed conventional path.
+	conventionalPath := expectedRepoPath(repo, effectiveURL)
+	inPlace := samePath(repo.Path, conventionalPath)
+
+	if !inPlace && repo.Pinned {
+		actionLog = append(actionLog, fmt.Sprintf("  Pinned: keeping '%s' (conventional location would be '%s')", repo.Path, conventionalPath))
+	} else if !inPlace {
+		if repo.PathOverride != "" {
+			actionLog = append(actionLog, fmt.Sprintf("  Path mismatch: Actual '%s', Override '%s'", repo.Path, conventionalPath))
+		} else {
+			actionLog = append(actionLog, fmt.Sprintf("  Path mismatch: Actual '%s', Conventional '%s'", repo.Path, conventionalPath))
+		}
+		ops = append(ops, plan.Operation{
+			Type:   plan.OpMove,
+			Repo:   repo.Name,
+			Path:   repo.Path,
+			Source: repo.Path,
+			Target: conventionalPath,
+		})
+		if repo.Locked && !forceReorg {
+			actionLog = append(actionLog, "  [WARN] Repository is locked; the move will be skipped unless --force is used.")
+		}
+		if fsType := networkFilesystemOf(repo.Path, conventionalPath); fsType != "" {
+			if size, _ := fsutil.DirSize(repo.Path); size >= networkMoveWarnSize {
+				actionLog = append(actionLog, fmt.Sprintf("  [WARN] The move involves a network filesystem (%s): %s will be copied over the network, which may take a long time.", fsType, formatSize(size)))
 			}
 		}
+		if gitutil.UsesLFS(repo.Path) && !gitutil.LFSInstalled() {
+			actionLog = append(actionLog, "  [WARN] The repository uses Git LFS, but git-lfs isn't installed; if the move copies it, its LFS content is only compared by size.")
+		}
+		if info, err := os.Lstat(repo.Path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			actionLog = append(actionLog, "  [WARN] The repository's path is a symlink; the link is moved, not the directory it points to.")
+		}
+		if dirty, err := gitutil.IsDirty(repo.Path); (err != nil || dirty) && !forceDirtyReorg {
+			actionLog = append(actionLog, "  [WARN] Working tree has uncommitted changes; the move will be skipped unless --force-dirty is used.")
+		}
+	}
+
+	return ops, actionLog, false
+}
+
+// applyReorgPlan executes the operations of a plan against the loaded state and saves it.
+// Every operation first verifies that its source still matches the current state.
+//
+// Progress is recorded in a journal next to the state file. SIGINT and SIGTERM are deferred
+// while an operation or the final save runs: the operation in flight is finished (or rolled
+// back, if its git checks are interrupted too), the state is saved, and where the run left off
+// is printed before exiting.
+func applyReorgPlan(reorgPlan *plan.Plan) error {
+	// In interactive mode every action must be confirmed; otherwise everything is applied.
+	// Prompts wait for input without holding the guard, so an interrupt stops them right away.
+	var guard sync.Mutex
+	var prompter *actionPrompter
+	if interactiveReorg {
+		prompter = newActionPrompter()
+	}
+	confirm := func(question string) bool {
+		if prompter == nil {
+			return true
+		}
+		guard.Unlock()
+		defer guard.Lock()
+		return prompter.Confirm(question)
+	}
+	confirmMove := func(question string) promptChoice {
+		if prompter == nil {
+			return choiceApply
+		}
+		guard.Unlock()
+		defer guard.Lock()
+		return prompter.ConfirmOrPin(question)
+	}
+
+	// Operations identify repositories by their path at planning time. Index the state
+	// up front so lookups keep working after earlier operations have moved a repository.
+	indexByPlannedPath := make(map[string]int, len(repoState.Repositories))
+	for i, repo := range repoState.Repositories {
+		indexByPlannedPath[repo.Path] = i
+	}
 
-		// Replace the old repoState.Repositories with the updated ones
-		repoState.Repositories = updatedRepositories
+	journal, err := plan.StartJournal(reorgJournalPath(), reorgPlan)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Applying changes...")
+	stateModified := false
+	// Entries of duplicates merged into another entry; they are removed once all operations
+	// are done, so the indices stay valid until then.
+	var merged []string
+	actionsTaken := 0
+	actionsFailed := 0
+	finished := false
+
+	// finish saves the state and prints the summary; it is called with the guard held, either
+	// after the last operation or after an interrupt.
+	finish := func(interrupted bool) error {
+		finished = true
+		if prompter != nil && prompter.Quit() {
+			fmt.Println("\nQuit requested: remaining actions were skipped.")
+		}
+		if interrupted {
+			fmt.Println("\nInterrupted: no further operations were started.")
+		}
 
-		if stateModified && !dryRunReorg {
+		if stateModified {
+			for _, path := range merged {
+				repoState.RemoveRepositoryByPath(path)
+			}
+			repoState.Reindex() // Paths and URLs were changed in place.
 			fmt.Println("\nSaving updated state to file...")
 			if err := repoState.Save(appConfig.StateFilePath); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: Failed to save updated state: %v\n", err)
 				fmt.Println("Please check the state file manually:", appConfig.StateFilePath)
-				return fmt.Errorf("failed to save state after reorganization: %w", err)
+				fmt.Printf("The journal at %s records what was done; the next 'fussy-git reorganize' brings the state in line with it.\n", journal.Path())
+				return &exitError{code: exitState, err: fmt.Errorf("failed to save state after reorganization: %w", err)}
 			}
 			fmt.Println("State saved successfully.")
-		} else if dryRunReorg && actionsProposed > 0 {
-			fmt.Println("\nDRY RUN summary: The above changes would be made.")
-		} else if !stateModified && actionsProposed == 0 {
-			fmt.Println("\nNo changes were necessary. All repositories are organized.")
 		}
 
 		fmt.Printf("\nReorganization summary:\n")
-		if dryRunReorg {
-			fmt.Printf("  Actions proposed: %d\n", actionsProposed)
-		} else {
-			fmt.Printf("  Actions taken:    %d\n", actionsTaken)
+		fmt.Printf("  Actions taken:    %d\n", actionsTaken)
+		if actionsFailed > 0 {
+			fmt.Printf("  Actions failed:   %d\n", actionsFailed)
+		}
+		if interrupted {
+			printJournalPosition(journal)
+		}
+		if err := journal.Remove(); err != nil {
+			fmt.Printf("[WARN] %v\n", err)
+		}
+		if actionsFailed > 0 {
+			return fmt.Errorf("%d reorganization actions failed", actionsFailed)
 		}
 		return nil
-	},
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-signals:
+		case <-done:
+			return
+		}
+		fmt.Fprintln(os.Stderr, "\nInterrupt received: finishing the current operation and saving the state...")
+		guard.Lock()
+		if finished {
+			return // The run completed in the meantime.
+		}
+		// Failing to save the state matters more than the interrupt; finish has reported it.
+		code := exitInterrupted
+		if err := finish(true); ExitCode(err) == exitState {
+			code = exitState
+		}
+		os.Exit(code)
+	}()
+
+	// fail reports an operation that failed and returns its journal status.
+	fail := func(op plan.Operation, name, reason string) string {
+		fmt.Printf("  [FAIL] %s: %s\n", name, reason)
+		reportAction("failed", op.Path, reason)
+		actionsFailed++
+		return plan.StatusFailed
+	}
+
+	// apply executes one operation with the guard held and returns its journal status.
+	apply := func(i int, op plan.Operation) string {
+		idx, found := indexByPlannedPath[op.Path]
+		if !found {
+			return fail(op, op.Type, fmt.Sprintf("no repository is tracked at '%s'. Skipping.", op.Path))
+		}
+		entry := &repoState.Repositories[idx]
+
+		switch op.Type {
+		case plan.OpURLUpdate:
+			if entry.CurrentURL != op.Source {
+				return fail(op, entry.Name, fmt.Sprintf("stored URL is '%s', plan expected '%s'. Skipping.", entry.CurrentURL, op.Source))
+			}
+			if !confirm(fmt.Sprintf("Update stored URL of '%s' from '%s' to '%s'?", entry.Name, op.Source, op.Target)) {
+				fmt.Printf("  [SKIP] %s: URL update declined.\n", entry.Name)
+				return plan.StatusSkipped
+			}
+			applyURLUpdate(entry, op.Target)
+
+		case plan.OpSetURL:
+			if entry.CurrentURL != op.Source {
+				return fail(op, entry.Name, fmt.Sprintf("stored URL is '%s', plan expected '%s'. Skipping.", entry.CurrentURL, op.Source))
+			}
+			if liveURL, err := gitutil.GetRemoteOriginURL(entry.Path, verbose); err != nil || liveURL != op.Source {
+				return fail(op, entry.Name, fmt.Sprintf("origin is '%s', not '%s'. Run 'fussy-git reorganize' first. Skipping.", liveURL, op.Source))
+			}
+			if !confirm(fmt.Sprintf("Set origin of '%s' from '%s' to '%s'?", entry.Name, op.Source, op.Target)) {
+				fmt.Printf("  [SKIP] %s: URL change declined.\n", entry.Name)
+				return plan.StatusSkipped
+			}
+			if err := journal.Mark(i, plan.StatusRunning); err != nil {
+				return fail(op, entry.Name, err.Error())
+			}
+			if _, err := gitutil.SetRemoteOriginURL(entry.Path, op.Target, verbose); err != nil {
+				return fail(op, entry.Name, err.Error())
+			}
+			applyURLUpdate(entry, op.Target)
+
+		case plan.OpMove, plan.OpMoveSuffixed:
+			if entry.Path != op.Source {
+				return fail(op, entry.Name, fmt.Sprintf("repository is at '%s', plan expected '%s'. Skipping.", entry.Path, op.Source))
+			}
+			if entry.Pinned {
+				fmt.Printf("  [SKIP] %s: repository is pinned at '%s' and will not be moved.\n", entry.Name, entry.Path)
+				return plan.StatusSkipped
+			}
+			if entry.Locked && !forceReorg {
+				fmt.Printf("  [SKIP] %s: repository is locked. Unlock it with 'fussy-git unlock', or use --force.\n", entry.Name)
+				return plan.StatusSkipped
+			}
+			if !forceDirtyReorg {
+				if dirty, err := gitutil.IsDirty(entry.Path); err != nil {
+					fmt.Printf("  [SKIP] %s: could not check the working tree for uncommitted changes: %v\n", entry.Name, err)
+					return plan.StatusSkipped
+				} else if dirty {
+					fmt.Printf("  [SKIP] %s: working tree has uncommitted changes. Commit or stash them, or use --force-dirty.\n", entry.Name)
+					return plan.StatusSkipped
+				}
+			}
+			switch confirmMove(fmt.Sprintf("Move '%s' from '%s' to '%s'?", entry.Name, op.Source, op.Target)) {
+			case choiceSkip:
+				fmt.Printf("  [SKIP] %s: Move declined.\n", entry.Name)
+				return plan.StatusSkipped
+			case choicePin:
+				entry.Pinned = true
+				entry.LastModified = time.Now()
+				stateModified = true
+				fmt.Printf("  [PIN] %s: pinned at '%s'; it will not be moved.\n", entry.Name, entry.Path)
+				return plan.StatusSkipped
+			}
+			if err := journal.Mark(i, plan.StatusRunning); err != nil {
+				return fail(op, entry.Name, err.Error())
+			}
+			if err := moveRepository(entry, op.Target); err != nil {
+				return fail(op, entry.Name, err.Error())
+			}
+			if op.Type == plan.OpMoveSuffixed {
+				entry.PathOverride = op.Target
+			}
+
+		case plan.OpMerge:
+			if entry.Path != op.Source {
+				return fail(op, entry.Name, fmt.Sprintf("repository is at '%s', plan expected '%s'. Skipping.", entry.Path, op.Source))
+			}
+			if entry.Locked && !forceReorg {
+				fmt.Printf("  [SKIP] %s: repository is locked. Unlock it with 'fussy-git unlock', or use --force.\n", entry.Name)
+				return plan.StatusSkipped
+			}
+			if !confirm(fmt.Sprintf("Move '%s', a duplicate of '%s', aside and merge its entry into it?", op.Source, op.Target)) {
+				fmt.Printf("  [SKIP] %s: Merge declined.\n", entry.Name)
+				return plan.StatusSkipped
+			}
+			if err := journal.Mark(i, plan.StatusRunning); err != nil {
+				return fail(op, entry.Name, err.Error())
+			}
+			backup, err := mergeDuplicate(entry, op.Target)
+			if err != nil {
+				return fail(op, entry.Name, fmt.Sprintf("%v. Not merged.", err))
+			}
+			if recordMergedDuplicate(entry, op.Target) {
+				merged = append(merged, entry.Path)
+			}
+			fmt.Printf("  %s: merged the duplicate at '%s' into '%s'; it was kept at '%s', delete it once you've checked nothing is missing.\n", entry.Name, op.Source, op.Target, backup)
+		}
+
+		entry.LastModified = time.Now()
+		stateModified = true
+		actionsTaken++
+		reportAction(op.Type, op.Source, op.Target)
+		return plan.StatusDone
+	}
+
+	guard.Lock()
+	defer guard.Unlock()
+	for i, op := range reorgPlan.Operations {
+		if prompter != nil && prompter.Quit() {
+			break
+		}
+		if err := journal.Mark(i, apply(i, op)); err != nil {
+			fmt.Printf("[WARN] %v\n", err)
+		}
+	}
+	return finish(false)
+}
+
+// applyURLUpdate records a new CurrentURL for the entry, and updates the fields derived from
+// it. The caller must call Reindex.
+func applyURLUpdate(entry *state.RepositoryEntry, newURL string) {
+	recordURLUpdate(entry, newURL, os.Stdout)
+}
+
+// recordURLUpdate is applyURLUpdate, describing the changes on out.
+func recordURLUpdate(entry *state.RepositoryEntry, newURL string, out io.Writer) {
+	oldURL := entry.CurrentURL
+	entry.CurrentURL = newURL
+	fmt.Fprintf(out, "  %s: Updated CurrentURL from '%s' to '%s'\n", entry.Name, oldURL, newURL)
+
+	// If OriginalURL was the same as the old CurrentURL, update it too,
+	// assuming the "original" intent was to track this remote.
+	if entry.OriginalURL == oldURL {
+		entry.OriginalURL = newURL
+		fmt.Fprintf(out, "    Also updated OriginalURL to '%s'\n", newURL)
+	}
+
+	// The name, domain and normalized path are derived from the URL and must follow it.
+	if parsed, err := parseRepoURL(newURL); err == nil {
+		oldName := entry.Name
+		applyDerivedFields(entry, parsed)
+		if entry.Name != oldName {
+			fmt.Fprintf(out, "    Repository name updated from '%s' to '%s' based on new URL.\n", oldName, entry.Name)
+		}
+	}
+}
+
+// moveRepository moves the repository directory of entry to targetPath and records the new path.
+// If source and target are on different filesystems, or either is on a network filesystem, the
+// repository is copied and the original removed afterwards. Unless verification is disabled, the moved repository's HEAD and object
+// connectivity, and for a copy its Git LFS content, are checked before the move is committed;
+// on failure the move is rolled back.
+func moveRepository(entry *state.RepositoryEntry, targetPath string) error {
+	// Pre-move safety checks
+	if _, err := os.Stat(targetPath); !os.IsNotExist(err) {
+		return fmt.Errorf("target path '%s' already exists. Cannot move. Manual intervention required", targetPath)
+	}
+	headBefore, err := gitutil.HeadCommit(entry.Path)
+	if err != nil {
+		return fmt.Errorf("failed to record HEAD before moving: %w", err)
+	}
+	gitLayout, err := gitutil.DetectGitDir(entry.Path)
+	if err != nil {
+		return fmt.Errorf("failed to locate the git directory: %w", err)
+	}
+
+	// Ensure parent directory of targetPath exists
+	parentDir := filepath.Dir(targetPath)
+	if err := makeRepoDirs(parentDir); err != nil {
+		return fmt.Errorf("failed to create parent directory '%s' for move: %w", parentDir, err)
+	}
+
+	fmt.Printf("  %s: Moving repository from '%s' to '%s'...\n", entry.Name, entry.Path, targetPath)
+	copied := false
+	copyRepo := func() error {
+		if err := copyVerified(entry.Path, targetPath, func() error { return copyRepository(entry.Path, targetPath) }); err != nil {
+			os.RemoveAll(targetPath)
+			removeEmptyParents(parentDir, appConfig.FussyGitHome)
+			return fmt.Errorf("failed to copy repository: %w", err)
+		}
+		copied = true
+		return nil
+	}
+	if fsType := networkFilesystemOf(entry.Path, targetPath); fsType != "" {
+		// Renames on network filesystems can be emulated by copying and fail halfway; a copy
+		// leaves the original untouched until it has been verified.
+		fmt.Printf("    The move involves a network filesystem (%s); copying instead of renaming.\n", fsType)
+		if err := copyRepo(); err != nil {
+			return err
+		}
+	} else if err := os.Rename(entry.Path, targetPath); err != nil {
+		if !fsutil.IsCrossDevice(err) {
+			return fmt.Errorf("failed to move repository: %w", err)
+		}
+		fmt.Println("    Source and target are on different filesystems; copying instead.")
+		if err := copyRepo(); err != nil {
+			return err
+		}
+	}
+
+	undoGitDir, err := relocateGitDir(gitLayout, entry.Path, targetPath)
+	if err != nil {
+		// Rolled back by the verification below, which can't find the repository.
+		fmt.Printf("    [WARN] %v\n", err)
+	}
+
+	if !noVerifyMoves || err != nil {
+		verifyErr := verifyMovedRepository(targetPath, headBefore)
+		if verifyErr == nil && copied && gitutil.UsesLFS(entry.Path) {
+			verifyErr = verifyLFSCopy(entry.Path, targetPath)
+		}
+		if verifyErr != nil {
+			var rollbackErr error
+			if copied {
+				rollbackErr = os.RemoveAll(targetPath) // The original is still in place.
+			} else {
+				rollbackErr = os.Rename(targetPath, entry.Path)
+			}
+			if rollbackErr == nil {
+				rollbackErr = undoGitDir()
+			}
+			if rollbackErr != nil {
+				return fmt.Errorf("verification after move failed (%v) and rolling back failed: %w. The repository is at '%s'; check it manually",
+					verifyErr, rollbackErr, targetPath)
+			}
+			removeEmptyParents(parentDir, appConfig.FussyGitHome)
+			return fmt.Errorf("verification after move failed, the move was rolled back: %w", verifyErr)
+		}
+	}
+
+	if copied {
+		if err := os.RemoveAll(entry.Path); err != nil {
+			fmt.Printf("    [WARN] Failed to remove the original at '%s' after copying: %v\n", entry.Path, err)
+		}
+	}
+	fmt.Println("    Move successful.")
+	updateAlternatesAfterMove(entry.Path, targetPath)
+	// Don't leave empty <domain>/<owner> directories behind, e.g. after switching layouts.
+	removeEmptyParents(filepath.Dir(entry.Path), appConfig.FussyGitHome)
+	oldPath, oldResolvedPath := entry.Path, entry.ResolvedPath
+	entry.Path = targetPath
+	entry.ResolvedPath = resolvedPath(targetPath)
+	entry.GitDir = separateGitDir(targetPath)
+	relocateGitMaintenance(entry, oldPath, oldResolvedPath)
+	return nil
+}
+
+// relocateGitDir fixes up the git directory of a work tree that was moved from oldPath to
+// targetPath:
+//   - A linked worktree is repaired, so its main repository knows its new location, and so
+//     are the linked worktrees of a repository that has some.
+//   - A separate git directory (--separate-git-dir) next to the work tree (e.g. <name>.git
+//     beside <name>) moves along, keeping its place relative to the work tree. One elsewhere
+//     stays where it is. Either way, the .git file and core.worktree are updated.
+//
+// It returns a function that undoes the relocation, to be called after the work tree was
+// moved back.
+func relocateGitDir(layout *gitutil.GitDirLayout, oldPath, targetPath string) (undo func() error, err error) {
+	undo = func() error { return nil }
+	repairWorktrees := func(path, gitDir string) error {
+		if _, err := os.Stat(filepath.Join(gitDir, "worktrees")); err != nil {
+			return nil // No linked worktrees
+		}
+		return gitutil.RepairWorktree(path)
+	}
+	switch {
+	case layout.Worktree:
+		return func() error { return gitutil.RepairWorktree(oldPath) }, gitutil.RepairWorktree(targetPath)
+	case !layout.Separate:
+		undo = func() error { return repairWorktrees(oldPath, filepath.Join(oldPath, ".git")) }
+		return undo, repairWorktrees(targetPath, filepath.Join(targetPath, ".git"))
+	}
+
+	newGitDir := layout.GitDir
+	insideWorkTree := isWithin(layout.GitDir, oldPath)
+	switch {
+	case insideWorkTree:
+		rel, _ := filepath.Rel(oldPath, layout.GitDir)
+		newGitDir = filepath.Join(targetPath, rel) // Already moved with the work tree.
+	case isWithin(layout.GitDir, filepath.Dir(oldPath)) && isWithin(layout.GitDir, appConfig.FussyGitHome):
+		rel, _ := filepath.Rel(filepath.Dir(oldPath), layout.GitDir)
+		if rel == filepath.Base(oldPath)+".git" {
+			rel = filepath.Base(targetPath) + ".git"
+		}
+		newGitDir = filepath.Join(filepath.Dir(targetPath), rel)
+	}
+
+	if newGitDir != layout.GitDir && !insideWorkTree {
+		if _, err := os.Stat(newGitDir); !os.IsNotExist(err) {
+			return undo, fmt.Errorf("the git directory can't be moved to '%s', which already exists; it stays at '%s'", newGitDir, layout.GitDir)
+		}
+		fmt.Printf("    Moving its git directory from '%s' to '%s'...\n", layout.GitDir, newGitDir)
+		if err := moveDir(layout.GitDir, newGitDir); err != nil {
+			return undo, fmt.Errorf("failed to move the git directory: %w", err)
+		}
+	}
+	undo = func() error {
+		if newGitDir != layout.GitDir && !insideWorkTree {
+			if err := moveDir(newGitDir, layout.GitDir); err != nil {
+				return fmt.Errorf("failed to move the git directory back to '%s': %w", layout.GitDir, err)
+			}
+		}
+		if err := gitutil.SetGitDir(oldPath, layout.GitDir); err != nil {
+			return err
+		}
+		return repairWorktrees(oldPath, layout.GitDir)
+	}
+	if err := gitutil.SetGitDir(targetPath, newGitDir); err != nil {
+		return undo, err
+	}
+	return undo, repairWorktrees(targetPath, newGitDir)
+}
+
+// moveDir renames a directory, copying it if source and target are on different filesystems.
+func moveDir(source, target string) error {
+	err := os.Rename(source, target)
+	if err == nil || !fsutil.IsCrossDevice(err) {
+		return err
+	}
+	if err := copyVerified(source, target, func() error { return fsutil.CopyDir(source, target) }); err != nil {
+		os.RemoveAll(target)
+		return err
+	}
+	return os.RemoveAll(source)
+}
+
+// copyVerified runs copy, which copies the directory source to target. With --verify-copy,
+// every file of source is hashed before the copy and the copy is checked against those
+// checksums; if they don't match, the copy is removed and an error returned, so the caller
+// never removes a source that wasn't copied faithfully.
+func copyVerified(source, target string, copy func() error) error {
+	if !verifyCopyMoves {
+		return copy()
+	}
+	checksums, _, err := fsutil.TreeChecksums(source)
+	if err != nil {
+		return fmt.Errorf("failed to checksum '%s' before copying: %w", source, err)
+	}
+	if err := copy(); err != nil {
+		return err
+	}
+	verified, err := checksums.Verify(target)
+	if err != nil {
+		os.RemoveAll(target)
+		return fmt.Errorf("the copy doesn't match the original, which was left in place: %w", err)
+	}
+	fmt.Printf("    Verified %d files (%s) of the copy against their checksums.\n", len(checksums), formatSize(verified))
+	return nil
+}
+
+// networkMoveWarnSize is the size from which planned moves involving a network filesystem are
+// warned about, since copying them over the network can take a long time.
+const networkMoveWarnSize = 512 << 20
+
+// networkFilesystemOf returns the type of the network filesystem the directory source or the
+// location targetPath is on, or "" if both are local.
+func networkFilesystemOf(source, targetPath string) string {
+	if fsType := fsutil.NetworkFilesystem(source); fsType != "" {
+		return fsType
+	}
+	return fsutil.NetworkFilesystem(targetPath)
+}
+
+// copyRepository copies the repository directory source to targetPath. On a terminal, the
+// progress is shown on a line that is redrawn as the copy advances.
+func copyRepository(source, targetPath string) error {
+	start := time.Now()
+	total, _ := fsutil.DirSize(source)
+	live := isTerminal(os.Stdout)
+	var lastDraw time.Time
+	progress := func(copied int64) {
+		if !live || time.Since(lastDraw) < progressRedrawInterval {
+			return
+		}
+		lastDraw = time.Now()
+		percent := 100
+		if total > 0 {
+			percent = int(min(copied*100/total, 100))
+		}
+		fmt.Printf("\r\x1b[K    Copying: %3d%% (%s of %s)", percent, formatSize(copied), formatSize(total))
+	}
+	err := fsutil.CopyDirProgress(source, targetPath, progress)
+	if live {
+		fmt.Print("\r\x1b[K")
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("    Copied %s in %s.\n", formatSize(total), time.Since(start).Round(time.Second))
+	return nil
+}
+
+// verifyMovedRepository checks that the repository at path still resolves HEAD to headBefore
+// and that all objects reachable from its refs are present.
+func verifyMovedRepository(path, headBefore string) error {
+	headAfter, err := gitutil.HeadCommit(path)
+	if err != nil {
+		return err
+	}
+	if headAfter != headBefore {
+		return fmt.Errorf("HEAD is '%s' after the move, expected '%s'", headAfter, headBefore)
+	}
+	if err := gitutil.CheckConnectivity(path); err != nil {
+		return err
+	}
+	if verbose {
+		fmt.Printf("    Verified HEAD %s and object connectivity at '%s'.\n", headAfter, path)
+	}
+	return nil
+}
+
+// verifyLFSCopy checks that the Git LFS content of a repository copied from source to target
+// arrived intact: the LFS objects must match in number and size, and 'git lfs fsck' must
+// pass if git-lfs is installed. git's connectivity check doesn't cover LFS content.
+func verifyLFSCopy(source, target string) error {
+	sourceSize, sourceCount, err := gitutil.LFSObjectsSize(source)
+	if err != nil {
+		return fmt.Errorf("failed to measure the LFS objects at '%s': %w", source, err)
+	}
+	targetSize, targetCount, err := gitutil.LFSObjectsSize(target)
+	if err != nil {
+		return fmt.Errorf("failed to measure the LFS objects at '%s': %w", target, err)
+	}
+	if sourceSize != targetSize || sourceCount != targetCount {
+		return fmt.Errorf("LFS objects differ after copying: %d objects (%s) at '%s', %d objects (%s) at '%s'",
+			sourceCount, formatSize(sourceSize), source, targetCount, formatSize(targetSize), target)
+	}
+	if gitutil.LFSInstalled() {
+		if err := gitutil.LFSCheck(target); err != nil {
+			return err
+		}
+	}
+	if verbose {
+		fmt.Printf("    Verified %d LFS objects (%s) at '%s'.\n", targetCount, formatSize(targetSize), target)
+	}
+	return nil
 }
 
 func init() {
 	rootCmd.AddCommand(reorganizeCmd)
 	reorganizeCmd.Flags().BoolVar(&dryRunReorg, "dry-run", false, "Show what changes would be made without actually applying them")
+	reorganizeCmd.Flags().StringVarP(&reorgOutput, "output", "o", "text", "Output format for --dry-run: 'text' or 'json' (a plan usable with --apply-plan)")
+	reorganizeCmd.Flags().StringVar(&reorgApplyPlan, "apply-plan", "", "Apply the operations of a plan previously produced with --dry-run --output json")
+	addFilterFlags(reorganizeCmd, &reorgFilter)
+	reorganizeCmd.Flags().BoolVar(&forceDirtyReorg, "force-dirty", false, "Also move repositories with uncommitted changes or untracked files")
+	reorganizeCmd.Flags().BoolVar(&forceReorg, "force", false, "Also move locked repositories")
+	reorganizeCmd.Flags().StringVar(&reorgOnConflict, "on-conflict", conflictSkip, "How to resolve moves to a location that is taken: 'skip', 'swap', 'merge', 'suffix' or 'auto'")
+	reorganizeCmd.Flags().BoolVar(&verifyCopyMoves, "verify-copy", false, "Compare every file of a copied repository with the original by checksum before removing the original")
+	reorganizeCmd.Flags().BoolVar(&noVerifyMoves, "no-verify", false, "Don't verify repository integrity (HEAD and 'git fsck --connectivity-only') after each move")
+	addQuietFlag(reorganizeCmd)
+	reorganizeCmd.Flags().BoolVarP(&interactiveReorg, "interactive", "i", false, "Prompt for confirmation before each URL update or move")
 }
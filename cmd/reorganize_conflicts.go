@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/plan"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Strategies for moves whose target is taken, selected with 'reorganize --on-conflict'.
+const (
+	conflictSkip   = "skip"   // Leave the move as it is; it fails
+	conflictAuto   = "auto"   // Swap, merge or suffix, whichever applies
+	conflictSwap   = "swap"   // Move a misplaced occupant to its own location first
+	conflictMerge  = "merge"  // Merge a duplicate clone of the same repository into the occupant
+	conflictSuffix = "suffix" // Move next to the occupant, to <target>-2, and record that as a path override
+)
+
+// swapSuffix is appended to the path of a repository that is moved aside temporarily to
+// break a cycle of moves, e.g. when two repositories swap places.
+const swapSuffix = ".fussy-git-swap"
+
+// mergedSuffix, followed by a timestamp, is appended to the path of a duplicate clone that was
+// merged into its occupant; the clone is kept there as a backup rather than deleted.
+const mergedSuffix = ".fussy-git-merged-"
+
+// conflictHelp describes the conflict strategies in the help of reorganize.
+const conflictHelp = `If the location a repository should move to is taken, --on-conflict selects how to resolve it:
+  skip    the move fails and is left for manual intervention (the default)
+  swap    if the occupant is a tracked repository that is misplaced itself, it is moved to
+          its own location first (two repositories swapping places go through a temporary
+          location)
+  merge   if the occupant is a clone of the same repository, the misplaced duplicate is
+          moved aside to <path>.fussy-git-merged-<time> and its tags, groups, metadata and
+          notes are added to the occupant's entry. It is only merged if it holds no local
+          work (uncommitted changes, untracked files, stashes or commits that aren't on its
+          remote), has no linked worktrees and no tracked repository borrows its objects.
+          Delete the backup once you've checked nothing is missing, e.g. ignored files
+  suffix  the repository moves next to the occupant instead, to <location>-2 (or -3, ...),
+          which is recorded as its path override
+  auto    swap, merge or suffix, whichever applies first
+With --interactive, each resolution is confirmed like any other action. Moves that wait for
+the occupant to move away are always ordered after it.`
+
+// validConflictStrategy reports whether s is one of the --on-conflict strategies.
+func validConflictStrategy(s string) bool {
+	return slices.Contains([]string{conflictSkip, conflictAuto, conflictSwap, conflictMerge, conflictSuffix}, s)
+}
+
+// resolveMoveConflicts resolves the moves of reorgPlan whose target is taken with the
+// --on-conflict strategy, narrating the resolutions to out, and orders the moves so that no
+// repository moves to a location before its occupant has moved away.
+func resolveMoveConflicts(reorgPlan *plan.Plan, strategy string, out io.Writer) {
+	allows := func(s string) bool { return strategy == s || strategy == conflictAuto }
+	moving := make(map[string]bool)
+	targets := make(map[string]bool)
+	for _, op := range reorgPlan.Operations {
+		if op.Type == plan.OpMove {
+			moving[filepath.Clean(op.Source)] = true
+			targets[filepath.Clean(op.Target)] = true
+		}
+	}
+
+	for i := 0; i < len(reorgPlan.Operations); i++ {
+		op := &reorgPlan.Operations[i]
+		target := filepath.Clean(op.Target)
+		if op.Type != plan.OpMove || moving[target] {
+			continue
+		}
+		if _, err := os.Lstat(target); os.IsNotExist(err) {
+			continue
+		}
+		incoming := repoState.IndexOfPath(op.Path)
+		if incoming < 0 {
+			continue
+		}
+		occupant := trackedAt(target)
+
+		if occupant >= 0 && allows(conflictSwap) {
+			occ := repoState.Repositories[occupant]
+			if own, ok := misplacedTarget(occ); ok {
+				fmt.Fprintf(out, "  [SWAP] %s: '%s' is taken by %s, which is misplaced itself; it moves to '%s' first.\n", op.Repo, target, occ.Name, own)
+				reorgPlan.Add(plan.Operation{Type: plan.OpMove, Repo: occ.Name, Path: occ.Path, Source: occ.Path, Target: own})
+				moving[target] = true
+				targets[filepath.Clean(own)] = true
+				continue
+			}
+		}
+		if allows(conflictMerge) && isDuplicateAt(repoState.Repositories[incoming], occupant, target) {
+			fmt.Fprintf(out, "  [MERGE] %s: '%s' is a clone of the same repository; '%s' will be merged into it and moved aside if it holds no local work.\n", op.Repo, target, op.Source)
+			op.Type = plan.OpMerge
+			continue
+		}
+		if allows(conflictSuffix) {
+			suffixed := freeSuffixedPath(target, targets)
+			fmt.Fprintf(out, "  [SUFFIX] %s: '%s' is taken; it moves to '%s' instead, recorded as its path override.\n", op.Repo, target, suffixed)
+			op.Type, op.Target = plan.OpMoveSuffixed, suffixed
+			targets[suffixed] = true
+			continue
+		}
+		fmt.Fprintf(out, "  [WARN] %s: '%s' is taken; the move will fail. Use --on-conflict to resolve it.\n", op.Repo, target)
+	}
+	orderMoves(reorgPlan)
+}
+
+// trackedAt returns the index of the repository tracked at path, or -1. Unlike IndexOfPath,
+// it doesn't rely on the index, which isn't rebuilt while a plan is applied.
+func trackedAt(path string) int {
+	for i, repo := range repoState.Repositories {
+		if samePath(repo.Path, path) {
+			return i
+		}
+	}
+	return -1
+}
+
+// misplacedTarget returns the location repo should move to if it isn't there, and whether it
+// can be moved there by reorganize: it isn't pinned or locked, and the location is free.
+func misplacedTarget(repo state.RepositoryEntry) (string, bool) {
+	if repo.Pinned || repo.Locked {
+		return "", false
+	}
+	parsed, err := parseRepoURL(repo.CurrentURL)
+	if err != nil {
+		return "", false
+	}
+	own := expectedRepoPath(repo, parsed)
+	if samePath(own, repo.Path) {
+		return "", false
+	}
+	return own, true
+}
+
+// isDuplicateAt reports whether the directory at target, tracked as the repository at index
+// occupant (or not tracked if it is -1), is a clone of the same repository as entry.
+func isDuplicateAt(entry state.RepositoryEntry, occupant int, target string) bool {
+	if occupant >= 0 {
+		return repoState.Repositories[occupant].NormalizedFS == entry.NormalizedFS
+	}
+	if !gitutil.IsGitRepository(target) {
+		return false
+	}
+	originURL, err := gitutil.GetRemoteOriginURL(target, verbose)
+	if err != nil {
+		return false
+	}
+	parsed, err := parseRepoURL(originURL)
+	return err == nil && parsed.GetNormalizedFSPath() == entry.NormalizedFS
+}
+
+// freeSuffixedPath returns the first of <target>-2, <target>-3, ... that doesn't exist and
+// isn't planned as the target of another move.
+func freeSuffixedPath(target string, planned map[string]bool) string {
+	for n := 2; ; n++ {
+		candidate := target + "-" + strconv.Itoa(n)
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) && !planned[candidate] {
+			return candidate
+		}
+	}
+}
+
+// orderMoves orders the operations of reorgPlan so that every move comes after the move of
+// the repository at its target. The other operations, which don't depend on locations, go
+// first. Moves that wait for each other in a cycle are broken up by moving one of the
+// repositories aside temporarily.
+func orderMoves(reorgPlan *plan.Plan) {
+	ordered := make([]plan.Operation, 0, len(reorgPlan.Operations))
+	var pending []plan.Operation
+	for _, op := range reorgPlan.Operations {
+		if op.Type == plan.OpMove || op.Type == plan.OpMoveSuffixed {
+			pending = append(pending, op)
+		} else {
+			ordered = append(ordered, op)
+		}
+	}
+	blocked := func(op plan.Operation) bool {
+		for _, other := range pending {
+			if other.Path != op.Path && samePath(other.Source, op.Target) {
+				return true
+			}
+		}
+		return false
+	}
+	for len(pending) > 0 {
+		progressed := false
+		for i := 0; i < len(pending); i++ {
+			if blocked(pending[i]) {
+				continue
+			}
+			ordered = append(ordered, pending[i])
+			pending = slices.Delete(pending, i, i+1)
+			i--
+			progressed = true
+		}
+		if !progressed {
+			aside := pending[0].Source + swapSuffix
+			ordered = append(ordered, plan.Operation{Type: plan.OpMove, Repo: pending[0].Repo, Path: pending[0].Path, Source: pending[0].Source, Target: aside})
+			pending[0].Source = aside
+		}
+	}
+	reorgPlan.Operations = ordered
+}
+
+// mergeDuplicate moves the clone of entry at source, which duplicates the repository at
+// target, out of the way to a backup next to it, and returns the backup's path. It refuses
+// if the clone holds local work that isn't in a remote, or if moving it would break other
+// repositories: linked worktrees, or tracked repositories borrowing its objects.
+// The backup keeps what can't be checked cheaply, such as ignored files and local tags.
+func mergeDuplicate(entry *state.RepositoryEntry, target string) (string, error) {
+	source := entry.Path
+	if !gitutil.IsGitRepository(target) {
+		return "", fmt.Errorf("'%s' is no longer a Git repository", target)
+	}
+	if borrowers := borrowersOf(source); len(borrowers) > 0 {
+		return "", fmt.Errorf("'%s' shares its objects with %s", source, strings.Join(borrowers, ", "))
+	}
+	if worktrees, err := gitutil.LinkedWorktrees(source); err != nil || len(worktrees) > 0 {
+		return "", fmt.Errorf("'%s' has linked worktrees", source)
+	}
+	if dirty, err := gitutil.IsDirty(source); err != nil || dirty {
+		return "", fmt.Errorf("'%s' has uncommitted changes or untracked files", source)
+	}
+	if stashes, err := gitutil.StashCount(source); err != nil || stashes > 0 {
+		return "", fmt.Errorf("'%s' has stashed changes", source)
+	}
+	if local, err := gitutil.LocalOnlyCommits(source); err != nil || local > 0 {
+		return "", fmt.Errorf("'%s' has commits that aren't on its remote", source)
+	}
+	backup := fmt.Sprintf("%s%s%s", source, mergedSuffix, time.Now().Format("20060102-150405"))
+	if err := os.Rename(source, backup); err != nil {
+		return "", fmt.Errorf("failed to move '%s' aside: %w", source, err)
+	}
+	return backup, nil
+}
+
+// recordMergedDuplicate records in the state that the clone of entry was merged into the
+// repository at target. If that repository is tracked, entry's tags, groups, metadata and
+// notes are added to it and true is returned: entry must be removed from the state, which is
+// left to the caller so indices stay valid. Otherwise entry takes over target.
+func recordMergedDuplicate(entry *state.RepositoryEntry, target string) bool {
+	occupant := trackedAt(target)
+	if occupant < 0 {
+		entry.Path = target
+		entry.ResolvedPath = resolvedPath(target)
+		entry.GitDir = separateGitDir(target)
+		entry.LastModified = time.Now()
+		return false
+	}
+	kept := &repoState.Repositories[occupant]
+	for _, tag := range entry.Tags {
+		if !kept.HasTag(tag) {
+			kept.Tags = append(kept.Tags, tag)
+		}
+	}
+	for _, group := range entry.Groups {
+		if !slices.Contains(kept.Groups, group) {
+			kept.Groups = append(kept.Groups, group)
+		}
+	}
+	for key, value := range entry.Metadata {
+		if _, set := kept.Metadata[key]; !set {
+			if kept.Metadata == nil {
+				kept.Metadata = map[string]string{}
+			}
+			kept.Metadata[key] = value
+		}
+	}
+	if kept.Notes == "" {
+		kept.Notes = entry.Notes
+	}
+	kept.LastModified = time.Now()
+	return true
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/plan"
+	"os"
+	"time"
+)
+
+// reorgJournalPath returns where reorganize records the progress of the plan it applies.
+func reorgJournalPath() string {
+	return appConfig.StateFilePath + ".reorganize-journal"
+}
+
+// reorgRemainingPath returns where reorganize writes the operations an interrupted run didn't
+// get to, as a plan for --apply-plan.
+func reorgRemainingPath() string {
+	return appConfig.StateFilePath + ".reorganize-remaining.json"
+}
+
+// printJournalPosition prints where an interrupted run left off, and writes the operations
+// that were not applied to a plan file, so exactly those can be resumed.
+func printJournalPosition(journal *plan.Journal) {
+	last := -1
+	for i, e := range journal.Operations {
+		if e.Status != plan.StatusPending {
+			last = i
+		}
+	}
+	total := len(journal.Operations)
+	if last < 0 {
+		fmt.Printf("\nThe run was interrupted before the first of %d operations.\n", total)
+	} else {
+		e := journal.Operations[last]
+		fmt.Printf("\nThe run left off after operation %d of %d (%s of %s: %s).\n", last+1, total, e.Type, e.Repo, e.Status)
+	}
+
+	remaining := journal.Remaining(appConfig.FussyGitHome, appConfig.StateFilePath)
+	if len(remaining.Operations) == 0 {
+		return
+	}
+	f, err := os.Create(reorgRemainingPath())
+	if err == nil {
+		err = remaining.Write(f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Printf("[WARN] Failed to write the remaining operations to %s: %v\n", reorgRemainingPath(), err)
+		fmt.Println("Run 'fussy-git reorganize' again to continue.")
+		return
+	}
+	fmt.Printf("%d operations were not applied. Run 'fussy-git reorganize' again to continue, or apply\n", len(remaining.Operations))
+	fmt.Printf("exactly those with 'fussy-git reorganize --apply-plan %s'.\n", reorgRemainingPath())
+}
+
+// recoverReorgJournal handles the journal of a reorganize run that ended without cleaning
+// up, e.g. because it was killed or the machine went down. Operations the journal records as
+// done, or as running when the repository is found at its target, are recorded in the state
+// if the run didn't get to save it.
+func recoverReorgJournal() error {
+	journal, err := plan.LoadJournal(reorgJournalPath())
+	if err != nil || journal == nil {
+		return err
+	}
+	fmt.Printf("A previous reorganize run (started %s) did not finish: %d of %d operations were applied.\n",
+		journal.StartedAt.Format(time.RFC3339), journal.Count(plan.StatusDone), len(journal.Operations))
+
+	changed := false
+	var merged []string
+	for _, e := range journal.Operations {
+		if e.Status != plan.StatusDone && e.Status != plan.StatusRunning {
+			continue
+		}
+		idx := repoState.IndexOfPath(e.Path)
+		if idx < 0 {
+			continue // The repository was moved or removed since; nothing to reconcile.
+		}
+		entry := &repoState.Repositories[idx]
+		fixed := false
+		switch e.Type {
+		case plan.OpURLUpdate:
+			if e.Status == plan.StatusDone && entry.CurrentURL == e.Source {
+				applyURLUpdate(entry, e.Target)
+				fixed = true
+			}
+		case plan.OpSetURL:
+			// The remote may have been set before the run died; the live origin tells.
+			if liveURL, err := gitutil.GetRemoteOriginURL(entry.Path, verbose); err == nil && liveURL == e.Target && entry.CurrentURL == e.Source {
+				applyURLUpdate(entry, e.Target)
+				fixed = true
+			}
+		case plan.OpMerge:
+			if _, err := os.Stat(e.Source); entry.Path == e.Source && os.IsNotExist(err) && gitutil.IsGitRepository(e.Target) {
+				if recordMergedDuplicate(entry, e.Target) {
+					merged = append(merged, entry.Path)
+				}
+				fixed = true
+				fmt.Printf("  [FIXED] %s: recorded its merge into '%s'.\n", entry.Name, e.Target)
+			}
+		case plan.OpMove, plan.OpMoveSuffixed:
+			if entry.Path != e.Source {
+				continue
+			}
+			_, sourceErr := os.Stat(e.Source)
+			switch {
+			case os.IsNotExist(sourceErr) && gitutil.IsGitRepository(e.Target):
+				entry.Path = e.Target
+				entry.ResolvedPath = resolvedPath(e.Target)
+				entry.GitDir = separateGitDir(e.Target)
+				if e.Type == plan.OpMoveSuffixed {
+					entry.PathOverride = e.Target
+				}
+				fixed = true
+				fmt.Printf("  [FIXED] %s: recorded its move from '%s' to '%s'.\n", entry.Name, e.Source, e.Target)
+			case sourceErr == nil && e.Status == plan.StatusRunning:
+				if _, err := os.Stat(e.Target); err == nil {
+					fmt.Printf("  [WARN] %s: the move to '%s' was interrupted while copying; '%s' is still the repository. Remove '%s' before running reorganize again.\n",
+						entry.Name, e.Target, e.Source, e.Target)
+				}
+			}
+		}
+		if fixed {
+			entry.LastModified = time.Now()
+			changed = true
+		}
+	}
+	if changed {
+		for _, path := range merged {
+			repoState.RemoveRepositoryByPath(path)
+		}
+		repoState.Reindex()
+		if err := repoState.Save(appConfig.StateFilePath); err != nil {
+			return fmt.Errorf("failed to save the state recovered from %s: %w", journal.Path(), err)
+		}
+		fmt.Println("The state was updated with the operations of the previous run.")
+	}
+	if err := journal.Remove(); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"path/filepath"
+	"strings"
+)
+
+// errNotTracked is returned by lookupRepository when no repository matches the reference.
+var errNotTracked = errors.New("no tracked repository matches")
+
+// lookupRepository resolves a user supplied repository reference to an index into repoState.Repositories.
+// The reference may be, in order of precedence:
+//   - a filesystem path to the repository (absolute or relative to the working directory)
+//   - its normalized path, e.g. github.com/spf13/cobra
+//   - its Go module path, e.g. golang.org/x/tools, or an import path below it
+//   - a clone URL in any supported form (SSH and HTTPS variants are treated as equal),
+//     including host shortcuts such as gh:spf13/cobra
+//   - its short name, e.g. cobra (must be unambiguous)
+func lookupRepository(ref string) (int, error) {
+	if ref == "" {
+		return -1, fmt.Errorf("repository reference is empty")
+	}
+
+	if absPath, err := filepath.Abs(ref); err == nil {
+		if i := repoState.IndexOfPath(absPath); i >= 0 {
+			return i, nil
+		}
+	}
+
+	if i := repoState.IndexOfNormalizedPath(strings.TrimSuffix(ref, "/")); i >= 0 {
+		return i, nil
+	}
+
+	if idx := lookupByModulePath(ref); idx >= 0 {
+		return idx, nil
+	}
+
+	for i, repo := range repoState.Repositories {
+		if repo.CurrentURL == ref || repo.OriginalURL == ref {
+			return i, nil
+		}
+	}
+
+	if parsedRef, err := parseRepoURL(gitutil.SanitizeURL(expandShortcut(ref))); err == nil && parsedRef.Scheme != "file" {
+		refHTTPS, _ := parsedRef.ToHTTPS()
+		for i, repo := range repoState.Repositories {
+			if parsedRepo, err := parseRepoURL(repo.CurrentURL); err == nil && refHTTPS != "" {
+				if repoHTTPS, _ := parsedRepo.ToHTTPS(); strings.TrimSuffix(repoHTTPS, ".git") == strings.TrimSuffix(refHTTPS, ".git") {
+					return i, nil
+				}
+			}
+		}
+	}
+
+	var matches []int
+	for i, repo := range repoState.Repositories {
+		if repo.Name == ref {
+			matches = append(matches, i)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return -1, fmt.Errorf("%w '%s'. Use 'fussy-git list' to see tracked repositories", errNotTracked, ref)
+	case 1:
+		return matches[0], nil
+	default:
+		var candidates []string
+		for _, i := range matches {
+			candidates = append(candidates, repoState.Repositories[i].Path)
+		}
+		return -1, fmt.Errorf("'%s' matches %d repositories, use a path or URL instead:\n  %s", ref, len(matches), strings.Join(candidates, "\n  "))
+	}
+}
+
+// lookupByModulePath returns the index of the repository whose recorded Go module path is
+// importPath or the longest prefix of it (so packages inside a module resolve to its repository),
+// or -1 if there is none.
+func lookupByModulePath(importPath string) int {
+	importPath = strings.TrimSuffix(importPath, "/")
+	best := -1
+	for i, repo := range repoState.Repositories {
+		if repo.ModulePath == "" || (importPath != repo.ModulePath && !strings.HasPrefix(importPath, repo.ModulePath+"/")) {
+			continue
+		}
+		if best < 0 || len(repo.ModulePath) > len(repoState.Repositories[best].ModulePath) {
+			best = i
+		}
+	}
+	return best
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	ownerRewrite      string
+	domainRewrite     string
+	dryRunRewrite     bool
+	forceDirtyRewrite bool
+	forceRewrite      bool
+)
+
+// ownerRewriteStep is the planned change to one repository affected by an owner rename.
+type ownerRewriteStep struct {
+	index   int    // Index of the repository in repoState.Repositories
+	oldURL  string // Stored (and live) origin URL before the rename
+	newURL  string
+	oldPath string
+	newPath string // Empty if the repository stays where it is
+}
+
+// rewriteCmd represents the rewrite command
+var rewriteCmd = &cobra.Command{
+	Use:   "rewrite --owner <old>=<new>",
+	Short: "Rewrites the remotes and locations of all repositories of a renamed owner.",
+	Long: `Handles the rename of a user, organization or group (e.g. 'oldcorp' becoming 'newcorp')
+for every tracked repository it owns, in one transaction:
+
+1. The 'origin' remote is changed to the new owner, keeping the protocol and host as they were
+   (git@github.com:oldcorp/tool.git becomes git@github.com:newcorp/tool.git).
+2. The stored URLs and name are updated in fussy-git's state.
+3. The repository is moved to its new conventional location. Pinned repositories and
+   repositories with a path override stay where they are.
+
+Everything is checked before anything is changed: all affected repositories must exist, their
+live 'origin' must match the stored URL (run 'fussy-git reorganize' first otherwise), and no new
+location may already be taken. Repositories with uncommitted changes are refused unless
+--force-dirty is given, and locked repositories (see 'fussy-git lock') that would be moved
+are refused unless --force is given. If any step fails halfway, all repositories that were already changed
+are restored, so either all or none of them are rewritten.
+
+Owners are compared case-insensitively and may span several path segments, e.g. a GitLab
+subgroup ('--owner group/old=group/new'). Use --domain to limit the rename to one host.
+
+Examples:
+  fussy-git rewrite --owner oldcorp=newcorp --dry-run
+  fussy-git rewrite --owner oldcorp=newcorp --domain github.com`,
+	Annotations: writesTree,
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		oldOwner, newOwner, found := strings.Cut(ownerRewrite, "=")
+		oldOwner, newOwner = strings.Trim(oldOwner, "/ "), strings.Trim(newOwner, "/ ")
+		if !found || oldOwner == "" || newOwner == "" {
+			return fmt.Errorf("--owner must have the form <old>=<new>, e.g. --owner oldcorp=newcorp")
+		}
+		if oldOwner == newOwner {
+			return fmt.Errorf("old and new owner are both '%s'; nothing to rewrite", oldOwner)
+		}
+
+		steps, problems := planOwnerRewrite(oldOwner, newOwner)
+		if len(steps) == 0 && len(problems) == 0 {
+			fmt.Printf("No tracked repositories are owned by '%s'.\n", oldOwner)
+			return nil
+		}
+
+		for _, step := range steps {
+			entry := repoState.Repositories[step.index]
+			fmt.Printf("%s:\n", entry.Name)
+			fmt.Printf("  URL:  %s -> %s\n", step.oldURL, step.newURL)
+			if step.newPath != "" {
+				fmt.Printf("  Path: %s -> %s\n", step.oldPath, step.newPath)
+			} else {
+				fmt.Printf("  Path: %s (unchanged)\n", step.oldPath)
+			}
+		}
+		if len(problems) > 0 {
+			fmt.Println()
+			for _, problem := range problems {
+				fmt.Printf("[FAIL] %s\n", problem)
+			}
+			return fmt.Errorf("%d of the affected repositories can't be rewritten; nothing was changed", len(problems))
+		}
+
+		if dryRunRewrite {
+			fmt.Printf("\nDRY RUN: %d repositories would be rewritten from '%s' to '%s'.\n", len(steps), oldOwner, newOwner)
+			return nil
+		}
+
+		fmt.Println()
+		if err := applyOwnerRewrite(steps); err != nil {
+			return err
+		}
+		fmt.Printf("\nRewrote %d repositories from '%s' to '%s'.\n", len(steps), oldOwner, newOwner)
+		return nil
+	},
+}
+
+// planOwnerRewrite finds the repositories owned by oldOwner and works out their new URLs and
+// locations. It returns a description of every reason the rewrite can't be applied as a whole.
+func planOwnerRewrite(oldOwner, newOwner string) ([]ownerRewriteStep, []string) {
+	var steps []ownerRewriteStep
+	var problems []string
+	// Locations that will be occupied once the rewrite is done, to catch two repositories
+	// being moved to the same place.
+	claimed := map[string]string{}
+
+	for i, repo := range repoState.Repositories {
+		parsed, err := parseRepoURL(repo.CurrentURL)
+		if err != nil || (domainRewrite != "" && !strings.EqualFold(parsed.Domain, domainRewrite)) {
+			continue
+		}
+		newURL, owned := gitutil.ReplaceOwner(repo.CurrentURL, oldOwner, newOwner)
+		if !owned {
+			continue
+		}
+		parsedNew, err := parseRepoURL(newURL)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: can't parse rewritten URL '%s': %v", repo.Path, newURL, err))
+			continue
+		}
+		step := ownerRewriteStep{index: i, oldURL: repo.CurrentURL, newURL: newURL, oldPath: repo.Path}
+		if target := expectedRepoPath(repo, parsedNew); !repo.Pinned && !samePath(target, repo.Path) {
+			step.newPath = target
+		}
+		steps = append(steps, step)
+
+		if !gitutil.IsGitRepository(repo.Path) {
+			problems = append(problems, fmt.Sprintf("%s: not found or not a Git repository", repo.Path))
+			continue
+		}
+		liveURL, err := gitutil.GetRemoteOriginURL(repo.Path, verbose)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", repo.Path, err))
+			continue
+		}
+		if liveURL != repo.CurrentURL {
+			problems = append(problems, fmt.Sprintf("%s: origin is '%s' but the stored URL is '%s'; run 'fussy-git reorganize' first", repo.Path, liveURL, repo.CurrentURL))
+			continue
+		}
+		if step.newPath == "" {
+			continue
+		}
+		if other, taken := claimed[step.newPath]; taken {
+			problems = append(problems, fmt.Sprintf("%s: would be moved to '%s', like %s", repo.Path, step.newPath, other))
+		} else if _, err := os.Stat(step.newPath); !os.IsNotExist(err) {
+			problems = append(problems, fmt.Sprintf("%s: new location '%s' already exists", repo.Path, step.newPath))
+		}
+		claimed[step.newPath] = repo.Path
+		if repo.Locked && !forceRewrite {
+			problems = append(problems, fmt.Sprintf("%s: repository is locked; unlock it or use --force", repo.Path))
+		}
+		if !forceDirtyRewrite {
+			if dirty, err := gitutil.IsDirty(repo.Path); err != nil || dirty {
+				problems = append(problems, fmt.Sprintf("%s: working tree has uncommitted changes; commit or stash them, or use --force-dirty", repo.Path))
+			}
+		}
+	}
+	return steps, problems
+}
+
+// applyOwnerRewrite updates the origin remote, state entry and location of every repository
+// in steps and saves the state. If any step fails, the changes made so far are undone in
+// reverse order and the state is left as it was.
+func applyOwnerRewrite(steps []ownerRewriteStep) error {
+	original := append([]state.RepositoryEntry(nil), repoState.Repositories...)
+	var undo []func() error
+
+	rollback := func(cause error) error {
+		fmt.Println("Rolling back...")
+		var failed []string
+		for i := len(undo) - 1; i >= 0; i-- {
+			if err := undo[i](); err != nil {
+				failed = append(failed, err.Error())
+			}
+		}
+		repoState.Repositories = original
+		repoState.Reindex()
+		if len(failed) > 0 {
+			return fmt.Errorf("%w; rolling back failed as well, check these repositories manually:\n  %s", cause, strings.Join(failed, "\n  "))
+		}
+		return fmt.Errorf("%w; all changes were rolled back", cause)
+	}
+
+	for _, step := range steps {
+		entry := &repoState.Repositories[step.index]
+		if _, err := gitutil.SetRemoteOriginURL(entry.Path, step.newURL, verbose); err != nil {
+			return rollback(err)
+		}
+		undo = append(undo, func() error {
+			_, err := gitutil.SetRemoteOriginURL(entry.Path, step.oldURL, verbose)
+			return err
+		})
+		applyURLUpdate(entry, step.newURL)
+		entry.LastModified = time.Now()
+
+		if step.newPath == "" {
+			continue
+		}
+		if err := moveRepository(entry, step.newPath); err != nil {
+			return rollback(fmt.Errorf("%s: %w", step.oldPath, err))
+		}
+		undo = append(undo, func() error {
+			return moveRepository(entry, step.oldPath)
+		})
+	}
+
+	repoState.Reindex() // Paths and URLs were changed in place.
+	if err := repoState.Save(appConfig.StateFilePath); err != nil {
+		return rollback(fmt.Errorf("failed to save state: %w", err))
+	}
+	return nil
+}
+
+func init() {
+	rewriteCmd.Flags().StringVar(&ownerRewrite, "owner", "", "Owner rename in the form <old>=<new>")
+	rewriteCmd.Flags().StringVar(&domainRewrite, "domain", "", "Only rewrite repositories hosted on this domain")
+	rewriteCmd.Flags().BoolVar(&dryRunRewrite, "dry-run", false, "Show what would be changed without changing anything")
+	rewriteCmd.Flags().BoolVar(&forceDirtyRewrite, "force-dirty", false, "Also move repositories with uncommitted changes or untracked files")
+	rewriteCmd.Flags().BoolVar(&forceRewrite, "force", false, "Also move locked repositories")
+	rewriteCmd.MarkFlagRequired("owner")
+}
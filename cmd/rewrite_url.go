@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	dryRunRewriteURL bool
+	yesRewriteURL    bool
+	rewriteURLFilter filter.Filter
+)
+
+// urlSubstitution is a parsed sed-style substitution, e.g. s#git.old.corp#git.new.corp#g.
+type urlSubstitution struct {
+	re          *regexp.Regexp
+	replacement string // In regexp.Expand syntax
+	global      bool
+}
+
+// plannedURLRewrite is a single URL change proposed by rewrite-url.
+type plannedURLRewrite struct {
+	index  int // Index of the repository in repoState.Repositories
+	oldURL string
+	newURL string
+}
+
+// rewriteURLCmd represents the rewrite-url command
+var rewriteURLCmd = &cobra.Command{
+	Use:   "rewrite-url <s/pattern/replacement/[flags]>",
+	Short: "Applies a sed-style substitution to the remote URL of every repository.",
+	Long: `Applies a sed-style substitution to the stored URL of every tracked repository, e.g. after a
+Git server moved to a new hostname. The changes are shown as a diff and, once confirmed, applied
+to both the 'origin' remote ('git remote set-url') and fussy-git's state.
+
+The expression has the form s<d>pattern<d>replacement<d>[flags], where <d> is any delimiter
+character, so URLs full of slashes can use e.g. '#'. The pattern is a Go regular expression
+(https://pkg.go.dev/regexp/syntax). In the replacement, & stands for the whole match and \1-\9
+for capture groups. Flags: 'g' replaces every match instead of only the first, 'i' ignores case.
+
+Repositories whose live 'origin' differs from the stored URL are skipped; run
+'fussy-git reorganize' first to record it. Repositories are not moved; if the new URLs map to
+different locations, run 'fussy-git reorganize' afterwards.
+
+Use --domain, --owner, --tag and --path-prefix to limit the rewrite to a subset of repositories.
+
+Examples:
+  fussy-git rewrite-url 's#git.old.corp#git.new.corp#' --dry-run
+  fussy-git rewrite-url 's#^https://github.com/(.*)$#git@github.com:\1#' --owner work-org
+  fussy-git rewrite-url 's/gitlab.example.com/gitlab.example.org/' --yes`,
+	Annotations: mutates,
+	Args:        cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sub, err := parseSubstitution(args[0])
+		if err != nil {
+			return err
+		}
+
+		var rewrites []plannedURLRewrite
+		var invalid []string
+		for i, repo := range repoState.Repositories {
+			if !rewriteURLFilter.Match(repo) {
+				continue
+			}
+			newURL := sub.Apply(repo.CurrentURL)
+			if newURL == repo.CurrentURL {
+				continue
+			}
+			if _, err := parseRepoURL(newURL); err != nil {
+				invalid = append(invalid, fmt.Sprintf("%s: rewritten URL '%s' is invalid: %v", repo.Path, newURL, err))
+				continue
+			}
+			rewrites = append(rewrites, plannedURLRewrite{index: i, oldURL: repo.CurrentURL, newURL: newURL})
+		}
+
+		for _, problem := range invalid {
+			fmt.Printf("[FAIL] %s\n", problem)
+		}
+		if len(invalid) > 0 {
+			return fmt.Errorf("the expression produces %d invalid URLs; nothing was changed", len(invalid))
+		}
+		if len(rewrites) == 0 {
+			fmt.Println("The expression doesn't change any repository URL.")
+			return nil
+		}
+
+		for _, rw := range rewrites {
+			entry := repoState.Repositories[rw.index]
+			fmt.Printf("%s (%s)\n", entry.Name, entry.Path)
+			fmt.Printf("  - %s\n", rw.oldURL)
+			fmt.Printf("  + %s\n", rw.newURL)
+		}
+		fmt.Println()
+
+		if dryRunRewriteURL {
+			fmt.Printf("DRY RUN: %d repository URLs would be rewritten.\n", len(rewrites))
+			return nil
+		}
+		if !yesRewriteURL && !newActionPrompter().Confirm(fmt.Sprintf("Rewrite %d repository URLs?", len(rewrites))) {
+			fmt.Println("Aborted; nothing was changed.")
+			return nil
+		}
+
+		return applyURLRewrites(rewrites)
+	},
+}
+
+// applyURLRewrites sets the new origin URL of every repository and records it in the state.
+func applyURLRewrites(rewrites []plannedURLRewrite) error {
+	rewritten, failed, needMove := 0, 0, 0
+	for _, rw := range rewrites {
+		entry := &repoState.Repositories[rw.index]
+		liveURL, err := gitutil.GetRemoteOriginURL(entry.Path, verbose)
+		if err != nil {
+			fmt.Printf("  [FAIL] %s: %v\n", entry.Path, err)
+			failed++
+			continue
+		}
+		if liveURL != rw.oldURL {
+			fmt.Printf("  [SKIP] %s: origin is '%s', not the stored '%s'. Run 'fussy-git reorganize' first.\n", entry.Path, liveURL, rw.oldURL)
+			continue
+		}
+		if _, err := gitutil.SetRemoteOriginURL(entry.Path, rw.newURL, verbose); err != nil {
+			fmt.Printf("  [FAIL] %s: %v\n", entry.Path, err)
+			failed++
+			continue
+		}
+		applyURLUpdate(entry, rw.newURL)
+		entry.LastModified = time.Now()
+		rewritten++
+
+		if parsed, err := parseRepoURL(rw.newURL); err == nil && !entry.Pinned && !samePath(expectedRepoPath(*entry, parsed), entry.Path) {
+			needMove++
+		}
+	}
+
+	if rewritten > 0 {
+		repoState.Reindex() // URLs were changed in place.
+		if err := repoState.Save(appConfig.StateFilePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to save updated state: %v\n", err)
+			return fmt.Errorf("origin remotes were updated, but saving the state failed: %w. Run 'fussy-git reorganize' to record the new URLs", err)
+		}
+	}
+
+	fmt.Printf("\nRewrite summary:\n")
+	fmt.Printf("  Rewritten: %d\n", rewritten)
+	fmt.Printf("  Skipped:   %d\n", len(rewrites)-rewritten-failed)
+	fmt.Printf("  Failed:    %d\n", failed)
+	if needMove > 0 {
+		fmt.Printf("\n%d repositories are no longer at the location matching their URL. Run 'fussy-git reorganize' to move them.\n", needMove)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d repository URLs could not be rewritten", failed)
+	}
+	return nil
+}
+
+// parseSubstitution parses a sed-style substitution expression: s<d>pattern<d>replacement<d>[flags].
+// The delimiter can be escaped with a backslash inside the pattern and replacement.
+func parseSubstitution(expr string) (*urlSubstitution, error) {
+	usage := fmt.Errorf("invalid expression '%s': expected s<d>pattern<d>replacement<d>[flags], e.g. 's#old.host#new.host#'", expr)
+	if len(expr) < 4 || expr[0] != 's' {
+		return nil, usage
+	}
+	delim := expr[1]
+	if delim == '\\' || delim == '\n' || (delim >= 'a' && delim <= 'z') || (delim >= 'A' && delim <= 'Z') || (delim >= '0' && delim <= '9') {
+		return nil, fmt.Errorf("invalid expression '%s': the delimiter must not be a letter, digit or backslash", expr)
+	}
+
+	// Split on unescaped delimiters; an escaped delimiter stands for itself.
+	var parts []string
+	var current strings.Builder
+	for i := 2; i < len(expr); i++ {
+		switch {
+		case expr[i] == '\\' && i+1 < len(expr) && expr[i+1] == delim:
+			current.WriteByte(delim)
+			i++
+		case expr[i] == '\\' && i+1 < len(expr):
+			current.WriteString(expr[i : i+2])
+			i++
+		case expr[i] == delim:
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(expr[i])
+		}
+	}
+	parts = append(parts, current.String())
+	if len(parts) != 3 {
+		return nil, usage
+	}
+	pattern, replacement, flags := parts[0], parts[1], parts[2]
+
+	sub := &urlSubstitution{}
+	for _, flag := range flags {
+		switch flag {
+		case 'g':
+			sub.global = true
+		case 'i':
+			pattern = "(?i)" + pattern
+		default:
+			return nil, fmt.Errorf("invalid expression '%s': unknown flag '%c' (supported: g, i)", expr, flag)
+		}
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern in '%s': %w", expr, err)
+	}
+	sub.re = re
+	sub.replacement = sedReplacementToExpand(replacement)
+	return sub, nil
+}
+
+// sedReplacementToExpand converts a sed replacement (& and \1-\9) to regexp.Expand syntax.
+func sedReplacementToExpand(replacement string) string {
+	var b strings.Builder
+	for i := 0; i < len(replacement); i++ {
+		c := replacement[i]
+		switch {
+		case c == '\\' && i+1 < len(replacement):
+			next := replacement[i+1]
+			i++
+			if next >= '0' && next <= '9' {
+				b.WriteString("${" + string(next) + "}")
+			} else if next == '$' {
+				b.WriteString("$$")
+			} else {
+				b.WriteByte(next) // \& and \\ stand for the character itself
+			}
+		case c == '&':
+			b.WriteString("${0}")
+		case c == '$':
+			b.WriteString("$$")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// Apply returns str with the substitution applied.
+func (s *urlSubstitution) Apply(str string) string {
+	if s.global {
+		return s.re.ReplaceAllString(str, s.replacement)
+	}
+	loc := s.re.FindStringSubmatchIndex(str)
+	if loc == nil {
+		return str
+	}
+	return str[:loc[0]] + string(s.re.ExpandString(nil, s.replacement, str, loc)) + str[loc[1]:]
+}
+
+func init() {
+	rewriteURLCmd.Flags().BoolVar(&dryRunRewriteURL, "dry-run", false, "Show the changes without applying them")
+	rewriteURLCmd.Flags().BoolVarP(&yesRewriteURL, "yes", "y", false, "Apply the changes without asking for confirmation")
+	addFilterFlags(rewriteURLCmd, &rewriteURLFilter)
+}
@@ -15,6 +15,8 @@ import (
 
 var (
 	cfgFile    string
+	homeFlag   string // --home, overriding FUSSY_GIT_HOME for this invocation
+	stateFlag  string // --state-file, overriding the state file for this invocation
 	verbose    bool
 	appConfig  *config.Config
 	repoState  *state.RepoState
@@ -33,11 +35,23 @@ var rootCmd = &cobra.Command{
 by cloning them into a structured directory based on their origin URL.
 It can also act as a proxy to the real 'git' command for unsupported operations.
 
-Default FUSSY_GIT_HOME is ~/git.`,
+Default FUSSY_GIT_HOME is ~/git. To run a single command against another tree, such as
+a mounted backup, pass --home and --state-file (combine with --read-only to be safe):
+
+  fussy-git --home /mnt/backup/git --state-file /mnt/backup/.fussy-git/repos.json --read-only list
+
+Path settings in the config file may use environment variables and ~, e.g.
+fussy_git_home: $WORKDIR/git.
+
+` + passthroughHelp + `
+
+` + readOnlyHelp + `
+
+` + exitCodesHelp,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		// Initialize config
 		var err error
-		appConfig, err = config.LoadConfig(cfgFile)
+		appConfig, err = loadConfig()
 		if err != nil {
 			return fmt.Errorf("failed to load configuration: %w", err)
 		}
@@ -45,16 +59,27 @@ Default FUSSY_GIT_HOME is ~/git.`,
 			fmt.Printf("Using FUSSY_GIT_HOME: %s\n", appConfig.FussyGitHome)
 			fmt.Printf("Using state file: %s\n", appConfig.StateFilePath)
 		}
+		if err := checkRunningAsRoot(cmd); err != nil {
+			return err
+		}
+		if err := checkReadOnly(cmd); err != nil {
+			return err
+		}
 
 		// Initialize state
-		repoState, err = state.LoadState(appConfig.StateFilePath)
+		repoState, err = loadRepoState()
 		if err != nil {
-			return fmt.Errorf("failed to load repository state: %w", err)
+			return &exitError{code: exitState, err: fmt.Errorf("failed to load repository state: %w", err)}
+		}
+		if appConfig.ReadOnly {
+			if err := repoState.SetReadOnly(); err != nil {
+				return err
+			}
 		}
 		if verbose {
 			fmt.Printf("Loaded %d repositories from state file: %s\n", len(repoState.Repositories), appConfig.StateFilePath)
 		}
-		return nil
+		return checkGroupFilter(cmd)
 	},
 	// This is the core of the passthrough logic.
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -97,6 +122,8 @@ func Execute(appVersion, appCommit, appDate, appBuiltBy string) error {
 	} else {
 		rootCmd.Version = fmt.Sprintf("%s (commit: %s, built: %s, by: %s)", AppVersion, AppCommit, AppDate, AppBuiltBy)
 	}
+	addCompletionInstall()
+	markUsageErrors(rootCmd)
 	return rootCmd.Execute()
 }
 
@@ -104,20 +131,81 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", fmt.Sprintf("config file (default is $HOME/%s/%s.yaml)", config.ConfigDirNameForHelp, config.DefaultConfigNameForHelp))
+	rootCmd.PersistentFlags().StringVar(&homeFlag, "home", "", "FUSSY_GIT_HOME for this invocation, overriding the environment and config file")
+	rootCmd.PersistentFlags().StringVar(&stateFlag, "state-file", "", "State file for this invocation, overriding the environment and config file")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().BoolVar(&readOnlyFlag, "read-only", false, "Refuse all operations that change repositories or the state (see 'read_only')")
+	rootCmd.PersistentFlags().BoolVar(&allowRoot, "allow-root", false, "Allow commands that create or move repositories to run as root in another user's FUSSY_GIT_HOME")
 
 	// Add known fussy-git commands here
 	rootCmd.AddCommand(cloneCmd)
+	rootCmd.AddCommand(getCmd)
+	rootCmd.AddCommand(whichCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(doctorCmd)
 	rootCmd.AddCommand(reorganizeCmd)
+	rootCmd.AddCommand(pinCmd)
+	rootCmd.AddCommand(unpinCmd)
+	rootCmd.AddCommand(lockCmd)
+	rootCmd.AddCommand(unlockCmd)
+	rootCmd.AddCommand(pathOverrideCmd)
+	rootCmd.AddCommand(importGopathCmd)
+	rootCmd.AddCommand(importDirCmd)
+	rootCmd.AddCommand(webCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(maintenanceCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(outdatedCmd)
+	rootCmd.AddCommand(topCmd)
+	rootCmd.AddCommand(rewriteCmd)
+	rootCmd.AddCommand(rewriteURLCmd)
+	rootCmd.AddCommand(docsCmd)
+	rootCmd.AddCommand(envCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(recloneCmd)
+	rootCmd.AddCommand(unshallowCmd)
+	rootCmd.AddCommand(densifyCmd)
+	rootCmd.AddCommand(installHooksCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(authCmd)
+	rootCmd.AddCommand(stateCmd)
+	rootCmd.AddCommand(metaCmd)
+	rootCmd.AddCommand(infoCmd)
+	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(groupCmd)
+	rootCmd.AddCommand(manifestCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(branchCmd)
+	rootCmd.AddCommand(prsCmd)
+	rootCmd.AddCommand(fetchCmd)
+	rootCmd.AddCommand(mirrorPushCmd)
+	rootCmd.AddCommand(licensesCmd)
+	rootCmd.AddCommand(cleanupAdvisorCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(installURLHandlerCmd)
+	rootCmd.AddCommand(handleURLCmd)
+	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(migrateHostCmd)
+	rootCmd.AddCommand(adoptConfigCmd)
+	rootCmd.AddCommand(activityCmd)
+	rootCmd.AddCommand(identityCmd)
+	rootCmd.AddCommand(signingCmd)
 	// Add other fussy-git specific commands here
 
 	// TraversalChildren enables passthrough for commands not explicitly defined.
 	rootCmd.TraverseChildren = true
 }
 
+// loadConfig loads the configuration from the config file given with --config, applying the
+// --home and --state-file overrides.
+func loadConfig() (*config.Config, error) {
+	return config.LoadConfig(cfgFile, config.Overrides{FussyGitHome: homeFlag, StateFilePath: stateFlag})
+}
+
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
 	// This function is called by cobra.OnInitialize()
@@ -174,15 +262,14 @@ func executeGitPassthrough(command string, args ...string) error {
 	var repoDir string
 	// Check if CWD is within a known fussy-git managed repository
 	if repoState != nil { // repoState might not be initialized if PersistentPreRunE failed
-		for _, repo := range repoState.Repositories {
-			// Check if cwd is repo.Path or a subdirectory of repo.Path
-			rel, err := filepath.Rel(repo.Path, cwd)
-			if err == nil && !strings.HasPrefix(rel, "..") {
-				repoDir = repo.Path
-				if verbose {
-					fmt.Printf("Executing git command in context of known fussy-git repo: %s (CWD: %s)\n", repoDir, cwd)
-				}
-				break
+		// The innermost repository whose path is cwd or a parent of it.
+		if i := repoState.IndexContaining(cwd); i >= 0 {
+			repoDir = repoState.Repositories[i].Path
+			if verbose {
+				fmt.Printf("Executing git command in context of known fussy-git repo: %s (CWD: %s)\n", repoDir, cwd)
+			}
+			if err := checkPassthrough(repoDir, command, args); err != nil {
+				return err
 			}
 		}
 	}
@@ -229,7 +316,7 @@ func executeGitPassthrough(command string, args ...string) error {
 }
 
 // findGitRepoRoot tries to find the root of a git repository by looking for a .git directory
-// starting from 'startPath' and going upwards.
+// (or the .git file of a separate git directory or worktree) starting from 'startPath' and going upwards.
 func findGitRepoRoot(startPath string) (string, error) {
 	currentPath, err := filepath.Abs(startPath)
 	if err != nil {
@@ -239,8 +326,8 @@ func findGitRepoRoot(startPath string) (string, error) {
 	for {
 		gitDir := filepath.Join(currentPath, ".git")
 		stat, err := os.Stat(gitDir)
-		if err == nil && stat.IsDir() {
-			return currentPath, nil // Found .git directory
+		if err == nil && (stat.IsDir() || stat.Mode().IsRegular()) {
+			return currentPath, nil // Found .git directory, or the .git file of a separate git dir or worktree
 		}
 		// Stop if we encounter an error other than "not exist" or if we reach root.
 		if err != nil && !os.IsNotExist(err) {
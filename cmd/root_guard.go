@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/fsutil"
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// annotationWritesTree marks commands that create, move or rewrite files below
+// FUSSY_GIT_HOME, which are refused when run as root in another user's tree.
+const annotationWritesTree = "fussy-git/writes-tree"
+
+// writesTree is the annotation set on commands that create files below FUSSY_GIT_HOME.
+var writesTree = map[string]string{annotationWritesTree: "true"}
+
+var allowRoot bool
+
+// checkRunningAsRoot refuses to run a command marked with annotationWritesTree as root when
+// FUSSY_GIT_HOME belongs to another user, unless --allow-root is given: the files root creates
+// there can't be changed or removed by the owner of the tree afterwards.
+func checkRunningAsRoot(cmd *cobra.Command) error {
+	if allowRoot || os.Geteuid() != 0 || cmd.Annotations[annotationWritesTree] == "" {
+		return nil
+	}
+	uid, _, ok := fsutil.Owner(appConfig.FussyGitHome)
+	if !ok || uid == 0 {
+		return nil
+	}
+	owner := strconv.Itoa(uid)
+	if u, err := user.LookupId(owner); err == nil {
+		owner = u.Username
+	}
+	fmt.Fprintf(os.Stderr, "Warning: running as root, but %s belongs to %s. Files created by '%s' would be owned by root.\n",
+		appConfig.FussyGitHome, owner, cmd.CommandPath())
+	return fmt.Errorf("refusing to run as root in another user's FUSSY_GIT_HOME; run the command as %s (without sudo), or use --allow-root", owner)
+}
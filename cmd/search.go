@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var searchFilter filter.Filter
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search <term>...",
+	Short: "Finds repositories by name, URL, description, tags or notes.",
+	Long: `Searches the tracked repositories for the given terms, case-insensitively, in their name,
+normalized path, local path, URLs, README description, tags and notes. Repositories match if
+every term occurs in at least one of these, and are listed with the beginning of their README
+description, to find the clone of a half-forgotten project by what it is about.
+
+Descriptions are the first paragraph (or the title) of each repository's README, recorded when
+it is cloned or added and refreshed with its URL. The common filter flags narrow the search.
+
+Examples:
+  fussy-git search yaml parser
+  fussy-git search --domain github.com cli`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		refreshStaleRepositories()
+
+		searchFilter.Terms = args
+		matches := searchFilter.Apply(repoState.Repositories)
+		if len(matches) == 0 {
+			fmt.Printf("No repositories match '%s'.\n", strings.Join(args, " "))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tPATH\tDESCRIPTION")
+		fmt.Fprintln(w, "----\t----\t-----------")
+		for _, repo := range matches {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", repo.Name, repo.Path, truncateDescription(repo.Description, descriptionWidth))
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	addFilterFlags(searchCmd, &searchFilter)
+}
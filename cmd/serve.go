@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/fsutil"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr     string
+	serveMetrics  bool
+	serveInterval time.Duration
+	serveWebhooks bool
+)
+
+// repoMetrics is a point-in-time snapshot of the health of all tracked repositories.
+type repoMetrics struct {
+	collectedAt        time.Time
+	collectionDuration time.Duration
+	repositories       int
+	missing            int
+	withIssues         int
+	dirty              int
+	behindUpstream     int
+	reposByDomain      map[string]int
+	diskUsageByDomain  map[string]int64
+}
+
+// metricsCollector periodically collects repoMetrics in the background and serves the latest snapshot.
+type metricsCollector struct {
+	mu      sync.RWMutex
+	current *repoMetrics
+}
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Runs a long-lived HTTP server exposing fussy-git data to other tools.",
+	Long: `Runs an HTTP server for integrating fussy-git with other tools.
+
+With --metrics, a Prometheus exporter is served on /metrics with gauges describing the
+health of all tracked repositories:
+
+  fussy_git_repositories{domain}             number of tracked repositories
+  fussy_git_repositories_missing             repositories whose path doesn't exist
+  fussy_git_repositories_with_issues         repositories for which 'doctor' reports errors
+  fussy_git_repositories_dirty               repositories with uncommitted changes
+  fussy_git_repositories_behind_upstream     repositories whose current branch is behind its upstream
+  fussy_git_disk_usage_bytes{domain}         disk space used by the repositories of a domain
+
+Collecting these requires inspecting every repository, so they are gathered in the background
+every --interval rather than on each scrape. "Behind upstream" is based on the last fetch;
+fussy-git does not fetch from remotes itself.
+
+With --webhooks, GitHub and GitLab webhooks are accepted on /webhooks (POST), and the
+repositories they are about are updated in the state right away, so doctor reports remote-side
+changes immediately instead of after the next 'doctor --check-remotes':
+
+  renamed, transferred     the new URL is recorded; doctor reports it (check remote-moved)
+                           until origin is updated, e.g. with 'git remote set-url'
+  archived, unarchived     recorded; doctor notes archived repositories (remote-archived)
+  default branch changed   recorded; doctor reports clones whose origin/HEAD still points at
+                           the old default branch (default-branch)
+
+On GitHub, add a webhook for the "Repositories" event (organization webhooks receive it for
+all repositories of the organization). On GitLab, renames and transfers are sent by system
+hooks; every project webhook reports the default branch. The webhooks must be signed with the
+secret in the FUSSY_GIT_WEBHOOK_SECRET environment variable (GitHub's "Secret", GitLab's
+"Secret token"); serve refuses to start with --webhooks without it. Events are logged on
+stdout, and for repositories that aren't tracked they are ignored.
+
+The server listens on 127.0.0.1 by default; use e.g. --addr :9787 to allow scraping from other
+hosts, or to receive webhooks from a provider (typically through a reverse proxy or tunnel).
+
+Examples:
+  fussy-git serve --metrics
+  fussy-git serve --metrics --addr :9787 --interval 15m
+  FUSSY_GIT_WEBHOOK_SECRET=... fussy-git serve --webhooks --addr :9787`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !serveMetrics && !serveWebhooks {
+			return fmt.Errorf("nothing to serve: enable at least one endpoint, e.g. --metrics or --webhooks")
+		}
+		if serveInterval < time.Minute {
+			return fmt.Errorf("--interval must be at least 1m, got %s", serveInterval)
+		}
+		if serveWebhooks {
+			if appConfig.ReadOnly {
+				return readOnlyError("'serve --webhooks'")
+			}
+			if appConfig.WebhookSecret == "" {
+				return fmt.Errorf("--webhooks requires a secret to verify webhooks with: set FUSSY_GIT_WEBHOOK_SECRET")
+			}
+		}
+
+		mux := http.NewServeMux()
+		if serveMetrics {
+			collector := &metricsCollector{}
+			fmt.Println("Collecting initial metrics...")
+			collector.collect()
+			go collector.run(serveInterval)
+			mux.Handle("GET /metrics", collector)
+			fmt.Printf("Serving metrics on http://%s/metrics\n", serveAddr)
+		}
+		if serveWebhooks {
+			mux.Handle("POST /webhooks", &webhookReceiver{secret: appConfig.WebhookSecret})
+			fmt.Printf("Receiving webhooks on http://%s/webhooks\n", serveAddr)
+		}
+
+		fmt.Println("Press Ctrl+C to stop.")
+		return http.ListenAndServe(serveAddr, mux)
+	},
+}
+
+// run re-collects metrics every interval. It never returns.
+func (c *metricsCollector) run(interval time.Duration) {
+	for range time.Tick(interval) {
+		c.collect()
+	}
+}
+
+// collect inspects all tracked repositories and replaces the current snapshot.
+// The state file is re-read so changes made by other fussy-git commands are picked up.
+func (c *metricsCollector) collect() {
+	start := time.Now()
+	rs, err := loadRepoState()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load state, keeping previous metrics: %v\n", err)
+		return
+	}
+
+	m := &repoMetrics{
+		repositories:      len(rs.Repositories),
+		reposByDomain:     map[string]int{},
+		diskUsageByDomain: map[string]int64{},
+	}
+	var mu sync.Mutex
+	jobs := make(chan state.RepositoryEntry)
+	var wg sync.WaitGroup
+	for i := 0; i < inspectWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for repo := range jobs {
+				exists, issues, dirty, behind, size := inspectRepositoryHealth(repo)
+				mu.Lock()
+				m.reposByDomain[repo.Domain]++
+				m.diskUsageByDomain[repo.Domain] += size
+				if !exists {
+					m.missing++
+				}
+				if issues {
+					m.withIssues++
+				}
+				if dirty {
+					m.dirty++
+				}
+				if behind {
+					m.behindUpstream++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, repo := range rs.Repositories {
+		jobs <- repo
+	}
+	close(jobs)
+	wg.Wait()
+
+	m.collectedAt = time.Now()
+	m.collectionDuration = m.collectedAt.Sub(start)
+	c.mu.Lock()
+	c.current = m
+	c.mu.Unlock()
+	if verbose {
+		fmt.Printf("Collected metrics for %d repositories in %s\n", m.repositories, m.collectionDuration.Round(time.Millisecond))
+	}
+}
+
+// inspectRepositoryHealth gathers the per-repository facts the metrics are built from.
+func inspectRepositoryHealth(repo state.RepositoryEntry) (exists, hasIssues, dirty, behindUpstream bool, diskUsage int64) {
+	hasIssues = countSeverity(checkRepository(repo), severityError) > 0
+	if _, err := os.Stat(repo.Path); err != nil {
+		return false, hasIssues, false, false, 0
+	}
+	if !gitutil.IsGitRepository(repo.Path) {
+		return true, hasIssues, false, false, 0
+	}
+
+	dirty, _ = gitutil.IsDirty(repo.Path)
+	if _, behind, err := gitutil.AheadBehind(repo.Path); err == nil {
+		behindUpstream = behind > 0
+	}
+	diskUsage, _ = fsutil.DirSize(repo.Path)
+	return true, hasIssues, dirty, behindUpstream, diskUsage
+}
+
+// ServeHTTP writes the latest snapshot in the Prometheus text exposition format.
+func (c *metricsCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.RLock()
+	m := c.current
+	c.mu.RUnlock()
+
+	// Until a collection succeeds, e.g. while the state file can't be read, there is nothing to report.
+	if m == nil {
+		http.Error(w, "no metrics collected yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.writeTo(w)
+}
+
+func (m *repoMetrics) writeTo(w io.Writer) {
+	domains := make([]string, 0, len(m.reposByDomain))
+	for domain := range m.reposByDomain {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	writeMetricHeader(w, "fussy_git_repositories", "Number of repositories tracked by fussy-git.")
+	for _, domain := range domains {
+		fmt.Fprintf(w, "fussy_git_repositories{domain=%q} %d\n", domain, m.reposByDomain[domain])
+	}
+	writeGauge(w, "fussy_git_repositories_missing", "Number of tracked repositories whose path does not exist.", m.missing)
+	writeGauge(w, "fussy_git_repositories_with_issues", "Number of tracked repositories for which doctor reports errors.", m.withIssues)
+	writeGauge(w, "fussy_git_repositories_dirty", "Number of tracked repositories with uncommitted changes.", m.dirty)
+	writeGauge(w, "fussy_git_repositories_behind_upstream", "Number of tracked repositories whose current branch is behind its upstream.", m.behindUpstream)
+
+	writeMetricHeader(w, "fussy_git_disk_usage_bytes", "Disk space used by tracked repositories, per domain.")
+	for _, domain := range domains {
+		fmt.Fprintf(w, "fussy_git_disk_usage_bytes{domain=%q} %d\n", domain, m.diskUsageByDomain[domain])
+	}
+
+	writeMetricHeader(w, "fussy_git_last_collection_timestamp_seconds", "Unix time of the last metrics collection.")
+	fmt.Fprintf(w, "fussy_git_last_collection_timestamp_seconds %d\n", m.collectedAt.Unix())
+	writeMetricHeader(w, "fussy_git_collection_duration_seconds", "Time taken by the last metrics collection.")
+	fmt.Fprintf(w, "fussy_git_collection_duration_seconds %g\n", m.collectionDuration.Seconds())
+}
+
+func writeMetricHeader(w io.Writer, name, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
+
+func writeGauge(w io.Writer, name, help string, value int) {
+	writeMetricHeader(w, name, help)
+	fmt.Fprintf(w, "%s %d\n", name, value)
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:9787", "Address to listen on")
+	serveCmd.Flags().BoolVar(&serveMetrics, "metrics", false, "Serve Prometheus metrics on /metrics")
+	serveCmd.Flags().BoolVar(&serveWebhooks, "webhooks", false, "Receive GitHub and GitLab webhooks on /webhooks and update the state")
+	serveCmd.Flags().DurationVar(&serveInterval, "interval", 5*time.Minute, "How often to re-collect metrics")
+}
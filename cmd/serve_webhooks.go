@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"github.com/jmsnll/fussy-git/internal/webhook"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxWebhookBytes is the largest webhook payload accepted; repository events are a few KiB.
+const maxWebhookBytes = 4 << 20
+
+// webhookReceiver updates the state from the GitHub and GitLab webhooks it receives.
+type webhookReceiver struct {
+	secret string
+	mu     sync.Mutex // Serializes updates of the state file
+}
+
+// ServeHTTP verifies and applies a webhook.
+func (wr *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+	if err != nil {
+		http.Error(w, "failed to read the payload: "+err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	events, err := webhook.Parse(r, body, wr.secret)
+	if errors.Is(err, webhook.ErrUnauthorized) {
+		fmt.Fprintf(os.Stderr, "[WARN] Rejected a webhook from %s: %v\n", r.RemoteAddr, err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	updated, err := applyWebhookEvents(events, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[FAIL] Could not apply a webhook: %v\n", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintf(w, "updated %d repositories\n", updated)
+}
+
+// applyWebhookEvents records the events in the repositories they are about and saves the state,
+// returning how many repositories changed. The state file is re-read first, so changes made by
+// other fussy-git commands while serve runs are kept.
+func applyWebhookEvents(events []webhook.Event, now time.Time) (int, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+	rs, err := loadRepoState()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	updated := 0
+	for _, ev := range events {
+		n := 0
+		for i := range rs.Repositories {
+			if applyWebhookEvent(&rs.Repositories[i], ev, now) {
+				n++
+			}
+		}
+		updated += n
+		if n == 0 && !verbose {
+			continue // Events about untracked repositories, or reporting nothing new
+		}
+		if ev.OldPath != ev.Path {
+			fmt.Printf("[webhook] %s: %s/%s %s to %s/%s, %d repositories updated\n", ev.Provider, ev.Domain, ev.OldPath, ev.Kind, ev.Domain, ev.Path, n)
+		} else {
+			fmt.Printf("[webhook] %s: %s/%s %s, %d repositories updated\n", ev.Provider, ev.Domain, ev.Path, describeWebhookEvent(ev), n)
+		}
+	}
+	if updated == 0 {
+		return 0, nil
+	}
+	if err := rs.Save(appConfig.StateFilePath); err != nil {
+		return 0, fmt.Errorf("failed to save state: %w", err)
+	}
+	return updated, nil
+}
+
+// applyWebhookEvent records ev in entry if the event is about the repository, and reports whether
+// anything changed. Repositories are matched by domain and path, both before and after a rename
+// or transfer, including the URL an earlier rename or transfer moved them to.
+func applyWebhookEvent(entry *state.RepositoryEntry, ev webhook.Event, now time.Time) bool {
+	current, ok := webhookRepoPath(entry.CurrentURL, ev.Domain)
+	if !ok {
+		return false
+	}
+	matches := func(path string) bool {
+		return strings.EqualFold(path, ev.OldPath) || strings.EqualFold(path, ev.Path)
+	}
+	rc := entry.RemoteChanges
+	if rc == nil {
+		rc = &state.RemoteChanges{}
+	}
+	moved, movedOK := webhookRepoPath(rc.MovedTo, ev.Domain)
+	if !matches(current) && !(movedOK && matches(moved)) {
+		return false
+	}
+
+	before := *rc
+	switch ev.Kind {
+	case webhook.KindRenamed, webhook.KindTransferred:
+		if strings.EqualFold(current, ev.Path) {
+			rc.MovedTo = "" // Origin was updated already.
+		} else if url, ok := gitutil.ReplaceRepoPath(entry.CurrentURL, current, ev.Path); ok {
+			rc.MovedTo = url
+		}
+	case webhook.KindArchived:
+		rc.Archived = true
+	case webhook.KindUnarchived:
+		rc.Archived = false
+	case webhook.KindDefaultBranch:
+		rc.DefaultBranch = ev.DefaultBranch
+	}
+	if *rc == before {
+		return false // e.g. a rename origin was already updated for, or a push reporting the same default branch
+	}
+	rc.ReceivedAt = now
+	entry.RemoteChanges = rc
+	entry.LastModified = now
+	return true
+}
+
+// webhookRepoPath returns the repository path (e.g. "owner/repo") of rawURL if it is on domain.
+func webhookRepoPath(rawURL, domain string) (string, bool) {
+	if rawURL == "" {
+		return "", false
+	}
+	parsed, err := parseRepoURL(rawURL)
+	if err != nil || !strings.EqualFold(parsed.Domain, domain) {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.Trim(parsed.Path, "/"), ".git"), true
+}
+
+// describeWebhookEvent describes an event that doesn't move the repository, for the log.
+func describeWebhookEvent(ev webhook.Event) string {
+	if ev.Kind == webhook.KindDefaultBranch {
+		return fmt.Sprintf("has default branch '%s'", ev.DefaultBranch)
+	}
+	return ev.Kind
+}
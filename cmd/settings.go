@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/config"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// repoSettings returns the effective layered settings (global, domain, owner, repository)
+// for a repository URL.
+func repoSettings(parsedURL *gitutil.ParsedGitURL) config.Resolved {
+	return urlResolver().Settings(parsedURL)
+}
+
+// repoLayout returns the layout that applies to a repository URL.
+func repoLayout(parsedURL *gitutil.ParsedGitURL) string {
+	return urlResolver().Layout(parsedURL)
+}
+
+// applyProtocol converts repoURL to the protocol configured for it, if any.
+func applyProtocol(repoURL string, parsedURL *gitutil.ParsedGitURL) (string, error) {
+	switch repoSettings(parsedURL).Protocol {
+	case "ssh":
+		if !parsedURL.IsSSH {
+			return parsedURL.ToSSH()
+		}
+	case "https":
+		if parsedURL.IsSSH {
+			return parsedURL.ToHTTPS()
+		}
+	}
+	return repoURL, nil
+}
+
+// applyIdentity sets the git identity, SSH command and commit signing configured for a
+// repository in its local git config. Settings that aren't configured are left alone.
+func applyIdentity(repoPath string, parsedURL *gitutil.ParsedGitURL) error {
+	settings := repoSettings(parsedURL)
+	for key, value := range map[string]string{
+		"user.name":       settings.UserName,
+		"user.email":      settings.UserEmail,
+		"core.sshCommand": settings.SSHCommand,
+	} {
+		if value == "" {
+			continue
+		}
+		if err := gitutil.SetConfigValue(repoPath, key, value); err != nil {
+			return err
+		}
+	}
+	return applySettings(repoPath, signingConfig(settings))
+}
+
+// metadataEnvPrefix marks repository metadata that sets an environment variable, e.g.
+// "env.GOFLAGS" (see 'fussy-git meta').
+const metadataEnvPrefix = "env."
+
+// repoEnv returns the environment variables ("NAME=value") configured for a tracked repository:
+// those of the layered config, overridden by its env.<NAME> metadata.
+func repoEnv(entry state.RepositoryEntry) []string {
+	env := appConfig.Layers.For(filepath.ToSlash(entry.NormalizedFS)).Env
+	var fromMetadata []string
+	for _, key := range sortedMetadataKeys(entry.Metadata) {
+		if name, ok := strings.CutPrefix(key, metadataEnvPrefix); ok && name != "" {
+			fromMetadata = append(fromMetadata, name+"="+entry.Metadata[key])
+		}
+	}
+	return config.MergeEnv(env, fromMetadata)
+}
+
+// runBootstrap runs the bootstrap commands configured for a repository in its directory,
+// one after the other, writing their output to out. It stops at the first failing command.
+func runBootstrap(repoPath string, parsedURL *gitutil.ParsedGitURL, out io.Writer) error {
+	for _, command := range repoSettings(parsedURL).Bootstrap {
+		fmt.Fprintf(out, "Running bootstrap command: %s\n", command)
+		c := exec.Command("sh", "-c", command)
+		c.Dir = repoPath
+		c.Stdout, c.Stderr = out, out
+		c.Env = append(os.Environ(), repoSettings(parsedURL).Env...)
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("bootstrap command '%s' failed: %w", command, err)
+		}
+	}
+	return nil
+}
+
+// setUpClone applies the per-repository settings to a fresh clone: the git identity, the
+// .envrc of the direnv integration and, if bootstrap is set, the bootstrap commands. Their
+// output goes to out; if they fail, the output is part of the error when out is nil.
+func setUpClone(repoPath string, parsedURL *gitutil.ParsedGitURL, out io.Writer, bootstrap bool) error {
+	if err := applyIdentity(repoPath, parsedURL); err != nil {
+		return err
+	}
+	if out != nil {
+		if err := setUpDirenv(repoPath, parsedURL, out); err != nil {
+			return err
+		}
+		if !bootstrap {
+			return nil
+		}
+		return runBootstrap(repoPath, parsedURL, out)
+	}
+	var buf bytes.Buffer
+	err := setUpDirenv(repoPath, parsedURL, &buf)
+	if err == nil && bootstrap {
+		err = runBootstrap(repoPath, parsedURL, &buf)
+	}
+	if err != nil {
+		return fmt.Errorf("%w. Output:\n%s", err, strings.TrimSpace(buf.String()))
+	}
+	return nil
+}
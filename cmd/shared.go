@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/fsutil"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+// loadRepoState loads the state file, overlaid with the shared state file if one is configured.
+func loadRepoState() (*state.RepoState, error) {
+	rs, err := state.LoadState(appConfig.StateFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if appConfig.SharedStateFile != "" {
+		if err := rs.LoadShared(appConfig.SharedStateFile); err != nil {
+			return nil, err
+		}
+	}
+	return rs, nil
+}
+
+// sharedGroupID returns the ID of the configured shared_group.
+func sharedGroupID() (int, error) {
+	group, err := user.LookupGroup(appConfig.SharedGroup)
+	if err != nil {
+		if group, err = user.LookupGroupId(appConfig.SharedGroup); err != nil {
+			return 0, fmt.Errorf("shared_group '%s' is not a known group", appConfig.SharedGroup)
+		}
+	}
+	return strconv.Atoi(group.Gid)
+}
+
+// makeRepoDirs creates dir and its missing parents for a repository. In a shared
+// FUSSY_GIT_HOME they are made group-writable and owned by the shared group.
+func makeRepoDirs(dir string) error {
+	if appConfig.SharedGroup == "" {
+		return os.MkdirAll(dir, 0755)
+	}
+	gid, err := sharedGroupID()
+	if err != nil {
+		return err
+	}
+	return fsutil.MkdirAllShared(dir, gid)
+}
+
+// sharedCloneArgs returns the 'git clone' options that make a clone usable by the whole
+// shared group, or nil if FUSSY_GIT_HOME isn't shared.
+func sharedCloneArgs() []string {
+	if appConfig.SharedGroup == "" {
+		return nil
+	}
+	return []string{"--config", "core.sharedRepository=group"}
+}
+
+// ownershipProblem returns why git would refuse to work in the repository at path because it
+// is owned by another user and not listed in safe.directory, or "" if it wouldn't.
+func ownershipProblem(path string) string {
+	uid, _, ok := fsutil.Owner(path)
+	if !ok || uid == os.Getuid() {
+		return ""
+	}
+	safe := gitutil.SafeDirectories()
+	if slices.Contains(safe, "*") || slices.Contains(safe, path) || slices.Contains(safe, filepath.ToSlash(path)) {
+		return ""
+	}
+	owner := strconv.Itoa(uid)
+	if u, err := user.LookupId(owner); err == nil {
+		owner = u.Username
+	}
+	return fmt.Sprintf("Owned by %s; git refuses to work in it until it is marked as safe: git config --global --add safe.directory %s", owner, path)
+}
+
+// permissionProblems returns what keeps the current user, or the members of the shared group,
+// from writing to the repository at path.
+func permissionProblems(path string) []string {
+	var problems []string
+	gitDir, err := gitutil.GitPath(path, "objects")
+	if err == nil && !fsutil.Writable(gitDir) {
+		problems = append(problems, fmt.Sprintf("%s is not writable by you; fetches and commits will fail", gitDir))
+	}
+	if appConfig.SharedGroup == "" {
+		return problems
+	}
+
+	if gid, err := sharedGroupID(); err != nil {
+		problems = append(problems, err.Error())
+	} else if _, owner, ok := fsutil.Owner(path); ok && owner != gid {
+		problems = append(problems, fmt.Sprintf("Not owned by group '%s'; other users can't write to it: chgrp -R %s %s", appConfig.SharedGroup, appConfig.SharedGroup, path))
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0020 == 0 {
+		problems = append(problems, fmt.Sprintf("Not group-writable: chmod g+w %s", path))
+	}
+	if shared := gitutil.ConfigValue(path, "core.sharedRepository"); shared != "group" && shared != "true" && shared != "all" && shared != "world" && shared != "everybody" {
+		problems = append(problems, "core.sharedRepository is not set, so git creates files only you can write: git config core.sharedRepository group")
+	}
+	return problems
+}
+
+// shareWorkTree makes the top directory of a fresh clone writable by the shared group; git only
+// takes care of the .git directory. Does nothing if FUSSY_GIT_HOME isn't shared.
+func shareWorkTree(path string) error {
+	if appConfig.SharedGroup == "" {
+		return nil
+	}
+	return os.Chmod(path, fsutil.SharedDirMode)
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/config"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	signingDryRun bool
+	signingFilter filter.Filter
+)
+
+// gitSetting is a git config key and the value fussy-git sets it to.
+type gitSetting struct {
+	key, value string
+}
+
+// signingCmd represents the signing command
+var signingCmd = &cobra.Command{
+	Use:   "signing",
+	Short: "Configures commit signing in tracked repositories.",
+	Long: `Commands for signing commits and tags. The signing key is configured with the signing_key
+and signing_format settings, globally or per domain, owner or repository (see 'fussy-git help
+config'); wherever a signing_key applies, commits must be signed:
+
+  domains:
+    github.corp:
+      signing_key: ~/.ssh/id_work.pub   # or a GPG key ID with signing_format: openpgp
+      signing_format: ssh
+
+New clones and added repositories get the signing configuration right away. 'fussy-git signing
+setup' rolls it out to the repositories that already exist, and 'fussy-git doctor' reports the
+repositories that don't sign their commits (the signing check).`,
+}
+
+// signingSetupCmd represents the signing setup command
+var signingSetupCmd = &cobra.Command{
+	Use:   "setup",
+	Short: "Sets up commit signing in the repositories that require it.",
+	Long: `Configures every tracked repository that a signing_key applies to (see 'fussy-git help
+signing') to sign its commits and tags with that key, by setting in its local git config:
+
+  user.signingKey  the signing_key
+  gpg.format       the signing_format (openpgp if it isn't set)
+  commit.gpgSign   true
+  tag.gpgSign      true
+
+Repositories that are already set up are left alone, as are repositories no signing_key
+applies to. Use --domain, --owner, --tag and --path-prefix to roll signing out to a subset of
+repositories, and --dry-run to see what would change. The key itself must be available to gpg
+(or ssh-keygen, or gpgsm); fussy-git doesn't create or check it.
+
+Examples:
+  fussy-git signing setup --domain github.corp --dry-run
+  fussy-git signing setup --domain github.corp`,
+	Annotations: mutates,
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repos := signingFilter.Apply(repoState.Repositories)
+		configured, upToDate, unconfigured, failed := 0, 0, 0, 0
+		for _, repo := range repos {
+			settings := appConfig.Layers.For(filepath.ToSlash(repo.NormalizedFS))
+			if settings.SigningKey == "" {
+				unconfigured++
+				continue
+			}
+			if !gitutil.IsGitRepository(repo.Path) {
+				fmt.Printf("[FAIL] %s: not a Git repository\n", repo.Path)
+				failed++
+				continue
+			}
+			changes := signingChanges(repo.Path, settings)
+			if len(changes) == 0 {
+				if verbose {
+					fmt.Printf("[SKIP] %s already signs with %s\n", repo.Path, settings.SigningKey)
+				}
+				upToDate++
+				continue
+			}
+			var assignments []string
+			for _, s := range changes {
+				assignments = append(assignments, s.key+"="+s.value)
+			}
+			if signingDryRun {
+				fmt.Printf("Would set %s in %s\n", strings.Join(assignments, ", "), repo.Path)
+				configured++
+				continue
+			}
+			if err := applySettings(repo.Path, changes); err != nil {
+				fmt.Printf("[FAIL] %s: %v\n", repo.Path, err)
+				failed++
+				continue
+			}
+			fmt.Printf("[OK] Set %s in %s\n", strings.Join(assignments, ", "), repo.Path)
+			configured++
+		}
+
+		if configured+upToDate+failed == 0 {
+			fmt.Println("No signing_key applies to the selected repositories; configure one first (see 'fussy-git help signing').")
+		}
+		fmt.Printf("\nSigning setup summary:\n")
+		if signingDryRun {
+			fmt.Printf("  Would configure:     %d\n", configured)
+		} else {
+			fmt.Printf("  Configured:          %d\n", configured)
+		}
+		fmt.Printf("  Already configured:  %d\n", upToDate)
+		fmt.Printf("  Without signing_key: %d\n", unconfigured)
+		fmt.Printf("  Failed:              %d\n", failed)
+		if failed > 0 {
+			return fmt.Errorf("%d repositories could not be set up for signing", failed)
+		}
+		return nil
+	},
+}
+
+// signingConfig returns the local git config that makes a repository sign its commits and
+// tags with the configured signing_key, or nothing if no signing_key applies to it.
+func signingConfig(settings config.Resolved) []gitSetting {
+	if settings.SigningKey == "" {
+		return nil
+	}
+	format := settings.SigningFormat
+	if format == "" {
+		format = "openpgp"
+	}
+	return []gitSetting{
+		{"user.signingKey", settings.SigningKey},
+		{"gpg.format", format},
+		{"commit.gpgSign", "true"},
+		{"tag.gpgSign", "true"},
+	}
+}
+
+// signingChanges returns the settings of signingConfig whose effective value in the
+// repository differs, as git sees them (including the global config).
+func signingChanges(repoPath string, settings config.Resolved) []gitSetting {
+	var changes []gitSetting
+	for _, s := range signingConfig(settings) {
+		var current string
+		switch s.key {
+		case "commit.gpgSign", "tag.gpgSign":
+			current = strconv.FormatBool(gitutil.ConfigBool(repoPath, s.key))
+		case "gpg.format":
+			if current = gitutil.ConfigValue(repoPath, s.key); current == "" {
+				current = "openpgp"
+			}
+		default:
+			current = gitutil.ConfigValue(repoPath, s.key)
+		}
+		if current != s.value {
+			changes = append(changes, s)
+		}
+	}
+	return changes
+}
+
+// applySettings sets git config values in the repository's own configuration.
+func applySettings(repoPath string, settings []gitSetting) error {
+	for _, s := range settings {
+		if err := gitutil.SetConfigValue(repoPath, s.key, s.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signingProblems returns why a repository that a signing_key applies to doesn't sign its
+// commits with that key, for doctor's signing check. Tags aren't checked.
+func signingProblems(repo state.RepositoryEntry) []string {
+	settings := appConfig.Layers.For(filepath.ToSlash(repo.NormalizedFS))
+	source := settings.Sources["signing_key"]
+	changes := signingChanges(repo.Path, settings)
+	for _, s := range changes {
+		// Unsigned commits are the problem; the key and format don't matter then.
+		if s.key == "commit.gpgSign" {
+			return []string{fmt.Sprintf("Commits aren't signed, but signing is required there (signing_key set by %s); run 'fussy-git signing setup'", source)}
+		}
+	}
+	var problems []string
+	for _, s := range changes {
+		if s.key == "tag.gpgSign" {
+			continue
+		}
+		current := gitutil.ConfigValue(repo.Path, s.key)
+		switch {
+		case current == "" && s.key == "gpg.format":
+			current = "openpgp"
+		case current == "":
+			current = "(not set)"
+		}
+		problems = append(problems, fmt.Sprintf("Commits are signed with %s '%s', but the signing configuration (%s) requires '%s'; run 'fussy-git signing setup'",
+			s.key, current, source, s.value))
+	}
+	return problems
+}
+
+func init() {
+	signingSetupCmd.Flags().BoolVar(&signingDryRun, "dry-run", false, "Show what would be configured without changing anything")
+	addFilterFlags(signingSetupCmd, &signingFilter)
+	signingCmd.AddCommand(signingSetupCmd)
+}
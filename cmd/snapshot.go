@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/manifest"
+	"github.com/jmsnll/fussy-git/internal/snapshot"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	snapshotFilter filter.Filter
+	forceSnapshot  bool
+)
+
+// snapshotCmd represents the snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Records and restores the checked out commits of all repositories.",
+	Long: `Snapshots record, for every tracked repository, the commit HEAD points to, the branch that
+is checked out and whether the working tree has uncommitted changes. Restoring a snapshot
+checks out those commits again, e.g. to get back to a known state after a risky refactoring
+across many repositories:
+  fussy-git snapshot create before-rename
+  ... change, commit, experiment ...
+  fussy-git snapshot restore before-rename
+
+Restoring checks out the recorded branch if it still points at the recorded commit, and
+otherwise detaches HEAD at the commit, so no branch is ever moved and no commit is lost.
+Repositories with uncommitted changes or untracked files are not touched; commit or stash
+the changes first. Uncommitted changes are not part of a snapshot: repositories that had
+some when the snapshot was created are marked as dirty.
+
+Snapshots are stored as JSON files in the 'snapshots' directory next to the state file.
+Use the filter flags with 'create' to record only some repositories.`,
+}
+
+// snapshotCreateCmd represents the snapshot create command
+var snapshotCreateCmd = &cobra.Command{
+	Use:         "create <name>",
+	Short:       "Records the checkouts of the tracked repositories under a name.",
+	Args:        cobra.ExactArgs(1),
+	Annotations: mutates,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := snapshot.ValidateName(name); err != nil {
+			return usageError("invalid snapshot name: %v", err)
+		}
+		if snapshot.Exists(snapshotDir(), name) && !forceSnapshot {
+			return fmt.Errorf("snapshot '%s' already exists; use --force to replace it", name)
+		}
+
+		snap := &snapshot.Snapshot{Name: name, CreatedAt: time.Now()}
+		dirty, skipped := 0, 0
+		for _, repo := range snapshotFilter.Apply(repoState.Repositories) {
+			head, err := gitutil.HeadCommit(repo.Path)
+			if err != nil || head == "" {
+				skipped++
+				fmt.Printf("[SKIP] %s: HEAD can't be resolved at %s\n", repo.Name, repo.Path)
+				continue
+			}
+			branch, _ := gitutil.CurrentBranch(repo.Path)
+			isDirty, _ := gitutil.IsDirty(repo.Path)
+			if isDirty {
+				dirty++
+				fmt.Printf("[WARN] %s has uncommitted changes; they are not part of the snapshot.\n", repo.Name)
+			}
+			snap.Repositories = append(snap.Repositories, snapshot.Repository{
+				Name:   repo.Name,
+				Path:   repo.Path,
+				URL:    repo.CurrentURL,
+				Branch: branch,
+				Commit: head,
+				Dirty:  isDirty,
+			})
+		}
+		if len(snap.Repositories) == 0 {
+			return fmt.Errorf("no repositories to record")
+		}
+		if err := snap.Save(snapshotDir()); err != nil {
+			return err
+		}
+		fmt.Printf("Recorded %d repositories in snapshot '%s' (%d dirty, %d skipped).\n", len(snap.Repositories), name, dirty, skipped)
+		return nil
+	},
+}
+
+// snapshotRestoreCmd represents the snapshot restore command
+var snapshotRestoreCmd = &cobra.Command{
+	Use:               "restore <name>",
+	Short:             "Checks out the commits recorded in a snapshot again.",
+	Annotations:       writesTree,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSnapshot,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		snap, err := snapshot.Load(snapshotDir(), args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Restoring snapshot '%s' from %s...\n", snap.Name, snap.CreatedAt.Format(time.RFC3339))
+
+		restored, unchanged, failed := 0, 0, 0
+		for _, recorded := range snap.Repositories {
+			idx := repoState.IndexOfPath(recorded.Path)
+			if idx < 0 {
+				// The repository may have been moved since.
+				idx, _ = lookupRepository(recorded.URL)
+			}
+			if idx < 0 {
+				failed++
+				fmt.Printf("[FAIL] %s: no longer tracked (was at %s)\n", recorded.Name, recorded.Path)
+				continue
+			}
+			repo := repoState.Repositories[idx]
+			done, err := checkoutPin(repo, manifest.Entry{URL: recorded.URL, Branch: recorded.Branch, Commit: recorded.Commit})
+			note := ""
+			if recorded.Dirty {
+				note = " (it had uncommitted changes when the snapshot was created, which were not recorded)"
+			}
+			switch {
+			case err != nil:
+				failed++
+				fmt.Printf("[FAIL] %s: %v\n", repo.Name, err)
+			case done != "":
+				restored++
+				fmt.Printf("[OK]   %s: %s%s\n", repo.Name, done, note)
+			default:
+				unchanged++
+				if verbose || note != "" {
+					fmt.Printf("[SKIP] %s: already at the snapshot%s\n", repo.Name, note)
+				}
+			}
+		}
+
+		fmt.Printf("\nSnapshot restore summary:\n")
+		fmt.Printf("  Repositories: %d\n", len(snap.Repositories))
+		fmt.Printf("  Restored:     %d\n", restored)
+		fmt.Printf("  Unchanged:    %d\n", unchanged)
+		fmt.Printf("  Failed:       %d\n", failed)
+		if failed > 0 {
+			return bulkFailure(failed, len(snap.Repositories), fmt.Errorf("%d of %d repositories could not be restored", failed, len(snap.Repositories)))
+		}
+		return nil
+	},
+}
+
+// snapshotListCmd represents the snapshot list command
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the stored snapshots.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		snapshots, err := snapshot.List(snapshotDir())
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			fmt.Println("No snapshots. Create one with 'fussy-git snapshot create <name>'.")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tCREATED\tREPOSITORIES\tDIRTY")
+		fmt.Fprintln(w, "----\t-------\t------------\t-----")
+		for _, s := range snapshots {
+			dirty := 0
+			for _, repo := range s.Repositories {
+				if repo.Dirty {
+					dirty++
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", s.Name, s.CreatedAt.Format("2006-01-02 15:04"), len(s.Repositories), dirty)
+		}
+		return w.Flush()
+	},
+}
+
+// snapshotShowCmd represents the snapshot show command
+var snapshotShowCmd = &cobra.Command{
+	Use:               "show <name>",
+	Short:             "Lists the checkouts recorded in a snapshot.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSnapshot,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		snap, err := snapshot.Load(snapshotDir(), args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Snapshot '%s', created %s\n\n", snap.Name, snap.CreatedAt.Format(time.RFC3339))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tBRANCH\tCOMMIT\tDIRTY\tPATH")
+		fmt.Fprintln(w, "----\t------\t------\t-----\t----")
+		for _, repo := range snap.Repositories {
+			branch := repo.Branch
+			if branch == "" {
+				branch = "(detached)"
+			}
+			dirty := ""
+			if repo.Dirty {
+				dirty = "yes"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", repo.Name, branch, repo.Commit[:min(len(repo.Commit), 12)], dirty, repo.Path)
+		}
+		return w.Flush()
+	},
+}
+
+// snapshotDeleteCmd represents the snapshot delete command
+var snapshotDeleteCmd = &cobra.Command{
+	Use:               "delete <name>",
+	Short:             "Deletes a snapshot.",
+	Annotations:       mutates,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSnapshot,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := snapshot.Delete(snapshotDir(), args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted snapshot '%s'.\n", args[0])
+		return nil
+	},
+}
+
+// snapshotDir returns the directory snapshots are stored in.
+func snapshotDir() string {
+	return filepath.Join(filepath.Dir(appConfig.StateFilePath), "snapshots")
+}
+
+// completeSnapshot completes the name of a stored snapshot as the first argument.
+func completeSnapshot(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || appConfig == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	snapshots, _ := snapshot.List(snapshotDir())
+	var names []string
+	for _, s := range snapshots {
+		names = append(names, s.Name+"\t"+s.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	addFilterFlags(snapshotCreateCmd, &snapshotFilter)
+	snapshotCreateCmd.Flags().BoolVar(&forceSnapshot, "force", false, "Replace an existing snapshot of the same name")
+	snapshotCmd.AddCommand(snapshotCreateCmd, snapshotRestoreCmd, snapshotListCmd, snapshotShowCmd, snapshotDeleteCmd)
+}
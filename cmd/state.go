@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	repoStateShow   string
+	stateDiffOutput string
+)
+
+// stateCmd represents the state command
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Shows and edits the state file of tracked repositories.",
+	Long: `Commands to inspect and repair the state file (~/.fussy-git/repos.json by default), which
+records every tracked repository. They only load the configuration, so they also work when
+the state file is broken.
+
+Saves don't rewrite the state file, but append the entries that changed to a change log next
+to it (repos.json.log), so that parallel clones and several fussy-git processes don't race or
+repeatedly write a large file. Loading replays the log on top of the state file. The log is
+folded into the state file ("compacted") once it is larger than the state file, before the
+state file is edited or backed up, and with 'fussy-git state compact'.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		if appConfig, err = loadConfig(); err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		return checkReadOnly(cmd)
+	},
+}
+
+// stateShowCmd represents the state show command
+var stateShowCmd = &cobra.Command{
+	Use:   "show [--repo <repo>]",
+	Short: "Pretty-prints the state file or a single entry.",
+	Long: `Prints the state as indented JSON, or only the entry of one repository with --repo.
+
+<repo> can be the repository's path, its normalized path (e.g. github.com/spf13/cobra),
+its URL, or its name if that is unambiguous.
+
+Examples:
+  fussy-git state show
+  fussy-git state show --repo github.com/spf13/cobra`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		if repoState, err = state.LoadState(appConfig.StateFilePath); err != nil {
+			return fmt.Errorf("failed to load repository state: %w", err)
+		}
+		var v any = repoState
+		if repoStateShow != "" {
+			idx, err := lookupRepository(repoStateShow)
+			if err != nil {
+				return err
+			}
+			v = repoState.Repositories[idx]
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	},
+}
+
+// stateEditCmd represents the state edit command
+var stateEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edits the state file in $EDITOR with validation.",
+	Long: `Opens a copy of the state file in $VISUAL or $EDITOR (vi if neither is set). When the editor
+exits, the result is validated before it replaces the state file:
+- it must be valid JSON without unknown fields (catches typos in field names),
+- every repository needs a name, an absolute path that no other entry uses, and parseable
+  original and current URLs.
+If validation fails, the problems are listed and you can edit again or give up. The previous
+state file is backed up to the backups directory next to it, and the new one is written
+atomically, so an interrupted edit never leaves a half-written state file behind.
+
+Graphical editors must wait until the file is closed, e.g. EDITOR="code --wait".`,
+	Annotations: mutates,
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Changes saved since the last compaction are only in the change log.
+		if err := state.Compact(appConfig.StateFilePath); err != nil {
+			return err
+		}
+		original, err := os.ReadFile(appConfig.StateFilePath)
+		if os.IsNotExist(err) {
+			original = []byte("{\n  \"repositories\": []\n}\n")
+		} else if err != nil {
+			return fmt.Errorf("failed to read state file: %w", err)
+		}
+
+		tmp, err := os.CreateTemp("", "fussy-git-state-*.json")
+		if err != nil {
+			return fmt.Errorf("failed to create a temporary file to edit: %w", err)
+		}
+		tmpPath := tmp.Name()
+		_, err = tmp.Write(original)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to write %s: %w", tmpPath, err)
+		}
+
+		prompter := newActionPrompter()
+		for {
+			if err := runEditor(tmpPath); err != nil {
+				return fmt.Errorf("%w. Your edits are kept in %s", err, tmpPath)
+			}
+			edited, err := os.ReadFile(tmpPath)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", tmpPath, err)
+			}
+			if bytes.Equal(edited, original) {
+				os.Remove(tmpPath)
+				fmt.Println("No changes made.")
+				return nil
+			}
+
+			newState, problems := validateEditedState(edited)
+			if len(problems) == 0 {
+				_, logErr := os.Stat(state.LogPath(appConfig.StateFilePath))
+				if current, err := os.ReadFile(appConfig.StateFilePath); err == nil && (!bytes.Equal(current, original) || logErr == nil) {
+					return fmt.Errorf("the state file was changed by another command while you were editing it; your edits are kept in %s", tmpPath)
+				}
+				backupDir := filepath.Join(filepath.Dir(appConfig.StateFilePath), "backups")
+				backupPath, err := state.Backup(appConfig.StateFilePath, backupDir, 0)
+				if err != nil {
+					return fmt.Errorf("failed to back up the state file: %w. Your edits are kept in %s", err, tmpPath)
+				}
+				if err := newState.Save(appConfig.StateFilePath); err != nil {
+					return fmt.Errorf("failed to save state: %w. Your edits are kept in %s", err, tmpPath)
+				}
+				os.Remove(tmpPath)
+				fmt.Printf("State saved with %d repositories.\n", len(newState.Repositories))
+				if backupPath != "" {
+					fmt.Printf("The previous state was backed up to %s.\n", backupPath)
+				}
+				return nil
+			}
+
+			fmt.Printf("The edited state is invalid:\n")
+			for _, problem := range problems {
+				fmt.Printf("  - %v\n", problem)
+			}
+			if !prompter.Confirm("Edit again?") {
+				return fmt.Errorf("state file left unchanged; your edits are kept in %s", tmpPath)
+			}
+		}
+	},
+}
+
+// stateCompactCmd represents the state compact command
+var stateCompactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Folds the change log into the state file.",
+	Long: `Writes the state file with all changes recorded in its change log (repos.json.log) and removes
+the log, e.g. before copying the state file to another machine. This happens automatically
+once the log is larger than the state file.`,
+	Annotations: mutates,
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logInfo, err := os.Stat(state.LogPath(appConfig.StateFilePath))
+		if os.IsNotExist(err) {
+			fmt.Println("The state file has no pending changes.")
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read change log: %w", err)
+		}
+		if err := state.Compact(appConfig.StateFilePath); err != nil {
+			return err
+		}
+		fmt.Printf("Folded %s of changes into %s.\n", formatSize(logInfo.Size()), appConfig.StateFilePath)
+		return nil
+	},
+}
+
+// stateDiffCmd represents the state diff command
+var stateDiffCmd = &cobra.Command{
+	Use:   "diff <other-state-file>",
+	Short: "Compares the state with another state file.",
+	Long: `Shows how another state file differs from the current state: repositories only tracked in
+the other file (+), repositories only tracked in the current state (-), and repositories tracked
+in both whose path, URLs, name, path override, tags, groups, pin, lock, notes or metadata differ.
+Use it to review a backup (see the backups directory next to the state file) before restoring
+it, or another machine's state before importing from it.
+
+Repositories are matched by their normalized path (e.g. github.com/spf13/cobra), so the same
+repository is recognized even if FUSSY_GIT_HOME is elsewhere on the other machine, then by
+path and original URL. Timestamps, clone statistics and verification results are ignored.
+
+Examples:
+  fussy-git state diff ~/.fussy-git/backups/repos-20250101-120000.json
+  fussy-git state diff laptop-repos.json --output json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if stateDiffOutput != "text" && stateDiffOutput != "json" {
+			return usageError("invalid --output value '%s': must be 'text' or 'json'", stateDiffOutput)
+		}
+		otherPath := args[0]
+		// LoadState would create a missing file.
+		if _, err := os.Stat(otherPath); err != nil {
+			return fmt.Errorf("failed to read %s: %w", otherPath, err)
+		}
+		other, err := state.LoadState(otherPath)
+		if err != nil {
+			return err
+		}
+		current, err := state.LoadState(appConfig.StateFilePath)
+		if err != nil {
+			return fmt.Errorf("failed to load repository state: %w", err)
+		}
+		diff := state.Compare(current, other)
+
+		if stateDiffOutput == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(diff)
+		}
+		if diff.Empty() {
+			fmt.Printf("%s tracks the same repositories as the current state.\n", otherPath)
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		if len(diff.Added) > 0 {
+			fmt.Fprintf(w, "Only in %s (%d):\n", otherPath, len(diff.Added))
+			for _, repo := range diff.Added {
+				fmt.Fprintf(w, "  + %s\t%s\t%s\n", repo.Name, repo.Path, repo.CurrentURL)
+			}
+		}
+		if len(diff.Removed) > 0 {
+			fmt.Fprintf(w, "Only in the current state (%d):\n", len(diff.Removed))
+			for _, repo := range diff.Removed {
+				fmt.Fprintf(w, "  - %s\t%s\t%s\n", repo.Name, repo.Path, repo.CurrentURL)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if len(diff.Changed) > 0 {
+			fmt.Printf("Changed (%d):\n", len(diff.Changed))
+			for _, entry := range diff.Changed {
+				fmt.Printf("  %s (%s):\n", entry.NormalizedFS, entry.Name)
+				for _, c := range entry.Changes {
+					fmt.Printf("    %s: '%s' -> '%s'\n", c.Field, c.Old, c.New)
+				}
+			}
+		}
+		fmt.Printf("\nState diff summary:\n")
+		fmt.Printf("  Only in %s: %d\n", otherPath, len(diff.Added))
+		fmt.Printf("  Only in the current state: %d\n", len(diff.Removed))
+		fmt.Printf("  Changed: %d\n", len(diff.Changed))
+		return nil
+	},
+}
+
+// validateEditedState parses and validates edited state file contents.
+func validateEditedState(data []byte) (*state.RepoState, []error) {
+	newState, err := state.Parse(data, appConfig.StateFilePath)
+	if err != nil {
+		return nil, []error{err}
+	}
+	return newState, newState.Validate(func(url string) error {
+		_, err := gitutil.ParseGitURL(url)
+		return err
+	})
+}
+
+// runEditor opens path in $VISUAL or $EDITOR (which may contain arguments) and waits for it to exit.
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	fields := strings.Fields(editor)
+	c := exec.Command(fields[0], append(fields[1:], path)...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("editor '%s' failed: %w", editor, err)
+	}
+	return nil
+}
+
+func init() {
+	stateShowCmd.Flags().StringVar(&repoStateShow, "repo", "", "Only print the entry of this repository")
+	stateDiffCmd.Flags().StringVarP(&stateDiffOutput, "output", "o", "text", "Output format: 'text' or 'json'")
+	stateCmd.AddCommand(stateShowCmd, stateEditCmd, stateDiffCmd, stateCompactCmd)
+}
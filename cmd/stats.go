@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/fsutil"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"github.com/jmsnll/fussy-git/internal/trend"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsTop     int
+	statsSort    string
+	statsFilter  filter.Filter
+	statsHistory bool
+)
+
+// statsHistoryWidth is the number of points of the trend lines of 'stats --history'.
+const statsHistoryWidth = 60
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Shows clone times and sizes, and ranks repositories by health.",
+	Long: `Summarizes the tracked repositories: how many there are per domain, and how long cloning
+them took and how much it fetched. Every clone records its duration, the number of objects it
+fetched and their size on disk (about what was transferred, unless objects were borrowed from
+a reference repository or the clone cache); 'fussy-git info' shows them for one repository.
+The content of Git LFS files isn't in the object database; the LFS content a clone downloaded
+is summed up separately, and a warning tells when sizes leave out LFS content that wasn't
+measured, e.g. because git-lfs isn't installed.
+
+The summary is followed by the --top repositories (10 by default) with the slowest clones, or
+the largest ones with --sort size or --sort objects. Repositories added with 'add' or cloned
+before statistics were recorded have none; reclone them to record them.
+
+With --sort health, the summary is followed by the average health score and the least healthy
+repositories instead, with what lowers their score, to prioritize cleanup.
+
+` + healthScoreHelp + `
+
+Use --domain, --owner, --tag and --path-prefix to only include a subset of repositories.
+
+With --history, trend lines show how the number of repositories, their size on disk, and how
+many of them are dirty or behind their upstream developed over time, e.g. to watch checkout
+sprawl grow or shrink over months. 'fussy-git maintenance' and every cycle of
+'fussy-git daemon' record these metrics in stats-history.jsonl next to the state file (one
+JSON object per line), so the history starts when either is run regularly.
+
+Examples:
+  fussy-git stats
+  fussy-git stats --sort size --top 20
+  fussy-git stats --owner work-org
+  fussy-git stats --sort health --top 0
+  fussy-git stats --history`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if statsHistory {
+			if !statsFilter.IsEmpty() || cmd.Flags().Changed("sort") || cmd.Flags().Changed("top") {
+				return usageError("--history covers all repositories and can't be combined with filter flags, --sort or --top")
+			}
+			return printStatsHistory()
+		}
+
+		var less func(a, b *state.CloneStats) bool
+		switch statsSort {
+		case "duration":
+			less = func(a, b *state.CloneStats) bool { return a.Duration > b.Duration }
+		case "size":
+			less = func(a, b *state.CloneStats) bool { return a.Size > b.Size }
+		case "objects":
+			less = func(a, b *state.CloneStats) bool { return a.Objects > b.Objects }
+		case "health":
+		default:
+			return usageError("invalid --sort '%s': must be one of duration, size, objects, health", statsSort)
+		}
+
+		repos := statsFilter.Apply(repoState.Repositories)
+		if len(repos) == 0 {
+			fmt.Println("No repositories match.")
+			return nil
+		}
+
+		byDomain := make(map[string]int)
+		var measured []state.RepositoryEntry
+		var total state.CloneStats
+		unmeasuredLFS := 0 // Repositories using LFS whose LFS content isn't in their statistics
+		for _, repo := range repos {
+			byDomain[repo.Domain]++
+			if s := repo.CloneStats; s != nil {
+				measured = append(measured, repo)
+				total.Duration += s.Duration
+				total.Objects += s.Objects
+				total.Size += s.Size
+				total.LFSSize += s.LFSSize
+			}
+			if repo.LFS && (repo.CloneStats == nil || repo.CloneStats.LFSSize == 0) {
+				unmeasuredLFS++
+			}
+		}
+
+		fmt.Printf("Repositories: %d\n", len(repos))
+		domains := make([]string, 0, len(byDomain))
+		for domain := range byDomain {
+			domains = append(domains, domain)
+		}
+		sort.Strings(domains)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, domain := range domains {
+			fmt.Fprintf(w, "  %s\t%d\n", domain, byDomain[domain])
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if statsSort == "health" {
+			return printHealthRanking(repos)
+		}
+
+		fmt.Printf("\nClone statistics (%d of %d repositories):\n", len(measured), len(repos))
+		if len(measured) == 0 {
+			fmt.Println("  None recorded yet; they are recorded by clone, get and reclone.")
+			return nil
+		}
+		fmt.Printf("  Total clone time: %s\n", formatSeconds(total.Duration))
+		fmt.Printf("  Total size:       %s\n", formatSize(total.Size))
+		fmt.Printf("  Total objects:    %d\n", total.Objects)
+		if total.LFSSize > 0 {
+			fmt.Printf("  LFS content:      %s (not included in the sizes)\n", formatSize(total.LFSSize))
+		}
+		if unmeasuredLFS > 0 {
+			fmt.Printf("  [WARN] %d repositories use Git LFS without recorded LFS content; their actual disk usage is larger than shown.\n", unmeasuredLFS)
+		}
+
+		sort.SliceStable(measured, func(i, j int) bool { return less(measured[i].CloneStats, measured[j].CloneStats) })
+		if statsTop > 0 && len(measured) > statsTop {
+			measured = measured[:statsTop]
+		}
+		title := map[string]string{"duration": "Slowest clones", "size": "Largest clones", "objects": "Clones with the most objects"}[statsSort]
+		fmt.Printf("\n%s:\n", title)
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tDURATION\tSIZE\tOBJECTS\tPATH")
+		fmt.Fprintln(w, "----\t--------\t----\t-------\t----")
+		for _, repo := range measured {
+			s := repo.CloneStats
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", repo.Name, formatSeconds(s.Duration), formatSize(s.Size), s.Objects, repo.Path)
+		}
+		return w.Flush()
+	},
+}
+
+// printHealthRanking prints the average health score of repos and the --top least healthy.
+func printHealthRanking(repos []state.RepositoryEntry) error {
+	health := assessRepositories(repos)
+	sortByHealth(health)
+	total, healthy := 0, 0
+	for _, h := range health {
+		total += h.score
+		if h.score == 100 {
+			healthy++
+		}
+	}
+	fmt.Printf("\nHealth:\n")
+	fmt.Printf("  Average score:  %d\n", total/len(health))
+	fmt.Printf("  Fully healthy:  %d\n", healthy)
+	if statsTop > 0 && len(health) > statsTop {
+		health = health[:statsTop]
+	}
+	fmt.Printf("\nLeast healthy repositories:\n")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tHEALTH\tISSUES\tPATH")
+	fmt.Fprintln(w, "----\t------\t------\t----")
+	for _, h := range health {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", h.entry.Name, h.score, h.summary(), h.entry.Path)
+	}
+	return w.Flush()
+}
+
+// recordStatsSnapshot appends the summary metrics of the repositories that exist to the
+// history of 'stats --history'. behind holds how far each of them is behind its upstream.
+func recordStatsSnapshot(existing []state.RepositoryEntry, behind map[string]int) error {
+	snapshot := trend.Snapshot{Repositories: len(existing)}
+	for _, repo := range existing {
+		if size, err := fsutil.DirSize(repo.Path); err == nil {
+			snapshot.Size += size
+		}
+		if dirty, err := gitutil.IsDirty(repo.Path); err == nil && dirty {
+			snapshot.Dirty++
+		}
+		if behind[repo.Path] > 0 {
+			snapshot.Behind++
+		}
+	}
+	return trend.Append(statsHistoryPath(), snapshot)
+}
+
+// printStatsHistory prints trend lines of the recorded summary metrics.
+func printStatsHistory() error {
+	history, err := trend.Load(statsHistoryPath())
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		fmt.Println("No stats history recorded yet; 'fussy-git maintenance' and 'fussy-git daemon' record it each time they run.")
+		return nil
+	}
+	first, last := history[0], history[len(history)-1]
+	fmt.Printf("Stats history: %d snapshots from %s to %s\n\n", len(history), first.Time.Format(time.DateOnly), last.Time.Format(time.DateOnly))
+
+	sampled := trend.Sample(history, statsHistoryWidth)
+	metrics := []struct {
+		name   string
+		value  func(s trend.Snapshot) int64
+		format func(n int64) string
+	}{
+		{"Repositories", func(s trend.Snapshot) int64 { return int64(s.Repositories) }, formatCount},
+		{"Size on disk", func(s trend.Snapshot) int64 { return s.Size }, formatSize},
+		{"Dirty", func(s trend.Snapshot) int64 { return int64(s.Dirty) }, formatCount},
+		{"Behind upstream", func(s trend.Snapshot) int64 { return int64(s.Behind) }, formatCount},
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METRIC\tTREND\tFIRST\tLAST\tCHANGE")
+	fmt.Fprintln(w, "------\t-----\t-----\t----\t------")
+	for _, m := range metrics {
+		values := make([]int64, len(sampled))
+		for i, s := range sampled {
+			values[i] = m.value(s)
+		}
+		change := m.value(last) - m.value(first)
+		sign := "+"
+		if change < 0 {
+			sign, change = "-", -change
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s%s\n", m.name, trend.Sparkline(values), m.format(m.value(first)), m.format(m.value(last)), sign, m.format(change))
+	}
+	return w.Flush()
+}
+
+// formatCount formats a number of repositories for printStatsHistory.
+func formatCount(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
+func init() {
+	statsCmd.Flags().BoolVar(&statsHistory, "history", false, "Show trend lines of the repository count, size on disk, and dirty and behind repositories over time")
+	statsCmd.Flags().IntVar(&statsTop, "top", 10, "Number of repositories to list (0 lists all)")
+	statsCmd.Flags().StringVar(&statsSort, "sort", "duration", "Order of the list: 'duration', 'size', 'objects' or 'health'")
+	addFilterFlags(statsCmd, &statsFilter)
+}
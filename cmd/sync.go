@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/manifest"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncCheckout bool
+	syncBulk     bulkOptions
+)
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync <manifest>",
+	Short: "Clones the repositories of a manifest that are missing, and checks out their pins.",
+	Long: `Makes this machine match a manifest (see 'fussy-git manifest'): repositories listed in it
+that aren't tracked yet are cloned, in parallel like 'fussy-git clone --batch'. Repositories
+that are already tracked are left alone, as are tracked repositories the manifest doesn't list.
+
+With --checkout, every repository the manifest pins to a branch or commit is also checked out
+at that pin, giving reproducible multi-repo environments:
+  commit=<hash>                 HEAD is detached at the commit
+  branch=<name>                 the branch is checked out (created from origin's if needed)
+  branch=<name> commit=<hash>   the branch is checked out if it points at the commit,
+                                otherwise HEAD is detached at the commit
+Commits and branches that aren't available locally are fetched from origin first.
+Repositories with uncommitted changes or untracked files are never checked out: commit or
+stash the changes first. The pins are checked out several at a time (see --jobs), and a report
+lists the status and duration of every pinned repository at the end, followed by the errors of
+the ones that failed; --output json prints the report as JSON, with everything else on stderr.
+--fail-fast and --continue-on-error apply to the checkouts; failed clones always make sync
+exit with an error. <manifest> can be '-' to read it from stdin.
+
+With --quiet, only one tab-separated line per action is printed: 'cloned <path> <url>' and
+'failed <url> <error>' for the clones (see 'fussy-git clone --quiet'), and
+'checked-out <path> <what was checked out>' and 'failed <url> <error>' for the pins. Nothing
+is printed if the machine already matches the manifest.
+
+Examples:
+  fussy-git sync repos.txt
+  fussy-git sync --checkout platform-release-1.4.txt`,
+	Annotations: writesTree,
+	Args:        cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("jobs") {
+			syncBulk.jobs = appConfig.MaxNetworkJobs
+		}
+		if err := syncBulk.validate(); err != nil {
+			return err
+		}
+		if quietOutput && syncBulk.output == "json" {
+			return usageError("--quiet and --output json can't be used together")
+		}
+		cloneJobs = syncBulk.jobs
+		endQuiet, err := beginQuiet()
+		if err != nil {
+			return err
+		}
+		defer endQuiet()
+		// With JSON output, stdout is reserved for the report of the checkouts.
+		restoreStdout := func() {}
+		if syncBulk.output == "json" {
+			stdout := os.Stdout
+			os.Stdout = os.Stderr
+			restoreStdout = func() { os.Stdout = stdout }
+			defer restoreStdout()
+		}
+		entries, err := manifest.Read(args[0])
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("The manifest lists no repositories. Nothing to do.")
+			return nil
+		}
+
+		var missing []string
+		pinned := 0
+		for _, e := range entries {
+			if _, err := lookupRepository(e.URL); errors.Is(err, errNotTracked) {
+				missing = append(missing, e.URL)
+			}
+			if e.Pinned() {
+				pinned++
+			}
+		}
+
+		var cloneErr error
+		if len(missing) > 0 {
+			fmt.Printf("Cloning %d of %d repositories that aren't tracked yet...\n", len(missing), len(entries))
+			cloneErr = runBatchClone(missing)
+		} else {
+			fmt.Printf("All %d repositories of the manifest are tracked.\n", len(entries))
+		}
+
+		if !syncCheckout {
+			if pinned > 0 {
+				fmt.Printf("\n%d repositories are pinned to a branch or commit; use --checkout to check them out.\n", pinned)
+			}
+			return cloneErr
+		}
+		if pinned == 0 {
+			fmt.Println("\nThe manifest pins no branches or commits; nothing to check out.")
+			return cloneErr
+		}
+
+		fmt.Printf("\nChecking out the pins of %d repositories...\n", pinned)
+		var targets []state.RepositoryEntry
+		var lookupErrs []error
+		var lookupURLs []string
+		pins := map[string]manifest.Entry{}
+		for _, e := range entries {
+			if !e.Pinned() {
+				continue
+			}
+			idx, err := lookupRepository(e.URL)
+			if err != nil {
+				fmt.Printf("[FAIL] %s: %v\n", e.URL, err)
+				reportAction("failed", e.URL, err.Error())
+				lookupURLs, lookupErrs = append(lookupURLs, e.URL), append(lookupErrs, err)
+				continue
+			}
+			repo := repoState.Repositories[idx]
+			targets = append(targets, repo)
+			pins[repo.Path] = e
+		}
+		report := runBulk("sync", targets, &syncBulk, func(repo state.RepositoryEntry, stdout, stderr io.Writer) (int, error) {
+			e := pins[repo.Path]
+			done, err := checkoutPin(repo, e)
+			switch {
+			case err != nil:
+				reportAction("failed", e.URL, err.Error())
+				return bulkExitCode(err), err
+			case done != "":
+				fmt.Fprintf(stdout, "[OK]   %s: %s\n", repo.Name, done)
+				reportAction("checked-out", repo.Path, done)
+			default:
+				fmt.Fprintf(stdout, "[SKIP] %s: already at %s\n", repo.Name, describePin(e))
+			}
+			return 0, nil
+		})
+		for i, url := range lookupURLs {
+			report.addFailure(url, "", lookupErrs[i])
+		}
+
+		restoreStdout()
+		if err := report.print(&syncBulk); err != nil {
+			return err
+		}
+		return cloneErr
+	},
+}
+
+// checkoutPin checks out the branch or commit e pins the repository to, see the sync command.
+// It returns what was checked out, or "" if the repository already was at the pin.
+func checkoutPin(repo state.RepositoryEntry, e manifest.Entry) (string, error) {
+	if dirty, err := gitutil.IsDirty(repo.Path); err != nil {
+		return "", err
+	} else if dirty {
+		return "", fmt.Errorf("the working tree has uncommitted changes or untracked files; commit or stash them first")
+	}
+
+	// Fetch once if anything the pin refers to isn't available locally.
+	resolve := func(rev string) (string, bool) { return gitutil.ResolveCommit(repo.Path, rev) }
+	available := func() bool {
+		if e.Commit != "" {
+			if _, ok := resolve(e.Commit); !ok {
+				return false
+			}
+		}
+		if e.Branch != "" && !gitutil.BranchExists(repo.Path, e.Branch) {
+			if _, ok := resolve("refs/remotes/origin/" + e.Branch); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	if !available() {
+		if err := gitutil.Fetch(repo.Path, verbose); err != nil {
+			return "", fmt.Errorf("failed to fetch the pinned revision: %w", err)
+		}
+		if !available() {
+			return "", fmt.Errorf("%s doesn't exist in the repository or on origin", describePin(e))
+		}
+	}
+
+	branchCommit := ""
+	if e.Branch != "" {
+		if gitutil.BranchExists(repo.Path, e.Branch) {
+			branchCommit, _ = resolve("refs/heads/" + e.Branch)
+		} else {
+			branchCommit, _ = resolve("refs/remotes/origin/" + e.Branch)
+		}
+	}
+	head, _ := gitutil.HeadCommit(repo.Path)
+	current, _ := gitutil.CurrentBranch(repo.Path)
+
+	checkout := func(ref string, detach bool, done string) (string, error) {
+		if err := gitutil.Checkout(repo.Path, ref, detach); err != nil {
+			return "", err
+		}
+		return done, nil
+	}
+	if e.Commit != "" {
+		commit, _ := resolve(e.Commit)
+		if e.Branch != "" && branchCommit == commit {
+			if current == e.Branch && head == commit {
+				return "", nil
+			}
+			return checkout(e.Branch, false, "checked out "+describePin(e))
+		}
+		if current == "" && head == commit {
+			return "", nil
+		}
+		done := "detached HEAD at " + describePin(manifest.Entry{Commit: commit})
+		if e.Branch != "" {
+			done += fmt.Sprintf(" (%s has moved on)", e.Branch)
+		}
+		return checkout(commit, true, done)
+	}
+	if current == e.Branch {
+		return "", nil
+	}
+	return checkout(e.Branch, false, "checked out "+describePin(e))
+}
+
+// describePin formats the pins of e for messages, e.g. "main at 40b5bc1".
+func describePin(e manifest.Entry) string {
+	commit := e.Commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	switch {
+	case e.Branch != "" && commit != "":
+		return fmt.Sprintf("%s at %s", e.Branch, commit)
+	case commit != "":
+		return "commit " + commit
+	default:
+		return "branch " + e.Branch
+	}
+}
+
+func init() {
+	syncCmd.Flags().BoolVar(&syncCheckout, "checkout", false, "Check out the branch or commit each repository is pinned to in the manifest")
+	addQuietFlag(syncCmd)
+	addBulkFlags(syncCmd, &syncBulk, 0)
+	syncCmd.Flags().Lookup("jobs").Usage = "Number of repositories to clone or check out in parallel (default: the max_network_jobs setting, 4)"
+}
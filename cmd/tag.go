@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var tagSelection bulkSelection
+
+// tagCmd represents the tag command
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Adds and removes the tags of repositories.",
+	Long: `Tags are free-form labels used to group and filter repositories: every command that accepts
+filters takes --tag <tag>. Tags are stored in the state file and shown by 'fussy-git info'. They
+are compared ignoring case and must not contain ',' or whitespace.
+
+` + bulkSelectionHelp + `
+
+<repo> can be the repository's path, its normalized path (e.g. github.com/spf13/cobra),
+its URL, or its name if that is unambiguous.
+
+Examples:
+  fussy-git tag add work github.com/work-org/api github.com/work-org/auth
+  fussy-git tag add work --domain github.corp.com
+  fussy-git tag remove archived --owner old-org --dry-run
+  fussy-git tag list`,
+}
+
+// tagAddCmd represents the tag add command
+var tagAddCmd = &cobra.Command{
+	Use:               "add <tag> [<repo>...]",
+	Short:             "Adds a tag to repositories.",
+	Annotations:       mutates,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeTagArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changeTags(cmd, args[0], args[1:], true)
+	},
+}
+
+// tagRemoveCmd represents the tag remove command
+var tagRemoveCmd = &cobra.Command{
+	Use:               "remove <tag> [<repo>...]",
+	Short:             "Removes a tag from repositories.",
+	Annotations:       mutates,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeTagArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changeTags(cmd, args[0], args[1:], false)
+	},
+}
+
+// tagListCmd represents the tag list command
+var tagListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists all tags with their number of repositories.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		counts := tagCounts()
+		if len(counts) == 0 {
+			fmt.Println("No repositories are tagged. Tag some with 'fussy-git tag add <tag> <repo>...'.")
+			return nil
+		}
+		tags := make([]string, 0, len(counts))
+		for tag := range counts {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TAG\tREPOSITORIES")
+		fmt.Fprintln(w, "---\t------------")
+		for _, tag := range tags {
+			fmt.Fprintf(w, "%s\t%d\n", tag, counts[tag])
+		}
+		return w.Flush()
+	},
+}
+
+// changeTags adds the tag to, or removes it from, the repositories refs refers to or the
+// selection flags select.
+func changeTags(cmd *cobra.Command, tag string, refs []string, add bool) error {
+	if err := state.ValidateTag(tag); err != nil {
+		return usageError("invalid tag: %v", err)
+	}
+	indices, err := tagSelection.indices(cmd, refs)
+	if err != nil {
+		return err
+	}
+	return tagSelection.apply("Tag", indices, func(entry *state.RepositoryEntry) string {
+		switch {
+		case add && !entry.HasTag(tag):
+			entry.Tags = append(entry.Tags, tag)
+			sort.Strings(entry.Tags)
+			return "tag +" + tag
+		case !add && entry.HasTag(tag):
+			kept := entry.Tags[:0]
+			for _, t := range entry.Tags {
+				if !strings.EqualFold(t, tag) {
+					kept = append(kept, t)
+				}
+			}
+			entry.Tags = kept
+			if len(entry.Tags) == 0 {
+				entry.Tags = nil
+			}
+			return "tag -" + tag
+		}
+		return ""
+	})
+}
+
+// tagCounts returns how many repositories carry each tag, spelled as most repositories do.
+func tagCounts() map[string]int {
+	counts := map[string]int{}
+	spelling := map[string]string{} // Lowercase tag -> first spelling seen
+	for _, repo := range repoState.Repositories {
+		for _, tag := range repo.Tags {
+			key := strings.ToLower(tag)
+			if _, ok := spelling[key]; !ok {
+				spelling[key] = tag
+			}
+			counts[spelling[key]]++
+		}
+	}
+	return counts
+}
+
+// completeTagArgs completes the tags in use for the first argument and repositories after it.
+func completeTagArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return completeRepository(cmd, nil, toComplete)
+	}
+	if repoState == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var tags []string
+	for tag := range tagCounts() {
+		if strings.HasPrefix(tag, toComplete) {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	tagCmd.AddCommand(tagAddCmd, tagRemoveCmd, tagListCmd)
+	for _, c := range []*cobra.Command{tagAddCmd, tagRemoveCmd} {
+		addBulkSelectionFlags(c, &tagSelection)
+	}
+}
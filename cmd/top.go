@@ -0,0 +1,442 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	intervalTop time.Duration
+	noFetchTop  bool
+	sortTop     string
+	topFilter   filter.Filter
+)
+
+// Fetch states of a repository shown by top.
+const (
+	fetchQueued   = "queued"
+	fetchRunning  = "fetching"
+	fetchDone     = "fetched"
+	fetchFailed   = "failed"
+	fetchSkipped  = "-"
+	fetchNotFound = "missing"
+)
+
+// topRow is the live status of one repository.
+type topRow struct {
+	entry      state.RepositoryEntry
+	fetch      string
+	fetchedAt  time.Time
+	branch     string
+	dirty      bool
+	ahead      int
+	behind     int
+	noUpstream bool
+	problem    string // Why the repository couldn't be inspected; empty if it could
+}
+
+// topView holds the rows shown by top and the progress of the background fetch pass.
+type topView struct {
+	mu       sync.Mutex
+	rows     []*topRow
+	fetching bool
+	fetched  int
+	failed   int
+	toFetch  int
+}
+
+// topCmd represents the top command
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Shows a continuously refreshing status view of all repositories.",
+	Long: `Shows a live view of all tracked repositories, similar to htop: the checked-out branch,
+whether the working tree has uncommitted changes, how many commits the branch is ahead of and
+behind its upstream, and the fetch status of the repository. A fetch pass over all repositories
+runs in the background (within the configured network limits), and every repository's row is
+updated as soon as its fetch finishes. The local status of all repositories is rescanned every
+--interval.
+
+Keys:
+  q      quit (Ctrl-C works as well)
+  r      rescan now
+  s      cycle the sort order (name, behind, dirty)
+
+If standard output isn't a terminal, the fetch pass is run to completion and the status table
+is printed once, so the command can be used in scripts.
+
+Use --domain, --owner, --tag, --path-prefix, --meta and --group to show only a subset of
+repositories.
+
+Examples:
+  fussy-git top
+  fussy-git top --no-fetch --interval 5s --owner work-org`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !slices.Contains(topSortOrders, sortTop) {
+			return fmt.Errorf("invalid --sort '%s': must be one of %s", sortTop, strings.Join(topSortOrders, ", "))
+		}
+		if intervalTop < 100*time.Millisecond {
+			return fmt.Errorf("--interval must be at least 100ms, got %s", intervalTop)
+		}
+		repos := topFilter.Apply(repoState.Repositories)
+		if len(repos) == 0 {
+			fmt.Println("No repositories to show.")
+			return nil
+		}
+
+		view := &topView{}
+		for _, repo := range repos {
+			row := &topRow{entry: repo, fetch: fetchSkipped}
+			if !noFetchTop {
+				row.fetch = fetchQueued
+				view.toFetch++
+			}
+			view.rows = append(view.rows, row)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if !isTerminal(os.Stdout) {
+			view.inspectAll()
+			if !noFetchTop {
+				view.fetchAll(ctx, func() {})
+			}
+			view.render(os.Stdout, sortTop, 0, 0)
+			return nil
+		}
+		return runTopScreen(ctx, view)
+	},
+}
+
+// topSortOrders are the values of --sort, in the order the 's' key cycles through them.
+var topSortOrders = []string{"name", "behind", "dirty"}
+
+// runTopScreen draws view on the alternate screen of the terminal until the user quits or ctx
+// is cancelled, redrawing it whenever a fetch finishes and rescanning every intervalTop.
+func runTopScreen(ctx context.Context, view *topView) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Switch to the alternate screen and hide the cursor; both are restored on exit.
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	keys := make(chan byte)
+	if isTerminal(os.Stdin) && stty("-icanon", "-echo", "min", "1") == nil {
+		defer stty("icanon", "echo")
+		go func() {
+			buf := make([]byte, 1)
+			for {
+				if n, err := os.Stdin.Read(buf); err != nil || n == 0 {
+					return
+				}
+				select {
+				case keys <- buf[0]:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	redraw := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case redraw <- struct{}{}:
+		default:
+		}
+	}
+	if !noFetchTop {
+		go view.fetchAll(ctx, notify)
+	}
+
+	order := sortTop
+	draw := func() {
+		rows, columns := terminalSize()
+		var buf bytes.Buffer
+		buf.WriteString("\x1b[H")
+		view.render(&buf, order, rows, columns)
+		buf.WriteString("\x1b[J")
+		os.Stdout.Write(buf.Bytes())
+	}
+
+	view.inspectAll()
+	draw()
+	ticker := time.NewTicker(intervalTop)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-redraw:
+			draw()
+		case <-ticker.C:
+			view.inspectAll()
+			draw()
+		case key := <-keys:
+			switch key {
+			case 'q', 'Q':
+				return nil
+			case 'r', 'R':
+				view.inspectAll()
+				draw()
+			case 's', 'S':
+				for i, o := range topSortOrders {
+					if o == order {
+						order = topSortOrders[(i+1)%len(topSortOrders)]
+						break
+					}
+				}
+				draw()
+			}
+		}
+	}
+}
+
+// fetchAll fetches every queued repository within the configured network limits, updating its
+// row and calling notify after each fetch, until all are done or ctx is cancelled.
+func (v *topView) fetchAll(ctx context.Context, notify func()) {
+	v.mu.Lock()
+	v.fetching = true
+	v.mu.Unlock()
+	defer func() {
+		v.mu.Lock()
+		v.fetching = false
+		v.mu.Unlock()
+		notify()
+	}()
+
+	limiter := newNetworkLimiter(0)
+	jobs := make(chan *topRow)
+	var wg sync.WaitGroup
+	for i := 0; i < inspectWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range jobs {
+				v.setFetch(row, fetchRunning)
+				notify()
+				if err := throttledFetch(limiter, row.entry.Path); err != nil {
+					v.setFetch(row, fetchFailed)
+				} else {
+					v.setFetch(row, fetchDone)
+				}
+				v.inspect(row)
+				notify()
+			}
+		}()
+	}
+	for _, row := range v.rows {
+		if !gitutil.IsGitRepository(row.entry.Path) {
+			v.setFetch(row, fetchNotFound)
+			continue
+		}
+		select {
+		case jobs <- row:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func (v *topView) setFetch(row *topRow, status string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	row.fetch = status
+	switch status {
+	case fetchDone:
+		v.fetched++
+		row.fetchedAt = time.Now()
+	case fetchFailed, fetchNotFound:
+		v.failed++
+	}
+}
+
+// inspectAll rescans the local status of all repositories, several at a time.
+func (v *topView) inspectAll() {
+	jobs := make(chan *topRow)
+	var wg sync.WaitGroup
+	for i := 0; i < inspectWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range jobs {
+				v.inspect(row)
+			}
+		}()
+	}
+	for _, row := range v.rows {
+		jobs <- row
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// inspect reads the branch, working tree status and upstream distance of a repository
+// without contacting its remote.
+func (v *topView) inspect(row *topRow) {
+	path := row.entry.Path
+	var branch, problem string
+	var dirty, noUpstream bool
+	var ahead, behind int
+	if !gitutil.IsGitRepository(path) {
+		problem = "not found"
+	} else {
+		branch, _ = gitutil.CurrentBranch(path)
+		var err error
+		if dirty, err = gitutil.IsDirty(path); err != nil {
+			problem = "status failed"
+		}
+		// Without an upstream (or with one that was never fetched) there is nothing to compare.
+		ahead, behind, err = gitutil.AheadBehind(path)
+		noUpstream = err != nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	row.branch, row.dirty, row.ahead, row.behind = branch, dirty, ahead, behind
+	row.noUpstream, row.problem = noUpstream, problem
+}
+
+// render writes the status table, sorted by order. With rows and columns greater than zero,
+// the output is cut to fit a terminal of that size and every line clears the rest of its line.
+func (v *topView) render(out io.Writer, order string, rows, columns int) {
+	v.mu.Lock()
+	sorted := append([]*topRow(nil), v.rows...)
+	dirty, behind := 0, 0
+	for _, row := range sorted {
+		if row.dirty {
+			dirty++
+		}
+		if row.behind > 0 {
+			behind++
+		}
+	}
+	fetchStatus := "not fetching"
+	if v.toFetch > 0 {
+		fetchStatus = fmt.Sprintf("fetched %d/%d, %d failed", v.fetched, v.toFetch, v.failed)
+		if !v.fetching {
+			fetchStatus += " (done)"
+		}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		switch order {
+		case "behind":
+			if a.behind != b.behind {
+				return a.behind > b.behind
+			}
+		case "dirty":
+			if a.dirty != b.dirty {
+				return a.dirty
+			}
+		}
+		return strings.ToLower(a.entry.Name) < strings.ToLower(b.entry.Name)
+	})
+
+	var table bytes.Buffer
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tBRANCH\tAHEAD\tBEHIND\tTREE\tFETCH\tPATH")
+	for _, row := range sorted {
+		branch, ahead, behindCol, tree := row.branch, strconv.Itoa(row.ahead), strconv.Itoa(row.behind), "clean"
+		if branch == "" {
+			branch = "(detached)"
+		}
+		if row.noUpstream {
+			ahead, behindCol = "-", "-"
+		}
+		if row.dirty {
+			tree = "dirty"
+		}
+		if row.problem != "" {
+			branch, ahead, behindCol, tree = "-", "-", "-", row.problem
+		}
+		fetch := row.fetch
+		if fetch == fetchDone {
+			fetch = "fetched " + row.fetchedAt.Format("15:04:05")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", row.entry.Name, branch, ahead, behindCol, tree, fetch, row.entry.Path)
+	}
+	w.Flush()
+	v.mu.Unlock()
+
+	lines := []string{
+		fmt.Sprintf("fussy-git top - %s - %d repositories, %d dirty, %d behind - %s",
+			time.Now().Format("15:04:05"), len(sorted), dirty, behind, fetchStatus),
+	}
+	if rows > 0 {
+		lines = append(lines, fmt.Sprintf("Sorted by %s. q: quit  r: rescan  s: sort", order))
+	}
+	lines = append(lines, "")
+	lines = append(lines, strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")...)
+
+	// Leave the last line empty: writing a newline on it would scroll the screen.
+	if rows > 1 && len(lines) > rows-1 {
+		hidden := len(lines) - rows + 2
+		lines = append(lines[:rows-2], fmt.Sprintf("... %d more repositories", hidden))
+	}
+	for _, line := range lines {
+		if columns > 0 {
+			if runes := []rune(line); len(runes) > columns-1 {
+				line = string(runes[:columns-1])
+			}
+			line += "\x1b[K"
+		}
+		fmt.Fprintf(out, "%s\n", line)
+	}
+}
+
+// terminalSize returns the number of rows and columns of the terminal connected to stdin,
+// falling back to $LINES and $COLUMNS and then to 24x80.
+func terminalSize() (rows, columns int) {
+	rows, columns = 24, 80
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdin
+	if out, err := cmd.Output(); err == nil {
+		if fields := strings.Fields(string(out)); len(fields) == 2 {
+			r, errRows := strconv.Atoi(fields[0])
+			c, errColumns := strconv.Atoi(fields[1])
+			if errRows == nil && errColumns == nil && r > 0 && c > 0 {
+				return r, c
+			}
+		}
+	}
+	if r, err := strconv.Atoi(os.Getenv("LINES")); err == nil && r > 0 {
+		rows = r
+	}
+	if c, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && c > 0 {
+		columns = c
+	}
+	return rows, columns
+}
+
+func init() {
+	topCmd.Flags().DurationVar(&intervalTop, "interval", 2*time.Second, "Time between rescans of the local status")
+	topCmd.Flags().BoolVar(&noFetchTop, "no-fetch", false, "Don't fetch; show the status as of the last fetch")
+	topCmd.Flags().StringVar(&sortTop, "sort", "name", "Initial sort order: 'name', 'behind' (most behind first) or 'dirty' (dirty first)")
+	addFilterFlags(topCmd, &topFilter)
+}
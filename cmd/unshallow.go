@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	unshallowAll    bool
+	unshallowFilter filter.Filter
+)
+
+// unshallowCmd represents the unshallow command
+var unshallowCmd = &cobra.Command{
+	Use:   "unshallow [--all|<repo>]",
+	Short: "Converts shallow clones into clones with the full history.",
+	Long: `Fetches the complete history of shallow clones (e.g. made with 'fussy-git clone --depth 1' or
+'git clone --depth'), which is needed for history-heavy work like 'git bisect', 'git blame' or
+'git log' beyond the truncated history. Shallow clones only track their default branch; the
+other branches of origin are fetched as well.
+
+Whether a repository is shallow is recorded in the state when it is cloned or added, along
+with the --depth or --shallow-since it was cloned with, and 'doctor' mentions shallow
+repositories. The command checks the repository itself, so the
+record is corrected if it was unshallowed by other means.
+
+Pass a repository, or --all for every shallow repository (optionally narrowed with --domain,
+--owner, --tag and --path-prefix). <repo> can be the repository's path, its normalized path
+(e.g. github.com/spf13/cobra), its URL, or its name if that is unambiguous.
+
+Examples:
+  fussy-git unshallow github.com/torvalds/linux
+  fussy-git unshallow --all --owner golang`,
+	Annotations: writesTree,
+	Args:        cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if unshallowAll == (len(args) == 1) {
+			return fmt.Errorf("specify either a repository or --all")
+		}
+
+		var indices []int
+		if unshallowAll {
+			for _, repo := range unshallowFilter.Apply(repoState.Repositories) {
+				if idx, err := lookupRepository(repo.Path); err == nil {
+					indices = append(indices, idx)
+				}
+			}
+		} else {
+			idx, err := lookupRepository(args[0])
+			if err != nil {
+				return err
+			}
+			indices = append(indices, idx)
+		}
+
+		unshallowed, failed, changed := 0, 0, false
+		for _, idx := range indices {
+			entry := &repoState.Repositories[idx]
+			if !gitutil.IsGitRepository(entry.Path) {
+				if !unshallowAll {
+					return fmt.Errorf("%s is not a git repository", entry.Path)
+				}
+				continue
+			}
+			if !gitutil.IsShallow(entry.Path) {
+				if forgetShallowClone(entry) {
+					changed = true
+				}
+				if !unshallowAll {
+					fmt.Printf("%s already has its full history.\n", entry.Path)
+				}
+				continue
+			}
+			fmt.Printf("Fetching the full history of %s...\n", entry.Path)
+			if err := gitutil.Unshallow(entry.Path, verbose); err != nil {
+				fmt.Printf("[FAIL] %s: %v\n", entry.Path, err)
+				failed++
+				continue
+			}
+			fmt.Printf("[OK] %s\n", entry.Path)
+			forgetShallowClone(entry)
+			changed = true
+			unshallowed++
+		}
+
+		if changed {
+			if err := repoState.Save(appConfig.StateFilePath); err != nil {
+				return fmt.Errorf("failed to save state: %w", err)
+			}
+		}
+		if unshallowAll {
+			fmt.Printf("\nUnshallow summary:\n")
+			fmt.Printf("  Unshallowed: %d\n", unshallowed)
+			fmt.Printf("  Failed:      %d\n", failed)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d repositories could not be unshallowed", failed)
+		}
+		return nil
+	},
+}
+
+// forgetShallowClone records that the repository of entry has its full history, and reports
+// whether the entry changed.
+func forgetShallowClone(entry *state.RepositoryEntry) bool {
+	changed := entry.Shallow
+	entry.Shallow = false
+	if o := entry.CloneOptions; o != nil && (o.Depth > 0 || o.ShallowSince != "") {
+		o.Depth, o.ShallowSince, changed = 0, "", true
+		if o.IsZero() {
+			entry.CloneOptions = nil
+		}
+	}
+	return changed
+}
+
+func init() {
+	unshallowCmd.Flags().BoolVar(&unshallowAll, "all", false, "Unshallow every shallow repository")
+	addFilterFlags(unshallowCmd, &unshallowFilter)
+}
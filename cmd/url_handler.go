@@ -0,0 +1,364 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/notify"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var urlHandlerUninstall bool
+
+const (
+	urlHandlerScheme    = "fussy-git"                                 // Scheme of the links the handler opens
+	urlHandlerDesktopID = "fussy-git-url-handler.desktop"             // Name of the desktop entry on Linux
+	urlHandlerAppName   = "fussy-git URL Handler.app"                 // Name of the application bundle on macOS
+	urlHandlerBundleID  = "io.github.jmsnll.fussy-git.url-handler"    // Bundle identifier of that application
+	urlHandlerRegistry  = `HKCU\Software\Classes\` + urlHandlerScheme // Registry key of the scheme on Windows
+)
+
+// installURLHandlerCmd represents the install-url-handler command
+var installURLHandlerCmd = &cobra.Command{
+	Use:   "install-url-handler",
+	Short: "Registers fussy-git as the handler of fussy-git:// links.",
+	Long: `Registers fussy-git with your desktop as the handler of fussy-git://clone?url=<url> links, so
+that "Open in fussy-git" browser extensions and bookmarklets can clone the repository you are
+looking at from the web UI of any provider into its conventional location. Browsers ask
+before they open such a link. The clone uses the configured defaults, like 'fussy-git clone
+<url>', except that the bootstrap commands aren't run: any web page can open such a link, and
+they would run on whatever it chose to clone. The result is shown as a desktop notification.
+Repositories that are already cloned are left alone, and links to local paths are refused.
+
+A bookmarklet that clones the repository of the current page (browser URLs such as
+https://github.com/spf13/cobra/tree/main/doc are cleaned up like for 'clone'):
+  javascript:location.href='fussy-git://clone?url='+encodeURIComponent(location.href)
+
+Where the handler is registered:
+  Linux    a desktop entry in ~/.local/share/applications, made the default handler with
+           xdg-mime
+  macOS    a small application in ~/Applications ("fussy-git URL Handler"), registered
+           with Launch Services
+  Windows  the fussy-git scheme in the registry of the current user (HKCU\Software\Classes)
+The handler runs the fussy-git executable at its current location; run the command again
+after moving it. Use --uninstall to remove the handler.
+
+Examples:
+  fussy-git install-url-handler
+  fussy-git install-url-handler --uninstall`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if urlHandlerUninstall {
+			if err := uninstallURLHandler(); err != nil {
+				return err
+			}
+			fmt.Printf("Removed the handler of %s:// links.\n", urlHandlerScheme)
+			return nil
+		}
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to determine the fussy-git executable: %w", err)
+		}
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		if err := installURLHandler(exe); err != nil {
+			return err
+		}
+		fmt.Printf("\nfussy-git now opens %s://clone?url=<url> links.\n", urlHandlerScheme)
+		return nil
+	},
+}
+
+// handleURLCmd represents the handle-url command, which the registered handler runs.
+var handleURLCmd = &cobra.Command{
+	Use:   "handle-url <fussy-git://clone?url=...>",
+	Short: "Opens a fussy-git:// link (run by the handler 'install-url-handler' registers).",
+	Long: `Clones the repository of a fussy-git://clone?url=<url> link like 'fussy-git clone <url>',
+without running the bootstrap commands, and reports the result as a desktop notification. This is what the handler registered with
+'fussy-git install-url-handler' runs when a browser opens such a link.`,
+	Hidden:      true,
+	Annotations: writesTree,
+	Args:        cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repoURL, err := parseHandlerURL(args[0])
+		if err != nil {
+			notifyHandlerResult("fussy-git: link not opened", err.Error())
+			return usageError("%v", err)
+		}
+		job, err := prepareClone(repoURL, "", nil)
+		if err == nil && job.alreadyTracked {
+			fmt.Printf("Repository %s already cloned at %s.\n", job.parsed.RepoName, job.target)
+			notifyHandlerResult("fussy-git: already cloned", fmt.Sprintf("%s is at %s", job.parsed.RepoName, job.target))
+			return nil
+		}
+		if err == nil {
+			err = applyCloneOptions(job)
+		}
+		if err == nil {
+			// Any web page can open a link, so the bootstrap commands never run on what it
+			// chose to clone.
+			job.noBootstrap = true
+			err = cloneAndRegister(job, false)
+		}
+		if err != nil {
+			notifyHandlerResult("fussy-git: clone failed", fmt.Sprintf("%s: %v", repoURL, err))
+			return err
+		}
+		message := fmt.Sprintf("%s is at %s", job.parsed.RepoName, job.target)
+		if len(repoSettings(job.parsed).Bootstrap) > 0 {
+			message += "; the bootstrap commands weren't run, review the repository and run them yourself"
+		}
+		notifyHandlerResult("fussy-git: cloned", message)
+		return nil
+	},
+}
+
+// parseHandlerURL returns the repository URL of a fussy-git://clone?url=<url> link. Links to
+// local repositories and git's transport helper syntax (<transport>::<address>) are refused:
+// the link may come from any web page.
+func parseHandlerURL(link string) (string, error) {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != urlHandlerScheme {
+		return "", fmt.Errorf("'%s' is not a %s:// link", link, urlHandlerScheme)
+	}
+	action := u.Host
+	if action == "" {
+		action = u.Opaque // fussy-git:clone?url=...
+	}
+	if action = strings.Trim(action+u.Path, "/"); action != "clone" {
+		return "", fmt.Errorf("unsupported %s:// link '%s': only %s://clone?url=<url> is supported", urlHandlerScheme, link, urlHandlerScheme)
+	}
+	repoURL := strings.TrimSpace(u.Query().Get("url"))
+	if repoURL == "" {
+		return "", fmt.Errorf("the link '%s' has no url parameter", link)
+	}
+	if strings.HasPrefix(repoURL, "-") || strings.Contains(repoURL, "::") {
+		return "", fmt.Errorf("'%s' is not a repository URL", repoURL)
+	}
+	parsed, err := parseRepoURL(gitutil.SanitizeURL(expandShortcut(repoURL)))
+	if err != nil {
+		return "", fmt.Errorf("invalid repository URL '%s': %w", repoURL, err)
+	}
+	if parsed.IsLocal() {
+		return "", fmt.Errorf("'%s' is a local repository, which links can't clone", repoURL)
+	}
+	return repoURL, nil
+}
+
+// notifyHandlerResult shows the result of opening a link as a desktop notification: there is
+// no terminal to print it to.
+func notifyHandlerResult(title, message string) {
+	if err := notify.Desktop(title, message); err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "[WARN] %v\n", err)
+	}
+}
+
+// installURLHandler registers exe as the handler of fussy-git:// links on this platform.
+func installURLHandler(exe string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return installURLHandlerDarwin(exe)
+	case "windows":
+		return installURLHandlerWindows(exe)
+	case "linux", "freebsd", "openbsd":
+		return installURLHandlerXDG(exe)
+	default:
+		return fmt.Errorf("registering a URL handler is not supported on %s", runtime.GOOS)
+	}
+}
+
+// uninstallURLHandler removes what installURLHandler registered.
+func uninstallURLHandler() error {
+	switch runtime.GOOS {
+	case "darwin":
+		app, err := urlHandlerAppPath()
+		if err != nil {
+			return err
+		}
+		// Launch Services forgets applications that are gone; unregister it right away anyway.
+		runHandlerTool(lsregister, "-u", app)
+		return os.RemoveAll(app)
+	case "windows":
+		if err := runHandlerTool("reg", "delete", urlHandlerRegistry, "/f"); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", urlHandlerRegistry, err)
+		}
+		return nil
+	case "linux", "freebsd", "openbsd":
+		dir, err := applicationsDir()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(filepath.Join(dir, urlHandlerDesktopID)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		runHandlerTool("update-desktop-database", dir)
+		return nil
+	default:
+		return fmt.Errorf("registering a URL handler is not supported on %s", runtime.GOOS)
+	}
+}
+
+// installURLHandlerXDG writes a desktop entry for the handler and makes it the default
+// handler of the scheme with xdg-mime.
+func installURLHandlerXDG(exe string) error {
+	dir, err := applicationsDir()
+	if err != nil {
+		return err
+	}
+	entry := "[Desktop Entry]\n" +
+		"Type=Application\n" +
+		"Name=fussy-git\n" +
+		"Comment=Clone repositories opened from fussy-git:// links\n" +
+		"Exec=" + desktopExecQuote(exe) + " handle-url %u\n" +
+		"Terminal=false\n" +
+		"NoDisplay=true\n" +
+		"MimeType=x-scheme-handler/" + urlHandlerScheme + ";\n"
+	if err := writeShellFile(filepath.Join(dir, urlHandlerDesktopID), entry); err != nil {
+		return err
+	}
+	runHandlerTool("update-desktop-database", dir)
+	if err := runHandlerTool("xdg-mime", "default", urlHandlerDesktopID, "x-scheme-handler/"+urlHandlerScheme); err != nil {
+		return fmt.Errorf("failed to make fussy-git the default handler of %s:// links (%w); select '%s' for them in your desktop's settings", urlHandlerScheme, err, urlHandlerDesktopID)
+	}
+	fmt.Printf("  Made %s the default handler of %s:// links\n", urlHandlerDesktopID, urlHandlerScheme)
+	return nil
+}
+
+// applicationsDir returns the directory of the user's desktop entries.
+func applicationsDir() (string, error) {
+	dataDir := os.Getenv("XDG_DATA_HOME")
+	if dataDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine the home directory: %w", err)
+		}
+		dataDir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataDir, "applications"), nil
+}
+
+// desktopExecQuote quotes an argument of the Exec key of a desktop entry: within double
+// quotes, ", `, $ and \ are escaped with a backslash, which is escaped again by the file
+// format.
+func desktopExecQuote(arg string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range arg {
+		switch r {
+		case '"', '`', '$':
+			b.WriteString(`\\`)
+		case '\\':
+			b.WriteString(`\\\`)
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// lsregister registers applications with Launch Services on macOS.
+const lsregister = "/System/Library/Frameworks/CoreServices.framework/Frameworks/LaunchServices.framework/Support/lsregister"
+
+// installURLHandlerDarwin builds a small AppleScript application that passes the links it is
+// opened with to fussy-git, declares the scheme in its Info.plist and registers it.
+func installURLHandlerDarwin(exe string) error {
+	app, err := urlHandlerAppPath()
+	if err != nil {
+		return err
+	}
+	script := "on open location theURL\n" +
+		"\tdo shell script quoted form of " + appleScriptQuote(exe) + " & \" handle-url \" & quoted form of theURL\n" +
+		"end open location\n"
+	scriptFile, err := os.CreateTemp("", "fussy-git-url-handler-*.applescript")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(scriptFile.Name())
+	if _, err := scriptFile.WriteString(script); err != nil {
+		scriptFile.Close()
+		return err
+	}
+	if err := scriptFile.Close(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(app), 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(app), err)
+	}
+	if err := os.RemoveAll(app); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", app, err)
+	}
+	if err := runHandlerTool("osacompile", "-o", app, scriptFile.Name()); err != nil {
+		return fmt.Errorf("failed to build %s: %w", app, err)
+	}
+	plist := filepath.Join(app, "Contents", "Info.plist")
+	for _, args := range [][]string{
+		{"-replace", "CFBundleIdentifier", "-string", urlHandlerBundleID},
+		{"-replace", "CFBundleURLTypes", "-json", `[{"CFBundleURLName":"fussy-git link","CFBundleURLSchemes":["` + urlHandlerScheme + `"]}]`},
+		{"-replace", "LSUIElement", "-bool", "YES"}, // No Dock icon while a link is handled
+	} {
+		if err := runHandlerTool("plutil", append(args, plist)...); err != nil {
+			return fmt.Errorf("failed to update %s: %w", plist, err)
+		}
+	}
+	fmt.Printf("  Built %s\n", app)
+	if err := runHandlerTool(lsregister, "-f", app); err != nil {
+		return fmt.Errorf("failed to register %s with Launch Services: %w", app, err)
+	}
+	fmt.Printf("  Registered it as the handler of %s:// links\n", urlHandlerScheme)
+	return nil
+}
+
+// urlHandlerAppPath returns where the handler application goes on macOS.
+func urlHandlerAppPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine the home directory: %w", err)
+	}
+	return filepath.Join(home, "Applications", urlHandlerAppName), nil
+}
+
+// appleScriptQuote returns s as an AppleScript string literal.
+func appleScriptQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// installURLHandlerWindows declares the scheme in the registry of the current user, with
+// fussy-git as the command that opens it.
+func installURLHandlerWindows(exe string) error {
+	command := fmt.Sprintf(`"%s" handle-url "%%1"`, exe)
+	for _, args := range [][]string{
+		{"add", urlHandlerRegistry, "/ve", "/d", "URL:fussy-git link", "/f"},
+		{"add", urlHandlerRegistry, "/v", "URL Protocol", "/d", "", "/f"},
+		{"add", urlHandlerRegistry + `\shell\open\command`, "/ve", "/d", command, "/f"},
+	} {
+		if err := runHandlerTool("reg", args...); err != nil {
+			return fmt.Errorf("failed to register the %s scheme: %w", urlHandlerScheme, err)
+		}
+	}
+	fmt.Printf("  Registered %s\n", urlHandlerRegistry)
+	return nil
+}
+
+// runHandlerTool runs a platform tool used to register the handler, returning an error
+// including its output if it fails.
+func runHandlerTool(name string, args ...string) error {
+	if verbose {
+		fmt.Printf("Executing: %s %s\n", name, strings.Join(args, " "))
+	}
+	if out, err := exec.Command(name, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", filepath.Base(name), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func init() {
+	installURLHandlerCmd.Flags().BoolVar(&urlHandlerUninstall, "uninstall", false, "Remove the handler instead of registering it")
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/repourl"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"os"
+	"path"
+	"strings"
+)
+
+// urlCache caches the user's git url.insteadOf rules and 'ssh -G' lookups for the duration of
+// a command; the dashboard and metrics exporter parse URLs concurrently.
+var urlCache = &repourl.Cache{}
+
+// urlResolver returns the resolver of repository URLs for the loaded configuration, logging
+// the normalizations it applies with --verbose.
+func urlResolver() *repourl.Resolver {
+	return &repourl.Resolver{
+		Config: appConfig,
+		Cache:  urlCache,
+		Logf: func(format string, args ...any) {
+			if verbose {
+				fmt.Printf(format+"\n", args...)
+			}
+		},
+		Warnf: func(format string, args ...any) { fmt.Fprintf(os.Stderr, "Warning: "+format+"\n", args...) },
+	}
+}
+
+// parseRepoURL parses a repository URL and applies the user's configured normalizations,
+// so that paths and comparisons are based on the URL's effective meaning rather than
+// how it happens to be spelled (see repourl.Resolver.Parse). Commands should use it instead
+// of gitutil.ParseGitURL.
+func parseRepoURL(rawURL string) (*gitutil.ParsedGitURL, error) {
+	return urlResolver().Parse(rawURL)
+}
+
+// rewriteURL applies the user's url.insteadOf rules to rawURL, as git would before using it.
+func rewriteURL(rawURL string) string {
+	return urlResolver().Rewrite(rawURL)
+}
+
+// expandShortcut expands host shortcuts such as gh:spf13/cobra using the host_shortcuts setting.
+// Shortcuts the user already defined as git url.insteadOf rules are left for git to rewrite.
+func expandShortcut(rawURL string) string {
+	return urlResolver().ExpandShortcut(rawURL)
+}
+
+// isPrivateHost reports whether domain matches one of the private_hosts patterns. Private hosts
+// are only accessed over SSH, and fussy-git never sends them HTTPS requests for metadata.
+func isPrivateHost(domain string) bool {
+	if appConfig == nil {
+		return false
+	}
+	for _, pattern := range appConfig.PrivateHosts {
+		if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(domain)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveSSHHost maps an SSH Host alias (e.g. "work-gh" from ~/.ssh/config) to the real
+// hostname, using the explicit ssh_host_aliases mapping first and 'ssh -G' if enabled.
+func resolveSSHHost(host string) string {
+	return urlResolver().ResolveSSHHost(host)
+}
+
+// repoWebURL returns the browser URL of a repository's remote, e.g. https://github.com/spf13/cobra,
+// or an empty string if it can't be derived from the repository's current URL.
+func repoWebURL(entry state.RepositoryEntry) string {
+	parsed, err := parseRepoURL(entry.CurrentURL)
+	if err != nil {
+		return ""
+	}
+	httpsURL, err := parsed.ToHTTPS()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(httpsURL, ".git")
+}
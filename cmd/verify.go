@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyJobs             int
+	verifyConnectivityOnly bool
+	verifyFilter           filter.Filter
+)
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Checks the object database of all tracked repositories with git fsck.",
+	Long: `Runs 'git fsck' in every tracked repository to find corrupt or missing objects, e.g. after a
+disk failure, an interrupted copy or a broken alternates reference. Up to --jobs repositories
+are checked at the same time. A full check reads every object and can take a while for large
+repositories; --connectivity-only only checks that all objects reachable from the refs are
+present, which is much faster and catches most damage.
+
+The result of each check is recorded in the state, and 'fussy-git doctor' lists repositories
+whose last verification found problems until a later 'verify' finds them intact. Repositories
+whose path doesn't exist or isn't a git repository are skipped; 'doctor' reports those.
+
+The command exits with status 2 if any repository is corrupt.
+Use --domain, --owner, --tag and --path-prefix to check only a subset of repositories.
+
+Examples:
+  fussy-git verify
+  fussy-git verify --connectivity-only --jobs 8
+  fussy-git verify --owner spf13`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if verifyJobs < 1 {
+			return fmt.Errorf("--jobs must be at least 1, got %d", verifyJobs)
+		}
+		repos := verifyFilter.Apply(repoState.Repositories)
+		if len(repos) == 0 {
+			fmt.Println("No repositories to verify.")
+			return nil
+		}
+		fmt.Printf("Verifying %d repositories...\n", len(repos))
+
+		results := verifyRepositories(repos)
+		verified, corrupt, skipped := 0, 0, 0
+		for i, repo := range repos {
+			result := results[i]
+			if result == nil {
+				fmt.Printf("[SKIP] %s: not a git repository.\n", repo.Path)
+				skipped++
+				continue
+			}
+			if result.Problem != "" {
+				fmt.Printf("[FAIL] %s:\n%s\n", repo.Path, indent(result.Problem, "    "))
+				corrupt++
+			} else {
+				fmt.Printf("[OK] %s\n", repo.Path)
+				verified++
+			}
+			repo.Verification = result
+			if err := repoState.UpdateRepository(repo); err != nil {
+				return err
+			}
+		}
+		if appConfig.ReadOnly {
+			fmt.Println("Read-only mode: the results are not recorded in the state.")
+		} else if err := repoState.Save(appConfig.StateFilePath); err != nil {
+			return fmt.Errorf("failed to save verification results: %w", err)
+		}
+
+		fmt.Printf("\nVerify summary:\n")
+		fmt.Printf("  Intact:  %d\n", verified)
+		fmt.Printf("  Corrupt: %d\n", corrupt)
+		fmt.Printf("  Skipped: %d\n", skipped)
+		if corrupt > 0 {
+			return &exitError{code: exitIssues, err: fmt.Errorf("%d repositories are corrupt", corrupt)}
+		}
+		return nil
+	},
+}
+
+// verifyRepositories runs git fsck in the given repositories, up to --jobs at a time.
+// The result for a repository that doesn't exist or isn't a git repository is nil.
+func verifyRepositories(repos []state.RepositoryEntry) []*state.Verification {
+	results := make([]*state.Verification, len(repos))
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < verifyJobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				if !gitutil.IsGitRepository(repos[i].Path) {
+					continue
+				}
+				result := &state.Verification{CheckedAt: time.Now(), ConnectivityOnly: verifyConnectivityOnly}
+				report, err := gitutil.Fsck(repos[i].Path, verifyConnectivityOnly)
+				if err != nil {
+					report = err.Error()
+				}
+				result.Problem = report
+				results[i] = result
+			}
+		}()
+	}
+	for i := range repos {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+	return results
+}
+
+// indent prefixes every line of s with prefix.
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\n"+prefix)
+}
+
+func init() {
+	verifyCmd.Flags().IntVarP(&verifyJobs, "jobs", "j", runtime.NumCPU(), "Number of repositories to check in parallel")
+	verifyCmd.Flags().BoolVar(&verifyConnectivityOnly, "connectivity-only", false, "Only check that all reachable objects are present, not their contents (faster)")
+	addFilterFlags(verifyCmd, &verifyFilter)
+}
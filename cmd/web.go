@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/fsutil"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+//go:embed web.html
+var webIndexHTML []byte
+
+// inspectWorkers limits how many repositories are inspected concurrently by the dashboard and metrics exporter.
+const inspectWorkers = 8
+
+var (
+	webAddr        string
+	webOpenBrowser bool
+)
+
+// webRepository is the JSON representation of a repository served to the dashboard.
+type webRepository struct {
+	Name         string    `json:"name"`
+	Path         string    `json:"path"`
+	URL          string    `json:"url"`
+	WebURL       string    `json:"web_url,omitempty"`
+	Domain       string    `json:"domain"`
+	Tags         []string  `json:"tags,omitempty"`
+	Pinned       bool      `json:"pinned"`
+	Exists       bool      `json:"exists"`
+	Dirty        bool      `json:"dirty"`
+	DiskUsage    int64     `json:"disk_usage"`
+	LastModified time.Time `json:"last_modified"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// webCmd represents the web command
+var webCmd = &cobra.Command{
+	Use:   "web",
+	Short: "Serves a local web dashboard of all tracked repositories.",
+	Long: `Starts a small web server on the local machine with a dashboard of all tracked
+repositories: a sortable, filterable table showing each repository's location,
+remote, tags, whether its working tree is dirty and how much disk space it uses.
+Each row has buttons to open the repository in your editor or its remote in the browser.
+
+The editor is taken from the 'editor' config setting, falling back to $VISUAL and $EDITOR.
+The state file is re-read on every page load, so changes made by other fussy-git
+commands show up after a refresh.
+
+Because the dashboard can launch programs, it only listens on loopback addresses.
+
+Examples:
+  fussy-git web
+  fussy-git web --addr 127.0.0.1:9000 --open`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _, err := net.SplitHostPort(webAddr)
+		if err != nil {
+			return fmt.Errorf("invalid address '%s': %w", webAddr, err)
+		}
+		if !isLoopbackHost(host) {
+			return fmt.Errorf("refusing to listen on '%s': the dashboard only listens on loopback addresses such as 127.0.0.1", webAddr)
+		}
+
+		listener, err := net.Listen("tcp", webAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", webAddr, err)
+		}
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /{$}", handleWebIndex)
+		mux.HandleFunc("GET /api/repos", handleWebRepositories)
+		mux.HandleFunc("POST /api/open", handleWebOpen)
+
+		url := "http://" + listener.Addr().String()
+		fmt.Printf("Serving the fussy-git dashboard on %s (press Ctrl+C to stop)\n", url)
+		if webOpenBrowser {
+			if err := openInBrowser(url); err != nil {
+				fmt.Printf("[WARN] %v\n", err)
+			}
+		}
+		return http.Serve(listener, requireLocalHost(mux))
+	},
+}
+
+// isLoopbackHost reports whether host names the local machine.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// requireLocalHost rejects requests whose Host header doesn't name the local machine,
+// which protects the dashboard against DNS rebinding from malicious websites.
+func requireLocalHost(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if !isLoopbackHost(host) {
+			http.Error(w, "forbidden host", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func handleWebIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(webIndexHTML)
+}
+
+func handleWebRepositories(w http.ResponseWriter, r *http.Request) {
+	rs, err := loadRepoState()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	repos := make([]webRepository, len(rs.Repositories))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < inspectWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				repos[j] = inspectWebRepository(rs.Repositories[j])
+			}
+		}()
+	}
+	for i := range rs.Repositories {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(repos)
+}
+
+// inspectWebRepository collects the live status of a repository for the dashboard.
+func inspectWebRepository(entry state.RepositoryEntry) webRepository {
+	repo := webRepository{
+		Name:         entry.Name,
+		Path:         entry.Path,
+		URL:          entry.CurrentURL,
+		WebURL:       repoWebURL(entry),
+		Domain:       entry.Domain,
+		Tags:         entry.Tags,
+		Pinned:       entry.Pinned,
+		LastModified: entry.LastModified,
+	}
+
+	if _, err := os.Stat(entry.Path); err != nil {
+		repo.Error = "path does not exist"
+		return repo
+	}
+	repo.Exists = true
+
+	if dirty, err := gitutil.IsDirty(entry.Path); err != nil {
+		repo.Error = "failed to get git status"
+	} else {
+		repo.Dirty = dirty
+	}
+	if size, err := fsutil.DirSize(entry.Path); err == nil {
+		repo.DiskUsage = size
+	}
+	return repo
+}
+
+func handleWebOpen(w http.ResponseWriter, r *http.Request) {
+	// Browsers can't send custom headers cross-origin without a CORS preflight, which we never allow.
+	if r.Header.Get("X-Fussy-Git") == "" {
+		http.Error(w, "missing X-Fussy-Git header", http.StatusForbidden)
+		return
+	}
+
+	rs, err := loadRepoState()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Only ever open repositories that are actually tracked.
+	entry, found := rs.FindRepositoryByPath(filepath.Clean(r.FormValue("path")))
+	if !found {
+		http.Error(w, "repository is not tracked by fussy-git", http.StatusNotFound)
+		return
+	}
+
+	switch r.FormValue("target") {
+	case "editor":
+		err = openInEditor(entry.Path)
+	case "browser":
+		webURL := repoWebURL(*entry)
+		if webURL == "" {
+			http.Error(w, "no web URL known for this repository", http.StatusBadRequest)
+			return
+		}
+		err = openInBrowser(webURL)
+	default:
+		http.Error(w, "target must be 'editor' or 'browser'", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func init() {
+	webCmd.Flags().StringVar(&webAddr, "addr", "127.0.0.1:7420", "Address to listen on (loopback only)")
+	webCmd.Flags().BoolVar(&webOpenBrowser, "open", false, "Open the dashboard in the default browser")
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// whichCmd represents the which command
+var whichCmd = &cobra.Command{
+	Use:   "which <import_path|url|repo>",
+	Short: "Prints the local directory of a repository given its import path or URL.",
+	Long: `Prints the directory of the local checkout for a Go module or import path, a repository URL,
+or anything else 'fussy-git' accepts as a repository reference. Only the path is printed, so
+the command can be used by editor tooling and scripts, e.g. cd "$(fussy-git which golang.org/x/tools)".
+
+Import paths are matched against the module paths recorded by 'fussy-git get' and against
+the repositories' normalized paths, so package paths inside a repository (e.g.
+github.com/spf13/cobra/doc) resolve to the repository containing them.
+
+If the repository isn't tracked, the command exits with status 1 and suggests how to clone it.
+
+Examples:
+  fussy-git which github.com/spf13/cobra/doc
+  fussy-git which golang.org/x/tools
+  fussy-git which git@github.com:spf13/cobra.git`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRepository,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ref := args[0]
+		importPath := normalizeImportPath(ref)
+
+		idx := lookupByModulePath(importPath)
+		if idx < 0 {
+			idx = lookupByNormalizedPrefix(importPath)
+		}
+		if idx < 0 {
+			var err error
+			if idx, err = lookupRepository(ref); err != nil && !errors.Is(err, errNotTracked) {
+				return err // e.g. an ambiguous name
+			}
+		}
+		if idx < 0 {
+			if looksLikeURL(ref) {
+				fmt.Fprintf(os.Stderr, "Not tracked. Clone it with:\n  fussy-git clone %s\n", ref)
+			} else {
+				fmt.Fprintf(os.Stderr, "Not tracked. Clone it with:\n  fussy-git get %s\n", importPath)
+			}
+			return fmt.Errorf("'%s' is not tracked by fussy-git", ref)
+		}
+
+		fmt.Println(repoState.Repositories[idx].Path)
+		return nil
+	},
+}
+
+// lookupByNormalizedPrefix returns the index of the repository whose normalized path
+// (e.g. github.com/spf13/cobra) is importPath or the longest prefix of it, or -1 if there is none.
+func lookupByNormalizedPrefix(importPath string) int {
+	best, bestLen := -1, 0
+	for i, repo := range repoState.Repositories {
+		normalized := filepath.ToSlash(repo.NormalizedFS)
+		if normalized == "" || (importPath != normalized && !strings.HasPrefix(importPath, normalized+"/")) {
+			continue
+		}
+		if len(normalized) > bestLen {
+			best, bestLen = i, len(normalized)
+		}
+	}
+	return best
+}
+
+// looksLikeURL reports whether ref is a clone URL (including SCP-like SSH syntax and host
+// shortcuts) rather than a bare import path.
+func looksLikeURL(ref string) bool {
+	if strings.Contains(ref, "://") {
+		return true
+	}
+	first, _, _ := strings.Cut(ref, "/")
+	return strings.Contains(first, ":")
+}
@@ -0,0 +1,223 @@
+// Package adopt reads the settings and repository lists of other tools that manage Git
+// repositories, so 'fussy-git adopt-config' can translate them into fussy-git's config and state.
+package adopt
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/config"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Tools are the names of the tools whose settings can be adopted.
+var Tools = []string{"ghq", "projectile", "git-workspace"}
+
+// Setting is a fussy-git config setting translated from another tool.
+type Setting struct {
+	Key   string // Key in the fussy-git config file, e.g. "fussy_git_home"
+	Value string // Value as the other tool has it, e.g. "~/ghq"
+	From  string // Where the value comes from, e.g. "ghq.root"
+}
+
+// Repository is a repository known to another tool.
+type Repository struct {
+	Path string // Absolute location of the work tree
+	URL  string // Clone URL, if the tool records one; "" otherwise
+}
+
+// Result is what fussy-git can take over from another tool.
+type Result struct {
+	Settings     []Setting
+	Repositories []Repository
+	Notes        []string // Settings of the tool that have no fussy-git equivalent
+}
+
+// Read reads the settings and repositories of tool. source overrides where they are read from:
+// the projectile bookmarks file or the git-workspace directory. For ghq, it is a root to use
+// instead of the configured ones. If source is "", the tool's own defaults are used.
+func Read(tool, source string) (*Result, error) {
+	switch tool {
+	case "ghq":
+		roots := ghqRoots()
+		if source != "" {
+			roots = []string{source}
+		}
+		return readGhq(roots)
+	case "projectile":
+		return readProjectile(source)
+	case "git-workspace":
+		return readGitWorkspace(source)
+	}
+	return nil, fmt.Errorf("unknown tool '%s': must be one of %s", tool, strings.Join(Tools, ", "))
+}
+
+// ghqRoots returns the roots ghq clones into, like ghq determines them: $GHQ_ROOT, the
+// 'ghq.root' git config values, or ~/ghq. The first root is the one ghq clones into.
+func ghqRoots() []string {
+	if env := os.Getenv("GHQ_ROOT"); env != "" {
+		return filepath.SplitList(env)
+	}
+	if roots := gitutil.GlobalConfigValues("ghq.root"); len(roots) > 0 {
+		return roots
+	}
+	return []string{"~/ghq"}
+}
+
+// readGhq translates ghq's roots. ghq clones to <root>/<host>/<owner>/<repo>, which is
+// fussy-git's domain layout, so its first root becomes FUSSY_GIT_HOME and every repository
+// below the roots is adopted where it is.
+func readGhq(roots []string) (*Result, error) {
+	result := &Result{Settings: []Setting{
+		{Key: "fussy_git_home", Value: roots[0], From: "ghq.root"},
+		{Key: "layout", Value: "domain", From: "ghq's <root>/<host>/<owner>/<repo> structure"},
+	}}
+	for i, root := range roots {
+		absRoot, err := config.ExpandPath(root)
+		if err != nil {
+			return nil, err
+		}
+		repoPaths, err := gitutil.FindRepositories(absRoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ghq root %s: %w", absRoot, err)
+		}
+		for _, repoPath := range repoPaths {
+			result.Repositories = append(result.Repositories, Repository{Path: repoPath})
+		}
+		if i > 0 {
+			result.Notes = append(result.Notes, fmt.Sprintf("ghq root %s: fussy-git has a single home; its repositories are adopted where they are", root))
+		}
+	}
+	return result, nil
+}
+
+// projectileBookmarks are the locations of projectile's list of known projects, relative to
+// the home directory.
+var projectileBookmarks = []string{
+	".emacs.d/projectile-bookmarks.eld",
+	".config/emacs/projectile-bookmarks.eld",
+}
+
+// elispString matches a string literal in an Emacs Lisp file.
+var elispString = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+
+// readProjectile reads the known projects of projectile (an Emacs package) from its bookmarks
+// file, a Lisp list of directories. Projects that aren't Git repositories are left to the
+// caller to skip.
+func readProjectile(path string) (*Result, error) {
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("could not get user home directory: %w", err)
+		}
+		for _, candidate := range projectileBookmarks {
+			if _, err := os.Stat(filepath.Join(home, candidate)); err == nil {
+				path = filepath.Join(home, candidate)
+				break
+			}
+		}
+		if path == "" {
+			return nil, fmt.Errorf("no projectile bookmarks file found (looked for ~/%s); name it as the source", strings.Join(projectileBookmarks, " and ~/"))
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read projectile bookmarks %s: %w", path, err)
+	}
+
+	result := &Result{}
+	for _, match := range elispString.FindAllStringSubmatch(string(data), -1) {
+		dir := strings.NewReplacer(`\\`, `\`, `\"`, `"`).Replace(match[1])
+		absDir, err := config.ExpandPath(dir)
+		if err != nil {
+			return nil, err
+		}
+		result.Repositories = append(result.Repositories, Repository{Path: absDir})
+	}
+	return result, nil
+}
+
+// gitWorkspaceLock is the lockfile of git-workspace, listing the repositories of a workspace.
+type gitWorkspaceLock struct {
+	Repo []struct {
+		Path string // Relative to the workspace directory
+		URL  string
+	}
+}
+
+// gitWorkspaceConfig is the configuration of a git-workspace workspace.
+type gitWorkspaceConfig struct {
+	Provider []struct {
+		Provider string // github, gitlab or gitea
+		Name     string // User, organisation or group
+		Path     string // Directory of its repositories in the workspace
+	}
+}
+
+// readGitWorkspace reads the repositories of a git-workspace workspace from its lockfile,
+// workspace-lock.toml, or by scanning the workspace if there is none. The providers of
+// workspace.toml, which git-workspace queries for the repositories of whole users and
+// organisations, have no equivalent and are reported as notes.
+func readGitWorkspace(dir string) (*Result, error) {
+	if dir == "" {
+		dir = os.Getenv("GIT_WORKSPACE")
+		if dir == "" {
+			return nil, fmt.Errorf("GIT_WORKSPACE is not set; name the workspace directory as the source")
+		}
+	}
+	absDir, err := config.ExpandPath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	var workspace gitWorkspaceConfig
+	if err := readTOML(filepath.Join(absDir, "workspace.toml"), &workspace); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, p := range workspace.Provider {
+		result.Notes = append(result.Notes, fmt.Sprintf("provider %s %s (in %s/): fussy-git doesn't follow whole users or organisations; the repositories cloned so far are adopted", p.Provider, p.Name, p.Path))
+	}
+
+	var lock gitWorkspaceLock
+	err = readTOML(filepath.Join(absDir, "workspace-lock.toml"), &lock)
+	if os.IsNotExist(err) {
+		repoPaths, err := gitutil.FindRepositories(absDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan workspace %s: %w", absDir, err)
+		}
+		for _, repoPath := range repoPaths {
+			result.Repositories = append(result.Repositories, Repository{Path: repoPath})
+		}
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, repo := range lock.Repo {
+		result.Repositories = append(result.Repositories, Repository{Path: filepath.Join(absDir, filepath.FromSlash(repo.Path)), URL: repo.URL})
+	}
+	return result, nil
+}
+
+// readTOML decodes the TOML file at path into out. A missing file is reported with an error
+// for which os.IsNotExist is true.
+func readTOML(path string, out any) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := v.Unmarshal(out); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileName is the name of the audit log, which lives next to the state file.
+const FileName = "audit.log"
+
+// Event is a single entry in the audit log. The log is a file of JSON objects, one per line,
+// so it can be inspected with tools like jq.
+type Event struct {
+	Time    time.Time      `json:"time"`
+	Action  string         `json:"action"`         // What happened, e.g. "daemon.cycle"
+	Repo    string         `json:"repo,omitempty"` // Path of the repository concerned, if any
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// Append writes e to the audit log at path, creating the file if needed.
+// If e.Time is zero, the current time is used.
+func Append(path string, e Event) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory for audit log %s: %w", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log %s: %w", path, err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Providers are the supported API flavours of git hosts.
+var Providers = []string{"github", "gitlab", "gitea", "bitbucket"}
+
+// requiredScopes lists, per provider, the token scopes of which at least one is needed to
+// read private repositories through the API.
+var requiredScopes = map[string][]string{
+	"github":    {"repo"},
+	"gitlab":    {"api", "read_api"},
+	"bitbucket": {"repository", "repository:write", "repository:admin"},
+}
+
+// client is used for API requests. An unreachable host shouldn't hang the command.
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// DetectProvider guesses the provider of a domain from its name, e.g. "gitlab" for
+// gitlab.example.com. It returns "" if the domain gives no hint.
+func DetectProvider(domain string) string {
+	domain = strings.ToLower(domain)
+	switch {
+	case domain == "github.com" || strings.HasPrefix(domain, "github."):
+		return "github"
+	case domain == "bitbucket.org":
+		return "bitbucket"
+	case strings.Contains(domain, "gitlab"):
+		return "gitlab"
+	case domain == "codeberg.org" || strings.Contains(domain, "gitea") || strings.Contains(domain, "forgejo"):
+		return "gitea"
+	}
+	return ""
+}
+
+// TokenInfo is what a provider reports about a token.
+type TokenInfo struct {
+	User string
+	// Scopes granted to the token. Nil if the provider doesn't report them, e.g. for
+	// GitHub fine-grained tokens, whose permissions are per repository.
+	Scopes []string
+	// Required lists the scopes of which the token needs at least one; empty if unknown.
+	Required []string
+}
+
+// Sufficient reports whether the token has one of the required scopes. It is true when
+// the scopes can't be checked.
+func (t *TokenInfo) Sufficient() bool {
+	if t.Scopes == nil || len(t.Required) == 0 {
+		return true
+	}
+	for _, scope := range t.Required {
+		if slices.Contains(t.Scopes, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckToken authenticates with the API of domain using token and returns the user it
+// belongs to and its scopes.
+func CheckToken(domain, provider, token string) (*TokenInfo, error) {
+	info := &TokenInfo{Required: requiredScopes[provider]}
+	switch provider {
+	case "github":
+		base := "https://api.github.com"
+		if domain != "github.com" {
+			base = "https://" + domain + "/api/v3" // GitHub Enterprise Server
+		}
+		var user struct {
+			Login string `json:"login"`
+		}
+		header, err := getJSON(base+"/user", "Bearer "+token, &user)
+		if err != nil {
+			return nil, err
+		}
+		info.User = user.Login
+		if scopes := header.Values("X-OAuth-Scopes"); scopes != nil {
+			info.Scopes = splitScopes(strings.Join(scopes, ","))
+		}
+	case "gitlab":
+		base := "https://" + domain + "/api/v4"
+		var user struct {
+			Username string `json:"username"`
+		}
+		if _, err := getJSON(base+"/user", "Bearer "+token, &user); err != nil {
+			return nil, err
+		}
+		info.User = user.Username
+		var self struct {
+			Scopes []string `json:"scopes"`
+		}
+		// Only personal, group and project access tokens can describe themselves.
+		if _, err := getJSON(base+"/personal_access_tokens/self", "Bearer "+token, &self); err == nil {
+			info.Scopes = self.Scopes
+		}
+	case "gitea":
+		var user struct {
+			Login string `json:"login"`
+		}
+		if _, err := getJSON("https://"+domain+"/api/v1/user", "token "+token, &user); err != nil {
+			return nil, err
+		}
+		info.User = user.Login
+	case "bitbucket":
+		var user struct {
+			Username string `json:"username"`
+		}
+		header, err := getJSON("https://api.bitbucket.org/2.0/user", "Bearer "+token, &user)
+		if err != nil {
+			return nil, err
+		}
+		info.User = user.Username
+		if scopes := header.Values("X-OAuth-Scopes"); scopes != nil {
+			info.Scopes = splitScopes(strings.Join(scopes, ","))
+		}
+	default:
+		return nil, fmt.Errorf("unknown provider '%s' (supported: %s)", provider, strings.Join(Providers, ", "))
+	}
+	return info, nil
+}
+
+// getJSON sends an authenticated GET request and decodes the JSON response into v.
+func getJSON(url, authorization string, v any) (http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, fmt.Errorf("%s rejected the token (HTTP %s); it may be invalid, expired or revoked", url, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("request to %s failed with HTTP %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("failed to parse response from %s: %w", url, err)
+	}
+	return resp.Header, nil
+}
+
+// splitScopes parses a comma-separated scope header such as "repo, read:org".
+func splitScopes(header string) []string {
+	scopes := []string{}
+	for _, scope := range strings.Split(header, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
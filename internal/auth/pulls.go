@@ -0,0 +1,342 @@
+package auth
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CI states of a pull request, summarized over all its checks.
+const (
+	CISuccess = "success"
+	CIFailure = "failure"
+	CIPending = "pending"
+)
+
+// PullRequest is an open pull request (a merge request on GitLab).
+type PullRequest struct {
+	Repo      string    `json:"repo"` // Path of the repository on its host, e.g. "spf13/cobra"
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	Author    string    `json:"author"`
+	Draft     bool      `json:"draft,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// CI is CISuccess, CIFailure or CIPending, or "" if no checks ran for the request.
+	CI string `json:"ci,omitempty"`
+}
+
+// OpenPullRequests returns the open pull requests on domain that user authored or is
+// assigned to, across all repositories the token can see. Bitbucket has no assignees, so
+// only authored pull requests are returned for it. At most 100 requests of each kind are
+// returned.
+func OpenPullRequests(domain, provider, token, user string) ([]PullRequest, error) {
+	var prs []PullRequest
+	var err error
+	switch provider {
+	case "github":
+		prs, err = githubPullRequests(domain, token, user)
+	case "gitlab":
+		prs, err = gitlabMergeRequests(domain, token)
+	case "gitea":
+		prs, err = giteaPullRequests(domain, token)
+	case "bitbucket":
+		prs, err = bitbucketPullRequests(token, user)
+	default:
+		return nil, fmt.Errorf("unknown provider '%s' (supported: %s)", provider, strings.Join(Providers, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A request can be both authored by and assigned to the user.
+	seen := make(map[string]bool)
+	unique := prs[:0]
+	for _, pr := range prs {
+		key := fmt.Sprintf("%s#%d", strings.ToLower(pr.Repo), pr.Number)
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, pr)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool { return unique[i].CreatedAt.Before(unique[j].CreatedAt) })
+	return unique, nil
+}
+
+// githubPullRequests searches the pull requests of user and looks up the checks of each.
+func githubPullRequests(domain, token, user string) ([]PullRequest, error) {
+	base := "https://api.github.com"
+	if domain != "github.com" {
+		base = "https://" + domain + "/api/v3" // GitHub Enterprise Server
+	}
+	authorization := "Bearer " + token
+	var prs []PullRequest
+	for _, qualifier := range []string{"author", "assignee"} {
+		query := url.QueryEscape(fmt.Sprintf("is:pr is:open archived:false %s:%s", qualifier, user))
+		var result struct {
+			Items []struct {
+				Number        int       `json:"number"`
+				Title         string    `json:"title"`
+				HTMLURL       string    `json:"html_url"`
+				Draft         bool      `json:"draft"`
+				CreatedAt     time.Time `json:"created_at"`
+				RepositoryURL string    `json:"repository_url"`
+				User          struct {
+					Login string `json:"login"`
+				} `json:"user"`
+			} `json:"items"`
+		}
+		if _, err := getJSON(base+"/search/issues?per_page=100&q="+query, authorization, &result); err != nil {
+			return nil, err
+		}
+		for _, item := range result.Items {
+			prs = append(prs, PullRequest{
+				Repo:      strings.TrimPrefix(item.RepositoryURL, base+"/repos/"),
+				Number:    item.Number,
+				Title:     item.Title,
+				URL:       item.HTMLURL,
+				Author:    item.User.Login,
+				Draft:     item.Draft,
+				CreatedAt: item.CreatedAt,
+			})
+		}
+	}
+	for i := range prs {
+		prs[i].CI = githubChecks(base, authorization, &prs[i])
+	}
+	return prs, nil
+}
+
+// githubChecks summarizes the commit statuses and check runs of the head commit of pr.
+// Failures to look them up leave the CI state unknown.
+func githubChecks(base, authorization string, pr *PullRequest) string {
+	repoURL := base + "/repos/" + pr.Repo
+	var details struct {
+		Head struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	}
+	if _, err := getJSON(fmt.Sprintf("%s/pulls/%d", repoURL, pr.Number), authorization, &details); err != nil {
+		return ""
+	}
+	var states []string
+	var status struct {
+		State      string `json:"state"`
+		TotalCount int    `json:"total_count"`
+	}
+	if _, err := getJSON(repoURL+"/commits/"+details.Head.SHA+"/status", authorization, &status); err == nil && status.TotalCount > 0 {
+		states = append(states, status.State)
+	}
+	var checks struct {
+		CheckRuns []struct {
+			Status     string `json:"status"`
+			Conclusion string `json:"conclusion"`
+		} `json:"check_runs"`
+	}
+	if _, err := getJSON(repoURL+"/commits/"+details.Head.SHA+"/check-runs?per_page=100", authorization, &checks); err == nil {
+		for _, run := range checks.CheckRuns {
+			switch {
+			case run.Status != "completed":
+				states = append(states, CIPending)
+			case run.Conclusion == "success" || run.Conclusion == "neutral" || run.Conclusion == "skipped":
+				states = append(states, CISuccess)
+			default:
+				states = append(states, CIFailure)
+			}
+		}
+	}
+	return summarizeCI(states, map[string]string{"success": CISuccess, "pending": CIPending, "failure": CIFailure, "error": CIFailure})
+}
+
+// gitlabMergeRequests lists the merge requests the token's user created or is assigned to,
+// with the state of their head pipelines.
+func gitlabMergeRequests(domain, token string) ([]PullRequest, error) {
+	base := "https://" + domain + "/api/v4"
+	authorization := "Bearer " + token
+	type mergeRequest struct {
+		IID       int       `json:"iid"`
+		ProjectID int       `json:"project_id"`
+		Title     string    `json:"title"`
+		WebURL    string    `json:"web_url"`
+		Draft     bool      `json:"draft"`
+		CreatedAt time.Time `json:"created_at"`
+		Author    struct {
+			Username string `json:"username"`
+		} `json:"author"`
+		References struct {
+			Full string `json:"full"` // e.g. "group/project!7"
+		} `json:"references"`
+	}
+	var prs []PullRequest
+	for _, scope := range []string{"created_by_me", "assigned_to_me"} {
+		var mrs []mergeRequest
+		if _, err := getJSON(base+"/merge_requests?state=opened&per_page=100&scope="+scope, authorization, &mrs); err != nil {
+			return nil, err
+		}
+		for _, mr := range mrs {
+			repo, _, _ := strings.Cut(mr.References.Full, "!")
+			pr := PullRequest{
+				Repo:      repo,
+				Number:    mr.IID,
+				Title:     mr.Title,
+				URL:       mr.WebURL,
+				Author:    mr.Author.Username,
+				Draft:     mr.Draft,
+				CreatedAt: mr.CreatedAt,
+			}
+			// Only single merge requests include their head pipeline.
+			var details struct {
+				HeadPipeline *struct {
+					Status string `json:"status"`
+				} `json:"head_pipeline"`
+			}
+			if _, err := getJSON(fmt.Sprintf("%s/projects/%d/merge_requests/%d", base, mr.ProjectID, mr.IID), authorization, &details); err == nil && details.HeadPipeline != nil {
+				pr.CI = summarizeCI([]string{details.HeadPipeline.Status}, map[string]string{
+					"success": CISuccess, "failed": CIFailure, "canceled": CIFailure,
+					"created": CIPending, "waiting_for_resource": CIPending, "preparing": CIPending,
+					"pending": CIPending, "running": CIPending, "scheduled": CIPending, "manual": CIPending,
+				})
+			}
+			prs = append(prs, pr)
+		}
+	}
+	return prs, nil
+}
+
+// giteaPullRequests lists the pull requests the token's user created or is assigned to,
+// with the combined commit status of their heads.
+func giteaPullRequests(domain, token string) ([]PullRequest, error) {
+	base := "https://" + domain + "/api/v1"
+	authorization := "token " + token
+	var prs []PullRequest
+	for _, involvement := range []string{"created", "assigned"} {
+		var issues []struct {
+			Number     int       `json:"number"`
+			Title      string    `json:"title"`
+			HTMLURL    string    `json:"html_url"`
+			CreatedAt  time.Time `json:"created_at"`
+			Repository struct {
+				FullName string `json:"full_name"`
+			} `json:"repository"`
+			User struct {
+				Login string `json:"login"`
+			} `json:"user"`
+			PullRequest *struct {
+				Draft bool `json:"draft"`
+			} `json:"pull_request"`
+		}
+		if _, err := getJSON(base+"/repos/issues/search?type=pulls&state=open&limit=100&"+involvement+"=true", authorization, &issues); err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			pr := PullRequest{
+				Repo:      issue.Repository.FullName,
+				Number:    issue.Number,
+				Title:     issue.Title,
+				URL:       issue.HTMLURL,
+				Author:    issue.User.Login,
+				CreatedAt: issue.CreatedAt,
+			}
+			if issue.PullRequest != nil {
+				pr.Draft = issue.PullRequest.Draft
+			}
+			var details struct {
+				Head struct {
+					SHA string `json:"sha"`
+				} `json:"head"`
+			}
+			var status struct {
+				State      string `json:"state"`
+				TotalCount int    `json:"total_count"`
+			}
+			repoURL := base + "/repos/" + pr.Repo
+			if _, err := getJSON(fmt.Sprintf("%s/pulls/%d", repoURL, pr.Number), authorization, &details); err == nil {
+				if _, err := getJSON(repoURL+"/commits/"+details.Head.SHA+"/status", authorization, &status); err == nil && status.TotalCount > 0 {
+					pr.CI = summarizeCI([]string{status.State}, map[string]string{
+						"success": CISuccess, "pending": CIPending, "failure": CIFailure, "error": CIFailure, "warning": CIFailure,
+					})
+				}
+			}
+			prs = append(prs, pr)
+		}
+	}
+	return prs, nil
+}
+
+// bitbucketPullRequests lists the pull requests user authored, with their build statuses.
+func bitbucketPullRequests(token, user string) ([]PullRequest, error) {
+	base := "https://api.bitbucket.org/2.0"
+	authorization := "Bearer " + token
+	var result struct {
+		Values []struct {
+			ID        int       `json:"id"`
+			Title     string    `json:"title"`
+			Draft     bool      `json:"draft"`
+			CreatedOn time.Time `json:"created_on"`
+			Author    struct {
+				DisplayName string `json:"display_name"`
+			} `json:"author"`
+			Links struct {
+				HTML struct {
+					Href string `json:"href"`
+				} `json:"html"`
+			} `json:"links"`
+			Destination struct {
+				Repository struct {
+					FullName string `json:"full_name"`
+				} `json:"repository"`
+			} `json:"destination"`
+		} `json:"values"`
+	}
+	if _, err := getJSON(base+"/pullrequests/"+url.PathEscape(user)+"?state=OPEN&pagelen=50", authorization, &result); err != nil {
+		return nil, err
+	}
+	var prs []PullRequest
+	for _, v := range result.Values {
+		pr := PullRequest{
+			Repo:      v.Destination.Repository.FullName,
+			Number:    v.ID,
+			Title:     v.Title,
+			URL:       v.Links.HTML.Href,
+			Author:    v.Author.DisplayName,
+			Draft:     v.Draft,
+			CreatedAt: v.CreatedOn,
+		}
+		var statuses struct {
+			Values []struct {
+				State string `json:"state"`
+			} `json:"values"`
+		}
+		if _, err := getJSON(fmt.Sprintf("%s/repositories/%s/pullrequests/%d/statuses", base, pr.Repo, pr.Number), authorization, &statuses); err == nil {
+			var states []string
+			for _, s := range statuses.Values {
+				states = append(states, s.State)
+			}
+			pr.CI = summarizeCI(states, map[string]string{"SUCCESSFUL": CISuccess, "INPROGRESS": CIPending, "FAILED": CIFailure, "STOPPED": CIFailure})
+		}
+		prs = append(prs, pr)
+	}
+	return prs, nil
+}
+
+// summarizeCI maps the provider's states to CI states with mapping and combines them: any
+// failure fails the request, otherwise anything still running leaves it pending. States
+// missing from mapping are ignored.
+func summarizeCI(states []string, mapping map[string]string) string {
+	summary := ""
+	for _, s := range states {
+		switch mapping[s] {
+		case CIFailure:
+			return CIFailure
+		case CIPending:
+			summary = CIPending
+		case CISuccess:
+			if summary == "" {
+				summary = CISuccess
+			}
+		}
+	}
+	return summary
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/keyring"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Credential records the token stored for one domain. The token itself is kept in a keyring.
+type Credential struct {
+	Provider  string    `json:"provider"` // API flavour of the host, see Providers
+	Storage   string    `json:"storage"`  // Name of the keyring holding the token
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Token is only read from credentials files written before tokens were kept in a
+	// keyring; Open moves it into the keyring.
+	Token string `json:"token,omitempty"`
+}
+
+// Store keeps track of the tokens configured per domain. The credentials file only records
+// which domains have a token; the tokens are stored in a keyring.
+type Store struct {
+	path        string
+	ring        keyring.Keyring
+	Credentials map[string]*Credential `json:"credentials"`
+}
+
+// Open reads the credentials file at path, with tokens stored in ring. A missing file is an
+// empty store. Tokens still stored in plaintext in the file are moved into ring.
+func Open(path string, ring keyring.Keyring) (*Store, error) {
+	s := &Store{path: path, ring: ring, Credentials: map[string]*Credential{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file %s: %w", path, err)
+	}
+	if s.Credentials == nil {
+		s.Credentials = map[string]*Credential{}
+	}
+
+	migrated := false
+	for domain, c := range s.Credentials {
+		if c.Token == "" {
+			continue
+		}
+		if err := ring.Set(domain, c.Token); err != nil {
+			return nil, fmt.Errorf("failed to move the plaintext token for %s into the %s: %w", domain, ring.Name(), err)
+		}
+		c.Token, c.Storage = "", ring.Name()
+		migrated = true
+	}
+	if migrated {
+		if err := s.save(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Domains returns the domains that have a token, sorted.
+func (s *Store) Domains() []string {
+	domains := make([]string, 0, len(s.Credentials))
+	for domain := range s.Credentials {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// Lookup returns the credential recorded for domain.
+func (s *Store) Lookup(domain string) (*Credential, bool) {
+	c, ok := s.Credentials[strings.ToLower(domain)]
+	return c, ok
+}
+
+// Token returns the token stored for domain.
+func (s *Store) Token(domain string) (string, error) {
+	domain = strings.ToLower(domain)
+	c, ok := s.Credentials[domain]
+	if !ok {
+		return "", fmt.Errorf("no token stored for %s; run 'fussy-git auth login %s'", domain, domain)
+	}
+	token, err := s.ring.Get(domain)
+	if errors.Is(err, keyring.ErrNotFound) && c.Storage != s.ring.Name() {
+		return "", fmt.Errorf("the token for %s is stored in the %s, but the %s is in use; run 'fussy-git auth login %s' again", domain, c.Storage, s.ring.Name(), domain)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the token for %s from the %s: %w", domain, s.ring.Name(), err)
+	}
+	return token, nil
+}
+
+// Set stores token for domain and returns the name of the keyring it was stored in.
+func (s *Store) Set(domain, provider, token string) (string, error) {
+	domain = strings.ToLower(domain)
+	if err := s.ring.Set(domain, token); err != nil {
+		return "", fmt.Errorf("failed to store the token for %s in the %s: %w", domain, s.ring.Name(), err)
+	}
+	s.Credentials[domain] = &Credential{Provider: provider, Storage: s.ring.Name(), UpdatedAt: time.Now()}
+	if err := s.save(); err != nil {
+		return "", err
+	}
+	return s.ring.Name(), nil
+}
+
+// Delete removes the token of domain from the keyring and the credentials file.
+func (s *Store) Delete(domain string) error {
+	domain = strings.ToLower(domain)
+	if _, ok := s.Credentials[domain]; !ok {
+		return fmt.Errorf("no token stored for %s", domain)
+	}
+	if err := s.ring.Delete(domain); err != nil {
+		return fmt.Errorf("failed to remove the token for %s from the %s: %w", domain, s.ring.Name(), err)
+	}
+	delete(s.Credentials, domain)
+	return s.save()
+}
+
+// save writes the credentials file atomically, readable only by the user.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory for credentials file %s: %w", s.path, err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write credentials file %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace credentials file %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// Redact shortens a token for display, keeping only enough to recognise it.
+// e.g. "ghp_abcdefghijklmnop1234" -> "ghp_…1234"
+func Redact(token string) string {
+	if len(token) <= 8 {
+		return strings.Repeat("*", len(token))
+	}
+	prefix := ""
+	if i := strings.IndexAny(token, "_-"); i > 0 && i <= 8 {
+		prefix = token[:i+1]
+	}
+	return prefix + "…" + token[len(token)-4:]
+}
@@ -2,8 +2,17 @@ package config
 
 import (
 	"fmt"
+	"github.com/jmsnll/fussy-git/internal/layout"
+	"github.com/jmsnll/fussy-git/internal/notify"
+	"github.com/jmsnll/fussy-git/internal/throttle"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 	// It's generally better to use os.MkdirAll which respects umask by default.
@@ -13,14 +22,19 @@ import (
 )
 
 const (
-	defaultFussyGitDirName = "git"             // Default directory name under home for repositories
-	configDirName          = ".fussy-git"      // Directory name for config and state files under home
-	stateFileName          = "repos.json"      // Name of the state file
-	defaultConfigFileType  = "yaml"            // Default config file type
-	defaultConfigFileName  = "config"          // Default config file name (e.g. config.yaml)
-	envFussyGitHome        = "FUSSY_GIT_HOME"  // Environment variable for FUSSY_GIT_HOME
-	configKeyFussyGitHome  = "fussy_git_home"  // Key in config file for FUSSY_GIT_HOME
-	configKeyStateFilePath = "state_file_path" // Key in config file for state file path (can be overridden)
+	defaultFussyGitDirName = "git"                 // Default directory name under home for repositories
+	configDirName          = ".fussy-git"          // Directory name for config and state files under home
+	stateFileName          = "repos.json"          // Name of the state file
+	credentialsFileName    = "credentials.json"    // Name of the file recording provider tokens
+	keyringFileName        = "keyring.enc"         // Name of the encrypted file keyring
+	defaultConfigFileType  = "yaml"                // Default config file type
+	defaultConfigFileName  = "config"              // Default config file name (e.g. config.yaml)
+	envFussyGitHome        = "FUSSY_GIT_HOME"      // Environment variable for FUSSY_GIT_HOME
+	configKeyFussyGitHome  = "fussy_git_home"      // Key in config file for FUSSY_GIT_HOME
+	configKeyStateFilePath = "state_file_path"     // Key in config file for state file path (can be overridden)
+	configKeyLayout        = "layout"              // Key in config file for the directory layout (domain, owner or flat)
+	configKeySSHAliases    = "ssh_host_aliases"    // Key in config file for explicit SSH host alias -> hostname mappings
+	configKeyResolveSSH    = "resolve_ssh_aliases" // Key in config file to resolve SSH host aliases via 'ssh -G'
 
 	// Constants for help messages in Cobra (exported)
 	// These need to be Exported (start with uppercase) to be accessible by other packages.
@@ -29,20 +43,214 @@ const (
 	DefaultConfigFileTypeForHelp = defaultConfigFileType
 )
 
+const (
+	configKeyEditor         = "editor"                  // Key in config file for the command used to open repositories in an editor
+	configKeyMaxNetworkJobs = "max_network_jobs"        // Key in config file for the maximum number of concurrent clones/fetches
+	configKeyBandwidthLimit = "bandwidth_limit"         // Key in config file for the average bandwidth cap of bulk network operations
+	configKeyHostShortcuts  = "host_shortcuts"          // Key in config file for URL shortcut prefix -> domain mappings
+	configKeyPrivateHosts   = "private_hosts"           // Key in config file for hosts that are only accessed over SSH
+	configKeyCloneCache     = "clone_cache"             // Key in config file to enable the local mirror cache for clones
+	configKeyCloneCacheDir  = "clone_cache_dir"         // Key in config file for the directory of the clone cache
+	configKeyDoctorSeverity = "doctor_severities"       // Key in config file for doctor check -> severity overrides
+	configKeyHooksTemplate  = "hooks_template_dir"      // Key in config file for a directory of git hooks installed into every repository
+	configKeyHooksPath      = "hooks_path"              // Key in config file for a shared hooks directory set as core.hooksPath
+	configKeyGitMaintenance = "git_maintenance"         // Key in config file to register new clones for git's background maintenance
+	configKeyReadOnly       = "read_only"               // Key in config file to refuse all operations that change repositories or the state
+	configKeyKeyring        = "keyring"                 // Key in config file for where secrets are stored: auto, system or file
+	configKeyFallback       = "protocol_fallback"       // Key in config file for retrying failed clones with the other protocol
+	configKeySharedState    = "shared_state_file"       // Key in config file for a read-only state file shared by several users
+	configKeySharedGroup    = "shared_group"            // Key in config file for the group that shares FUSSY_GIT_HOME
+	configKeyDirenv         = "direnv"                  // Key in config file to write a .envrc into new clones
+	configKeyEnvrcTemplates = "envrc_templates"         // Key in config file for language -> .envrc template mappings
+	configKeyNotifyDesktop  = "notify_desktop"          // Key in config file to enable desktop notifications
+	configKeyNotifyWebhook  = "notify_webhook"          // Key in config file for a URL notifications are POSTed to
+	configKeyNotifyFormat   = "notify_webhook_format"   // Key in config file for the webhook payload format: json or slack
+	configKeyNotifyBehind   = "notify_behind_threshold" // Key in config file for how far behind upstream a repository must fall to notify
+	configKeyNotifyFinished = "notify_finished_after"   // Key in config file for how long a bulk operation must run to notify when it finishes
+	configKeyURLRefresh     = "url_refresh_after"       // Key in config file for how old a repository's last check may be before list refreshes its URL
+	configKeyLocalDir       = "local_dir"               // Key in config file for the directory repositories cloned from local paths go into
+	configKeyWebhookSecret  = "webhook_secret"          // Key for the secret webhooks are signed with; only read from FUSSY_GIT_WEBHOOK_SECRET
+	configKeyPassAllow      = "passthrough_allow"       // Key in config file for the only git subcommands passed through in managed repositories
+	configKeyPassDeny       = "passthrough_deny"        // Key in config file for git subcommands never passed through in managed repositories
+	configKeyPassConfirm    = "passthrough_confirm"     // Key in config file for git commands passed through only after confirmation
+
+	defaultMaxNetworkJobs = 4
+	defaultNotifyBehind   = 50
+	defaultNotifyFinished = "1m"
+	defaultURLRefresh     = "24h"
+	defaultLocalDir       = "local"
+	cloneCacheDirName     = "cache" // Default clone cache directory name under the config directory
+)
+
+// defaultPassthroughConfirm are the destructive git commands that need confirmation when
+// passed through in a managed repository, unless passthrough_confirm is configured. Each
+// option also matches its other spellings, e.g. "push --force" matches "push -f" and
+// "push origin +main".
+var defaultPassthroughConfirm = []string{
+	"clean -f",
+	"reset --hard",
+	"checkout -f",
+	"push --force",
+	"branch -D",
+	"stash clear",
+}
+
+// defaultHostShortcuts are the URL shortcut prefixes available without any configuration.
+// Entries in host_shortcuts are added to them; mapping a prefix to "" removes it.
+var defaultHostShortcuts = map[string]string{
+	"gh": "github.com",
+	"gl": "gitlab.com",
+	"bb": "bitbucket.org",
+}
+
 // Config stores the application's configuration.
 type Config struct {
 	FussyGitHome  string // Base directory where git repositories will be cloned.
 	StateFilePath string // Path to the JSON file storing repository state.
 	ConfigFile    string // Path to the config file used.
+	Layout        string // Directory layout for repositories below FussyGitHome (see the layout package).
+
+	// SSHHostAliases maps ~/.ssh/config Host aliases (e.g. "work-gh") to the real hostname
+	// ("github.com") used for directory paths. Explicit mappings take precedence over ResolveSSHAliases.
+	SSHHostAliases map[string]string
+	// ResolveSSHAliases enables looking up unknown SSH hosts with 'ssh -G' to find their real hostname.
+	ResolveSSHAliases bool
+	// Editor is the command used to open a repository in an editor, e.g. "code" or "idea".
+	// Falls back to $VISUAL and $EDITOR when empty.
+	Editor string
+
+	// MaxNetworkJobs is the maximum number of clones or fetches run at the same time by bulk operations.
+	MaxNetworkJobs int
+	// BandwidthLimit caps the average transfer rate of bulk network operations in bytes per second;
+	// 0 means unlimited. It is configured as a string such as "2MB/s".
+	BandwidthLimit int64
+	// HostShortcuts maps shortcut prefixes to domains, so that e.g. "gh:spf13/cobra"
+	// stands for https://github.com/spf13/cobra.
+	HostShortcuts map[string]string
+	// PrivateHosts lists domain patterns (path.Match syntax, e.g. "*.corp.example.com") of
+	// internal git hosts. They are only accessed over SSH and never queried for metadata.
+	PrivateHosts []string
+	// CloneCache enables keeping a bare mirror of every cloned repository in CloneCacheDir,
+	// so that later clones of the same repository copy objects from disk.
+	CloneCache bool
+	// CloneCacheDir is the directory holding the mirrors of the clone cache.
+	CloneCacheDir string
+	// DoctorSeverities overrides the severity ("error", "warning" or "info") of doctor checks,
+	// keyed by check ID (e.g. "unconventional-path-manual").
+	DoctorSeverities map[string]string
+	// HooksTemplateDir is a directory of git hooks (e.g. pre-commit, commit-msg) that are copied
+	// into every cloned or added repository. Empty if not configured.
+	HooksTemplateDir string
+	// HooksPath is a shared hooks directory that is set as core.hooksPath in every cloned or added
+	// repository instead of copying hooks. Empty if not configured.
+	HooksPath string
+	// GitMaintenance enables registering every cloned or added repository for git's background
+	// maintenance ('git maintenance start'), and makes doctor check that they are registered.
+	GitMaintenance bool
+	// ReadOnly refuses all operations that change repositories or the state, for auditing a tree;
+	// only timestamps in the state are updated. It is also set by the --read-only flag.
+	ReadOnly bool
+
+	// ProtocolFallback selects which failed clones are retried with the other protocol:
+	// "off", "ssh-to-https", "https-to-ssh" or "both".
+	ProtocolFallback string
+
+	// Layers holds settings that can be overridden per domain, owner and repository
+	// (protocol, layout, bootstrap commands, git identity, SSH command).
+	Layers Layers
+
+	// CredentialsFile records which domains have an API token and where it is stored
+	// (see the auth package).
+	CredentialsFile string
+	// Keyring selects where secrets are stored: "auto" (the OS keyring if available, the
+	// encrypted KeyringFile otherwise), "system" or "file".
+	Keyring string
+	// KeyringFile is the passphrase-encrypted file secrets are stored in without an OS keyring.
+	KeyringFile string
+
+	// SharedStateFile is a read-only state file listing the repositories of a FUSSY_GIT_HOME shared
+	// by several users; StateFilePath then only holds this user's additions and changes.
+	// Empty if not configured.
+	SharedStateFile string
+	// SharedGroup is the group (name or ID) that shares FUSSY_GIT_HOME. If set, directories are
+	// created group-writable and owned by it, and repositories are cloned with
+	// core.sharedRepository=group. Empty if not configured.
+	SharedGroup string
+
+	// Direnv enables writing a .envrc rendered from a template into every new clone and running
+	// 'direnv allow' on it. The template is the layered envrc_template setting, or the entry of
+	// EnvrcTemplates for the language of the repository.
+	Direnv bool
+	// EnvrcTemplates maps languages ("go", "node", "python", "rust", "ruby", "java") to .envrc
+	// template files.
+	EnvrcTemplates map[string]string
+
+	// NotifyDesktop enables desktop notifications from the daemon, maintenance and bulk operations.
+	NotifyDesktop bool
+	// NotifyWebhook is a URL notifications are POSTed to as JSON, e.g. a Slack incoming webhook.
+	// Empty if not configured.
+	NotifyWebhook string
+	// NotifyWebhookFormat is the payload format of NotifyWebhook: "json" or "slack". It defaults
+	// to "slack" for Slack webhooks and "json" otherwise.
+	NotifyWebhookFormat string
+	// NotifyBehindThreshold is how many commits a repository must be behind its upstream for a
+	// notification to be sent.
+	NotifyBehindThreshold int
+	// NotifyFinishedAfter is how long a bulk operation must run for a notification to be sent
+	// when it finishes. 0 disables these notifications.
+	NotifyFinishedAfter time.Duration
+	// URLRefreshAfter is how long ago a repository must have been checked for 'list' and 'info'
+	// to refresh its origin URL from the clone first. 0 disables the refresh.
+	URLRefreshAfter time.Duration
+	// LocalDir is the directory repositories cloned from a local path or file:// URL go into,
+	// relative to FussyGitHome unless it is absolute.
+	LocalDir string
+	// WebhookSecret is the secret GitHub and GitLab webhooks received by 'serve --webhooks' must
+	// be signed with. Like every secret, it is only read from the environment.
+	WebhookSecret string
+
+	// PassthroughAllow lists the only git subcommands (e.g. "status", "log") that are passed
+	// through to git in managed repositories. Empty allows every subcommand.
+	PassthroughAllow []string
+	// PassthroughDeny lists git subcommands that are never passed through to git in managed
+	// repositories.
+	PassthroughDeny []string
+	// PassthroughConfirm lists git commands that are only passed through in managed repositories
+	// after confirmation. Each is a subcommand followed by the options that make it destructive,
+	// e.g. "reset --hard"; it matches if all of them are given.
+	PassthroughConfirm []string
+
+	// ConfigFileFound reports whether ConfigFile existed and was read.
+	ConfigFileFound bool
+	// Settings lists every effective setting with its source, for 'fussy-git env'.
+	Settings []Setting
+}
+
+// Setting is one effective configuration value and where it came from.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string // "default", "config file", "env <VARIABLE>" or "flag --<name>"
+}
+
+// Overrides are settings given on the command line for a single invocation, e.g. to run
+// against a mounted backup. They take precedence over the environment and the config file.
+type Overrides struct {
+	FussyGitHome  string // --home
+	StateFilePath string // --state-file
+	// ReadOnly leaves FUSSY_GIT_HOME and the state file's directory alone if they don't exist,
+	// for readers that must not change anything on disk.
+	ReadOnly bool
 }
 
 // LoadConfig loads the application configuration.
 // It prioritizes:
-// 1. Explicitly passed configFile path (from --config flag).
+// 1. Explicitly passed configFile path (from --config flag) and overrides (from --home and --state-file).
 // 2. Environment variable FUSSY_GIT_HOME.
 // 3. Configuration file (~/.fussy-git/config.yaml).
 // 4. Default values.
-func LoadConfig(configFileFromFlag string) (*Config, error) {
+// Path settings may refer to environment variables ($VAR or ${VAR}) and start with ~.
+func LoadConfig(configFileFromFlag string, overrides Overrides) (*Config, error) {
 	cfg := &Config{}
 
 	// Determine user's home directory
@@ -65,6 +273,19 @@ func LoadConfig(configFileFromFlag string) (*Config, error) {
 	defaultStateFilePath := filepath.Join(defaultConfigDirPath, stateFileName)
 	v.SetDefault(configKeyStateFilePath, defaultStateFilePath)
 
+	// --- Configure Layout ---
+	v.SetDefault(configKeyLayout, layout.Default)
+	v.SetDefault(configKeyMaxNetworkJobs, defaultMaxNetworkJobs)
+	v.SetDefault(configKeyCloneCacheDir, filepath.Join(defaultConfigDirPath, cloneCacheDirName))
+	v.SetDefault(configKeyKeyring, "auto")
+	v.SetDefault(configKeyFallback, "off")
+	v.SetDefault(configKeyNotifyBehind, defaultNotifyBehind)
+	v.SetDefault(configKeyNotifyFinished, defaultNotifyFinished)
+	v.SetDefault(configKeyURLRefresh, defaultURLRefresh)
+	v.SetDefault(configKeyLocalDir, defaultLocalDir)
+	v.SetDefault(configKeyWebhookSecret, "")
+	v.SetDefault(configKeyPassConfirm, defaultPassthroughConfirm)
+
 	// --- Configure Config File ---
 	// This logic is primarily for viper to find and read a config file.
 	// The actual `cfg.ConfigFile` field should reflect what was loaded or attempted.
@@ -94,11 +315,16 @@ func LoadConfig(configFileFromFlag string) (*Config, error) {
 	if err := v.BindEnv(configKeyStateFilePath, "FUSSY_GIT_STATE_FILE_PATH"); err != nil { // Example for state path env var
 		return nil, fmt.Errorf("failed to bind env var FUSSY_GIT_STATE_FILE_PATH: %w", err)
 	}
+	if err := v.BindEnv(configKeyLayout, "FUSSY_GIT_LAYOUT"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var FUSSY_GIT_LAYOUT: %w", err)
+	}
 
 	// Attempt to read the config file.
 	// It's not an error if the config file doesn't exist and no specific file was passed,
 	// defaults will be used.
-	if err := v.ReadInConfig(); err != nil {
+	err = v.ReadInConfig()
+	cfg.ConfigFileFound = err == nil
+	if err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 			// Config file not found. This is okay if no specific file was required by flag.
 			// If configFileFromFlag was set, this means that specific file wasn't found.
@@ -110,8 +336,183 @@ func LoadConfig(configFileFromFlag string) (*Config, error) {
 	}
 
 	// Populate Config struct from Viper (which now has values from defaults, file, or env)
-	cfg.FussyGitHome = v.GetString(configKeyFussyGitHome)
-	cfg.StateFilePath = v.GetString(configKeyStateFilePath)
+	flagSources := map[string]string{}
+	if cfg.FussyGitHome, err = expandConfigPath(v.GetString(configKeyFussyGitHome)); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %s: %w", configKeyFussyGitHome, err)
+	}
+	if overrides.FussyGitHome != "" {
+		if cfg.FussyGitHome, err = ExpandPath(overrides.FussyGitHome); err != nil {
+			return nil, fmt.Errorf("invalid --home: %w", err)
+		}
+		flagSources[configKeyFussyGitHome] = "--home"
+	}
+	if cfg.StateFilePath, err = expandConfigPath(v.GetString(configKeyStateFilePath)); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %s: %w", configKeyStateFilePath, err)
+	}
+	if overrides.StateFilePath != "" {
+		if cfg.StateFilePath, err = ExpandPath(overrides.StateFilePath); err != nil {
+			return nil, fmt.Errorf("invalid --state-file: %w", err)
+		}
+		flagSources[configKeyStateFilePath] = "--state-file"
+	}
+	cfg.CredentialsFile = filepath.Join(defaultConfigDirPath, credentialsFileName)
+	cfg.KeyringFile = filepath.Join(defaultConfigDirPath, keyringFileName)
+	cfg.Layout = v.GetString(configKeyLayout)
+	cfg.SSHHostAliases = v.GetStringMapString(configKeySSHAliases)
+	cfg.ResolveSSHAliases = v.GetBool(configKeyResolveSSH)
+	cfg.Editor = v.GetString(configKeyEditor)
+	cfg.MaxNetworkJobs = v.GetInt(configKeyMaxNetworkJobs)
+	if cfg.MaxNetworkJobs < 1 {
+		return nil, fmt.Errorf("invalid configuration: %s must be at least 1, got %d", configKeyMaxNetworkJobs, cfg.MaxNetworkJobs)
+	}
+	if cfg.BandwidthLimit, err = throttle.ParseRate(v.GetString(configKeyBandwidthLimit)); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %s: %w", configKeyBandwidthLimit, err)
+	}
+	cfg.HostShortcuts = map[string]string{}
+	for prefix, domain := range defaultHostShortcuts {
+		cfg.HostShortcuts[prefix] = domain
+	}
+	for prefix, domain := range v.GetStringMapString(configKeyHostShortcuts) {
+		if domain == "" {
+			delete(cfg.HostShortcuts, prefix)
+			continue
+		}
+		if strings.ContainsAny(prefix, ":/@") {
+			return nil, fmt.Errorf("invalid configuration: %s: prefix '%s' must not contain ':', '/' or '@'", configKeyHostShortcuts, prefix)
+		}
+		cfg.HostShortcuts[prefix] = domain
+	}
+	// Like GOPRIVATE, the environment variable takes a comma-separated list.
+	for _, pattern := range splitList(v.GetStringSlice(configKeyPrivateHosts)) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid configuration: %s: invalid pattern '%s': %w", configKeyPrivateHosts, pattern, err)
+		}
+		cfg.PrivateHosts = append(cfg.PrivateHosts, pattern)
+	}
+	cfg.CloneCache = v.GetBool(configKeyCloneCache)
+	if cfg.CloneCacheDir, err = expandConfigPath(v.GetString(configKeyCloneCacheDir)); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %s: %w", configKeyCloneCacheDir, err)
+	}
+	cfg.DoctorSeverities = v.GetStringMapString(configKeyDoctorSeverity)
+	for check, severity := range cfg.DoctorSeverities {
+		if severity != "error" && severity != "warning" && severity != "info" {
+			return nil, fmt.Errorf("invalid configuration: %s: severity of '%s' must be one of error, warning, info, got '%s'", configKeyDoctorSeverity, check, severity)
+		}
+	}
+	if dir := v.GetString(configKeyHooksTemplate); dir != "" {
+		if cfg.HooksTemplateDir, err = expandConfigPath(dir); err != nil {
+			return nil, fmt.Errorf("invalid configuration: %s: %w", configKeyHooksTemplate, err)
+		}
+	}
+	cfg.HooksPath = v.GetString(configKeyHooksPath)
+	if cfg.HooksTemplateDir != "" && cfg.HooksPath != "" {
+		return nil, fmt.Errorf("invalid configuration: set only one of %s and %s", configKeyHooksTemplate, configKeyHooksPath)
+	}
+	cfg.GitMaintenance = v.GetBool(configKeyGitMaintenance)
+	cfg.ReadOnly = v.GetBool(configKeyReadOnly)
+	cfg.Keyring = v.GetString(configKeyKeyring)
+	if cfg.Keyring != "auto" && cfg.Keyring != "system" && cfg.Keyring != "file" {
+		return nil, fmt.Errorf("invalid configuration: %s must be one of auto, system, file, got '%s'", configKeyKeyring, cfg.Keyring)
+	}
+	cfg.ProtocolFallback = v.GetString(configKeyFallback)
+	switch cfg.ProtocolFallback {
+	case "off", "ssh-to-https", "https-to-ssh", "both":
+	default:
+		return nil, fmt.Errorf("invalid configuration: %s must be one of off, ssh-to-https, https-to-ssh, both, got '%s'", configKeyFallback, cfg.ProtocolFallback)
+	}
+	if file := v.GetString(configKeySharedState); file != "" {
+		if cfg.SharedStateFile, err = expandConfigPath(file); err != nil {
+			return nil, fmt.Errorf("invalid configuration: %s: %w", configKeySharedState, err)
+		}
+		if cfg.StateFilePath == cfg.SharedStateFile {
+			return nil, fmt.Errorf("invalid configuration: %s must not be the state file itself (%s)", configKeySharedState, cfg.StateFilePath)
+		}
+	}
+	cfg.SharedGroup = v.GetString(configKeySharedGroup)
+	cfg.Direnv = v.GetBool(configKeyDirenv)
+	cfg.EnvrcTemplates = map[string]string{}
+	for language, file := range v.GetStringMapString(configKeyEnvrcTemplates) {
+		if cfg.EnvrcTemplates[language], err = expandConfigPath(file); err != nil {
+			return nil, fmt.Errorf("invalid configuration: %s: %w", configKeyEnvrcTemplates, err)
+		}
+	}
+	cfg.NotifyDesktop = v.GetBool(configKeyNotifyDesktop)
+	cfg.NotifyWebhook = v.GetString(configKeyNotifyWebhook)
+	if cfg.NotifyWebhook != "" {
+		if u, err := url.Parse(cfg.NotifyWebhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid configuration: %s must be an http(s) URL", configKeyNotifyWebhook)
+		}
+	}
+	cfg.NotifyWebhookFormat = v.GetString(configKeyNotifyFormat)
+	switch cfg.NotifyWebhookFormat {
+	case "":
+		cfg.NotifyWebhookFormat = notify.DetectFormat(cfg.NotifyWebhook)
+	case notify.FormatJSON, notify.FormatSlack:
+	default:
+		return nil, fmt.Errorf("invalid configuration: %s must be one of json, slack, got '%s'", configKeyNotifyFormat, cfg.NotifyWebhookFormat)
+	}
+	cfg.NotifyBehindThreshold = v.GetInt(configKeyNotifyBehind)
+	if cfg.NotifyBehindThreshold < 1 {
+		return nil, fmt.Errorf("invalid configuration: %s must be at least 1, got %d", configKeyNotifyBehind, cfg.NotifyBehindThreshold)
+	}
+	if cfg.NotifyFinishedAfter, err = time.ParseDuration(v.GetString(configKeyNotifyFinished)); err != nil || cfg.NotifyFinishedAfter < 0 {
+		return nil, fmt.Errorf("invalid configuration: %s must be a duration such as 1m or 0 to disable, got '%s'", configKeyNotifyFinished, v.GetString(configKeyNotifyFinished))
+	}
+	if cfg.URLRefreshAfter, err = time.ParseDuration(v.GetString(configKeyURLRefresh)); err != nil || cfg.URLRefreshAfter < 0 {
+		return nil, fmt.Errorf("invalid configuration: %s must be a duration such as 24h or 0 to disable, got '%s'", configKeyURLRefresh, v.GetString(configKeyURLRefresh))
+	}
+	localDir, err := expandEnv(v.GetString(configKeyLocalDir))
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %s: %w", configKeyLocalDir, err)
+	}
+	if localDir == "~" || strings.HasPrefix(localDir, "~/") {
+		if localDir, err = ExpandPath(localDir); err != nil {
+			return nil, fmt.Errorf("invalid configuration: %s: %w", configKeyLocalDir, err)
+		}
+	}
+	if cfg.LocalDir = filepath.Clean(localDir); cfg.LocalDir == "." || strings.HasPrefix(cfg.LocalDir, "..") {
+		return nil, fmt.Errorf("invalid configuration: %s must be a directory below %s or an absolute path, got '%s'", configKeyLocalDir, configKeyFussyGitHome, v.GetString(configKeyLocalDir))
+	}
+	cfg.WebhookSecret = v.GetString(configKeyWebhookSecret)
+	for key, list := range map[string]*[]string{configKeyPassAllow: &cfg.PassthroughAllow, configKeyPassDeny: &cfg.PassthroughDeny} {
+		for _, command := range splitList(v.GetStringSlice(key)) {
+			if strings.ContainsAny(command, " \t") || strings.HasPrefix(command, "-") {
+				return nil, fmt.Errorf("invalid configuration: %s: '%s' is not a git subcommand", key, command)
+			}
+			*list = append(*list, command)
+		}
+	}
+	// Its entries contain spaces, so a string (from the environment) is only split at commas.
+	confirm, ok := v.Get(configKeyPassConfirm).(string)
+	confirmEntries := []string{confirm}
+	if !ok {
+		confirmEntries = v.GetStringSlice(configKeyPassConfirm)
+	}
+	for _, command := range splitList(confirmEntries) {
+		if strings.HasPrefix(command, "-") {
+			return nil, fmt.Errorf("invalid configuration: %s: '%s' must start with a git subcommand", configKeyPassConfirm, command)
+		}
+		cfg.PassthroughConfirm = append(cfg.PassthroughConfirm, command)
+	}
+	// Secrets belong in the keyring; refuse to read them from a file that is often shared.
+	for _, key := range v.AllKeys() {
+		if isSecretKey(key) && v.InConfig(key) {
+			return nil, fmt.Errorf("invalid configuration: '%s' looks like a secret; secrets are never read from the config file. Store tokens with 'fussy-git auth login' instead", key)
+		}
+	}
+	if err := layout.Validate(cfg.Layout); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if cfg.Layers, err = loadLayers(v, cfg); err != nil {
+		return nil, err
+	}
+
+	cfg.Settings = describeSettings(v, cfg, flagSources)
+
+	if overrides.ReadOnly {
+		return cfg, nil
+	}
 
 	// Ensure FUSSY_GIT_HOME directory exists
 	if err := ensureDirExists(cfg.FussyGitHome, 0755); err != nil {
@@ -127,6 +528,120 @@ func LoadConfig(configFileFromFlag string) (*Config, error) {
 	return cfg, nil
 }
 
+// describeSettings lists the effective value and source of every setting. Values of settings
+// holding credentials (keys containing "token", "password" or "secret") are redacted.
+// flagSources maps the keys overridden on the command line to the flag that set them.
+func describeSettings(v *viper.Viper, cfg *Config, flagSources map[string]string) []Setting {
+	// Env variables explicitly bound to keys in addition to the automatic FUSSY_GIT_<KEY>.
+	boundEnv := map[string]string{configKeyFussyGitHome: envFussyGitHome}
+	source := func(key string) string {
+		if flag, ok := flagSources[key]; ok {
+			return "flag " + flag
+		}
+		names := []string{"FUSSY_GIT_" + strings.ToUpper(key)}
+		if bound, ok := boundEnv[key]; ok {
+			names = append(names, bound)
+		}
+		for _, name := range names {
+			if os.Getenv(name) != "" {
+				return "env " + name
+			}
+		}
+		if v.InConfig(key) {
+			return "config file"
+		}
+		return "default"
+	}
+	formatMap := func(m map[string]string) string {
+		pairs := make([]string, 0, len(m))
+		for k, val := range m {
+			pairs = append(pairs, k+"="+val)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ", ")
+	}
+	bandwidth := v.GetString(configKeyBandwidthLimit)
+	if cfg.BandwidthLimit == 0 {
+		bandwidth = "unlimited"
+	}
+	// The path of a webhook URL is usually its secret.
+	notifyWebhook := ""
+	if cfg.NotifyWebhook != "" {
+		notifyWebhook = notify.RedactURL(cfg.NotifyWebhook)
+	}
+
+	settings := []Setting{
+		{Key: configKeyFussyGitHome, Value: cfg.FussyGitHome},
+		{Key: configKeyStateFilePath, Value: cfg.StateFilePath},
+		{Key: configKeyLayout, Value: cfg.Layout},
+		{Key: configKeySSHAliases, Value: formatMap(cfg.SSHHostAliases)},
+		{Key: configKeyResolveSSH, Value: strconv.FormatBool(cfg.ResolveSSHAliases)},
+		{Key: configKeyEditor, Value: cfg.Editor},
+		{Key: configKeyMaxNetworkJobs, Value: strconv.Itoa(cfg.MaxNetworkJobs)},
+		{Key: configKeyBandwidthLimit, Value: bandwidth},
+		{Key: configKeyHostShortcuts, Value: formatMap(cfg.HostShortcuts)},
+		{Key: configKeyPrivateHosts, Value: strings.Join(cfg.PrivateHosts, ", ")},
+		{Key: configKeyCloneCache, Value: strconv.FormatBool(cfg.CloneCache)},
+		{Key: configKeyCloneCacheDir, Value: cfg.CloneCacheDir},
+		{Key: configKeyDoctorSeverity, Value: formatMap(cfg.DoctorSeverities)},
+		{Key: configKeyHooksTemplate, Value: cfg.HooksTemplateDir},
+		{Key: configKeyHooksPath, Value: cfg.HooksPath},
+		{Key: configKeyGitMaintenance, Value: strconv.FormatBool(cfg.GitMaintenance)},
+		{Key: configKeyReadOnly, Value: strconv.FormatBool(cfg.ReadOnly)},
+		{Key: configKeyKeyring, Value: cfg.Keyring},
+		{Key: configKeyFallback, Value: cfg.ProtocolFallback},
+		{Key: configKeySharedState, Value: cfg.SharedStateFile},
+		{Key: configKeySharedGroup, Value: cfg.SharedGroup},
+		{Key: configKeyDirenv, Value: strconv.FormatBool(cfg.Direnv)},
+		{Key: configKeyEnvrcTemplates, Value: formatMap(cfg.EnvrcTemplates)},
+		{Key: configKeyNotifyDesktop, Value: strconv.FormatBool(cfg.NotifyDesktop)},
+		{Key: configKeyNotifyWebhook, Value: notifyWebhook},
+		{Key: configKeyNotifyFormat, Value: cfg.NotifyWebhookFormat},
+		{Key: configKeyNotifyBehind, Value: strconv.Itoa(cfg.NotifyBehindThreshold)},
+		{Key: configKeyNotifyFinished, Value: cfg.NotifyFinishedAfter.String()},
+		{Key: configKeyURLRefresh, Value: cfg.URLRefreshAfter.String()},
+		{Key: configKeyLocalDir, Value: cfg.LocalDir},
+		{Key: configKeyWebhookSecret, Value: cfg.WebhookSecret},
+		{Key: configKeyPassAllow, Value: strings.Join(cfg.PassthroughAllow, ", ")},
+		{Key: configKeyPassDeny, Value: strings.Join(cfg.PassthroughDeny, ", ")},
+		{Key: configKeyPassConfirm, Value: strings.Join(cfg.PassthroughConfirm, ", ")},
+		{Key: configKeyProtocol, Value: cfg.Layers.Global.Protocol},
+		{Key: configKeyBootstrap, Value: strings.Join(cfg.Layers.Global.Bootstrap, "; ")},
+		{Key: configKeyUserName, Value: cfg.Layers.Global.UserName},
+		{Key: configKeyUserEmail, Value: cfg.Layers.Global.UserEmail},
+		{Key: configKeySSHCommand, Value: cfg.Layers.Global.SSHCommand},
+		{Key: configKeySigningKey, Value: cfg.Layers.Global.SigningKey},
+		{Key: configKeySigningFmt, Value: cfg.Layers.Global.SigningFormat},
+		{Key: configKeyEnv, Value: strings.Join(cfg.Layers.Global.Env, " ")},
+		{Key: configKeyEnvrc, Value: cfg.Layers.Global.EnvrcTemplate},
+		{Key: configKeyCloneDepth, Value: strconv.Itoa(cfg.Layers.Global.CloneDepth)},
+		{Key: configKeyCloneShallowSince, Value: cfg.Layers.Global.CloneShallowSince},
+		{Key: configKeyCloneFilter, Value: cfg.Layers.Global.CloneFilter},
+		{Key: configKeyCloneSparse, Value: strings.Join(cfg.Layers.Global.CloneSparse, ", ")},
+	}
+	for i := range settings {
+		settings[i].Source = source(settings[i].Key)
+		if settings[i].Value != "" && isSecretKey(settings[i].Key) {
+			settings[i].Value = "<redacted>"
+		}
+	}
+	return settings
+}
+
+// splitList returns the entries of a list setting. An entry may itself be a comma-separated
+// list, as environment variables give them; entries are trimmed and empty ones dropped.
+func splitList(entries []string) []string {
+	var list []string
+	for _, entry := range entries {
+		for _, item := range strings.Split(entry, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
 // ensureDirExists checks if a directory exists, and if not, creates it with the given permissions.
 // os.MkdirAll respects the system's umask by default.
 func ensureDirExists(path string, perm os.FileMode) error {
@@ -143,6 +658,50 @@ func ensureDirExists(path string, perm os.FileMode) error {
 	return nil
 }
 
+// ExpandPath expands a leading ~ to the user's home directory and makes the path absolute.
+func ExpandPath(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("could not get user home directory: %w", err)
+		}
+		path = filepath.Join(homeDir, path[1:])
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path for '%s': %w", path, err)
+	}
+	return absPath, nil
+}
+
+// expandConfigPath expands the environment variables in a path setting and then resolves it
+// like ExpandPath, so that e.g. "$WORKDIR/git" and "~/src" can be used in the config file.
+func expandConfigPath(value string) (string, error) {
+	expanded, err := expandEnv(value)
+	if err != nil {
+		return "", err
+	}
+	return ExpandPath(expanded)
+}
+
+// expandEnv replaces $VAR and ${VAR} in value with the value of the environment variable.
+// Unlike os.ExpandEnv, it fails for variables that aren't set, rather than silently turning
+// "$WORKDIR/git" into "/git".
+func expandEnv(value string) (string, error) {
+	var missing []string
+	expanded := os.Expand(value, func(name string) string {
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return val
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s in '%s' is not set", missing[0], value)
+	}
+	return expanded, nil
+}
+
 // GetDefaultFussyGitHome returns the default FUSSY_GIT_HOME path.
 func GetDefaultFussyGitHome() (string, error) {
 	homeDir, err := os.UserHomeDir()
@@ -160,3 +719,10 @@ func GetDefaultStateFilePath() (string, error) {
 	}
 	return filepath.Join(homeDir, configDirName, stateFileName), nil
 }
+
+// isSecretKey reports whether a config key holds a credential, i.e. its last segment
+// contains "token", "password" or "secret".
+func isSecretKey(key string) bool {
+	lower := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+	return strings.Contains(lower, "token") || strings.Contains(lower, "password") || strings.Contains(lower, "secret")
+}
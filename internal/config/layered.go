@@ -0,0 +1,304 @@
+package config
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/layout"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+// Keys of the settings that can be overridden per domain, owner and repository. At the top
+// level of the config file they set the global value.
+const (
+	configKeyProtocol   = "protocol"       // "ssh" or "https": the protocol repositories are cloned with
+	configKeyBootstrap  = "bootstrap"      // Shell commands run in a repository after it is cloned
+	configKeyUserName   = "user_name"      // git user.name set in the repository
+	configKeyUserEmail  = "user_email"     // git user.email set in the repository
+	configKeySSHCommand = "ssh_command"    // git core.sshCommand set in the repository, e.g. to select an SSH key
+	configKeySigningKey = "signing_key"    // git user.signingKey; where it is set, commits must be signed
+	configKeySigningFmt = "signing_format" // git gpg.format of the signing key: openpgp, ssh or x509
+	configKeyEnv        = "env"            // Environment variables ("NAME=value") for bootstrap commands and 'fussy-git exec'
+	configKeyEnvrc      = "envrc_template" // Template of the .envrc written into new clones when direnv is enabled
+
+	configKeyCloneDepth        = "clone_depth"         // Commits of history new clones fetch ('git clone --depth')
+	configKeyCloneShallowSince = "clone_shallow_since" // Date from which on new clones fetch history ('git clone --shallow-since')
+	configKeyCloneFilter       = "clone_filter"        // Object filter of new clones ('git clone --filter'), e.g. blob:none
+	configKeyCloneSparse       = "clone_sparse"        // Directories new clones check out (sparse checkout in cone mode)
+
+	configKeyDomains = "domains" // Section with settings per domain, e.g. domains: {github.com: {...}}
+	configKeyOwners  = "owners"  // Section with settings per owner, e.g. owners: {github.com/spf13: {...}}
+	configKeyRepos   = "repos"   // Section with settings per repository, e.g. repos: {github.com/spf13/cobra: {...}}
+)
+
+// RepoSettings are the settings that can be configured globally and overridden per domain,
+// per owner and per repository. Empty fields are not set at that level.
+type RepoSettings struct {
+	Protocol   string   `mapstructure:"protocol"`
+	Layout     string   `mapstructure:"layout"`
+	Bootstrap  []string `mapstructure:"bootstrap"`
+	UserName   string   `mapstructure:"user_name"`
+	UserEmail  string   `mapstructure:"user_email"`
+	SSHCommand string   `mapstructure:"ssh_command"`
+	// SigningKey is the key commits are signed with (a GPG key ID, or an SSH key file or
+	// "key::<public key>" with SigningFormat ssh). Where it is set, signing is required.
+	SigningKey    string `mapstructure:"signing_key"`
+	SigningFormat string `mapstructure:"signing_format"`
+	// Env holds environment variables as "NAME=value". Unlike the other settings, the variables
+	// of all layers are combined; a variable set at several layers takes the most specific value.
+	Env []string `mapstructure:"env"`
+	// EnvrcTemplate is a text/template file rendered into the .envrc of new clones when the
+	// direnv integration is enabled; it takes precedence over the language templates.
+	EnvrcTemplate string `mapstructure:"envrc_template"`
+	// CloneDepth and CloneShallowSince truncate the history of new clones. They are one setting:
+	// a level that sets either replaces both.
+	CloneDepth        int    `mapstructure:"clone_depth"`
+	CloneShallowSince string `mapstructure:"clone_shallow_since"`
+	// CloneFilter makes new clones partial clones that fetch objects on demand, e.g. blob:none.
+	CloneFilter string `mapstructure:"clone_filter"`
+	// CloneSparse lists the directories new clones check out; the rest of the tree isn't.
+	CloneSparse []string `mapstructure:"clone_sparse"`
+}
+
+// Layers holds the layered repository settings from the config file.
+type Layers struct {
+	Global  RepoSettings
+	Domains map[string]RepoSettings // Keyed by lowercase domain, e.g. "github.com"
+	Owners  map[string]RepoSettings // Keyed by lowercase "<domain>/<owner>"
+	Repos   map[string]RepoSettings // Keyed by lowercase "<domain>/<path>", e.g. "github.com/spf13/cobra"
+}
+
+// loadLayers reads the global repository settings and the domains, owners and repos sections.
+func loadLayers(v *viper.Viper, cfg *Config) (Layers, error) {
+	layers := Layers{
+		Global: RepoSettings{
+			Protocol:      v.GetString(configKeyProtocol),
+			Layout:        cfg.Layout,
+			Bootstrap:     v.GetStringSlice(configKeyBootstrap),
+			UserName:      v.GetString(configKeyUserName),
+			UserEmail:     v.GetString(configKeyUserEmail),
+			SSHCommand:    v.GetString(configKeySSHCommand),
+			SigningKey:    v.GetString(configKeySigningKey),
+			SigningFormat: v.GetString(configKeySigningFmt),
+			Env:           v.GetStringSlice(configKeyEnv),
+			EnvrcTemplate: v.GetString(configKeyEnvrc),
+
+			CloneDepth:        v.GetInt(configKeyCloneDepth),
+			CloneShallowSince: dateValue(v.Get(configKeyCloneShallowSince)),
+			CloneFilter:       v.GetString(configKeyCloneFilter),
+			CloneSparse:       v.GetStringSlice(configKeyCloneSparse),
+		},
+	}
+	if err := validateRepoSettings("", layers.Global); err != nil {
+		return Layers{}, err
+	}
+	for key, target := range map[string]*map[string]RepoSettings{
+		configKeyDomains: &layers.Domains,
+		configKeyOwners:  &layers.Owners,
+		configKeyRepos:   &layers.Repos,
+	} {
+		var section map[string]RepoSettings
+		if err := v.UnmarshalKey(key, &section, viper.DecodeHook(layerDecodeHook)); err != nil {
+			return Layers{}, fmt.Errorf("invalid configuration: %s: %w", key, err)
+		}
+		*target = map[string]RepoSettings{}
+		for name, settings := range section {
+			name = strings.ToLower(strings.Trim(name, "/"))
+			if err := validateRepoSettings(key+"."+name, settings); err != nil {
+				return Layers{}, err
+			}
+			(*target)[name] = settings
+		}
+	}
+	return layers, nil
+}
+
+// layerDecodeHook converts the values of a layer section to the types of RepoSettings: viper's
+// default conversions, and dates back to strings.
+var layerDecodeHook = mapstructure.ComposeDecodeHookFunc(
+	func(from, to reflect.Type, data any) (any, error) {
+		if t, ok := data.(time.Time); ok && to.Kind() == reflect.String {
+			return dateValue(t), nil
+		}
+		return data, nil
+	},
+	mapstructure.StringToTimeDurationHookFunc(),
+	mapstructure.StringToSliceHookFunc(","),
+)
+
+// dateValue returns a date setting as a string. YAML turns unquoted dates such as 2024-01-01
+// into timestamps, which are formatted back as they were written.
+func dateValue(value any) string {
+	t, ok := value.(time.Time)
+	if !ok {
+		if value == nil {
+			return ""
+		}
+		return fmt.Sprint(value)
+	}
+	if t.Equal(t.Truncate(24 * time.Hour)) {
+		return t.Format(time.DateOnly)
+	}
+	return t.Format(time.RFC3339)
+}
+
+// validateRepoSettings checks the values of one layer; where names it in error messages.
+func validateRepoSettings(where string, s RepoSettings) error {
+	prefix := "invalid configuration: "
+	if where != "" {
+		prefix += where + ": "
+	}
+	switch s.Protocol {
+	case "", "ssh", "https":
+	default:
+		return fmt.Errorf("%s%s must be 'ssh' or 'https', got '%s'", prefix, configKeyProtocol, s.Protocol)
+	}
+	switch s.SigningFormat {
+	case "", "openpgp", "ssh", "x509":
+	default:
+		return fmt.Errorf("%s%s must be 'openpgp', 'ssh' or 'x509', got '%s'", prefix, configKeySigningFmt, s.SigningFormat)
+	}
+	if s.Layout != "" {
+		if err := layout.Validate(s.Layout); err != nil {
+			return fmt.Errorf("%s%w", prefix, err)
+		}
+	}
+	for _, variable := range s.Env {
+		name, _, found := strings.Cut(variable, "=")
+		if !found || name == "" || strings.ContainsAny(name, " \t") {
+			return fmt.Errorf("%s%s: '%s' must have the form NAME=value", prefix, configKeyEnv, variable)
+		}
+		// Like other secrets, credentials in variables belong in the keyring, not the config file.
+		if isSecretKey(name) {
+			return fmt.Errorf("%s%s: '%s' looks like a secret; secrets are never read from the config file", prefix, configKeyEnv, name)
+		}
+	}
+	if s.CloneDepth < 0 {
+		return fmt.Errorf("%s%s must not be negative, got %d", prefix, configKeyCloneDepth, s.CloneDepth)
+	}
+	if s.CloneDepth > 0 && s.CloneShallowSince != "" {
+		return fmt.Errorf("%sset only one of %s and %s", prefix, configKeyCloneDepth, configKeyCloneShallowSince)
+	}
+	if err := ValidateCloneFilter(s.CloneFilter); err != nil {
+		return fmt.Errorf("%s%s: %w", prefix, configKeyCloneFilter, err)
+	}
+	for _, dir := range s.CloneSparse {
+		if dir == "" || strings.HasPrefix(dir, "/") || strings.Contains("/"+dir+"/", "/../") {
+			return fmt.Errorf("%s%s: '%s' must be a directory relative to the repository root", prefix, configKeyCloneSparse, dir)
+		}
+	}
+	return nil
+}
+
+// filterPrefixes are the kinds of object filters 'git clone --filter' accepts.
+var filterPrefixes = []string{"blob:none", "blob:limit=", "tree:", "object:type=", "sparse:oid=", "combine:"}
+
+// ValidateCloneFilter checks that filter (e.g. blob:none or tree:0) is an object filter git
+// understands; the empty filter is valid.
+func ValidateCloneFilter(filter string) error {
+	if filter == "" {
+		return nil
+	}
+	for _, prefix := range filterPrefixes {
+		if strings.HasPrefix(filter, prefix) && (len(filter) > len(prefix) || prefix == "blob:none") {
+			return nil
+		}
+	}
+	return fmt.Errorf("'%s' is not an object filter such as blob:none, blob:limit=1m or tree:0", filter)
+}
+
+// Resolved are the effective repository settings for one repository, with the layer each
+// value came from ("global", "domain github.com", "owner github.com/spf13",
+// "repo github.com/spf13/cobra", or "default" if it isn't set anywhere).
+type Resolved struct {
+	RepoSettings
+	Sources map[string]string // Setting key -> source
+}
+
+// For returns the effective settings of the repository at normalizedPath (e.g.
+// "github.com/spf13/cobra"): the global settings, overridden by the settings of its domain,
+// then its owner, then the repository itself.
+func (l Layers) For(normalizedPath string) Resolved {
+	normalizedPath = strings.ToLower(strings.Trim(normalizedPath, "/"))
+	parts := strings.Split(normalizedPath, "/")
+	domain := parts[0]
+	owner := ""
+	if len(parts) > 2 {
+		owner = parts[0] + "/" + parts[1]
+	}
+
+	r := Resolved{Sources: map[string]string{}}
+	for _, key := range []string{configKeyProtocol, configKeyLayout, configKeyBootstrap, configKeyUserName, configKeyUserEmail, configKeySSHCommand,
+		configKeySigningKey, configKeySigningFmt, configKeyEnv, configKeyEnvrc,
+		configKeyCloneDepth, configKeyCloneShallowSince, configKeyCloneFilter, configKeyCloneSparse} {
+		r.Sources[key] = "default"
+	}
+	apply := func(s RepoSettings, source string) {
+		set := func(key string, value *string, override string) {
+			if override != "" {
+				*value, r.Sources[key] = override, source
+			}
+		}
+		set(configKeyProtocol, &r.Protocol, s.Protocol)
+		set(configKeyLayout, &r.Layout, s.Layout)
+		set(configKeyUserName, &r.UserName, s.UserName)
+		set(configKeyUserEmail, &r.UserEmail, s.UserEmail)
+		set(configKeySSHCommand, &r.SSHCommand, s.SSHCommand)
+		set(configKeySigningKey, &r.SigningKey, s.SigningKey)
+		set(configKeySigningFmt, &r.SigningFormat, s.SigningFormat)
+		set(configKeyEnvrc, &r.EnvrcTemplate, s.EnvrcTemplate)
+		set(configKeyCloneFilter, &r.CloneFilter, s.CloneFilter)
+		if s.CloneDepth > 0 || s.CloneShallowSince != "" {
+			r.CloneDepth, r.CloneShallowSince = s.CloneDepth, s.CloneShallowSince
+			r.Sources[configKeyCloneDepth], r.Sources[configKeyCloneShallowSince] = source, source
+		}
+		if len(s.CloneSparse) > 0 {
+			r.CloneSparse, r.Sources[configKeyCloneSparse] = s.CloneSparse, source
+		}
+		if len(s.Bootstrap) > 0 {
+			r.Bootstrap, r.Sources[configKeyBootstrap] = s.Bootstrap, source
+		}
+		if len(s.Env) > 0 {
+			r.Env = MergeEnv(r.Env, s.Env)
+			if r.Sources[configKeyEnv] == "default" {
+				r.Sources[configKeyEnv] = source
+			} else {
+				r.Sources[configKeyEnv] += ", " + source
+			}
+		}
+	}
+	apply(l.Global, "global")
+	if s, ok := l.Domains[domain]; ok {
+		apply(s, "domain "+domain)
+	}
+	if s, ok := l.Owners[owner]; ok && owner != "" {
+		apply(s, "owner "+owner)
+	}
+	if s, ok := l.Repos[normalizedPath]; ok {
+		apply(s, "repo "+normalizedPath)
+	}
+	return r
+}
+
+// MergeEnv returns the "NAME=value" variables of base with those of overrides added, replacing
+// variables of the same name in place.
+func MergeEnv(base, overrides []string) []string {
+	merged := append([]string(nil), base...)
+	for _, variable := range overrides {
+		name, _, _ := strings.Cut(variable, "=")
+		replaced := false
+		for i, existing := range merged {
+			if existingName, _, _ := strings.Cut(existing, "="); existingName == name {
+				merged[i], replaced = variable, true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, variable)
+		}
+	}
+	return merged
+}
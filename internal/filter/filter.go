@@ -0,0 +1,164 @@
+package filter
+
+import (
+	"github.com/jmsnll/fussy-git/internal/config"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"path/filepath"
+	"strings"
+)
+
+// Filter selects a subset of the tracked repositories.
+// Values given for the same criterion are OR-ed together (--domain a --domain b matches either),
+// while different criteria are AND-ed (--domain a --owner b must match both).
+// An empty Filter matches every repository.
+type Filter struct {
+	Domains      []string // Match repositories hosted on any of these domains (e.g. "github.com")
+	Owners       []string // Match repositories owned by any of these users/organizations
+	Tags         []string // Match repositories carrying any of these tags
+	PathPrefixes []string // Match repositories located under any of these directories
+	Metadata     []string // Match repositories with any of these metadata entries: "key=value", or "key" for any value
+	Groups       []string // Match repositories that are members of any of these groups
+	Terms        []string // Match repositories in which every one of these search terms occurs, see MatchesTerms
+}
+
+// IsEmpty reports whether the filter has no criteria and therefore matches everything.
+func (f Filter) IsEmpty() bool {
+	return len(f.Domains) == 0 && len(f.Owners) == 0 && len(f.Tags) == 0 && len(f.PathPrefixes) == 0 && len(f.Metadata) == 0 && len(f.Groups) == 0 && len(f.Terms) == 0
+}
+
+// Match reports whether the given repository entry satisfies all criteria of the filter.
+func (f Filter) Match(entry state.RepositoryEntry) bool {
+	if len(f.Domains) > 0 && !containsFold(f.Domains, entry.Domain) {
+		return false
+	}
+	if len(f.Owners) > 0 && !containsFold(f.Owners, entryOwner(entry)) {
+		return false
+	}
+	if len(f.Tags) > 0 {
+		matched := false
+		for _, tag := range entry.Tags {
+			if containsFold(f.Tags, tag) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(f.PathPrefixes) > 0 {
+		matched := false
+		for _, prefix := range f.PathPrefixes {
+			if isUnder(entry.Path, prefix) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(f.Metadata) > 0 {
+		matched := false
+		for _, expr := range f.Metadata {
+			if matchMetadata(entry.Metadata, expr) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(f.Groups) > 0 {
+		matched := false
+		for _, group := range f.Groups {
+			if entry.InGroup(group) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(f.Terms) > 0 && !MatchesTerms(entry, f.Terms) {
+		return false
+	}
+	return true
+}
+
+// MatchesTerms reports whether every one of the search terms occurs, ignoring case, in one of
+// the searched fields of the repository: its name, normalized path, local path, URLs, README
+// description, tags and notes.
+func MatchesTerms(entry state.RepositoryEntry, terms []string) bool {
+	fields := []string{entry.Name, entry.NormalizedFS, entry.Path, entry.CurrentURL, entry.OriginalURL, entry.Description, entry.Notes}
+	fields = append(fields, entry.Tags...)
+	text := strings.ToLower(strings.Join(fields, "\n"))
+	for _, term := range terms {
+		if !strings.Contains(text, strings.ToLower(term)) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchMetadata reports whether metadata satisfies expr: "key=value" requires the key to have
+// that value (ignoring case), a bare "key" only requires the key to be set.
+func matchMetadata(metadata map[string]string, expr string) bool {
+	key, want, hasValue := strings.Cut(expr, "=")
+	value, ok := metadata[key]
+	return ok && (!hasValue || strings.EqualFold(value, want))
+}
+
+// Apply returns the entries matching the filter, preserving their order.
+func (f Filter) Apply(entries []state.RepositoryEntry) []state.RepositoryEntry {
+	if f.IsEmpty() {
+		return entries
+	}
+	matched := make([]state.RepositoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if f.Match(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// entryOwner determines the owner of a repository from its current URL,
+// falling back to the normalized filesystem path (domain/owner/...) if the URL can't be parsed.
+func entryOwner(entry state.RepositoryEntry) string {
+	if parsed, err := gitutil.ParseGitURL(entry.CurrentURL); err == nil {
+		return parsed.Owner()
+	}
+	segments := strings.Split(filepath.ToSlash(entry.NormalizedFS), "/")
+	if len(segments) >= 3 {
+		return segments[1]
+	}
+	return ""
+}
+
+// isUnder reports whether path is equal to or located below prefix.
+// A leading ~ in prefix is expanded to the user's home directory.
+func isUnder(path, prefix string) bool {
+	absPrefix, err := config.ExpandPath(prefix)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absPrefix, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// containsFold reports whether values contains s, ignoring case.
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
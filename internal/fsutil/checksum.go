@@ -0,0 +1,95 @@
+package fsutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Checksums maps the files below a directory, by their path relative to it, to the SHA-256
+// checksum of their content. Symlinks map to their target, prefixed with "->".
+type Checksums map[string]string
+
+// TreeChecksums returns the checksums of all regular files and symlinks below root, and the
+// total size of the regular files. Other special files are skipped, as CopyDir skips them.
+func TreeChecksums(root string) (Checksums, int64, error) {
+	sums := make(Checksums)
+	var total int64
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("failed to read symlink %s: %w", path, err)
+			}
+			sums[rel] = "->" + link
+		case d.Type().IsRegular():
+			sum, size, err := fileChecksum(path)
+			if err != nil {
+				return err
+			}
+			sums[rel] = sum
+			total += size
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return sums, total, nil
+}
+
+// fileChecksum returns the hex-encoded SHA-256 checksum and the size of the file at path.
+func fileChecksum(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// Verify checks that the files below root are exactly those of c, with the same content, and
+// returns the number of bytes verified.
+func (c Checksums) Verify(root string) (int64, error) {
+	got, total, err := TreeChecksums(root)
+	if err != nil {
+		return 0, err
+	}
+	var mismatches []string
+	for rel, sum := range c {
+		switch gotSum, ok := got[rel]; {
+		case !ok:
+			mismatches = append(mismatches, fmt.Sprintf("'%s' is missing", rel))
+		case gotSum != sum:
+			mismatches = append(mismatches, fmt.Sprintf("'%s' differs", rel))
+		}
+	}
+	for rel := range got {
+		if _, ok := c[rel]; !ok {
+			mismatches = append(mismatches, fmt.Sprintf("'%s' wasn't in the original", rel))
+		}
+	}
+	if len(mismatches) > 0 {
+		sort.Strings(mismatches)
+		return 0, fmt.Errorf("%d files don't match, e.g. %s", len(mismatches), mismatches[0])
+	}
+	return total, nil
+}
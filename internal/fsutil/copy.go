@@ -0,0 +1,125 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// IsCrossDevice reports whether err is the error os.Rename returns when source and target
+// are on different filesystems, in which case the data has to be copied instead.
+func IsCrossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
+
+// CopyDir recursively copies the directory src to dst, which must not exist yet.
+// Regular files keep their permissions and modification times, and symlinks are recreated
+// as symlinks. Other special files (sockets, devices) are skipped.
+func CopyDir(src, dst string) error {
+	return CopyDirProgress(src, dst, nil)
+}
+
+// CopyDirProgress is CopyDir, calling progress (if not nil) with the number of bytes copied
+// so far as the copy advances.
+func CopyDirProgress(src, dst string, progress func(copied int64)) error {
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("copy target %s already exists", dst)
+	}
+
+	var copied int64
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			if err := os.MkdirAll(target, info.Mode().Perm()|0700); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", target, err)
+			}
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("failed to read symlink %s: %w", path, err)
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return fmt.Errorf("failed to create symlink %s: %w", target, err)
+			}
+		case d.Type().IsRegular():
+			if err := copyFile(path, target, info, &progressWriter{total: &copied, progress: progress}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// progressWriter counts the bytes written through it into total and reports the new total.
+type progressWriter struct {
+	total    *int64
+	progress func(copied int64)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	*w.total += int64(len(p))
+	if w.progress != nil {
+		w.progress(*w.total)
+	}
+	return len(p), nil
+}
+
+// copyFile copies the regular file src, described by info, to dst. If counter is not nil,
+// the copied data is also written to it.
+func copyFile(src, dst string, info fs.FileInfo, counter io.Writer) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	var w io.Writer = out
+	if counter != nil {
+		w = io.MultiWriter(out, counter)
+	}
+	if _, err := io.Copy(w, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy %s to %s: %w", src, dst, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// CopyFile copies the regular file src to dst, which must not exist yet, creating the parent
+// directories of dst as needed. The file keeps its permissions and modification time.
+func CopyFile(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", src, err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", src)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(dst), err)
+	}
+	return copyFile(src, dst, info, nil)
+}
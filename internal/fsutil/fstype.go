@@ -0,0 +1,49 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// networkFilesystems are the filesystem types (as returned by FilesystemType) whose data is
+// stored on another machine.
+var networkFilesystems = map[string]bool{
+	"nfs":    true,
+	"smb":    true,
+	"smbfs":  true,
+	"cifs":   true,
+	"smb2":   true,
+	"afpfs":  true,
+	"afs":    true,
+	"coda":   true,
+	"9p":     true,
+	"ceph":   true,
+	"webdav": true,
+}
+
+// FilesystemType returns the type of the filesystem path is on, e.g. "ext4", "apfs" or "nfs".
+// Paths that don't exist (yet) are looked up through their longest existing parent. Types
+// without a well-known name are returned as their numeric identifier, e.g. "0x1234".
+func FilesystemType(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(path); err == nil || !os.IsNotExist(err) || filepath.Dir(path) == path {
+			break
+		}
+		path = filepath.Dir(path)
+	}
+	return filesystemType(path)
+}
+
+// NetworkFilesystem returns the type of the filesystem path is on if it is a network
+// filesystem (NFS, SMB, ...), and "" if it is local or its type can't be determined.
+func NetworkFilesystem(path string) string {
+	fsType, err := FilesystemType(path)
+	if err != nil || !networkFilesystems[fsType] {
+		return ""
+	}
+	return fsType
+}
@@ -0,0 +1,23 @@
+//go:build darwin
+
+package fsutil
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func filesystemType(path string) (string, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return "", fmt.Errorf("failed to determine the filesystem of %s: %w", path, err)
+	}
+	name := make([]byte, 0, len(stat.Fstypename))
+	for _, c := range stat.Fstypename {
+		if c == 0 {
+			break
+		}
+		name = append(name, byte(c))
+	}
+	return string(name), nil
+}
@@ -0,0 +1,41 @@
+//go:build linux
+
+package fsutil
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// linuxFilesystems maps the filesystem magic numbers of statfs(2) to their names.
+var linuxFilesystems = map[uint32]string{
+	0xEF53:     "ext4",
+	0x58465342: "xfs",
+	0x9123683E: "btrfs",
+	0x2FC12FC1: "zfs",
+	0x01021994: "tmpfs",
+	0x9FA0:     "proc",
+	0x794C7630: "overlay",
+	0x65735546: "fuse",
+	0x6969:     "nfs",
+	0x517B:     "smb",
+	0xFF534D42: "cifs",
+	0xFE534D42: "smb2",
+	0x5346414F: "afs",
+	0x73757245: "coda",
+	0x01021997: "9p",
+	0x00C36400: "ceph",
+}
+
+func filesystemType(path string) (string, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return "", fmt.Errorf("failed to determine the filesystem of %s: %w", path, err)
+	}
+	// Type is 32 or 64 bits wide depending on the architecture; the magic numbers are 32 bits.
+	magic := uint32(stat.Type)
+	if name, ok := linuxFilesystems[magic]; ok {
+		return name, nil
+	}
+	return fmt.Sprintf("0x%x", magic), nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package fsutil
+
+import "fmt"
+
+func filesystemType(path string) (string, error) {
+	return "", fmt.Errorf("determining the filesystem of %s is not supported on this platform", path)
+}
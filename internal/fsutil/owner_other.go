@@ -0,0 +1,17 @@
+//go:build !unix
+
+package fsutil
+
+import "os"
+
+// Owner returns the user and group IDs owning path. File ownership isn't expressed in user
+// and group IDs on this platform, so ok is always false.
+func Owner(path string) (uid, gid int, ok bool) {
+	return 0, 0, false
+}
+
+// Writable reports whether the current user may create files in the directory path.
+func Writable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().Perm()&0200 != 0
+}
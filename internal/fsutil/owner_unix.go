@@ -0,0 +1,26 @@
+//go:build unix
+
+package fsutil
+
+import (
+	"os"
+	"syscall"
+)
+
+// Owner returns the user and group IDs owning path. ok is false if they can't be determined.
+func Owner(path string) (uid, gid int, ok bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, false
+	}
+	stat, isUnix := info.Sys().(*syscall.Stat_t)
+	if !isUnix {
+		return 0, 0, false
+	}
+	return int(stat.Uid), int(stat.Gid), true
+}
+
+// Writable reports whether the current user may create files in the directory path.
+func Writable(path string) bool {
+	return syscall.Access(path, 0x2) == nil // W_OK
+}
@@ -0,0 +1,28 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ResolvePath returns the absolute, cleaned path with all symlinks resolved, like
+// filepath.EvalSymlinks. Unlike it, it also works for paths that don't exist (yet): the
+// longest existing parent is resolved and the rest of the path appended to it.
+func ResolvePath(path string) string {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	var rest []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...)
+		} else if !os.IsNotExist(err) {
+			return path
+		}
+		if filepath.Dir(dir) == dir {
+			return path
+		}
+		rest = append([]string{filepath.Base(dir)}, rest...)
+	}
+}
@@ -0,0 +1,41 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SharedDirMode is the mode of directories in a tree shared by a group: group-writable, with
+// the setgid bit so that everything created below them belongs to the same group.
+const SharedDirMode = os.ModeSetgid | 0775
+
+// MkdirAllShared creates dir and any missing parents like os.MkdirAll, but gives every
+// directory it creates the group gid and SharedDirMode, regardless of the umask.
+// Existing directories are left alone.
+func MkdirAllShared(dir string, gid int) error {
+	dir = filepath.Clean(dir)
+	if info, err := os.Stat(dir); err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("%s exists and is not a directory", dir)
+		}
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if parent := filepath.Dir(dir); parent != dir {
+		if err := MkdirAllShared(parent, gid); err != nil {
+			return err
+		}
+	}
+	if err := os.Mkdir(dir, 0775); err != nil && !os.IsExist(err) {
+		return err
+	}
+	if err := os.Chown(dir, -1, gid); err != nil {
+		return fmt.Errorf("failed to give %s to group %d: %w", dir, gid, err)
+	}
+	if err := os.Chmod(dir, SharedDirMode); err != nil {
+		return fmt.Errorf("failed to make %s group-writable: %w", dir, err)
+	}
+	return nil
+}
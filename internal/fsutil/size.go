@@ -0,0 +1,27 @@
+package fsutil
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// DirSize returns the total size in bytes of all regular files below root.
+// Symlinks are not followed. Unreadable entries below root are skipped.
+func DirSize(root string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total, err
+}
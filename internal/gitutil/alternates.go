@@ -0,0 +1,61 @@
+package gitutil
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// alternatesFile returns the location of a repository's objects/info/alternates file.
+func alternatesFile(repoPath string) string {
+	return filepath.Join(CommonGitDir(repoPath), "objects", "info", "alternates")
+}
+
+// Alternates returns the object directories a repository borrows objects from, as set up by
+// 'git clone --reference'. Relative entries are resolved against the repository's object
+// directory. An empty slice is returned if the repository has no alternates.
+func Alternates(repoPath string) ([]string, error) {
+	f, err := os.Open(alternatesFile(repoPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alternates of %s: %w", repoPath, err)
+	}
+	defer f.Close()
+
+	objectsDir := ObjectsDir(repoPath)
+	var dirs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(objectsDir, line)
+		}
+		dirs = append(dirs, filepath.Clean(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read alternates of %s: %w", repoPath, err)
+	}
+	return dirs, nil
+}
+
+// SetAlternates replaces the object directories a repository borrows objects from.
+func SetAlternates(repoPath string, dirs []string) error {
+	content := strings.Join(dirs, "\n") + "\n"
+	if err := os.WriteFile(alternatesFile(repoPath), []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write alternates of %s: %w", repoPath, err)
+	}
+	return nil
+}
+
+// ObjectsDir returns the object directory of the repository at repoPath, as it appears in
+// the alternates of repositories borrowing from it.
+func ObjectsDir(repoPath string) string {
+	return filepath.Join(CommonGitDir(repoPath), "objects")
+}
@@ -0,0 +1,82 @@
+package gitutil
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// CurrentBranch returns the name of the branch checked out in the repository,
+// or an empty string if HEAD is detached.
+func CurrentBranch(repoPath string) (string, error) {
+	cmd := exec.Command("git", "-C", repoPath, "symbolic-ref", "--short", "-q", "HEAD")
+
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return "", nil // Detached HEAD
+		}
+		return "", fmt.Errorf("failed to get current branch of %s: %w. Stderr:\n%s", repoPath, err, errb.String())
+	}
+	return strings.TrimSpace(outb.String()), nil
+}
+
+// DefaultBranch returns the default branch of the repository's 'origin' remote, e.g. "main",
+// based on refs/remotes/origin/HEAD as set up by 'git clone'. If that ref is missing, it falls
+// back to "main" or "master" when a matching remote-tracking branch exists.
+func DefaultBranch(repoPath string) (string, error) {
+	cmd := exec.Command("git", "-C", repoPath, "symbolic-ref", "--short", "-q", "refs/remotes/origin/HEAD")
+	var outb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if err := cmd.Run(); err == nil {
+		return strings.TrimPrefix(strings.TrimSpace(outb.String()), "origin/"), nil
+	}
+
+	for _, candidate := range []string{"main", "master"} {
+		check := exec.Command("git", "-C", repoPath, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+candidate)
+		if check.Run() == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("could not determine the default branch of %s: refs/remotes/origin/HEAD is not set", repoPath)
+}
+
+// BranchExists reports whether a local branch with the given name exists.
+func BranchExists(repoPath, branch string) bool {
+	cmd := exec.Command("git", "-C", repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	return cmd.Run() == nil
+}
+
+// FastForwardBranch fast-forwards the local branch to upstream (e.g. "origin/main"), failing
+// if that isn't possible without a merge. If the branch is checked out, the working tree is
+// updated as well; otherwise only the branch ref moves.
+func FastForwardBranch(repoPath, branch, upstream string, verbose bool) error {
+	current, err := CurrentBranch(repoPath)
+	if err != nil {
+		return err
+	}
+	if current == branch {
+		if verbose {
+			fmt.Printf("Executing: git -C %s merge --ff-only %s\n", repoPath, upstream)
+		}
+		return runQuiet(repoPath, "merge", "--ff-only", "--quiet", upstream)
+	}
+	// Fetching from the repository itself updates a branch that isn't checked out, and
+	// (without a leading '+') refuses anything but a fast-forward.
+	if verbose {
+		fmt.Printf("Executing: git -C %s fetch . %s:%s\n", repoPath, upstream, branch)
+	}
+	return runQuiet(repoPath, "fetch", "--quiet", ".", upstream+":"+branch)
+}
+
+// ValidBranchName reports whether name is acceptable to git as the name of a branch.
+func ValidBranchName(name string) bool {
+	return exec.Command("git", "check-ref-format", "--branch", name).Run() == nil
+}
@@ -0,0 +1,25 @@
+package gitutil
+
+import (
+	"strings"
+)
+
+// ResolveCommit returns the full hash of the commit rev (a hash, branch or other revision)
+// names in the repository, and false if it doesn't name a commit there.
+func ResolveCommit(repoPath, rev string) (string, bool) {
+	out, err := runOutput(repoPath, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(out), true
+}
+
+// Checkout checks out ref. With detach, HEAD is detached at the commit ref names; otherwise
+// ref is a branch, which git creates from origin's branch of the same name if needed.
+func Checkout(repoPath, ref string, detach bool) error {
+	args := []string{"checkout", "--quiet"}
+	if detach {
+		args = append(args, "--detach")
+	}
+	return runQuiet(repoPath, append(args, ref, "--")...)
+}
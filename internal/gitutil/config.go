@@ -0,0 +1,89 @@
+package gitutil
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigValue returns the value of a git config key as seen from the repository, including
+// global and system settings, or an empty string if it isn't set.
+func ConfigValue(repoPath, key string) string {
+	out, err := exec.Command("git", "-C", repoPath, "config", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// ConfigBool returns the value of a boolean git config key as seen from the repository, with
+// git's spellings of true and false (yes, on, 1, ...) understood; false if it isn't set.
+func ConfigBool(repoPath, key string) bool {
+	out, err := exec.Command("git", "-C", repoPath, "config", "--type=bool", "--get", key).Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// ConfigOrigin returns where the value of a git config key seen from the repository is set,
+// e.g. "file:/home/me/.gitconfig" or "file:.git/config", or an empty string if it isn't set.
+func ConfigOrigin(repoPath, key string) string {
+	out, err := exec.Command("git", "-C", repoPath, "config", "--show-origin", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	origin, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\t")
+	return origin
+}
+
+// SetConfigValue sets a git config key in the repository's own configuration.
+func SetConfigValue(repoPath, key, value string) error {
+	return runQuiet(repoPath, "config", "--local", key, value)
+}
+
+// GitPath returns the absolute location of a path inside the repository's git directory,
+// e.g. "hooks", resolving worktrees and a relocated git directory ('git rev-parse --git-path').
+func GitPath(repoPath, name string) (string, error) {
+	out, err := runOutput(repoPath, "rev-parse", "--git-path", name)
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimSpace(out)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(repoPath, path)
+	}
+	return path, nil
+}
+
+// SafeDirectories returns the effective safe.directory entries of the global and system
+// configuration, which name repositories owned by other users that git agrees to work in.
+// "*" allows all of them.
+func SafeDirectories() []string {
+	out, err := exec.Command("git", "config", "--get-all", "safe.directory").Output()
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			dirs = nil // An empty value resets the list.
+			continue
+		}
+		dirs = append(dirs, line)
+	}
+	return dirs
+}
+
+// GlobalConfigValues returns all values of a multi-valued git config key in the global and
+// system configuration, e.g. the roots of ghq ('ghq.root'), in the order git reports them.
+func GlobalConfigValues(key string) []string {
+	out, err := exec.Command("git", "config", "--get-all", key).Output()
+	if err != nil {
+		return nil
+	}
+	var values []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line != "" {
+			values = append(values, line)
+		}
+	}
+	return values
+}
@@ -0,0 +1,38 @@
+package gitutil
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FindRepositories walks the directory tree below root and returns the paths of all
+// Git repositories found, i.e. directories containing a .git directory or file.
+// The walk doesn't descend into repositories it has found (so submodules and nested
+// checkouts are not reported separately). Unreadable directories are skipped.
+func FindRepositories(root string) ([]string, error) {
+	var repos []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err // The root itself must be readable
+			}
+			return fs.SkipDir
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == ".git" {
+			return fs.SkipDir
+		}
+		if _, statErr := os.Lstat(filepath.Join(path, ".git")); statErr == nil {
+			repos = append(repos, path)
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return repos, nil
+}
@@ -0,0 +1,43 @@
+package gitutil
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AddExclude adds pattern to the repository's .git/info/exclude unless it is listed already,
+// so files that only exist locally (e.g. .envrc) don't show up as untracked.
+func AddExclude(repoPath, pattern string) error {
+	excludeFile, err := GitPath(repoPath, "info/exclude")
+	if err != nil {
+		return err
+	}
+	if data, err := os.ReadFile(excludeFile); err == nil {
+		scanner := bufio.NewScanner(strings.NewReader(string(data)))
+		for scanner.Scan() {
+			if strings.TrimSpace(scanner.Text()) == pattern {
+				return nil
+			}
+		}
+		if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+			pattern = "\n" + pattern
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", excludeFile, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(excludeFile), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(excludeFile), err)
+	}
+	f, err := os.OpenFile(excludeFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", excludeFile, err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintln(f, pattern); err != nil {
+		return fmt.Errorf("failed to write %s: %w", excludeFile, err)
+	}
+	return nil
+}
@@ -1,22 +1,27 @@
 package gitutil
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// CloneRepository executes 'git clone' command.
+// CloneRepository executes 'git clone' command. Options such as "--reference <path>" can be
+// passed in extraArgs and are placed before the URL.
 // It returns the combined stdout/stderr output and an error if any.
-func CloneRepository(repoURL, targetPath string, verbose bool) (string, error) {
+func CloneRepository(repoURL, targetPath string, verbose bool, extraArgs ...string) (string, error) {
+	args := append(append([]string{"clone"}, extraArgs...), repoURL, targetPath)
 	if verbose {
-		fmt.Printf("Executing: git clone %s %s\n", repoURL, targetPath)
+		fmt.Printf("Executing: git %s\n", strings.Join(args, " "))
 	}
 
-	cmd := exec.Command("git", "clone", repoURL, targetPath)
+	cmd := exec.Command("git", args...)
 
 	// Capture stdout and stderr for more detailed error reporting or verbose output
 	var outb, errb bytes.Buffer
@@ -50,6 +55,69 @@ func CloneRepository(repoURL, targetPath string, verbose bool) (string, error) {
 	return combinedOutput, nil
 }
 
+// CloneRepositoryWithProgress executes 'git clone --progress' and calls progress with each
+// progress line git reports (e.g. "Receiving objects:  45% (123/456)"), as it happens.
+// It is meant for callers that render their own progress display instead of git's raw output.
+// extraArgs are passed to 'git clone' like in CloneRepository.
+func CloneRepositoryWithProgress(repoURL, targetPath string, progress func(line string), extraArgs ...string) error {
+	args := append(append([]string{"clone", "--progress"}, extraArgs...), repoURL, targetPath)
+	return runWithProgress(exec.Command("git", args...), fmt.Sprintf("git clone failed for %s into %s", repoURL, targetPath), progress)
+}
+
+// runWithProgress runs a git command that reports progress on stderr, calling progress (if not
+// nil) with every progress line. If it fails, the error starts with errMsg and includes the
+// last lines of output.
+func runWithProgress(cmd *exec.Cmd, errMsg string, progress func(line string)) error {
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to capture git output: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start git: %w", err)
+	}
+
+	// git redraws progress lines with '\r', so split on both '\r' and '\n'.
+	var lastLines []string
+	scanner := bufio.NewScanner(stderr)
+	scanner.Split(scanProgressLines)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if progress != nil {
+			progress(line)
+		}
+		if len(lastLines) == 10 {
+			lastLines = lastLines[1:]
+		}
+		lastLines = append(lastLines, line)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			errMsg = fmt.Sprintf("%s (exit code %d)", errMsg, exitErr.ExitCode())
+		}
+		return fmt.Errorf("%s: %w. Output:\n%s", errMsg, err, strings.Join(lastLines, "\n"))
+	}
+	return nil
+}
+
+// scanProgressLines is a bufio.SplitFunc like bufio.ScanLines that also treats '\r' as a line end.
+func scanProgressLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
 // GetRemoteOriginURL fetches the URL of the "origin" remote for a repository at a given path.
 func GetRemoteOriginURL(repoPath string, verbose bool) (string, error) {
 	if verbose {
@@ -119,16 +187,194 @@ func SetRemoteOriginURL(repoPath, newURL string, verbose bool) (string, error) {
 }
 
 // IsGitRepository checks if the given path is a Git repository
-// by looking for a .git directory or running `git rev-parse --is-inside-work-tree`.
+// by looking for a .git directory, a .git file pointing to an existing git directory
+// (--separate-git-dir clones and linked worktrees), or running `git rev-parse --is-inside-work-tree`.
 func IsGitRepository(path string) bool {
 	// Option 1: Check for .git directory (faster for simple cases)
 	gitDir := filepath.Join(path, ".git")
 	if stat, err := os.Stat(gitDir); err == nil && stat.IsDir() {
 		return true
 	}
+	if target, err := readGitFile(path); err == nil && target != "" {
+		if stat, err := os.Stat(target); err == nil && stat.IsDir() {
+			return true
+		}
+	}
 
 	// Option 2: Use git command (more robust, handles worktrees, etc.)
 	cmd := exec.Command("git", "-C", path, "rev-parse", "--is-inside-work-tree")
 	err := cmd.Run()  // We only care about the exit status
 	return err == nil // Exit code 0 means it's a git repo
 }
+
+// IsDirty reports whether the working tree of the repository has uncommitted changes
+// or untracked files, based on 'git status --porcelain'.
+func IsDirty(repoPath string) (bool, error) {
+	cmd := exec.Command("git", "-C", repoPath, "status", "--porcelain")
+
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if err := cmd.Run(); err != nil {
+		errMsg := fmt.Sprintf("failed to get status of %s", repoPath)
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			errMsg = fmt.Sprintf("%s (exit code %d)", errMsg, exitErr.ExitCode())
+		}
+		return false, fmt.Errorf("%s: %w. Stderr:\n%s", errMsg, err, errb.String())
+	}
+	return strings.TrimSpace(outb.String()) != "", nil
+}
+
+// Fetch runs 'git fetch --all --prune' in the repository, updating all remote-tracking branches.
+func Fetch(repoPath string, verbose bool) error {
+	if verbose {
+		fmt.Printf("Executing: git -C %s fetch --all --prune\n", repoPath)
+	}
+	return runQuiet(repoPath, "fetch", "--all", "--prune", "--quiet")
+}
+
+// FetchWithProgress fetches origin, or every remote if allRemotes is set, calling progress (if
+// not nil) with git's progress lines. With prune, remote-tracking branches whose branch was
+// deleted on the remote are removed.
+func FetchWithProgress(repoPath string, allRemotes, prune bool, progress func(line string)) error {
+	args := []string{"-C", repoPath, "fetch", "--progress"}
+	if prune {
+		args = append(args, "--prune")
+	}
+	if allRemotes {
+		args = append(args, "--all")
+	} else {
+		args = append(args, "origin")
+	}
+	return runWithProgress(exec.Command("git", args...), fmt.Sprintf("git fetch failed for %s", repoPath), progress)
+}
+
+// GarbageCollect runs 'git gc --auto' in the repository, which only packs and prunes
+// objects when git's own thresholds say it is worthwhile.
+func GarbageCollect(repoPath string, verbose bool) error {
+	if verbose {
+		fmt.Printf("Executing: git -C %s gc --auto\n", repoPath)
+	}
+	return runQuiet(repoPath, "gc", "--auto", "--quiet")
+}
+
+// GarbageCollectNow runs a full 'git gc' in the repository, packing all loose objects and
+// pruning unreachable ones, whatever git's thresholds say.
+func GarbageCollectNow(repoPath string) error {
+	return runQuiet(repoPath, "gc", "--quiet")
+}
+
+// runQuiet runs a git subcommand in repoPath without prompting, returning an error including
+// git's stderr if it fails.
+func runQuiet(repoPath string, args ...string) error {
+	cmd := exec.Command("git", append([]string{"-C", repoPath}, args...)...)
+
+	var errb bytes.Buffer
+	cmd.Stderr = &errb
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if err := cmd.Run(); err != nil {
+		errMsg := fmt.Sprintf("git %s failed for %s", args[0], repoPath)
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			errMsg = fmt.Sprintf("%s (exit code %d)", errMsg, exitErr.ExitCode())
+		}
+		return fmt.Errorf("%s: %w. Stderr:\n%s", errMsg, err, strings.TrimSpace(errb.String()))
+	}
+	return nil
+}
+
+// HeadCommit returns the commit hash HEAD points to, or an empty string if the repository
+// has no commits yet.
+func HeadCommit(repoPath string) (string, error) {
+	cmd := exec.Command("git", "-C", repoPath, "rev-parse", "--verify", "--quiet", "HEAD")
+
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 && errb.Len() == 0 {
+			return "", nil // Unborn branch
+		}
+		return "", fmt.Errorf("failed to resolve HEAD of %s: %w. Stderr:\n%s", repoPath, err, errb.String())
+	}
+	return strings.TrimSpace(outb.String()), nil
+}
+
+// LastCommitTime returns the committer date of HEAD, or the zero time if the repository has
+// no commits yet.
+func LastCommitTime(repoPath string) (time.Time, error) {
+	commit, err := HeadCommit(repoPath)
+	if err != nil || commit == "" {
+		return time.Time{}, err
+	}
+	out, err := exec.Command("git", "-C", repoPath, "log", "-1", "--format=%ct", commit).Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read the date of HEAD of %s: %w", repoPath, err)
+	}
+	seconds, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected output from git log for %s: %q", repoPath, out)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// AuthorCommitTimes returns the author dates of the commits on any branch, remote-tracking
+// branch or tag of the repository whose author matches the regular expression author (as
+// 'git log --author'), authored after since, newest first.
+func AuthorCommitTimes(repoPath, author string, since time.Time) ([]time.Time, error) {
+	commit, err := HeadCommit(repoPath)
+	if err != nil || commit == "" {
+		return nil, err
+	}
+	out, err := runOutput(repoPath, "log", "--branches", "--remotes", "--tags", "--author="+author, "--since="+since.Format(time.RFC3339), "--format=%at")
+	if err != nil {
+		return nil, err
+	}
+	var times []time.Time
+	for _, line := range strings.Fields(out) {
+		seconds, err := strconv.ParseInt(line, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected output from git log for %s: %q", repoPath, line)
+		}
+		times = append(times, time.Unix(seconds, 0))
+	}
+	return times, nil
+}
+
+// CheckConnectivity runs 'git fsck --connectivity-only', a quick check that all objects
+// reachable from the repository's refs are present.
+func CheckConnectivity(repoPath string) error {
+	return runQuiet(repoPath, "fsck", "--connectivity-only", "--no-progress")
+}
+
+// Fsck runs 'git fsck' in the repository, or with connectivityOnly the quicker
+// 'git fsck --connectivity-only', which doesn't check the contents of blobs. Dangling objects
+// are not reported. If git finds problems, they are returned as git printed them; an empty
+// report means the repository is intact. err is only set if git couldn't be run.
+func Fsck(repoPath string, connectivityOnly bool) (report string, err error) {
+	args := []string{"-C", repoPath, "fsck", "--no-progress", "--no-dangling"}
+	if connectivityOnly {
+		args = append(args, "--connectivity-only")
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	// Missing and broken objects are reported on stdout, errors on stderr.
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return "", fmt.Errorf("failed to run git fsck for %s: %w", repoPath, err)
+		}
+		report = strings.TrimSpace(string(output))
+		if report == "" {
+			report = fmt.Sprintf("git fsck exited with code %d", exitErr.ExitCode())
+		}
+		return report, nil
+	}
+	return "", nil
+}
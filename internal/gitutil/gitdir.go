@@ -0,0 +1,126 @@
+package gitutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GitDirLayout describes where the git directory of a work tree is. Usually it is the .git
+// directory inside the work tree; repositories cloned with --separate-git-dir and linked
+// worktrees ('git worktree add') have a .git file pointing to it instead.
+type GitDirLayout struct {
+	GitDir   string // The git directory, absolute
+	Separate bool   // .git is a file pointing to GitDir
+	// Worktree is true if the work tree is a linked worktree of another repository, whose git
+	// directory (CommonDir) holds the objects and refs. GitDir is then the worktree's
+	// administrative directory below CommonDir/worktrees.
+	Worktree  bool
+	CommonDir string // The git directory holding the objects; GitDir unless Worktree
+}
+
+// readGitFile returns the git directory a .git file points to, resolved against the work
+// tree if it is relative, and "" if workTree/.git is not a file.
+func readGitFile(workTree string) (string, error) {
+	gitFile := filepath.Join(workTree, ".git")
+	info, err := os.Stat(gitFile)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", nil
+	}
+	data, err := os.ReadFile(gitFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", gitFile, err)
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(line), "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("%s is not a gitdir file", gitFile)
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(workTree, gitDir)
+	}
+	return filepath.Clean(gitDir), nil
+}
+
+// DetectGitDir returns where the git directory of the work tree at workTree is.
+func DetectGitDir(workTree string) (*GitDirLayout, error) {
+	gitDir, err := readGitFile(workTree)
+	if err != nil {
+		return nil, err
+	}
+	if gitDir == "" {
+		dir := filepath.Join(workTree, ".git")
+		return &GitDirLayout{GitDir: dir, CommonDir: dir}, nil
+	}
+	layout := &GitDirLayout{GitDir: gitDir, Separate: true, CommonDir: gitDir}
+	// The administrative directory of a linked worktree names the main git directory.
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+		layout.Worktree = true
+		layout.CommonDir = filepath.Clean(common)
+	}
+	return layout, nil
+}
+
+// CommonGitDir returns the git directory holding the objects of the repository at repoPath:
+// its .git directory, the directory its .git file points to, or for a linked worktree the
+// git directory of the main repository. Errors reading a .git file fall back to repoPath/.git.
+func CommonGitDir(repoPath string) string {
+	layout, err := DetectGitDir(repoPath)
+	if err != nil {
+		return filepath.Join(repoPath, ".git")
+	}
+	return layout.CommonDir
+}
+
+// SetGitDir points the .git file of the work tree at workTree to gitDir. If the git directory
+// records the location of its work tree in core.worktree, that is updated too.
+func SetGitDir(workTree, gitDir string) error {
+	gitFile := filepath.Join(workTree, ".git")
+	if err := os.WriteFile(gitFile, []byte("gitdir: "+gitDir+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", gitFile, err)
+	}
+	configFile := filepath.Join(gitDir, "config")
+	out, err := runOutput("", "config", "--file", configFile, "--get", "core.worktree")
+	if err != nil || strings.TrimSpace(out) == "" {
+		return nil // Not set, which is what 'git clone --separate-git-dir' does.
+	}
+	if _, err := runOutput("", "config", "--file", configFile, "core.worktree", workTree); err != nil {
+		return fmt.Errorf("failed to update core.worktree of %s: %w", gitDir, err)
+	}
+	return nil
+}
+
+// RepairWorktree updates the administrative files of the linked worktree at workTree after
+// it was moved, so its main repository finds it again ('git worktree repair').
+func RepairWorktree(workTree string) error {
+	if _, err := runOutput(workTree, "worktree", "repair"); err != nil {
+		return fmt.Errorf("failed to repair the worktree at %s: %w", workTree, err)
+	}
+	return nil
+}
+
+// LinkedWorktrees returns the work trees added to the repository at repoPath with
+// 'git worktree add', which depend on its git directory staying where it is.
+func LinkedWorktrees(repoPath string) ([]string, error) {
+	out, err := runOutput(repoPath, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	var worktrees []string
+	for _, line := range strings.Split(out, "\n") {
+		if path, ok := strings.CutPrefix(line, "worktree "); ok {
+			worktrees = append(worktrees, path)
+		}
+	}
+	// The first one is the main work tree.
+	if len(worktrees) > 0 {
+		worktrees = worktrees[1:]
+	}
+	return worktrees, nil
+}
@@ -0,0 +1,146 @@
+package gitutil
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// HostKey is a public host key offered by an SSH server.
+type HostKey struct {
+	Type        string // e.g. "ssh-ed25519"
+	Line        string // The known_hosts line, e.g. "github.com ssh-ed25519 AAAA..."
+	Fingerprint string // SHA256 fingerprint as printed by ssh, e.g. "SHA256:+DiY3wvv..."
+}
+
+// sshTarget returns the hostname and port ssh connects to for host, following ~/.ssh/config,
+// and whether the configuration accepts unknown host keys without asking (StrictHostKeyChecking
+// no or accept-new).
+func sshTarget(host string) (hostname, port string, acceptsNew bool, err error) {
+	cmd := exec.Command("ssh", "-G", host)
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	if err := cmd.Run(); err != nil {
+		return "", "", false, fmt.Errorf("failed to read ssh configuration for '%s' with 'ssh -G': %w. Stderr:\n%s", host, err, errb.String())
+	}
+	hostname, port = host, "22"
+	scanner := bufio.NewScanner(&outb)
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), " ")
+		switch strings.ToLower(key) {
+		case "hostname":
+			hostname = value
+		case "port":
+			port = value
+		case "stricthostkeychecking":
+			acceptsNew = value == "false" || value == "no" || value == "off" || value == "accept-new"
+		}
+	}
+	return hostname, port, acceptsNew, nil
+}
+
+// knownHostsName is how a host is written in known_hosts: "host", or "[host]:port" for
+// non-standard ports.
+func knownHostsName(hostname, port string) string {
+	if port == "22" {
+		return hostname
+	}
+	return "[" + hostname + "]:" + port
+}
+
+// NeedsHostKey reports whether connecting to host over SSH would stop at the prompt to
+// accept an unknown host key, which fails when git runs without a terminal.
+func NeedsHostKey(host string) (bool, error) {
+	hostname, port, acceptsNew, err := sshTarget(host)
+	if err != nil || acceptsNew {
+		return false, err
+	}
+	if _, err := os.Stat(KnownHostsFile()); os.IsNotExist(err) {
+		return true, nil
+	}
+	// ssh-keygen -F exits with 1 if the host isn't found, also in hashed known_hosts files.
+	err = exec.Command("ssh-keygen", "-F", knownHostsName(hostname, port), "-f", KnownHostsFile()).Run()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up %s in %s: %w", host, KnownHostsFile(), err)
+	}
+	return false, nil
+}
+
+// ScanHostKeys fetches the host keys host offers, like 'ssh-keyscan', without trusting them.
+func ScanHostKeys(host string) ([]HostKey, error) {
+	hostname, port, _, err := sshTarget(host)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command("ssh-keyscan", "-T", "10", "-p", port, hostname)
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ssh-keyscan %s failed: %w. Stderr:\n%s", hostname, err, errb.String())
+	}
+
+	var keys []HostKey
+	scanner := bufio.NewScanner(&outb)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		fields := strings.Fields(line)
+		if len(fields) < 3 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		blob, err := base64.StdEncoding.DecodeString(fields[2])
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(blob)
+		keys = append(keys, HostKey{
+			Type:        fields[1],
+			Line:        line,
+			Fingerprint: "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]),
+		})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s didn't offer any host keys (is it reachable on port %s?)", hostname, port)
+	}
+	return keys, nil
+}
+
+// KnownHostsFile returns the user's known_hosts file, ~/.ssh/known_hosts.
+func KnownHostsFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".ssh", "known_hosts")
+	}
+	return filepath.Join(home, ".ssh", "known_hosts")
+}
+
+// AddKnownHosts appends host keys to the user's known_hosts file, creating it if needed.
+func AddKnownHosts(keys []HostKey) error {
+	path := KnownHostsFile()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	var sb strings.Builder
+	for _, key := range keys {
+		sb.WriteString(key.Line + "\n")
+	}
+	if _, err := f.WriteString(sb.String()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
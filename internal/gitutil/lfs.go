@@ -0,0 +1,98 @@
+package gitutil
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	lfsOnce      sync.Once
+	lfsInstalled bool
+)
+
+// LFSInstalled reports whether the git-lfs extension is installed ('git lfs version' works).
+func LFSInstalled() bool {
+	lfsOnce.Do(func() {
+		lfsInstalled = exec.Command("git", "lfs", "version").Run() == nil
+	})
+	return lfsInstalled
+}
+
+// LFSObjectsDir returns the directory Git LFS stores the content of the repository's files in.
+func LFSObjectsDir(repoPath string) string {
+	return filepath.Join(CommonGitDir(repoPath), "lfs", "objects")
+}
+
+// UsesLFS reports whether the repository uses Git LFS: its .gitattributes routes files
+// through the lfs filter, or it has downloaded LFS content. git-lfs doesn't need to be
+// installed for this.
+func UsesLFS(repoPath string) bool {
+	if info, err := os.Stat(LFSObjectsDir(repoPath)); err == nil && info.IsDir() {
+		return true
+	}
+	f, err := os.Open(filepath.Join(repoPath, ".gitattributes"))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "#") && strings.Contains(line, "filter=lfs") {
+			return true
+		}
+	}
+	return false
+}
+
+// LFSObjectsSize returns the total size and the number of the LFS objects stored in the
+// repository, which 'git count-objects' doesn't include. Both are 0 if it has none.
+func LFSObjectsSize(repoPath string) (size int64, count int, err error) {
+	dir := LFSObjectsDir(repoPath)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	err = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil // Unreadable entries are skipped, like for the size of a repository.
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+			count++
+		}
+		return nil
+	})
+	return size, count, err
+}
+
+// LFSMissingContent returns the LFS-tracked files of the work tree that are only pointers,
+// i.e. whose content was never downloaded ('git lfs pull' fetches it). It needs git-lfs.
+func LFSMissingContent(repoPath string) ([]string, error) {
+	out, err := runOutput(repoPath, "lfs", "ls-files")
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, line := range strings.Split(out, "\n") {
+		// "<oid> * <path>" if the content is checked out, "<oid> - <path>" for a pointer.
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 3)
+		if len(fields) == 3 && fields[1] == "-" {
+			missing = append(missing, fields[2])
+		}
+	}
+	return missing, nil
+}
+
+// LFSCheck checks that the LFS objects of the repository are intact ('git lfs fsck'), i.e.
+// that their content matches their object IDs. It needs git-lfs.
+func LFSCheck(repoPath string) error {
+	if _, err := runOutput(repoPath, "lfs", "fsck"); err != nil {
+		return fmt.Errorf("LFS objects are corrupt or missing: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package gitutil
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// StartMaintenance registers the repository for git's background maintenance (prefetching,
+// commit-graph updates, incremental repacking) and makes sure the scheduler runs it
+// ('git maintenance start').
+func StartMaintenance(repoPath string) error {
+	if _, err := runOutput(repoPath, "maintenance", "start"); err != nil {
+		return fmt.Errorf("failed to start git maintenance: %w", err)
+	}
+	return nil
+}
+
+// RegisterMaintenance adds the repository to the repositories maintained by an already running
+// scheduler, without touching the scheduler ('git maintenance register').
+func RegisterMaintenance(repoPath string) error {
+	if _, err := runOutput(repoPath, "maintenance", "register"); err != nil {
+		return fmt.Errorf("failed to register for git maintenance: %w", err)
+	}
+	return nil
+}
+
+// UnregisterMaintenance removes path from the repositories maintained in the background. Unlike
+// 'git maintenance unregister', it works when no repository is at path anymore, e.g. after a move.
+func UnregisterMaintenance(path string) error {
+	if !maintenanceRepos()[path] {
+		return nil
+	}
+	if _, err := runOutput("", "config", "--global", "--fixed-value", "--unset-all", "maintenance.repo", path); err != nil {
+		return fmt.Errorf("failed to unregister %s from git maintenance: %w", path, err)
+	}
+	return nil
+}
+
+// MaintenanceRegistered reports whether the repository is registered for background maintenance.
+// git records the path with symlinks resolved.
+func MaintenanceRegistered(repoPath string) bool {
+	repos := maintenanceRepos()
+	if repos[repoPath] {
+		return true
+	}
+	resolved, err := filepath.EvalSymlinks(repoPath)
+	return err == nil && repos[resolved]
+}
+
+// maintenanceRepos returns the repositories registered for background maintenance in the
+// global git config (maintenance.repo).
+func maintenanceRepos() map[string]bool {
+	repos := make(map[string]bool)
+	out, err := runOutput("", "config", "--global", "--get-all", "maintenance.repo")
+	if err != nil {
+		return repos // Exit code 1: none registered.
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			repos[filepath.Clean(line)] = true
+		}
+	}
+	return repos
+}
@@ -0,0 +1,23 @@
+package gitutil
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// CloneMirror creates a bare mirror of a repository at mirrorPath ('git clone --mirror').
+// The parent directory of mirrorPath must exist.
+func CloneMirror(repoURL, mirrorPath string, verbose bool) error {
+	if verbose {
+		fmt.Printf("Executing: git clone --mirror %s %s\n", repoURL, mirrorPath)
+	}
+	return runQuiet(filepath.Dir(mirrorPath), "clone", "--mirror", "--quiet", repoURL, mirrorPath)
+}
+
+// UpdateMirror fetches all refs of a bare mirror from its origin, removing refs deleted there.
+func UpdateMirror(mirrorPath string, verbose bool) error {
+	if verbose {
+		fmt.Printf("Executing: git -C %s remote update --prune\n", mirrorPath)
+	}
+	return runQuiet(mirrorPath, "remote", "update", "--prune")
+}
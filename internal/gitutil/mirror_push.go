@@ -0,0 +1,32 @@
+package gitutil
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// mirrorRefspecs are pushed to a mirror remote: every local branch and tag, under the same name.
+var mirrorRefspecs = []string{"refs/heads/*:refs/heads/*", "refs/tags/*:refs/tags/*"}
+
+// RemoteURL returns the URL of the named remote of the repository, or an empty string if the
+// remote doesn't exist.
+func RemoteURL(repoPath, remote string) string {
+	return ConfigValue(repoPath, "remote."+remote+".url")
+}
+
+// SetMirrorRemote points the named remote of the repository at url, adding the remote if it
+// doesn't exist yet. The remote isn't fetched from.
+func SetMirrorRemote(repoPath, remote, url string) error {
+	if RemoteURL(repoPath, remote) == "" {
+		return runQuiet(repoPath, "remote", "add", remote, url)
+	}
+	return runQuiet(repoPath, "remote", "set-url", remote, url)
+}
+
+// PushMirrorWithProgress force-pushes every local branch and tag of the repository to the named
+// remote, calling progress with each line of git's progress output. Branches and tags that only
+// exist on the remote are kept, so deleting a branch locally never deletes its backup.
+func PushMirrorWithProgress(repoPath, remote string, progress func(line string)) error {
+	args := append([]string{"-C", repoPath, "push", "--progress", "--force", remote}, mirrorRefspecs...)
+	return runWithProgress(exec.Command("git", args...), fmt.Sprintf("git push to %s failed for %s", remote, repoPath), progress)
+}
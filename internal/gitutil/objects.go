@@ -0,0 +1,62 @@
+package gitutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CountObjects returns the number of objects in the repository's object database and their
+// size on disk in bytes, loose and packed, as reported by 'git count-objects -v'. Objects
+// borrowed from alternates are not included.
+func CountObjects(repoPath string) (objects int, size int64, err error) {
+	out, err := runOutput(repoPath, "count-objects", "-v")
+	if err != nil {
+		return 0, 0, err
+	}
+	found := false
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "count":
+			found = true
+			objects += int(n)
+		case "in-pack":
+			objects += int(n)
+		case "size", "size-pack": // In KiB
+			size += n * 1024
+		}
+	}
+	if !found {
+		return 0, 0, fmt.Errorf("unexpected output of git count-objects in %s: %q", repoPath, out)
+	}
+	return objects, size, nil
+}
+
+// ReclaimableSize returns how many bytes of the repository's object database a full
+// 'git gc' could free at most: the size of its loose objects, which get packed, and of
+// garbage files left behind by interrupted operations.
+func ReclaimableSize(repoPath string) (int64, error) {
+	out, err := runOutput(repoPath, "count-objects", "-v")
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || (key != "size" && key != "size-garbage") {
+			continue
+		}
+		if n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			size += n * 1024 // In KiB
+		}
+	}
+	return size, nil
+}
@@ -0,0 +1,67 @@
+package gitutil
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// IsPartialClone reports whether the repository is a partial clone whose objects are fetched
+// from origin on demand (see 'git clone --filter').
+func IsPartialClone(repoPath string) bool {
+	out, err := exec.Command("git", "-C", repoPath, "config", "--bool", "remote.origin.promisor").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// IsSparse reports whether only part of the repository's tree is checked out (see
+// 'git sparse-checkout').
+func IsSparse(repoPath string) bool {
+	out, err := exec.Command("git", "-C", repoPath, "config", "--bool", "core.sparseCheckout").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// SetSparseCheckout limits the checked out files of the repository to the given directories
+// (and the files at its top level), using a cone mode sparse checkout.
+func SetSparseCheckout(repoPath string, dirs []string, verbose bool) error {
+	args := append([]string{"sparse-checkout", "set", "--cone", "--"}, dirs...)
+	if verbose {
+		fmt.Printf("Executing: git -C %s %s\n", repoPath, strings.Join(args, " "))
+	}
+	return runQuiet(repoPath, args...)
+}
+
+// DisableSparseCheckout checks out the repository's whole tree again.
+func DisableSparseCheckout(repoPath string, verbose bool) error {
+	if verbose {
+		fmt.Printf("Executing: git -C %s sparse-checkout disable\n", repoPath)
+	}
+	return runQuiet(repoPath, "sparse-checkout", "disable")
+}
+
+// FetchMissingObjects turns a partial clone into a complete one: the object filter is
+// removed and all objects are fetched from origin again, after which origin isn't needed to
+// provide missing objects anymore. It needs git 2.36 or newer ('git fetch --refetch').
+func FetchMissingObjects(repoPath string, verbose bool) error {
+	if err := unsetConfig(repoPath, "remote.origin.partialclonefilter"); err != nil {
+		return err
+	}
+	if verbose {
+		fmt.Printf("Executing: git -C %s fetch --refetch --tags origin\n", repoPath)
+	}
+	if err := runQuiet(repoPath, "fetch", "--refetch", "--tags", "--quiet", "origin"); err != nil {
+		return err
+	}
+	return unsetConfig(repoPath, "remote.origin.promisor")
+}
+
+// unsetConfig removes all values of a key from the repository's config; it is not an error
+// if the key isn't set.
+func unsetConfig(repoPath, key string) error {
+	err := runQuiet(repoPath, "config", "--unset-all", key)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 5 { // The key isn't set
+		return nil
+	}
+	return err
+}
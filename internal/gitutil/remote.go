@@ -0,0 +1,123 @@
+package gitutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Kinds of problems CheckRemote reports.
+const (
+	RemoteNotFound     = "not found"     // The repository doesn't exist (anymore), or is private
+	RemoteAccessDenied = "access denied" // The credentials were refused
+	RemoteMoved        = "moved"         // The server redirects to another URL
+	RemoteUnreachable  = "unreachable"   // The host can't be resolved or reached, or didn't answer in time
+	RemoteFailed       = "failed"        // Anything else
+)
+
+// RemoteProblem describes why the origin of a repository can't be used.
+type RemoteProblem struct {
+	Kind   string // One of the Remote* constants
+	Detail string // The most relevant line of git's output
+	NewURL string // Where the server redirects to, for RemoteMoved
+}
+
+// remoteFailureKinds maps messages of git, ssh and git hosts to the kind of problem they
+// indicate, checked in order: hosts answer "not found" for private repositories too.
+var remoteFailureKinds = []struct{ message, kind string }{
+	{"repository not found", RemoteNotFound},
+	{"does not appear to be a git repository", RemoteNotFound},
+	{"does not exist", RemoteNotFound},
+	{"returned error: 404", RemoteNotFound},
+	{"permission denied", RemoteAccessDenied},
+	{"authentication failed", RemoteAccessDenied},
+	{"could not read username", RemoteAccessDenied},
+	{"could not read password", RemoteAccessDenied},
+	{"terminal prompts disabled", RemoteAccessDenied},
+	{"returned error: 403", RemoteAccessDenied},
+	{"host key verification failed", RemoteAccessDenied},
+	{"could not resolve host", RemoteUnreachable},
+	{"connection refused", RemoteUnreachable},
+	{"connection timed out", RemoteUnreachable},
+	{"operation timed out", RemoteUnreachable},
+	{"network is unreachable", RemoteUnreachable},
+	{"no route to host", RemoteUnreachable},
+	{"failed to connect to", RemoteUnreachable},
+}
+
+// CheckRemote runs 'git ls-remote --exit-code origin' in the repository, with its git
+// configuration (e.g. core.sshCommand), and returns nil if origin answered with its refs.
+// An empty remote repository counts as alive. The check gives up after timeout.
+func CheckRemote(repoPath string, timeout time.Duration) *RemoteProblem {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "ls-remote", "--exit-code", "--heads", "origin")
+	var errb bytes.Buffer
+	cmd.Stderr = &errb
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_SSH_COMMAND="+batchSSHCommand(repoPath))
+	err := cmd.Run()
+	stderr := errb.String()
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		return &RemoteProblem{Kind: RemoteUnreachable, Detail: "no answer within " + timeout.String()}
+	case err == nil, errors.As(err, &exitErr) && exitErr.ExitCode() == 2:
+		// Exit code 2: the remote has no branches yet.
+		for _, line := range strings.Split(stderr, "\n") {
+			if newURL, ok := strings.CutPrefix(strings.TrimSpace(line), "warning: redirecting to "); ok {
+				return &RemoteProblem{Kind: RemoteMoved, Detail: "redirects to " + newURL, NewURL: newURL}
+			}
+		}
+		return nil
+	}
+
+	lower := strings.ToLower(stderr)
+	kind := RemoteFailed
+	for _, f := range remoteFailureKinds {
+		if strings.Contains(lower, f.message) {
+			kind = f.kind
+			break
+		}
+	}
+	return &RemoteProblem{Kind: kind, Detail: firstErrorLine(stderr, err)}
+}
+
+// batchSSHCommand returns the ssh command of the repository (core.sshCommand, or ssh) with
+// BatchMode enabled, so ssh fails instead of prompting for passphrases or host keys.
+func batchSSHCommand(repoPath string) string {
+	sshCommand := os.Getenv("GIT_SSH_COMMAND")
+	if sshCommand == "" {
+		sshCommand, _ = runOutput(repoPath, "config", "core.sshCommand")
+		sshCommand = strings.TrimSpace(sshCommand)
+	}
+	if sshCommand == "" {
+		sshCommand = "ssh"
+	}
+	return sshCommand + " -o BatchMode=yes"
+}
+
+// firstErrorLine returns the first line of stderr that reports an error, or err itself.
+func firstErrorLine(stderr string, err error) string {
+	var fallback string
+	for _, line := range strings.Split(stderr, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "fatal:") || strings.HasPrefix(line, "ERROR:") || strings.HasPrefix(line, "remote:") {
+			return line
+		}
+		if fallback == "" {
+			fallback = line
+		}
+	}
+	if fallback != "" {
+		return fallback
+	}
+	return err.Error()
+}
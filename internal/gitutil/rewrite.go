@@ -0,0 +1,63 @@
+package gitutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// URLRewrite is a single 'url.<Base>.insteadOf <Prefix>' rule from the user's git configuration.
+// URLs starting with Prefix are rewritten by git to start with Base instead.
+type URLRewrite struct {
+	Base   string
+	Prefix string
+}
+
+// GetURLRewrites reads all url.<base>.insteadOf rules from the user's git configuration
+// (system and global). An empty slice is returned if none are configured.
+func GetURLRewrites() ([]URLRewrite, error) {
+	cmd := exec.Command("git", "config", "--get-regexp", `^url\..*\.insteadof$`)
+
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return nil, nil // Exit code 1 means no matching keys
+		}
+		return nil, fmt.Errorf("failed to read url.insteadOf rules from git config: %w. Stderr:\n%s", err, errb.String())
+	}
+
+	var rules []URLRewrite
+	scanner := bufio.NewScanner(&outb)
+	for scanner.Scan() {
+		// Lines look like: url.git@github.com:.insteadof https://github.com/
+		key, prefix, found := strings.Cut(scanner.Text(), " ")
+		if !found || prefix == "" {
+			continue
+		}
+		base := strings.TrimSuffix(strings.TrimPrefix(key, "url."), ".insteadof")
+		rules = append(rules, URLRewrite{Base: base, Prefix: prefix})
+	}
+	return rules, nil
+}
+
+// RewriteURL applies insteadOf rules to rawURL the way git does: the rule with the
+// longest matching prefix wins, and at most one rule is applied.
+func RewriteURL(rawURL string, rules []URLRewrite) string {
+	best := -1
+	for i, rule := range rules {
+		if strings.HasPrefix(rawURL, rule.Prefix) && (best < 0 || len(rule.Prefix) > len(rules[best].Prefix)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return rawURL
+	}
+	return rules[best].Base + strings.TrimPrefix(rawURL, rules[best].Prefix)
+}
@@ -0,0 +1,115 @@
+package gitutil
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// runOutput runs a git subcommand in repoPath and returns its stdout, or an error including
+// git's stderr if it fails.
+func runOutput(repoPath string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", repoPath}, args...)...)
+
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if err := cmd.Run(); err != nil {
+		errMsg := fmt.Sprintf("git %s failed for %s", args[0], repoPath)
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			errMsg = fmt.Sprintf("%s (exit code %d)", errMsg, exitErr.ExitCode())
+		}
+		return "", fmt.Errorf("%s: %w. Stderr:\n%s", errMsg, err, strings.TrimSpace(errb.String()))
+	}
+	return outb.String(), nil
+}
+
+// LocalBranches returns the names of the repository's local branches.
+func LocalBranches(repoPath string) ([]string, error) {
+	out, err := runOutput(repoPath, "for-each-ref", "--format=%(refname:short)", "refs/heads/")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// FetchBranch copies a local branch of the repository at source into repoPath, overwriting
+// a branch of the same name there, even if it is checked out. Only the ref and the objects
+// it needs are copied; the working tree is not updated.
+func FetchBranch(repoPath, source, branch string) error {
+	refspec := "+refs/heads/" + branch + ":refs/heads/" + branch
+	return runQuiet(repoPath, "fetch", "--quiet", "--no-tags", "--update-head-ok", source, refspec)
+}
+
+// StashCreate records the uncommitted changes to tracked files of the repository as a stash
+// commit without changing the working tree or any ref ('git stash create'). It returns an
+// empty string if there are no changes.
+func StashCreate(repoPath string) (string, error) {
+	out, err := runOutput(repoPath, "stash", "create")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// UpdateRef points ref (e.g. "refs/fussy-git/salvage") at commit.
+func UpdateRef(repoPath, ref, commit string) error {
+	return runQuiet(repoPath, "update-ref", ref, commit)
+}
+
+// FetchStash fetches the commit at ref in the repository at source and adds it to the
+// stash of repoPath with the given message, so it can be applied with 'git stash pop'.
+func FetchStash(repoPath, source, ref, message string) error {
+	if err := runQuiet(repoPath, "fetch", "--quiet", "--no-tags", source, ref); err != nil {
+		return err
+	}
+	commit, err := runOutput(repoPath, "rev-parse", "--verify", "FETCH_HEAD")
+	if err != nil {
+		return err
+	}
+	return runQuiet(repoPath, "stash", "store", "-m", message, strings.TrimSpace(commit))
+}
+
+// UntrackedFiles returns the paths, relative to the repository root, of files that are
+// neither tracked nor ignored.
+func UntrackedFiles(repoPath string) ([]string, error) {
+	out, err := runOutput(repoPath, "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range strings.Split(out, "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// CheckoutClean checks out branch, discarding any differences between the working tree and
+// the branch. It is meant for fresh clones without local changes.
+func CheckoutClean(repoPath, branch string) error {
+	return runQuiet(repoPath, "checkout", "--quiet", "--force", branch)
+}
+
+// StashCount returns the number of entries in the stash of the repository.
+func StashCount(repoPath string) (int, error) {
+	out, err := runOutput(repoPath, "stash", "list")
+	if err != nil {
+		return 0, err
+	}
+	if out = strings.TrimSpace(out); out == "" {
+		return 0, nil
+	}
+	return strings.Count(out, "\n") + 1, nil
+}
+
+// CreateBundle writes every ref of the repository and the objects they need to a single
+// bundle file, from which it can be cloned again ('git clone <bundle>').
+func CreateBundle(repoPath, bundlePath string) error {
+	return runQuiet(repoPath, "bundle", "create", bundlePath, "--all")
+}
@@ -0,0 +1,33 @@
+package gitutil
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// IsShallow reports whether the repository is a shallow clone, i.e. its history is truncated
+// (see 'git clone --depth').
+func IsShallow(repoPath string) bool {
+	out, err := exec.Command("git", "-C", repoPath, "rev-parse", "--is-shallow-repository").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// Unshallow fetches the complete history of a shallow clone from origin. Shallow clones
+// only track their default branch, so a single-branch fetch refspec is widened to all
+// branches first.
+func Unshallow(repoPath string, verbose bool) error {
+	refspecs, err := runOutput(repoPath, "config", "--get-all", "remote.origin.fetch")
+	if err == nil && !strings.Contains(refspecs, "*") {
+		if verbose {
+			fmt.Printf("Executing: git -C %s remote set-branches origin '*'\n", repoPath)
+		}
+		if err := runQuiet(repoPath, "remote", "set-branches", "origin", "*"); err != nil {
+			return err
+		}
+	}
+	if verbose {
+		fmt.Printf("Executing: git -C %s fetch --unshallow --tags origin\n", repoPath)
+	}
+	return runQuiet(repoPath, "fetch", "--unshallow", "--tags", "--quiet", "origin")
+}
@@ -0,0 +1,61 @@
+package gitutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ResolveSSHHostAlias returns the real hostname ssh connects to for host, taking Host
+// aliases from ~/.ssh/config (and any other ssh configuration) into account.
+// It runs 'ssh -G <host>', which prints the effective configuration without connecting.
+// If host isn't an alias, it is returned unchanged.
+func ResolveSSHHostAlias(host string) (string, error) {
+	cmd := exec.Command("ssh", "-G", host)
+
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to resolve ssh host '%s' with 'ssh -G': %w. Stderr:\n%s", host, err, errb.String())
+	}
+
+	scanner := bufio.NewScanner(&outb)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), " ")
+		if found && strings.EqualFold(key, "hostname") && value != "" {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return host, nil
+}
+
+// CheckSSHAuth connects to git@host the way 'ssh -T' does and reports whether the server
+// accepted the key. Git hosts refuse the shell after authenticating, so any exit code but
+// ssh's own 255 means authentication worked. The server's greeting (e.g. "Hi user! You've
+// successfully authenticated...") is returned. sshCommand replaces "ssh" if it is set, e.g.
+// "ssh -i ~/.ssh/id_work".
+func CheckSSHAuth(host, sshCommand string) (string, error) {
+	if sshCommand == "" {
+		sshCommand = "ssh"
+	}
+	args := []string{"-c", sshCommand + ` "$@"`, "ssh", "-T", "-o", "BatchMode=yes", "-o", "ConnectTimeout=10", "git@" + host}
+	cmd := exec.Command("sh", args...)
+
+	var outb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &outb
+
+	err := cmd.Run()
+	output := strings.TrimSpace(outb.String())
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() != 255 {
+		return output, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("ssh to git@%s failed: %w. Output:\n%s", host, err, output)
+	}
+	return output, nil
+}
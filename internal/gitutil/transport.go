@@ -0,0 +1,41 @@
+package gitutil
+
+import "strings"
+
+// transportFailures are messages git and ssh print when the remote can't be reached or
+// refuses the credentials, as opposed to e.g. a repository that doesn't exist.
+var transportFailures = []string{
+	"Permission denied (publickey",
+	"Host key verification failed",
+	"Could not resolve hostname",
+	"Could not resolve host",
+	"Connection refused",
+	"Connection timed out",
+	"Connection reset",
+	"Operation timed out",
+	"Network is unreachable",
+	"No route to host",
+	"Failed to connect to",
+	"kex_exchange_identification",
+	"Authentication failed",
+	"could not read Username",
+	"could not read Password",
+	"terminal prompts disabled",
+	"SSL certificate problem",
+	"The requested URL returned error: 403",
+}
+
+// IsTransportError reports whether a failed git network operation failed because the remote
+// couldn't be reached or rejected the credentials, so another protocol might work.
+func IsTransportError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	for _, failure := range transportFailures {
+		if strings.Contains(msg, failure) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package gitutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ErrNoUpstream is returned when the current branch of a repository has no upstream branch configured
+// (or HEAD is detached).
+var ErrNoUpstream = errors.New("no upstream branch configured")
+
+// AheadBehind returns how many commits the current branch of the repository is ahead of and behind
+// its upstream branch. It compares against the remote-tracking branch as of the last fetch and
+// does not contact the remote itself.
+func AheadBehind(repoPath string) (ahead, behind int, err error) {
+	return AheadBehindRefs(repoPath, "HEAD", "@{upstream}")
+}
+
+// AheadBehindRefs returns how many commits ref is ahead of and behind upstream, e.g. for
+// ref "main" and upstream "origin/main".
+func AheadBehindRefs(repoPath, ref, upstream string) (ahead, behind int, err error) {
+	cmd := exec.Command("git", "-C", repoPath, "rev-list", "--left-right", "--count", ref+"..."+upstream)
+
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if err := cmd.Run(); err != nil {
+		stdError := errb.String()
+		if strings.Contains(stdError, "no upstream") || strings.Contains(stdError, "HEAD does not point to a branch") {
+			return 0, 0, ErrNoUpstream
+		}
+		errMsg := fmt.Sprintf("failed to compare %s with %s in %s", ref, upstream, repoPath)
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			errMsg = fmt.Sprintf("%s (exit code %d)", errMsg, exitErr.ExitCode())
+		}
+		return 0, 0, fmt.Errorf("%s: %w. Stderr:\n%s", errMsg, err, stdError)
+	}
+
+	fields := strings.Fields(outb.String())
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected output from git rev-list for %s: %q", repoPath, outb.String())
+	}
+	if ahead, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, fmt.Errorf("unexpected output from git rev-list for %s: %w", repoPath, err)
+	}
+	if behind, err = strconv.Atoi(fields[1]); err != nil {
+		return 0, 0, fmt.Errorf("unexpected output from git rev-list for %s: %w", repoPath, err)
+	}
+	return ahead, behind, nil
+}
+
+// LocalOnlyCommits returns how many commits on the local branches of the repository aren't
+// on any remote-tracking branch, i.e. would be lost with the clone. Like AheadBehind, it
+// relies on the last fetch.
+func LocalOnlyCommits(repoPath string) (int, error) {
+	out, err := runOutput(repoPath, "rev-list", "--count", "--branches", "--not", "--remotes")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(out))
+}
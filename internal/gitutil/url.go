@@ -1,6 +1,8 @@
 package gitutil
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/url"
 	"path/filepath"
@@ -18,8 +20,12 @@ type ParsedGitURL struct {
 	Path        string // Path part of the URL, e.g., "owner/project.git" or "owner/project"
 	RepoName    string // The name of the repository, e.g., "project"
 	IsSSH       bool   // True if the URL is an SSH URL
+	SourcePath  string // Absolute path of the repository, for local paths and file:// URLs
 }
 
+// LocalDomain is the domain of repositories cloned from a local path or file:// URL.
+const LocalDomain = "local"
+
 // scpLikeURLRegex matches SCP-like SSH URLs, e.g., git@github.com:user/repo.git
 // It captures:
 // 1. User (e.g., "git")
@@ -27,7 +33,79 @@ type ParsedGitURL struct {
 // 3. Path (e.g., "user/repo.git")
 var scpLikeURLRegex = regexp.MustCompile(`^([a-zA-Z0-9_.-]+)@([a-zA-Z0-9.-]+):(.*)$`)
 
-// ParseGitURL parses a Git repository URL (HTTPS or SSH) into its components.
+// webPathMarkers are path segments that, following <owner>/<repo>, point at a page of a
+// provider's web UI rather than at the repository itself.
+// e.g. https://github.com/owner/repo/tree/main/pkg or https://github.com/owner/repo/pull/42
+var webPathMarkers = map[string]bool{
+	"tree": true, "blob": true, "raw": true, "blame": true, "commit": true, "commits": true,
+	"pull": true, "pulls": true, "issues": true, "releases": true, "tags": true, "branches": true,
+	"actions": true, "wiki": true, "compare": true, "src": true, "pull-requests": true,
+}
+
+// fixedDepthHosts are the providers whose repositories are always exactly <owner>/<repo>, so
+// anything after that is a web UI page. Elsewhere, e.g. on GitLab with nested groups, a
+// repository may be named like a marker, so only GitLab's /-/ separator is recognized.
+var fixedDepthHosts = map[string]bool{
+	"github.com": true, "bitbucket.org": true, "codeberg.org": true,
+}
+
+// SanitizeURL cleans up repository URLs copied from a browser's address bar so they can be cloned:
+// query strings, fragments and trailing slashes are removed, as are GitLab's /-/... pages and,
+// on providers with <owner>/<repo> paths (github.com, bitbucket.org, codeberg.org), web UI paths
+// such as /tree/<branch>/..., /blob/..., /pull/<n> or /issues/<n>.
+// Only http(s) URLs are touched; anything else is returned unchanged.
+//
+//	https://github.com/owner/repo/tree/main/pkg?tab=readme -> https://github.com/owner/repo
+//	https://gitlab.com/group/sub/project/-/merge_requests/7 -> https://gitlab.com/group/sub/project
+func SanitizeURL(rawURL string) string {
+	trimmed := strings.TrimSpace(rawURL)
+	u, err := url.Parse(trimmed)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return rawURL
+	}
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	path := strings.Trim(u.Path, "/")
+	if before, _, found := strings.Cut(path, "/-/"); found {
+		path = before // GitLab puts all web UI pages below /-/
+	}
+	segments := strings.Split(path, "/")
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if fixedDepthHosts[host] && len(segments) > 2 && webPathMarkers[segments[2]] {
+		segments = segments[:2]
+	}
+	u.Path = "/" + strings.Join(segments, "/")
+	u.RawPath = ""
+	return u.String()
+}
+
+// ExpandShortcut expands a "<prefix>:<owner>/<repo>" shortcut to an HTTPS URL using the
+// prefix -> domain mapping in shortcuts. Prefixes are matched case-insensitively.
+// Anything that isn't a known shortcut is returned unchanged.
+//
+//	gh:spf13/cobra -> https://github.com/spf13/cobra
+func ExpandShortcut(rawURL string, shortcuts map[string]string) string {
+	prefix, path, found := strings.Cut(strings.TrimSpace(rawURL), ":")
+	if !found || strings.HasPrefix(path, "//") || strings.Contains(prefix, "@") {
+		return rawURL // A regular URL, or SCP-like SSH syntax
+	}
+	path = strings.Trim(path, "/")
+	if !strings.Contains(path, "/") {
+		return rawURL
+	}
+	for shortcut, domain := range shortcuts {
+		if strings.EqualFold(shortcut, prefix) {
+			return "https://" + domain + "/" + path
+		}
+	}
+	return rawURL
+}
+
+// ParseGitURL parses a Git repository URL (HTTPS or SSH) into its components. Local paths and
+// file:// URLs are parsed too; see LocalKey for where they go.
 func ParseGitURL(repoURL string) (*ParsedGitURL, error) {
 	parsed := &ParsedGitURL{OriginalURL: repoURL}
 
@@ -54,6 +132,10 @@ func ParseGitURL(repoURL string) (*ParsedGitURL, error) {
 		return nil, fmt.Errorf("could not parse URL '%s': %w", repoURL, err)
 	}
 
+	if u.Scheme == "file" {
+		return parseLocalPath(parsed, u.Path)
+	}
+
 	parsed.Scheme = u.Scheme
 	parsed.Host = u.Host // For https://user@host/path, Host includes user. We want u.Hostname()
 	parsed.Domain = u.Hostname()
@@ -82,21 +164,8 @@ func ParseGitURL(repoURL string) (*ParsedGitURL, error) {
 		// A more robust solution might try to re-evaluate or specifically handle local paths.
 		return nil, fmt.Errorf("ambiguous URL format (potentially SCP-like or local path not fully parsed): %s", repoURL)
 	} else if parsed.Scheme == "" && !strings.Contains(repoURL, ":") {
-		// This is likely a local path, e.g., /path/to/repo or ./repo
-		// fussy-git primarily targets remote URLs for its structured organization.
-		// For now, we'll treat local paths as needing special handling or being out of scope
-		// for the domain/user/project structure.
-		// However, to make it somewhat work, we can try to extract a "repo name".
-		// The "domain" and "user" would be undefined or set to a placeholder.
-		parsed.Scheme = "file" // Treat as local file
-		parsed.Path = strings.TrimSuffix(repoURL, ".git")
-		parsed.RepoName = strings.TrimSuffix(filepath.Base(parsed.Path), ".git")
-		parsed.Domain = "local" // Placeholder domain for local paths
-		parsed.User = ""        // No user for local paths in this context
-		parsed.IsSSH = false
-		// Note: This handling of local paths is basic.
-		// A full implementation might require different logic for GetLocalPath.
-		// return nil, fmt.Errorf("local file paths are not fully supported for structured cloning: %s", repoURL)
+		// A local path, e.g., /path/to/repo or ./repo
+		return parseLocalPath(parsed, repoURL)
 	}
 
 	if parsed.Domain == "" || parsed.RepoName == "" {
@@ -106,6 +175,46 @@ func ParseGitURL(repoURL string) (*ParsedGitURL, error) {
 	return parsed, nil
 }
 
+// parseLocalPath fills in parsed for the repository at the local path repoPath, which is made
+// absolute. Its domain is LocalDomain and its path is the LocalKey of the repository, since the
+// directories a local repository lives in say nothing about who owns it.
+func parseLocalPath(parsed *ParsedGitURL, repoPath string) (*ParsedGitURL, error) {
+	if repoPath == "" {
+		return nil, fmt.Errorf("could not determine the path of the local repository: %s", parsed.OriginalURL)
+	}
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve local repository path '%s': %w", repoPath, err)
+	}
+	// The work tree is meant when pointing at its .git directory.
+	absPath = strings.TrimSuffix(absPath, string(filepath.Separator)+".git")
+
+	parsed.Scheme = "file"
+	parsed.User = ""
+	parsed.Host = ""
+	parsed.Domain = LocalDomain
+	parsed.SourcePath = absPath
+	parsed.RepoName = strings.TrimSuffix(filepath.Base(absPath), ".git")
+	parsed.Path = LocalKey(absPath)
+	parsed.IsSSH = false
+	return parsed, nil
+}
+
+// LocalKey returns the directory a repository cloned from the local path sourcePath is kept in:
+// its name followed by a short hash of the absolute path, so that repositories with the same
+// name in different places don't collide.
+//
+//	/srv/git/tool.git -> tool-<first 8 hex digits of the SHA-256 of "/srv/git/tool.git">
+func LocalKey(sourcePath string) string {
+	sum := sha256.Sum256([]byte(filepath.Clean(sourcePath)))
+	return strings.TrimSuffix(filepath.Base(sourcePath), ".git") + "-" + hex.EncodeToString(sum[:4])
+}
+
+// IsLocal reports whether the URL is a local path or file:// URL.
+func (pu *ParsedGitURL) IsLocal() bool {
+	return pu.Scheme == "file"
+}
+
 // GetLocalPath constructs the full local filesystem path for the repository
 // based on FUSSY_GIT_HOME, domain, user (if present), and repository path.
 // Example:
@@ -122,6 +231,17 @@ func (pu *ParsedGitURL) GetLocalPath(fussyGitHome string) string {
 	return filepath.Join(fussyGitHome, pu.Domain, pu.Path)
 }
 
+// Owner returns the first segment of the repository path, i.e. the user, organization
+// or top-level group that owns the repository.
+// e.g., "spf13" for github.com/spf13/cobra, "group" for gitlab.com/group/subgroup/project
+func (pu *ParsedGitURL) Owner() string {
+	owner, _, found := strings.Cut(pu.Path, "/")
+	if !found {
+		return ""
+	}
+	return owner
+}
+
 // GetNormalizedFSPath returns a string representation suitable for filesystem paths,
 // combining domain and the rest of the path.
 // e.g., github.com/user/project
@@ -172,3 +292,64 @@ func (pu *ParsedGitURL) ToHTTPS() (string, error) {
 	}
 	return "", fmt.Errorf("cannot convert URL scheme '%s' to HTTPS (Original: %s)", pu.Scheme, pu.OriginalURL)
 }
+
+// ReplaceOwner replaces the owner at the start of the repository path in rawURL, keeping
+// everything else (scheme, user, host, .git suffix) as written. oldOwner may span several
+// path segments (e.g. "group/subgroup") and is compared case-insensitively, since most
+// providers treat owner names that way. It reports false if rawURL isn't owned by oldOwner.
+//
+//	ReplaceOwner("git@github.com:oldcorp/tool.git", "oldcorp", "newcorp") -> "git@github.com:newcorp/tool.git", true
+func ReplaceOwner(rawURL, oldOwner, newOwner string) (string, bool) {
+	oldOwner = strings.Trim(oldOwner, "/")
+	newOwner = strings.Trim(newOwner, "/")
+	replacePath := func(path string) (string, bool) {
+		trimmed := strings.TrimPrefix(path, "/")
+		if len(trimmed) <= len(oldOwner) || trimmed[len(oldOwner)] != '/' || !strings.EqualFold(trimmed[:len(oldOwner)], oldOwner) {
+			return "", false
+		}
+		return strings.TrimSuffix(path, trimmed) + newOwner + trimmed[len(oldOwner):], true
+	}
+	return replaceURLPath(rawURL, replacePath)
+}
+
+// ReplaceRepoPath replaces the whole repository path (e.g. "owner/repo") of rawURL, keeping
+// everything else (scheme, user, host, .git suffix) as written, like ReplaceOwner does for the
+// owner. oldPath is compared case-insensitively. It reports false if rawURL isn't for oldPath.
+//
+//	ReplaceRepoPath("https://github.com/me/tool.git", "me/tool", "me/tool2") -> "https://github.com/me/tool2.git", true
+func ReplaceRepoPath(rawURL, oldPath, newPath string) (string, bool) {
+	oldPath = strings.Trim(oldPath, "/")
+	newPath = strings.Trim(newPath, "/")
+	replacePath := func(path string) (string, bool) {
+		trimmed := strings.TrimPrefix(path, "/")
+		rest := strings.TrimSuffix(strings.TrimSuffix(trimmed, "/"), ".git")
+		if !strings.EqualFold(rest, oldPath) {
+			return "", false
+		}
+		return strings.TrimSuffix(path, trimmed) + newPath + trimmed[len(rest):], true
+	}
+	return replaceURLPath(rawURL, replacePath)
+}
+
+// replaceURLPath returns rawURL, an SCP-like or regular URL, with its path changed by replace.
+func replaceURLPath(rawURL string, replace func(path string) (string, bool)) (string, bool) {
+	if matches := scpLikeURLRegex.FindStringSubmatch(rawURL); len(matches) == 4 {
+		path, ok := replace(matches[3])
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf("%s@%s:%s", matches[1], matches[2], path), true
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	path, ok := replace(u.Path)
+	if !ok {
+		return "", false
+	}
+	u.Path = path
+	u.RawPath = ""
+	return u.String(), true
+}
@@ -0,0 +1,42 @@
+package gitutil
+
+import "testing"
+
+func TestSanitizeURL(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"clean URL", "https://github.com/spf13/cobra", "https://github.com/spf13/cobra"},
+		{"git suffix kept", "https://github.com/spf13/cobra.git", "https://github.com/spf13/cobra.git"},
+		{"surrounding space", "  https://github.com/spf13/cobra\n", "https://github.com/spf13/cobra"},
+		{"trailing slash", "https://github.com/spf13/cobra/", "https://github.com/spf13/cobra"},
+		{"query and fragment", "https://github.com/spf13/cobra?tab=readme-ov-file#install", "https://github.com/spf13/cobra"},
+		{"github tree", "https://github.com/spf13/cobra/tree/main/doc", "https://github.com/spf13/cobra"},
+		{"github blob", "https://github.com/spf13/cobra/blob/main/README.md#L10", "https://github.com/spf13/cobra"},
+		{"github pull request", "https://github.com/spf13/cobra/pull/42/files", "https://github.com/spf13/cobra"},
+		{"github issues", "https://github.com/spf13/cobra/issues", "https://github.com/spf13/cobra"},
+		{"github with www", "https://www.github.com/spf13/cobra/releases/tag/v1.0.0", "https://www.github.com/spf13/cobra"},
+		{"github host case", "https://GitHub.com/spf13/cobra/commits/main", "https://GitHub.com/spf13/cobra"},
+		{"github unknown page kept", "https://github.com/spf13/cobra/settings", "https://github.com/spf13/cobra/settings"},
+		{"bitbucket src", "https://bitbucket.org/owner/repo/src/master/", "https://bitbucket.org/owner/repo"},
+		{"bitbucket pull request", "https://bitbucket.org/owner/repo/pull-requests/3", "https://bitbucket.org/owner/repo"},
+		{"codeberg branches", "https://codeberg.org/owner/repo/branches", "https://codeberg.org/owner/repo"},
+		{"gitlab web page", "https://gitlab.com/group/sub/project/-/merge_requests/7", "https://gitlab.com/group/sub/project"},
+		{"gitlab tree", "https://gitlab.com/group/project/-/tree/main?ref_type=heads", "https://gitlab.com/group/project"},
+		{"gitlab group named like a marker", "https://gitlab.com/group/tree/project", "https://gitlab.com/group/tree/project"},
+		{"self-hosted /-/ page", "https://git.example.com/team/project/-/blob/main/x.go", "https://git.example.com/team/project"},
+		{"self-hosted marker kept", "https://git.example.com/team/project/tree/main", "https://git.example.com/team/project/tree/main"},
+		{"http", "http://github.com/spf13/cobra/tree/main", "http://github.com/spf13/cobra"},
+		{"ssh URL unchanged", "ssh://git@github.com/spf13/cobra/tree", "ssh://git@github.com/spf13/cobra/tree"},
+		{"scp-like unchanged", "git@github.com:spf13/cobra.git", "git@github.com:spf13/cobra.git"},
+		{"shortcut unchanged", "gh:spf13/cobra", "gh:spf13/cobra"},
+		{"local path unchanged", "/src/cobra/", "/src/cobra/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeURL(tt.in); got != tt.want {
+				t.Errorf("SanitizeURL(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
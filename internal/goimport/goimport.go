@@ -0,0 +1,127 @@
+package goimport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RepoRoot is the repository a Go import path resolves to.
+type RepoRoot struct {
+	Prefix  string // Import path prefix corresponding to the repository root, e.g. "golang.org/x/tools"
+	VCS     string // Version control system, e.g. "git"
+	RepoURL string // URL of the repository, e.g. "https://go.googlesource.com/tools"
+}
+
+// knownHosts are code hosts whose import paths map directly to repositories, so no
+// request is needed. The value is the number of path segments forming the repository root.
+var knownHosts = map[string]int{
+	"github.com":    3,
+	"bitbucket.org": 3,
+}
+
+// client is used for go-import lookups. A slow vanity server shouldn't hang the command.
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// Resolve determines the repository behind a Go import path the way 'go get' does:
+// import paths on well-known hosts map directly to their repositories; for everything
+// else (vanity import paths such as golang.org/x/tools) https://<path>?go-get=1 is
+// fetched and its <meta name="go-import"> tag is used.
+func Resolve(importPath string) (*RepoRoot, error) {
+	importPath = strings.Trim(importPath, "/")
+	host, _, _ := strings.Cut(importPath, "/")
+	if segments, known := knownHosts[host]; known {
+		parts := strings.Split(importPath, "/")
+		if len(parts) < segments {
+			return nil, fmt.Errorf("invalid import path '%s': expected %s/<owner>/<repo>", importPath, host)
+		}
+		prefix := strings.Join(parts[:segments], "/")
+		return &RepoRoot{Prefix: prefix, VCS: "git", RepoURL: "https://" + prefix}, nil
+	}
+
+	url := "https://" + importPath + "?go-get=1"
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up import path '%s': %w", importPath, err)
+	}
+	defer resp.Body.Close()
+	// Like the go command, look for the meta tags even on error pages; many servers
+	// serve them with a 404 for paths below the repository root.
+	roots, err := parseMetaGoImports(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go-import meta tags from %s: %w", url, err)
+	}
+
+	var match *RepoRoot
+	for i, root := range roots {
+		if importPath != root.Prefix && !strings.HasPrefix(importPath, root.Prefix+"/") {
+			continue
+		}
+		if match != nil {
+			return nil, fmt.Errorf("multiple go-import meta tags at %s match import path '%s'", url, importPath)
+		}
+		match = &roots[i]
+	}
+	if match == nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("no go-import meta tag found at %s (HTTP %s)", url, resp.Status)
+		}
+		return nil, fmt.Errorf("no go-import meta tag for '%s' found at %s", importPath, url)
+	}
+	if match.VCS == "mod" {
+		return nil, fmt.Errorf("'%s' is served by a module proxy (%s), not a repository", importPath, match.RepoURL)
+	}
+	return match, nil
+}
+
+// parseMetaGoImports returns the go-import meta tags in the <head> of an HTML document.
+// The document doesn't need to be well-formed XML; like the go command, the parser is lenient.
+func parseMetaGoImports(r io.Reader) ([]RepoRoot, error) {
+	d := xml.NewDecoder(r)
+	d.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		switch strings.ToLower(charset) {
+		case "utf-8", "ascii":
+			return input, nil
+		default:
+			return nil, fmt.Errorf("can't decode XML document using charset %q", charset)
+		}
+	}
+	d.Strict = false
+
+	var roots []RepoRoot
+	for {
+		t, err := d.RawToken()
+		if err != nil {
+			if err == io.EOF || len(roots) > 0 {
+				return roots, nil
+			}
+			return nil, err
+		}
+		if e, ok := t.(xml.StartElement); ok && strings.EqualFold(e.Name.Local, "body") {
+			return roots, nil
+		}
+		if e, ok := t.(xml.EndElement); ok && strings.EqualFold(e.Name.Local, "head") {
+			return roots, nil
+		}
+		e, ok := t.(xml.StartElement)
+		if !ok || !strings.EqualFold(e.Name.Local, "meta") || attrValue(e.Attr, "name") != "go-import" {
+			continue
+		}
+		if f := strings.Fields(attrValue(e.Attr, "content")); len(f) == 3 {
+			roots = append(roots, RepoRoot{Prefix: f[0], VCS: f[1], RepoURL: f[2]})
+		}
+	}
+}
+
+// attrValue returns the value of the attribute with the given name, or "".
+func attrValue(attrs []xml.Attr, name string) string {
+	for _, a := range attrs {
+		if strings.EqualFold(a.Name.Local, name) {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,117 @@
+package keyring
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// macKeychain stores secrets as generic passwords in the macOS Keychain through 'security'.
+type macKeychain struct{}
+
+func (macKeychain) Name() string { return "macOS Keychain" }
+
+func (macKeychain) available() error {
+	if runtime.GOOS != "darwin" {
+		return errUnavailable
+	}
+	_, err := exec.LookPath("security")
+	return err
+}
+
+func (macKeychain) Get(account string) (string, error) {
+	secret, stderr, err := runTool("", "security", "find-generic-password", "-s", Service, "-a", account, "-w")
+	if err != nil && strings.Contains(stderr, "could not be found") {
+		return "", ErrNotFound
+	}
+	return secret, err
+}
+
+// Set runs add-generic-password through 'security -i', which reads the command from stdin,
+// since the password can only be given as an argument and would show up in ps otherwise.
+func (macKeychain) Set(account, secret string) error {
+	if strings.ContainsAny(secret, "\r\n") {
+		return fmt.Errorf("secrets stored in the macOS Keychain can't contain line breaks")
+	}
+	command := []string{"add-generic-password", "-U", "-s", Service, "-a", account, "-w", secret}
+	for i, arg := range command {
+		command[i] = securityQuote(arg)
+	}
+	_, stderr, err := runTool(strings.Join(command, " ")+"\n", "security", "-i")
+	if err == nil && strings.TrimSpace(stderr) != "" {
+		// In interactive mode, security reports failed commands but exits successfully.
+		err = fmt.Errorf("security add-generic-password failed: %s", strings.TrimSpace(stderr))
+	}
+	return err
+}
+
+// securityQuote quotes an argument of a command read by 'security -i'.
+func securityQuote(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+func (macKeychain) Delete(account string) error {
+	_, stderr, err := runTool("", "security", "delete-generic-password", "-s", Service, "-a", account)
+	if err != nil && strings.Contains(stderr, "could not be found") {
+		return nil
+	}
+	return err
+}
+
+// secretService stores secrets through the freedesktop Secret Service API (GNOME Keyring,
+// KWallet, KeePassXC) using libsecret's 'secret-tool'.
+type secretService struct{}
+
+func (secretService) Name() string { return "Secret Service (libsecret)" }
+
+// available checks that secret-tool is installed and a Secret Service answers on the
+// session bus, which is typically not the case over SSH or in containers.
+func (secretService) available() error {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		return errUnavailable
+	}
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return errUnavailable
+	}
+	// A lookup of a missing item exits with 1 and prints nothing; without a reachable
+	// service it prints an error.
+	_, stderr, err := runTool("", "secret-tool", "lookup", "service", Service, "account", "")
+	if err != nil && strings.TrimSpace(stderr) != "" {
+		return errUnavailable
+	}
+	return nil
+}
+
+func (secretService) Get(account string) (string, error) {
+	secret, stderr, err := runTool("", "secret-tool", "lookup", "service", Service, "account", account)
+	if err != nil && strings.TrimSpace(stderr) == "" {
+		return "", ErrNotFound
+	}
+	return secret, err
+}
+
+func (secretService) Set(account, secret string) error {
+	_, _, err := runTool(secret, "secret-tool", "store", "--label", Service+": "+account, "service", Service, "account", account)
+	return err
+}
+
+func (secretService) Delete(account string) error {
+	_, _, err := runTool("", "secret-tool", "clear", "service", Service, "account", account)
+	return err
+}
+
+// runTool runs a keyring command line tool with stdin as input and returns its output.
+// Secrets are passed on stdin where the tool supports it, so they don't show up in ps.
+func runTool(stdin, name string, args ...string) (stdout, stderr string, err error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	if err := cmd.Run(); err != nil {
+		return "", errb.String(), fmt.Errorf("%s %s failed: %w. Stderr:\n%s", name, args[0], err, errb.String())
+	}
+	return strings.TrimRight(outb.String(), "\r\n"), errb.String(), nil
+}
@@ -0,0 +1,163 @@
+package keyring
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	fileFormatVersion = 1
+	kdfIterations     = 600000 // PBKDF2-HMAC-SHA256, as recommended by OWASP
+)
+
+// encryptedFile is the on-disk format of the file keyring. All secrets are encrypted
+// together with AES-256-GCM under a key derived from the passphrase.
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// fileKeyring stores secrets in a passphrase-encrypted file, for machines without an OS
+// keyring such as servers and containers.
+type fileKeyring struct {
+	path       string
+	passphrase func() (string, error)
+	key, salt  []byte // Derived key cache, since derivation is deliberately slow
+}
+
+func (k *fileKeyring) Name() string { return "encrypted keyring file" }
+
+func (k *fileKeyring) Get(account string) (string, error) {
+	secrets, _, err := k.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+func (k *fileKeyring) Set(account, secret string) error {
+	secrets, salt, err := k.load()
+	if err != nil {
+		return err
+	}
+	secrets[account] = secret
+	return k.store(secrets, salt)
+}
+
+func (k *fileKeyring) Delete(account string) error {
+	secrets, salt, err := k.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[account]; !ok {
+		return nil
+	}
+	delete(secrets, account)
+	return k.store(secrets, salt)
+}
+
+// load decrypts the file. A missing file holds no secrets and gets a new salt.
+func (k *fileKeyring) load() (map[string]string, []byte, error) {
+	data, err := os.ReadFile(k.path)
+	if os.IsNotExist(err) {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, nil, err
+		}
+		return map[string]string{}, salt, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read keyring file %s: %w", k.path, err)
+	}
+	var f encryptedFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse keyring file %s: %w", k.path, err)
+	}
+	if f.Version != fileFormatVersion {
+		return nil, nil, fmt.Errorf("keyring file %s has unsupported version %d", k.path, f.Version)
+	}
+	aead, err := k.cipher(f.Salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := aead.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		return nil, nil, errors.New("failed to decrypt keyring file " + k.path + ": wrong passphrase or corrupt file")
+	}
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse decrypted keyring file %s: %w", k.path, err)
+	}
+	return secrets, f.Salt, nil
+}
+
+// store encrypts secrets with a fresh nonce and replaces the file atomically.
+func (k *fileKeyring) store(secrets map[string]string, salt []byte) error {
+	aead, err := k.cipher(salt)
+	if err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	f := encryptedFile{Version: fileFormatVersion, Salt: salt, Nonce: make([]byte, aead.NonceSize())}
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Ciphertext = aead.Seal(nil, f.Nonce, plaintext, nil)
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(k.path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory for keyring file %s: %w", k.path, err)
+	}
+	tmp := k.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write keyring file %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, k.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace keyring file %s: %w", k.path, err)
+	}
+	return nil
+}
+
+// cipher derives the file key from the passphrase and salt.
+func (k *fileKeyring) cipher(salt []byte) (cipher.AEAD, error) {
+	if k.key == nil || !bytes.Equal(k.salt, salt) {
+		passphrase, err := k.passphrase()
+		if err != nil {
+			return nil, err
+		}
+		if passphrase == "" {
+			return nil, errors.New("the keyring file needs a passphrase")
+		}
+		if k.key, err = pbkdf2.Key(sha256.New, passphrase, salt, kdfIterations, 32); err != nil {
+			return nil, err
+		}
+		k.salt = salt
+	}
+	block, err := aes.NewCipher(k.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
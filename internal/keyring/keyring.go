@@ -0,0 +1,71 @@
+// Package keyring stores secrets (API tokens, webhook secrets) outside of the config file:
+// in the OS keyring when one is available, and in a passphrase-encrypted file otherwise.
+package keyring
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Service is the service name secrets are stored under in the OS keyring.
+const Service = "fussy-git"
+
+// Backends that can be selected with the 'keyring' setting.
+const (
+	BackendAuto   = "auto"   // The OS keyring if available, the encrypted file otherwise
+	BackendSystem = "system" // The OS keyring; fail if there is none
+	BackendFile   = "file"   // The encrypted file, e.g. on headless machines
+)
+
+// ErrNotFound is returned when no secret is stored for an account.
+var ErrNotFound = errors.New("secret not found in keyring")
+
+// errUnavailable is returned by system backends whose keyring can't be used on this machine.
+var errUnavailable = errors.New("no OS keyring available")
+
+// Keyring stores secrets by account name, e.g. a domain for API tokens.
+type Keyring interface {
+	// Name describes where secrets are stored, e.g. "macOS Keychain".
+	Name() string
+	// Get returns the secret stored for account, or ErrNotFound.
+	Get(account string) (string, error)
+	// Set stores secret for account, replacing any previous one.
+	Set(account, secret string) error
+	// Delete removes the secret stored for account. It is not an error if there is none.
+	Delete(account string) error
+}
+
+// Open returns the keyring selected by backend (BackendAuto, BackendSystem or BackendFile).
+// The encrypted file is stored at path and unlocked with the passphrase returned by
+// passphrase, which is only called once the file is actually read or written.
+func Open(backend, path string, passphrase func() (string, error)) (Keyring, error) {
+	switch backend {
+	case BackendAuto, "":
+		if k, err := system(); err == nil {
+			return k, nil
+		}
+		return &fileKeyring{path: path, passphrase: passphrase}, nil
+	case BackendSystem:
+		k, err := system()
+		if err != nil {
+			return nil, fmt.Errorf("%w; set 'keyring: file' to use an encrypted file instead", err)
+		}
+		return k, nil
+	case BackendFile:
+		return &fileKeyring{path: path, passphrase: passphrase}, nil
+	}
+	return nil, fmt.Errorf("unknown keyring backend '%s' (expected %s, %s or %s)", backend, BackendAuto, BackendSystem, BackendFile)
+}
+
+// system returns the keyring of the operating system.
+func system() (Keyring, error) {
+	for _, k := range []interface {
+		Keyring
+		available() error
+	}{macKeychain{}, secretService{}, windowsCredentials{}} {
+		if err := k.available(); err == nil {
+			return k, nil
+		}
+	}
+	return nil, errUnavailable
+}
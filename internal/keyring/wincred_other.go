@@ -0,0 +1,12 @@
+//go:build !windows
+
+package keyring
+
+// windowsCredentials is the Windows Credential Manager, which only exists on Windows.
+type windowsCredentials struct{}
+
+func (windowsCredentials) Name() string                       { return "Windows Credential Manager" }
+func (windowsCredentials) available() error                   { return errUnavailable }
+func (windowsCredentials) Get(account string) (string, error) { return "", errUnavailable }
+func (windowsCredentials) Set(account, secret string) error   { return errUnavailable }
+func (windowsCredentials) Delete(account string) error        { return errUnavailable }
@@ -0,0 +1,62 @@
+package layout
+
+import (
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"path/filepath"
+	"strings"
+)
+
+// Supported layouts for arranging repositories below FUSSY_GIT_HOME.
+const (
+	Domain = "domain" // <home>/<domain>/<owner>/<repo> (default)
+	Owner  = "owner"  // <home>/<owner>/<repo>, without the domain
+	Flat   = "flat"   // <home>/<repo>
+)
+
+// Default is the layout used when none is configured.
+const Default = Domain
+
+// Names lists all supported layouts.
+var Names = []string{Domain, Owner, Flat}
+
+// Validate returns an error if name is not a supported layout.
+func Validate(name string) error {
+	for _, n := range Names {
+		if n == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown layout '%s': must be one of %s", name, strings.Join(Names, ", "))
+}
+
+// Path returns the location of the repository described by pu below fussyGitHome
+// according to the given layout. An empty layout name selects the default layout.
+//
+// Examples for git@github.com:spf13/cobra.git and FUSSY_GIT_HOME=/home/user/git:
+//
+//	domain: /home/user/git/github.com/spf13/cobra
+//	owner:  /home/user/git/spf13/cobra
+//	flat:   /home/user/git/cobra
+func Path(name, fussyGitHome string, pu *gitutil.ParsedGitURL) string {
+	switch name {
+	case Owner:
+		return filepath.Join(fussyGitHome, pu.Path)
+	case Flat:
+		return filepath.Join(fussyGitHome, pu.RepoName)
+	default:
+		return pu.GetLocalPath(fussyGitHome)
+	}
+}
+
+// Describe returns a short human readable description of a layout's directory structure.
+func Describe(name string) string {
+	switch name {
+	case Owner:
+		return "$FUSSY_GIT_HOME/<user_or_org>/<project_name>"
+	case Flat:
+		return "$FUSSY_GIT_HOME/<project_name>"
+	default:
+		return "$FUSSY_GIT_HOME/<domain>/<user_or_org>/<project_name>"
+	}
+}
@@ -0,0 +1,137 @@
+package license
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Results of Detect that aren't license identifiers.
+const (
+	None    = "none"    // The repository has no license file
+	Unknown = "unknown" // The repository has a license file, but its license wasn't recognized
+)
+
+// maxLicenseBytes is how much of a license file is read; every license is recognized by its
+// first paragraphs.
+const maxLicenseBytes = 64 << 10
+
+// filePrefixes are the names license files start with, compared case-insensitively, e.g.
+// LICENSE, LICENSE.md, LICENCE-MIT, COPYING, COPYING.LESSER, UNLICENSE.
+var filePrefixes = []string{"license", "licence", "copying", "unlicense"}
+
+// rule recognizes a license by phrases of its text, which must all occur.
+type rule struct {
+	id      string
+	phrases []string
+}
+
+// rules are checked in order, so more specific licenses come before the ones their text
+// mentions too (the LGPL refers to the GPL, for example).
+var rules = []rule{
+	{"AGPL-3.0", []string{"gnu affero general public license"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2.1"}},
+	{"LGPL-2.0", []string{"gnu library general public license"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"EPL-2.0", []string{"eclipse public license", "2.0"}},
+	{"EPL-1.0", []string{"eclipse public license"}},
+	{"BSL-1.0", []string{"boost software license"}},
+	{"Unlicense", []string{"free and unencumbered software released into the public domain"}},
+	{"CC0-1.0", []string{"cc0 1.0 universal"}},
+	{"ISC", []string{"permission to use, copy, modify, and/or distribute this software for any purpose with or without fee is hereby granted"}},
+	{"MIT", []string{"permission is hereby granted, free of charge, to any person obtaining a copy"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+}
+
+// Detect identifies the license of the repository at repoPath from the license files in its
+// top-level directory, by heuristics on their text. It returns the SPDX identifier of the
+// license (e.g. "MIT" or "Apache-2.0"), Unknown or None. Repositories with several license
+// files (dual licensing) get the identifiers of all recognized licenses joined with " OR ".
+func Detect(repoPath string) (string, error) {
+	files, err := licenseFiles(repoPath)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return None, nil
+	}
+	var ids []string
+	seen := map[string]bool{}
+	for _, name := range files {
+		text, err := readText(filepath.Join(repoPath, name))
+		if err != nil {
+			return "", err
+		}
+		if id := identify(text); id != Unknown && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return Unknown, nil
+	}
+	return strings.Join(ids, " OR "), nil
+}
+
+// licenseFiles returns the names of the license files in the top-level directory of the
+// repository, sorted.
+func licenseFiles(repoPath string) ([]string, error) {
+	entries, err := os.ReadDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		name := strings.ToLower(e.Name())
+		for _, prefix := range filePrefixes {
+			if strings.HasPrefix(name, prefix) {
+				files = append(files, e.Name())
+				break
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// readText returns the beginning of a license file in lower case, with all whitespace
+// collapsed to single spaces so that line breaks don't matter.
+func readText(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxLicenseBytes))
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(strings.ToLower(string(data))), " "), nil
+}
+
+// identify returns the identifier of the license text, or Unknown.
+func identify(text string) string {
+	for _, r := range rules {
+		matched := true
+		for _, phrase := range r.phrases {
+			if !strings.Contains(text, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return r.id
+		}
+	}
+	return Unknown
+}
@@ -0,0 +1,106 @@
+package manifest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Entry is a repository listed in a manifest, optionally pinned to a branch or commit.
+type Entry struct {
+	URL    string // Clone URL of the repository
+	Branch string // Branch to check out, "" if not pinned to a branch
+	Commit string // Commit to check out (full or abbreviated hash), "" if not pinned to a commit
+}
+
+// Pinned reports whether the entry pins a branch or commit.
+func (e Entry) Pinned() bool {
+	return e.Branch != "" || e.Commit != ""
+}
+
+// String formats the entry as a manifest line.
+func (e Entry) String() string {
+	line := e.URL
+	if e.Branch != "" {
+		line += " branch=" + e.Branch
+	}
+	if e.Commit != "" {
+		line += " commit=" + e.Commit
+	}
+	return line
+}
+
+// Read reads the manifest at path, or from stdin if path is "-".
+func Read(path string) ([]Entry, error) {
+	if path == "-" {
+		return Parse(os.Stdin, "stdin")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer f.Close()
+	return Parse(f, path)
+}
+
+// Parse reads manifest lines from r; name identifies the source in errors. Every line names
+// a repository URL, optionally followed by pins:
+//
+//	https://github.com/spf13/cobra branch=main commit=40b5bc1
+//
+// Blank lines and lines starting with '#' are ignored.
+func Parse(r io.Reader, name string) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		entry := Entry{URL: fields[0]}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok || value == "" {
+				return nil, fmt.Errorf("%s, line %d: expected key=value after the URL, got '%s'", name, lineNo, field)
+			}
+			switch key {
+			case "branch":
+				entry.Branch = value
+			case "commit":
+				if !isHex(value) || len(value) < 4 || len(value) > 64 {
+					return nil, fmt.Errorf("%s, line %d: commit '%s' is not a commit hash", name, lineNo, value)
+				}
+				entry.Commit = strings.ToLower(value)
+			default:
+				return nil, fmt.Errorf("%s, line %d: unknown pin '%s' (expected branch or commit)", name, lineNo, key)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return entries, nil
+}
+
+// Write writes entries as manifest lines to w.
+func Write(w io.Writer, entries []Entry) error {
+	for _, e := range entries {
+		if _, err := fmt.Fprintln(w, e.String()); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+	}
+	return nil
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+// Package notify sends notifications about repositories to the desktop and to webhooks,
+// e.g. a Slack incoming webhook.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Kinds of events notifications are sent for.
+const (
+	EventIssues   = "issues"   // Repositories developed doctor issues
+	EventBehind   = "behind"   // Repositories fell far behind their upstream
+	EventFinished = "finished" // A bulk operation finished
+)
+
+// Webhook payload formats.
+const (
+	FormatJSON  = "json"  // A JSON object with all fields of the notification
+	FormatSlack = "slack" // A Slack message: {"text": "..."}
+)
+
+// client is used for webhook requests. An unreachable endpoint shouldn't hang a daemon cycle.
+var client = &http.Client{Timeout: 15 * time.Second}
+
+// Notification is a message about one event.
+type Notification struct {
+	Event        string    `json:"event"`
+	Title        string    `json:"title"`
+	Message      string    `json:"message"`
+	Repositories []string  `json:"repositories,omitempty"` // Paths of the repositories concerned
+	Host         string    `json:"host"`
+	Time         time.Time `json:"time"`
+}
+
+// Notifier delivers notifications to the enabled channels.
+type Notifier struct {
+	Desktop       bool   // Show desktop notifications
+	WebhookURL    string // POST notifications to this URL; empty to disable
+	WebhookFormat string // FormatJSON or FormatSlack
+}
+
+// Enabled reports whether any channel is enabled.
+func (n *Notifier) Enabled() bool {
+	return n.Desktop || n.WebhookURL != ""
+}
+
+// Send delivers a notification to every enabled channel. A channel that fails doesn't keep
+// the others from being tried; all failures are returned.
+func (n *Notifier) Send(msg Notification) error {
+	if msg.Time.IsZero() {
+		msg.Time = time.Now()
+	}
+	if msg.Host == "" {
+		msg.Host, _ = os.Hostname()
+	}
+	var errs []error
+	if n.Desktop {
+		errs = append(errs, Desktop(msg.Title, msg.Message))
+	}
+	if n.WebhookURL != "" {
+		errs = append(errs, n.post(msg))
+	}
+	return errors.Join(errs...)
+}
+
+// post sends msg to the webhook.
+func (n *Notifier) post(msg Notification) error {
+	var payload any = msg
+	if n.WebhookFormat == FormatSlack {
+		text := fmt.Sprintf("*%s* (%s)\n%s", msg.Title, msg.Host, msg.Message)
+		payload = map[string]string{"text": text}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(n.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send notification to %s: %w", RedactURL(n.WebhookURL), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification webhook %s responded with HTTP %s", RedactURL(n.WebhookURL), resp.Status)
+	}
+	return nil
+}
+
+// DetectFormat returns the payload format for a webhook URL: FormatSlack for Slack incoming
+// webhooks, FormatJSON otherwise.
+func DetectFormat(webhookURL string) string {
+	if u, err := url.Parse(webhookURL); err == nil && u.Host == "hooks.slack.com" {
+		return FormatSlack
+	}
+	return FormatJSON
+}
+
+// RedactURL returns webhookURL without its path and query, which often contain a secret,
+// e.g. https://hooks.slack.com/... for a Slack webhook.
+func RedactURL(webhookURL string) string {
+	u, err := url.Parse(webhookURL)
+	if err != nil || u.Host == "" {
+		return "(invalid URL)"
+	}
+	if u.Path == "" && u.RawQuery == "" {
+		return u.Scheme + "://" + u.Host
+	}
+	return u.Scheme + "://" + u.Host + "/..."
+}
+
+// Desktop shows a desktop notification using notify-send on Linux or osascript on macOS.
+func Desktop(title, message string) error {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("osascript", "-e", fmt.Sprintf("display notification %q with title %q", message, title))
+	case "linux", "freebsd", "openbsd":
+		c = exec.Command("notify-send", "--app-name=fussy-git", title, message)
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+	if out, err := c.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to send desktop notification: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
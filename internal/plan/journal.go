@@ -0,0 +1,128 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Statuses of the operations in a journal.
+const (
+	StatusPending = "pending" // Not started yet
+	StatusRunning = "running" // Started; the process may have died before it finished
+	StatusDone    = "done"    // Applied
+	StatusFailed  = "failed"  // Failed, or was skipped because it no longer applied
+	StatusSkipped = "skipped" // Declined or not applicable (e.g. pinned or locked)
+)
+
+// JournalEntry is an operation of a journal with its progress.
+type JournalEntry struct {
+	Operation
+	Status string `json:"status"`
+}
+
+// Journal records the progress of applying a plan, so an interrupted run can tell what it
+// had done. It is rewritten atomically on every change.
+type Journal struct {
+	StartedAt  time.Time      `json:"started_at"`
+	Operations []JournalEntry `json:"operations"`
+
+	path string
+}
+
+// StartJournal creates a journal at path for the operations of p, all pending.
+func StartJournal(path string, p *Plan) (*Journal, error) {
+	j := &Journal{StartedAt: time.Now(), path: path}
+	for _, op := range p.Operations {
+		j.Operations = append(j.Operations, JournalEntry{Operation: op, Status: StatusPending})
+	}
+	if err := j.write(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// LoadJournal reads the journal at path. It returns nil and no error if there is none.
+func LoadJournal(path string) (*Journal, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal %s: %w", path, err)
+	}
+	j := &Journal{path: path}
+	if err := json.Unmarshal(data, j); err != nil {
+		return nil, fmt.Errorf("journal %s contains invalid JSON: %w", path, err)
+	}
+	return j, nil
+}
+
+// Path returns the file the journal is stored in.
+func (j *Journal) Path() string { return j.path }
+
+// Mark records the status of the i-th operation.
+func (j *Journal) Mark(i int, status string) error {
+	j.Operations[i].Status = status
+	return j.write()
+}
+
+// Count returns the number of operations with the given status.
+func (j *Journal) Count(status string) int {
+	n := 0
+	for _, e := range j.Operations {
+		if e.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Remaining returns a plan with the operations that are still pending, or were running when
+// the journal was last written.
+func (j *Journal) Remaining(fussyGitHome, stateFilePath string) *Plan {
+	p := New(fussyGitHome, stateFilePath)
+	for _, e := range j.Operations {
+		if e.Status == StatusPending || e.Status == StatusRunning {
+			p.Add(e.Operation)
+		}
+	}
+	return p
+}
+
+// Remove deletes the journal file once the plan has been applied completely.
+func (j *Journal) Remove() error {
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove journal %s: %w", j.path, err)
+	}
+	return nil
+}
+
+// write stores the journal atomically: a crash leaves either the old or the new version.
+func (j *Journal) write() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal to JSON: %w", err)
+	}
+	tempPath := j.path + ".tmp"
+	f, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write journal %s: %w", tempPath, err)
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempPath, j.path)
+	}
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to write journal %s: %w", j.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Operation types understood by reorganize.
+const (
+	OpURLUpdate = "url-update" // Update the stored CurrentURL of a repository
+	OpSetURL    = "set-url"    // Set the 'origin' remote of a repository to a new URL and record it
+	OpMove      = "move"       // Move a repository directory to a new location
+	// OpMoveSuffixed moves a repository next to its taken location and records the new
+	// location as its path override.
+	OpMoveSuffixed = "move-suffixed"
+	// OpMerge moves a repository that duplicates the clone at Target aside as a backup,
+	// merging its entry into the one of that clone.
+	OpMerge = "merge"
+)
+
+// Operation is a single change proposed by reorganize.
+// Source and Target hold URLs for url-update and set-url operations and directories for the
+// others.
+type Operation struct {
+	Type   string `json:"type"`   // One of the Op* constants
+	Repo   string `json:"repo"`   // Name of the repository, for display purposes
+	Path   string `json:"path"`   // Path of the repository in the state file when the plan was made (its identity)
+	Source string `json:"source"` // Expected value before the operation is applied
+	Target string `json:"target"` // Value after the operation is applied
+}
+
+// Plan is an ordered list of operations, as produced by 'reorganize --dry-run --output json'
+// and consumed by 'reorganize --apply-plan'.
+type Plan struct {
+	GeneratedAt   time.Time   `json:"generated_at"`
+	FussyGitHome  string      `json:"fussy_git_home"`
+	StateFilePath string      `json:"state_file_path"`
+	Operations    []Operation `json:"operations"`
+	Warnings      []string    `json:"warnings,omitempty"` // Repositories that were skipped while planning, and why
+}
+
+// New creates an empty plan for the given FUSSY_GIT_HOME and state file.
+func New(fussyGitHome, stateFilePath string) *Plan {
+	return &Plan{
+		GeneratedAt:   time.Now(),
+		FussyGitHome:  fussyGitHome,
+		StateFilePath: stateFilePath,
+		Operations:    []Operation{},
+	}
+}
+
+// Add appends an operation to the plan.
+func (p *Plan) Add(op Operation) {
+	p.Operations = append(p.Operations, op)
+}
+
+// Write encodes the plan as indented JSON.
+func (p *Plan) Write(w io.Writer) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan to JSON: %w", err)
+	}
+	if _, err := fmt.Fprintln(w, string(data)); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
+
+// Load reads a plan previously written by Write and validates its operations.
+func Load(filePath string) (*Plan, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan file %s: %w", filePath, err)
+	}
+
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("plan file %s contains invalid JSON: %w", filePath, err)
+	}
+
+	for i, op := range p.Operations {
+		switch op.Type {
+		case OpURLUpdate, OpSetURL, OpMove, OpMoveSuffixed, OpMerge:
+		default:
+			return nil, fmt.Errorf("plan file %s: operation #%d has unknown type '%s'", filePath, i+1, op.Type)
+		}
+		if op.Path == "" || op.Source == "" || op.Target == "" {
+			return nil, fmt.Errorf("plan file %s: operation #%d is missing path, source or target", filePath, i+1)
+		}
+	}
+	return &p, nil
+}
@@ -0,0 +1,162 @@
+package readme
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxReadmeBytes is how much of a README is read; the description is always near the top.
+const maxReadmeBytes = 32 << 10
+
+var (
+	// images and links are markdown images and links, whose text is kept and target dropped.
+	images = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	links  = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	// tags are HTML tags, which READMEs often use for logos and centered titles.
+	tags = regexp.MustCompile(`<[^>]*>`)
+	// emphasis is markdown emphasis and inline code.
+	emphasis = strings.NewReplacer("**", "", "__", "", "`", "")
+)
+
+// Description returns a one-line description of the repository at repoPath from the README in
+// its top-level directory: the first paragraph of prose, or the first heading if the README has
+// no prose before its second heading. Markdown and HTML markup is stripped. It returns "" if the
+// repository has no README or nothing usable was found in it.
+func Description(repoPath string) (string, error) {
+	name, err := findReadme(repoPath)
+	if err != nil || name == "" {
+		return "", err
+	}
+	f, err := os.Open(filepath.Join(repoPath, name))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return describe(io.LimitReader(f, maxReadmeBytes))
+}
+
+// findReadme returns the name of the README in the directory, "" if there is none. README.md
+// is preferred over other extensions when there are several.
+func findReadme(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && strings.HasPrefix(strings.ToLower(e.Name()), "readme") {
+			names = append(names, e.Name())
+		}
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		mi, mj := strings.EqualFold(names[i], "readme.md"), strings.EqualFold(names[j], "readme.md")
+		if mi != mj {
+			return mi
+		}
+		return names[i] < names[j]
+	})
+	return names[0], nil
+}
+
+// describe extracts the description from the text of a README.
+func describe(r io.Reader) (string, error) {
+	var heading string
+	var paragraph []string
+	headings := 0
+	inCode := false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxReadmeBytes)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		if line != "" && isRule(line) && len(paragraph) == 1 {
+			// The underline of a setext heading ("Title" followed by "=====").
+			headings++
+			title := paragraph[0]
+			paragraph = nil
+			if headings > 1 {
+				break
+			}
+			heading = title
+			continue
+		}
+		if line == "" || isRule(line) {
+			if len(paragraph) > 0 {
+				break
+			}
+			continue
+		}
+		if title, ok := headingText(line); ok {
+			if len(paragraph) > 0 {
+				break
+			}
+			headings++
+			if headings > 1 {
+				break
+			}
+			heading = title
+			continue
+		}
+		text := clean(line)
+		if text == "" {
+			continue // Badges, logos and other markup only.
+		}
+		if len(paragraph) == 0 && isStructure(line) {
+			continue // Tables of contents, lists and quotes rarely describe the project.
+		}
+		paragraph = append(paragraph, text)
+	}
+	if err := scanner.Err(); err != nil && err != bufio.ErrTooLong {
+		return "", err
+	}
+	if len(paragraph) > 0 {
+		return strings.Join(paragraph, " "), nil
+	}
+	return heading, nil
+}
+
+// headingText returns the text of an ATX heading line ("# Title"), and whether it is one.
+func headingText(line string) (string, bool) {
+	if !strings.HasPrefix(line, "#") {
+		return "", false
+	}
+	return clean(strings.Trim(line, "# ")), true
+}
+
+// isRule reports whether the line is a horizontal rule or a setext heading underline.
+func isRule(line string) bool {
+	return strings.Trim(line, "-=*_ ") == ""
+}
+
+// isStructure reports whether the line starts a list, quote or table.
+func isStructure(line string) bool {
+	for _, prefix := range []string{"- ", "* ", "+ ", "> ", "|", "1. "} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// clean strips markup from a line of a README and collapses its whitespace.
+func clean(line string) string {
+	line = images.ReplaceAllString(line, "")
+	line = links.ReplaceAllString(line, "$1")
+	line = tags.ReplaceAllString(line, "")
+	line = emphasis.Replace(line)
+	return strings.Join(strings.Fields(line), " ")
+}
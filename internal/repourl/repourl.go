@@ -0,0 +1,175 @@
+// Package repourl resolves repository URLs the way fussy-git understands them: with the user's
+// host shortcuts, git url.<base>.insteadOf rules and SSH host aliases applied, and with the
+// location a repository belongs at below FUSSY_GIT_HOME. Both the fussy-git command and the Go
+// API in pkg/fussy use it, so they agree on what a URL means and where it goes.
+package repourl
+
+import (
+	"github.com/jmsnll/fussy-git/internal/config"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/layout"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Cache holds what a Resolver looks up outside the configuration: the user's url.insteadOf
+// rules and 'ssh -G' results. It is safe for concurrent use, and can be shared by resolvers
+// for different configurations.
+type Cache struct {
+	rewritesOnce sync.Once
+	rewrites     []gitutil.URLRewrite
+	rewritesErr  error
+
+	mu       sync.Mutex
+	sshHosts map[string]string
+}
+
+// Resolver applies a configuration to repository URLs. Config may be nil before the
+// configuration is loaded; only the url.insteadOf rules are applied then.
+type Resolver struct {
+	Config *config.Config
+	Cache  *Cache
+	// Logf, if set, is called with each normalization that is applied, e.g. for --verbose.
+	Logf func(format string, args ...any)
+	// Warnf, if set, is called with problems that don't stop resolving, e.g. a failing 'ssh -G'.
+	Warnf func(format string, args ...any)
+}
+
+// New returns a resolver for cfg with a cache of its own.
+func New(cfg *config.Config) *Resolver {
+	return &Resolver{Config: cfg, Cache: &Cache{}}
+}
+
+// LoadRewrites reads the user's url.insteadOf rules, once per cache, and returns them with the
+// error of reading them, which is also reported with Warnf.
+func (r *Resolver) LoadRewrites() ([]gitutil.URLRewrite, error) {
+	r.Cache.rewritesOnce.Do(func() {
+		r.Cache.rewrites, r.Cache.rewritesErr = gitutil.GetURLRewrites()
+		if r.Cache.rewritesErr != nil {
+			r.warnf("%v", r.Cache.rewritesErr)
+		}
+	})
+	return r.Cache.rewrites, r.Cache.rewritesErr
+}
+
+// Rewrite applies the user's url.insteadOf rules to rawURL, as git would before using it.
+func (r *Resolver) Rewrite(rawURL string) string {
+	rules, _ := r.LoadRewrites()
+	rewritten := gitutil.RewriteURL(rawURL, rules)
+	if rewritten != rawURL {
+		r.logf("Applied git url.insteadOf rule: '%s' -> '%s'", rawURL, rewritten)
+	}
+	return rewritten
+}
+
+// ExpandShortcut expands host shortcuts such as gh:spf13/cobra using the host_shortcuts
+// setting. Shortcuts the user already defined as git url.insteadOf rules are left for git to
+// rewrite.
+func (r *Resolver) ExpandShortcut(rawURL string) string {
+	if r.Config == nil || r.Rewrite(rawURL) != rawURL {
+		return rawURL
+	}
+	expanded := gitutil.ExpandShortcut(rawURL, r.Config.HostShortcuts)
+	if expanded != rawURL {
+		r.logf("Expanded host shortcut '%s' to '%s'", rawURL, expanded)
+	}
+	return expanded
+}
+
+// Parse parses a repository URL and applies the user's normalizations, so that paths and
+// comparisons are based on the URL's effective meaning rather than how it happens to be
+// spelled:
+//   - git's url.<base>.insteadOf rewrites (git applies them too, e.g. in 'git remote get-url')
+//   - SSH Host aliases (see ResolveSSHHost)
+//
+// Host shortcuts are not expanded; see ExpandShortcut.
+func (r *Resolver) Parse(rawURL string) (*gitutil.ParsedGitURL, error) {
+	parsed, err := gitutil.ParseGitURL(r.Rewrite(rawURL))
+	if err != nil {
+		return nil, err
+	}
+	if parsed.IsSSH {
+		parsed.Domain = r.ResolveSSHHost(parsed.Domain)
+	}
+	return parsed, nil
+}
+
+// ResolveSSHHost maps an SSH Host alias (e.g. "work-gh" from ~/.ssh/config) to the real
+// hostname, using the explicit ssh_host_aliases mapping first and 'ssh -G' if enabled.
+func (r *Resolver) ResolveSSHHost(host string) string {
+	if r.Config == nil {
+		return host
+	}
+	for alias, hostname := range r.Config.SSHHostAliases {
+		if strings.EqualFold(alias, host) {
+			return hostname
+		}
+	}
+	if !r.Config.ResolveSSHAliases {
+		return host
+	}
+
+	r.Cache.mu.Lock()
+	defer r.Cache.mu.Unlock()
+	if resolved, ok := r.Cache.sshHosts[host]; ok {
+		return resolved
+	}
+	resolved, err := gitutil.ResolveSSHHostAlias(host)
+	if err != nil {
+		r.warnf("%v", err)
+		resolved = host
+	}
+	if resolved != host {
+		r.logf("Resolved SSH host alias '%s' to '%s'", host, resolved)
+	}
+	if r.Cache.sshHosts == nil {
+		r.Cache.sshHosts = map[string]string{}
+	}
+	r.Cache.sshHosts[host] = resolved
+	return resolved
+}
+
+// Settings returns the effective layered settings (global, domain, owner, repository) for a
+// repository URL.
+func (r *Resolver) Settings(parsed *gitutil.ParsedGitURL) config.Resolved {
+	return r.Config.Layers.For(filepath.ToSlash(parsed.GetNormalizedFSPath()))
+}
+
+// Layout returns the layout that applies to a repository URL.
+func (r *Resolver) Layout(parsed *gitutil.ParsedGitURL) string {
+	if l := r.Settings(parsed).Layout; l != "" {
+		return l
+	}
+	return r.Config.Layout
+}
+
+// LocalReposDir returns the directory repositories cloned from local paths go into.
+func (r *Resolver) LocalReposDir() string {
+	if filepath.IsAbs(r.Config.LocalDir) {
+		return r.Config.LocalDir
+	}
+	return filepath.Join(r.Config.FussyGitHome, r.Config.LocalDir)
+}
+
+// ConventionalPath returns the conventional location for a repository URL according to the
+// layout configured for it. Repositories cloned from local paths go into the local_dir
+// directory whatever the layout, named by their LocalKey.
+func (r *Resolver) ConventionalPath(parsed *gitutil.ParsedGitURL) string {
+	if parsed.IsLocal() {
+		return filepath.Join(r.LocalReposDir(), parsed.Path)
+	}
+	return layout.Path(r.Layout(parsed), r.Config.FussyGitHome, parsed)
+}
+
+func (r *Resolver) logf(format string, args ...any) {
+	if r.Logf != nil {
+		r.Logf(format, args...)
+	}
+}
+
+func (r *Resolver) warnf(format string, args ...any) {
+	if r.Warnf != nil {
+		r.Warnf(format, args...)
+	}
+}
@@ -0,0 +1,116 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Repository is the recorded checkout of a single repository.
+type Repository struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	URL    string `json:"url"`
+	Branch string `json:"branch,omitempty"` // Branch that was checked out, "" if HEAD was detached
+	Commit string `json:"commit"`           // Commit HEAD pointed to
+	Dirty  bool   `json:"dirty,omitempty"`  // The working tree had uncommitted changes or untracked files, which aren't part of the snapshot
+}
+
+// Snapshot records the checkouts of a set of repositories at one point in time, see
+// 'fussy-git snapshot'.
+type Snapshot struct {
+	Name         string       `json:"name"`
+	CreatedAt    time.Time    `json:"created_at"`
+	Repositories []Repository `json:"repositories"`
+}
+
+// ValidateName checks that name can be used as a snapshot name, which is also its file name.
+func ValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("snapshot name is empty")
+	}
+	if strings.ContainsAny(name, `/\`) || name == "." || name == ".." || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("snapshot name '%s' must not contain path separators or start with '.'", name)
+	}
+	return nil
+}
+
+// path returns the file the snapshot name is stored in below dir.
+func path(dir, name string) string {
+	return filepath.Join(dir, name+".json")
+}
+
+// Exists reports whether a snapshot of that name is stored in dir.
+func Exists(dir, name string) bool {
+	_, err := os.Stat(path(dir, name))
+	return err == nil
+}
+
+// Save stores the snapshot in dir, replacing one of the same name.
+func (s *Snapshot) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory %s: %w", dir, err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot to JSON: %w", err)
+	}
+	target := path(dir, s.Name)
+	if err := os.WriteFile(target+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot %s: %w", target, err)
+	}
+	if err := os.Rename(target+".tmp", target); err != nil {
+		_ = os.Remove(target + ".tmp")
+		return fmt.Errorf("failed to write snapshot %s: %w", target, err)
+	}
+	return nil
+}
+
+// Load reads the snapshot name from dir.
+func Load(dir, name string) (*Snapshot, error) {
+	data, err := os.ReadFile(path(dir, name))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("snapshot '%s' does not exist (see 'fussy-git snapshot list')", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot '%s': %w", name, err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("snapshot file %s contains invalid JSON: %w", path(dir, name), err)
+	}
+	return &s, nil
+}
+
+// List returns the snapshots stored in dir, oldest first.
+func List(dir string) ([]*Snapshot, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []*Snapshot
+	for _, file := range files {
+		s, err := Load(dir, strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt) })
+	return snapshots, nil
+}
+
+// Delete removes the snapshot name from dir.
+func Delete(dir, name string) error {
+	if err := os.Remove(path(dir, name)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("snapshot '%s' does not exist (see 'fussy-git snapshot list')", name)
+		}
+		return fmt.Errorf("failed to delete snapshot '%s': %w", name, err)
+	}
+	return nil
+}
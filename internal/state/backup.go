@@ -0,0 +1,78 @@
+package state
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Backup copies the state file at filePath into backupDir under a timestamped name, e.g.
+// repos-20240102-150405.json, and then removes the oldest backups so that at most keep remain
+// (keep <= 0 keeps all of them). It returns the path of the new backup, or an empty string if
+// there is no state file to back up yet. The change log is folded into the state file first, so
+// the backup holds the whole state.
+func Backup(filePath, backupDir string, keep int) (string, error) {
+	if err := Compact(filePath); err != nil {
+		return "", err
+	}
+	src, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to open state file %s: %w", filePath, err)
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory %s: %w", backupDir, err)
+	}
+
+	ext := filepath.Ext(filePath)
+	prefix := strings.TrimSuffix(filepath.Base(filePath), ext) + "-"
+	backupPath := filepath.Join(backupDir, prefix+time.Now().Format("20060102-150405")+ext)
+
+	dst, err := os.OpenFile(backupPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup file %s: %w", backupPath, err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", fmt.Errorf("failed to write backup file %s: %w", backupPath, err)
+	}
+	if err := dst.Close(); err != nil {
+		return "", fmt.Errorf("failed to write backup file %s: %w", backupPath, err)
+	}
+
+	if keep > 0 {
+		if err := pruneBackups(backupDir, prefix, ext, keep); err != nil {
+			return backupPath, err
+		}
+	}
+	return backupPath, nil
+}
+
+// pruneBackups removes all but the newest keep backups in backupDir. Backup names sort chronologically.
+func pruneBackups(backupDir, prefix, ext string, keep int) error {
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		return fmt.Errorf("failed to list backups in %s: %w", backupDir, err)
+	}
+	var backups []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), prefix) && strings.HasSuffix(e.Name(), ext) {
+			backups = append(backups, e.Name())
+		}
+	}
+	sort.Strings(backups)
+	for len(backups) > keep {
+		if err := os.Remove(filepath.Join(backupDir, backups[0])); err != nil {
+			return fmt.Errorf("failed to remove old backup %s: %w", backups[0], err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
@@ -0,0 +1,368 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Saves don't rewrite the whole state file: the entries that changed since the state was loaded
+// or last saved are appended to a change log next to it (repos.json.log), which loading replays
+// on top of the state file. Several processes can save concurrently without losing each other's
+// changes, since each only appends its own, and a save costs what changed rather than what is
+// tracked. Once the log grows larger than the state file, it is compacted: folded into a new
+// state file, written atomically. Appends and compactions hold a lock on repos.json.lock.
+
+// Operations of change log records.
+const (
+	opPut      = "put"      // Add or replace the entry with the path of Entry
+	opDelete   = "delete"   // Remove the entry with Path
+	opSettings = "settings" // Replace PreferredProtocols and Groups
+)
+
+// compactMinBytes is how large the change log may always grow before it is compacted, so that
+// small states aren't rewritten on every few saves.
+const compactMinBytes = 256 << 10
+
+// changeRecord is one line of the change log.
+type changeRecord struct {
+	Op                 string            `json:"op"`
+	Entry              json.RawMessage   `json:"entry,omitempty"`
+	Path               string            `json:"path,omitempty"`
+	PreferredProtocols map[string]string `json:"preferred_protocols,omitempty"`
+	Groups             map[string]Group  `json:"groups,omitempty"`
+}
+
+// persistedState is what the state file and its change log hold for a RepoState, as of its
+// last load or save, to tell which entries a save has to record.
+type persistedState struct {
+	entries  map[string][]byte // JSON of each entry, by path
+	settings []byte            // JSON of the settings record
+	// base and log describe the state file and change log after the last load or save.
+	base, log fileMarker
+	// concurrent is set once another process saved since the state was loaded. Saves then
+	// merge their changes with the entries on disk instead of replacing them.
+	concurrent bool
+}
+
+// fileMarker tells whether a file changed: its size and modification time.
+type fileMarker struct {
+	size    int64
+	modTime time.Time
+}
+
+// markerOf returns the marker of the file at path, zero if it doesn't exist.
+func markerOf(path string) fileMarker {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileMarker{}
+	}
+	return fileMarker{size: info.Size(), modTime: info.ModTime()}
+}
+
+// mark records the current state file and change log of filePath as the ones p describes.
+func (p *persistedState) mark(filePath string) {
+	p.base, p.log = markerOf(filePath), markerOf(LogPath(filePath))
+}
+
+// LogPath returns the path of the change log of the state file at filePath.
+func LogPath(filePath string) string {
+	return filePath + ".log"
+}
+
+// snapshot returns what saving own records.
+func snapshot(own *RepoState) (*persistedState, error) {
+	p := &persistedState{entries: make(map[string][]byte, len(own.Repositories))}
+	for _, repo := range own.Repositories {
+		data, err := json.Marshal(repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal repository %s: %w", repo.Path, err)
+		}
+		p.entries[repo.Path] = data
+	}
+	settings, err := json.Marshal(changeRecord{Op: opSettings, PreferredProtocols: own.PreferredProtocols, Groups: own.Groups})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal state settings: %w", err)
+	}
+	p.settings = settings
+	return p, nil
+}
+
+// appendChangesLocked appends the records turning the persisted state of rs into own to the
+// change log of filePath, and compacts the log if it grew too large. If another process saved
+// since rs was loaded, changed entries are merged with the ones on disk field by field (see
+// merge3), and the merged entries replace those of rs. The caller must hold rs.mu and the lock
+// of the state file.
+func (rs *RepoState) appendChangesLocked(filePath string, own *RepoState) error {
+	if err := trimPartialRecord(LogPath(filePath)); err != nil {
+		return err
+	}
+	concurrent := rs.persisted.concurrent || markerOf(filePath) != rs.persisted.base || markerOf(LogPath(filePath)) != rs.persisted.log
+	var disk *persistedState
+	if concurrent {
+		fresh := NewRepoState(filePath)
+		if err := fresh.readLocked(filePath); err != nil {
+			return err
+		}
+		var err error
+		if disk, err = snapshot(fresh); err != nil {
+			return err
+		}
+	}
+
+	current, err := snapshot(own)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	merged := false
+	for _, repo := range own.Repositories {
+		data := current.entries[repo.Path]
+		old, ok := rs.persisted.entries[repo.Path]
+		if ok && bytes.Equal(old, data) {
+			continue
+		}
+		if theirs, found := disk.entry(repo.Path); found && ok && !bytes.Equal(theirs, old) {
+			var entry RepositoryEntry
+			if err := json.Unmarshal(merge3(old, data, theirs), &entry); err != nil {
+				return fmt.Errorf("failed to merge repository %s with the state on disk: %w", repo.Path, err)
+			}
+			if data, err = json.Marshal(entry); err != nil {
+				return err
+			}
+			current.entries[repo.Path] = data
+			if i := slices.IndexFunc(rs.Repositories, func(r RepositoryEntry) bool { return r.Path == repo.Path }); i >= 0 {
+				rs.Repositories[i] = entry
+				merged = true
+			}
+		}
+		if err := enc.Encode(changeRecord{Op: opPut, Entry: data}); err != nil {
+			return err
+		}
+	}
+	if merged {
+		rs.reindexLocked()
+	}
+	var removed []string
+	for path := range rs.persisted.entries {
+		if _, ok := current.entries[path]; !ok {
+			removed = append(removed, path)
+		}
+	}
+	sort.Strings(removed)
+	for _, path := range removed {
+		if err := enc.Encode(changeRecord{Op: opDelete, Path: path}); err != nil {
+			return err
+		}
+	}
+	if !bytes.Equal(current.settings, rs.persisted.settings) {
+		settings := current.settings
+		if disk != nil && !bytes.Equal(disk.settings, rs.persisted.settings) {
+			var record changeRecord
+			if err := json.Unmarshal(merge3(rs.persisted.settings, settings, disk.settings), &record); err != nil {
+				return fmt.Errorf("failed to merge state settings with the state on disk: %w", err)
+			}
+			rs.PreferredProtocols, rs.Groups = record.PreferredProtocols, record.Groups
+			if settings, err = json.Marshal(record); err != nil {
+				return err
+			}
+			current.settings = settings
+		}
+		buf.Write(settings)
+		buf.WriteByte('\n')
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+
+	// One write per save, so a crash can at most cut off the last record.
+	f, err := os.OpenFile(LogPath(filePath), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open change log %s: %w", LogPath(filePath), err)
+	}
+	_, err = f.Write(buf.Bytes())
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to append to change log %s: %w", LogPath(filePath), err)
+	}
+	rs.persisted = current
+	err = compactIfLargeLocked(filePath)
+	current.concurrent = concurrent
+	if !concurrent {
+		current.mark(filePath)
+	}
+	return err
+}
+
+// entry returns the JSON of the entry with path, if p holds one. p may be nil.
+func (p *persistedState) entry(path string) ([]byte, bool) {
+	if p == nil {
+		return nil, false
+	}
+	data, ok := p.entries[path]
+	return data, ok
+}
+
+// merge3 merges the JSON values ours and theirs, both changed from base: where only one of them
+// changed a field of an object (at any depth), its change is taken; where both changed it, ours
+// wins. nil stands for a missing field.
+func merge3(base, ours, theirs json.RawMessage) json.RawMessage {
+	switch {
+	case bytes.Equal(ours, base):
+		return theirs
+	case bytes.Equal(theirs, base), bytes.Equal(ours, theirs):
+		return ours
+	}
+	b := map[string]json.RawMessage{} // A field both added is merged as if it was empty before
+	var o, t map[string]json.RawMessage
+	if (base != nil && json.Unmarshal(base, &b) != nil) || json.Unmarshal(ours, &o) != nil || json.Unmarshal(theirs, &t) != nil || b == nil || o == nil || t == nil {
+		return ours // Not all objects: a conflict
+	}
+	merged := make(map[string]json.RawMessage, len(o))
+	for _, fields := range []map[string]json.RawMessage{b, o, t} {
+		for key := range fields {
+			if _, done := merged[key]; done {
+				continue
+			}
+			if value := merge3(b[key], o[key], t[key]); value != nil {
+				merged[key] = value
+			}
+		}
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return ours
+	}
+	return data
+}
+
+// trimPartialRecord removes a last record without its newline from the change log at path, left
+// behind by a crash while appending, so the next record doesn't continue its line.
+func trimPartialRecord(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read change log %s: %w", path, err)
+	}
+	if len(data) == 0 || data[len(data)-1] == '\n' {
+		return nil
+	}
+	if err := os.Truncate(path, int64(bytes.LastIndexByte(data, '\n')+1)); err != nil {
+		return fmt.Errorf("failed to repair change log %s: %w", path, err)
+	}
+	return nil
+}
+
+// replayLogLocked applies the change log of filePath to rs, which holds the state file it
+// belongs to. A missing log is empty. A last record cut off by a crash is ignored. The caller
+// must hold rs.mu.
+func (rs *RepoState) replayLogLocked(filePath string) error {
+	f, err := os.Open(LogPath(filePath))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to open change log %s: %w", LogPath(filePath), err)
+	}
+	defer f.Close()
+
+	positions := make(map[string]int, len(rs.Repositories))
+	for i, repo := range rs.Repositories {
+		positions[repo.Path] = i
+	}
+	reader := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return nil // A record without its newline wasn't completely written.
+		} else if err != nil {
+			return fmt.Errorf("failed to read change log %s: %w", LogPath(filePath), err)
+		}
+		var record changeRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return fmt.Errorf("change log %s is corrupt on line %d: %w", LogPath(filePath), line, err)
+		}
+		switch record.Op {
+		case opPut:
+			var repo RepositoryEntry
+			if err := json.Unmarshal(record.Entry, &repo); err != nil {
+				return fmt.Errorf("change log %s is corrupt on line %d: %w", LogPath(filePath), line, err)
+			}
+			if i, ok := positions[repo.Path]; ok {
+				rs.Repositories[i] = repo
+			} else {
+				positions[repo.Path] = len(rs.Repositories)
+				rs.Repositories = append(rs.Repositories, repo)
+			}
+		case opDelete:
+			i, ok := positions[record.Path]
+			if !ok {
+				continue
+			}
+			rs.Repositories = append(rs.Repositories[:i], rs.Repositories[i+1:]...)
+			delete(positions, record.Path)
+			for path, j := range positions {
+				if j > i {
+					positions[path] = j - 1
+				}
+			}
+		case opSettings:
+			rs.PreferredProtocols, rs.Groups = record.PreferredProtocols, record.Groups
+		default:
+			return fmt.Errorf("change log %s is corrupt on line %d: unknown operation '%s'", LogPath(filePath), line, record.Op)
+		}
+	}
+}
+
+// Compact folds the change log of the state file at filePath into the state file, so that the
+// file alone holds the whole state, e.g. before it is copied or edited. The state file is
+// locked against other fussy-git processes meanwhile.
+func Compact(filePath string) error {
+	unlock, err := lockStateFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to lock state file %s: %w", filePath, err)
+	}
+	defer unlock()
+	return compactLocked(filePath)
+}
+
+// compactIfLargeLocked compacts the change log of filePath once it is larger than the state file
+// and compactMinBytes. The caller must hold the lock of the state file.
+func compactIfLargeLocked(filePath string) error {
+	logInfo, err := os.Stat(LogPath(filePath))
+	if err != nil || logInfo.Size() < compactMinBytes {
+		return nil
+	}
+	if info, err := os.Stat(filePath); err == nil && logInfo.Size() < info.Size() {
+		return nil
+	}
+	return compactLocked(filePath)
+}
+
+// compactLocked writes the state file at filePath with its change log applied and removes the
+// log. The caller must hold the lock of the state file.
+func compactLocked(filePath string) error {
+	if _, err := os.Stat(LogPath(filePath)); os.IsNotExist(err) {
+		return nil
+	}
+	rs := NewRepoState(filePath)
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if err := rs.readLocked(filePath); err != nil {
+		return err
+	}
+	return rs.writeFileLocked(filePath, rs)
+}
@@ -0,0 +1,419 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name                     string
+		base, ours, theirs, want string
+	}{
+		{
+			name: "only ours changed",
+			base: `{"a":1,"b":2}`, ours: `{"a":3,"b":2}`, theirs: `{"a":1,"b":2}`,
+			want: `{"a":3,"b":2}`,
+		},
+		{
+			name: "only theirs changed",
+			base: `{"a":1,"b":2}`, ours: `{"a":1,"b":2}`, theirs: `{"a":1,"b":4}`,
+			want: `{"a":1,"b":4}`,
+		},
+		{
+			name: "different fields changed",
+			base: `{"a":1,"b":2}`, ours: `{"a":3,"b":2}`, theirs: `{"a":1,"b":4}`,
+			want: `{"a":3,"b":4}`,
+		},
+		{
+			name: "same field changed alike",
+			base: `{"a":1}`, ours: `{"a":3}`, theirs: `{"a":3}`,
+			want: `{"a":3}`,
+		},
+		{
+			name: "same field changed differently, ours wins",
+			base: `{"a":1,"b":2}`, ours: `{"a":3,"b":2}`, theirs: `{"a":5,"b":6}`,
+			want: `{"a":3,"b":6}`,
+		},
+		{
+			name: "nested objects merged field by field",
+			base: `{"o":{"x":1,"y":2}}`, ours: `{"o":{"x":3,"y":2}}`, theirs: `{"o":{"x":1,"y":4}}`,
+			want: `{"o":{"x":3,"y":4}}`,
+		},
+		{
+			name: "fields added on both sides",
+			base: `{"a":1}`, ours: `{"a":1,"b":2}`, theirs: `{"a":1,"c":3}`,
+			want: `{"a":1,"b":2,"c":3}`,
+		},
+		{
+			name: "object added on both sides",
+			base: `{}`, ours: `{"o":{"x":1}}`, theirs: `{"o":{"y":2}}`,
+			want: `{"o":{"x":1,"y":2}}`,
+		},
+		{
+			name: "field removed by theirs",
+			base: `{"a":1,"b":2}`, ours: `{"a":3,"b":2}`, theirs: `{"a":1}`,
+			want: `{"a":3}`,
+		},
+		{
+			name: "field removed by ours, changed by theirs",
+			base: `{"a":1,"b":2}`, ours: `{"a":1}`, theirs: `{"a":1,"b":4}`,
+			want: `{"a":1}`,
+		},
+		{
+			name: "arrays aren't merged",
+			base: `{"t":["x"]}`, ours: `{"t":["x","y"]}`, theirs: `{"t":["x","z"]}`,
+			want: `{"t":["x","y"]}`,
+		},
+		{
+			name: "objects replaced by other values",
+			base: `{"o":{"x":1}}`, ours: `{"o":{"x":2}}`, theirs: `{"o":null}`,
+			want: `{"o":{"x":2}}`,
+		},
+		{
+			name: "no base",
+			base: "", ours: `{"a":1,"b":2}`, theirs: `{"a":3,"c":4}`,
+			want: `{"a":1,"b":2,"c":4}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var base json.RawMessage
+			if tt.base != "" {
+				base = json.RawMessage(tt.base)
+			}
+			got := merge3(base, json.RawMessage(tt.ours), json.RawMessage(tt.theirs))
+			if !jsonEqual(t, got, []byte(tt.want)) {
+				t.Errorf("merge3() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// jsonEqual tells whether a and b hold the same JSON value, whatever the order of their fields.
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var va, vb any
+	if err := json.Unmarshal(a, &va); err != nil {
+		t.Fatalf("invalid JSON %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func TestConcurrentSaves(t *testing.T) {
+	tests := []struct {
+		name string
+		// first and second change the state of two processes that loaded the same state, which
+		// then save in that order.
+		first, second func(rs *RepoState)
+		want          []RepositoryEntry
+		wantGroups    []string
+	}{
+		{
+			name:   "different fields of an entry",
+			first:  func(rs *RepoState) { rs.Repositories[0].Notes = "first" },
+			second: func(rs *RepoState) { rs.Repositories[0].Pinned = true },
+			want: []RepositoryEntry{
+				{Name: "a", Path: "/src/a", Notes: "first", Pinned: true},
+				{Name: "b", Path: "/src/b"},
+			},
+		},
+		{
+			name:   "same field of an entry",
+			first:  func(rs *RepoState) { rs.Repositories[0].Notes = "first" },
+			second: func(rs *RepoState) { rs.Repositories[0].Notes = "second" },
+			want: []RepositoryEntry{
+				{Name: "a", Path: "/src/a", Notes: "second"},
+				{Name: "b", Path: "/src/b"},
+			},
+		},
+		{
+			name:   "metadata keys of an entry",
+			first:  func(rs *RepoState) { rs.Repositories[0].Metadata = map[string]string{"team": "core"} },
+			second: func(rs *RepoState) { rs.Repositories[0].Metadata = map[string]string{"owner": "me"} },
+			want: []RepositoryEntry{
+				{Name: "a", Path: "/src/a", Metadata: map[string]string{"team": "core", "owner": "me"}},
+				{Name: "b", Path: "/src/b"},
+			},
+		},
+		{
+			name:   "different entries",
+			first:  func(rs *RepoState) { rs.Repositories[0].Notes = "first" },
+			second: func(rs *RepoState) { rs.Repositories[1].Notes = "second" },
+			want: []RepositoryEntry{
+				{Name: "a", Path: "/src/a", Notes: "first"},
+				{Name: "b", Path: "/src/b", Notes: "second"},
+			},
+		},
+		{
+			name: "added and changed entries",
+			first: func(rs *RepoState) {
+				rs.Repositories = append(rs.Repositories, RepositoryEntry{Name: "c", Path: "/src/c"})
+			},
+			second: func(rs *RepoState) { rs.Repositories[1].Notes = "second" },
+			want: []RepositoryEntry{
+				{Name: "a", Path: "/src/a"},
+				{Name: "b", Path: "/src/b", Notes: "second"},
+				{Name: "c", Path: "/src/c"},
+			},
+		},
+		{
+			name:   "removed and changed entries",
+			first:  func(rs *RepoState) { rs.Repositories = rs.Repositories[1:] },
+			second: func(rs *RepoState) { rs.Repositories[1].Notes = "second" },
+			want: []RepositoryEntry{
+				{Name: "b", Path: "/src/b", Notes: "second"},
+			},
+		},
+		{
+			name:   "groups",
+			first:  func(rs *RepoState) { rs.Groups = map[string]Group{"one": {}} },
+			second: func(rs *RepoState) { rs.Groups = map[string]Group{"two": {}} },
+			want: []RepositoryEntry{
+				{Name: "a", Path: "/src/a"},
+				{Name: "b", Path: "/src/b"},
+			},
+			wantGroups: []string{"one", "two"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestState(t, RepositoryEntry{Name: "a", Path: "/src/a"}, RepositoryEntry{Name: "b", Path: "/src/b"})
+			first, second := loadTestState(t, path), loadTestState(t, path)
+
+			tt.first(first)
+			if err := first.Save(); err != nil {
+				t.Fatalf("first Save() error = %v", err)
+			}
+			loadedBySecond := slices.Clone(second.Repositories)
+			tt.second(second)
+			if err := second.Save(); err != nil {
+				t.Fatalf("second Save() error = %v", err)
+			}
+
+			loaded := loadTestState(t, path)
+			assertRepositories(t, loaded.Repositories, tt.want)
+			if got := groupNames(loaded); !reflect.DeepEqual(got, tt.wantGroups) {
+				t.Errorf("groups = %v, want %v", got, tt.wantGroups)
+			}
+			// The process that saved last holds the merged entries it changed.
+			for _, want := range tt.want {
+				i := slices.IndexFunc(second.Repositories, func(r RepositoryEntry) bool { return r.Path == want.Path })
+				if i >= 0 && i < len(loadedBySecond) && !reflect.DeepEqual(second.Repositories[i], loadedBySecond[i]) {
+					assertRepositories(t, second.Repositories[i:i+1], []RepositoryEntry{want})
+				}
+			}
+
+			// Later saves of either process keep the merged state.
+			first.Repositories = append(first.Repositories, RepositoryEntry{Name: "d", Path: "/src/d"})
+			if err := first.Save(); err != nil {
+				t.Fatalf("third Save() error = %v", err)
+			}
+			loaded = loadTestState(t, path)
+			assertRepositories(t, loaded.Repositories, append(slices.Clone(tt.want), RepositoryEntry{Name: "d", Path: "/src/d"}))
+		})
+	}
+}
+
+func TestReplayLog(t *testing.T) {
+	tests := []struct {
+		name       string
+		log        string
+		want       []RepositoryEntry
+		wantGroups []string
+		wantErr    string
+	}{
+		{
+			name: "no records",
+			log:  "",
+			want: []RepositoryEntry{{Name: "a", Path: "/src/a"}, {Name: "b", Path: "/src/b"}},
+		},
+		{
+			name: "put replaces and adds entries",
+			log: `{"op":"put","entry":{"name":"a","path":"/src/a","notes":"changed"}}
+{"op":"put","entry":{"name":"c","path":"/src/c"}}
+`,
+			want: []RepositoryEntry{{Name: "a", Path: "/src/a", Notes: "changed"}, {Name: "b", Path: "/src/b"}, {Name: "c", Path: "/src/c"}},
+		},
+		{
+			name: "delete removes entries",
+			log: `{"op":"put","entry":{"name":"c","path":"/src/c"}}
+{"op":"delete","path":"/src/a"}
+{"op":"put","entry":{"name":"c","path":"/src/c","notes":"after delete"}}
+{"op":"delete","path":"/src/missing"}
+`,
+			want: []RepositoryEntry{{Name: "b", Path: "/src/b"}, {Name: "c", Path: "/src/c", Notes: "after delete"}},
+		},
+		{
+			name: "later records win",
+			log: `{"op":"put","entry":{"name":"a","path":"/src/a","notes":"one"}}
+{"op":"put","entry":{"name":"a","path":"/src/a","notes":"two"}}
+`,
+			want: []RepositoryEntry{{Name: "a", Path: "/src/a", Notes: "two"}, {Name: "b", Path: "/src/b"}},
+		},
+		{
+			name:       "settings",
+			log:        `{"op":"settings","groups":{"g":{}}}` + "\n",
+			want:       []RepositoryEntry{{Name: "a", Path: "/src/a"}, {Name: "b", Path: "/src/b"}},
+			wantGroups: []string{"g"},
+		},
+		{
+			name: "record cut off by a crash",
+			log: `{"op":"put","entry":{"name":"a","path":"/src/a","notes":"complete"}}
+{"op":"delete","path":"/sr`,
+			want: []RepositoryEntry{{Name: "a", Path: "/src/a", Notes: "complete"}, {Name: "b", Path: "/src/b"}},
+		},
+		{
+			name:    "corrupt record",
+			log:     "{\"op\":\"put\",\"entry\":{}}\nnot json\n",
+			wantErr: "corrupt on line 2",
+		},
+		{
+			name:    "unknown operation",
+			log:     `{"op":"rename","path":"/src/a"}` + "\n",
+			wantErr: "unknown operation 'rename'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestState(t, RepositoryEntry{Name: "a", Path: "/src/a"}, RepositoryEntry{Name: "b", Path: "/src/b"})
+			if err := os.WriteFile(LogPath(path), []byte(tt.log), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			rs, err := ReadState(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadState() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadState() error = %v", err)
+			}
+			assertRepositories(t, rs.Repositories, tt.want)
+			if got := groupNames(rs); !reflect.DeepEqual(got, tt.wantGroups) {
+				t.Errorf("groups = %v, want %v", got, tt.wantGroups)
+			}
+		})
+	}
+}
+
+func TestSaveAfterPartialRecord(t *testing.T) {
+	path := writeTestState(t, RepositoryEntry{Name: "a", Path: "/src/a"})
+	rs := loadTestState(t, path)
+	if err := os.WriteFile(LogPath(path), []byte(`{"op":"delete","pa`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rs.Repositories[0].Notes = "saved"
+	if err := rs.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	assertRepositories(t, loadTestState(t, path).Repositories, []RepositoryEntry{{Name: "a", Path: "/src/a", Notes: "saved"}})
+}
+
+func TestCompaction(t *testing.T) {
+	path := writeTestState(t, RepositoryEntry{Name: "a", Path: "/src/a"})
+	rs := loadTestState(t, path)
+
+	rs.Repositories[0].Notes = "small"
+	if err := rs.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := os.Stat(LogPath(path)); err != nil {
+		t.Fatalf("a small save didn't append to the change log: %v", err)
+	}
+
+	// A log larger than compactMinBytes and the state file is folded into the state file.
+	large := strings.Repeat("x", compactMinBytes)
+	rs.Repositories = append(rs.Repositories, RepositoryEntry{Name: "b", Path: "/src/b", Notes: large})
+	if err := rs.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := os.Stat(LogPath(path)); !os.IsNotExist(err) {
+		t.Fatalf("the change log wasn't compacted: %v", err)
+	}
+	want := []RepositoryEntry{{Name: "a", Path: "/src/a", Notes: "small"}, {Name: "b", Path: "/src/b", Notes: large}}
+	assertRepositories(t, readStateFile(t, path), want)
+
+	// Saves after the compaction append to a new log again.
+	rs.Repositories[0].Notes = "after"
+	if err := rs.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	want[0].Notes = "after"
+	assertRepositories(t, loadTestState(t, path).Repositories, want)
+
+	if err := Compact(path); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	if _, err := os.Stat(LogPath(path)); !os.IsNotExist(err) {
+		t.Fatalf("Compact() left the change log behind: %v", err)
+	}
+	assertRepositories(t, readStateFile(t, path), want)
+}
+
+// writeTestState writes a state file holding entries to a temporary directory and returns its
+// path.
+func writeTestState(t *testing.T, entries ...RepositoryEntry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "repos.json")
+	rs := NewRepoState(path)
+	rs.Repositories = entries
+	if err := rs.Save(); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+	return path
+}
+
+func loadTestState(t *testing.T, path string) *RepoState {
+	t.Helper()
+	rs, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	return rs
+}
+
+// readStateFile returns the entries of the state file at path, without its change log.
+func readStateFile(t *testing.T, path string) []RepositoryEntry {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rs RepoState
+	if err := json.Unmarshal(data, &rs); err != nil {
+		t.Fatal(err)
+	}
+	return rs.Repositories
+}
+
+// assertRepositories compares entries by their JSON, which is what the state records.
+func assertRepositories(t *testing.T, got, want []RepositoryEntry) {
+	t.Helper()
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if !bytes.Equal(gotJSON, wantJSON) {
+		t.Errorf("repositories = %s\nwant %s", gotJSON, wantJSON)
+	}
+}
+
+func groupNames(rs *RepoState) []string {
+	var names []string
+	for name := range rs.Groups {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
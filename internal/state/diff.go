@@ -0,0 +1,125 @@
+package state
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldChange is a field of a repository entry that differs between two states.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// EntryChange lists how the entry of a repository tracked in both states differs.
+type EntryChange struct {
+	Name         string        `json:"name"`
+	NormalizedFS string        `json:"normalized_fs"`
+	Changes      []FieldChange `json:"changes"`
+}
+
+// Diff is the difference between an old and a new state.
+type Diff struct {
+	Added   []RepositoryEntry `json:"added"`   // Tracked only in the new state
+	Removed []RepositoryEntry `json:"removed"` // Tracked only in the old state
+	Changed []EntryChange     `json:"changed"`
+}
+
+// Empty reports whether the states track the same repositories the same way.
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Compare returns how newState differs from oldState. Entries are matched by normalized path
+// (host/owner/repository), which doesn't depend on where FUSSY_GIT_HOME is, then by path and
+// by original URL. Timestamps, clone statistics and verification results are not compared.
+func Compare(oldState, newState *RepoState) *Diff {
+	oldState.mu.RLock()
+	defer oldState.mu.RUnlock()
+	newState.mu.RLock()
+	defer newState.mu.RUnlock()
+
+	matched := make([]bool, len(newState.Repositories))
+	find := func(key func(RepositoryEntry) string, value string) int {
+		if value == "" {
+			return -1
+		}
+		for i, repo := range newState.Repositories {
+			if !matched[i] && key(repo) == value {
+				return i
+			}
+		}
+		return -1
+	}
+
+	d := &Diff{Added: []RepositoryEntry{}, Removed: []RepositoryEntry{}, Changed: []EntryChange{}}
+	for _, old := range oldState.Repositories {
+		i := find(func(r RepositoryEntry) string { return r.NormalizedFS }, old.NormalizedFS)
+		if i < 0 {
+			i = find(func(r RepositoryEntry) string { return r.Path }, old.Path)
+		}
+		if i < 0 {
+			i = find(func(r RepositoryEntry) string { return r.OriginalURL }, old.OriginalURL)
+		}
+		if i < 0 {
+			d.Removed = append(d.Removed, old)
+			continue
+		}
+		matched[i] = true
+		if changes := compareEntries(old, newState.Repositories[i]); len(changes) > 0 {
+			d.Changed = append(d.Changed, EntryChange{Name: old.Name, NormalizedFS: old.NormalizedFS, Changes: changes})
+		}
+	}
+	for i, repo := range newState.Repositories {
+		if !matched[i] {
+			d.Added = append(d.Added, repo)
+		}
+	}
+
+	byPath := func(repos []RepositoryEntry) {
+		sort.SliceStable(repos, func(i, j int) bool { return repos[i].Path < repos[j].Path })
+	}
+	byPath(d.Added)
+	byPath(d.Removed)
+	sort.SliceStable(d.Changed, func(i, j int) bool { return d.Changed[i].NormalizedFS < d.Changed[j].NormalizedFS })
+	return d
+}
+
+// compareEntries returns the fields users care about that differ between two entries of the
+// same repository.
+func compareEntries(old, new RepositoryEntry) []FieldChange {
+	var changes []FieldChange
+	field := func(name, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, FieldChange{Field: name, Old: oldValue, New: newValue})
+		}
+	}
+	list := func(values []string) string {
+		sorted := append([]string{}, values...)
+		sort.Strings(sorted)
+		return strings.Join(sorted, ", ")
+	}
+	metadata := func(m map[string]string) string {
+		pairs := make([]string, 0, len(m))
+		for k, v := range m {
+			pairs = append(pairs, k+"="+v)
+		}
+		return list(pairs)
+	}
+
+	field("name", old.Name, new.Name)
+	field("path", old.Path, new.Path)
+	field("original_url", old.OriginalURL, new.OriginalURL)
+	field("current_url", old.CurrentURL, new.CurrentURL)
+	field("normalized_fs", old.NormalizedFS, new.NormalizedFS)
+	field("path_override", old.PathOverride, new.PathOverride)
+	field("tags", list(old.Tags), list(new.Tags))
+	field("groups", list(old.Groups), list(new.Groups))
+	field("pinned", strconv.FormatBool(old.Pinned), strconv.FormatBool(new.Pinned))
+	field("locked", strconv.FormatBool(old.Locked), strconv.FormatBool(new.Locked))
+	field("notes", old.Notes, new.Notes)
+	field("metadata", metadata(old.Metadata), metadata(new.Metadata))
+	return changes
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package state
+
+// lockStateFile would lock the state file at filePath against other fussy-git processes. File
+// locks aren't available on this platform, so it does nothing; saves still never leave a
+// half-written state file behind, but concurrent processes may overwrite each other's changes
+// when the change log is compacted.
+func lockStateFile(filePath string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package state
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockStateFile takes an exclusive lock on the lock file next to the state file at filePath,
+// waiting for other fussy-git processes to release it, and returns the function releasing it.
+func lockStateFile(filePath string) (func(), error) {
+	f, err := os.OpenFile(filePath+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,56 @@
+package state
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Group describes a named collection of repositories, e.g. the repositories of a multi-repo
+// project, see 'fussy-git group'.
+type Group struct {
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ValidateGroupName checks that name can be used as a group name: it must not be empty or
+// contain ',' or whitespace, so that it can be given in comma-separated flag values.
+func ValidateGroupName(name string) error {
+	if name == "" {
+		return fmt.Errorf("group name is empty")
+	}
+	if strings.ContainsAny(name, ", \t\n") {
+		return fmt.Errorf("group name '%s' must not contain ',' or whitespace", name)
+	}
+	return nil
+}
+
+// InGroup reports whether the repository is a member of the group.
+func (e RepositoryEntry) InGroup(name string) bool {
+	return slices.Contains(e.Groups, name)
+}
+
+// DeleteGroup removes the group and the membership of all its repositories. It returns the
+// number of repositories that were members.
+func (rs *RepoState) DeleteGroup(name string) int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	delete(rs.Groups, name)
+	if len(rs.Groups) == 0 {
+		rs.Groups = nil
+	}
+	removed := 0
+	for i := range rs.Repositories {
+		repo := &rs.Repositories[i]
+		if idx := slices.Index(repo.Groups, name); idx >= 0 {
+			repo.Groups = slices.Delete(repo.Groups, idx, idx+1)
+			if len(repo.Groups) == 0 {
+				repo.Groups = nil
+			}
+			repo.LastModified = time.Now()
+			removed++
+		}
+	}
+	return removed
+}
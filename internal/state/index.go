@@ -0,0 +1,195 @@
+package state
+
+import (
+	"github.com/jmsnll/fussy-git/internal/fsutil"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// index maps the keys repositories are looked up by to their position in
+// RepoState.Repositories, so lookups don't scan the whole slice.
+type index struct {
+	count         int // len(Repositories) when the index was built, to detect direct appends
+	byPath        map[string]int
+	byOriginalURL map[string]int
+	byNormalized  map[string][]int // Keyed by the slash-separated NormalizedFS
+	paths         *pathTrie
+}
+
+// pathTrie holds repository paths split into their elements, to find the repository
+// containing a directory by walking down from the root.
+type pathTrie struct {
+	children map[string]*pathTrie
+	repo     int // Index of the repository at this path, -1 if there is none
+}
+
+func newPathTrie() *pathTrie {
+	return &pathTrie{children: map[string]*pathTrie{}, repo: -1}
+}
+
+// splitPath splits a cleaned absolute path into its elements, e.g. "/a/b" -> ["", "a", "b"].
+func splitPath(path string) []string {
+	return strings.Split(filepath.Clean(path), string(filepath.Separator))
+}
+
+func (t *pathTrie) insert(path string, repo int) {
+	node := t
+	for _, elem := range splitPath(path) {
+		child, ok := node.children[elem]
+		if !ok {
+			child = newPathTrie()
+			node.children[elem] = child
+		}
+		node = child
+	}
+	node.repo = repo
+}
+
+// longestPrefix returns the repository whose path is path or its closest parent, or -1.
+func (t *pathTrie) longestPrefix(path string) int {
+	best, node := -1, t
+	for _, elem := range splitPath(path) {
+		if node = node.children[elem]; node == nil {
+			break
+		}
+		if node.repo >= 0 {
+			best = node.repo
+		}
+	}
+	return best
+}
+
+// add records the repository at position i. Earlier entries win for duplicate keys, like
+// the linear scans the index replaces.
+func (idx *index) add(i int, repo RepositoryEntry) {
+	// A repository reached through a symlink can also be found by its resolved path, e.g.
+	// when the current directory is given as the physical path.
+	for _, path := range repo.paths() {
+		if _, dup := idx.byPath[path]; !dup {
+			idx.byPath[path] = i
+			idx.paths.insert(path, i)
+		}
+	}
+	if _, dup := idx.byOriginalURL[repo.OriginalURL]; !dup && repo.OriginalURL != "" {
+		idx.byOriginalURL[repo.OriginalURL] = i
+	}
+	if repo.NormalizedFS != "" {
+		normalized := filepath.ToSlash(repo.NormalizedFS)
+		idx.byNormalized[normalized] = append(idx.byNormalized[normalized], i)
+	}
+	idx.count++
+}
+
+// reindexLocked rebuilds the index from Repositories. The caller must hold the write lock.
+func (rs *RepoState) reindexLocked() {
+	rs.idx = &index{
+		byPath:        map[string]int{},
+		byOriginalURL: map[string]int{},
+		byNormalized:  map[string][]int{},
+		paths:         newPathTrie(),
+	}
+	for i, repo := range rs.Repositories {
+		rs.idx.add(i, repo)
+	}
+}
+
+// Reindex rebuilds the lookup indexes. Call it after changing the Path, OriginalURL or
+// NormalizedFS of entries in Repositories directly instead of through the methods of RepoState.
+func (rs *RepoState) Reindex() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.reindexLocked()
+}
+
+// lookupLocked finds a repository through the index. valid reports whether the entry found
+// still has the key it was indexed under; if not, or if entries were appended directly,
+// the index is rebuilt once. The caller must hold the write lock.
+func (rs *RepoState) lookupLocked(find func(*index) int, valid func(RepositoryEntry) bool) int {
+	if rs.idx == nil || rs.idx.count != len(rs.Repositories) {
+		rs.reindexLocked()
+	}
+	i := find(rs.idx)
+	if i >= 0 && (i >= len(rs.Repositories) || !valid(rs.Repositories[i])) {
+		rs.reindexLocked()
+		i = find(rs.idx)
+	}
+	return i
+}
+
+// IndexOfPath returns the position in Repositories of the repository at path, or -1.
+// If no repository is recorded at path, its resolved path is looked up, so a repository is
+// also found through a symlink pointing to it.
+func (rs *RepoState) IndexOfPath(path string) int {
+	if i := rs.indexOfPath(filepath.Clean(path)); i >= 0 {
+		return i
+	}
+	if resolved := fsutil.ResolvePath(path); resolved != filepath.Clean(path) {
+		return rs.indexOfPath(resolved)
+	}
+	return -1
+}
+
+// indexOfPath looks up the repository whose Path or ResolvedPath is the cleaned path.
+func (rs *RepoState) indexOfPath(path string) int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.lookupLocked(func(idx *index) int {
+		if i, ok := idx.byPath[path]; ok {
+			return i
+		}
+		return -1
+	}, func(repo RepositoryEntry) bool { return slices.Contains(repo.paths(), path) })
+}
+
+// IndexOfNormalizedPath returns the position in Repositories of the first repository whose
+// normalized path (e.g. github.com/spf13/cobra) is normalized, or -1.
+func (rs *RepoState) IndexOfNormalizedPath(normalized string) int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.lookupLocked(func(idx *index) int {
+		if matches := idx.byNormalized[normalized]; len(matches) > 0 {
+			return matches[0]
+		}
+		return -1
+	}, func(repo RepositoryEntry) bool { return filepath.ToSlash(repo.NormalizedFS) == normalized })
+}
+
+// IndexContaining returns the position in Repositories of the repository that contains
+// dir, i.e. whose path is dir or its closest parent directory, or -1. Like IndexOfPath, it
+// falls back to the resolved path of dir.
+func (rs *RepoState) IndexContaining(dir string) int {
+	if i := rs.indexContaining(filepath.Clean(dir)); i >= 0 {
+		return i
+	}
+	if resolved := fsutil.ResolvePath(dir); resolved != filepath.Clean(dir) {
+		return rs.indexContaining(resolved)
+	}
+	return -1
+}
+
+// indexContaining looks up the repository containing the cleaned dir.
+func (rs *RepoState) indexContaining(dir string) int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.lookupLocked(func(idx *index) int {
+		return idx.paths.longestPrefix(dir)
+	}, func(repo RepositoryEntry) bool {
+		for _, path := range repo.paths() {
+			rel, err := filepath.Rel(path, dir)
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// paths returns the cleaned Path of the repository, followed by its ResolvedPath if it has one.
+func (repo RepositoryEntry) paths() []string {
+	paths := []string{filepath.Clean(repo.Path)}
+	if repo.ResolvedPath != "" && filepath.Clean(repo.ResolvedPath) != paths[0] {
+		paths = append(paths, filepath.Clean(repo.ResolvedPath))
+	}
+	return paths
+}
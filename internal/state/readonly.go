@@ -0,0 +1,54 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrReadOnly is returned by Save for a read-only state that was changed beyond timestamps.
+var ErrReadOnly = errors.New("the state is read-only")
+
+// SetReadOnly makes Save refuse to write the state if anything but the timestamps of its
+// repositories (LastChecked, LastModified, LastFetched, LastMirrored) changed since this call, e.g. when
+// auditing a tree that must not be changed.
+func (rs *RepoState) SetReadOnly() error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	data, err := rs.withoutTimestampsLocked()
+	if err != nil {
+		return err
+	}
+	rs.readOnly = data
+	return nil
+}
+
+// checkReadOnlyLocked returns an error wrapping ErrReadOnly if the state is read-only and
+// changed beyond timestamps. The caller must hold rs.mu.
+func (rs *RepoState) checkReadOnlyLocked() error {
+	if rs.readOnly == nil {
+		return nil
+	}
+	data, err := rs.withoutTimestampsLocked()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(data, rs.readOnly) {
+		return fmt.Errorf("%w: only timestamps may be updated", ErrReadOnly)
+	}
+	return nil
+}
+
+// withoutTimestampsLocked returns the JSON of the state with the timestamps of its repositories
+// zeroed, to compare states ignoring them. The caller must hold rs.mu.
+func (rs *RepoState) withoutTimestampsLocked() ([]byte, error) {
+	repos := make([]RepositoryEntry, len(rs.Repositories))
+	copy(repos, rs.Repositories)
+	for i := range repos {
+		repos[i].LastChecked, repos[i].LastModified = time.Time{}, time.Time{}
+		repos[i].LastFetched, repos[i].LastMirrored = time.Time{}, time.Time{}
+	}
+	return json.Marshal(&RepoState{Repositories: repos, PreferredProtocols: rs.PreferredProtocols, Groups: rs.Groups})
+}
@@ -0,0 +1,86 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// LoadShared overlays the repositories of the read-only shared state file at sharedPath, e.g.
+// the state of a tree on a build server maintained by an administrator, onto rs. Entries of rs
+// take precedence over shared entries with the same path. Shared entries are marked as locked,
+// since moving or replacing them affects everyone using the tree.
+//
+// Shared entries are only written to the state file of rs once they were changed (e.g. tagged
+// or unlocked); from then on that copy overrides the shared one. A missing shared state file is
+// treated as empty. Its change log is replayed, but the file isn't locked, since it is usually
+// not writable for everyone reading it.
+func (rs *RepoState) LoadShared(sharedPath string) error {
+	var shared RepoState
+	if err := shared.readLocked(sharedPath); err != nil {
+		return fmt.Errorf("failed to read shared state: %w", err)
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	own := make(map[string]bool, len(rs.Repositories))
+	for _, repo := range rs.Repositories {
+		own[repo.Path] = true
+	}
+	if rs.shared == nil {
+		rs.shared = make(map[string][]byte)
+	}
+	for _, repo := range shared.Repositories {
+		if own[repo.Path] {
+			continue
+		}
+		repo.Locked = true
+		// Keep the entry as it was loaded, to tell on save whether it was changed.
+		original, err := json.Marshal(repo)
+		if err != nil {
+			return fmt.Errorf("failed to record shared repository %s: %w", repo.Path, err)
+		}
+		rs.shared[repo.Path] = original
+		rs.Repositories = append(rs.Repositories, repo)
+	}
+	rs.reindexLocked()
+	return nil
+}
+
+// IsShared reports whether the repository at path comes from the shared state and hasn't been
+// changed locally.
+func (rs *RepoState) IsShared(path string) bool {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	for _, repo := range rs.Repositories {
+		if repo.Path == path {
+			return rs.unchangedSharedLocked(repo)
+		}
+	}
+	return false
+}
+
+// unchangedSharedLocked reports whether repo is a shared entry that is still as it was loaded.
+// The caller must hold rs.mu.
+func (rs *RepoState) unchangedSharedLocked(repo RepositoryEntry) bool {
+	original, ok := rs.shared[repo.Path]
+	if !ok {
+		return false
+	}
+	current, err := json.Marshal(repo)
+	return err == nil && string(current) == string(original)
+}
+
+// ownRepositoriesLocked returns the repositories to write to the state file: all of them
+// except unchanged shared ones. The caller must hold rs.mu.
+func (rs *RepoState) ownRepositoriesLocked() []RepositoryEntry {
+	if len(rs.shared) == 0 {
+		return rs.Repositories
+	}
+	own := make([]RepositoryEntry, 0, len(rs.Repositories))
+	for _, repo := range rs.Repositories {
+		if !rs.unchangedSharedLocked(repo) {
+			own = append(own, repo)
+		}
+	}
+	return own
+}
@@ -3,7 +3,6 @@ package state
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -12,24 +11,108 @@ import (
 
 // RepositoryEntry represents a single repository tracked by fussy-git.
 type RepositoryEntry struct {
-	Name          string    `json:"name"`           // Short name of the repository (e.g., "cobra")
-	Path          string    `json:"path"`           // Full local path to the repository
-	OriginalURL   string    `json:"original_url"`   // The URL used when initially cloned
-	CurrentURL    string    `json:"current_url"`    // The current origin URL (might change if remote changes)
-	Domain        string    `json:"domain"`         // Domain of the repository (e.g., "github.com")
-	NormalizedFS  string    `json:"normalized_fs"`  // Normalized path used for filesystem structure (e.g., github.com/user/repo)
-	LastChecked   time.Time `json:"last_checked"`   // Timestamp of when the repo origin was last checked
-	LastModified  time.Time `json:"last_modified"`  // Timestamp of when this entry was last modified
-	ClonedAt      time.Time `json:"cloned_at"`      // Timestamp of when the repo was cloned
-	ManuallyAdded bool      `json:"manually_added"` // True if this entry was added via a command other than clone (e.g. 'fussy-git add')
-	Notes         string    `json:"notes"`          // Any user-added notes for this repository
+	Name          string        `json:"name"`                    // Short name of the repository (e.g., "cobra")
+	Path          string        `json:"path"`                    // Full local path to the repository
+	ResolvedPath  string        `json:"resolved_path,omitempty"` // Path with symlinks resolved, if that differs from Path
+	GitDir        string        `json:"git_dir,omitempty"`       // Git directory the .git file of the work tree points to (--separate-git-dir, worktrees); empty for a .git directory
+	OriginalURL   string        `json:"original_url"`            // The URL used when initially cloned
+	CurrentURL    string        `json:"current_url"`             // The current origin URL (might change if remote changes)
+	Domain        string        `json:"domain"`                  // Domain of the repository (e.g., "github.com")
+	NormalizedFS  string        `json:"normalized_fs"`           // Normalized path used for filesystem structure (e.g., github.com/user/repo)
+	LastChecked   time.Time     `json:"last_checked"`            // Timestamp of when the repo origin was last checked
+	LastFetched   time.Time     `json:"last_fetched,omitzero"`   // Timestamp of the last successful 'fussy-git fetch'
+	LastMirrored  time.Time     `json:"last_mirrored,omitzero"`  // Timestamp of the last successful 'fussy-git mirror-push run'
+	LastModified  time.Time     `json:"last_modified"`           // Timestamp of when this entry was last modified
+	ClonedAt      time.Time     `json:"cloned_at"`               // Timestamp of when the repo was cloned
+	ManuallyAdded bool          `json:"manually_added"`          // True if this entry was added via a command other than clone (e.g. 'fussy-git add')
+	Notes         string        `json:"notes"`                   // Any user-added notes for this repository
+	Tags          []string      `json:"tags,omitempty"`          // Free-form labels used to group and filter repositories
+	Pinned        bool          `json:"pinned,omitempty"`        // True if the repository must stay at its current path (never moved by reorganize)
+	Locked        bool          `json:"locked,omitempty"`        // True if destructive operations (moves, reclone, pruning) require --force, see 'fussy-git lock'
+	PathOverride  string        `json:"path_override,omitempty"` // Custom location that replaces the computed conventional path
+	ModulePath    string        `json:"module_path,omitempty"`   // Go module path the repository was fetched by (e.g. golang.org/x/tools), see 'fussy-git get'
+	Shallow       bool          `json:"shallow,omitempty"`       // True if the repository is a shallow clone with truncated history
+	LFS           bool          `json:"lfs,omitempty"`           // True if the repository uses Git LFS, whose content isn't in its object database
+	Maintenance   bool          `json:"maintenance,omitempty"`   // True if the repository was registered for git's background maintenance ('git maintenance start')
+	Verification  *Verification `json:"verification,omitempty"`  // Result of the last 'fussy-git verify', nil if never verified
+	License       string        `json:"license,omitempty"`       // SPDX identifier of the repository's license, "unknown" or "none" (see 'fussy-git licenses'); empty if not scanned
+	Description   string        `json:"description,omitempty"`   // First paragraph or heading of the repository's README, refreshed with its origin URL
+	// RemoteChanges records changes on the provider reported by webhooks, nil if none were.
+	RemoteChanges *RemoteChanges `json:"remote_changes,omitempty"`
+	// IgnoredChecks lists the IDs of the doctor checks not reported for the repository, see
+	// 'fussy-git doctor ignore'.
+	IgnoredChecks []string `json:"ignored_checks,omitempty"`
+	// Metadata holds arbitrary key/value data attached by users and tools, e.g. "ticket" -> "OPS-123".
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// CloneStats records how long the last clone took and how much it fetched, nil for
+	// repositories that were added rather than cloned.
+	CloneStats *CloneStats `json:"clone_stats,omitempty"`
+	// Groups lists the groups (see RepoState.Groups) the repository is a member of.
+	Groups []string `json:"groups,omitempty"`
+	// CloneOptions records the options that limited what the repository was cloned with, nil
+	// for a full clone.
+	CloneOptions *CloneOptions `json:"clone_options,omitempty"`
+}
+
+// CloneOptions are the options a repository was cloned with that limit its history, objects
+// or checked out files. 'fussy-git unshallow' and 'fussy-git densify' reverse them.
+type CloneOptions struct {
+	Depth        int      `json:"depth,omitempty"`         // Commits of history fetched ('git clone --depth')
+	ShallowSince string   `json:"shallow_since,omitempty"` // Date history was fetched from ('git clone --shallow-since')
+	Filter       string   `json:"filter,omitempty"`        // Object filter of the partial clone ('git clone --filter')
+	Sparse       []string `json:"sparse,omitempty"`        // Directories checked out by the sparse checkout
+}
+
+// IsZero reports whether no options are recorded, i.e. the clone is complete.
+func (o *CloneOptions) IsZero() bool {
+	return o == nil || (o.Depth == 0 && o.ShallowSince == "" && o.Filter == "" && len(o.Sparse) == 0)
+}
+
+// CloneStats describes the clone of a repository, see 'fussy-git stats'.
+type CloneStats struct {
+	Duration float64 `json:"duration_seconds"`         // Wall time of 'git clone'
+	Objects  int     `json:"objects"`                  // Objects in the repository's object database after the clone
+	Size     int64   `json:"size_bytes"`               // Size of those objects on disk, about what was transferred
+	LFSSize  int64   `json:"lfs_size_bytes,omitempty"` // Size of the Git LFS content downloaded by the clone, not included in Size
+}
+
+// RemoteChanges describes a repository as its provider last reported it through webhooks, see
+// 'fussy-git serve --webhooks'.
+type RemoteChanges struct {
+	MovedTo       string    `json:"moved_to,omitempty"`       // URL of the repository after it was renamed or transferred
+	Archived      bool      `json:"archived,omitempty"`       // True if the repository was archived
+	DefaultBranch string    `json:"default_branch,omitempty"` // Default branch of the repository
+	ReceivedAt    time.Time `json:"received_at"`              // When the last webhook about the repository was received
+}
+
+// Verification is the result of checking a repository's object database with 'git fsck'.
+type Verification struct {
+	CheckedAt        time.Time `json:"checked_at"`
+	ConnectivityOnly bool      `json:"connectivity_only,omitempty"` // Only reachability was checked, not object contents
+	Problem          string    `json:"problem,omitempty"`           // What git fsck reported; empty if the repository is intact
 }
 
 // RepoState holds the collection of all tracked repositories.
+// Lookups go through indexes of the repositories' paths, original URLs and normalized paths.
+// Entries changed directly in Repositories must be followed by a call to Reindex if their
+// Path, OriginalURL or NormalizedFS changed.
 type RepoState struct {
 	Repositories []RepositoryEntry `json:"repositories"`
-	filePath     string
-	mu           sync.RWMutex // For thread-safe access to Repositories
+	// PreferredProtocols records, per domain, the protocol ("ssh" or "https") the last clone
+	// worked with when falling back between protocols is enabled.
+	PreferredProtocols map[string]string `json:"preferred_protocols,omitempty"`
+	// Groups are named collections of repositories operated on as a unit, by name. Membership
+	// is recorded on the repositories, so it follows them through moves and URL changes.
+	Groups map[string]Group `json:"groups,omitempty"`
+
+	filePath string
+	mu       sync.RWMutex      // For thread-safe access to Repositories
+	idx      *index            // Lookup indexes, rebuilt on load and kept up to date on mutation
+	shared   map[string][]byte // JSON of the entries loaded from the shared state, by path (see LoadShared)
+	readOnly []byte            // JSON of the state without timestamps when it was made read-only (see SetReadOnly)
+	// persisted is what the state file and its change log hold, to append only changes on save;
+	// nil for states that weren't loaded from their file, which replace it when saved.
+	persisted *persistedState
 }
 
 // NewRepoState creates an empty RepoState, primarily for initialization.
@@ -40,62 +123,96 @@ func NewRepoState(filePath string) *RepoState {
 	}
 }
 
-// LoadState loads the repository state from the given JSON file.
-// If the file doesn't exist, it returns an empty state without error.
+// LoadState loads the repository state from the given JSON file and its change log.
+// If neither exists, it returns an empty state without error.
 func LoadState(filePath string) (*RepoState, error) {
 	rs := NewRepoState(filePath)
 
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
 
-	// Check if the state file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+	// Ensure the directory exists for the lock file and future saves.
+	dir := filepath.Dir(filePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for state file %s: %w", dir, err)
+	}
+	unlock, err := lockStateFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock state file %s: %w", filePath, err)
+	}
+	defer unlock()
+
+	_, statErr := os.Stat(filePath)
+	_, logErr := os.Stat(LogPath(filePath))
+	if os.IsNotExist(statErr) && os.IsNotExist(logErr) {
 		// File doesn't exist, return empty state. This is not an error.
-		// Ensure the directory exists for future saves.
-		dir := filepath.Dir(filePath)
-		if dirErr := os.MkdirAll(dir, 0755); dirErr != nil {
-			return nil, fmt.Errorf("failed to create directory for state file %s: %w", dir, dirErr)
-		}
 		// Attempt to save an empty state file to ensure writability
-		if saveErr := rs.saveLocked(); saveErr != nil {
+		if saveErr := rs.writeFileLocked(filePath, rs); saveErr != nil {
 			return nil, fmt.Errorf("failed to create initial empty state file at %s: %w", filePath, saveErr)
 		}
 		return rs, nil
-	} else if err != nil {
+	} else if statErr != nil && !os.IsNotExist(statErr) {
 		// Some other error occurred when stating the file
-		return nil, fmt.Errorf("error checking state file %s: %w", filePath, err)
+		return nil, fmt.Errorf("error checking state file %s: %w", filePath, statErr)
 	}
 
-	// File exists, try to read and unmarshal it
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open state file %s: %w", filePath, err)
+	if err := rs.readLocked(filePath); err != nil {
+		return nil, err
 	}
-	defer file.Close()
+	rs.reindexLocked()
+	if rs.persisted, err = snapshot(rs); err != nil {
+		return nil, err
+	}
+	rs.persisted.mark(filePath)
+	return rs, nil
+}
 
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read state file %s: %w", filePath, err)
+// ReadState reads the repository state from the given JSON file and its change log without
+// changing anything on disk: unlike LoadState, it creates neither the file, its directory nor
+// its lock, and doesn't wait for other fussy-git processes to release the lock. It is for
+// readers that must not have side effects, such as embedding tools; the state it returns is a
+// snapshot that may miss a save in progress and is not meant to be saved. If neither the file
+// nor its change log exists, it returns an empty state without error.
+func ReadState(filePath string) (*RepoState, error) {
+	rs := NewRepoState(filePath)
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if err := rs.readLocked(filePath); err != nil {
+		return nil, err
 	}
+	rs.reindexLocked()
+	return rs, nil
+}
 
-	// If the file is empty, don't try to unmarshal
-	if len(data) == 0 {
-		return rs, nil // Return empty state
+// readLocked reads the state file at filePath, if it exists, and replays its change log into
+// rs. The caller must hold rs.mu and the lock of the state file.
+func (rs *RepoState) readLocked(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read state file %s: %w", filePath, err)
 	}
 
-	if err := json.Unmarshal(data, &rs); err != nil {
-		// Check for specific unmarshal errors, e.g. if the file is not JSON
-		// but contains some other data.
-		if _, ok := err.(*json.SyntaxError); ok {
-			return nil, fmt.Errorf("state file %s contains invalid JSON: %w. Consider backing it up and deleting it to start fresh", filePath, err)
+	// If the file is empty, don't try to unmarshal
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &rs); err != nil {
+			// Check for specific unmarshal errors, e.g. if the file is not JSON
+			// but contains some other data.
+			if _, ok := err.(*json.SyntaxError); ok {
+				return fmt.Errorf("state file %s contains invalid JSON: %w. Consider backing it up and deleting it to start fresh", filePath, err)
+			}
+			return fmt.Errorf("failed to unmarshal state file %s: %w", filePath, err)
 		}
-		return nil, fmt.Errorf("failed to unmarshal state file %s: %w", filePath, err)
 	}
-
-	return rs, nil
+	if rs.Repositories == nil {
+		rs.Repositories = []RepositoryEntry{}
+	}
+	return rs.replayLogLocked(filePath)
 }
 
-// Save writes the current repository state to the JSON file.
+// Save writes the changes to the repository state since it was loaded or last saved to its
+// change log, see changelog.go. States that weren't loaded from their file, and saves to
+// another file, write the whole state file instead.
 func (rs *RepoState) Save(customFilePath ...string) error {
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
@@ -112,6 +229,9 @@ func (rs *RepoState) saveLocked(customFilePath ...string) error {
 	if filePathToUse == "" {
 		return fmt.Errorf("cannot save state: file path is not set")
 	}
+	if err := rs.checkReadOnlyLocked(); err != nil {
+		return fmt.Errorf("cannot save state: %w", err)
+	}
 
 	// Ensure the directory for the state file exists
 	dir := filepath.Dir(filePathToUse)
@@ -119,29 +239,96 @@ func (rs *RepoState) saveLocked(customFilePath ...string) error {
 		return fmt.Errorf("failed to create directory for state file %s: %w", dir, err)
 	}
 
-	data, err := json.MarshalIndent(rs, "", "  ") // Pretty print JSON
+	// Unchanged entries of the shared state stay in the shared state file.
+	own := &RepoState{Repositories: rs.ownRepositoriesLocked(), PreferredProtocols: rs.PreferredProtocols, Groups: rs.Groups}
+
+	unlock, err := lockStateFile(filePathToUse)
 	if err != nil {
-		return fmt.Errorf("failed to marshal state to JSON: %w", err)
+		return fmt.Errorf("failed to lock state file %s: %w", filePathToUse, err)
+	}
+	defer unlock()
+	if rs.persisted != nil && filePathToUse == rs.filePath {
+		return rs.appendChangesLocked(filePathToUse, own)
 	}
+	// The log is folded in first, so a crash while replacing the file can't leave a log behind
+	// that would be replayed on top of the new state.
+	if err := compactLocked(filePathToUse); err != nil {
+		return err
+	}
+	return rs.writeFileLocked(filePathToUse, own)
+}
 
-	// Write to a temporary file first, then rename. This makes the save atomic.
-	tempFilePath := filePathToUse + ".tmp"
-	err = os.WriteFile(tempFilePath, data, 0644) // 0644 for file permissions
+// writeFileLocked replaces the state file at filePath with own and removes its change log, which
+// must be applied to own already. The caller must hold rs.mu and the lock of the state file.
+func (rs *RepoState) writeFileLocked(filePath string, own *RepoState) error {
+	data, err := json.MarshalIndent(own, "", "  ") // Pretty print JSON
 	if err != nil {
+		return fmt.Errorf("failed to marshal state to JSON: %w", err)
+	}
+
+	// Write to a temporary file first, then rename. This makes the save atomic. The data is
+	// flushed to disk before the rename, so a crash can't leave an empty state file behind.
+	tempFilePath := filePath + ".tmp"
+	if err := writeSynced(tempFilePath, data, 0644); err != nil { // 0644 for file permissions
+		_ = os.Remove(tempFilePath)
 		return fmt.Errorf("failed to write state to temporary file %s: %w", tempFilePath, err)
 	}
 
 	// Rename temporary file to actual state file
-	err = os.Rename(tempFilePath, filePathToUse)
+	err = os.Rename(tempFilePath, filePath)
 	if err != nil {
 		// Attempt to clean up temp file if rename fails
 		_ = os.Remove(tempFilePath)
-		return fmt.Errorf("failed to rename temporary state file %s to %s: %w", tempFilePath, filePathToUse, err)
+		return fmt.Errorf("failed to rename temporary state file %s to %s: %w", tempFilePath, filePath, err)
+	}
+	// When compacting, replaying the log again after a crash before it is removed does no
+	// harm, since the state file already holds its changes.
+	if err := os.Remove(LogPath(filePath)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove change log %s: %w", LogPath(filePath), err)
 	}
 
+	if filePath == rs.filePath {
+		if rs.persisted, err = snapshot(own); err != nil {
+			return err
+		}
+		rs.persisted.mark(filePath)
+	}
 	return nil
 }
 
+// writeSynced writes data to the file at path and flushes it to disk.
+func writeSynced(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// PreferredProtocol returns the protocol recorded as working for domain, or "" if none is.
+func (rs *RepoState) PreferredProtocol(domain string) string {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.PreferredProtocols[domain]
+}
+
+// SetPreferredProtocol records that cloning from domain works with protocol.
+func (rs *RepoState) SetPreferredProtocol(domain, protocol string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.PreferredProtocols == nil {
+		rs.PreferredProtocols = map[string]string{}
+	}
+	rs.PreferredProtocols[domain] = protocol
+}
+
 // AddRepository adds a new repository to the state or updates an existing one.
 // It checks for duplicates based on the repository path.
 func (rs *RepoState) AddRepository(entry RepositoryEntry) error {
@@ -175,6 +362,9 @@ func (rs *RepoState) AddRepository(entry RepositoryEntry) error {
 				entry.ClonedAt = r.ClonedAt
 			}
 			rs.Repositories[i] = entry
+			if entry.OriginalURL != r.OriginalURL || entry.NormalizedFS != r.NormalizedFS {
+				rs.reindexLocked()
+			}
 			return nil
 		}
 		// Also check for duplicate by original URL to prevent adding the same repo twice
@@ -187,33 +377,42 @@ func (rs *RepoState) AddRepository(entry RepositoryEntry) error {
 
 	// If not found, add as a new entry
 	rs.Repositories = append(rs.Repositories, entry)
+	if rs.idx != nil && rs.idx.count == len(rs.Repositories)-1 {
+		rs.idx.add(len(rs.Repositories)-1, entry)
+	} else {
+		rs.reindexLocked()
+	}
 	return nil
 }
 
 // FindRepositoryByPath searches for a repository by its full local path.
 func (rs *RepoState) FindRepositoryByPath(path string) (*RepositoryEntry, bool) {
+	i := rs.IndexOfPath(path)
+	if i < 0 {
+		return nil, false
+	}
 	rs.mu.RLock()
 	defer rs.mu.RUnlock()
-
-	for _, r := range rs.Repositories {
-		if r.Path == path {
-			return &r, true
-		}
-	}
-	return nil, false
+	r := rs.Repositories[i]
+	return &r, true
 }
 
 // FindRepositoryByOriginalURL searches for a repository by its original clone URL.
 func (rs *RepoState) FindRepositoryByOriginalURL(originalURL string) (*RepositoryEntry, bool) {
-	rs.mu.RLock()
-	defer rs.mu.RUnlock()
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
 
-	for _, r := range rs.Repositories {
-		if r.OriginalURL == originalURL {
-			return &r, true
+	i := rs.lookupLocked(func(idx *index) int {
+		if i, ok := idx.byOriginalURL[originalURL]; ok {
+			return i
 		}
+		return -1
+	}, func(repo RepositoryEntry) bool { return repo.OriginalURL == originalURL })
+	if i < 0 {
+		return nil, false
 	}
-	return nil, false
+	r := rs.Repositories[i]
+	return &r, true
 }
 
 // RemoveRepositoryByPath removes a repository from the state by its path.
@@ -224,6 +423,7 @@ func (rs *RepoState) RemoveRepositoryByPath(path string) bool {
 	for i, r := range rs.Repositories {
 		if r.Path == path {
 			rs.Repositories = append(rs.Repositories[:i], rs.Repositories[i+1:]...)
+			rs.reindexLocked()
 			return true
 		}
 	}
@@ -252,6 +452,9 @@ func (rs *RepoState) UpdateRepository(updatedEntry RepositoryEntry) error {
 			}
 			updatedEntry.LastModified = time.Now()
 			rs.Repositories[i] = updatedEntry
+			if updatedEntry.OriginalURL != r.OriginalURL || updatedEntry.NormalizedFS != r.NormalizedFS {
+				rs.reindexLocked()
+			}
 			found = true
 			break
 		}
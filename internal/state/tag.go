@@ -0,0 +1,28 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateTag checks that tag can be used as a tag: it must not be empty or contain ',' or
+// whitespace, so that it can be given in comma-separated --tag values.
+func ValidateTag(tag string) error {
+	if tag == "" {
+		return fmt.Errorf("tag is empty")
+	}
+	if strings.ContainsAny(tag, ", \t\n") {
+		return fmt.Errorf("tag '%s' must not contain ',' or whitespace", tag)
+	}
+	return nil
+}
+
+// HasTag reports whether the repository carries the tag, ignoring case like the --tag filter.
+func (e RepositoryEntry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
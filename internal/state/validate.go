@@ -0,0 +1,109 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Parse decodes the contents of a state file strictly: unknown fields (usually typos) and
+// anything after the JSON document are errors. The result is saved to filePath by Save.
+func Parse(data []byte, filePath string) (*RepoState, error) {
+	rs := NewRepoState(filePath)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(rs); err != nil {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			line := bytes.Count(data[:syntaxErr.Offset], []byte("\n")) + 1
+			return nil, fmt.Errorf("invalid JSON on line %d: %w", line, err)
+		}
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid state: unexpected data after the JSON document")
+	}
+	if rs.Repositories == nil {
+		rs.Repositories = []RepositoryEntry{}
+	}
+	rs.reindexLocked()
+	return rs, nil
+}
+
+// Validate checks the state for entries fussy-git can't work with: missing required fields,
+// relative or duplicate paths, and URLs checkURL rejects. It returns all problems found.
+func (rs *RepoState) Validate(checkURL func(url string) error) []error {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	var problems []error
+	seen := map[string]int{}
+	for i, repo := range rs.Repositories {
+		label := fmt.Sprintf("repositories[%d]", i)
+		if repo.Name != "" {
+			label += " (" + repo.Name + ")"
+		}
+		report := func(format string, a ...any) {
+			problems = append(problems, fmt.Errorf("%s: %s", label, fmt.Sprintf(format, a...)))
+		}
+
+		if repo.Name == "" {
+			report("name is empty")
+		}
+		switch {
+		case repo.Path == "":
+			report("path is empty")
+		case !filepath.IsAbs(repo.Path):
+			report("path '%s' is not absolute", repo.Path)
+		default:
+			clean := filepath.Clean(repo.Path)
+			if first, dup := seen[clean]; dup {
+				report("path %s is also used by repositories[%d]", repo.Path, first)
+			} else {
+				seen[clean] = i
+			}
+		}
+		for key := range repo.Metadata {
+			if err := ValidateMetadataKey(key); err != nil {
+				report("metadata: %v", err)
+			}
+		}
+		for _, group := range repo.Groups {
+			if _, ok := rs.Groups[group]; !ok {
+				report("group '%s' is not defined in groups", group)
+			}
+		}
+		for _, u := range []struct{ field, url string }{{"original_url", repo.OriginalURL}, {"current_url", repo.CurrentURL}} {
+			if u.url == "" {
+				report("%s is empty", u.field)
+			} else if err := checkURL(u.url); err != nil {
+				report("%s '%s' can't be parsed: %v", u.field, u.url, err)
+			}
+		}
+	}
+	for name := range rs.Groups {
+		if err := ValidateGroupName(name); err != nil {
+			problems = append(problems, fmt.Errorf("groups: %v", err))
+		}
+	}
+	for domain, protocol := range rs.PreferredProtocols {
+		if protocol != "ssh" && protocol != "https" {
+			problems = append(problems, fmt.Errorf("preferred_protocols: protocol of %s must be ssh or https, got '%s'", domain, protocol))
+		}
+	}
+	return problems
+}
+
+// ValidateMetadataKey checks that key can be used as a metadata key: it must not be empty
+// or contain '=' or whitespace, so that key=value filters stay unambiguous.
+func ValidateMetadataKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("key is empty")
+	}
+	if strings.ContainsAny(key, "= \t\n") {
+		return fmt.Errorf("key '%s' must not contain '=' or whitespace", key)
+	}
+	return nil
+}
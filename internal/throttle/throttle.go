@@ -0,0 +1,94 @@
+package throttle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limiter bounds how many network operations run at once and, optionally, their average
+// bandwidth. Git has no option to cap its transfer rate, so the bandwidth cap is enforced
+// by scheduling: after an operation has transferred n bytes, the next operation may only
+// start once n bytes' worth of time at the capped rate has passed. Individual transfers
+// still run at full speed, but over a batch of operations the average rate stays below the cap.
+type Limiter struct {
+	slots       chan struct{}
+	bytesPerSec int64
+
+	mu   sync.Mutex
+	next time.Time // Operations may not start before this time
+}
+
+// New returns a Limiter allowing maxConcurrent operations at once (at least 1) with an
+// average rate of at most bytesPerSec (0 means unlimited).
+func New(maxConcurrent int, bytesPerSec int64) *Limiter {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	return &Limiter{slots: make(chan struct{}, maxConcurrent), bytesPerSec: bytesPerSec}
+}
+
+// Limited reports whether the limiter enforces a bandwidth cap. Callers can skip measuring
+// transferred bytes otherwise.
+func (l *Limiter) Limited() bool {
+	return l.bytesPerSec > 0
+}
+
+// Acquire blocks until an operation may start. Every Acquire must be followed by a Release.
+func (l *Limiter) Acquire() {
+	l.slots <- struct{}{}
+	if !l.Limited() {
+		return
+	}
+	l.mu.Lock()
+	wait := time.Until(l.next)
+	l.mu.Unlock()
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// Release ends an operation that transferred the given number of bytes.
+func (l *Limiter) Release(transferred int64) {
+	if l.Limited() && transferred > 0 {
+		l.mu.Lock()
+		if now := time.Now(); l.next.Before(now) {
+			l.next = now
+		}
+		l.next = l.next.Add(time.Duration(float64(transferred) / float64(l.bytesPerSec) * float64(time.Second)))
+		l.mu.Unlock()
+	}
+	<-l.slots
+}
+
+// ParseRate parses a transfer rate such as "500K", "2MB/s" or "1.5 MiB/s" into bytes per second.
+// Units are powers of 1024 (K, KB and KiB all mean 1024 bytes). "", "0" and "unlimited" mean no limit.
+func ParseRate(s string) (int64, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	s = strings.TrimSuffix(s, "/s")
+	if s == "" || s == "0" || s == "unlimited" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	unit := strings.TrimLeft(s, "0123456789. ")
+	number := strings.TrimSpace(strings.TrimSuffix(s, unit))
+	switch strings.TrimSuffix(strings.TrimSuffix(unit, "b"), "i") {
+	case "":
+	case "k":
+		multiplier = 1 << 10
+	case "m":
+		multiplier = 1 << 20
+	case "g":
+		multiplier = 1 << 30
+	default:
+		return 0, fmt.Errorf("invalid rate '%s': unknown unit '%s' (use e.g. 500K, 2MB/s)", s, unit)
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid rate '%s': use e.g. 500K, 2MB/s", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
@@ -0,0 +1,127 @@
+// Package trend records snapshots of summary metrics of the tracked repositories over time,
+// so 'fussy-git stats --history' can show how they develop.
+package trend
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileName is the name of the history file, which lives next to the state file.
+const FileName = "stats-history.jsonl"
+
+// Snapshot holds the summary metrics of the tracked repositories at one point in time. The
+// history is a file of JSON objects, one per line, so it can be inspected with tools like jq.
+type Snapshot struct {
+	Time         time.Time `json:"time"`
+	Repositories int       `json:"repositories"`
+	Size         int64     `json:"size"`   // Bytes on disk of all work trees, including their git directories
+	Dirty        int       `json:"dirty"`  // Repositories with uncommitted changes
+	Behind       int       `json:"behind"` // Repositories behind their upstream branch
+}
+
+// Append writes s to the history at path, creating the file if needed.
+// If s.Time is zero, the current time is used.
+func Append(path string, s Snapshot) error {
+	if s.Time.IsZero() {
+		s.Time = time.Now()
+	}
+	line, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal stats snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory for stats history %s: %w", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open stats history %s: %w", path, err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write stats history %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// Load reads the history at path in the order it was recorded. A missing file is an empty
+// history. Lines that can't be parsed, e.g. one cut short by a crash, are skipped.
+func Load(path string) ([]Snapshot, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stats history %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var history []Snapshot
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil || s.Time.IsZero() {
+			continue
+		}
+		history = append(history, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stats history %s: %w", path, err)
+	}
+	return history, nil
+}
+
+// Sample returns at most n snapshots of history spread evenly over the time it covers: the
+// last snapshot of each of n equal intervals that has one. history must be in time order.
+func Sample(history []Snapshot, n int) []Snapshot {
+	if len(history) <= n || n < 1 {
+		return history
+	}
+	first, last := history[0].Time, history[len(history)-1].Time
+	span := last.Sub(first)
+	sampled := make([]Snapshot, 0, n)
+	bucket := -1
+	for _, s := range history {
+		b := n - 1
+		if span > 0 {
+			b = min(int(float64(s.Time.Sub(first))/float64(span)*float64(n)), n-1)
+		}
+		if b != bucket {
+			sampled = append(sampled, s)
+			bucket = b
+		} else {
+			sampled[len(sampled)-1] = s
+		}
+	}
+	return sampled
+}
+
+// sparkBlocks are the characters of a sparkline, from the lowest to the highest value.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values as a line of block characters whose heights follow the values,
+// scaled between their minimum and maximum. Constant values are drawn at the lowest height.
+func Sparkline(values []int64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if hi > lo {
+			level = int((v - lo) * int64(len(sparkBlocks)-1) / (hi - lo))
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
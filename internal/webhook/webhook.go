@@ -0,0 +1,217 @@
+// Package webhook parses the webhooks GitHub and GitLab send when a repository is renamed,
+// transferred, archived or has its default branch changed.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Providers webhooks are accepted from.
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// Kinds of events.
+const (
+	KindRenamed       = "renamed"        // The repository's name changed
+	KindTransferred   = "transferred"    // The repository moved to another owner or group
+	KindArchived      = "archived"       // The repository was archived
+	KindUnarchived    = "unarchived"     // The repository was unarchived
+	KindDefaultBranch = "default-branch" // The repository's default branch is reported, possibly changed
+)
+
+// ErrUnauthorized is returned by Parse for requests that aren't signed with the secret.
+var ErrUnauthorized = errors.New("invalid or missing webhook signature")
+
+// Event is a change to a repository reported by a webhook.
+type Event struct {
+	Provider      string // ProviderGitHub or ProviderGitLab
+	Kind          string // One of the Kind* constants
+	Domain        string // Host of the provider, e.g. "github.com"
+	OldPath       string // Path of the repository before the event, e.g. "owner/repo"
+	Path          string // Path of the repository after the event; equal to OldPath unless renamed or transferred
+	DefaultBranch string // Default branch of the repository, if the event reports it
+}
+
+// Parse checks that the webhook request r with the given body is signed with secret and
+// returns the events it reports. A request for an event that isn't about any of the changes
+// above (including GitHub's ping) returns no events and no error.
+func Parse(r *http.Request, body []byte, secret string) ([]Event, error) {
+	switch {
+	case r.Header.Get("X-GitHub-Event") != "":
+		if !validGitHubSignature(r.Header.Get("X-Hub-Signature-256"), body, secret) {
+			return nil, ErrUnauthorized
+		}
+		return parseGitHub(r.Header.Get("X-GitHub-Event"), body)
+	case r.Header.Get("X-Gitlab-Event") != "":
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Gitlab-Token")), []byte(secret)) != 1 {
+			return nil, ErrUnauthorized
+		}
+		return parseGitLab(r.Header.Get("X-Gitlab-Instance"), body)
+	}
+	return nil, fmt.Errorf("not a GitHub or GitLab webhook: the X-GitHub-Event or X-Gitlab-Event header is missing")
+}
+
+// validGitHubSignature reports whether signature ("sha256=<hex>") is the HMAC of body with secret.
+func validGitHubSignature(signature string, body []byte, secret string) bool {
+	sum, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sum)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// gitHubPayload holds the fields of GitHub's repository event used here.
+type gitHubPayload struct {
+	Action     string `json:"action"`
+	Repository struct {
+		Name          string `json:"name"`
+		FullName      string `json:"full_name"`
+		HTMLURL       string `json:"html_url"`
+		DefaultBranch string `json:"default_branch"`
+	} `json:"repository"`
+	Changes struct {
+		Repository struct {
+			Name struct {
+				From string `json:"from"`
+			} `json:"name"`
+		} `json:"repository"`
+		Owner struct {
+			From struct {
+				User struct {
+					Login string `json:"login"`
+				} `json:"user"`
+				Organization struct {
+					Login string `json:"login"`
+				} `json:"organization"`
+			} `json:"from"`
+		} `json:"owner"`
+		DefaultBranch *struct {
+			From string `json:"from"`
+		} `json:"default_branch"`
+	} `json:"changes"`
+}
+
+// parseGitHub parses the payload of a GitHub webhook for the given event.
+func parseGitHub(eventName string, body []byte) ([]Event, error) {
+	if eventName != "repository" {
+		return nil, nil
+	}
+	var p gitHubPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("invalid GitHub payload: %w", err)
+	}
+	domain, err := hostOf(p.Repository.HTMLURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub payload: repository.html_url: %w", err)
+	}
+	path := p.Repository.FullName
+	owner, _, _ := strings.Cut(path, "/")
+	event := Event{Provider: ProviderGitHub, Domain: domain, OldPath: path, Path: path}
+
+	switch p.Action {
+	case "renamed":
+		if p.Changes.Repository.Name.From == "" {
+			return nil, fmt.Errorf("invalid GitHub payload: renamed without changes.repository.name.from")
+		}
+		event.Kind = KindRenamed
+		event.OldPath = owner + "/" + p.Changes.Repository.Name.From
+	case "transferred":
+		from := p.Changes.Owner.From.Organization.Login
+		if from == "" {
+			from = p.Changes.Owner.From.User.Login
+		}
+		if from == "" {
+			return nil, fmt.Errorf("invalid GitHub payload: transferred without changes.owner.from")
+		}
+		event.Kind = KindTransferred
+		event.OldPath = from + "/" + p.Repository.Name
+	case "archived":
+		event.Kind = KindArchived
+	case "unarchived":
+		event.Kind = KindUnarchived
+	case "edited":
+		if p.Changes.DefaultBranch == nil {
+			return nil, nil
+		}
+		event.Kind = KindDefaultBranch
+		event.DefaultBranch = p.Repository.DefaultBranch
+	default:
+		return nil, nil
+	}
+	return []Event{event}, nil
+}
+
+// gitLabPayload holds the fields of GitLab's system hooks for projects and of the project
+// object included in project webhooks.
+type gitLabPayload struct {
+	EventName            string `json:"event_name"`
+	PathWithNamespace    string `json:"path_with_namespace"`
+	OldPathWithNamespace string `json:"old_path_with_namespace"`
+	Project              *struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+		WebURL            string `json:"web_url"`
+		DefaultBranch     string `json:"default_branch"`
+	} `json:"project"`
+}
+
+// parseGitLab parses the payload of a GitLab webhook sent by the instance at instanceURL (the
+// X-Gitlab-Instance header). System hooks report renames and transfers; every project webhook
+// (push, merge request, ...) reports the project's default branch.
+func parseGitLab(instanceURL string, body []byte) ([]Event, error) {
+	var p gitLabPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("invalid GitLab payload: %w", err)
+	}
+
+	switch p.EventName {
+	case "project_rename", "project_transfer":
+		domain, err := hostOf(instanceURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GitLab system hook: X-Gitlab-Instance header: %w", err)
+		}
+		if p.OldPathWithNamespace == "" || p.PathWithNamespace == "" {
+			return nil, fmt.Errorf("invalid GitLab system hook: %s without path_with_namespace and old_path_with_namespace", p.EventName)
+		}
+		kind := KindRenamed
+		if p.EventName == "project_transfer" {
+			kind = KindTransferred
+		}
+		return []Event{{Provider: ProviderGitLab, Kind: kind, Domain: domain, OldPath: p.OldPathWithNamespace, Path: p.PathWithNamespace}}, nil
+	}
+
+	if p.Project == nil || p.Project.DefaultBranch == "" {
+		return nil, nil
+	}
+	domain, err := hostOf(p.Project.WebURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitLab payload: project.web_url: %w", err)
+	}
+	path := p.Project.PathWithNamespace
+	return []Event{{Provider: ProviderGitLab, Kind: KindDefaultBranch, Domain: domain, OldPath: path, Path: path, DefaultBranch: p.Project.DefaultBranch}}, nil
+}
+
+// hostOf returns the host of an http(s) URL.
+func hostOf(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("'%s' is not a URL", rawURL)
+	}
+	return strings.ToLower(u.Host), nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"github.com/jmsnll/fussy-git/cmd" // Assuming cmd is your package for cobra commands
 	"os"
 )
@@ -18,8 +19,9 @@ func main() {
 	// Pass the version information to the command execution logic.
 	// The cmd.Execute function in cmd/root.go will use these to set rootCmd.Version.
 	if err := cmd.Execute(version, commit, date, builtBy); err != nil {
-		// Cobra's Execute() often prints errors to stderr itself.
-		// Exiting with 1 indicates failure.
-		os.Exit(1)
+		// The root command silences Cobra's error output, so errors are printed here.
+		// The exit code tells scripts what went wrong (see 'fussy-git help').
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -0,0 +1,264 @@
+// Package fussy is the Go API of fussy-git, for tools that embed its inventory of repositories,
+// such as editor plugins and launchers. It reads the same config file and state file as the
+// fussy-git command, and computes locations and normalizes URLs exactly like it does, without
+// depending on the command line.
+//
+// A typical use opens the inventory and looks up repositories:
+//
+//	inv, err := fussy.Open(fussy.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	for _, repo := range inv.Repositories() {
+//		fmt.Println(repo.NormalizedFS, repo.Path)
+//	}
+//	path, err := inv.PathFor("git@github.com:spf13/cobra.git")
+//
+// The exported names of this package are kept stable across releases of fussy-git; everything
+// below internal/ may change at any time.
+package fussy
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/config"
+	"github.com/jmsnll/fussy-git/internal/filter"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+	"github.com/jmsnll/fussy-git/internal/repourl"
+	"github.com/jmsnll/fussy-git/internal/state"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Repository is a repository tracked by fussy-git, as recorded in the state file.
+type Repository struct {
+	Name         string            // Short name, e.g. "cobra"
+	Path         string            // Location of the work tree
+	OriginalURL  string            // URL the repository was cloned or added with
+	CurrentURL   string            // URL of its origin remote as last checked
+	Domain       string            // Host of its URL, e.g. "github.com"
+	NormalizedFS string            // Normalized path its location is derived from, e.g. "github.com/spf13/cobra"
+	PathOverride string            // Location that replaces the conventional one, if set
+	Description  string            // First paragraph or heading of its README
+	Notes        string            // Notes recorded with 'fussy-git notes'
+	Tags         []string          // Tags, see 'fussy-git tag'
+	Groups       []string          // Groups the repository is a member of
+	Metadata     map[string]string // Key/value data attached by users and tools
+	Pinned       bool              // Never moved by 'fussy-git reorganize'
+	Locked       bool              // Destructive operations require --force
+	ClonedAt     time.Time         // When the repository was cloned or added
+	LastFetched  time.Time         // Last successful 'fussy-git fetch', zero if never
+}
+
+// URL is a parsed repository URL.
+type URL struct {
+	Original       string // The URL as given
+	Scheme         string // e.g. "https", "ssh" or "file"
+	User           string // User of the URL, e.g. "git" for SSH URLs
+	Domain         string // Host the repository is on, with SSH host aliases resolved by Inventory.ParseURL
+	Path           string // Path of the repository on the host, e.g. "spf13/cobra.git"
+	Name           string // Name of the repository, e.g. "cobra"
+	SSH            bool   // Whether the URL is an SSH URL
+	Local          bool   // Whether the URL is a local path or file:// URL
+	NormalizedPath string // Path that identifies the repository regardless of protocol, e.g. "github.com/spf13/cobra"
+}
+
+// Query selects repositories, see Inventory.Select. Values given for the same criterion are
+// OR-ed together, different criteria are AND-ed, and the zero Query selects every repository.
+type Query struct {
+	Domains []string // Hosted on any of these domains
+	Owners  []string // Owned by any of these users or organizations
+	Tags    []string // Carrying any of these tags
+	Groups  []string // Members of any of these groups
+	Terms   []string // Every term occurs in the name, paths, URLs, description, tags or notes, like 'fussy-git search'
+}
+
+// ErrNotFound is returned when no tracked repository matches a reference.
+var ErrNotFound = errors.New("no tracked repository matches")
+
+// Options select the configuration to open. The zero value uses the same configuration as the
+// fussy-git command: ~/.fussy-git/config.yaml, overridden by FUSSY_GIT_HOME.
+type Options struct {
+	ConfigFile string // Config file to read instead of the default one (--config)
+	Home       string // FUSSY_GIT_HOME to use instead of the configured one (--home)
+	StateFile  string // State file to use instead of the configured one (--state-file)
+}
+
+// Inventory is the set of repositories tracked by fussy-git, together with the configuration
+// that determines their locations. It is a snapshot: call Reload to see later changes.
+type Inventory struct {
+	cfg      *config.Config
+	state    *state.RepoState
+	resolver *repourl.Resolver
+}
+
+// Open loads the configuration and the state file selected by opts. It never writes anything:
+// a missing state file is an empty inventory.
+func Open(opts Options) (*Inventory, error) {
+	cfg, err := config.LoadConfig(opts.ConfigFile, config.Overrides{FussyGitHome: opts.Home, StateFilePath: opts.StateFile, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	resolver := repourl.New(cfg)
+	if _, err := resolver.LoadRewrites(); err != nil {
+		return nil, err
+	}
+	inv := &Inventory{cfg: cfg, resolver: resolver}
+	if err := inv.Reload(); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// Reload reads the state file again, e.g. after the fussy-git command changed it. Like Open, it
+// never writes anything, and doesn't wait for a fussy-git command that is saving the state.
+func (inv *Inventory) Reload() error {
+	rs, err := state.ReadState(inv.cfg.StateFilePath)
+	if err != nil {
+		return err
+	}
+	if inv.cfg.SharedStateFile != "" {
+		if err := rs.LoadShared(inv.cfg.SharedStateFile); err != nil {
+			return err
+		}
+	}
+	inv.state = rs
+	return nil
+}
+
+// Home returns FUSSY_GIT_HOME, the directory repositories are cloned into.
+func (inv *Inventory) Home() string {
+	return inv.cfg.FussyGitHome
+}
+
+// StateFile returns the location of the state file the inventory was read from.
+func (inv *Inventory) StateFile() string {
+	return inv.cfg.StateFilePath
+}
+
+// Repositories returns the tracked repositories in the order of the state file. The entries
+// are copies; changing them doesn't change the inventory.
+func (inv *Inventory) Repositories() []Repository {
+	return repositories(inv.state.Repositories)
+}
+
+// Select returns the tracked repositories q selects, in the order of the state file, like the
+// filter flags of the fussy-git command.
+func (inv *Inventory) Select(q Query) []Repository {
+	f := filter.Filter{Domains: q.Domains, Owners: q.Owners, Tags: q.Tags, Groups: q.Groups, Terms: q.Terms}
+	return repositories(f.Apply(inv.state.Repositories))
+}
+
+// Find returns the tracked repository ref refers to, resolving it like the fussy-git command
+// resolves repository arguments: ref can be the repository's path (or a path inside it), its
+// normalized path (e.g. "github.com/spf13/cobra"), its URL in any protocol, or its name if
+// that is unambiguous. It returns an error wrapping ErrNotFound if none matches.
+func (inv *Inventory) Find(ref string) (Repository, error) {
+	if absPath, err := filepath.Abs(ref); err == nil {
+		if i := inv.state.IndexOfPath(absPath); i >= 0 {
+			return repository(inv.state.Repositories[i]), nil
+		}
+		if i := inv.state.IndexContaining(absPath); i >= 0 {
+			return repository(inv.state.Repositories[i]), nil
+		}
+	}
+	if i := inv.state.IndexOfNormalizedPath(strings.TrimSuffix(ref, "/")); i >= 0 {
+		return repository(inv.state.Repositories[i]), nil
+	}
+	for _, repo := range inv.state.Repositories {
+		if repo.CurrentURL == ref || repo.OriginalURL == ref {
+			return repository(repo), nil
+		}
+	}
+	if parsed, err := inv.resolver.Parse(inv.resolver.ExpandShortcut(ref)); err == nil && !parsed.IsLocal() {
+		if i := inv.state.IndexOfNormalizedPath(filepath.ToSlash(parsed.GetNormalizedFSPath())); i >= 0 {
+			return repository(inv.state.Repositories[i]), nil
+		}
+	}
+
+	var matches []Repository
+	for _, repo := range inv.state.Repositories {
+		if repo.Name == ref {
+			matches = append(matches, repository(repo))
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Repository{}, fmt.Errorf("%w '%s'", ErrNotFound, ref)
+	case 1:
+		return matches[0], nil
+	}
+	return Repository{}, fmt.Errorf("'%s' matches %d repositories; use a path or URL instead", ref, len(matches))
+}
+
+// ParseURL parses a repository URL with the user's normalizations applied, like the fussy-git
+// command: host shortcuts (e.g. "gh:spf13/cobra") unless git's url.<base>.insteadOf rules
+// already rewrite them, those rewrites, and SSH host aliases.
+func (inv *Inventory) ParseURL(rawURL string) (*URL, error) {
+	parsed, err := inv.resolver.Parse(inv.resolver.ExpandShortcut(rawURL))
+	if err != nil {
+		return nil, err
+	}
+	return newURL(parsed), nil
+}
+
+// PathFor returns the conventional location of the repository at rawURL below FUSSY_GIT_HOME,
+// following the configured layout, which may differ per domain, owner or repository. It is
+// where 'fussy-git clone' puts the repository; tracked repositories can be elsewhere (see
+// Repository.Path and Repository.PathOverride).
+func (inv *Inventory) PathFor(rawURL string) (string, error) {
+	parsed, err := inv.resolver.Parse(inv.resolver.ExpandShortcut(rawURL))
+	if err != nil {
+		return "", err
+	}
+	return inv.resolver.ConventionalPath(parsed), nil
+}
+
+// repositories converts state entries to Repositories.
+func repositories(entries []state.RepositoryEntry) []Repository {
+	repos := make([]Repository, len(entries))
+	for i, entry := range entries {
+		repos[i] = repository(entry)
+	}
+	return repos
+}
+
+// repository converts a state entry to a Repository, copying what the entry shares.
+func repository(entry state.RepositoryEntry) Repository {
+	return Repository{
+		Name:         entry.Name,
+		Path:         entry.Path,
+		OriginalURL:  entry.OriginalURL,
+		CurrentURL:   entry.CurrentURL,
+		Domain:       entry.Domain,
+		NormalizedFS: entry.NormalizedFS,
+		PathOverride: entry.PathOverride,
+		Description:  entry.Description,
+		Notes:        entry.Notes,
+		Tags:         slices.Clone(entry.Tags),
+		Groups:       slices.Clone(entry.Groups),
+		Metadata:     maps.Clone(entry.Metadata),
+		Pinned:       entry.Pinned,
+		Locked:       entry.Locked,
+		ClonedAt:     entry.ClonedAt,
+		LastFetched:  entry.LastFetched,
+	}
+}
+
+// newURL converts a parsed URL to a URL.
+func newURL(parsed *gitutil.ParsedGitURL) *URL {
+	return &URL{
+		Original:       parsed.OriginalURL,
+		Scheme:         parsed.Scheme,
+		User:           parsed.User,
+		Domain:         parsed.Domain,
+		Path:           parsed.Path,
+		Name:           parsed.RepoName,
+		SSH:            parsed.IsSSH,
+		Local:          parsed.IsLocal(),
+		NormalizedPath: filepath.ToSlash(parsed.GetNormalizedFSPath()),
+	}
+}
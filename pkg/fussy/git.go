@@ -0,0 +1,58 @@
+package fussy
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jmsnll/fussy-git/internal/gitutil"
+)
+
+// Status is the state of the working tree and branch of a repository. It is read from the
+// repository without contacting its remote.
+type Status struct {
+	Branch   string // Checked out branch, "" if HEAD is detached
+	Head     string // Commit HEAD points to, "" if the repository has no commits yet
+	Dirty    bool   // Uncommitted changes or untracked files
+	Upstream bool   // Whether the branch has an upstream branch; Ahead and Behind are 0 if not
+	Ahead    int    // Commits on the branch that aren't on its upstream branch
+	Behind   int    // Commits on the upstream branch (as of the last fetch) that aren't on the branch
+}
+
+// RepositoryStatus returns the status of the Git repository at path, e.g. Repository.Path.
+func RepositoryStatus(path string) (Status, error) {
+	if !gitutil.IsGitRepository(path) {
+		return Status{}, fmt.Errorf("'%s' is not a Git repository", path)
+	}
+	var status Status
+	var err error
+	if status.Branch, err = gitutil.CurrentBranch(path); err != nil {
+		return Status{}, err
+	}
+	if status.Head, err = gitutil.HeadCommit(path); err != nil {
+		return Status{}, err
+	}
+	if status.Dirty, err = gitutil.IsDirty(path); err != nil {
+		return Status{}, err
+	}
+	if status.Head == "" {
+		return status, nil
+	}
+	status.Ahead, status.Behind, err = gitutil.AheadBehind(path)
+	switch {
+	case errors.Is(err, gitutil.ErrNoUpstream):
+	case err != nil:
+		return Status{}, err
+	default:
+		status.Upstream = true
+	}
+	return status, nil
+}
+
+// ParseURL parses a repository URL as written, without the user's normalizations. Use
+// Inventory.ParseURL to parse URLs the way the fussy-git command does.
+func ParseURL(rawURL string) (*URL, error) {
+	parsed, err := gitutil.ParseGitURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return newURL(parsed), nil
+}